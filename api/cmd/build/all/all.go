@@ -1,17 +1,69 @@
 package all
 
 import (
+	"context"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/domain/aclapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/activityapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/announcementapp"
 	"github.com/jcpaschoal/spi-exata/app/domain/authapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/brandingapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/checkapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/dashboardapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/datasourceapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/featureapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/graphqlapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/ldapapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/notificationapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/reportapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/samlapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/tenantapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/termsapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/usageapp"
 	"github.com/jcpaschoal/spi-exata/app/domain/userapp"
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/jobs"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mux"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/aclcache"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/acldb"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus/stores/activitydb"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus/stores/announcementdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus/stores/brandingdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboardcache"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboarddb"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus/stores/datasourcedb"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus/stores/featuredb"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus/stores/ldapdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus/stores/notificationdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus/stores/reportdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus/stores/rolechangedb"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus/stores/samldb"
 	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	tenantdb "github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/usercache"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userredis"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cache"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 )
 
@@ -25,25 +77,314 @@ type add struct{}
 
 func (add) Add(app *web.App, cfg mux.Config) {
 
-	userBus := userbus.NewCore(usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), time.Minute*5))
-	tenantBus := tenantbus.NewCore(cfg.Log, tenantdb.NewStore(cfg.Log, cfg.DB))
+	// Leituras fora de transação vão para a réplica quando configurada. ACLs
+	// e usuários ficam no primário: a invalidação do cache chega antes da
+	// réplica e o cache seria repopulado com permissões ou papéis antigos.
+	db := sqldb.NewRouter(cfg.DB, cfg.ReplicaDB)
+
+	// Os eventos são gravados na transação da alteração, sempre no primário.
+	outboxBus := outboxbus.NewCore(cfg.Log, outboxdb.NewStore(cfg.Log, cfg.DB), cfg.OutboxCrypto)
+
+	var userStore interface {
+		userbus.Storer
+		Invalidate(payload string)
+	}
+
+	userCache := usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), time.Minute*5)
+	cachestats.Register("usercache", func() any { return userCache.Stats() })
+
+	userStore = userCache
+	if cfg.Redis != nil {
+		userStore = userredis.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB), cfg.Redis, time.Minute*5)
+	}
+
+	// Os resultados caros (consultas dos widgets, dashboards) ficam no Redis
+	// quando configurado, compartilhados entre as instâncias.
+	var backend cache.Backend = cache.NewMemory(10000)
+	if cfg.Redis != nil {
+		backend = cache.NewRedis(cfg.Redis)
+	}
+
+	// Desabilitar ou remover um usuário revoga as ACLs e os acessos a
+	// dashboards registrados no delegate pelas cores abaixo.
+	delegate := delegate.New(cfg.Log)
+
+	userBus := userbus.NewCore(userStore, outboxBus, delegate, cfg.Hasher)
+	tenantBus := tenantbus.NewCore(cfg.Log, delegate, tenantdb.NewStore(cfg.Log, db))
+	dashboardStore := dashboardcache.NewStore(cfg.Log, dashboarddb.NewStore(cfg.Log, db), backend, time.Minute*5)
+	cachestats.Register("dashboardcache", func() any { return dashboardStore.Stats() })
+	dashboardBus := dashboardbus.NewCore(cfg.Log, dashboardStore)
+	// Os contadores são os mesmos do middleware do mux, que mede todas as
+	// rotas autenticadas; o job abaixo os grava.
+	usageBus := cfg.UsageBus
+	aclStore := aclcache.NewStore(cfg.Log, acldb.NewStore(cfg.Log, cfg.DB), time.Minute*5)
+	cachestats.Register("aclcache", func() any { return aclStore.Stats() })
+	aclBus := aclbus.NewCore(cfg.Log, delegate, aclStore, outboxBus)
+
+	// As trocas de perfil pendentes ficam no primário: a aprovação lê o
+	// pedido que acabou de ser feito.
+	roleChangeBus := rolechangebus.NewCore(cfg.Log, rolechangedb.NewStore(cfg.Log, cfg.DB), tenantBus)
+
+	// O feed é gravado no primário; as consultas do painel vão para a réplica.
+	activityBus := activitybus.NewCore(cfg.Log, activitydb.NewStore(cfg.Log, db))
+
+	// As flags são lidas em quase toda requisição e ficam num snapshot local;
+	// uma mudança feita em outra instância leva até 30s para valer aqui.
+	featureBus := featurebus.NewCore(cfg.Log, featuredb.NewStore(cfg.Log, cfg.DB), time.Second*30)
+
+	// A caixa de entrada fica no primário: o contador de não lidas tem de
+	// refletir a marcação que o usuário acabou de fazer.
+	notificationBus := notificationbus.NewCore(cfg.Log, notificationdb.NewStore(cfg.Log, cfg.DB))
+
+	// Os avisos ficam no primário: o admin confere o aviso logo após publicá-lo.
+	announcementBus := announcementbus.NewCore(cfg.Log, announcementdb.NewStore(cfg.Log, cfg.DB))
+
+	// Permission changes made by any instance are broadcast by the database
+	// so the local acl cache never serves stale grants. The users connected
+	// to this instance are then told to reload their permissions.
+	go sqldb.Listen(context.Background(), cfg.Log, cfg.DB, aclcache.Channel, func(payload string) {
+		aclStore.Invalidate(payload)
+
+		if payload == "*" {
+			notificationBus.PublishAccessChangeAll()
+			return
+		}
+
+		userID, err := uuid.Parse(payload)
+		if err != nil {
+			cfg.Log.Error(context.Background(), "acl changes", "status", "parse", "payload", payload, "ERROR", err)
+			return
+		}
+
+		notificationBus.PublishAccessChange(userID)
+	})
+
+	// The same goes for users: a role change or a disable made elsewhere
+	// evicts the cached user the auth check reads on every request.
+	go sqldb.Listen(context.Background(), cfg.Log, cfg.DB, userdb.Channel, userStore.Invalidate)
+
+	// New notifications, created here, by another instance or by the worker,
+	// are pushed to the streams of their users connected to this instance.
+	go sqldb.Listen(context.Background(), cfg.Log, cfg.DB, notificationdb.Channel, func(payload string) {
+		n, err := notificationdb.ParseNotification(payload)
+		if err != nil {
+			cfg.Log.Error(context.Background(), "notifications", "status", "parse", "ERROR", err)
+			return
+		}
+
+		notificationBus.Publish(n)
+	})
+
+	// Sem chave para cifrar as credenciais as datasources, e os relatórios
+	// gerados a partir delas, ficam desligados. O mesmo vale para os
+	// diretórios LDAP, que guardam a senha do bind de serviço.
+	var datasourceBus *datasourcebus.Core
+	var reportBus *reportbus.Core
+	var ldapBus *ldapbus.Core
+
+	if cfg.Crypto != nil {
+		datasourceBus = datasourcebus.NewCore(cfg.Log, datasourcedb.NewStore(cfg.Log, cfg.DB), cfg.Crypto, backend, cfg.Datasource)
+		cachestats.Register("widgetcache", func() any { return datasourceBus.Stats() })
+
+		reportBus = reportbus.NewCore(cfg.Log, reportdb.NewStore(cfg.Log, cfg.DB), datasourceBus, notificationBus, cfg.Worker, cfg.Reports)
+
+		ldapBus = ldapbus.NewCore(cfg.Log, ldapdb.NewStore(cfg.Log, cfg.DB), cfg.Crypto, userBus, tenantBus)
+	}
+
+	// O SSO por SAML não guarda segredos: só o certificado público do IdP.
+	samlBus := samlbus.NewCore(cfg.Log, samldb.NewStore(cfg.Log, cfg.DB), userBus, tenantBus, dashboardBus)
+
+	brandingBus := brandingbus.NewCore(cfg.Log, brandingdb.NewStore(cfg.Log, cfg.DB), tenantBus)
+
+	jobs.Register(cfg.Worker, jobs.Config{
+		Log:             cfg.Log,
+		UserBus:         userBus,
+		ACLBus:          aclBus,
+		UsageBus:        usageBus,
+		OutboxBus:       outboxBus,
+		ActivityBus:     activityBus,
+		ReportBus:       reportBus,
+		NotificationBus: notificationBus,
+		SAMLBus:         samlBus,
+		OutboxPublisher: cfg.OutboxPublisher,
+		OutboxInterval:  cfg.OutboxInterval,
+	})
 
 	authClient := auth.New(auth.Config{
 		Log:       cfg.Log,
 		UserBus:   userBus,
+		LDAPBus:   ldapBus,
+		TenantBus: tenantBus,
 		KeyLookup: cfg.AuthConfig.KeyLookup,
 		Issuer:    cfg.AuthConfig.Issuer,
 		ActiveKID: cfg.AuthConfig.ActiveKID,
 	})
 
+	checkapp.Routes(app, checkapp.Config{
+		Build:     cfg.Build,
+		Log:       cfg.Log,
+		DB:        cfg.DB,
+		KeyLookup: cfg.AuthConfig.KeyLookup,
+		ActiveKID: cfg.AuthConfig.ActiveKID,
+		Health:    cfg.Health,
+	})
+
 	userapp.Routes(app, userapp.Config{
-		Auth:    authClient,
-		UserBus: userBus,
+		Log:           cfg.Log,
+		DB:            cfg.DB,
+		Auth:          authClient,
+		UserBus:       userBus,
+		ACLBus:        aclBus,
+		ActivityBus:   activityBus,
+		TenantBus:     tenantBus,
+		RoleChangeBus: roleChangeBus,
+		RateLimiter:   cfg.RateLimiter,
 	})
 
 	authapp.Routes(app, authapp.Config{
-		Auth:      authClient,
-		TenantBus: tenantBus,
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		Auth:        authClient,
+		UserBus:     userBus,
+		TenantBus:   tenantBus,
+		ACLBus:      aclBus,
+		RateLimiter: cfg.RateLimiter,
+		DomainField: cfg.LoginDomainField,
+	})
+
+	dashboardapp.Routes(app, dashboardapp.Config{
+		Log:          cfg.Log,
+		DB:           cfg.DB,
+		Auth:         authClient,
+		ACLBus:       aclBus,
+		DashboardBus: dashboardBus,
+		UsageBus:     usageBus,
+		ActivityBus:  activityBus,
+		TenantBus:    tenantBus,
+		RateLimiter:  cfg.RateLimiter,
+	})
+
+	usageapp.Routes(app, usageapp.Config{
+		Auth:        authClient,
+		UsageBus:    usageBus,
+		TenantBus:   tenantBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	graphqlapp.Routes(app, graphqlapp.Config{
+		Log:          cfg.Log,
+		Auth:         authClient,
+		UserBus:      userBus,
+		TenantBus:    tenantBus,
+		DashboardBus: dashboardBus,
+		ACLBus:       aclBus,
+		UsageBus:     usageBus,
+		ActivityBus:  activityBus,
+		RateLimiter:  cfg.RateLimiter,
+	})
+
+	aclapp.Routes(app, aclapp.Config{
+		Log:             cfg.Log,
+		DB:              cfg.DB,
+		Auth:            authClient,
+		ACLBus:          aclBus,
+		UserBus:         userBus,
+		TenantBus:       tenantBus,
+		NotificationBus: notificationBus,
+		RateLimiter:     cfg.RateLimiter,
+	})
+
+	featureapp.Routes(app, featureapp.Config{
+		Auth:        authClient,
+		FeatureBus:  featureBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	announcementapp.Routes(app, announcementapp.Config{
+		Auth:            authClient,
+		AnnouncementBus: announcementBus,
+		RateLimiter:     cfg.RateLimiter,
 	})
 
+	termsapp.Routes(app, termsapp.Config{
+		Auth:        authClient,
+		TermsBus:    cfg.TermsBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	notificationapp.Routes(app, notificationapp.Config{
+		Auth:            authClient,
+		NotificationBus: notificationBus,
+		RateLimiter:     cfg.RateLimiter,
+	})
+
+	activityapp.Routes(app, activityapp.Config{
+		Auth:        authClient,
+		ActivityBus: activityBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	samlapp.Routes(app, samlapp.Config{
+		Auth:        authClient,
+		SAMLBus:     samlBus,
+		UserBus:     userBus,
+		TenantBus:   tenantBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	brandingapp.Routes(app, brandingapp.Config{
+		Auth:        authClient,
+		BrandingBus: brandingBus,
+		TenantBus:   tenantBus,
+		RateLimiter: cfg.RateLimiter,
+	})
+
+	if datasourceBus != nil {
+		datasourceapp.Routes(app, datasourceapp.Config{
+			Auth:          authClient,
+			ACLBus:        aclBus,
+			DatasourceBus: datasourceBus,
+			TenantBus:     tenantBus,
+			RateLimiter:   cfg.RateLimiter,
+		})
+
+		reportapp.Routes(app, reportapp.Config{
+			Auth:          authClient,
+			ACLBus:        aclBus,
+			ReportBus:     reportBus,
+			DashboardBus:  dashboardBus,
+			DatasourceBus: datasourceBus,
+			TenantBus:     tenantBus,
+			RateLimiter:   cfg.RateLimiter,
+		})
+
+		ldapapp.Routes(app, ldapapp.Config{
+			Auth:        authClient,
+			LDAPBus:     ldapBus,
+			TenantBus:   tenantBus,
+			RateLimiter: cfg.RateLimiter,
+		})
+	}
+
+	// Os serviços gRPC atendem os consumidores internos com os mesmos cores e
+	// as mesmas políticas das rotas.
+	if cfg.GRPC != nil {
+		userapp.RegisterGRPC(cfg.GRPC, userapp.Config{
+			Auth:    authClient,
+			UserBus: userBus,
+		})
+
+		tenantapp.RegisterGRPC(cfg.GRPC, tenantapp.Config{
+			Auth:      authClient,
+			TenantBus: tenantBus,
+		})
+
+		dashboardapp.RegisterGRPC(cfg.GRPC, dashboardapp.Config{
+			Auth:         authClient,
+			ACLBus:       aclBus,
+			DashboardBus: dashboardBus,
+			UsageBus:     usageBus,
+			ActivityBus:  activityBus,
+		})
+	}
 }
@@ -2,59 +2,236 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"embed"
+	"errors"
 	"expvar"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jcpaschoal/spi-exata/api/cmd/build/all"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/debug"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mux"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus/stores/auditdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus/stores/crashdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/keybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/keybus/stores/keydb"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus/stores/termsdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus/stores/usagedb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/migrate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker/stores/workerdb"
+	"github.com/jcpaschoal/spi-exata/foundation/alert"
+	"github.com/jcpaschoal/spi-exata/foundation/conf"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/events"
+	"github.com/jcpaschoal/spi-exata/foundation/health"
 	"github.com/jcpaschoal/spi-exata/foundation/keystore"
+	"github.com/jcpaschoal/spi-exata/foundation/lifecycle"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/mail"
 	"github.com/jcpaschoal/spi-exata/foundation/otel"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+	"github.com/jcpaschoal/spi-exata/foundation/secrets"
+	"google.golang.org/grpc"
 )
 
 var build = "develop"
 var routes = "all" // go build -ldflags "-X main.routes=crud"
 
-// Assumindo que 'static' é definido em outro lugar ou via embed,
-// caso contrário precisaria de: var static embed.FS
+// static contém o build do frontend (dashboard SPA), copiado para
+// api/cmd/static antes de compilar para que o binário seja autossuficiente.
+//
+//go:embed static
+var static embed.FS
 
 type Config struct {
-	Version struct {
-		Build string `json:"build"`
-		Desc  string `json:"desc"`
-	} `json:"version"`
+	Version conf.Version
 
 	Web struct {
 		ReadTimeout        time.Duration `envconfig:"WEB_READ_TIMEOUT" default:"5s"`
 		WriteTimeout       time.Duration `envconfig:"WEB_WRITE_TIMEOUT" default:"10s"`
 		IdleTimeout        time.Duration `envconfig:"WEB_IDLE_TIMEOUT" default:"120s"`
 		ShutdownTimeout    time.Duration `envconfig:"WEB_SHUTDOWN_TIMEOUT" default:"20s"`
-		APIHost            string        `envconfig:"WEB_API_HOST" default:"0.0.0.0:3000"`
+		DrainDelay         time.Duration `envconfig:"WEB_DRAIN_DELAY" default:"5s"`
+		APIHost            string        `envconfig:"WEB_API_HOST" default:"0.0.0.0:3000" required:"true"`
 		DebugHost          string        `envconfig:"WEB_DEBUG_HOST" default:"0.0.0.0:3010"`
+		GRPCHost           string        `envconfig:"WEB_GRPC_HOST" default:"0.0.0.0:3030"`
+		Environment        string        `envconfig:"WEB_ENVIRONMENT" default:"development"`
 		CORSAllowedOrigins []string      `envconfig:"WEB_CORS_ALLOWED_ORIGINS" default:"*"`
+		RateLimitRPS       float64       `envconfig:"WEB_RATE_LIMIT_RPS" default:"10"`
+		RateLimitBurst     int           `envconfig:"WEB_RATE_LIMIT_BURST" default:"20"`
+		RateLimitStore     string        `envconfig:"WEB_RATE_LIMIT_STORE" default:"memory"`
+		MaxBodyBytes       int64         `envconfig:"WEB_MAX_BODY_BYTES" default:"1048576"`
+		StrictJSON         bool          `envconfig:"WEB_STRICT_JSON" default:"false"`
+		TrustedProxies     []string      `envconfig:"WEB_TRUSTED_PROXIES"`
+		LoginDomainField   bool          `envconfig:"WEB_LOGIN_DOMAIN_FIELD" default:"false"`
+	}
+	Log struct {
+		Level   string `envconfig:"LOG_LEVEL" default:"INFO"`
+		Modules string `envconfig:"LOG_MODULES"`
+		OTLP    bool   `envconfig:"LOG_OTLP" default:"false"`
 	}
 	DB struct {
-		User         string `envconfig:"DB_USER" default:"postgres"`
-		Password     string `envconfig:"DB_PASSWORD" default:"postgres"`
-		Host         string `envconfig:"DB_HOST" default:"localhost"`
-		Name         string `envconfig:"DB_NAME" default:"spi"`
-		MaxIdleConns int    `envconfig:"DB_MAX_IDLE_CONNS" default:"0"`
-		MaxOpenConns int    `envconfig:"DB_MAX_OPEN_CONNS" default:"0"`
-		DisableTLS   bool   `envconfig:"DB_DISABLE_TLS" default:"true"`
+		User         string        `envconfig:"DB_USER" default:"postgres" required:"true"`
+		Password     string        `envconfig:"DB_PASSWORD" default:"postgres" conf:"mask"`
+		PasswordRef  string        `envconfig:"DB_PASSWORD_SECRET"`
+		Host         string        `envconfig:"DB_HOST" default:"localhost" required:"true"`
+		ReplicaHost  string        `envconfig:"DB_REPLICA_HOST"`
+		Name         string        `envconfig:"DB_NAME" default:"spi" required:"true"`
+		MaxIdleConns int           `envconfig:"DB_MAX_IDLE_CONNS" default:"0"`
+		MaxOpenConns int           `envconfig:"DB_MAX_OPEN_CONNS" default:"0"`
+		DisableTLS   bool          `envconfig:"DB_DISABLE_TLS" default:"true"`
+		Migrate      bool          `envconfig:"DB_MIGRATE" default:"false"`
+		MaxRetries   int           `envconfig:"DB_MAX_RETRY_ATTEMPTS" default:"3"`
+		SlowQuery    time.Duration `envconfig:"DB_SLOW_QUERY_THRESHOLD" default:"500ms"`
+		StmtTimeout  time.Duration `envconfig:"DB_STATEMENT_TIMEOUT" default:"8s"`
+		StmtCache    int           `envconfig:"DB_STATEMENT_CACHE" default:"512"`
 	}
 	Auth struct {
-		KeysFolder string `envconfig:"AUTH_KEYS_FOLDER" default:"foundation/zarf/keys/"`
-		Issuer     string `envconfig:"AUTH_ISSUER" default:"spi-exata"`
-		ActiveKID  string `envconfig:"AUTH_ACTIVE_KID" default:"e02696d9-f1b7-4c0a-b78c-90eb05d5f998"`
+		KeysFolder string `envconfig:"AUTH_KEYS_FOLDER" default:"foundation/zarf/keys/" required:"true"`
+		Issuer     string `envconfig:"AUTH_ISSUER" default:"spi-exata" required:"true"`
+		ActiveKID  string `envconfig:"AUTH_ACTIVE_KID" default:"e02696d9-f1b7-4c0a-b78c-90eb05d5f998" required:"true"`
+
+		// KeySecrets references secrets holding {"key": kid, "pem": private
+		// key} documents. When set, KeysFolder is not read.
+		KeySecrets []string `envconfig:"AUTH_KEY_SECRETS"`
+
+		// KeysDB shares the keys between the instances through the database.
+		// The active key is chosen there and ActiveKID is ignored.
+		KeysDB       bool          `envconfig:"AUTH_KEYS_DB" default:"false"`
+		KeysCacheTTL time.Duration `envconfig:"AUTH_KEYS_CACHE_TTL" default:"1m"`
+
+		// PasswordCost is the bcrypt cost of new hashes. Zero calibrates it at
+		// startup to PasswordTarget per hash. PasswordWorkers bounds the
+		// hashes running at once, zero means one per CPU.
+		PasswordCost    int           `envconfig:"AUTH_PASSWORD_COST" default:"0"`
+		PasswordTarget  time.Duration `envconfig:"AUTH_PASSWORD_TARGET" default:"250ms"`
+		PasswordWorkers int           `envconfig:"AUTH_PASSWORD_WORKERS" default:"0"`
+	}
+	Datasource struct {
+
+		// Keys seal the credentials of the datasources, base64 encoded
+		// AES-256 keys. The first seals, the others are kept to open the
+		// credentials sealed before a rotation. KeysRef references a
+		// secret holding the same comma separated list.
+		Keys    []string `envconfig:"DATASOURCE_KEYS" conf:"mask"`
+		KeysRef string   `envconfig:"DATASOURCE_KEYS_SECRET"`
+
+		// MaxRows and QueryTimeout bound the queries of the widgets; the
+		// timeout stays under the write timeout. CacheTTL is how long a
+		// result is reused, zero disables the cache.
+		MaxRows      int           `envconfig:"DATASOURCE_MAX_ROWS" default:"5000"`
+		QueryTimeout time.Duration `envconfig:"DATASOURCE_QUERY_TIMEOUT" default:"8s"`
+		CacheTTL     time.Duration `envconfig:"DATASOURCE_CACHE_TTL" default:"1m"`
+	}
+	Reports struct {
+
+		// SigningKey signs the download links, a base64 encoded 32 byte
+		// key shared by the instances. When empty a random key is used and
+		// the links only work on the instance that created them.
+		SigningKey string        `envconfig:"REPORTS_SIGNING_KEY" conf:"mask"`
+		Retention  time.Duration `envconfig:"REPORTS_RETENTION" default:"168h"`
+		LinkTTL    time.Duration `envconfig:"REPORTS_LINK_TTL" default:"15m"`
+	}
+	Terms struct {
+
+		// Required forces the users to accept the latest terms of service
+		// before using the API. A version published on another instance
+		// is required here after CacheTTL.
+		Required bool          `envconfig:"TERMS_REQUIRED" default:"false"`
+		CacheTTL time.Duration `envconfig:"TERMS_CACHE_TTL" default:"30s"`
+	}
+	Secrets struct {
+		Provider           string `envconfig:"SECRETS_PROVIDER"`
+		VaultAddr          string `envconfig:"VAULT_ADDR" default:"http://localhost:8200"`
+		VaultToken         string `envconfig:"VAULT_TOKEN" conf:"mask"`
+		VaultNamespace     string `envconfig:"VAULT_NAMESPACE"`
+		VaultMount         string `envconfig:"VAULT_MOUNT" default:"secret"`
+		AWSRegion          string `envconfig:"AWS_REGION"`
+		AWSAccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID"`
+		AWSSecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" conf:"mask"`
+		AWSSessionToken    string `envconfig:"AWS_SESSION_TOKEN" conf:"mask"`
+		AWSEndpoint        string `envconfig:"AWS_SECRETS_ENDPOINT"`
+	}
+	Alert struct {
+		DedupWindow         time.Duration `envconfig:"ALERT_DEDUP_WINDOW" default:"10m"`
+		PerMinute           int           `envconfig:"ALERT_PER_MINUTE" default:"20"`
+		SlackWebhookURL     string        `envconfig:"ALERT_SLACK_WEBHOOK_URL" conf:"mask"`
+		SlackSeverity       string        `envconfig:"ALERT_SLACK_SEVERITY" default:"error"`
+		PagerDutyRoutingKey string        `envconfig:"ALERT_PAGERDUTY_ROUTING_KEY" conf:"mask"`
+		PagerDutySeverity   string        `envconfig:"ALERT_PAGERDUTY_SEVERITY" default:"critical"`
+		WebhookURL          string        `envconfig:"ALERT_WEBHOOK_URL"`
+		WebhookSecret       string        `envconfig:"ALERT_WEBHOOK_SECRET" conf:"mask"`
+		WebhookSeverity     string        `envconfig:"ALERT_WEBHOOK_SEVERITY" default:"warning"`
+		EmailSMTPAddr       string        `envconfig:"ALERT_EMAIL_SMTP_ADDR"`
+		EmailUsername       string        `envconfig:"ALERT_EMAIL_USERNAME"`
+		EmailPassword       string        `envconfig:"ALERT_EMAIL_PASSWORD" conf:"mask"`
+		EmailFrom           string        `envconfig:"ALERT_EMAIL_FROM"`
+		EmailTo             []string      `envconfig:"ALERT_EMAIL_TO"`
+		EmailSeverity       string        `envconfig:"ALERT_EMAIL_SEVERITY" default:"critical"`
+	}
+	Outbox struct {
+		WebhookURL    string        `envconfig:"OUTBOX_WEBHOOK_URL"`
+		WebhookSecret string        `envconfig:"OUTBOX_WEBHOOK_SECRET" conf:"mask"`
+		Interval      time.Duration `envconfig:"OUTBOX_INTERVAL" default:"5s"`
+
+		// Keys seal the secrets of the events, such as the confirmation
+		// tokens, in the same format of the datasource keys. The worker
+		// must have the same keys to open them.
+		Keys    []string `envconfig:"OUTBOX_KEYS" conf:"mask"`
+		KeysRef string   `envconfig:"OUTBOX_KEYS_SECRET"`
+	}
+	Mail struct {
+
+		// The confirmation links of the email changes and sign ups are sent
+		// through this SMTP server. The URLs take {token} and, for the sign
+		// up, the {domain} the user signed up through.
+		SMTPAddr        string `envconfig:"MAIL_SMTP_ADDR"`
+		Username        string `envconfig:"MAIL_USERNAME"`
+		Password        string `envconfig:"MAIL_PASSWORD" conf:"mask"`
+		From            string `envconfig:"MAIL_FROM"`
+		EmailChangeURL  string `envconfig:"MAIL_EMAIL_CHANGE_URL"`
+		RegistrationURL string `envconfig:"MAIL_REGISTRATION_URL" default:"https://{domain}/register/confirm?token={token}"`
+	}
+	Events struct {
+		Broker       string `envconfig:"EVENTS_BROKER"`
+		NATSURL      string `envconfig:"EVENTS_NATS_URL" default:"localhost:4222"`
+		KafkaRESTURL string `envconfig:"EVENTS_KAFKA_REST_URL" default:"http://localhost:8082"`
+		TopicPrefix  string `envconfig:"EVENTS_TOPIC_PREFIX" default:"spi"`
+	}
+	Cache struct {
+		Users string `envconfig:"CACHE_USERS" default:"memory"`
+	}
+	Redis struct {
+		Addr     string `envconfig:"REDIS_ADDR" default:"localhost:6379"`
+		Password string `envconfig:"REDIS_PASSWORD" conf:"mask"`
+		DB       int    `envconfig:"REDIS_DB" default:"0"`
+	}
+	Worker struct {
+		InProcess bool          `envconfig:"WORKER_IN_PROCESS" default:"true"`
+		Poll      time.Duration `envconfig:"WORKER_POLL" default:"5s"`
 	}
 	Tempo struct {
 		Host        string  `envconfig:"TEMPO_HOST" default:"tempo:4317"`
@@ -65,27 +242,39 @@ type Config struct {
 }
 
 func main() {
-	var log *logger.Logger
+
+	// Os alertas são configurados em run, depois da leitura da configuração;
+	// até lá os erros logados não vão para nenhum canal.
+	alerts := alert.New("SPI-EXATA")
 
 	events := logger.Events{
-		Error: func(ctx context.Context, r logger.Record) {
-			log.Info(ctx, "******* SEND ALERT *******")
-		},
+		Error: alerts.LogEvent(alert.Error),
 	}
 
-	log = logger.NewWithEvents(os.Stdout, logger.LevelInfo, "SPI-EXATA", otel.GetTraceID, events)
+	log := logger.NewWithEvents(os.Stdout, logger.LevelInfo, "SPI-EXATA", otel.GetTraceID, events)
+	log.AddContext(otel.LogAttrs)
+	log.AddContext(webcontext.LogAttrs)
 
 	// -------------------------------------------------------------------------
 
 	ctx := context.Background()
 
-	if err := run(ctx, log); err != nil {
+	err := run(ctx, log, alerts)
+	if err != nil {
 		log.Error(ctx, "startup", "err", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	alerts.Shutdown(ctx)
+
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, log *logger.Logger) error {
+func run(ctx context.Context, log *logger.Logger, alerts *alert.Alerter) error {
 
 	// -------------------------------------------------------------------------
 	// GOMAXPROCS
@@ -99,15 +288,39 @@ func run(ctx context.Context, log *logger.Logger) error {
 	cfg.Version.Build = build
 	cfg.Version.Desc = "SPI-EXATA"
 
-	if err := envconfig.Process("", &cfg); err != nil {
-		return fmt.Errorf("processing config: %w", err)
+	help, err := conf.Parse(os.Args[1:], &cfg)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelpWanted) || errors.Is(err, conf.ErrVersionWanted) {
+			fmt.Println(help)
+			return nil
+		}
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+	log.SetLevel(level)
+
+	if err := log.ConfigureModules(cfg.Log.Modules); err != nil {
+		return fmt.Errorf("parsing log modules: %w", err)
 	}
 
 	// -------------------------------------------------------------------------
 	// App Info & Config Logging
 
 	log.Info(ctx, "startup", "version", cfg.Version)
-	log.Info(ctx, "startup", "config", sanitizeConfig(cfg))
+	out, err := conf.String(&cfg)
+	if err != nil {
+		return fmt.Errorf("generating config for output: %w", err)
+	}
+	log.Info(ctx, "startup", "config", out)
+
+	if err := configureAlerts(alerts, cfg); err != nil {
+		return fmt.Errorf("configuring alerts: %w", err)
+	}
+	log.Info(ctx, "startup", "alerts", alerts.Routes())
 
 	// -------------------------------------------------------------------------
 	// App Starting
@@ -119,37 +332,193 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	expvar.NewString("build").Set(cfg.Version.Build)
 
+	// -------------------------------------------------------------------------
+	// Secrets Support
+
+	provider, err := newSecrets(cfg)
+	if err != nil {
+		return fmt.Errorf("initializing secrets: %w", err)
+	}
+
+	if cfg.DB.PasswordRef != "" {
+		if provider == nil {
+			return errors.New("DB_PASSWORD_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading database password", "provider", cfg.Secrets.Provider)
+
+		if cfg.DB.Password, err = provider.Secret(ctx, cfg.DB.PasswordRef); err != nil {
+			return fmt.Errorf("loading database password: %w", err)
+		}
+	}
+
+	if cfg.Datasource.KeysRef != "" {
+		if provider == nil {
+			return errors.New("DATASOURCE_KEYS_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading datasource keys", "provider", cfg.Secrets.Provider)
+
+		keys, err := provider.Secret(ctx, cfg.Datasource.KeysRef)
+		if err != nil {
+			return fmt.Errorf("loading datasource keys: %w", err)
+		}
+
+		cfg.Datasource.Keys = strings.Split(keys, ",")
+	}
+
+	if cfg.Outbox.KeysRef != "" {
+		if provider == nil {
+			return errors.New("OUTBOX_KEYS_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading outbox keys", "provider", cfg.Secrets.Provider)
+
+		keys, err := provider.Secret(ctx, cfg.Outbox.KeysRef)
+		if err != nil {
+			return fmt.Errorf("loading outbox keys: %w", err)
+		}
+
+		cfg.Outbox.Keys = strings.Split(keys, ",")
+	}
+
 	// -------------------------------------------------------------------------
 	// Database Support
 
 	log.Info(ctx, "startup", "status", "initializing database support", "hostport", cfg.DB.Host)
 
-	db, err := sqldb.Open(sqldb.Config{
+	sqldb.SetRetryPolicy(sqldb.RetryPolicy{
+		MaxAttempts: cfg.DB.MaxRetries,
+	})
+	sqldb.SetSlowQueryThreshold(cfg.DB.SlowQuery)
+
+	dbCfg := sqldb.Config{
 		User:         cfg.DB.User,
 		Password:     cfg.DB.Password,
 		Host:         cfg.DB.Host,
 		Name:         cfg.DB.Name,
+		ReplicaHost:  cfg.DB.ReplicaHost,
 		MaxIdleConns: cfg.DB.MaxIdleConns,
 		MaxOpenConns: cfg.DB.MaxOpenConns,
 		DisableTLS:   cfg.DB.DisableTLS,
-	})
+
+		// Abaixo do WEB_WRITE_TIMEOUT para a API ainda conseguir responder.
+		StatementTimeout: cfg.DB.StmtTimeout,
+		StatementCache:   cfg.DB.StmtCache,
+	}
+
+	db, err := sqldb.Open(dbCfg)
 	if err != nil {
 		return fmt.Errorf("connecting to db: %w", err)
 	}
 
 	defer db.Close()
 
+	sqldb.PublishStats("db", db)
+
+	replica, err := sqldb.OpenReplica(dbCfg)
+	if err != nil {
+		return fmt.Errorf("connecting to replica db: %w", err)
+	}
+
+	if replica != nil {
+		log.Info(ctx, "startup", "status", "read replica enabled", "hostport", cfg.DB.ReplicaHost)
+		defer replica.Close()
+
+		sqldb.PublishStats("db_replica", replica)
+	}
+
+	if cfg.DB.Migrate {
+		applied, err := migrate.Migrate(ctx, db)
+		if err != nil {
+			return fmt.Errorf("migrating db: %w", err)
+		}
+
+		for _, m := range applied {
+			log.Info(ctx, "startup", "status", "migration applied", "version", m.Version, "name", m.Name)
+		}
+	}
+
 	// -------------------------------------------------------------------------
 	// Auth Support
 
 	log.Info(ctx, "startup", "status", "initializing authentication support")
 
-	ks := keystore.New()
+	var keyLookup auth.KeyLookup
+
+	switch {
+	case cfg.Auth.KeysDB:
+		dbKeys := keybus.NewKeyStore(log, keybus.NewCore(log, keydb.NewStore(log, db)), cfg.Auth.KeysCacheTTL)
+		if err := dbKeys.Load(ctx); err != nil {
+			return fmt.Errorf("loading keys from db: %w", err)
+		}
+
+		keyLookup = dbKeys
+
+	case len(cfg.Auth.KeySecrets) > 0:
+		if provider == nil {
+			return errors.New("AUTH_KEY_SECRETS requires a SECRETS_PROVIDER")
+		}
+
+		ks := keystore.New()
+
+		for _, ref := range cfg.Auth.KeySecrets {
+			document, err := provider.Secret(ctx, ref)
+			if err != nil {
+				return fmt.Errorf("loading key %s: %w", ref, err)
+			}
+
+			if _, err := ks.LoadByJSON(document); err != nil {
+				return fmt.Errorf("loading key %s: %w", ref, err)
+			}
+		}
+
+		keyLookup = ks
+
+	default:
+		ks := keystore.New()
+
+		if _, err := ks.LoadByFileSystem(os.DirFS(cfg.Auth.KeysFolder)); err != nil {
+			return fmt.Errorf("loading keys: %w", err)
+		}
+
+		keyLookup = ks
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Debug Service
 
-	if _, err := ks.LoadByFileSystem(os.DirFS("foundation/zarf/keys")); err != nil {
-		return fmt.Errorf("loading keys: %w", err)
+	crashBus := crashbus.NewCore(log, crashdb.NewStore(log, db))
+
+	debugMux := debug.Mux(log)
+	debugMux.HandleFunc("GET /debug/panics", debug.Panics(crashBus))
+
+	debugSrv := http.Server{
+		Addr:        cfg.Web.DebugHost,
+		Handler:     debugMux,
+		ReadTimeout: cfg.Web.ReadTimeout,
+		IdleTimeout: cfg.Web.IdleTimeout,
+		ErrorLog:    logger.NewStdLogger(log, logger.LevelError),
 	}
 
+	go func() {
+		log.Info(ctx, "startup", "status", "debug v1 router started", "host", debugSrv.Addr)
+
+		if err := debugSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(ctx, "shutdown", "status", "debug v1 router closed", "host", debugSrv.Addr, "msg", err)
+		}
+	}()
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := debugSrv.Shutdown(ctx); err != nil {
+			debugSrv.Close()
+			log.Error(ctx, "shutdown", "status", "could not stop debug server gracefully", "msg", err)
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Start Tracing Support
 
@@ -173,26 +542,280 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	tracer := traceProvider.Tracer(cfg.Tempo.ServiceName)
 
+	// Os logs vão para o mesmo coletor dos traces, correlacionados pelo
+	// trace e span de cada registro.
+	if cfg.Log.OTLP {
+		log.Info(ctx, "startup", "status", "initializing OTLP log export", "host", cfg.Tempo.Host)
+
+		logExporter, err := otel.NewLogExporter(otel.LogConfig{
+			ServiceName: cfg.Tempo.ServiceName,
+			Host:        cfg.Tempo.Host,
+		})
+		if err != nil {
+			return fmt.Errorf("starting log export: %w", err)
+		}
+
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			logExporter.Shutdown(ctx)
+		}()
+
+		log.Export(logExporter)
+	}
+
 	log.Info(ctx, "startup", "status", "initializing V1 API support")
 
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	// Only the proxies listed here can report the client IP and host through
+	// the forwarding headers.
+	trustedProxies, err := mid.ParseTrustedProxies(cfg.Web.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("parsing trusted proxies: %w", err)
+	}
+
+	// CORS and security headers differ between the environments, e.g. HSTS
+	// is only sent outside development.
+	security, err := mux.SecurityProfile(cfg.Web.Environment, cfg.Web.CORSAllowedOrigins)
+	if err != nil {
+		return fmt.Errorf("security profile: %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// Redis Support
+
+	// The client is shared by the user cache and the rate limiter, and is
+	// only opened when one of them is kept in Redis.
+	var rdb *redis.Client
+	if cfg.Cache.Users == "redis" || cfg.Web.RateLimitStore == "redis" {
+		log.Info(ctx, "startup", "status", "initializing redis support", "hostport", cfg.Redis.Addr)
+
+		rdb = redis.New(redis.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer rdb.Close()
+
+		if err := rdb.Ping(ctx); err != nil {
+			return fmt.Errorf("connecting to redis: %w", err)
+		}
+	}
+
+	var limiter ratelimit.Limiter
+	var memLimiter *ratelimit.Memory
+
+	switch cfg.Web.RateLimitStore {
+	case "memory":
+		// In-memory token buckets: the limit is enforced per instance.
+		memLimiter = ratelimit.NewMemory(cfg.Web.RateLimitRPS, cfg.Web.RateLimitBurst)
+		limiter = memLimiter
+	case "redis":
+		// Buckets in Redis: the limit holds across every instance.
+		limiter = ratelimit.NewRedis(rdb, "ratelimit:", cfg.Web.RateLimitRPS, cfg.Web.RateLimitBurst)
+	default:
+		return fmt.Errorf("unknown rate limit store %q: expected memory or redis", cfg.Web.RateLimitStore)
+	}
+
+	hasher, err := passhash.New(passhash.Config{
+		Cost:    cfg.Auth.PasswordCost,
+		Target:  cfg.Auth.PasswordTarget,
+		Workers: cfg.Auth.PasswordWorkers,
+	})
+	if err != nil {
+		return fmt.Errorf("password hasher: %w", err)
+	}
+
+	log.Info(ctx, "startup", "status", "password hasher ready", "cost", hasher.Cost())
+
+	box, err := newBox(cfg.Datasource.Keys)
+	if err != nil {
+		return fmt.Errorf("datasource keys: %w", err)
+	}
+
+	if box == nil {
+		log.Info(ctx, "startup", "status", "datasources disabled: DATASOURCE_KEYS not configured")
+	}
+
+	outboxBox, err := newBox(cfg.Outbox.Keys)
+	if err != nil {
+		return fmt.Errorf("outbox keys: %w", err)
+	}
+
+	signingKey, err := newSigningKey(cfg.Reports.SigningKey)
+	if err != nil {
+		return fmt.Errorf("reports signing key: %w", err)
+	}
+
+	if box != nil && cfg.Reports.SigningKey == "" {
+		log.Info(ctx, "startup", "status", "reports signing key not configured: download links are valid on this instance only")
+	}
+
 	cfgMux := mux.Config{
-		Build:  cfg.Version.Build,
-		Log:    log,
-		DB:     db,
-		Tracer: tracer,
+		Build:     cfg.Version.Build,
+		Log:       log,
+		DB:        db,
+		ReplicaDB: replica,
+		Tracer:    tracer,
 		AuthConfig: mux.AuthConfig{
-			KeyLookup: ks,
+			KeyLookup: keyLookup,
 			Issuer:    cfg.Auth.Issuer,
 			ActiveKID: cfg.Auth.ActiveKID,
 		},
+		RateLimiter: limiter,
+		Hasher:      hasher,
+		AuditBus:    auditbus.NewCore(log, auditdb.NewStore(log, db)),
+		CrashBus:    crashBus,
+
+		TrustedProxies:   trustedProxies,
+		LoginDomainField: cfg.Web.LoginDomainField,
+
+		OutboxInterval: cfg.Outbox.Interval,
+		OutboxCrypto:   outboxBox,
+
+		Crypto: box,
+		Datasource: datasourcebus.Config{
+			MaxRows:      cfg.Datasource.MaxRows,
+			QueryTimeout: cfg.Datasource.QueryTimeout,
+			CacheTTL:     cfg.Datasource.CacheTTL,
+		},
+		Reports: reportbus.Config{
+			Retention:  cfg.Reports.Retention,
+			LinkTTL:    cfg.Reports.LinkTTL,
+			SigningKey: signingKey,
+		},
+
+		TermsBus:     termsbus.NewCore(log, termsdb.NewStore(log, db), cfg.Terms.CacheTTL),
+		RequireTerms: cfg.Terms.Required,
+
+		UsageBus: usagebus.NewCore(log, usagedb.NewStore(log, sqldb.NewRouter(db, replica))),
+
+		Health: health.New(),
+	}
+
+	// The database and the signing key are checked by the readiness route.
+	// The dependencies below only degrade the service when unavailable.
+	if replica != nil {
+		cfgMux.Health.Register(health.Check{
+			Name:     "database-replica",
+			Optional: true,
+			Func: func(ctx context.Context) error {
+				return sqldb.StatusCheck(ctx, replica)
+			},
+		})
+	}
+
+	if cfg.Tempo.Enabled && cfg.Tempo.Host != "" {
+		cfgMux.Health.Register(health.Check{
+			Name:     "tracing-collector",
+			Optional: true,
+			Func:     health.Dial(cfg.Tempo.Host),
+		})
+	}
+
+	var publishers outboxbus.Publishers
+
+	if cfg.Outbox.WebhookURL != "" {
+		publishers = append(publishers, outboxbus.NewWebhook(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookSecret))
+	}
+
+	if cfg.Mail.SMTPAddr != "" {
+		// O token do link vai cifrado no outbox; sem as chaves não há como
+		// abri-lo para enviar.
+		if outboxBox == nil {
+			return errors.New("MAIL_SMTP_ADDR requires OUTBOX_KEYS to seal the confirmation tokens")
+		}
+
+		if err := checkMailURL("MAIL_EMAIL_CHANGE_URL", cfg.Mail.EmailChangeURL); err != nil {
+			return err
+		}
+
+		if err := checkMailURL("MAIL_REGISTRATION_URL", cfg.Mail.RegistrationURL); err != nil {
+			return err
+		}
+
+		sender := mail.NewSMTP(mail.Config{
+			Addr:     cfg.Mail.SMTPAddr,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		})
+
+		publishers = append(publishers, outboxbus.NewMail(sender, outboxbus.MailConfig{
+			EmailChangeURL:  cfg.Mail.EmailChangeURL,
+			RegistrationURL: cfg.Mail.RegistrationURL,
+		}))
+	}
+
+	switch cfg.Events.Broker {
+	case "":
+	case "nats":
+		broker := events.NewNATS(cfg.Events.NATSURL, cfg.Version.Desc)
+		defer broker.Close()
+
+		publishers = append(publishers, outboxbus.NewBroker(broker, cfg.Events.TopicPrefix))
+	case "kafka":
+		broker := events.NewKafka(cfg.Events.KafkaRESTURL)
+		defer broker.Close()
+
+		publishers = append(publishers, outboxbus.NewBroker(broker, cfg.Events.TopicPrefix))
+	default:
+		return fmt.Errorf("unknown events broker %q: expected nats or kafka", cfg.Events.Broker)
+	}
+
+	if len(publishers) > 0 {
+		log.Info(ctx, "startup", "status", "outbox dispatcher enabled", "publishers", len(publishers), "broker", cfg.Events.Broker)
+		cfgMux.OutboxPublisher = publishers
+	}
+
+	// -------------------------------------------------------------------------
+	// Cache Support
+
+	switch cfg.Cache.Users {
+	case "memory":
+	case "redis":
+		log.Info(ctx, "startup", "status", "initializing redis user cache")
+
+		cfgMux.Redis = rdb
+	default:
+		return fmt.Errorf("unknown user cache %q: expected memory or redis", cfg.Cache.Users)
+	}
+
+	if rdb != nil {
+		cfgMux.Health.Register(health.Check{
+			Name:     "redis",
+			Optional: true,
+			Func:     rdb.Ping,
+		})
+	}
+
+	// -------------------------------------------------------------------------
+	// Background Jobs
+
+	// Com o worker rodando como binário separado (api/cmd/worker) a API só
+	// executa os jobs locais, como o flush dos contadores de uso.
+	wrk := worker.New(log, workerdb.NewStore(log, db), worker.Config{
+		Poll:      cfg.Worker.Poll,
+		LocalOnly: !cfg.Worker.InProcess,
+	})
+	cfgMux.Worker = wrk
+
+	// -------------------------------------------------------------------------
+	// gRPC Support
+
+	// Os serviços gRPC são registrados junto com as rotas. Sem host a API
+	// atende apenas REST.
+	if cfg.Web.GRPCHost != "" {
+		cfgMux.GRPC = mux.GRPCServer(cfgMux)
 	}
 
 	webAPI := mux.WebAPI(cfgMux,
 		buildRoutes(), // Corrigido de build.Routes()
-		mux.WithCORS(cfg.Web.CORSAllowedOrigins),
+		mux.WithSecurity(security),
+		mux.WithDecodeLimits(cfg.Web.MaxBodyBytes, cfg.Web.StrictJSON),
+		mux.WithFileServer(static, "static", "/"),
 	)
 
 	api := http.Server{
@@ -204,36 +827,273 @@ func run(ctx context.Context, log *logger.Logger) error {
 		ErrorLog:     logger.NewStdLogger(log, logger.LevelError),
 	}
 
-	serverErrors := make(chan error, 1)
+	// -------------------------------------------------------------------------
+	// Start Services
 
-	go func() {
-		log.Info(ctx, "startup", "status", "api router started", "host", api.Addr)
-		serverErrors <- api.ListenAndServe()
-	}()
+	// Os serviços param na ordem inversa: primeiro os servidores deixam de
+	// aceitar requisições, depois o worker roda os jobs finais com tudo o
+	// que elas produziram.
+	services := lifecycle.New(log)
+
+	// Os buckets no Redis expiram sozinhos, só os da memória precisam de
+	// limpeza.
+	if memLimiter != nil {
+		services.Add(lifecycle.Service{
+			Name: "ratelimit",
+			Run: func(ctx context.Context) error {
+				memLimiter.Run(ctx, time.Minute)
+				return nil
+			},
+		})
+	}
+
+	// The routes registered the jobs, so the worker starts after them.
+	services.Add(lifecycle.Service{
+		Name: "worker",
+		Run:  wrk.Run,
+	})
+
+	services.Add(lifecycle.Service{
+		Name: "api",
+		Run: func(ctx context.Context) error {
+			log.Info(ctx, "startup", "status", "api router started", "host", api.Addr)
+
+			if err := api.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			if err := api.Shutdown(ctx); err != nil {
+				api.Close()
+				return err
+			}
+			return nil
+		},
+	})
+
+	if cfgMux.GRPC != nil {
+		lis, err := net.Listen("tcp", cfg.Web.GRPCHost)
+		if err != nil {
+			return fmt.Errorf("listening grpc: %w", err)
+		}
+
+		services.Add(lifecycle.Service{
+			Name: "grpc",
+			Run: func(ctx context.Context) error {
+				log.Info(ctx, "startup", "status", "grpc server started", "host", lis.Addr().String())
+				return cfgMux.GRPC.Serve(lis)
+			},
+			Stop: func(ctx context.Context) error {
+				return stopGRPC(ctx, cfgMux.GRPC)
+			},
+		})
+	}
+
+	services.Start(ctx)
 
 	// -------------------------------------------------------------------------
 	// Shutdown
 
 	select {
-	case err := <-serverErrors:
-		return fmt.Errorf("server error: %w", err)
+	case err := <-services.Errors():
+		log.Error(ctx, "shutdown", "status", "service failed", "ERROR", err)
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		return errors.Join(fmt.Errorf("service error: %w", err), services.Shutdown(ctx))
 
 	case sig := <-shutdown:
 		log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
 		defer log.Info(ctx, "shutdown", "status", "shutdown complete", "signal", sig)
 
+		// A readiness passa a falhar e o balanceador tem DrainDelay para
+		// parar de enviar requisições antes de o servidor recusar conexões.
+		cfgMux.Health.Drain()
+		log.Info(ctx, "shutdown", "status", "draining", "delay", cfg.Web.DrainDelay)
+		time.Sleep(cfg.Web.DrainDelay)
+
 		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
 		defer cancel()
 
-		if err := api.Shutdown(ctx); err != nil {
-			api.Close()
-			return fmt.Errorf("could not stop server gracefully: %w", err)
+		if err := services.Shutdown(ctx); err != nil {
+			return fmt.Errorf("could not stop services gracefully: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// newSecrets constructs the configured secrets provider. It returns nil when
+// no provider is configured and the secrets come from the environment.
+func newSecrets(cfg Config) (secrets.Provider, error) {
+	switch cfg.Secrets.Provider {
+	case "":
+		return nil, nil
+	case "vault":
+		return secrets.NewVault(secrets.VaultConfig{
+			Addr:      cfg.Secrets.VaultAddr,
+			Token:     cfg.Secrets.VaultToken,
+			Namespace: cfg.Secrets.VaultNamespace,
+			Mount:     cfg.Secrets.VaultMount,
+		}), nil
+	case "aws":
+		return secrets.NewAWS(secrets.AWSConfig{
+			Region:          cfg.Secrets.AWSRegion,
+			AccessKeyID:     cfg.Secrets.AWSAccessKeyID,
+			SecretAccessKey: cfg.Secrets.AWSSecretAccessKey,
+			SessionToken:    cfg.Secrets.AWSSessionToken,
+			Endpoint:        cfg.Secrets.AWSEndpoint,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q: expected vault or aws", cfg.Secrets.Provider)
+	}
+}
+
+// newBox constructs a box from the base64 encoded keys, the first sealing
+// and the others only opening. It returns nil when no key is configured.
+func newBox(encoded []string) (*crypto.Box, error) {
+	var keys [][]byte
+
+	for i, s := range encoded {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		key, err := crypto.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return crypto.NewBox(keys...)
+}
+
+// checkMailURL validates a link template of the confirmation emails: an
+// absolute http(s) URL carrying the {token} placeholder.
+func checkMailURL(env string, value string) error {
+	if value == "" {
+		return fmt.Errorf("MAIL_SMTP_ADDR requires %s", env)
+	}
+
+	if !strings.Contains(value, "{token}") {
+		return fmt.Errorf("%s must contain {token}", env)
+	}
+
+	// As chaves não são válidas numa URL; o {domain} vira um host qualquer
+	// só para a validação.
+	u, err := url.Parse(strings.NewReplacer("{token}", "token", "{domain}", "example.com").Replace(value))
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http or https URL", env)
+	}
+
+	return nil
+}
+
+// newSigningKey decodes the key signing the report links or generates a
+// random one when it is not configured.
+func newSigningKey(encoded string) ([]byte, error) {
+	if encoded != "" {
+		return crypto.ParseKey(encoded)
+	}
+
+	key := make([]byte, crypto.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// configureAlerts adds a route for each alert channel configured, sending the
+// alerts at or above the severity set for the channel.
+func configureAlerts(alerts *alert.Alerter, cfg Config) error {
+	alerts.Configure(alert.Config{
+		Window:    cfg.Alert.DedupWindow,
+		PerMinute: cfg.Alert.PerMinute,
+	})
+
+	add := func(sink alert.Sink, severity string) error {
+		sev, err := alert.ParseSeverity(severity)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+
+		alerts.AddRoute(alert.Route{Sink: sink, MinSeverity: sev})
+
+		return nil
+	}
+
+	if cfg.Alert.SlackWebhookURL != "" {
+		if err := add(alert.NewSlack(cfg.Alert.SlackWebhookURL), cfg.Alert.SlackSeverity); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Alert.PagerDutyRoutingKey != "" {
+		if err := add(alert.NewPagerDuty(cfg.Alert.PagerDutyRoutingKey), cfg.Alert.PagerDutySeverity); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Alert.WebhookURL != "" {
+		if err := add(alert.NewWebhook(cfg.Alert.WebhookURL, cfg.Alert.WebhookSecret), cfg.Alert.WebhookSeverity); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Alert.EmailSMTPAddr != "" {
+		if cfg.Alert.EmailFrom == "" || len(cfg.Alert.EmailTo) == 0 {
+			return errors.New("email: ALERT_EMAIL_FROM and ALERT_EMAIL_TO are required")
+		}
+
+		email := alert.NewEmail(alert.EmailConfig{
+			Addr:     cfg.Alert.EmailSMTPAddr,
+			Username: cfg.Alert.EmailUsername,
+			Password: cfg.Alert.EmailPassword,
+			From:     cfg.Alert.EmailFrom,
+			To:       cfg.Alert.EmailTo,
+		})
+
+		if err := add(email, cfg.Alert.EmailSeverity); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// stopGRPC waits for the calls in flight until the context is done, then
+// closes the remaining connections.
+func stopGRPC(ctx context.Context, srv *grpc.Server) error {
+	done := make(chan struct{})
+
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		return ctx.Err()
+	}
+}
+
 func buildRoutes() mux.RouteAdder {
 
 	// The idea here is that we can build different versions of the binary
@@ -255,13 +1115,3 @@ func buildRoutes() mux.RouteAdder {
 
 	return all.Routes()
 }
-
-func sanitizeConfig(cfg Config) string {
-	cfg.DB.Password = "[MASKED]"
-
-	data, err := json.Marshal(cfg)
-	if err != nil {
-		return fmt.Sprintf("%+v", cfg)
-	}
-	return string(data)
-}
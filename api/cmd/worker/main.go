@@ -0,0 +1,534 @@
+// Worker runs the global background jobs and the on-demand tasks outside the
+// API process. Start the API with WORKER_IN_PROCESS=false when using it.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/debug"
+	"github.com/jcpaschoal/spi-exata/app/sdk/jobs"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/acldb"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus/stores/activitydb"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboarddb"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus/stores/datasourcedb"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus/stores/notificationdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus/stores/reportdb"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus/stores/samldb"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	tenantdb "github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cache"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker/stores/workerdb"
+	"github.com/jcpaschoal/spi-exata/foundation/conf"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/events"
+	"github.com/jcpaschoal/spi-exata/foundation/lifecycle"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/mail"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+	"github.com/jcpaschoal/spi-exata/foundation/secrets"
+)
+
+var build = "develop"
+
+type Config struct {
+	Version conf.Version
+
+	Web struct {
+		DebugHost       string        `envconfig:"WEB_DEBUG_HOST" default:"0.0.0.0:3020"`
+		ShutdownTimeout time.Duration `envconfig:"WEB_SHUTDOWN_TIMEOUT" default:"20s"`
+	}
+	Log struct {
+		Level   string `envconfig:"LOG_LEVEL" default:"INFO"`
+		Modules string `envconfig:"LOG_MODULES"`
+	}
+	DB struct {
+		User         string        `envconfig:"DB_USER" default:"postgres" required:"true"`
+		Password     string        `envconfig:"DB_PASSWORD" default:"postgres" conf:"mask"`
+		PasswordRef  string        `envconfig:"DB_PASSWORD_SECRET"`
+		Host         string        `envconfig:"DB_HOST" default:"localhost" required:"true"`
+		Name         string        `envconfig:"DB_NAME" default:"spi" required:"true"`
+		MaxIdleConns int           `envconfig:"DB_MAX_IDLE_CONNS" default:"0"`
+		MaxOpenConns int           `envconfig:"DB_MAX_OPEN_CONNS" default:"0"`
+		DisableTLS   bool          `envconfig:"DB_DISABLE_TLS" default:"true"`
+		MaxRetries   int           `envconfig:"DB_MAX_RETRY_ATTEMPTS" default:"3"`
+		SlowQuery    time.Duration `envconfig:"DB_SLOW_QUERY_THRESHOLD" default:"500ms"`
+	}
+	Secrets struct {
+		Provider           string `envconfig:"SECRETS_PROVIDER"`
+		VaultAddr          string `envconfig:"VAULT_ADDR" default:"http://localhost:8200"`
+		VaultToken         string `envconfig:"VAULT_TOKEN" conf:"mask"`
+		VaultNamespace     string `envconfig:"VAULT_NAMESPACE"`
+		VaultMount         string `envconfig:"VAULT_MOUNT" default:"secret"`
+		AWSRegion          string `envconfig:"AWS_REGION"`
+		AWSAccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID"`
+		AWSSecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" conf:"mask"`
+		AWSSessionToken    string `envconfig:"AWS_SESSION_TOKEN" conf:"mask"`
+		AWSEndpoint        string `envconfig:"AWS_SECRETS_ENDPOINT"`
+	}
+	Outbox struct {
+		WebhookURL    string        `envconfig:"OUTBOX_WEBHOOK_URL"`
+		WebhookSecret string        `envconfig:"OUTBOX_WEBHOOK_SECRET" conf:"mask"`
+		Interval      time.Duration `envconfig:"OUTBOX_INTERVAL" default:"5s"`
+
+		// Keys open the secrets of the events to deliver them; the same
+		// keys of the API.
+		Keys    []string `envconfig:"OUTBOX_KEYS" conf:"mask"`
+		KeysRef string   `envconfig:"OUTBOX_KEYS_SECRET"`
+	}
+	Mail struct {
+
+		// The confirmation links of the email changes and sign ups are sent
+		// through this SMTP server. The URLs take {token} and, for the sign
+		// up, the {domain} the user signed up through.
+		SMTPAddr        string `envconfig:"MAIL_SMTP_ADDR"`
+		Username        string `envconfig:"MAIL_USERNAME"`
+		Password        string `envconfig:"MAIL_PASSWORD" conf:"mask"`
+		From            string `envconfig:"MAIL_FROM"`
+		EmailChangeURL  string `envconfig:"MAIL_EMAIL_CHANGE_URL"`
+		RegistrationURL string `envconfig:"MAIL_REGISTRATION_URL" default:"https://{domain}/register/confirm?token={token}"`
+	}
+	Events struct {
+		Broker       string `envconfig:"EVENTS_BROKER"`
+		NATSURL      string `envconfig:"EVENTS_NATS_URL" default:"localhost:4222"`
+		KafkaRESTURL string `envconfig:"EVENTS_KAFKA_REST_URL" default:"http://localhost:8082"`
+		TopicPrefix  string `envconfig:"EVENTS_TOPIC_PREFIX" default:"spi"`
+	}
+	Worker struct {
+		Poll time.Duration `envconfig:"WORKER_POLL" default:"5s"`
+	}
+	Datasource struct {
+
+		// Keys open the credentials of the datasources to render the
+		// reports; the same keys of the API. Without them the reports are
+		// not rendered here.
+		Keys         []string      `envconfig:"DATASOURCE_KEYS" conf:"mask"`
+		KeysRef      string        `envconfig:"DATASOURCE_KEYS_SECRET"`
+		MaxRows      int           `envconfig:"DATASOURCE_MAX_ROWS" default:"5000"`
+		QueryTimeout time.Duration `envconfig:"DATASOURCE_QUERY_TIMEOUT" default:"8s"`
+	}
+	Reports struct {
+		Retention time.Duration `envconfig:"REPORTS_RETENTION" default:"168h"`
+	}
+}
+
+func main() {
+	log := logger.New(os.Stdout, logger.LevelInfo, "SPI-WORKER", otel.GetTraceID)
+	log.AddContext(otel.LogAttrs)
+
+	ctx := context.Background()
+
+	if err := run(ctx, log); err != nil {
+		log.Error(ctx, "startup", "err", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, log *logger.Logger) error {
+
+	// -------------------------------------------------------------------------
+	// GOMAXPROCS
+
+	log.Info(ctx, "startup", "GOMAXPROCS", runtime.GOMAXPROCS(0))
+
+	// -------------------------------------------------------------------------
+	// Configuration
+
+	var cfg Config
+
+	cfg.Version.Build = build
+	cfg.Version.Desc = "SPI-WORKER"
+
+	help, err := conf.Parse(os.Args[1:], &cfg)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelpWanted) || errors.Is(err, conf.ErrVersionWanted) {
+			fmt.Println(help)
+			return nil
+		}
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+	log.SetLevel(level)
+
+	if err := log.ConfigureModules(cfg.Log.Modules); err != nil {
+		return fmt.Errorf("parsing log modules: %w", err)
+	}
+
+	log.Info(ctx, "starting service", "version", cfg.Version.Build)
+	defer log.Info(ctx, "shutdown complete")
+
+	out, err := conf.String(&cfg)
+	if err != nil {
+		return fmt.Errorf("generating config for output: %w", err)
+	}
+	log.Info(ctx, "startup", "config", out)
+
+	// -------------------------------------------------------------------------
+	// Secrets Support
+
+	provider, err := newSecrets(cfg)
+	if err != nil {
+		return fmt.Errorf("initializing secrets: %w", err)
+	}
+
+	if cfg.DB.PasswordRef != "" {
+		if provider == nil {
+			return errors.New("DB_PASSWORD_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading database password", "provider", cfg.Secrets.Provider)
+
+		if cfg.DB.Password, err = provider.Secret(ctx, cfg.DB.PasswordRef); err != nil {
+			return fmt.Errorf("loading database password: %w", err)
+		}
+	}
+
+	if cfg.Datasource.KeysRef != "" {
+		if provider == nil {
+			return errors.New("DATASOURCE_KEYS_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading datasource keys", "provider", cfg.Secrets.Provider)
+
+		keys, err := provider.Secret(ctx, cfg.Datasource.KeysRef)
+		if err != nil {
+			return fmt.Errorf("loading datasource keys: %w", err)
+		}
+
+		cfg.Datasource.Keys = strings.Split(keys, ",")
+	}
+
+	if cfg.Outbox.KeysRef != "" {
+		if provider == nil {
+			return errors.New("OUTBOX_KEYS_SECRET requires a SECRETS_PROVIDER")
+		}
+
+		log.Info(ctx, "startup", "status", "loading outbox keys", "provider", cfg.Secrets.Provider)
+
+		keys, err := provider.Secret(ctx, cfg.Outbox.KeysRef)
+		if err != nil {
+			return fmt.Errorf("loading outbox keys: %w", err)
+		}
+
+		cfg.Outbox.Keys = strings.Split(keys, ",")
+	}
+
+	// -------------------------------------------------------------------------
+	// Database Support
+
+	log.Info(ctx, "startup", "status", "initializing database support", "hostport", cfg.DB.Host)
+
+	sqldb.SetRetryPolicy(sqldb.RetryPolicy{
+		MaxAttempts: cfg.DB.MaxRetries,
+	})
+	sqldb.SetSlowQueryThreshold(cfg.DB.SlowQuery)
+
+	db, err := sqldb.Open(sqldb.Config{
+		User:         cfg.DB.User,
+		Password:     cfg.DB.Password,
+		Host:         cfg.DB.Host,
+		Name:         cfg.DB.Name,
+		MaxIdleConns: cfg.DB.MaxIdleConns,
+		MaxOpenConns: cfg.DB.MaxOpenConns,
+		DisableTLS:   cfg.DB.DisableTLS,
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to db: %w", err)
+	}
+
+	defer db.Close()
+
+	sqldb.PublishStats("db", db)
+
+	// -------------------------------------------------------------------------
+	// Start Debug Service
+
+	debugSrv := http.Server{
+		Addr:     cfg.Web.DebugHost,
+		Handler:  debug.Mux(log),
+		ErrorLog: logger.NewStdLogger(log, logger.LevelError),
+	}
+
+	go func() {
+		log.Info(ctx, "startup", "status", "debug v1 router started", "host", debugSrv.Addr)
+
+		if err := debugSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(ctx, "shutdown", "status", "debug v1 router closed", "host", debugSrv.Addr, "msg", err)
+		}
+	}()
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := debugSrv.Shutdown(ctx); err != nil {
+			debugSrv.Close()
+			log.Error(ctx, "shutdown", "status", "could not stop debug server gracefully", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Outbox Publishers
+
+	box, err := newBox(cfg.Datasource.Keys)
+	if err != nil {
+		return fmt.Errorf("datasource keys: %w", err)
+	}
+
+	outboxBox, err := newBox(cfg.Outbox.Keys)
+	if err != nil {
+		return fmt.Errorf("outbox keys: %w", err)
+	}
+
+	var publishers outboxbus.Publishers
+
+	if cfg.Outbox.WebhookURL != "" {
+		publishers = append(publishers, outboxbus.NewWebhook(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookSecret))
+	}
+
+	if cfg.Mail.SMTPAddr != "" {
+		// O token do link vai cifrado no outbox; sem as chaves não há como
+		// abri-lo para enviar.
+		if outboxBox == nil {
+			return errors.New("MAIL_SMTP_ADDR requires OUTBOX_KEYS to open the confirmation tokens")
+		}
+
+		if err := checkMailURL("MAIL_EMAIL_CHANGE_URL", cfg.Mail.EmailChangeURL); err != nil {
+			return err
+		}
+
+		if err := checkMailURL("MAIL_REGISTRATION_URL", cfg.Mail.RegistrationURL); err != nil {
+			return err
+		}
+
+		sender := mail.NewSMTP(mail.Config{
+			Addr:     cfg.Mail.SMTPAddr,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		})
+
+		publishers = append(publishers, outboxbus.NewMail(sender, outboxbus.MailConfig{
+			EmailChangeURL:  cfg.Mail.EmailChangeURL,
+			RegistrationURL: cfg.Mail.RegistrationURL,
+		}))
+	}
+
+	switch cfg.Events.Broker {
+	case "":
+	case "nats":
+		broker := events.NewNATS(cfg.Events.NATSURL, cfg.Version.Desc)
+		defer broker.Close()
+
+		publishers = append(publishers, outboxbus.NewBroker(broker, cfg.Events.TopicPrefix))
+	case "kafka":
+		broker := events.NewKafka(cfg.Events.KafkaRESTURL)
+		defer broker.Close()
+
+		publishers = append(publishers, outboxbus.NewBroker(broker, cfg.Events.TopicPrefix))
+	default:
+		return fmt.Errorf("unknown events broker %q: expected nats or kafka", cfg.Events.Broker)
+	}
+
+	// -------------------------------------------------------------------------
+	// Background Jobs
+
+	// As alterações feitas aqui chegam aos caches da API pelo NOTIFY do banco.
+	outboxBus := outboxbus.NewCore(log, outboxdb.NewStore(log, db), outboxBox)
+	delegate := delegate.New(log)
+	// Os jobs não criam senhas; o custo padrão basta para o hasher.
+	hasher, err := passhash.New(passhash.Config{Cost: passhash.DefaultCost, Workers: 1})
+	if err != nil {
+		return fmt.Errorf("password hasher: %w", err)
+	}
+
+	userBus := userbus.NewCore(userdb.NewStore(log, db), outboxBus, delegate, hasher)
+	aclBus := aclbus.NewCore(log, delegate, acldb.NewStore(log, db), outboxBus)
+	activityBus := activitybus.NewCore(log, activitydb.NewStore(log, db))
+	notificationBus := notificationbus.NewCore(log, notificationdb.NewStore(log, db))
+	tenantBus := tenantbus.NewCore(log, delegate, tenantdb.NewStore(log, db))
+	dashboardBus := dashboardbus.NewCore(log, dashboarddb.NewStore(log, db))
+	samlBus := samlbus.NewCore(log, samldb.NewStore(log, db), userBus, tenantBus, dashboardBus)
+
+	wrk := worker.New(log, workerdb.NewStore(log, db), worker.Config{
+		Poll: cfg.Worker.Poll,
+	})
+
+	jobsCfg := jobs.Config{
+		Log:             log,
+		UserBus:         userBus,
+		ACLBus:          aclBus,
+		OutboxBus:       outboxBus,
+		ActivityBus:     activityBus,
+		NotificationBus: notificationBus,
+		SAMLBus:         samlBus,
+		OutboxInterval:  cfg.Outbox.Interval,
+	}
+
+	switch box {
+	case nil:
+		log.Info(ctx, "startup", "status", "reports disabled: DATASOURCE_KEYS not configured")
+
+	default:
+		// Os relatórios não usam o cache de resultados da API; cada um roda
+		// as consultas uma vez.
+		datasourceBus := datasourcebus.NewCore(log, datasourcedb.NewStore(log, db), box, cache.NewMemory(1), datasourcebus.Config{
+			MaxRows:      cfg.Datasource.MaxRows,
+			QueryTimeout: cfg.Datasource.QueryTimeout,
+		})
+
+		jobsCfg.ReportBus = reportbus.NewCore(log, reportdb.NewStore(log, db), datasourceBus, notificationBus, wrk, reportbus.Config{
+			Retention: cfg.Reports.Retention,
+		})
+	}
+
+	if len(publishers) > 0 {
+		log.Info(ctx, "startup", "status", "outbox dispatcher enabled", "publishers", len(publishers), "broker", cfg.Events.Broker)
+		jobsCfg.OutboxPublisher = publishers
+	}
+
+	jobs.Register(wrk, jobsCfg)
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+
+	services := lifecycle.New(log)
+
+	services.Add(lifecycle.Service{
+		Name: "worker",
+		Run:  wrk.Run,
+	})
+
+	services.Start(ctx)
+
+	// -------------------------------------------------------------------------
+	// Shutdown
+
+	select {
+	case err := <-services.Errors():
+		log.Error(ctx, "shutdown", "status", "service failed", "ERROR", err)
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		return errors.Join(fmt.Errorf("service error: %w", err), services.Shutdown(ctx))
+
+	case sig := <-shutdown:
+		log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
+		defer log.Info(ctx, "shutdown", "status", "shutdown complete", "signal", sig)
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := services.Shutdown(ctx); err != nil {
+			return fmt.Errorf("could not stop services gracefully: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkMailURL validates a link template of the confirmation emails: an
+// absolute http(s) URL carrying the {token} placeholder.
+func checkMailURL(env string, value string) error {
+	if value == "" {
+		return fmt.Errorf("MAIL_SMTP_ADDR requires %s", env)
+	}
+
+	if !strings.Contains(value, "{token}") {
+		return fmt.Errorf("%s must contain {token}", env)
+	}
+
+	// As chaves não são válidas numa URL; o {domain} vira um host qualquer
+	// só para a validação.
+	u, err := url.Parse(strings.NewReplacer("{token}", "token", "{domain}", "example.com").Replace(value))
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http or https URL", env)
+	}
+
+	return nil
+}
+
+// newBox constructs a box from the base64 encoded keys, the first sealing
+// and the others only opening. It returns nil when no key is configured.
+func newBox(encoded []string) (*crypto.Box, error) {
+	var keys [][]byte
+
+	for i, s := range encoded {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		key, err := crypto.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	return crypto.NewBox(keys...)
+}
+
+// newSecrets constructs the configured secrets provider. It returns nil when
+// no provider is configured and the secrets come from the environment.
+func newSecrets(cfg Config) (secrets.Provider, error) {
+	switch cfg.Secrets.Provider {
+	case "":
+		return nil, nil
+	case "vault":
+		return secrets.NewVault(secrets.VaultConfig{
+			Addr:      cfg.Secrets.VaultAddr,
+			Token:     cfg.Secrets.VaultToken,
+			Namespace: cfg.Secrets.VaultNamespace,
+			Mount:     cfg.Secrets.VaultMount,
+		}), nil
+	case "aws":
+		return secrets.NewAWS(secrets.AWSConfig{
+			Region:          cfg.Secrets.AWSRegion,
+			AccessKeyID:     cfg.Secrets.AWSAccessKeyID,
+			SecretAccessKey: cfg.Secrets.AWSSecretAccessKey,
+			SessionToken:    cfg.Secrets.AWSSessionToken,
+			Endpoint:        cfg.Secrets.AWSEndpoint,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q: expected vault or aws", cfg.Secrets.Provider)
+	}
+}
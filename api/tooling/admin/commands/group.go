@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+)
+
+// GroupInfo represents a group in the results.
+type GroupInfo struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenantId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Text implements the Result interface.
+func (g GroupInfo) Text(w io.Writer) {
+	fmt.Fprintf(w, "ID:     %s\nTenant: %s\nName:   %s\n", g.ID, g.TenantID, g.Name)
+}
+
+func toGroupInfo(g tenantbus.Group) GroupInfo {
+	return GroupInfo{
+		ID:        g.ID,
+		TenantID:  g.TenantID,
+		Name:      g.Name.String(),
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+// GroupList is the list of groups of a tenant.
+type GroupList struct {
+	Items []GroupInfo `json:"items"`
+}
+
+// Text implements the Result interface.
+func (l GroupList) Text(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tCREATED")
+	for _, g := range l.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.ID, g.Name, g.CreatedAt.Format(time.DateTime))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d groups\n", len(l.Items))
+}
+
+// GroupResult reports a change to a group.
+type GroupResult struct {
+	GroupID uuid.UUID `json:"groupId"`
+	Message string    `json:"message"`
+}
+
+// Text implements the Result interface.
+func (r GroupResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Group %s: %s\n", r.GroupID, r.Message)
+}
+
+// =============================================================================
+
+// CreateGroup creates a group of users in a tenant.
+func CreateGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("create-group")
+	tenantIDStr := fs.String("tenant-id", "", "Tenant UUID (Required)")
+	nameStr := fs.String("name", "", "Group name, like the department (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *tenantIDStr == "" || *nameStr == "" {
+		return nil, fmt.Errorf("%w: -tenant-id and -name are required", ErrUsage)
+	}
+
+	tenantID, err := uuid.Parse(*tenantIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid tenant uuid: %s", ErrUsage, err)
+	}
+
+	n, err := name.ParseWith(*nameStr, tenantbus.GroupNameRules)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid name: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := bus.Tenant.QueryByID(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("query tenant: %w", err)
+	}
+
+	g, err := bus.Tenant.CreateGroup(ctx, tenantbus.NewGroup{TenantID: tenantID, Name: n})
+	if err != nil {
+		return nil, fmt.Errorf("create group: %w", err)
+	}
+
+	return toGroupInfo(g), nil
+}
+
+// ListGroups lists the groups of a tenant.
+func ListGroups(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("list-groups")
+	tenantIDStr := fs.String("tenant-id", "", "Tenant UUID (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *tenantIDStr == "" {
+		return nil, fmt.Errorf("%w: -tenant-id is required", ErrUsage)
+	}
+
+	tenantID, err := uuid.Parse(*tenantIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid tenant uuid: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := bus.Tenant.QueryGroups(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query groups: %w", err)
+	}
+
+	l := GroupList{
+		Items: make([]GroupInfo, len(groups)),
+	}
+	for i, g := range groups {
+		l.Items[i] = toGroupInfo(g)
+	}
+
+	return l, nil
+}
+
+// DeleteGroup deletes a group. The members lose the dashboards granted only
+// through it.
+func DeleteGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("delete-group")
+	groupIDStr := fs.String("group-id", "", "Group UUID (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	g, bus, err := queryGroup(ctx, env, *groupIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.Confirm(fmt.Sprintf("Delete group %s and the dashboard access of its members?", g.Name)); err != nil {
+		return nil, err
+	}
+
+	if err := bus.Tenant.DeleteGroup(ctx, g); err != nil {
+		return nil, fmt.Errorf("delete group: %w", err)
+	}
+
+	return GroupResult{GroupID: g.ID, Message: "deleted"}, nil
+}
+
+// AddToGroup adds a member of the tenant to a group.
+func AddToGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	return changeGroupMember(ctx, env, "add-to-group", args, true)
+}
+
+// RemoveFromGroup removes a user from a group.
+func RemoveFromGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	return changeGroupMember(ctx, env, "remove-from-group", args, false)
+}
+
+// LinkGroup gives every member of a group access to a dashboard of the
+// tenant.
+func LinkGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	return changeGroupDashboard(ctx, env, "link-group", args, true)
+}
+
+// UnlinkGroup removes the access of a group to a dashboard.
+func UnlinkGroup(ctx context.Context, env *Env, args []string) (Result, error) {
+	return changeGroupDashboard(ctx, env, "unlink-group", args, false)
+}
+
+// =============================================================================
+
+func changeGroupMember(ctx context.Context, env *Env, cmd string, args []string, add bool) (Result, error) {
+	fs := env.Flags(cmd)
+	groupIDStr := fs.String("group-id", "", "Group UUID (Required)")
+	userIDStr := fs.String("user-id", "", "User UUID (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *userIDStr == "" {
+		return nil, fmt.Errorf("%w: -user-id is required", ErrUsage)
+	}
+
+	userID, err := uuid.Parse(*userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user uuid: %s", ErrUsage, err)
+	}
+
+	g, bus, err := queryGroup(ctx, env, *groupIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !add {
+		if err := bus.Tenant.RemoveGroupMember(ctx, g, userID); err != nil {
+			return nil, fmt.Errorf("remove member: %w", err)
+		}
+
+		return GroupResult{GroupID: g.ID, Message: fmt.Sprintf("user %s removed", userID)}, nil
+	}
+
+	if err := bus.Tenant.AddGroupMember(ctx, g, userID); err != nil {
+		return nil, fmt.Errorf("add member: %w", err)
+	}
+
+	return GroupResult{GroupID: g.ID, Message: fmt.Sprintf("user %s added", userID)}, nil
+}
+
+func changeGroupDashboard(ctx context.Context, env *Env, cmd string, args []string, link bool) (Result, error) {
+	fs := env.Flags(cmd)
+	groupIDStr := fs.String("group-id", "", "Group UUID (Required)")
+	dashIDStr := fs.String("dashboard-id", "", "Dashboard UUID (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *dashIDStr == "" {
+		return nil, fmt.Errorf("%w: -dashboard-id is required", ErrUsage)
+	}
+
+	dashID, err := uuid.Parse(*dashIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid dashboard uuid: %s", ErrUsage, err)
+	}
+
+	g, bus, err := queryGroup(ctx, env, *groupIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !link {
+		if err := bus.Tenant.RevokeGroupAccessToDashboard(ctx, g, dashID); err != nil {
+			return nil, fmt.Errorf("unlink group: %w", err)
+		}
+
+		return GroupResult{GroupID: g.ID, Message: fmt.Sprintf("dashboard %s unlinked", dashID)}, nil
+	}
+
+	if err := bus.Tenant.GrantGroupAccessToDashboard(ctx, g, dashID); err != nil {
+		return nil, fmt.Errorf("link group: %w", err)
+	}
+
+	return GroupResult{GroupID: g.ID, Message: fmt.Sprintf("dashboard %s linked", dashID)}, nil
+}
+
+// queryGroup parses the group flag and loads the group.
+func queryGroup(ctx context.Context, env *Env, groupIDStr string) (tenantbus.Group, *Buses, error) {
+	if groupIDStr == "" {
+		return tenantbus.Group{}, nil, fmt.Errorf("%w: -group-id is required", ErrUsage)
+	}
+
+	groupID, err := uuid.Parse(groupIDStr)
+	if err != nil {
+		return tenantbus.Group{}, nil, fmt.Errorf("%w: invalid group uuid: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return tenantbus.Group{}, nil, err
+	}
+
+	g, err := bus.Tenant.QueryGroupByID(ctx, groupID)
+	if err != nil {
+		return tenantbus.Group{}, nil, fmt.Errorf("query group: %w", err)
+	}
+
+	return g, bus, nil
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker/stores/workerdb"
+)
+
+// JobResult identifies the task enqueued.
+type JobResult struct {
+	TaskID uuid.UUID `json:"taskId"`
+	Name   string    `json:"name"`
+}
+
+// Text implements the Result interface.
+func (r JobResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Job %s enqueued\nTask ID: %s\n", r.Name, r.TaskID)
+}
+
+// RunJob enqueues a task. It stays in the queue until a worker, in the API or
+// in api/cmd/worker, runs it.
+func RunJob(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("run-job")
+	nameStr := fs.String("name", "", "Job name, like acl.sweep (Required)")
+	payloadStr := fs.String("payload", "null", "Task payload as JSON")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *nameStr == "" {
+		return nil, fmt.Errorf("%w: -name is required", ErrUsage)
+	}
+
+	if !json.Valid([]byte(*payloadStr)) {
+		return nil, fmt.Errorf("%w: invalid payload: not a JSON document", ErrUsage)
+	}
+
+	var task worker.Task
+	err := env.InTx(func(tx sqldb.CommitRollbacker) error {
+		ec, err := sqldb.GetExtContext(tx)
+		if err != nil {
+			return err
+		}
+
+		wrk := worker.New(env.Log, workerdb.NewStore(env.Log, ec), worker.Config{})
+
+		task, err = wrk.Enqueue(ctx, *nameStr, json.RawMessage(*payloadStr), time.Time{})
+		if err != nil {
+			return fmt.Errorf("enqueue job: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return JobResult{TaskID: task.ID, Name: task.Name}, nil
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/keybus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/keystore"
+)
+
+// KeyResult identifies the new active signing key.
+type KeyResult struct {
+	KID         string    `json:"kid"`
+	Imported    bool      `json:"imported"`
+	RetireAfter time.Time `json:"retireAfter"`
+}
+
+// Text implements the Result interface.
+func (r KeyResult) Text(w io.Writer) {
+	source := "generated"
+	if r.Imported {
+		source = "imported"
+	}
+
+	fmt.Fprintf(w, "Key %s %s and active\nPrevious key valid until %s\n", r.KID, source, r.RetireAfter.Format(time.RFC3339))
+}
+
+// RotateKey adds a signing key to the database and makes it the active one
+// for the instances running with AUTH_KEYS_DB. The previous key keeps
+// verifying the tokens it signed until -retire-after has passed.
+func RotateKey(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("rotate-key")
+	kidStr := fs.String("kid", "", "Key ID of the new key (default a new UUID)")
+	pemFile := fs.String("pem", "", "PEM file of an existing private key to import instead of generating one")
+	retireAfter := fs.Duration("retire-after", 24*time.Hour, "How long the previous key keeps verifying tokens, at least the token lifetime")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	kid := *kidStr
+	if kid == "" {
+		kid = uuid.NewString()
+	}
+
+	var privatePEM string
+
+	switch *pemFile {
+	case "":
+		pem, err := keystore.GeneratePEM()
+		if err != nil {
+			return nil, err
+		}
+		privatePEM = pem
+
+	default:
+		data, err := os.ReadFile(*pemFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading pem: %s", ErrUsage, err)
+		}
+		privatePEM = string(data)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := env.Confirm(fmt.Sprintf("Sign the new tokens with key %s?", kid)); err != nil {
+		return nil, err
+	}
+
+	err = env.InTx(func(tx sqldb.CommitRollbacker) error {
+		kb, err := bus.Key.NewWithTx(tx)
+		if err != nil {
+			return fmt.Errorf("new with tx: %w", err)
+		}
+
+		if _, err := kb.Rotate(ctx, keybus.NewKey{KID: kid, PrivatePEM: privatePEM}, *retireAfter); err != nil {
+			return fmt.Errorf("rotate: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := KeyResult{
+		KID:         kid,
+		Imported:    *pemFile != "",
+		RetireAfter: time.Now().Add(*retireAfter),
+	}
+
+	return r, nil
+}
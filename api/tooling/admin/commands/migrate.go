@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/migrate"
+)
+
+// MigrationInfo identifies a migration in the results.
+type MigrationInfo struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+}
+
+// MigrateResult lists the migrations applied, or pending with --dry-run.
+type MigrateResult struct {
+	Applied []MigrationInfo `json:"applied"`
+	DryRun  bool            `json:"dryRun"`
+}
+
+// Text implements the Result interface.
+func (r MigrateResult) Text(w io.Writer) {
+	if len(r.Applied) == 0 {
+		fmt.Fprintln(w, "Database is up to date")
+		return
+	}
+
+	verb := "applied"
+	if r.DryRun {
+		verb = "pending"
+	}
+
+	for _, m := range r.Applied {
+		fmt.Fprintf(w, "%s: %05d %s\n", verb, m.Version, m.Name)
+	}
+}
+
+// Migrate applies the pending migrations.
+func Migrate(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("migrate")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	db, err := env.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	apply := migrate.Migrate
+	if env.Opts.DryRun {
+		apply = migrate.Pending
+	}
+
+	migrations, err := apply(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	r := MigrateResult{
+		Applied: make([]MigrationInfo, len(migrations)),
+		DryRun:  env.Opts.DryRun,
+	}
+	for i, m := range migrations {
+		r.Applied[i] = MigrationInfo{Version: m.Version, Name: m.Name}
+	}
+
+	return r, nil
+}
+
+// RollbackResult identifies the migration reverted.
+type RollbackResult struct {
+	RolledBack MigrationInfo `json:"rolledBack"`
+	DryRun     bool          `json:"dryRun"`
+}
+
+// Text implements the Result interface.
+func (r RollbackResult) Text(w io.Writer) {
+	verb := "Rolled back"
+	if r.DryRun {
+		verb = "Would roll back"
+	}
+
+	fmt.Fprintf(w, "%s %05d %s\n", verb, r.RolledBack.Version, r.RolledBack.Name)
+}
+
+// Rollback reverts the last migration applied.
+func Rollback(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("rollback")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	db, err := env.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := migrate.Latest(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	r := RollbackResult{
+		RolledBack: MigrationInfo{Version: latest.Version, Name: latest.Name},
+		DryRun:     env.Opts.DryRun,
+	}
+
+	if env.Opts.DryRun {
+		return r, nil
+	}
+
+	if err := env.Confirm(fmt.Sprintf("Roll back migration %05d %s?", latest.Version, latest.Name)); err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.Rollback(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	r.RolledBack = MigrationInfo{Version: m.Version, Name: m.Name}
+
+	return r, nil
+}
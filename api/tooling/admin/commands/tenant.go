@@ -0,0 +1,260 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+// TenantInfo represents a tenant in the results.
+type TenantInfo struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Slug             string    `json:"slug"`
+	Enabled          bool      `json:"enabled"`
+	SelfRegistration bool      `json:"selfRegistration"`
+	ScopedEmails     bool      `json:"scopedEmails"`
+	RoleApproval     bool      `json:"roleApproval"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// Text implements the Result interface.
+func (t TenantInfo) Text(w io.Writer) {
+	fmt.Fprintf(w, "ID:                %s\nName:              %s\nSlug:              %s\nEnabled:           %t\nSelf registration: %t\nScoped emails:     %t\nRole approval:     %t\n", t.ID, t.Name, t.Slug, t.Enabled, t.SelfRegistration, t.ScopedEmails, t.RoleApproval)
+}
+
+func toTenantInfo(t tenantbus.Tenant) TenantInfo {
+	return TenantInfo{
+		ID:               t.ID,
+		Name:             t.Name.String(),
+		Slug:             t.Slug.String(),
+		Enabled:          t.Enabled,
+		SelfRegistration: t.SelfRegistration,
+		ScopedEmails:     t.ScopedEmails,
+		RoleApproval:     t.RoleApproval,
+		CreatedAt:        t.CreatedAt,
+	}
+}
+
+// TenantList is a page of tenants, in the same shape as the query endpoints
+// of the API.
+type TenantList struct {
+	Items       []TenantInfo `json:"items"`
+	Total       int          `json:"total"`
+	Page        int          `json:"page"`
+	RowsPerPage int          `json:"rowsPerPage"`
+}
+
+// Text implements the Result interface.
+func (l TenantList) Text(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSLUG\tENABLED\tSELF REGISTRATION\tCREATED")
+	for _, t := range l.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%t\t%s\n", t.ID, t.Name, t.Slug, t.Enabled, t.SelfRegistration, t.CreatedAt.Format(time.DateTime))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d of %d tenants (page %d)\n", len(l.Items), l.Total, l.Page)
+}
+
+// LinkResult reports the access given to a user.
+type LinkResult struct {
+	UserID      uuid.UUID `json:"userId"`
+	DashboardID uuid.UUID `json:"dashboardId"`
+}
+
+// Text implements the Result interface.
+func (r LinkResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "User %s linked to Dashboard %s (and its Tenant)\n", r.UserID, r.DashboardID)
+}
+
+// =============================================================================
+
+// LinkUser gives the user access to the dashboard and makes them a member of
+// its tenant.
+func LinkUser(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("link-user")
+	userIDStr := fs.String("user-id", "", "User UUID (Required)")
+	dashIDStr := fs.String("dashboard-id", "", "Dashboard UUID (Required)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *userIDStr == "" || *dashIDStr == "" {
+		return nil, fmt.Errorf("%w: -user-id and -dashboard-id are required", ErrUsage)
+	}
+
+	userID, err := uuid.Parse(*userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user uuid: %s", ErrUsage, err)
+	}
+
+	dashID, err := uuid.Parse(*dashIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid dashboard uuid: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	err = env.InTx(func(tx sqldb.CommitRollbacker) error {
+		tb, err := bus.Tenant.NewWithTx(tx)
+		if err != nil {
+			return fmt.Errorf("new with tx: %w", err)
+		}
+
+		if err := tb.GrantUserAccessToDashboard(ctx, userID, dashID); err != nil {
+			return fmt.Errorf("link user: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return LinkResult{UserID: userID, DashboardID: dashID}, nil
+}
+
+// ListTenants lists the tenants matching the filters.
+func ListTenants(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("list-tenants")
+	nameStr := fs.String("name", "", "Filter by part of the name")
+	slugStr := fs.String("slug", "", "Filter by exact slug")
+	enabledStr := fs.String("enabled", "", "Filter by status (true, false)")
+	pageStr := fs.String("page", "1", "Page number")
+	rowsStr := fs.String("rows", "50", "Rows per page (max 100)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	var filter tenantbus.QueryFilter
+
+	if *nameStr != "" {
+		filter.Name = nameStr
+	}
+
+	if *slugStr != "" {
+		s, err := slug.Parse(*slugStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid slug: %s", ErrUsage, err)
+		}
+		filter.Slug = &s
+	}
+
+	enabled, err := parseOptionalBool(*enabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid enabled: %s", ErrUsage, err)
+	}
+	filter.Enabled = enabled
+
+	pg, err := page.Parse(*pageStr, *rowsStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	tenants, total, err := bus.Tenant.QueryWithCount(ctx, filter, tenantbus.DefaultOrderBy, pg)
+	if err != nil {
+		return nil, fmt.Errorf("query tenants: %w", err)
+	}
+
+	l := TenantList{
+		Items:       make([]TenantInfo, len(tenants)),
+		Total:       total,
+		Page:        pg.Number(),
+		RowsPerPage: pg.RowsPerPage(),
+	}
+	for i, t := range tenants {
+		l.Items[i] = toTenantInfo(t)
+	}
+
+	return l, nil
+}
+
+// UpdateTenant turns a tenant, its self registration, its scoped emails and
+// the approval of promotions to ADMIN on or off. Flags left empty keep the
+// current value.
+func UpdateTenant(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("update-tenant")
+	tenantIDStr := fs.String("tenant-id", "", "Tenant UUID (Required)")
+	enabledStr := fs.String("enabled", "", "Enable or disable the tenant (true, false)")
+	selfRegStr := fs.String("self-registration", "", "Let users sign up through the domains of the tenant (true, false)")
+	scopedStr := fs.String("scoped-emails", "", "Make the emails of the users of the tenant unique only within it (true, false)")
+	approvalStr := fs.String("role-approval", "", "Require a second ADMIN to approve the promotions to ADMIN (true, false)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *tenantIDStr == "" {
+		return nil, fmt.Errorf("%w: -tenant-id is required", ErrUsage)
+	}
+
+	tenantID, err := uuid.Parse(*tenantIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid tenant uuid: %s", ErrUsage, err)
+	}
+
+	var ut tenantbus.UpdateTenant
+
+	if ut.Enabled, err = parseOptionalBool(*enabledStr); err != nil {
+		return nil, fmt.Errorf("%w: invalid enabled: %s", ErrUsage, err)
+	}
+
+	if ut.SelfRegistration, err = parseOptionalBool(*selfRegStr); err != nil {
+		return nil, fmt.Errorf("%w: invalid self-registration: %s", ErrUsage, err)
+	}
+
+	if ut.ScopedEmails, err = parseOptionalBool(*scopedStr); err != nil {
+		return nil, fmt.Errorf("%w: invalid scoped-emails: %s", ErrUsage, err)
+	}
+
+	if ut.RoleApproval, err = parseOptionalBool(*approvalStr); err != nil {
+		return nil, fmt.Errorf("%w: invalid role-approval: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := bus.Tenant.QueryByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query tenant: %w", err)
+	}
+
+	if ut.Enabled != nil && !*ut.Enabled && t.Enabled {
+		if err := env.Confirm(fmt.Sprintf("Disable tenant %s?", t.Slug)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Voltar ao escopo global falha quando dois usuários já dividem um
+	// e-mail; o administrador resolve o conflito antes.
+	if ut.ScopedEmails != nil && !*ut.ScopedEmails && t.ScopedEmails {
+		if err := env.Confirm(fmt.Sprintf("Make the emails of tenant %s unique across all tenants?", t.Slug)); err != nil {
+			return nil, err
+		}
+	}
+
+	t, err = bus.Tenant.Update(ctx, t, ut)
+	if err != nil {
+		return nil, fmt.Errorf("update tenant: %w", err)
+	}
+
+	return toTenantInfo(t), nil
+}
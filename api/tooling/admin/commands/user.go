@@ -0,0 +1,511 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+// UserInfo represents a user in the results. The password hash is never
+// printed.
+type UserInfo struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func toUserInfo(usr userbus.User) UserInfo {
+	return UserInfo{
+		ID:        usr.ID,
+		Name:      usr.Name.String(),
+		Email:     usr.Email.Address,
+		Role:      usr.Role.String(),
+		Enabled:   usr.Enabled,
+		CreatedAt: usr.CreatedAt,
+	}
+}
+
+// Text implements the Result interface.
+func (u UserInfo) Text(w io.Writer) {
+	fmt.Fprintf(w, "ID:      %s\nName:    %s\nEmail:   %s\nRole:    %s\nEnabled: %t\n", u.ID, u.Name, u.Email, u.Role, u.Enabled)
+}
+
+// UserList is a page of users, in the same shape as the query endpoints of
+// the API.
+type UserList struct {
+	Items       []UserInfo `json:"items"`
+	Total       int        `json:"total"`
+	Page        int        `json:"page"`
+	RowsPerPage int        `json:"rowsPerPage"`
+}
+
+// Text implements the Result interface.
+func (l UserList) Text(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tEMAIL\tROLE\tENABLED\tCREATED")
+	for _, u := range l.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%s\n", u.ID, u.Name, u.Email, u.Role, u.Enabled, u.CreatedAt.Format(time.DateTime))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d of %d users (page %d)\n", len(l.Items), l.Total, l.Page)
+}
+
+// PasswordResult reports a password reset. TemporaryPassword is only set when
+// the password was generated.
+type PasswordResult struct {
+	User              UserInfo `json:"user"`
+	TemporaryPassword string   `json:"temporaryPassword,omitempty"`
+}
+
+// Text implements the Result interface.
+func (r PasswordResult) Text(w io.Writer) {
+	fmt.Fprintf(w, "Password reset for %s (%s)\n", r.User.ID, r.User.Email)
+	if r.TemporaryPassword != "" {
+		fmt.Fprintf(w, "Temporary password: %s\n", r.TemporaryPassword)
+	}
+}
+
+// =============================================================================
+
+// CreateUser creates a user.
+func CreateUser(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("create-user")
+	emailStr := fs.String("email", "", "User email (Required)")
+	passStr := fs.String("password", "", "User password (Required)")
+	nameStr := fs.String("name", "", "User full name (Required)")
+	roleStr := fs.String("role", "USER", "User role (ADMIN, ANALYST, USER)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *emailStr == "" || *passStr == "" || *nameStr == "" {
+		return nil, fmt.Errorf("%w: -email, -password and -name are required", ErrUsage)
+	}
+
+	addr, err := mail.ParseAddress(*emailStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid email: %s", ErrUsage, err)
+	}
+
+	n, err := name.Parse(*nameStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid name: %s", ErrUsage, err)
+	}
+
+	r, err := role.Parse(*roleStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid role: %s", ErrUsage, err)
+	}
+
+	p, err := password.Parse(*passStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid password: %s", ErrUsage, err)
+	}
+
+	nu := userbus.NewUser{
+		Name:     n,
+		Email:    *addr,
+		Password: p,
+		Role:     r,
+		Phone:    phone.Null{},
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	// O usuário e o evento user.created são gravados juntos.
+	var usr userbus.User
+	err = env.InTx(func(tx sqldb.CommitRollbacker) error {
+		ub, err := bus.User.NewWithTx(tx)
+		if err != nil {
+			return fmt.Errorf("new with tx: %w", err)
+		}
+
+		usr, err = ub.Create(ctx, nu)
+		if err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserInfo(usr), nil
+}
+
+// ListUsers lists the users matching the filters.
+func ListUsers(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("list-users")
+	emailStr := fs.String("email", "", "Filter by exact email")
+	nameStr := fs.String("name", "", "Filter by part of the name")
+	roleStr := fs.String("role", "", "Filter by role (ADMIN, ANALYST, USER)")
+	tenantStr := fs.String("tenant", "", "Filter by tenant UUID or slug")
+	enabledStr := fs.String("enabled", "", "Filter by status (true, false)")
+	pageStr := fs.String("page", "1", "Page number")
+	rowsStr := fs.String("rows", "50", "Rows per page (max 100)")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	var filter userbus.QueryFilter
+
+	if *emailStr != "" {
+		addr, err := mail.ParseAddress(*emailStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid email: %s", ErrUsage, err)
+		}
+		filter.Email = addr
+	}
+
+	if *nameStr != "" {
+		n, err := name.Parse(*nameStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid name: %s", ErrUsage, err)
+		}
+		filter.Name = &n
+	}
+
+	if *roleStr != "" {
+		r, err := role.Parse(*roleStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid role: %s", ErrUsage, err)
+		}
+		filter.Role = &r
+	}
+
+	enabled, err := parseOptionalBool(*enabledStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid enabled: %s", ErrUsage, err)
+	}
+	filter.Enabled = enabled
+
+	pg, err := page.Parse(*pageStr, *rowsStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid page: %s", ErrUsage, err)
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	if *tenantStr != "" {
+		tenantID, err := uuid.Parse(*tenantStr)
+		if err != nil {
+			// Não é um UUID: tenta como slug, que é o que o suporte costuma ter em mãos.
+			s, err := slug.Parse(*tenantStr)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid tenant: %s", ErrUsage, err)
+			}
+
+			tenantID, err = bus.Tenant.QueryIDBySlug(ctx, s)
+			if err != nil {
+				return nil, fmt.Errorf("tenant %q: %w", *tenantStr, err)
+			}
+		}
+		filter.TenantID = &tenantID
+	}
+
+	usrs, total, err := bus.User.QueryWithCount(ctx, filter, userbus.DefaultOrderBy, pg)
+	if err != nil {
+		return nil, fmt.Errorf("query users: %w", err)
+	}
+
+	l := UserList{
+		Items:       make([]UserInfo, len(usrs)),
+		Total:       total,
+		Page:        pg.Number(),
+		RowsPerPage: pg.RowsPerPage(),
+	}
+	for i, usr := range usrs {
+		l.Items[i] = toUserInfo(usr)
+	}
+
+	return l, nil
+}
+
+// ResetPassword sets a new password for the user, generating a temporary
+// one when none is informed.
+func ResetPassword(ctx context.Context, env *Env, args []string) (Result, error) {
+	fs := env.Flags("reset-password")
+	userIDStr := fs.String("user-id", "", "User UUID (this or -email is required)")
+	emailStr := fs.String("email", "", "User email (this or -user-id is required)")
+	passStr := fs.String("password", "", "New password; a temporary one is generated when empty")
+	actorStr := fs.String("actor-id", "", "UUID of the admin running the command, for the audit trail")
+	reason := fs.String("reason", "", "Why the change was made, for the audit trail")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	actorID, err := parseActor(*actorStr)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := lookupUser(ctx, bus.User, *userIDStr, *emailStr)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := *passStr == ""
+	if generated {
+		if *passStr, err = temporaryPassword(); err != nil {
+			return nil, fmt.Errorf("generate password: %w", err)
+		}
+	}
+
+	pw, err := password.Parse(*passStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid password: %s", ErrUsage, err)
+	}
+
+	if err := env.Confirm(fmt.Sprintf("Reset the password of %s?", usr.Email.Address)); err != nil {
+		return nil, err
+	}
+
+	if err := updateUser(ctx, env, bus.User, usr, userbus.UpdateUser{Password: &pw}); err != nil {
+		return nil, fmt.Errorf("reset password: %w", err)
+	}
+
+	body := map[string]any{
+		"user_id":   usr.ID,
+		"generated": generated,
+		"reason":    *reason,
+	}
+	recordAudit(ctx, env, bus, actorID, usr.ID, "reset-password", body)
+
+	r := PasswordResult{
+		User: toUserInfo(usr),
+	}
+	if generated {
+		r.TemporaryPassword = *passStr
+	}
+
+	return r, nil
+}
+
+// DisableUser disables the user, who can no longer log in or use the tokens
+// already issued.
+func DisableUser(ctx context.Context, env *Env, args []string) (Result, error) {
+	return setEnabled(ctx, env, "disable-user", false, args)
+}
+
+// EnableUser enables the user again.
+func EnableUser(ctx context.Context, env *Env, args []string) (Result, error) {
+	return setEnabled(ctx, env, "enable-user", true, args)
+}
+
+func setEnabled(ctx context.Context, env *Env, command string, enabled bool, args []string) (Result, error) {
+	fs := env.Flags(command)
+	userIDStr := fs.String("user-id", "", "User UUID (this or -email is required)")
+	emailStr := fs.String("email", "", "User email (this or -user-id is required)")
+	actorStr := fs.String("actor-id", "", "UUID of the admin running the command, for the audit trail")
+	reason := fs.String("reason", "", "Why the change was made, for the audit trail")
+	if err := env.Parse(fs, args); err != nil {
+		return nil, err
+	}
+
+	actorID, err := parseActor(*actorStr)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := env.Buses()
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := lookupUser(ctx, bus.User, *userIDStr, *emailStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if usr.Enabled == enabled {
+		return toUserInfo(usr), nil
+	}
+
+	if !enabled {
+		if err := env.Confirm(fmt.Sprintf("Disable %s?", usr.Email.Address)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := updateUser(ctx, env, bus.User, usr, userbus.UpdateUser{Enabled: &enabled}); err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+
+	body := map[string]any{
+		"user_id": usr.ID,
+		"enabled": enabled,
+		"reason":  *reason,
+	}
+	recordAudit(ctx, env, bus, actorID, usr.ID, command, body)
+
+	usr.Enabled = enabled
+
+	return toUserInfo(usr), nil
+}
+
+// =============================================================================
+
+// lookupUser finds the user by id or, when the id is not informed, by email.
+func lookupUser(ctx context.Context, ub *userbus.Core, userIDStr string, emailStr string) (userbus.User, error) {
+	switch {
+	case userIDStr != "":
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("%w: invalid user uuid: %s", ErrUsage, err)
+		}
+
+		usr, err := ub.QueryByID(ctx, userID)
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("query user: %w", err)
+		}
+		return usr, nil
+
+	case emailStr != "":
+		addr, err := mail.ParseAddress(emailStr)
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("%w: invalid email: %s", ErrUsage, err)
+		}
+
+		// Só o escopo global: os usuários de clientes com e-mails isolados
+		// são encontrados pelo -user-id.
+		usr, err := ub.QueryByEmail(ctx, nil, *addr)
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("query user: %w", err)
+		}
+		return usr, nil
+	}
+
+	return userbus.User{}, fmt.Errorf("%w: -user-id or -email is required", ErrUsage)
+}
+
+// updateUser aplica a alteração numa transação, junto com os eventos que o
+// userbus grava no outbox.
+func updateUser(ctx context.Context, env *Env, ub *userbus.Core, usr userbus.User, uu userbus.UpdateUser) error {
+	return env.InTx(func(tx sqldb.CommitRollbacker) error {
+		ub, err := ub.NewWithTx(tx)
+		if err != nil {
+			return fmt.Errorf("new with tx: %w", err)
+		}
+
+		if _, err := ub.Update(ctx, usr, uu); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// recordAudit writes the change to the same audit trail as the API requests,
+// with CLI as the method and the command as the route. As in the audit
+// middleware, a failure is only logged since the change is already done.
+// Nothing is recorded with --dry-run.
+func recordAudit(ctx context.Context, env *Env, bus *Buses, actorID uuid.UUID, userID uuid.UUID, command string, body map[string]any) {
+	if env.Opts.DryRun {
+		return
+	}
+
+	// Admins e analistas não pertencem a um tenant; nesse caso fica uuid.Nil.
+	tenantID, err := bus.Tenant.QueryTenantIDByUserID(ctx, userID)
+	if err != nil {
+		tenantID = uuid.Nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		env.Log.Error(ctx, "audit", "status", "encoding body", "command", command, "ERROR", err)
+		return
+	}
+
+	na := auditbus.NewAudit{
+		ActorID:  actorID,
+		TenantID: tenantID,
+		Method:   "CLI",
+		Route:    "admin " + command,
+		Status:   http.StatusOK,
+		Body:     data,
+	}
+
+	if _, err := bus.Audit.Create(ctx, na); err != nil {
+		env.Log.Error(ctx, "audit", "status", "recording command", "command", command, "ERROR", err)
+	}
+}
+
+func parseActor(actorStr string) (uuid.UUID, error) {
+	if actorStr == "" {
+		return uuid.Nil, nil
+	}
+
+	actorID, err := uuid.Parse(actorStr)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: invalid actor uuid: %s", ErrUsage, err)
+	}
+
+	return actorID, nil
+}
+
+// temporaryPassword generates a password accepted by password.Parse, which
+// allows up to 19 characters.
+func temporaryPassword() (string, error) {
+	const alphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	b := make([]byte, 16)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// parseOptionalBool devolve nil para vazio, já que o flag.Bool não distingue
+// "não informado" de false.
+func parseOptionalBool(s string) (*bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
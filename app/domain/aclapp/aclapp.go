@@ -0,0 +1,428 @@
+// Package aclapp maintains the app layer api for the acl domain.
+package aclapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	aclBus          *aclbus.Core
+	userBus         *userbus.Core
+	tenantBus       *tenantbus.Core
+	notificationBus *notificationbus.Core
+}
+
+func newApp(aclBus *aclbus.Core, userBus *userbus.Core, tenantBus *tenantbus.Core, notificationBus *notificationbus.Core) *app {
+	return &app{
+		aclBus:          aclBus,
+		userBus:         userBus,
+		tenantBus:       tenantBus,
+		notificationBus: notificationBus,
+	}
+}
+
+// newWithTx constructs a new app value with the domain apis using a store
+// transaction that was created via middleware. ACL changes and their audit
+// records must be committed together, so the write routes use it through
+// mid.WithTran.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aclBus, err := a.aclBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := a.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	notificationBus, err := a.notificationBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newApp(aclBus, userBus, a.tenantBus, notificationBus), nil
+}
+
+// create grants a set of actions to a user on a resource.
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewACL
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	na, err := toBusNewACL(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	actorID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	if _, err := a.userBus.QueryByID(ctx, na.UserID); err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "query user: userID[%s]: %s", na.UserID, err)
+	}
+
+	if errEnc := a.checkTenant(ctx, na.UserID, na.ResourceID); errEnc != nil {
+		return errEnc
+	}
+
+	acl, err := a.aclBus.Create(ctx, actorID, na)
+	if err != nil {
+		switch {
+		case errors.Is(err, aclbus.ErrResourceNotFound):
+			return errs.New(errs.NotFound, aclbus.ErrResourceNotFound).WithReason(errs.ReasonResourceNotFound)
+		case errors.Is(err, aclbus.ErrUniqueACL):
+			return errs.New(errs.Aborted, aclbus.ErrUniqueACL).WithReason(errs.ReasonACLNotUnique)
+		case errors.Is(err, aclbus.ErrNoActions):
+			return errs.New(errs.InvalidArgument, aclbus.ErrNoActions).WithReason(errs.ReasonACLNoActions)
+		case errors.Is(err, aclbus.ErrInvalidExpiry):
+			return errs.New(errs.InvalidArgument, aclbus.ErrInvalidExpiry).WithReason(errs.ReasonACLInvalidExpiry)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "create: na[%+v]: %s", na, err)
+	}
+
+	if _, err := a.notificationBus.Create(ctx, accessGranted(actorID, acl)); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "notify: aclID[%s]: %s", acl.ID, err)
+	}
+
+	return toAppACL(acl)
+}
+
+// update replaces the actions granted by an ACL.
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateACL
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ua, err := toBusUpdateACL(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	actorID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	acl, errEnc := a.queryACL(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	updACL, err := a.aclBus.Update(ctx, actorID, acl, ua)
+	if err != nil {
+		if errors.Is(err, aclbus.ErrNoActions) {
+			return errs.New(errs.InvalidArgument, aclbus.ErrNoActions).WithReason(errs.ReasonACLNoActions)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "update: aclID[%s] ua[%+v]: %s", acl.ID, ua, err)
+	}
+
+	return toAppACL(updACL)
+}
+
+// delete revokes an ACL.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	actorID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	acl, errEnc := a.queryACL(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.aclBus.Delete(ctx, actorID, acl); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "delete: aclID[%s]: %s", acl.ID, err)
+	}
+
+	return nil
+}
+
+// query returns a list of ACLs with paging. It also serves the per user and
+// per resource listings, which only add a path filter.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, aclbus.DefaultOrderBy)
+	if err != nil {
+		return errs.NewFieldErrors("order", err)
+	}
+
+	acls, err := a.aclBus.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query: %s", err)
+	}
+
+	total, err := a.aclBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "count: %s", err)
+	}
+
+	return query.NewResult(toAppACLs(acls), total, page)
+}
+
+// queryByID returns an ACL by its ID.
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	acl, errEnc := a.queryACL(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppACL(acl)
+}
+
+// queryHistory returns the audit trail of ACL changes with paging.
+func (a *app) queryHistory(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseHistoryQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseHistoryFilter(qp)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	history, err := a.aclBus.QueryHistory(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "queryhistory: %s", err)
+	}
+
+	total, err := a.aclBus.CountHistory(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "counthistory: %s", err)
+	}
+
+	return query.NewResult(toAppHistories(history), total, page)
+}
+
+// queryPermissions returns the effective permissions of the calling user so
+// clients can adapt the UI up front instead of relying on 403 responses.
+func (a *app) queryPermissions(ctx context.Context, _ *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	perms, err := a.aclBus.QueryPermissions(ctx, userID)
+	if err != nil {
+		if errors.Is(err, aclbus.ErrAccessDenied) {
+			return errs.New(errs.PermissionDenied, aclbus.ErrAccessDenied).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "querypermissions: userID[%s]: %s", userID, err)
+	}
+
+	return toAppPermissions(perms)
+}
+
+// check answers many access checks of the calling user at once, so a screen
+// can decide what to show without a request per button.
+func (a *app) check(ctx context.Context, r *http.Request) web.Encoder {
+	var app AccessChecks
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	checks, err := toBusAccessChecks(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	allowed, err := a.aclBus.ValidateAccessBatch(ctx, userID, checks)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "validateaccessbatch: userID[%s]: %s", userID, err)
+	}
+
+	return toAppAccessResults(checks, allowed)
+}
+
+// simulate explains the decision on an action of a user on a resource, with
+// the rule that allows it, so an access ticket is answered without reading
+// the tables.
+func (a *app) simulate(ctx context.Context, r *http.Request) web.Encoder {
+	var app Simulate
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, resourceID, action, err := toBusSimulate(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	if _, err := a.userBus.QueryByID(ctx, userID); err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "query user: userID[%s]: %s", userID, err)
+	}
+
+	if errEnc := a.checkTenant(ctx, userID, resourceID); errEnc != nil {
+		return errEnc
+	}
+
+	sim, err := a.aclBus.Simulate(ctx, userID, resourceID, action)
+	if err != nil {
+		if errors.Is(err, aclbus.ErrResourceNotFound) {
+			return errs.New(errs.NotFound, aclbus.ErrResourceNotFound).WithReason(errs.ReasonResourceNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "simulate: userID[%s] resourceID[%s]: %s", userID, resourceID, err)
+	}
+
+	return toAppSimulation(sim)
+}
+
+// queryTenantAccess returns every member of the tenant with the dashboard
+// links and ACL actions, so the access matrix is loaded in one call.
+func (a *app) queryTenantAccess(ctx context.Context, r *http.Request) web.Encoder {
+	tenantID, err := uuid.Parse(r.PathValue("tenant_id"))
+	if err != nil {
+		return errs.NewFieldErrors("tenant_id", err)
+	}
+
+	access, err := a.aclBus.QueryTenantAccess(ctx, tenantID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "querytenantaccess: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return toAppTenantAccess(tenantID, access)
+}
+
+// queryPolicies returns the actions every role is granted per resource type.
+func (a *app) queryPolicies(ctx context.Context, _ *http.Request) web.Encoder {
+	policies, err := a.aclBus.QueryPolicies(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "querypolicies: %s", err)
+	}
+
+	return toAppPolicies(policies)
+}
+
+// updatePolicy replaces the actions a role is granted on a resource type.
+func (a *app) updatePolicy(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdatePolicy
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	p, err := toBusPolicy(app, r.PathValue("role"), r.PathValue("resource_type"))
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	updPolicy, err := a.aclBus.UpdatePolicy(ctx, p)
+	if err != nil {
+		if errors.Is(err, aclbus.ErrAdminPolicy) {
+			return errs.New(errs.InvalidArgument, aclbus.ErrAdminPolicy).WithReason(errs.ReasonACLAdminPolicy)
+		}
+		if errors.Is(err, aclbus.ErrTenantAdminPolicy) {
+			return errs.New(errs.InvalidArgument, aclbus.ErrTenantAdminPolicy).WithReason(errs.ReasonACLTenantAdminPolicy)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "updatepolicy: p[%+v]: %s", p, err)
+	}
+
+	return toAppPolicy(updPolicy)
+}
+
+// queryACL loads the ACL referenced by the acl_id path parameter. Only the
+// ACLs of the tenant of the session are loaded for a TENANT_ADMIN.
+func (a *app) queryACL(ctx context.Context, r *http.Request) (aclbus.ACL, *errs.Error) {
+	aclID, err := uuid.Parse(r.PathValue("acl_id"))
+	if err != nil {
+		return aclbus.ACL{}, errs.New(errs.InvalidArgument, err)
+	}
+
+	acl, err := a.aclBus.QueryByID(ctx, aclID)
+	if err != nil {
+		if errors.Is(err, aclbus.ErrNotFound) {
+			return aclbus.ACL{}, errs.New(errs.NotFound, err).WithReason(errs.ReasonACLNotFound)
+		}
+		return aclbus.ACL{}, errs.Errorf(errs.InternalOnlyLog, "querybyid: aclID[%s]: %s", aclID, err)
+	}
+
+	if errEnc := a.checkTenant(ctx, acl.UserID, acl.ResourceID); errEnc != nil {
+		return aclbus.ACL{}, errEnc
+	}
+
+	return acl, nil
+}
+
+// checkTenant checks the user and the resource of an ACL belong to the
+// tenant of the session. The tenant of a resource is the tenant of the
+// dashboard at the top of its hierarchy. ADMINs pass for any tenant.
+func (a *app) checkTenant(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) *errs.Error {
+	if mid.IsAdmin(ctx) {
+		return nil
+	}
+
+	if errEnc := mid.CheckTenantUser(ctx, a.tenantBus, userID); errEnc != nil {
+		return errEnc
+	}
+
+	rootID, err := a.aclBus.QueryRoot(ctx, resourceID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "queryroot: resourceID[%s]: %s", resourceID, err)
+	}
+
+	td, err := a.tenantBus.ResolveDashboard(ctx, rootID)
+	if err != nil {
+		// Um recurso fora de qualquer dashboard não pertence a nenhum cliente.
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return errs.New(errs.PermissionDenied, fmt.Errorf("resource[%s] is not in a tenant", resourceID)).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "resolvedashboard: dashboardID[%s]: %s", rootID, err)
+	}
+
+	return mid.CheckTenant(ctx, td.TenantID)
+}
@@ -0,0 +1,185 @@
+package aclapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+)
+
+// queryParams struct interna para capturar os dados crus da URL.
+type queryParams struct {
+	Page         string
+	Rows         string
+	OrderBy      string
+	ID           string
+	UserID       string
+	ResourceID   string
+	ResourceType string
+}
+
+// parseQueryParams extrai os parâmetros da request. Os parâmetros de path
+// (/users/{user_id}/acl e /resources/{resource_id}/acl) têm precedência
+// sobre a query string.
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	qp := queryParams{
+		Page:         values.Get("page"),
+		Rows:         values.Get("rows"),
+		OrderBy:      values.Get("orderBy"),
+		ID:           values.Get("acl_id"),
+		UserID:       values.Get("user_id"),
+		ResourceID:   values.Get("resource_id"),
+		ResourceType: values.Get("resource_type"),
+	}
+
+	if v := r.PathValue("user_id"); v != "" {
+		qp.UserID = v
+	}
+
+	if v := r.PathValue("resource_id"); v != "" {
+		qp.ResourceID = v
+	}
+
+	return qp
+}
+
+// parseFilter valida e converte os parâmetros crus para o filtro de domínio.
+func parseFilter(qp queryParams) (aclbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter aclbus.QueryFilter
+
+	if qp.ID != "" {
+		id, err := uuid.Parse(qp.ID)
+		switch err {
+		case nil:
+			filter.ID = &id
+		default:
+			fieldErrors.Add("acl_id", err)
+		}
+	}
+
+	if qp.UserID != "" {
+		id, err := uuid.Parse(qp.UserID)
+		switch err {
+		case nil:
+			filter.UserID = &id
+		default:
+			fieldErrors.Add("user_id", err)
+		}
+	}
+
+	if qp.ResourceID != "" {
+		id, err := uuid.Parse(qp.ResourceID)
+		switch err {
+		case nil:
+			filter.ResourceID = &id
+		default:
+			fieldErrors.Add("resource_id", err)
+		}
+	}
+
+	if qp.ResourceType != "" {
+		rt, err := resource.Parse(qp.ResourceType)
+		switch err {
+		case nil:
+			filter.ResourceType = &rt
+		default:
+			fieldErrors.Add("resource_type", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return aclbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
+
+// historyQueryParams captura os parâmetros crus da consulta de auditoria.
+type historyQueryParams struct {
+	Page             string
+	Rows             string
+	ACLID            string
+	UserID           string
+	ResourceID       string
+	ActorID          string
+	StartCreatedDate string
+	EndCreatedDate   string
+}
+
+func parseHistoryQueryParams(r *http.Request) historyQueryParams {
+	values := r.URL.Query()
+
+	return historyQueryParams{
+		Page:             values.Get("page"),
+		Rows:             values.Get("rows"),
+		ACLID:            values.Get("acl_id"),
+		UserID:           values.Get("user_id"),
+		ResourceID:       values.Get("resource_id"),
+		ActorID:          values.Get("actor_id"),
+		StartCreatedDate: values.Get("start_created_date"),
+		EndCreatedDate:   values.Get("end_created_date"),
+	}
+}
+
+// parseHistoryFilter valida e converte os parâmetros crus para o filtro de auditoria.
+func parseHistoryFilter(qp historyQueryParams) (aclbus.HistoryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter aclbus.HistoryFilter
+
+	ids := []struct {
+		field string
+		value string
+		dest  **uuid.UUID
+	}{
+		{"acl_id", qp.ACLID, &filter.ACLID},
+		{"user_id", qp.UserID, &filter.UserID},
+		{"resource_id", qp.ResourceID, &filter.ResourceID},
+		{"actor_id", qp.ActorID, &filter.ActorID},
+	}
+
+	for _, id := range ids {
+		if id.value == "" {
+			continue
+		}
+
+		v, err := uuid.Parse(id.value)
+		switch err {
+		case nil:
+			*id.dest = &v
+		default:
+			fieldErrors.Add(id.field, err)
+		}
+	}
+
+	if qp.StartCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.StartCreatedDate)
+		switch err {
+		case nil:
+			filter.StartCreatedAt = &t
+		default:
+			fieldErrors.Add("start_created_date", err)
+		}
+	}
+
+	if qp.EndCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.EndCreatedDate)
+		switch err {
+		case nil:
+			filter.EndCreatedAt = &t
+		default:
+			fieldErrors.Add("end_created_date", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return aclbus.HistoryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
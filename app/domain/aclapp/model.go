@@ -0,0 +1,664 @@
+package aclapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// =============================================================================
+// ACL (Output)
+// =============================================================================
+
+// ACL represents the actions a user was granted on a resource.
+type ACL struct {
+	ID           string   `json:"id"`
+	UserID       string   `json:"userId"`
+	ResourceID   string   `json:"resourceId"`
+	ResourceType string   `json:"resourceType"`
+	Actions      []string `json:"actions"`
+	ExpiresAt    string   `json:"expiresAt,omitempty"`
+	DateCreated  string   `json:"dateCreated"`
+	DateUpdated  string   `json:"dateUpdated"`
+}
+
+// Encode implements the web.Encoder interface.
+func (a ACL) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(a)
+	return data, "application/json", err
+}
+
+func toAppACL(bus aclbus.ACL) ACL {
+	var expiresAt string
+	if bus.ExpiresAt != nil {
+		expiresAt = bus.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return ACL{
+		ID:           bus.ID.String(),
+		UserID:       bus.UserID.String(),
+		ResourceID:   bus.ResourceID.String(),
+		ResourceType: bus.ResourceType.String(),
+		Actions:      toAppActions(bus.Actions),
+		ExpiresAt:    expiresAt,
+		DateCreated:  bus.CreatedAt.Format(time.RFC3339),
+		DateUpdated:  bus.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toAppACLs(acls []aclbus.ACL) []ACL {
+	app := make([]ACL, len(acls))
+	for i, acl := range acls {
+		app[i] = toAppACL(acl)
+	}
+	return app
+}
+
+// =============================================================================
+// NewACL (Input)
+// =============================================================================
+
+// NewACL defines the data needed to grant access to a resource.
+type NewACL struct {
+	UserID     string   `json:"userId" validate:"required,uuid"`
+	ResourceID string   `json:"resourceId" validate:"required,uuid"`
+	Actions    []string `json:"actions" validate:"required,min=1"`
+	ExpiresAt  *string  `json:"expiresAt"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewACL) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewACL) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewACL(app NewACL) (aclbus.NewACL, error) {
+	userID, err := uuid.Parse(app.UserID)
+	if err != nil {
+		return aclbus.NewACL{}, fmt.Errorf("parse userId: %w", err)
+	}
+
+	resourceID, err := uuid.Parse(app.ResourceID)
+	if err != nil {
+		return aclbus.NewACL{}, fmt.Errorf("parse resourceId: %w", err)
+	}
+
+	acts, err := parseActions(app.Actions)
+	if err != nil {
+		return aclbus.NewACL{}, err
+	}
+
+	var expiresAt *time.Time
+	if app.ExpiresAt != nil {
+		t, err := time.Parse(time.RFC3339, *app.ExpiresAt)
+		if err != nil {
+			return aclbus.NewACL{}, fmt.Errorf("parse expiresAt: %w", err)
+		}
+		expiresAt = &t
+	}
+
+	bus := aclbus.NewACL{
+		UserID:     userID,
+		ResourceID: resourceID,
+		Actions:    acts,
+		ExpiresAt:  expiresAt,
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+// UpdateACL (Input)
+// =============================================================================
+
+// UpdateACL defines the data needed to update the actions of an ACL.
+type UpdateACL struct {
+	Actions []string `json:"actions" validate:"required,min=1"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdateACL) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateACL) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdateACL(app UpdateACL) (aclbus.UpdateACL, error) {
+	acts, err := parseActions(app.Actions)
+	if err != nil {
+		return aclbus.UpdateACL{}, err
+	}
+
+	bus := aclbus.UpdateACL{
+		Actions: acts,
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+// AccessChecks (Input)
+// =============================================================================
+
+// AccessCheck defines an action to check on a resource.
+type AccessCheck struct {
+	ResourceID string `json:"resourceId" validate:"required,uuid"`
+	Action     string `json:"action" validate:"required"`
+}
+
+// AccessChecks defines the actions the calling user wants to check at once.
+type AccessChecks struct {
+	Checks []AccessCheck `json:"checks" validate:"required,min=1,max=100,dive"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *AccessChecks) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AccessChecks) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusAccessChecks(app AccessChecks) ([]aclbus.AccessCheck, error) {
+	bus := make([]aclbus.AccessCheck, len(app.Checks))
+	for i, chk := range app.Checks {
+		resourceID, err := uuid.Parse(chk.ResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("parse checks[%d].resourceId: %w", i, err)
+		}
+
+		a, err := actions.Parse(chk.Action)
+		if err != nil {
+			return nil, fmt.Errorf("parse checks[%d].action: %w", i, err)
+		}
+
+		bus[i] = aclbus.AccessCheck{
+			ResourceID: resourceID,
+			Action:     a,
+		}
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+// AccessResults (Output)
+// =============================================================================
+
+// AccessResult represents whether an action is allowed on a resource.
+type AccessResult struct {
+	ResourceID string `json:"resourceId"`
+	Action     string `json:"action"`
+	Allowed    bool   `json:"allowed"`
+}
+
+// AccessResults represents the results of the checks, in the order asked.
+type AccessResults struct {
+	Results []AccessResult `json:"results"`
+}
+
+// Encode implements the web.Encoder interface.
+func (ar AccessResults) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(ar)
+	return data, "application/json", err
+}
+
+func toAppAccessResults(checks []aclbus.AccessCheck, allowed map[aclbus.AccessCheck]bool) AccessResults {
+	results := make([]AccessResult, len(checks))
+	for i, chk := range checks {
+		results[i] = AccessResult{
+			ResourceID: chk.ResourceID.String(),
+			Action:     chk.Action.String(),
+			Allowed:    allowed[chk],
+		}
+	}
+
+	return AccessResults{
+		Results: results,
+	}
+}
+
+// =============================================================================
+// Simulate (Input)
+// =============================================================================
+
+// Simulate defines the action of a user on a resource to explain.
+type Simulate struct {
+	UserID     string `json:"userId" validate:"required,uuid"`
+	ResourceID string `json:"resourceId" validate:"required,uuid"`
+	Action     string `json:"action" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *Simulate) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app Simulate) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusSimulate(app Simulate) (uuid.UUID, uuid.UUID, actions.Action, error) {
+	userID, err := uuid.Parse(app.UserID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, actions.Action{}, fmt.Errorf("parse userId: %w", err)
+	}
+
+	resourceID, err := uuid.Parse(app.ResourceID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, actions.Action{}, fmt.Errorf("parse resourceId: %w", err)
+	}
+
+	a, err := actions.Parse(app.Action)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, actions.Action{}, fmt.Errorf("parse action: %w", err)
+	}
+
+	return userID, resourceID, a, nil
+}
+
+// =============================================================================
+// Simulation (Output)
+// =============================================================================
+
+// SimulationStep represents a rule evaluated to reach the decision.
+type SimulationStep struct {
+	Rule       string   `json:"rule"`
+	Matched    bool     `json:"matched"`
+	Actions    []string `json:"actions,omitempty"`
+	ACLID      string   `json:"aclId,omitempty"`
+	ResourceID string   `json:"resourceId,omitempty"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	Expired    bool     `json:"expired,omitempty"`
+}
+
+// Simulation represents the decision on an action of a user on a resource
+// and the rules that lead to it.
+type Simulation struct {
+	UserID       string           `json:"userId"`
+	ResourceID   string           `json:"resourceId"`
+	ResourceType string           `json:"resourceType"`
+	Action       string           `json:"action"`
+	Role         string           `json:"role"`
+	Allowed      bool             `json:"allowed"`
+	Rule         string           `json:"rule,omitempty"`
+	Steps        []SimulationStep `json:"steps"`
+}
+
+// Encode implements the web.Encoder interface.
+func (s Simulation) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(s)
+	return data, "application/json", err
+}
+
+func toAppSimulation(bus aclbus.Simulation) Simulation {
+	steps := make([]SimulationStep, len(bus.Steps))
+	for i, st := range bus.Steps {
+		step := SimulationStep{
+			Rule:    st.Rule,
+			Matched: st.Matched,
+			Actions: toAppActions(st.Actions),
+			Expired: st.Expired,
+		}
+
+		if st.ACLID != nil {
+			step.ACLID = st.ACLID.String()
+		}
+
+		if st.ResourceID != nil {
+			step.ResourceID = st.ResourceID.String()
+		}
+
+		if st.ExpiresAt != nil {
+			step.ExpiresAt = st.ExpiresAt.Format(time.RFC3339)
+		}
+
+		steps[i] = step
+	}
+
+	return Simulation{
+		UserID:       bus.UserID.String(),
+		ResourceID:   bus.ResourceID.String(),
+		ResourceType: bus.ResourceType.String(),
+		Action:       bus.Action.String(),
+		Role:         bus.Role.String(),
+		Allowed:      bus.Allowed,
+		Rule:         bus.Rule,
+		Steps:        steps,
+	}
+}
+
+// =============================================================================
+
+func parseActions(values []string) ([]actions.Action, error) {
+	acts := make([]actions.Action, len(values))
+	for i, v := range values {
+		a, err := actions.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse actions: %w", err)
+		}
+		acts[i] = a
+	}
+
+	return acts, nil
+}
+
+// =============================================================================
+// Permissions (Output)
+// =============================================================================
+
+// TypePermission represents the actions granted on every resource of a type.
+type TypePermission struct {
+	ResourceType string   `json:"resourceType"`
+	Actions      []string `json:"actions"`
+}
+
+// ResourcePermission represents the actions granted on a specific resource.
+type ResourcePermission struct {
+	ResourceID   string   `json:"resourceId"`
+	ResourceType string   `json:"resourceType"`
+	Actions      []string `json:"actions"`
+}
+
+// Permissions represents the effective permissions of the calling user.
+type Permissions struct {
+	UserID    string               `json:"userId"`
+	Role      string               `json:"role"`
+	Types     []TypePermission     `json:"types"`
+	Resources []ResourcePermission `json:"resources"`
+}
+
+// Encode implements the web.Encoder interface.
+func (p Permissions) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(p)
+	return data, "application/json", err
+}
+
+func toAppPermissions(bus aclbus.Permissions) Permissions {
+	types := make([]TypePermission, len(bus.Types))
+	for i, tp := range bus.Types {
+		types[i] = TypePermission{
+			ResourceType: tp.ResourceType.String(),
+			Actions:      toAppActions(tp.Actions),
+		}
+	}
+
+	resources := make([]ResourcePermission, len(bus.Resources))
+	for i, acl := range bus.Resources {
+		resources[i] = ResourcePermission{
+			ResourceID:   acl.ResourceID.String(),
+			ResourceType: acl.ResourceType.String(),
+			Actions:      toAppActions(acl.Actions),
+		}
+	}
+
+	return Permissions{
+		UserID:    bus.UserID.String(),
+		Role:      bus.Role.String(),
+		Types:     types,
+		Resources: resources,
+	}
+}
+
+func toAppActions(acts []actions.Action) []string {
+	app := make([]string, len(acts))
+	for i, a := range acts {
+		app[i] = a.String()
+	}
+	return app
+}
+
+// =============================================================================
+// Policy (Output)
+// =============================================================================
+
+// Policy represents the actions a role is granted on a resource type.
+type Policy struct {
+	Role         string   `json:"role"`
+	ResourceType string   `json:"resourceType"`
+	Actions      []string `json:"actions"`
+}
+
+// Encode implements the web.Encoder interface.
+func (p Policy) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(p)
+	return data, "application/json", err
+}
+
+// Policies is a collection of role policies.
+type Policies []Policy
+
+// Encode implements the web.Encoder interface.
+func (p Policies) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(p)
+	return data, "application/json", err
+}
+
+func toAppPolicy(bus aclbus.Policy) Policy {
+	return Policy{
+		Role:         bus.Role.String(),
+		ResourceType: bus.ResourceType.String(),
+		Actions:      toAppActions(bus.Actions),
+	}
+}
+
+func toAppPolicies(bus []aclbus.Policy) Policies {
+	app := make(Policies, len(bus))
+	for i, p := range bus {
+		app[i] = toAppPolicy(p)
+	}
+	return app
+}
+
+// =============================================================================
+// UpdatePolicy (Input)
+// =============================================================================
+
+// UpdatePolicy defines the actions a role is granted on a resource type. An
+// empty list revokes every action.
+type UpdatePolicy struct {
+	Actions []string `json:"actions" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdatePolicy) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdatePolicy) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusPolicy(app UpdatePolicy, roleName string, resourceType string) (aclbus.Policy, error) {
+	r, err := role.Parse(roleName)
+	if err != nil {
+		return aclbus.Policy{}, fmt.Errorf("parse role: %w", err)
+	}
+
+	rt, err := resource.Parse(resourceType)
+	if err != nil {
+		return aclbus.Policy{}, fmt.Errorf("parse resource type: %w", err)
+	}
+
+	acts, err := parseActions(app.Actions)
+	if err != nil {
+		return aclbus.Policy{}, err
+	}
+
+	bus := aclbus.Policy{
+		Role:         r,
+		ResourceType: rt,
+		Actions:      acts,
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+// History (Output)
+// =============================================================================
+
+// History represents an audit record of a change made to an ACL.
+type History struct {
+	ID          string   `json:"id"`
+	ACLID       string   `json:"aclId"`
+	UserID      string   `json:"userId"`
+	ResourceID  string   `json:"resourceId"`
+	ActorID     string   `json:"actorId"`
+	Operation   string   `json:"operation"`
+	OldActions  []string `json:"oldActions"`
+	NewActions  []string `json:"newActions"`
+	DateCreated string   `json:"dateCreated"`
+}
+
+func toAppHistory(bus aclbus.History) History {
+	return History{
+		ID:          bus.ID.String(),
+		ACLID:       bus.ACLID.String(),
+		UserID:      bus.UserID.String(),
+		ResourceID:  bus.ResourceID.String(),
+		ActorID:     bus.ActorID.String(),
+		Operation:   bus.Operation.String(),
+		OldActions:  toAppActions(bus.OldActions),
+		NewActions:  toAppActions(bus.NewActions),
+		DateCreated: bus.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toAppHistories(bus []aclbus.History) []History {
+	app := make([]History, len(bus))
+	for i, h := range bus {
+		app[i] = toAppHistory(h)
+	}
+	return app
+}
+
+// =============================================================================
+// TenantAccess (Output)
+// =============================================================================
+
+// DashboardAccess represents the access of a user to a dashboard of the
+// tenant. Actions are the ones granted by the ACL on the dashboard.
+type DashboardAccess struct {
+	DashboardID string   `json:"dashboardId"`
+	Name        string   `json:"name"`
+	Linked      bool     `json:"linked"`
+	Actions     []string `json:"actions"`
+	ExpiresAt   string   `json:"expiresAt,omitempty"`
+}
+
+// UserAccess represents a member of the tenant with the access to each
+// dashboard of the tenant.
+type UserAccess struct {
+	UserID     string            `json:"userId"`
+	Name       string            `json:"name"`
+	Email      string            `json:"email"`
+	Role       string            `json:"role"`
+	Enabled    bool              `json:"enabled"`
+	Dashboards []DashboardAccess `json:"dashboards"`
+}
+
+// TenantAccess is the access matrix of a tenant.
+type TenantAccess struct {
+	TenantID string       `json:"tenantId"`
+	Users    []UserAccess `json:"users"`
+}
+
+// Encode implements the web.Encoder interface.
+func (ta TenantAccess) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(ta)
+	return data, "application/json", err
+}
+
+func toAppTenantAccess(tenantID uuid.UUID, bus []aclbus.UserAccess) TenantAccess {
+	users := make([]UserAccess, len(bus))
+	for i, ua := range bus {
+		dashboards := make([]DashboardAccess, len(ua.Dashboards))
+		for j, da := range ua.Dashboards {
+			var expiresAt string
+			if da.ExpiresAt != nil {
+				expiresAt = da.ExpiresAt.Format(time.RFC3339)
+			}
+
+			dashboards[j] = DashboardAccess{
+				DashboardID: da.DashboardID.String(),
+				Name:        da.Name,
+				Linked:      da.Linked,
+				Actions:     toAppActions(da.Actions),
+				ExpiresAt:   expiresAt,
+			}
+		}
+
+		users[i] = UserAccess{
+			UserID:     ua.UserID.String(),
+			Name:       ua.Name,
+			Email:      ua.Email,
+			Role:       ua.Role.String(),
+			Enabled:    ua.Enabled,
+			Dashboards: dashboards,
+		}
+	}
+
+	return TenantAccess{
+		TenantID: tenantID.String(),
+		Users:    users,
+	}
+}
+
+// =============================================================================
+// Notifications
+// =============================================================================
+
+// accessGranted builds the notification telling the user an ACL was granted.
+func accessGranted(actorID uuid.UUID, acl aclbus.ACL) notificationbus.NewNotification {
+	data := map[string]any{
+		"actorId": actorID.String(),
+		"actions": toAppActions(acl.Actions),
+	}
+
+	if acl.ExpiresAt != nil {
+		data["expiresAt"] = acl.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return notificationbus.NewNotification{
+		UserID:     acl.UserID,
+		Kind:       notificationbus.KindAccessGranted,
+		EntityType: acl.ResourceType.String(),
+		EntityID:   acl.ResourceID,
+		Data:       data,
+	}
+}
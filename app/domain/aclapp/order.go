@@ -0,0 +1,13 @@
+package aclapp
+
+import (
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+)
+
+var orderByFields = map[string]string{
+	"acl_id":        aclbus.OrderByID,
+	"user_id":       aclbus.OrderByUserID,
+	"resource_id":   aclbus.OrderByResourceID,
+	"resource_type": aclbus.OrderByResourceType,
+	"created_at":    aclbus.OrderByCreatedAt,
+}
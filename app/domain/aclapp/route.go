@@ -0,0 +1,68 @@
+package aclapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log             *logger.Logger
+	DB              *sqlx.DB
+	Auth            *auth.Auth
+	ACLBus          *aclbus.Core
+	UserBus         *userbus.Core
+	TenantBus       *tenantbus.Core
+	NotificationBus *notificationbus.Core
+	RateLimiter     ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+//
+// The parameter is not named app since route handlers that run inside a
+// transaction are referenced through the app type, e.g. (*app).create.
+func Routes(a *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+
+	// O TENANT_ADMIN gerencia os acessos aos recursos do cliente da sessão;
+	// as listagens gerais e as políticas por perfil ficam só com o ADMIN.
+	manage := mid.Authorize(cfg.Auth, role.Admin, role.TenantAdmin)
+
+	api := newApp(cfg.ACLBus, cfg.UserBus, cfg.TenantBus, cfg.NotificationBus)
+
+	a.HandlerFunc(http.MethodGet, version, "/acl", api.query, authen, limit, admin)
+	a.HandlerFunc(http.MethodGet, version, "/acl/history", api.queryHistory, authen, limit, admin)
+	a.HandlerFunc(http.MethodGet, version, "/acl/{acl_id}", api.queryByID, authen, limit, manage)
+	a.HandlerFunc(http.MethodPost, version, "/acl", mid.WithTran(api.newWithTx, (*app).create), authen, limit, manage, transaction)
+	a.HandlerFunc(http.MethodPut, version, "/acl/{acl_id}", mid.WithTran(api.newWithTx, (*app).update), authen, limit, manage, transaction)
+	a.HandlerFunc(http.MethodDelete, version, "/acl/{acl_id}", mid.WithTran(api.newWithTx, (*app).delete), authen, limit, manage, transaction)
+
+	a.HandlerFunc(http.MethodGet, version, "/role-policies", api.queryPolicies, authen, limit, admin)
+	a.HandlerFunc(http.MethodPut, version, "/role-policies/{role}/{resource_type}", api.updatePolicy, authen, limit, admin)
+
+	a.HandlerFunc(http.MethodGet, version, "/users/me/permissions", api.queryPermissions, authen, limit)
+	a.HandlerFunc(http.MethodPost, version, "/acl/check", api.check, authen, limit)
+	a.HandlerFunc(http.MethodPost, version, "/acl/simulate", api.simulate, authen, limit, manage)
+
+	a.HandlerFunc(http.MethodGet, version, "/users/{user_id}/acl", api.query, authen, limit, manage, mid.AuthorizeTenantUser(cfg.TenantBus, "user_id"))
+	a.HandlerFunc(http.MethodGet, version, "/resources/{resource_id}/acl", api.query, authen, limit, admin)
+
+	a.HandlerFunc(http.MethodGet, version, "/tenants/{tenant_id}/access", api.queryTenantAccess, authen, limit, manage, mid.AuthorizeTenant("tenant_id"))
+}
@@ -0,0 +1,53 @@
+// Package activityapp maintains the app layer api for the activity domain.
+package activityapp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	activityBus *activitybus.Core
+}
+
+func newApp(activityBus *activitybus.Core) *app {
+	return &app{
+		activityBus: activityBus,
+	}
+}
+
+// query returns the activity feed with paging, most recent first.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	activities, err := a.activityBus.Query(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "query: %s", err)
+	}
+
+	total, err := a.activityBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "count: %s", err)
+	}
+
+	return query.NewResult(toAppActivities(activities), total, page)
+}
@@ -0,0 +1,89 @@
+package activityapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+)
+
+type queryParams struct {
+	Page             string
+	Rows             string
+	UserID           string
+	TenantID         string
+	Action           string
+	StartCreatedDate string
+	EndCreatedDate   string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	return queryParams{
+		Page:             values.Get("page"),
+		Rows:             values.Get("rows"),
+		UserID:           values.Get("user_id"),
+		TenantID:         values.Get("tenant_id"),
+		Action:           values.Get("action"),
+		StartCreatedDate: values.Get("start_created_date"),
+		EndCreatedDate:   values.Get("end_created_date"),
+	}
+}
+
+func parseFilter(qp queryParams) (activitybus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter activitybus.QueryFilter
+
+	if qp.UserID != "" {
+		id, err := uuid.Parse(qp.UserID)
+		switch err {
+		case nil:
+			filter.UserID = &id
+		default:
+			fieldErrors.Add("user_id", err)
+		}
+	}
+
+	if qp.TenantID != "" {
+		id, err := uuid.Parse(qp.TenantID)
+		switch err {
+		case nil:
+			filter.TenantID = &id
+		default:
+			fieldErrors.Add("tenant_id", err)
+		}
+	}
+
+	if qp.Action != "" {
+		filter.Action = &qp.Action
+	}
+
+	if qp.StartCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.StartCreatedDate)
+		switch err {
+		case nil:
+			filter.StartCreatedAt = &t
+		default:
+			fieldErrors.Add("start_created_date", err)
+		}
+	}
+
+	if qp.EndCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.EndCreatedDate)
+		switch err {
+		case nil:
+			filter.EndCreatedAt = &t
+		default:
+			fieldErrors.Add("end_created_date", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return activitybus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,47 @@
+package activityapp
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+)
+
+// Activity represents a high-level action taken by a user.
+type Activity struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userId"`
+	TenantID    string `json:"tenantId,omitempty"`
+	Action      string `json:"action"`
+	EntityType  string `json:"entityType"`
+	EntityID    string `json:"entityId,omitempty"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAppActivity(bus activitybus.Activity) Activity {
+	a := Activity{
+		ID:          bus.ID.String(),
+		UserID:      bus.UserID.String(),
+		Action:      bus.Action,
+		EntityType:  bus.EntityType,
+		DateCreated: bus.CreatedAt.Format(time.RFC3339),
+	}
+
+	if bus.TenantID != uuid.Nil {
+		a.TenantID = bus.TenantID.String()
+	}
+
+	if bus.EntityID != uuid.Nil {
+		a.EntityID = bus.EntityID.String()
+	}
+
+	return a
+}
+
+func toAppActivities(activities []activitybus.Activity) []Activity {
+	app := make([]Activity, len(activities))
+	for i, a := range activities {
+		app[i] = toAppActivity(a)
+	}
+	return app
+}
@@ -0,0 +1,32 @@
+package activityapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	ActivityBus *activitybus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, nil)
+
+	api := newApp(cfg.ActivityBus)
+
+	// GET /v1/activity
+	app.HandlerFunc(http.MethodGet, version, "/activity", api.query, authen, limit, mid.Authorize(cfg.Auth, role.Admin))
+}
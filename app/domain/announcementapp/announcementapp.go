@@ -0,0 +1,182 @@
+// Package announcementapp maintains the app layer api for the announcement
+// domain.
+package announcementapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type app struct {
+	announcementBus *announcementbus.Core
+}
+
+func newApp(announcementBus *announcementbus.Core) *app {
+	return &app{
+		announcementBus: announcementBus,
+	}
+}
+
+// queryActive returns the announcements shown now to the authenticated user,
+// for the banner of the dashboard.
+func (a *app) queryActive(ctx context.Context, r *http.Request) web.Encoder {
+	rl, err := role.Parse(mid.GetClaims(ctx).Role)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	// Os usuários sem tenant, como os administradores, só veem os avisos
+	// que não são direcionados a tenants.
+	tenantID, err := mid.GetTenantID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	announcements, err := a.announcementBus.QueryActive(ctx, tenantID, rl)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "queryActive: %s", err)
+	}
+
+	return toAppBanner(announcements)
+}
+
+// query returns the announcements with paging, the latest to start first.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	announcements, err := a.announcementBus.Query(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "query: %s", err)
+	}
+
+	total, err := a.announcementBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "count: %s", err)
+	}
+
+	return query.NewResult(toAppAnnouncements(announcements), total, page)
+}
+
+// queryByID returns an announcement.
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	ann, errEnc := a.queryAnnouncement(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppAnnouncement(ann)
+}
+
+// create publishes a new announcement.
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewAnnouncement
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	na, err := toBusNewAnnouncement(app, userID)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ann, err := a.announcementBus.Create(ctx, na)
+	if err != nil {
+		return toAppError(err, "create: title[%s]: %s", app.Title)
+	}
+
+	return toAppAnnouncement(ann)
+}
+
+// update modifies an announcement.
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateAnnouncement
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ua, err := toBusUpdateAnnouncement(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ann, errEnc := a.queryAnnouncement(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	updAnn, err := a.announcementBus.Update(ctx, ann, ua)
+	if err != nil {
+		return toAppError(err, "update: announcementID[%s]: %s", ann.ID.String())
+	}
+
+	return toAppAnnouncement(updAnn)
+}
+
+// delete removes an announcement.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	ann, errEnc := a.queryAnnouncement(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.announcementBus.Delete(ctx, ann); err != nil {
+		return toAppError(err, "delete: announcementID[%s]: %s", ann.ID.String())
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func (a *app) queryAnnouncement(ctx context.Context, r *http.Request) (announcementbus.Announcement, *errs.Error) {
+	id, err := uuid.Parse(web.Param(r, "announcement_id"))
+	if err != nil {
+		return announcementbus.Announcement{}, errs.NewFieldErrors("announcement_id", err)
+	}
+
+	ann, err := a.announcementBus.QueryByID(ctx, id)
+	if err != nil {
+		return announcementbus.Announcement{}, toAppError(err, "query: announcementID[%s]: %s", id.String())
+	}
+
+	return ann, nil
+}
+
+func toAppError(err error, format string, key string) *errs.Error {
+	switch {
+	case errors.Is(err, announcementbus.ErrNotFound):
+		return errs.New(errs.NotFound, announcementbus.ErrNotFound).WithReason(errs.ReasonAnnouncementNotFound)
+	case errors.Is(err, announcementbus.ErrInvalidSeverity), errors.Is(err, announcementbus.ErrInvalidWindow):
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	return errs.Errorf(errs.Internal, format, key, err)
+}
@@ -0,0 +1,67 @@
+package announcementapp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+)
+
+type queryParams struct {
+	Page     string
+	Rows     string
+	Active   string
+	TenantID string
+	Severity string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	return queryParams{
+		Page:     values.Get("page"),
+		Rows:     values.Get("rows"),
+		Active:   values.Get("active"),
+		TenantID: values.Get("tenant_id"),
+		Severity: values.Get("severity"),
+	}
+}
+
+func parseFilter(qp queryParams) (announcementbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter announcementbus.QueryFilter
+
+	if qp.Active != "" {
+		active, err := strconv.ParseBool(qp.Active)
+		switch {
+		case err != nil:
+			fieldErrors.Add("active", err)
+		case active:
+			now := time.Now()
+			filter.ActiveAt = &now
+		}
+	}
+
+	if qp.TenantID != "" {
+		id, err := uuid.Parse(qp.TenantID)
+		switch err {
+		case nil:
+			filter.TenantID = &id
+		default:
+			fieldErrors.Add("tenant_id", err)
+		}
+	}
+
+	if qp.Severity != "" {
+		filter.Severity = &qp.Severity
+	}
+
+	if fieldErrors != nil {
+		return announcementbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
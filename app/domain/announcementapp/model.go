@@ -0,0 +1,286 @@
+package announcementapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Announcement represents a message of the banner of the dashboard.
+type Announcement struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Severity  string   `json:"severity"`
+	TenantIDs []string `json:"tenantIds"`
+	Roles     []string `json:"roles"`
+	StartsAt  string   `json:"startsAt"`
+	EndsAt    string   `json:"endsAt,omitempty"`
+	Active    bool     `json:"active"`
+	CreatedBy string   `json:"createdBy,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (a Announcement) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(a)
+	return data, "application/json", err
+}
+
+func toAppAnnouncement(bus announcementbus.Announcement) Announcement {
+	tenantIDs := make([]string, len(bus.TenantIDs))
+	for i, id := range bus.TenantIDs {
+		tenantIDs[i] = id.String()
+	}
+
+	roles := make([]string, len(bus.Roles))
+	for i, r := range bus.Roles {
+		roles[i] = r.String()
+	}
+
+	a := Announcement{
+		ID:        bus.ID.String(),
+		Title:     bus.Title,
+		Body:      bus.Body,
+		Severity:  bus.Severity,
+		TenantIDs: tenantIDs,
+		Roles:     roles,
+		StartsAt:  bus.StartsAt.Format(time.RFC3339),
+		Active:    bus.Active(time.Now()),
+		CreatedAt: bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: bus.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if bus.EndsAt != nil {
+		a.EndsAt = bus.EndsAt.Format(time.RFC3339)
+	}
+
+	if bus.CreatedBy != uuid.Nil {
+		a.CreatedBy = bus.CreatedBy.String()
+	}
+
+	return a
+}
+
+func toAppAnnouncements(bus []announcementbus.Announcement) []Announcement {
+	app := make([]Announcement, len(bus))
+	for i, a := range bus {
+		app[i] = toAppAnnouncement(a)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// Banner is the list of announcements shown now to the authenticated user.
+type Banner struct {
+	Items []BannerItem `json:"items"`
+}
+
+// BannerItem is an announcement as shown in the banner, without the
+// targeting only the admins need.
+type BannerItem struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	Severity string `json:"severity"`
+	StartsAt string `json:"startsAt"`
+	EndsAt   string `json:"endsAt,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (b Banner) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(b)
+	return data, "application/json", err
+}
+
+func toAppBanner(bus []announcementbus.Announcement) Banner {
+	items := make([]BannerItem, len(bus))
+	for i, a := range bus {
+		items[i] = BannerItem{
+			ID:       a.ID.String(),
+			Title:    a.Title,
+			Body:     a.Body,
+			Severity: a.Severity,
+			StartsAt: a.StartsAt.Format(time.RFC3339),
+		}
+
+		if a.EndsAt != nil {
+			items[i].EndsAt = a.EndsAt.Format(time.RFC3339)
+		}
+	}
+
+	return Banner{
+		Items: items,
+	}
+}
+
+// =============================================================================
+
+// NewAnnouncement defines the data needed to publish an announcement. Without
+// startsAt it starts now and without endsAt it lasts until ended.
+type NewAnnouncement struct {
+	Title     string   `json:"title" validate:"required,max=128"`
+	Body      string   `json:"body" validate:"max=4096"`
+	Severity  string   `json:"severity" validate:"omitempty,oneof=info warning critical"`
+	TenantIDs []string `json:"tenantIds" validate:"dive,uuid"`
+	Roles     []string `json:"roles"`
+	StartsAt  *string  `json:"startsAt"`
+	EndsAt    *string  `json:"endsAt"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewAnnouncement) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewAnnouncement) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewAnnouncement(app NewAnnouncement, createdBy uuid.UUID) (announcementbus.NewAnnouncement, error) {
+	tenantIDs, err := parseTenantIDs(app.TenantIDs)
+	if err != nil {
+		return announcementbus.NewAnnouncement{}, err
+	}
+
+	roles, err := parseRoles(app.Roles)
+	if err != nil {
+		return announcementbus.NewAnnouncement{}, err
+	}
+
+	bus := announcementbus.NewAnnouncement{
+		Title:     app.Title,
+		Body:      app.Body,
+		Severity:  app.Severity,
+		TenantIDs: tenantIDs,
+		Roles:     roles,
+		CreatedBy: createdBy,
+	}
+
+	if app.StartsAt != nil {
+		t, err := time.Parse(time.RFC3339, *app.StartsAt)
+		if err != nil {
+			return announcementbus.NewAnnouncement{}, fmt.Errorf("parse startsAt: %w", err)
+		}
+		bus.StartsAt = t
+	}
+
+	if app.EndsAt != nil {
+		t, err := time.Parse(time.RFC3339, *app.EndsAt)
+		if err != nil {
+			return announcementbus.NewAnnouncement{}, fmt.Errorf("parse endsAt: %w", err)
+		}
+		bus.EndsAt = &t
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+// UpdateAnnouncement defines the data needed to update an announcement. An
+// empty tenantIds or roles targets everyone.
+type UpdateAnnouncement struct {
+	Title     *string   `json:"title" validate:"omitempty,min=1,max=128"`
+	Body      *string   `json:"body" validate:"omitempty,max=4096"`
+	Severity  *string   `json:"severity" validate:"omitempty,oneof=info warning critical"`
+	TenantIDs *[]string `json:"tenantIds" validate:"omitempty,dive,uuid"`
+	Roles     *[]string `json:"roles"`
+	StartsAt  *string   `json:"startsAt"`
+	EndsAt    *string   `json:"endsAt"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdateAnnouncement) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateAnnouncement) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdateAnnouncement(app UpdateAnnouncement) (announcementbus.UpdateAnnouncement, error) {
+	bus := announcementbus.UpdateAnnouncement{
+		Title:    app.Title,
+		Body:     app.Body,
+		Severity: app.Severity,
+	}
+
+	if app.TenantIDs != nil {
+		tenantIDs, err := parseTenantIDs(*app.TenantIDs)
+		if err != nil {
+			return announcementbus.UpdateAnnouncement{}, err
+		}
+		bus.TenantIDs = &tenantIDs
+	}
+
+	if app.Roles != nil {
+		roles, err := parseRoles(*app.Roles)
+		if err != nil {
+			return announcementbus.UpdateAnnouncement{}, err
+		}
+		bus.Roles = &roles
+	}
+
+	if app.StartsAt != nil {
+		t, err := time.Parse(time.RFC3339, *app.StartsAt)
+		if err != nil {
+			return announcementbus.UpdateAnnouncement{}, fmt.Errorf("parse startsAt: %w", err)
+		}
+		bus.StartsAt = &t
+	}
+
+	if app.EndsAt != nil {
+		t, err := time.Parse(time.RFC3339, *app.EndsAt)
+		if err != nil {
+			return announcementbus.UpdateAnnouncement{}, fmt.Errorf("parse endsAt: %w", err)
+		}
+		bus.EndsAt = &t
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+func parseTenantIDs(values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(values))
+	for i, v := range values {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse tenantIds: %w", err)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+func parseRoles(values []string) ([]role.Role, error) {
+	roles := make([]role.Role, len(values))
+	for i, v := range values {
+		r, err := role.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse roles: %w", err)
+		}
+		roles[i] = r
+	}
+
+	return roles, nil
+}
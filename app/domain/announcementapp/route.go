@@ -0,0 +1,39 @@
+package announcementapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth            *auth.Auth
+	AnnouncementBus *announcementbus.Core
+	RateLimiter     ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, nil)
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+
+	api := newApp(cfg.AnnouncementBus)
+
+	// GET /v1/announcements/active
+	app.HandlerFunc(http.MethodGet, version, "/announcements/active", api.queryActive, authen, limit)
+
+	app.HandlerFunc(http.MethodGet, version, "/announcements", api.query, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/announcements", api.create, authen, limit, admin)
+	app.HandlerFunc(http.MethodGet, version, "/announcements/{announcement_id}", api.queryByID, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/announcements/{announcement_id}", api.update, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/announcements/{announcement_id}", api.delete, authen, limit, admin)
+}
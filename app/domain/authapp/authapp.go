@@ -6,26 +6,36 @@ import (
 	"fmt"
 	"net/http"
 	"net/mail"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
 	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
 type app struct {
-	auth      *auth.Auth
-	tenantBus *tenantbus.Core
+	auth        *auth.Auth
+	tenantBus   *tenantbus.Core
+	userBus     *userbus.Core
+	aclBus      *aclbus.Core
+	domainField bool
 }
 
 // newApp constructs a user app API for use.
-func newApp(auth *auth.Auth, tenantBus *tenantbus.Core, userBus *userbus.Core) *app {
+func newApp(cfg Config) *app {
 	return &app{
-		auth:      auth,
-		tenantBus: tenantBus,
+		auth:        cfg.Auth,
+		tenantBus:   cfg.TenantBus,
+		userBus:     cfg.UserBus,
+		aclBus:      cfg.ACLBus,
+		domainField: cfg.DomainField,
 	}
 }
 
@@ -42,41 +52,389 @@ func (a *app) login(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, fmt.Errorf("parsing email: %w", err))
 	}
 
-	usr, err := a.auth.Login(ctx, *addr, req.Password)
+	// O host já vem resolvido pelo middleware Proxy, que considera os
+	// cabeçalhos de encaminhamento apenas dos proxies confiáveis.
+	domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+	if a.domainField && req.Domain != "" {
+		domain = strings.ToLower(req.Domain)
+	}
+
+	// A falha de credenciais tem sempre o mesmo payload, exista ou não o
+	// e-mail; o detalhe fica apenas no erro interno.
+	usr, err := a.auth.Login(ctx, domain, *addr, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, userbus.ErrAuthenticationFailure):
+			return errs.New(errs.Unauthenticated, userbus.ErrAuthenticationFailure).WithReason(errs.ReasonAuthFailed)
+		case errors.Is(err, auth.ErrUserDisabled):
+			return errs.New(errs.PermissionDenied, auth.ErrUserDisabled).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "login: %s", err)
+	}
+
+	requested, err := parseDashboardID(req.DashboardID)
+	if err != nil {
+		return errs.NewFieldErrors("dashboardId", err)
+	}
+
+	td, errEnc := a.selectDashboard(ctx, usr, domain, requested)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	tokenStr, err := a.auth.GenerateToken(td.TenantID, usr.ID, td.DashboardID, usr.Role)
+	if err != nil {
+		if err != nil {
+			return errs.Errorf(errs.InternalOnlyLog, "GenerateToken: userID[%s] td[%+v]: %s", usr.ID, td, err)
+		}
+	}
+
+	// Só o login concluído entra no histórico, depois de gerado o token.
+	nl := userbus.NewLogin{
+		IP:        mid.GetClientIP(ctx),
+		UserAgent: r.UserAgent(),
+	}
+
+	if _, err := a.userBus.RecordLogin(ctx, usr, nl); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "RecordLogin: userID[%s]: %s", usr.ID, err)
+	}
+
+	return toAppToken(tokenStr)
+}
+
+// refresh reissues the token of the session with a new expiration. The
+// dashboard of the token is kept, unless another one of the client of the
+// domain is requested. The access is checked again, so a revoked dashboard
+// falls back to the default one.
+func (a *app) refresh(ctx context.Context, r *http.Request) web.Encoder {
+	var req Refresh
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	requested, err := parseDashboardID(req.DashboardID)
+	if err != nil {
+		return errs.NewFieldErrors("dashboardId", err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
 	if err != nil {
 		return errs.New(errs.Unauthenticated, err)
 	}
 
-	domain := auth.ExtractDomain(r.Host)
+	// O papel vem do banco, não do token: uma mudança de papel vale a partir
+	// da renovação.
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.Unauthenticated, userbus.ErrNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "querybyid: userID[%s]: %s", userID, err)
+	}
+
+	domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+	if a.domainField && req.Domain != "" {
+		domain = strings.ToLower(req.Domain)
+	}
+
+	current, _ := mid.GetDashboardID(ctx)
 
-	domain = "apexata.govsp.com"
+	td, errEnc := a.selectDashboard(ctx, usr, domain, requested, current)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	tokenStr, err := a.auth.GenerateToken(td.TenantID, usr.ID, td.DashboardID, usr.Role)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "GenerateToken: userID[%s] td[%+v]: %s", usr.ID, td, err)
+	}
+
+	return toAppToken(tokenStr)
+}
+
+// switchDashboard issues a token for another dashboard the caller can
+// access, in any tenant. A USER needs the dashboard granted; the other roles
+// need the view permission on it. Unlike the login, the token carries the
+// tenant of the dashboard for every role, the client the session works on.
+func (a *app) switchDashboard(ctx context.Context, r *http.Request) web.Encoder {
+	var req SwitchDashboard
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	dashboardID, err := uuid.Parse(req.DashboardID)
+	if err != nil {
+		return errs.NewFieldErrors("dashboardId", err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.Unauthenticated, userbus.ErrNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "querybyid: userID[%s]: %s", userID, err)
+	}
 
 	var td tenantbus.TenantDashboard
 
-	if usr.Role.Equal(role.User) {
-		td, err = a.tenantBus.AuthorizeUserAccessToDashboard(ctx, usr.ID, domain)
-		if err != nil {
-			return errs.New(errs.PermissionDenied, tenantbus.ErrAccessDenied)
+	switch {
+	case usr.Role.Equal(role.User):
+		td, err = a.tenantBus.AuthorizeDashboard(ctx, usr.ID, dashboardID)
+
+	default:
+		if err = a.aclBus.ValidateAccess(ctx, usr.ID, dashboardID, actions.Get); err == nil {
+			td, err = a.tenantBus.ResolveDashboard(ctx, dashboardID)
 		}
-	} else {
-		td, err = a.tenantBus.ResolveDomain(ctx, domain)
+	}
 
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrAccessDenied) || errors.Is(err, aclbus.ErrAccessDenied) || errors.Is(err, tenantbus.ErrNotFound) {
+			return errs.New(errs.PermissionDenied, tenantbus.ErrAccessDenied).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "authorize: userID[%s] dashboardID[%s]: %s", usr.ID, dashboardID, err)
+	}
+
+	tokenStr, err := a.auth.GenerateToken(td.TenantID, usr.ID, td.DashboardID, usr.Role)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "GenerateToken: userID[%s] td[%+v]: %s", usr.ID, td, err)
+	}
+
+	return toAppToken(tokenStr)
+}
+
+// selectDashboard picks the dashboard of the session on the domain. A
+// requested dashboard must be accessible, there is no fallback for it.
+// Otherwise the preferred dashboards are tried in order, then the default
+// dashboard of the user, then the dashboard published under the domain.
+// The USER and the TENANT_ADMIN carry their tenant in the token, the ADMIN
+// and the ANALYST carry none.
+func (a *app) selectDashboard(ctx context.Context, usr userbus.User, domain string, requested uuid.UUID, preferred ...uuid.UUID) (tenantbus.TenantDashboard, *errs.Error) {
+	if requested != uuid.Nil {
+		td, err := a.authorizeDashboard(ctx, usr, domain, requested)
 		if err != nil {
-			if errors.Is(err, tenantbus.ErrDomainNotFound) {
-				return errs.New(errs.NotFound, tenantbus.ErrDomainNotFound)
+			if errors.Is(err, tenantbus.ErrAccessDenied) || errors.Is(err, tenantbus.ErrDomainNotFound) {
+				return tenantbus.TenantDashboard{}, errs.New(errs.PermissionDenied, tenantbus.ErrAccessDenied).WithReason(errs.ReasonAccessDenied)
 			}
-			return errs.Errorf(errs.InternalOnlyLog, "ResolveDomain: userID[%s] domain[%s]: %s", usr.ID, domain, err)
+			return tenantbus.TenantDashboard{}, errs.Errorf(errs.InternalOnlyLog, "authorizeDashboard: userID[%s] dashboardID[%s]: %s", usr.ID, requested, err)
+		}
+		return td, nil
+	}
+
+	prefs, err := a.userBus.QueryPreferences(ctx, usr.ID)
+	if err != nil {
+		return tenantbus.TenantDashboard{}, errs.Errorf(errs.InternalOnlyLog, "queryPreferences: userID[%s]: %s", usr.ID, err)
+	}
+
+	// Um dashboard preferido que deixou de ser acessível, ou que é de outro
+	// cliente, é ignorado em silêncio.
+	for _, dashboardID := range append(preferred, prefs.DefaultDashboardID) {
+		if dashboardID == uuid.Nil {
+			continue
+		}
+
+		td, err := a.authorizeDashboard(ctx, usr, domain, dashboardID)
+		switch {
+		case err == nil:
+			return td, nil
+		case !errors.Is(err, tenantbus.ErrAccessDenied) && !errors.Is(err, tenantbus.ErrDomainNotFound):
+			return tenantbus.TenantDashboard{}, errs.Errorf(errs.InternalOnlyLog, "authorizeDashboard: userID[%s] dashboardID[%s]: %s", usr.ID, dashboardID, err)
+		}
+	}
+
+	if usr.Role.Equal(role.User) {
+		td, err := a.tenantBus.AuthorizeUserAccessToDashboard(ctx, usr.ID, domain)
+		if err != nil {
+			return tenantbus.TenantDashboard{}, errs.New(errs.PermissionDenied, tenantbus.ErrAccessDenied).WithReason(errs.ReasonAccessDenied)
+		}
+		return td, nil
+	}
+
+	td, err := a.tenantBus.ResolveDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrDomainNotFound) {
+			return tenantbus.TenantDashboard{}, errs.New(errs.NotFound, tenantbus.ErrDomainNotFound).WithReason(errs.ReasonDomainNotFound)
 		}
+		return tenantbus.TenantDashboard{}, errs.Errorf(errs.InternalOnlyLog, "ResolveDomain: userID[%s] domain[%s]: %s", usr.ID, domain, err)
+	}
+
+	td, err = a.sessionTenant(ctx, usr, td)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrAccessDenied) {
+			return tenantbus.TenantDashboard{}, errs.New(errs.PermissionDenied, tenantbus.ErrAccessDenied).WithReason(errs.ReasonAccessDenied)
+		}
+		return tenantbus.TenantDashboard{}, errs.Errorf(errs.InternalOnlyLog, "sessionTenant: userID[%s] domain[%s]: %s", usr.ID, domain, err)
+	}
 
+	return td, nil
+}
+
+// authorizeDashboard checks the user can work on the dashboard in the
+// context of the domain.
+func (a *app) authorizeDashboard(ctx context.Context, usr userbus.User, domain string, dashboardID uuid.UUID) (tenantbus.TenantDashboard, error) {
+	if usr.Role.Equal(role.User) {
+		return a.tenantBus.AuthorizeUserDashboard(ctx, usr.ID, domain, dashboardID)
+	}
+
+	td, err := a.tenantBus.ResolveDomainDashboard(ctx, domain, dashboardID)
+	if err != nil {
+		return tenantbus.TenantDashboard{}, err
+	}
+
+	return a.sessionTenant(ctx, usr, td)
+}
+
+// sessionTenant sets the tenant the token of a staff user carries for the
+// dashboard. The TENANT_ADMIN keeps the tenant it is a member of, which must
+// own the dashboard, while the ADMIN and the ANALYST work across tenants and
+// carry none.
+func (a *app) sessionTenant(ctx context.Context, usr userbus.User, td tenantbus.TenantDashboard) (tenantbus.TenantDashboard, error) {
+	if !usr.Role.Equal(role.TenantAdmin) {
 		td.TenantID = uuid.Nil
+		return td, nil
 	}
 
-	tokenStr, err := a.auth.GenerateToken(td.TenantID, usr.ID, td.DashboardID, usr.Role)
+	tenantID, err := a.tenantBus.QueryTenantIDByUserID(ctx, usr.ID)
 	if err != nil {
-		if err != nil {
-			return errs.Errorf(errs.InternalOnlyLog, "GenerateToken: userID[%s] td[%+v]: %s", usr.ID, td, err)
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return tenantbus.TenantDashboard{}, tenantbus.ErrAccessDenied
 		}
+		return tenantbus.TenantDashboard{}, fmt.Errorf("queryTenantIDByUserID: %w", err)
 	}
 
-	return toAppToken(tokenStr)
+	// O administrador de um cliente não entra pelo domínio de outro.
+	if tenantID != td.TenantID {
+		return tenantbus.TenantDashboard{}, tenantbus.ErrAccessDenied
+	}
+
+	return td, nil
+}
+
+// parseDashboardID parses an optional dashboard id.
+func parseDashboardID(s string) (uuid.UUID, error) {
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	return uuid.Parse(s)
+}
+
+// newWithTx returns a copy of the app with the buses bound to the
+// transaction of the request.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := a.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantBus, err := a.tenantBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	na := *a
+	na.userBus = userBus
+	na.tenantBus = tenantBus
+
+	return &na, nil
+}
+
+// register signs up a user through the domain of the request. The tenant of
+// the domain must have self registration enabled. The user is created
+// disabled, as a member of the tenant with access to the dashboard of the
+// domain, and is enabled once the email is confirmed.
+func (a *app) register(ctx context.Context, r *http.Request) web.Encoder {
+	var req Register
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	nu, err := toBusNewUser(req)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+	if a.domainField && req.Domain != "" {
+		domain = strings.ToLower(req.Domain)
+	}
+
+	td, err := a.tenantBus.ResolveDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrDomainNotFound) {
+			return errs.New(errs.NotFound, tenantbus.ErrDomainNotFound).WithReason(errs.ReasonDomainNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "resolvedomain: domain[%s]: %s", domain, err)
+	}
+
+	t, err := a.tenantBus.QueryByID(ctx, td.TenantID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "querybyid: tenantID[%s]: %s", td.TenantID, err)
+	}
+
+	if !t.Enabled || !t.SelfRegistration {
+		return errs.New(errs.PermissionDenied, fmt.Errorf("self registration disabled: tenantID[%s]", t.ID)).WithReason(errs.ReasonSignUpDisabled)
+	}
+
+	// Um e-mail já cadastrado recebe a mesma resposta, para o cadastro não
+	// revelar quem tem conta.
+	resp := Registration{
+		Email: nu.Email.Address,
+	}
+
+	scope, err := a.tenantBus.EmailScope(ctx, td.TenantID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "emailscope: tenantID[%s]: %s", td.TenantID, err)
+	}
+
+	nu.EmailScope = scope
+
+	_, err = a.userBus.QueryByEmail(ctx, scope, nu.Email)
+	switch {
+	case err == nil:
+		return resp
+	case !errors.Is(err, userbus.ErrNotFound):
+		return errs.Errorf(errs.InternalOnlyLog, "querybyemail: %s", err)
+	}
+
+	usr, err := a.userBus.Register(ctx, nu, domain)
+	if err != nil {
+		if errors.Is(err, userbus.ErrUniqueEmail) {
+			return errs.New(errs.Aborted, userbus.ErrUniqueEmail).WithReason(errs.ReasonUserEmailNotUnique)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "register: domain[%s]: %s", domain, err)
+	}
+
+	if err := a.tenantBus.GrantUserAccessToDashboard(ctx, usr.ID, td.DashboardID); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "grantuseraccesstodashboard: userID[%s] dashboardID[%s]: %s", usr.ID, td.DashboardID, err)
+	}
+
+	return resp
+}
+
+// confirmRegistration enables the user who owns the token sent to the email.
+// The user logs in afterwards through the domain of the registration.
+func (a *app) confirmRegistration(ctx context.Context, r *http.Request) web.Encoder {
+	var req ConfirmRegistration
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	if _, err := a.userBus.ConfirmRegistration(ctx, req.Token); err != nil {
+		if errors.Is(err, userbus.ErrInvalidToken) {
+			return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonInvalidToken)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "confirmregistration: %s", err)
+	}
+
+	return nil
 }
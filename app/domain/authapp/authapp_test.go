@@ -0,0 +1,297 @@
+package authapp_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/domain/authapp"
+	"github.com/jcpaschoal/spi-exata/app/domain/userapp"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/aclmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores/tenantmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/usermemory"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/keystore"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const pass = "Secr3t!pass"
+
+// TestTenantAdminUpdateRole logs a TENANT_ADMIN in through the domain of its
+// tenant and uses the token it gets to manage the users of the tenant.
+func TestTenantAdminUpdateRole(t *testing.T) {
+	f := newFixture(t)
+
+	token := f.login(t, "acme.example.com", "admin@acme.com")
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		role   role.Role
+		want   int
+	}{
+		{name: "member", userID: f.member, role: role.TenantAdmin, want: http.StatusOK},
+		{name: "outsider", userID: f.outsider, role: role.TenantAdmin, want: http.StatusForbidden},
+		{name: "admin", userID: f.member, role: role.Admin, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"role":%q}`, tt.role)
+
+			r := httptest.NewRequest(http.MethodPut, "http://acme.example.com/v1/users/"+tt.userID.String()+"/role", bytes.NewBufferString(body))
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+
+			f.mux.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestLoginDenied(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name   string
+		domain string
+		email  string
+	}{
+		{name: "otherDomain", domain: "other.example.com", email: "admin@acme.com"},
+		{name: "disabled", domain: "acme.example.com", email: "disabled@acme.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"email":"` + tt.email + `","password":"` + pass + `"}`
+
+			r := httptest.NewRequest(http.MethodPost, "http://"+tt.domain+"/v1/auth/login", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+
+			f.mux.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusForbidden, w.Body)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// fixture serves the auth and user routes over the memory stores. acme
+// publishes acme.example.com and has a TENANT_ADMIN, a disabled TENANT_ADMIN
+// and a USER; other publishes other.example.com and has a USER.
+type fixture struct {
+	mux *web.App
+
+	member   uuid.UUID
+	outsider uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	box, err := crypto.NewBox(make([]byte, crypto.KeySize))
+	if err != nil {
+		t.Fatalf("box: %s", err)
+	}
+
+	hasher, err := passhash.New(passhash.Config{Cost: 4, Workers: 1})
+	if err != nil {
+		t.Fatalf("hasher: %s", err)
+	}
+
+	dlg := delegate.New(log)
+	outbox := outboxbus.NewCore(log, outboxmemory.NewStore(), box)
+
+	tenantStore := tenantmemory.NewStore()
+	tenantBus := tenantbus.NewCore(log, dlg, tenantStore)
+	userBus := userbus.NewCore(usermemory.NewStore(), outbox, dlg, hasher)
+	aclBus := aclbus.NewCore(log, dlg, aclmemory.NewStore(), outbox)
+
+	newTenant := func(s string) uuid.UUID {
+		tn, err := tenantBus.Create(ctx, tenantbus.NewTenant{
+			Name: name.MustParse("Tenant"),
+			Slug: slug.MustParse(s),
+		})
+		if err != nil {
+			t.Fatalf("create tenant %s: %s", s, err)
+		}
+		tenantStore.AddDashboard(tn.ID, uuid.New(), s+".example.com")
+		return tn.ID
+	}
+
+	newUser := func(email string, rl role.Role, tenantID uuid.UUID) userbus.User {
+		usr, err := userBus.Create(ctx, userbus.NewUser{
+			Name:     name.MustParse("Test User"),
+			Email:    mail.Address{Address: email},
+			Phone:    phone.MustParseNull(""),
+			Role:     rl,
+			Password: password.MustParse(pass),
+		})
+		if err != nil {
+			t.Fatalf("create user %s: %s", email, err)
+		}
+		if err := tenantStore.AddUserToTenant(ctx, usr.ID, tenantID); err != nil {
+			t.Fatalf("add user %s: %s", email, err)
+		}
+		return usr
+	}
+
+	acme := newTenant("acme")
+	other := newTenant("other")
+
+	newUser("admin@acme.com", role.TenantAdmin, acme)
+
+	enabled := false
+	if _, err := userBus.Update(ctx, newUser("disabled@acme.com", role.TenantAdmin, acme), userbus.UpdateUser{Enabled: &enabled}); err != nil {
+		t.Fatalf("disable user: %s", err)
+	}
+
+	f := fixture{
+		member:   newUser("member@acme.com", role.User, acme).ID,
+		outsider: newUser("user@other.com", role.User, other).ID,
+	}
+
+	pem, err := keystore.GeneratePEM()
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+
+	ks := keystore.New()
+	doc, _ := json.Marshal(map[string]string{"key": "test", "pem": pem})
+	if _, err := ks.LoadByJSON(string(doc)); err != nil {
+		t.Fatalf("load key: %s", err)
+	}
+
+	ath := auth.New(auth.Config{
+		Log:       log,
+		UserBus:   userBus,
+		TenantBus: tenantBus,
+		KeyLookup: ks,
+		Issuer:    "test",
+		ActiveKID: "test",
+	})
+
+	// Os stores em memória ignoram a transação; o banco só precisa abrir e
+	// fechar uma.
+	db := sqlx.NewDb(sql.OpenDB(connector{}), "postgres")
+	t.Cleanup(func() { db.Close() })
+
+	f.mux = web.NewApp(log.Info, noop.NewTracerProvider().Tracer(""), mid.Proxy(nil), mid.Errors(log))
+
+	authapp.Routes(f.mux, authapp.Config{
+		Log:       log,
+		DB:        db,
+		Auth:      ath,
+		UserBus:   userBus,
+		TenantBus: tenantBus,
+		ACLBus:    aclBus,
+	})
+
+	userapp.Routes(f.mux, userapp.Config{
+		Log:           log,
+		DB:            db,
+		Auth:          ath,
+		UserBus:       userBus,
+		ACLBus:        aclBus,
+		ActivityBus:   activitybus.NewCore(log, activityStore{}),
+		TenantBus:     tenantBus,
+		RoleChangeBus: rolechangebus.NewCore(log, roleChangeStore{}, tenantBus),
+	})
+
+	return f
+}
+
+// login authenticates through the domain and returns the token issued.
+func (f fixture) login(t *testing.T, domain string, email string) string {
+	t.Helper()
+
+	body := `{"email":"` + email + `","password":"` + pass + `"}`
+
+	r := httptest.NewRequest(http.MethodPost, "http://"+domain+"/v1/auth/login", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	f.mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("login %s: got status %d: %s", email, w.Code, w.Body)
+	}
+
+	var tkn authapp.Token
+	if err := json.Unmarshal(w.Body.Bytes(), &tkn); err != nil {
+		t.Fatalf("unmarshal token: %s", err)
+	}
+
+	return tkn.Token
+}
+
+// activityStore discards the activities recorded.
+type activityStore struct {
+	activitybus.Storer
+}
+
+func (activityStore) Create(ctx context.Context, a activitybus.Activity) error {
+	return nil
+}
+
+// roleChangeStore stands in for the store the approvals are kept in, which
+// the changes between USER and TENANT_ADMIN never reach.
+type roleChangeStore struct {
+	rolechangebus.Storer
+}
+
+func (s roleChangeStore) NewWithTx(tx sqldb.CommitRollbacker) (rolechangebus.Storer, error) {
+	return s, nil
+}
+
+// connector opens connections that only begin, commit and roll back.
+type connector struct{}
+
+func (connector) Connect(ctx context.Context) (driver.Conn, error) { return conn{}, nil }
+func (connector) Driver() driver.Driver                            { return nil }
+
+type conn struct{}
+
+func (conn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (conn) Close() error                              { return nil }
+func (conn) Begin() (driver.Tx, error)                 { return conn{}, nil }
+func (conn) Commit() error                             { return nil }
+func (conn) Rollback() error                           { return nil }
@@ -3,8 +3,14 @@ package authapp
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/mail"
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
 type Token struct {
@@ -26,6 +32,14 @@ func toAppToken(token string) Token {
 type Login struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+
+	// Domain substitui o host da requisição apenas quando o campo está
+	// habilitado na configuração, para o desenvolvimento local.
+	Domain string `json:"domain"`
+
+	// DashboardID escolhe um dos dashboards do cliente do domínio no lugar
+	// do dashboard padrão do usuário.
+	DashboardID string `json:"dashboardId" validate:"omitempty,uuid"`
 }
 
 // Decode implements the web.Decoder interface.
@@ -40,3 +54,136 @@ func (app Login) Validate() error {
 	}
 	return nil
 }
+
+// =============================================================================
+
+// Refresh defines the data to reissue the token of the session. Without a
+// dashboard the token keeps the dashboard it was issued for.
+type Refresh struct {
+	Domain      string `json:"domain"`
+	DashboardID string `json:"dashboardId" validate:"omitempty,uuid"`
+}
+
+// Decode implements the web.Decoder interface. The body is optional.
+func (app *Refresh) Decode(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app Refresh) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+// SwitchDashboard defines the dashboard a session moves to.
+type SwitchDashboard struct {
+	DashboardID string `json:"dashboardId" validate:"required,uuid"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *SwitchDashboard) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app SwitchDashboard) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+// =============================================================================
+
+// Register defines the data needed to sign up through the domain of a
+// tenant. The user always gets the USER role.
+type Register struct {
+	Name            string `json:"name" validate:"required"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"passwordConfirm" validate:"eqfield=Password"`
+
+	// Domain substitui o host da requisição apenas quando o campo está
+	// habilitado na configuração, para o desenvolvimento local.
+	Domain string `json:"domain"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *Register) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app Register) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewUser(app Register) (userbus.NewUser, error) {
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse email: %w", err)
+	}
+
+	nme, err := name.Parse(app.Name)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse name: %w", err)
+	}
+
+	pass, err := password.Parse(app.Password)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse password: %w", err)
+	}
+
+	bus := userbus.NewUser{
+		Name:     nme,
+		Email:    *addr,
+		Role:     role.User,
+		Password: pass,
+	}
+
+	return bus, nil
+}
+
+// Registration is the answer to a sign up. It is the same whether or not the
+// email was already in use, so the endpoint does not reveal the accounts.
+type Registration struct {
+	Email string `json:"email"`
+}
+
+// Encode implements the web.Encoder interface.
+func (r Registration) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(r)
+	return data, "application/json", err
+}
+
+// HTTPStatus implements the web.httpStatus interface. The account is only
+// usable once the email is confirmed.
+func (r Registration) HTTPStatus() int {
+	return http.StatusAccepted
+}
+
+// ConfirmRegistration defines the token sent to the email of a new user.
+type ConfirmRegistration struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *ConfirmRegistration) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app ConfirmRegistration) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
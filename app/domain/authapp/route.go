@@ -4,28 +4,62 @@ import (
 	"net/http"
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Auth      *auth.Auth
-	UserBus   *userbus.Core
-	TenantBus *tenantbus.Core
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	Auth        *auth.Auth
+	UserBus     *userbus.Core
+	TenantBus   *tenantbus.Core
+	ACLBus      *aclbus.Core
+	RateLimiter ratelimit.Limiter
+
+	// DomainField lets the login payload choose the domain, for local
+	// development where every dashboard is served from localhost.
+	DomainField bool
 }
 
 // Routes adds specific routes for this group.
-func Routes(app *web.App, cfg Config) {
+//
+// The parameter is not named app since route handlers that run inside a
+// transaction are referenced through the app type, e.g. (*app).register.
+func Routes(a *web.App, cfg Config) {
 	const version = "v1"
 
 	// Middlewares
-	//authen := mid.Authenticate(cfg.Auth)
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	// O login não altera estado e carrega a senha, então fica fora da auditoria.
+	noAudit := mid.NoAudit()
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
 
 	// Instanciamos a API
-	api := newApp(cfg.Auth, cfg.TenantBus, cfg.UserBus)
+	api := newApp(cfg)
+
+	a.HandlerFunc(http.MethodPost, version, "/auth/login", api.login, limit, noAudit)
+
+	// A renovação reemite o token da sessão e permite trocar de dashboard
+	// dentro do cliente do domínio.
+	a.HandlerFunc(http.MethodPost, version, "/auth/refresh", api.refresh, authen, limit, noAudit)
 
-	app.HandlerFunc(http.MethodPost, version, "/auth/login", api.login)
+	// A troca de dashboard não depende do domínio: o ANALYST atende vários
+	// clientes e passa de um para outro sem novo login.
+	a.HandlerFunc(http.MethodPost, version, "/auth/switch-dashboard", api.switchDashboard, authen, limit)
 
+	// O auto-cadastro vale só nos domínios dos tenants que o habilitaram, e o
+	// usuário fica desabilitado até confirmar o e-mail.
+	a.HandlerFunc(http.MethodPost, version, "/auth/register", mid.WithTran(api.newWithTx, (*app).register), limit, noAudit, transaction)
+	a.HandlerFunc(http.MethodPost, version, "/auth/register/confirm", mid.WithTran(api.newWithTx, (*app).confirmRegistration), limit, transaction)
 }
@@ -0,0 +1,140 @@
+// Package brandingapp maintains the app layer api for the branding domain.
+package brandingapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	brandingBus *brandingbus.Core
+	tenantBus   *tenantbus.Core
+}
+
+func newApp(brandingBus *brandingbus.Core, tenantBus *tenantbus.Core) *app {
+	return &app{
+		brandingBus: brandingBus,
+		tenantBus:   tenantBus,
+	}
+}
+
+// save configures the branding of a tenant.
+func (a *app) save(ctx context.Context, r *http.Request) web.Encoder {
+	var app SaveBranding
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	b, err := a.brandingBus.Save(ctx, tenantID, toBusSaveBranding(app))
+	if err != nil {
+		return toAppError(err, "save: tenantID[%s]: %s", tenantID)
+	}
+
+	return toAppBranding(b)
+}
+
+// delete removes the branding of a tenant.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	b, errEnc := a.queryBranding(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.brandingBus.Delete(ctx, b); err != nil {
+		return toAppError(err, "delete: tenantID[%s]: %s", b.TenantID)
+	}
+
+	return nil
+}
+
+// query returns the branding of a tenant.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	b, errEnc := a.queryBranding(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return web.Validated(r, toAppBranding(b), b.UpdatedAt)
+}
+
+// public returns the branding of the domain to the login page. The domain
+// comes from the query string and defaults to the host of the request.
+func (a *app) public(ctx context.Context, r *http.Request) web.Encoder {
+	domain := r.URL.Query().Get("domain")
+	if domain == "" {
+		domain = auth.ExtractDomain(mid.GetHost(ctx))
+	}
+	domain = strings.ToLower(domain)
+
+	b, err := a.brandingBus.QueryByDomain(ctx, domain)
+	if err != nil {
+		// Domínio desconhecido e cliente sem identidade visual respondem
+		// igual: a página de login usa o visual padrão nos dois casos.
+		if errors.Is(err, tenantbus.ErrDomainNotFound) || errors.Is(err, brandingbus.ErrNotFound) {
+			return errs.New(errs.NotFound, brandingbus.ErrNotFound).WithReason(errs.ReasonBrandingNotFound)
+		}
+		return errs.Errorf(errs.Internal, "public: domain[%s]: %s", domain, err)
+	}
+
+	return web.Validated(r, toAppPublicBranding(ctx, b), b.UpdatedAt)
+}
+
+// =============================================================================
+
+func (a *app) queryTenantID(ctx context.Context, r *http.Request) (uuid.UUID, *errs.Error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	if _, err := a.tenantBus.QueryByID(ctx, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return uuid.Nil, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return uuid.Nil, errs.Errorf(errs.Internal, "query tenant: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return tenantID, nil
+}
+
+func (a *app) queryBranding(ctx context.Context, r *http.Request) (brandingbus.Branding, *errs.Error) {
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return brandingbus.Branding{}, errEnc
+	}
+
+	b, err := a.brandingBus.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return brandingbus.Branding{}, toAppError(err, "query: tenantID[%s]: %s", tenantID)
+	}
+
+	return b, nil
+}
+
+// toAppError translates the errors of the branding core. The format must
+// have a verb for id followed by one for the error.
+func toAppError(err error, format string, id uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, brandingbus.ErrNotFound):
+		return errs.New(errs.NotFound, brandingbus.ErrNotFound).WithReason(errs.ReasonBrandingNotFound)
+	case errors.Is(err, brandingbus.ErrInvalidSettings):
+		return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonBrandingInvalid)
+	}
+
+	return errs.Errorf(errs.Internal, format, id, err)
+}
@@ -0,0 +1,112 @@
+package brandingapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Branding represents the branding of a tenant.
+type Branding struct {
+	TenantID        string `json:"tenantId"`
+	Logo            []byte `json:"logo,omitempty"`
+	LogoContentType string `json:"logoContentType,omitempty"`
+	PrimaryColor    string `json:"primaryColor"`
+	SecondaryColor  string `json:"secondaryColor"`
+	EmailHeader     string `json:"emailHeader"`
+	EmailFooter     string `json:"emailFooter"`
+	CreatedAt       string `json:"createdAt"`
+	UpdatedAt       string `json:"updatedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (b Branding) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(b)
+	return data, "application/json", err
+}
+
+func toAppBranding(bus brandingbus.Branding) Branding {
+	return Branding{
+		TenantID:        bus.TenantID.String(),
+		Logo:            bus.Logo,
+		LogoContentType: bus.LogoContentType,
+		PrimaryColor:    bus.PrimaryColor,
+		SecondaryColor:  bus.SecondaryColor,
+		EmailHeader:     bus.EmailHeader,
+		EmailFooter:     bus.EmailFooter,
+		CreatedAt:       bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       bus.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// =============================================================================
+
+// PublicBranding represents the branding shown on the login page. It is
+// served without authentication, so it carries only the look of the page.
+type PublicBranding struct {
+	Logo            []byte `json:"logo,omitempty"`
+	LogoContentType string `json:"logoContentType,omitempty"`
+	PrimaryColor    string `json:"primaryColor"`
+	SecondaryColor  string `json:"secondaryColor"`
+}
+
+// Encode implements the web.Encoder interface.
+func (b PublicBranding) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(b)
+	return data, "application/json", err
+}
+
+// toAppPublicBranding converts the branding and lets shared caches keep the
+// response for a while, since the login page asks for it on every visit.
+func toAppPublicBranding(ctx context.Context, bus brandingbus.Branding) PublicBranding {
+	w := web.GetWriter(ctx)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return PublicBranding{
+		Logo:            bus.Logo,
+		LogoContentType: bus.LogoContentType,
+		PrimaryColor:    bus.PrimaryColor,
+		SecondaryColor:  bus.SecondaryColor,
+	}
+}
+
+// =============================================================================
+
+// SaveBranding defines the data needed to configure the branding of a
+// tenant. The logo goes in base64 and can be left out to keep the current
+// one.
+type SaveBranding struct {
+	Logo           []byte `json:"logo"`
+	PrimaryColor   string `json:"primaryColor" validate:"required,max=7"`
+	SecondaryColor string `json:"secondaryColor" validate:"required,max=7"`
+	EmailHeader    string `json:"emailHeader" validate:"max=16384"`
+	EmailFooter    string `json:"emailFooter" validate:"max=16384"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *SaveBranding) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app SaveBranding) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusSaveBranding(app SaveBranding) brandingbus.SaveBranding {
+	return brandingbus.SaveBranding{
+		Logo:           app.Logo,
+		PrimaryColor:   app.PrimaryColor,
+		SecondaryColor: app.SecondaryColor,
+		EmailHeader:    app.EmailHeader,
+		EmailFooter:    app.EmailFooter,
+	}
+}
@@ -0,0 +1,44 @@
+package brandingapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	BrandingBus *brandingbus.Core
+	TenantBus   *tenantbus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+	// O TENANT_ADMIN mantém a identidade visual do próprio cliente.
+	manage := mid.Authorize(cfg.Auth, role.Admin, role.TenantAdmin)
+	inTenant := mid.AuthorizeTenant("tenant_id")
+
+	// O logo é enviado em base64 no corpo, por isso o limite é maior.
+	logoBody := mid.MaxBodyBytes(2 << 20)
+
+	api := newApp(cfg.BrandingBus, cfg.TenantBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/tenants/{tenant_id}/branding", api.query, authen, limit, manage, inTenant)
+	app.HandlerFunc(http.MethodPut, version, "/tenants/{tenant_id}/branding", api.save, authen, limit, manage, inTenant, logoBody)
+	app.HandlerFunc(http.MethodDelete, version, "/tenants/{tenant_id}/branding", api.delete, authen, limit, manage, inTenant)
+
+	// A página de login pede a identidade visual antes de autenticar.
+	app.HandlerFunc(http.MethodGet, version, "/branding", api.public, limit)
+}
@@ -0,0 +1,67 @@
+// Package checkapp maintains the app layer api for the check domain.
+package checkapp
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/health"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+type app struct {
+	build  string
+	log    *logger.Logger
+	health *health.Registry
+}
+
+func newApp(build string, log *logger.Logger, registry *health.Registry) *app {
+	return &app{
+		build:  build,
+		log:    log,
+		health: registry,
+	}
+}
+
+// readiness runs the dependency checks and reports the outcome of each one.
+// If a required dependency is down or the service is shutting down, a 503
+// status is returned so the orchestrator stops sending traffic to this
+// instance.
+func (a *app) readiness(ctx context.Context, r *http.Request) web.Encoder {
+	report := a.health.Run(ctx)
+
+	for _, res := range report.Checks {
+		if res.Status != health.StatusUp {
+			a.log.Info(ctx, "readiness failure", "check", res.Name, "optional", res.Optional, "ERROR", res.Error)
+		}
+	}
+
+	return toAppReadiness(a.build, report)
+}
+
+// liveness returns simple status info if the service is alive. If the
+// app is deployed to a Kubernetes cluster, it will also return pod, node, and
+// namespace details via the Downward API. The Kubernetes environment variables
+// need to be set within your Pod/Deployment manifest.
+func (a *app) liveness(ctx context.Context, r *http.Request) web.Encoder {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unavailable"
+	}
+
+	info := Info{
+		Status:     "up",
+		Build:      a.build,
+		Host:       host,
+		Name:       os.Getenv("KUBERNETES_NAME"),
+		PodIP:      os.Getenv("KUBERNETES_POD_IP"),
+		Node:       os.Getenv("KUBERNETES_NODE_NAME"),
+		Namespace:  os.Getenv("KUBERNETES_NAMESPACE"),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+
+	return info
+}
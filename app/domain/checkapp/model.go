@@ -0,0 +1,80 @@
+package checkapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/health"
+)
+
+// Info represents information about the service.
+type Info struct {
+	Status     string `json:"status,omitempty"`
+	Build      string `json:"build,omitempty"`
+	Host       string `json:"host,omitempty"`
+	Name       string `json:"name,omitempty"`
+	PodIP      string `json:"podIP,omitempty"`
+	Node       string `json:"node,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	GOMAXPROCS int    `json:"GOMAXPROCS,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (app Info) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+// Check represents the outcome of a single dependency check.
+type Check struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Optional bool   `json:"optional,omitempty"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Readiness represents the report of the dependency checks.
+type Readiness struct {
+	Status string  `json:"status"`
+	Build  string  `json:"build,omitempty"`
+	Checks []Check `json:"checks"`
+	ready  bool
+}
+
+// Encode implements the web.Encoder interface.
+func (app Readiness) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+// HTTPStatus implements the web package httpStatus interface so the
+// orchestrator stops sending traffic while the instance is not ready.
+func (app Readiness) HTTPStatus() int {
+	if !app.ready {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
+
+func toAppReadiness(build string, report health.Report) Readiness {
+	checks := make([]Check, len(report.Checks))
+	for i, res := range report.Checks {
+		checks[i] = Check{
+			Name:     res.Name,
+			Status:   res.Status,
+			Optional: res.Optional,
+			Duration: res.Duration.Round(time.Microsecond).String(),
+			Error:    res.Error,
+		}
+	}
+
+	return Readiness{
+		Status: report.Status,
+		Build:  build,
+		Checks: checks,
+		ready:  report.Ready(),
+	}
+}
@@ -0,0 +1,65 @@
+package checkapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/health"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Build     string
+	Log       *logger.Logger
+	DB        *sqlx.DB
+	KeyLookup auth.KeyLookup
+	ActiveKID string
+
+	// Health holds the checks of the optional dependencies registered at
+	// startup. The database and signing key checks are added here.
+	Health *health.Registry
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	registry := cfg.Health
+	if registry == nil {
+		registry = health.New()
+	}
+
+	registry.Register(health.Check{
+		Name: "database",
+		Func: func(ctx context.Context) error {
+			return sqldb.StatusCheck(ctx, cfg.DB)
+		},
+	})
+
+	registry.Register(health.Check{
+		Name: "keystore",
+		Func: func(ctx context.Context) error {
+			kid, err := auth.ActiveKID(cfg.KeyLookup, cfg.ActiveKID)
+			if err != nil {
+				return fmt.Errorf("active kid: %w", err)
+			}
+
+			if _, err := cfg.KeyLookup.PrivateKey(kid); err != nil {
+				return fmt.Errorf("signing key not loaded: %w", err)
+			}
+			return nil
+		},
+	})
+
+	api := newApp(cfg.Build, cfg.Log, registry)
+
+	// Sem middlewares: as sondas não devem gerar logs, métricas ou traces.
+	app.HandlerFuncNoMid(http.MethodGet, version, "/readiness", api.readiness)
+	app.HandlerFuncNoMid(http.MethodGet, version, "/liveness", api.liveness)
+}
@@ -2,24 +2,50 @@ package dashboardapp
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
 	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 )
 
 type app struct {
 	dashboardBus *dashboardbus.Core
+	usageBus     *usagebus.Core
+	activityBus  *activitybus.Core
 }
 
-func newApp(dashboardBus *dashboardbus.Core) *app {
+func newApp(dashboardBus *dashboardbus.Core, usageBus *usagebus.Core, activityBus *activitybus.Core) *app {
 	return &app{
 		dashboardBus: dashboardBus,
+		usageBus:     usageBus,
+		activityBus:  activityBus,
 	}
 }
 
+// newWithTx constructs a new app value with the domain apis using a store
+// transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboardBus, err := a.dashboardBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newApp(dashboardBus, a.usageBus, a.activityBus), nil
+}
+
 // create adds a new dashboard to the system.
 func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 	var req NewDashboard
@@ -34,17 +60,21 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 
 	d, err := a.dashboardBus.Create(ctx, nd)
 	if err != nil {
+		if errors.Is(err, dashboardbus.ErrDomainTaken) {
+			return errs.New(errs.AlreadyExists, dashboardbus.ErrDomainTaken).WithReason(errs.ReasonDomainTaken)
+		}
 		return errs.Errorf(errs.Internal, "create dashboard: %s", err)
 	}
 
 	return toAppDashboard(d)
 }
 
-// query returns the dashboard details for the current user's context.
+// query returns the dashboard details for the current user's context. The
+// client revalidates it with the ETag instead of downloading the logo again.
 func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
-	dashboardID, err := mid.GetDashboardID(ctx)
-	if err != nil {
-		return errs.New(errs.Unauthenticated, err)
+	dashboardID, errEnc := resolveDashboardID(ctx, r)
+	if errEnc != nil {
+		return errEnc
 	}
 
 	d, err := a.dashboardBus.QueryByID(ctx, dashboardID)
@@ -52,19 +82,30 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Errorf(errs.Internal, "query dashboard: %s", err)
 	}
 
-	return toAppDashboard(d)
+	userID, _ := mid.GetUserID(ctx)
+	a.usageBus.RecordDashboardView(d.TenantID, userID)
+	a.activityBus.Record(ctx, activitybus.NewActivity{
+		UserID:     userID,
+		TenantID:   d.TenantID,
+		Action:     activitybus.ActionDashboardViewed,
+		EntityType: activitybus.EntityDashboard,
+		EntityID:   d.ID,
+	})
+
+	return web.Validated(r, toAppDashboard(d), d.UpdatedAt)
 }
 
-// update updates the dashboard details.
+// update updates the dashboard details. With If-Match the update is
+// conditional on the ETag of the dashboard the client read.
 func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 	var req UpdateDashboard
 	if err := web.Decode(r, &req); err != nil {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
-	dashboardID, err := mid.GetDashboardID(ctx)
-	if err != nil {
-		return errs.New(errs.Unauthenticated, err)
+	dashboardID, errEnc := resolveDashboardID(ctx, r)
+	if errEnc != nil {
+		return errEnc
 	}
 
 	d, err := a.dashboardBus.QueryByID(ctx, dashboardID)
@@ -77,10 +118,162 @@ func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	// Com If-Match a alteração vale só para o dashboard que o cliente leu.
+	if r.Header.Get("If-Match") != "" {
+		match, err := web.IfMatch(r, toAppDashboard(d))
+		if err != nil {
+			return errs.Errorf(errs.Internal, "ifmatch: dashboardID[%s]: %s", d.ID, err)
+		}
+		if !match {
+			return errs.New(errs.Aborted, dashboardbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+		}
+		ud.Version = &d.UpdatedAt
+	}
+
 	updatedD, err := a.dashboardBus.Update(ctx, d, ud)
 	if err != nil {
+		switch {
+		case errors.Is(err, dashboardbus.ErrDomainTaken):
+			return errs.New(errs.AlreadyExists, dashboardbus.ErrDomainTaken).WithReason(errs.ReasonDomainTaken)
+		case errors.Is(err, dashboardbus.ErrConflict):
+			return errs.New(errs.Aborted, dashboardbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+		}
 		return errs.Errorf(errs.Internal, "update dashboard: %s", err)
 	}
 
 	return toAppDashboard(updatedD)
 }
+
+// queryDomains returns the domains the dashboard is published under.
+func (a *app) queryDomains(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDashboard(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	domains, err := a.dashboardBus.QueryDomains(ctx, d)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query domains: dashboardID[%s]: %s", d.ID, err)
+	}
+
+	return toAppDomains(domains)
+}
+
+// addDomain publishes the dashboard under one more domain.
+func (a *app) addDomain(ctx context.Context, r *http.Request) web.Encoder {
+	var req NewDomain
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	d, errEnc := a.queryDashboard(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	dm, err := a.dashboardBus.AddDomain(ctx, d, req.Domain)
+	if err != nil {
+		return toDomainError(err, "add domain: dashboardID[%s]: %s", d.ID)
+	}
+
+	return toAppDomain(dm)
+}
+
+// removeDomain stops publishing the dashboard under an alias.
+func (a *app) removeDomain(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDashboard(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.dashboardBus.RemoveDomain(ctx, d, web.Param(r, "domain")); err != nil {
+		return toDomainError(err, "remove domain: dashboardID[%s]: %s", d.ID)
+	}
+
+	return nil
+}
+
+// promoteDomain makes an alias the primary domain of the dashboard, keeping
+// the former primary domain as an alias.
+func (a *app) promoteDomain(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDashboard(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	promoted, err := a.dashboardBus.PromoteDomain(ctx, d, web.Param(r, "domain"))
+	if err != nil {
+		return toDomainError(err, "promote domain: dashboardID[%s]: %s", d.ID)
+	}
+
+	return toAppDashboard(promoted)
+}
+
+// queryPublic returns the dashboard published under the host of the request
+// to the SPA, before the login.
+func (a *app) queryPublic(ctx context.Context, r *http.Request) web.Encoder {
+	domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+
+	d, err := a.dashboardBus.QueryByDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, dashboardbus.ErrNotFound) {
+			return errs.New(errs.NotFound, dashboardbus.ErrNotFound).WithReason(errs.ReasonDomainNotFound)
+		}
+		return errs.Errorf(errs.Internal, "queryPublic: domain[%s]: %s", domain, err)
+	}
+
+	return toAppPublicDashboard(ctx, d)
+}
+
+// =============================================================================
+
+func (a *app) queryDashboard(ctx context.Context, r *http.Request) (dashboardbus.Dashboard, *errs.Error) {
+	dashboardID, errEnc := resolveDashboardID(ctx, r)
+	if errEnc != nil {
+		return dashboardbus.Dashboard{}, errEnc
+	}
+
+	d, err := a.dashboardBus.QueryByID(ctx, dashboardID)
+	if err != nil {
+		if errors.Is(err, dashboardbus.ErrNotFound) {
+			return dashboardbus.Dashboard{}, errs.New(errs.NotFound, dashboardbus.ErrNotFound).WithReason(errs.ReasonResourceNotFound)
+		}
+		return dashboardbus.Dashboard{}, errs.Errorf(errs.Internal, "query dashboard: %s", err)
+	}
+
+	return d, nil
+}
+
+// toDomainError translates the errors of the domain management. The format
+// must have a verb for id followed by one for the error.
+func toDomainError(err error, format string, id uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, dashboardbus.ErrDomainTaken):
+		return errs.New(errs.AlreadyExists, dashboardbus.ErrDomainTaken).WithReason(errs.ReasonDomainTaken)
+	case errors.Is(err, dashboardbus.ErrDomainNotFound):
+		return errs.New(errs.NotFound, dashboardbus.ErrDomainNotFound).WithReason(errs.ReasonDomainNotFound)
+	case errors.Is(err, dashboardbus.ErrPrimaryDomain):
+		return errs.New(errs.FailedPrecondition, dashboardbus.ErrPrimaryDomain).WithReason(errs.ReasonPrimaryDomain)
+	}
+
+	return errs.Errorf(errs.Internal, format, id, err)
+}
+
+// resolveDashboardID returns the dashboard informed in the URL or, when the
+// route has no dashboard_id, the dashboard bound to the token.
+func resolveDashboardID(ctx context.Context, r *http.Request) (uuid.UUID, *errs.Error) {
+	if v := r.PathValue("dashboard_id"); v != "" {
+		dashboardID, err := uuid.Parse(v)
+		if err != nil {
+			return uuid.Nil, errs.NewFieldErrors("dashboard_id", err)
+		}
+		return dashboardID, nil
+	}
+
+	dashboardID, err := mid.GetDashboardID(ctx)
+	if err != nil {
+		return uuid.Nil, errs.New(errs.Unauthenticated, err)
+	}
+
+	return dashboardID, nil
+}
@@ -0,0 +1,92 @@
+package dashboardapp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/api/proto/spiv1"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterGRPC binds the dashboard service to the gRPC server.
+func RegisterGRPC(srv grpc.ServiceRegistrar, cfg Config) {
+	spiv1.RegisterDashboardServiceServer(srv, &grpcServer{
+		auth:         cfg.Auth,
+		aclBus:       cfg.ACLBus,
+		dashboardBus: cfg.DashboardBus,
+		usageBus:     cfg.UsageBus,
+		activityBus:  cfg.ActivityBus,
+	})
+}
+
+type grpcServer struct {
+	spiv1.UnimplementedDashboardServiceServer
+	auth         *auth.Auth
+	aclBus       *aclbus.Core
+	dashboardBus *dashboardbus.Core
+	usageBus     *usagebus.Core
+	activityBus  *activitybus.Core
+}
+
+// GetDashboard returns a dashboard by its ID. Access follows the same rule of
+// GET /v1/dashboards/{dashboard_id}: role policy plus the ACL of the user.
+func (s *grpcServer) GetDashboard(ctx context.Context, req *spiv1.GetDashboardRequest) (*spiv1.Dashboard, error) {
+	ctx, err := mid.GRPCAuthenticate(ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mid.GRPCAuthorizeResource(ctx, s.aclBus, resource.Dashboard, actions.Get, req.GetDashboardId()); err != nil {
+		return nil, err
+	}
+
+	dashboardID, err := uuid.Parse(req.GetDashboardId())
+	if err != nil {
+		return nil, errs.NewFieldErrors("dashboard_id", err)
+	}
+
+	d, err := s.dashboardBus.QueryByID(ctx, dashboardID)
+	if err != nil {
+		if errors.Is(err, dashboardbus.ErrNotFound) {
+			return nil, errs.New(errs.NotFound, dashboardbus.ErrNotFound).WithReason(errs.ReasonResourceNotFound)
+		}
+		return nil, errs.Errorf(errs.Internal, "query dashboard: %s", err)
+	}
+
+	userID, _ := mid.GetUserID(ctx)
+	s.usageBus.RecordDashboardView(d.TenantID, userID)
+	s.activityBus.Record(ctx, activitybus.NewActivity{
+		UserID:     userID,
+		TenantID:   d.TenantID,
+		Action:     activitybus.ActionDashboardViewed,
+		EntityType: activitybus.EntityDashboard,
+		EntityID:   d.ID,
+	})
+
+	return toProtoDashboard(d), nil
+}
+
+func toProtoDashboard(bus dashboardbus.Dashboard) *spiv1.Dashboard {
+	app := toAppDashboard(bus)
+
+	return &spiv1.Dashboard{
+		Id:        app.ID,
+		TenantId:  app.TenantID,
+		Name:      app.Name,
+		Domain:    app.Domain,
+		Logo:      app.Logo,
+		CreatedAt: timestamppb.New(bus.CreatedAt),
+		UpdatedAt: timestamppb.New(bus.UpdatedAt),
+	}
+}
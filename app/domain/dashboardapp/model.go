@@ -1,6 +1,7 @@
 package dashboardapp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 	"github.com/jcpaschoal/spi-exata/business/types/name"
 )
 
@@ -45,6 +47,31 @@ func toAppDashboard(bus dashboardbus.Dashboard) Dashboard {
 	}
 }
 
+// PublicDashboard represents the dashboard shown before the login. It is
+// served without authentication, so it carries only what the page displays.
+type PublicDashboard struct {
+	Name string `json:"name"`
+	Logo []byte `json:"logo,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (d PublicDashboard) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+// toAppPublicDashboard converts the dashboard and lets shared caches keep
+// the response for a while, since the SPA asks for it on every load.
+func toAppPublicDashboard(ctx context.Context, bus dashboardbus.Dashboard) PublicDashboard {
+	w := web.GetWriter(ctx)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	return PublicDashboard{
+		Name: bus.Name.String(),
+		Logo: bus.Logo,
+	}
+}
+
 type NewDashboard struct {
 	TenantID string `json:"tenantId" validate:"required,uuid"`
 	Name     string `json:"name" validate:"required,min=3"`
@@ -127,3 +154,66 @@ func toBusUpdateDashboard(app UpdateDashboard) (dashboardbus.UpdateDashboard, er
 		Logo:   app.Logo,
 	}, nil
 }
+
+// =============================================================================
+
+// Domain represents a domain the dashboard is published under.
+type Domain struct {
+	Domain    string `json:"domain"`
+	Primary   bool   `json:"primary"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (d Domain) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppDomain(bus dashboardbus.Domain) Domain {
+	d := Domain{
+		Domain:  bus.Name,
+		Primary: bus.Primary,
+	}
+
+	if !bus.CreatedAt.IsZero() {
+		d.CreatedAt = bus.CreatedAt.Format(time.RFC3339)
+	}
+
+	return d
+}
+
+// Domains is a collection of dashboard domains, the primary first.
+type Domains []Domain
+
+// Encode implements the web.Encoder interface.
+func (d Domains) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppDomains(bus []dashboardbus.Domain) Domains {
+	domains := make(Domains, len(bus))
+	for i, d := range bus {
+		domains[i] = toAppDomain(d)
+	}
+	return domains
+}
+
+// NewDomain defines the data needed to add an alias to a dashboard.
+type NewDomain struct {
+	Domain string `json:"domain" validate:"required,hostname"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewDomain) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewDomain) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
@@ -5,36 +5,92 @@ import (
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
 	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
 	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
+	Log          *logger.Logger
+	DB           *sqlx.DB
 	Auth         *auth.Auth
+	ACLBus       *aclbus.Core
 	DashboardBus *dashboardbus.Core
+	UsageBus     *usagebus.Core
+	ActivityBus  *activitybus.Core
+	TenantBus    *tenantbus.Core
+	RateLimiter  ratelimit.Limiter
 }
 
 // Routes adds specific routes for this group.
-func Routes(app *web.App, cfg Config) {
+//
+// The parameter is not named app since route handlers that run inside a
+// transaction are referenced through the app type, e.g. (*app).promoteDomain.
+func Routes(a *web.App, cfg Config) {
 	const version = "v1"
 
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
 
 	// Regras de negócio: Apenas ADMIN e ANALYST podem alterar o Dashboard.
 	// USER pode apenas visualizar (query).
-	adminOnly := mid.Authorize(cfg.Auth, role.Admin)
 	canWrite := mid.Authorize(cfg.Auth, role.Admin, role.Analyst)
-	canUpdate := mid.Authorize(cfg.Auth, role.Admin, role.Analyst)
 
-	api := newApp(cfg.DashboardBus)
+	// O logo é enviado em base64 no corpo, por isso o limite é maior.
+	logoBody := mid.MaxBodyBytes(5 << 20)
 
-	app.HandlerFunc(http.MethodGet, version, "/dashboard", api.query, canUpdate)
+	// Regras por instância: política do papel + ACL do usuário sobre o dashboard.
+	canCreate := mid.AuthorizeResource(cfg.ACLBus, resource.Dashboard, actions.Create, "")
+	canGetInstance := mid.AuthorizeResource(cfg.ACLBus, resource.Dashboard, actions.Get, "dashboard_id")
+	canUpdateInstance := mid.AuthorizeResource(cfg.ACLBus, resource.Dashboard, actions.Update, "dashboard_id")
+
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+
+	api := newApp(cfg.DashboardBus, cfg.UsageBus, cfg.ActivityBus)
+
+	// GET /v1/dashboard
+	a.HandlerFunc(http.MethodGet, version, "/dashboard", api.query, authen, limit)
 
 	// POST /v1/dashboard
-	app.HandlerFunc(http.MethodPost, version, "/dashboard", api.create, authen, adminOnly)
+	a.HandlerFunc(http.MethodPost, version, "/dashboard", api.create, authen, limit, canCreate, logoBody)
 
 	// PUT /v1/dashboard
-	app.HandlerFunc(http.MethodPut, version, "/dashboard", api.update, authen, canWrite)
+	a.HandlerFunc(http.MethodPut, version, "/dashboard", api.update, authen, limit, canWrite, logoBody)
+
+	// GET /v1/dashboards/{dashboard_id}
+	a.HandlerFunc(http.MethodGet, version, "/dashboards/{dashboard_id}", api.query, authen, limit, canGetInstance)
+
+	// PUT /v1/dashboards/{dashboard_id}
+	a.HandlerFunc(http.MethodPut, version, "/dashboards/{dashboard_id}", api.update, authen, limit, canUpdateInstance, logoBody)
+
+	// GET /v1/dashboards/{dashboard_id}/domains
+	a.HandlerFunc(http.MethodGet, version, "/dashboards/{dashboard_id}/domains", api.queryDomains, authen, limit, canGetInstance)
+
+	// POST /v1/dashboards/{dashboard_id}/domains
+	// Um alias publica o dashboard em mais um domínio, o que permite migrar de
+	// domínio sem indisponibilidade: o novo entra como alias, é promovido a
+	// principal e o antigo é removido quando não tiver mais acessos.
+	a.HandlerFunc(http.MethodPost, version, "/dashboards/{dashboard_id}/domains", api.addDomain, authen, limit, canUpdateInstance)
+
+	// DELETE /v1/dashboards/{dashboard_id}/domains/{domain}
+	a.HandlerFunc(http.MethodDelete, version, "/dashboards/{dashboard_id}/domains/{domain}", api.removeDomain, authen, limit, canUpdateInstance)
+
+	// POST /v1/dashboards/{dashboard_id}/domains/{domain}/primary
+	a.HandlerFunc(http.MethodPost, version, "/dashboards/{dashboard_id}/domains/{domain}/primary", mid.WithTran(api.newWithTx, (*app).promoteDomain), authen, limit, canUpdateInstance, transaction)
+
+	// GET /v1/public/dashboard-info
+	// Aberta: o SPA mostra o nome e o logo do dashboard do host antes do
+	// login.
+	a.HandlerFunc(http.MethodGet, version, "/public/dashboard-info", api.queryPublic, limit)
 }
@@ -0,0 +1,301 @@
+// Package datasourceapp maintains the app layer api for the datasource domain.
+package datasourceapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// testTimeout bounds a connection test, so an unreachable host doesn't hold
+// the request until the write timeout.
+const testTimeout = 8 * time.Second
+
+type app struct {
+	datasourceBus *datasourcebus.Core
+	tenantBus     *tenantbus.Core
+}
+
+func newApp(datasourceBus *datasourcebus.Core, tenantBus *tenantbus.Core) *app {
+	return &app{
+		datasourceBus: datasourceBus,
+		tenantBus:     tenantBus,
+	}
+}
+
+// create adds a datasource to the tenant.
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewDatasource
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	ds, err := a.datasourceBus.Create(ctx, toBusNewDatasource(tenantID, app))
+	if err != nil {
+		return toAppError(err, "create: tenantID[%s]: %s", tenantID)
+	}
+
+	return toAppDatasource(ds)
+}
+
+// update modifies a datasource.
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateDatasource
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ds, errEnc := a.queryDatasource(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	updDS, err := a.datasourceBus.Update(ctx, ds, toBusUpdateDatasource(app))
+	if err != nil {
+		return toAppError(err, "update: datasourceID[%s]: %s", ds.ID)
+	}
+
+	return toAppDatasource(updDS)
+}
+
+// delete removes a datasource no widget is bound to.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	ds, errEnc := a.queryDatasource(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.datasourceBus.Delete(ctx, ds); err != nil {
+		return toAppError(err, "delete: datasourceID[%s]: %s", ds.ID)
+	}
+
+	return nil
+}
+
+// queryByTenant returns the datasources of a tenant.
+func (a *app) queryByTenant(ctx context.Context, r *http.Request) web.Encoder {
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	dss, err := a.datasourceBus.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return toAppDatasources(dss)
+}
+
+// queryByID returns a datasource by its ID.
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	ds, errEnc := a.queryDatasource(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppDatasource(ds)
+}
+
+// test connects to the datasource with the stored credentials.
+func (a *app) test(ctx context.Context, r *http.Request) web.Encoder {
+	ds, errEnc := a.queryDatasource(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	err := a.datasourceBus.Test(ctx, ds)
+
+	result := TestResult{
+		OK:        err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, datasourcebus.ErrConnection):
+		result.Error = err.Error()
+	default:
+		return errs.Errorf(errs.Internal, "test: datasourceID[%s]: %s", ds.ID, err)
+	}
+
+	return result
+}
+
+// =============================================================================
+
+// queryWidget returns the data binding of a widget.
+func (a *app) queryWidget(ctx context.Context, r *http.Request) web.Encoder {
+	w, errEnc := a.queryWidgetByID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppWidget(w)
+}
+
+// bindWidget makes a widget read from a datasource of its tenant.
+func (a *app) bindWidget(ctx context.Context, r *http.Request) web.Encoder {
+	var app BindWidget
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	bw, err := toBusBindWidget(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	w, errEnc := a.queryWidgetByID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	w, err = a.datasourceBus.BindWidget(ctx, w, bw)
+	if err != nil {
+		return toAppError(err, "bindWidget: widgetID[%s]: %s", w.ID)
+	}
+
+	return toAppWidget(w)
+}
+
+// unbindWidget removes the data binding of a widget.
+func (a *app) unbindWidget(ctx context.Context, r *http.Request) web.Encoder {
+	w, errEnc := a.queryWidgetByID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	w, err := a.datasourceBus.UnbindWidget(ctx, w)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "unbindWidget: widgetID[%s]: %s", w.ID, err)
+	}
+
+	return toAppWidget(w)
+}
+
+// execute runs the query of a widget of the dashboard and returns the rows.
+func (a *app) execute(ctx context.Context, r *http.Request) web.Encoder {
+	var app QueryWidget
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	dashboardID, err := uuid.Parse(web.Param(r, "dashboard_id"))
+	if err != nil {
+		return errs.NewFieldErrors("dashboard_id", err)
+	}
+
+	w, errEnc := a.queryWidgetByID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	// A ACL foi checada no dashboard da rota: o widget tem de ser dele.
+	if w.DashboardID != dashboardID {
+		return errs.New(errs.NotFound, datasourcebus.ErrWidgetNotFound).WithReason(errs.ReasonWidgetNotFound)
+	}
+
+	result, err := a.datasourceBus.Execute(ctx, w, app.Params, app.MaxRows)
+	if err != nil {
+		switch {
+		case errors.Is(err, datasourcebus.ErrWidgetNotBound):
+			return errs.New(errs.FailedPrecondition, datasourcebus.ErrWidgetNotBound).WithReason(errs.ReasonWidgetNotBound)
+		case errors.Is(err, datasourcebus.ErrQuery) && errors.Is(err, context.DeadlineExceeded):
+			return errs.New(errs.DeadlineExceeded, err).WithReason(errs.ReasonDatasourceTimeout)
+		case errors.Is(err, datasourcebus.ErrQuery):
+			return errs.New(errs.Unavailable, err).WithReason(errs.ReasonDatasourceQueryFailed)
+		}
+		return toAppError(err, "execute: widgetID[%s]: %s", w.ID)
+	}
+
+	return toAppQueryResult(result)
+}
+
+// =============================================================================
+
+func (a *app) queryTenantID(ctx context.Context, r *http.Request) (uuid.UUID, *errs.Error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	if _, err := a.tenantBus.QueryByID(ctx, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return uuid.Nil, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return uuid.Nil, errs.Errorf(errs.Internal, "query tenant: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return tenantID, nil
+}
+
+func (a *app) queryDatasource(ctx context.Context, r *http.Request) (datasourcebus.Datasource, *errs.Error) {
+	datasourceID, err := uuid.Parse(web.Param(r, "datasource_id"))
+	if err != nil {
+		return datasourcebus.Datasource{}, errs.NewFieldErrors("datasource_id", err)
+	}
+
+	ds, err := a.datasourceBus.QueryByID(ctx, datasourceID)
+	if err != nil {
+		if errors.Is(err, datasourcebus.ErrNotFound) {
+			return datasourcebus.Datasource{}, errs.New(errs.NotFound, datasourcebus.ErrNotFound).WithReason(errs.ReasonDatasourceNotFound)
+		}
+		return datasourcebus.Datasource{}, errs.Errorf(errs.Internal, "query: datasourceID[%s]: %s", datasourceID, err)
+	}
+
+	return ds, nil
+}
+
+func (a *app) queryWidgetByID(ctx context.Context, r *http.Request) (datasourcebus.Widget, *errs.Error) {
+	widgetID, err := uuid.Parse(web.Param(r, "widget_id"))
+	if err != nil {
+		return datasourcebus.Widget{}, errs.NewFieldErrors("widget_id", err)
+	}
+
+	w, err := a.datasourceBus.QueryWidget(ctx, widgetID)
+	if err != nil {
+		if errors.Is(err, datasourcebus.ErrWidgetNotFound) {
+			return datasourcebus.Widget{}, errs.New(errs.NotFound, datasourcebus.ErrWidgetNotFound).WithReason(errs.ReasonWidgetNotFound)
+		}
+		return datasourcebus.Widget{}, errs.Errorf(errs.Internal, "query widget: widgetID[%s]: %s", widgetID, err)
+	}
+
+	return w, nil
+}
+
+// toAppError translates the errors of the datasource core. The format must
+// have a verb for id followed by one for the error.
+func toAppError(err error, format string, id uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, datasourcebus.ErrNotFound):
+		return errs.New(errs.NotFound, datasourcebus.ErrNotFound).WithReason(errs.ReasonDatasourceNotFound)
+	case errors.Is(err, datasourcebus.ErrUniqueName):
+		return errs.New(errs.Aborted, datasourcebus.ErrUniqueName).WithReason(errs.ReasonDatasourceNotUnique)
+	case errors.Is(err, datasourcebus.ErrInUse):
+		return errs.New(errs.FailedPrecondition, datasourcebus.ErrInUse).WithReason(errs.ReasonDatasourceInUse)
+	case errors.Is(err, datasourcebus.ErrInvalidSettings), errors.Is(err, datasourcebus.ErrUnknownKind):
+		return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonDatasourceInvalid)
+	case errors.Is(err, datasourcebus.ErrTenantMismatch), errors.Is(err, datasourcebus.ErrEmptyQuery):
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	return errs.Errorf(errs.Internal, format, id, err)
+}
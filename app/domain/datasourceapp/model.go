@@ -0,0 +1,280 @@
+package datasourceapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+)
+
+// Datasource represents a connection of a tenant. The credentials are never
+// returned, only whether they are set.
+type Datasource struct {
+	ID             string            `json:"id"`
+	TenantID       string            `json:"tenantId"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	Settings       map[string]string `json:"settings"`
+	HasCredentials bool              `json:"hasCredentials"`
+	CreatedAt      string            `json:"createdAt"`
+	UpdatedAt      string            `json:"updatedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (d Datasource) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppDatasource(bus datasourcebus.Datasource) Datasource {
+	settings := bus.Settings
+	if settings == nil {
+		settings = map[string]string{}
+	}
+
+	return Datasource{
+		ID:             bus.ID.String(),
+		TenantID:       bus.TenantID.String(),
+		Name:           bus.Name,
+		Kind:           bus.Kind,
+		Settings:       settings,
+		HasCredentials: len(bus.Credentials) > 0,
+		CreatedAt:      bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      bus.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// Datasources is the list of datasources of a tenant.
+type Datasources struct {
+	Items []Datasource `json:"items"`
+}
+
+// Encode implements the web.Encoder interface.
+func (d Datasources) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppDatasources(bus []datasourcebus.Datasource) Datasources {
+	items := make([]Datasource, len(bus))
+	for i, ds := range bus {
+		items[i] = toAppDatasource(ds)
+	}
+
+	return Datasources{
+		Items: items,
+	}
+}
+
+// =============================================================================
+
+// NewDatasource defines the data needed to add a datasource.
+type NewDatasource struct {
+	Name        string            `json:"name" validate:"required,min=3,max=256"`
+	Kind        string            `json:"kind" validate:"required,oneof=postgres bigquery http"`
+	Settings    map[string]string `json:"settings"`
+	Credentials map[string]string `json:"credentials"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewDatasource) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewDatasource) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewDatasource(tenantID uuid.UUID, app NewDatasource) datasourcebus.NewDatasource {
+	return datasourcebus.NewDatasource{
+		TenantID:    tenantID,
+		Name:        app.Name,
+		Kind:        app.Kind,
+		Settings:    app.Settings,
+		Credentials: app.Credentials,
+	}
+}
+
+// =============================================================================
+
+// UpdateDatasource defines the data needed to update a datasource. Settings
+// and credentials are replaced as a whole when informed.
+type UpdateDatasource struct {
+	Name        *string           `json:"name" validate:"omitempty,min=3,max=256"`
+	Settings    map[string]string `json:"settings"`
+	Credentials map[string]string `json:"credentials"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdateDatasource) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateDatasource) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdateDatasource(app UpdateDatasource) datasourcebus.UpdateDatasource {
+	return datasourcebus.UpdateDatasource{
+		Name:        app.Name,
+		Settings:    app.Settings,
+		Credentials: app.Credentials,
+	}
+}
+
+// =============================================================================
+
+// TestResult is the outcome of a connection test. A failed connection is
+// not an error of the request, the reason is reported in Error.
+type TestResult struct {
+	OK        bool   `json:"ok"`
+	LatencyMS int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (t TestResult) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(t)
+	return data, "application/json", err
+}
+
+// =============================================================================
+
+// Widget represents the data binding of a widget.
+type Widget struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	DashboardID  string `json:"dashboardId"`
+	DatasourceID string `json:"datasourceId,omitempty"`
+	Query        string `json:"query,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (w Widget) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(w)
+	return data, "application/json", err
+}
+
+func toAppWidget(bus datasourcebus.Widget) Widget {
+	w := Widget{
+		ID:          bus.ID.String(),
+		Title:       bus.Title,
+		DashboardID: bus.DashboardID.String(),
+		Query:       bus.Query,
+	}
+
+	if bus.DatasourceID != nil {
+		w.DatasourceID = bus.DatasourceID.String()
+	}
+
+	return w
+}
+
+// BindWidget defines the data needed to bind a widget to a datasource.
+type BindWidget struct {
+	DatasourceID string `json:"datasourceId" validate:"required,uuid"`
+	Query        string `json:"query" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *BindWidget) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app BindWidget) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusBindWidget(app BindWidget) (datasourcebus.BindWidget, error) {
+	datasourceID, err := uuid.Parse(app.DatasourceID)
+	if err != nil {
+		return datasourcebus.BindWidget{}, fmt.Errorf("parse datasourceID: %w", err)
+	}
+
+	return datasourcebus.BindWidget{
+		DatasourceID: datasourceID,
+		Query:        app.Query,
+	}, nil
+}
+
+// =============================================================================
+
+// QueryWidget defines the data needed to run the query of a widget. The
+// params are bound by name in the query; MaxRows lowers the row limit.
+type QueryWidget struct {
+	Params  map[string]any `json:"params"`
+	MaxRows int            `json:"maxRows" validate:"omitempty,min=1"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *QueryWidget) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app QueryWidget) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+
+	for name, v := range app.Params {
+		switch v.(type) {
+		case string, float64, bool, nil:
+		default:
+			return errs.NewFieldErrors("params."+name, errors.New("must be a string, number, boolean or null"))
+		}
+	}
+
+	return nil
+}
+
+// QueryResult is the table returned by the query of a widget.
+type QueryResult struct {
+	Columns    []string `json:"columns"`
+	Rows       [][]any  `json:"rows"`
+	Truncated  bool     `json:"truncated"`
+	Cached     bool     `json:"cached"`
+	ExecutedAt string   `json:"executedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (q QueryResult) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(q)
+	return data, "application/json", err
+}
+
+func toAppQueryResult(bus datasourcebus.Result) QueryResult {
+	q := QueryResult{
+		Columns:    bus.Columns,
+		Rows:       bus.Rows,
+		Truncated:  bus.Truncated,
+		Cached:     bus.Cached,
+		ExecutedAt: bus.ExecutedAt.Format(time.RFC3339),
+	}
+
+	if q.Columns == nil {
+		q.Columns = []string{}
+	}
+
+	if q.Rows == nil {
+		q.Rows = [][]any{}
+	}
+
+	return q
+}
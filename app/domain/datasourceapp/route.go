@@ -0,0 +1,59 @@
+package datasourceapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth          *auth.Auth
+	ACLBus        *aclbus.Core
+	DatasourceBus *datasourcebus.Core
+	TenantBus     *tenantbus.Core
+	RateLimiter   ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	// As conexões guardam credenciais dos clientes: só o ADMIN as gerencia.
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+
+	// Vincular um widget é editar o widget: papel + ACL sobre o subject.
+	canWrite := mid.Authorize(cfg.Auth, role.Admin, role.Analyst)
+	canGetWidget := mid.AuthorizeResource(cfg.ACLBus, resource.Subject, actions.Get, "widget_id")
+	canUpdateWidget := mid.AuthorizeResource(cfg.ACLBus, resource.Subject, actions.Update, "widget_id")
+
+	// Executar a consulta é ver o dashboard: quem o vê, vê os dados.
+	canGetDashboard := mid.AuthorizeResource(cfg.ACLBus, resource.Dashboard, actions.Get, "dashboard_id")
+
+	api := newApp(cfg.DatasourceBus, cfg.TenantBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/tenants/{tenant_id}/datasources", api.queryByTenant, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/tenants/{tenant_id}/datasources", api.create, authen, limit, admin)
+	app.HandlerFunc(http.MethodGet, version, "/datasources/{datasource_id}", api.queryByID, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/datasources/{datasource_id}", api.update, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/datasources/{datasource_id}", api.delete, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/datasources/{datasource_id}/test", api.test, authen, limit, admin)
+
+	app.HandlerFunc(http.MethodGet, version, "/widgets/{widget_id}/datasource", api.queryWidget, authen, limit, canGetWidget)
+	app.HandlerFunc(http.MethodPut, version, "/widgets/{widget_id}/datasource", api.bindWidget, authen, limit, canWrite, canUpdateWidget)
+	app.HandlerFunc(http.MethodDelete, version, "/widgets/{widget_id}/datasource", api.unbindWidget, authen, limit, canWrite, canUpdateWidget)
+
+	app.HandlerFunc(http.MethodPost, version, "/dashboards/{dashboard_id}/widgets/{widget_id}/query", api.execute, authen, limit, canGetDashboard)
+}
@@ -0,0 +1,195 @@
+// Package featureapp maintains the app layer api for the feature flag domain.
+package featureapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	featureBus *featurebus.Core
+}
+
+func newApp(featureBus *featurebus.Core) *app {
+	return &app{
+		featureBus: featureBus,
+	}
+}
+
+// queryMine returns the value of every flag for the authenticated user, as
+// evaluated by the Features middleware.
+func (a *app) queryMine(ctx context.Context, r *http.Request) web.Encoder {
+	return toAppFeatures(mid.GetFeatures(ctx))
+}
+
+// query returns every flag with its overrides.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	flags, err := a.featureBus.QueryAll(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query: %s", err)
+	}
+
+	return toAppFlags(flags)
+}
+
+// queryByKey returns a flag with its overrides.
+func (a *app) queryByKey(ctx context.Context, r *http.Request) web.Encoder {
+	f, errEnc := a.queryFlag(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppFlag(f)
+}
+
+// create adds a new flag.
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewFlag
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	f, err := a.featureBus.Create(ctx, toBusNewFlag(app))
+	if err != nil {
+		return toAppError(err, "create: key[%s]: %s", app.Key)
+	}
+
+	return toAppFlag(f)
+}
+
+// update modifies a flag.
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateFlag
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	f, errEnc := a.queryFlag(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	updFlag, err := a.featureBus.Update(ctx, f, toBusUpdateFlag(app))
+	if err != nil {
+		return toAppError(err, "update: key[%s]: %s", f.Key)
+	}
+
+	return toAppFlag(updFlag)
+}
+
+// delete removes a flag.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	f, errEnc := a.queryFlag(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.featureBus.Delete(ctx, f); err != nil {
+		return toAppError(err, "delete: key[%s]: %s", f.Key)
+	}
+
+	return nil
+}
+
+// setOverride turns a flag on or off for the tenant or the user of the path.
+func (a *app) setOverride(ctx context.Context, r *http.Request) web.Encoder {
+	var app SetOverride
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	f, errEnc := a.queryFlag(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	o, errEnc := parseOverride(r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	o.Enabled = *app.Enabled
+
+	if err := a.featureBus.SetOverride(ctx, f, o); err != nil {
+		return toAppError(err, "setOverride: key[%s]: %s", f.Key)
+	}
+
+	return a.queryByKey(ctx, r)
+}
+
+// deleteOverride removes the override of the tenant or the user of the path.
+func (a *app) deleteOverride(ctx context.Context, r *http.Request) web.Encoder {
+	f, errEnc := a.queryFlag(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	o, errEnc := parseOverride(r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.featureBus.DeleteOverride(ctx, f, o); err != nil {
+		return toAppError(err, "deleteOverride: key[%s]: %s", f.Key)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func (a *app) queryFlag(ctx context.Context, r *http.Request) (featurebus.Flag, *errs.Error) {
+	key := web.Param(r, "flag_key")
+
+	f, err := a.featureBus.QueryByKey(ctx, key)
+	if err != nil {
+		return featurebus.Flag{}, toAppError(err, "query: key[%s]: %s", key)
+	}
+
+	return f, nil
+}
+
+// parseOverride returns the override of the tenant_id or user_id of the path.
+func parseOverride(r *http.Request) (featurebus.Override, *errs.Error) {
+	var o featurebus.Override
+
+	if s := web.Param(r, "tenant_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return featurebus.Override{}, errs.NewFieldErrors("tenant_id", err)
+		}
+		o.TenantID = id
+	}
+
+	if s := web.Param(r, "user_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return featurebus.Override{}, errs.NewFieldErrors("user_id", err)
+		}
+		o.UserID = id
+	}
+
+	return o, nil
+}
+
+func toAppError(err error, format string, key string) *errs.Error {
+	switch {
+	case errors.Is(err, featurebus.ErrNotFound):
+		return errs.New(errs.NotFound, featurebus.ErrNotFound).WithReason(errs.ReasonFeatureNotFound)
+	case errors.Is(err, featurebus.ErrUniqueKey):
+		return errs.New(errs.Aborted, featurebus.ErrUniqueKey).WithReason(errs.ReasonFeatureNotUnique)
+	case errors.Is(err, featurebus.ErrSubjectNotFound):
+		return errs.New(errs.NotFound, featurebus.ErrSubjectNotFound).WithReason(errs.ReasonResourceNotFound)
+	case errors.Is(err, featurebus.ErrInvalidKey), errors.Is(err, featurebus.ErrInvalidRollout), errors.Is(err, featurebus.ErrInvalidOverride):
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	return errs.Errorf(errs.Internal, format, key, err)
+}
@@ -0,0 +1,194 @@
+package featureapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+)
+
+// Features is the value of every flag for the authenticated user.
+type Features struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// Encode implements the web.Encoder interface.
+func (f Features) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(f)
+	return data, "application/json", err
+}
+
+func toAppFeatures(set featurebus.Set) Features {
+	flags := make(map[string]bool, len(set))
+	for key, on := range set {
+		flags[key] = on
+	}
+
+	return Features{
+		Flags: flags,
+	}
+}
+
+// =============================================================================
+
+// Override represents a flag turned on or off for a tenant or a user.
+type Override struct {
+	TenantID string `json:"tenantId,omitempty"`
+	UserID   string `json:"userId,omitempty"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Flag represents a feature flag and its overrides.
+type Flag struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Rollout     int        `json:"rollout"`
+	Overrides   []Override `json:"overrides"`
+	CreatedAt   string     `json:"createdAt"`
+	UpdatedAt   string     `json:"updatedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (f Flag) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(f)
+	return data, "application/json", err
+}
+
+func toAppFlag(bus featurebus.Flag) Flag {
+	overrides := make([]Override, len(bus.Overrides))
+	for i, o := range bus.Overrides {
+		overrides[i] = Override{Enabled: o.Enabled}
+
+		if o.TenantID != uuid.Nil {
+			overrides[i].TenantID = o.TenantID.String()
+		}
+
+		if o.UserID != uuid.Nil {
+			overrides[i].UserID = o.UserID.String()
+		}
+	}
+
+	return Flag{
+		Key:         bus.Key,
+		Description: bus.Description,
+		Enabled:     bus.Enabled,
+		Rollout:     bus.Rollout,
+		Overrides:   overrides,
+		CreatedAt:   bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   bus.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// Flags is the list of feature flags.
+type Flags struct {
+	Items []Flag `json:"items"`
+}
+
+// Encode implements the web.Encoder interface.
+func (f Flags) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(f)
+	return data, "application/json", err
+}
+
+func toAppFlags(bus []featurebus.Flag) Flags {
+	items := make([]Flag, len(bus))
+	for i, f := range bus {
+		items[i] = toAppFlag(f)
+	}
+
+	return Flags{
+		Items: items,
+	}
+}
+
+// =============================================================================
+
+// NewFlag defines the data needed to create a feature flag. Rollout defaults
+// to every tenant.
+type NewFlag struct {
+	Key         string `json:"key" validate:"required,max=64"`
+	Description string `json:"description" validate:"max=1024"`
+	Enabled     bool   `json:"enabled"`
+	Rollout     *int   `json:"rollout" validate:"omitempty,min=0,max=100"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewFlag) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewFlag) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewFlag(app NewFlag) featurebus.NewFlag {
+	rollout := 100
+	if app.Rollout != nil {
+		rollout = *app.Rollout
+	}
+
+	return featurebus.NewFlag{
+		Key:         app.Key,
+		Description: app.Description,
+		Enabled:     app.Enabled,
+		Rollout:     rollout,
+	}
+}
+
+// =============================================================================
+
+// UpdateFlag defines the data needed to update a feature flag.
+type UpdateFlag struct {
+	Description *string `json:"description" validate:"omitempty,max=1024"`
+	Enabled     *bool   `json:"enabled"`
+	Rollout     *int    `json:"rollout" validate:"omitempty,min=0,max=100"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdateFlag) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateFlag) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdateFlag(app UpdateFlag) featurebus.UpdateFlag {
+	return featurebus.UpdateFlag{
+		Description: app.Description,
+		Enabled:     app.Enabled,
+		Rollout:     app.Rollout,
+	}
+}
+
+// =============================================================================
+
+// SetOverride defines the value of a flag for a tenant or a user.
+type SetOverride struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *SetOverride) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app SetOverride) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package featureapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	FeatureBus  *featurebus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, nil)
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+	features := mid.Features(cfg.FeatureBus)
+
+	api := newApp(cfg.FeatureBus)
+
+	// GET /v1/features
+	app.HandlerFunc(http.MethodGet, version, "/features", api.queryMine, authen, limit, features)
+
+	app.HandlerFunc(http.MethodGet, version, "/feature-flags", api.query, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/feature-flags", api.create, authen, limit, admin)
+	app.HandlerFunc(http.MethodGet, version, "/feature-flags/{flag_key}", api.queryByKey, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/feature-flags/{flag_key}", api.update, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/feature-flags/{flag_key}", api.delete, authen, limit, admin)
+
+	app.HandlerFunc(http.MethodPut, version, "/feature-flags/{flag_key}/tenants/{tenant_id}", api.setOverride, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/feature-flags/{flag_key}/tenants/{tenant_id}", api.deleteOverride, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/feature-flags/{flag_key}/users/{user_id}", api.setOverride, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/feature-flags/{flag_key}/users/{user_id}", api.deleteOverride, authen, limit, admin)
+}
@@ -0,0 +1,421 @@
+// Package graphqlapp maintains the app layer api for the GraphQL endpoint used
+// by the dashboard frontend to fetch nested data in a single round trip.
+package graphqlapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/graphql"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+type app struct {
+	log          *logger.Logger
+	auth         *auth.Auth
+	userBus      *userbus.Core
+	tenantBus    *tenantbus.Core
+	dashboardBus *dashboardbus.Core
+	aclBus       *aclbus.Core
+	usageBus     *usagebus.Core
+	activityBus  *activitybus.Core
+	schema       *graphql.Schema
+}
+
+func newApp(cfg Config) *app {
+	a := app{
+		log:          cfg.Log,
+		auth:         cfg.Auth,
+		userBus:      cfg.UserBus,
+		tenantBus:    cfg.TenantBus,
+		dashboardBus: cfg.DashboardBus,
+		aclBus:       cfg.ACLBus,
+		usageBus:     cfg.UsageBus,
+		activityBus:  cfg.ActivityBus,
+	}
+
+	a.schema = newSchema(&a)
+
+	return &a
+}
+
+// query executes a GraphQL query for the authenticated user.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	var req Request
+	if err := web.Decode(r, &req); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ctx = setLocale(ctx, errs.ParseLocale(r.Header.Get("Accept-Language")))
+
+	return Response(a.schema.Execute(ctx, toGraphQLRequest(req)))
+}
+
+// presentError converts the error of a field the same way mid.Errors does
+// for a whole request: internal details are logged and never sent.
+func (a *app) presentError(ctx context.Context, err error) graphql.Error {
+	var appErr *errs.Error
+	if !errors.As(err, &appErr) {
+		appErr = errs.Errorf(errs.Internal, "Internal Server Error")
+	}
+
+	a.log.Error(ctx, "handled error during graphql query",
+		"err", err,
+		"source_err_file", path.Base(appErr.FileName),
+		"source_err_func", path.Base(appErr.FuncName))
+
+	if appErr.Code == errs.InternalOnlyLog {
+		appErr = errs.Errorf(errs.Internal, "Internal Server Error")
+	}
+
+	appErr = appErr.Localize(getLocale(ctx))
+
+	return graphql.Error{
+		Message: appErr.Message,
+		Extensions: map[string]any{
+			"code":   appErr.Code.String(),
+			"reason": appErr.Reason.String(),
+		},
+	}
+}
+
+// =============================================================================
+// Query resolvers
+
+func (a *app) me(ctx context.Context, _ graphql.Params) (any, error) {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	return a.queryUser(ctx, userID)
+}
+
+func (a *app) user(ctx context.Context, p graphql.Params) (any, error) {
+	if err := a.authorize(ctx, role.Admin); err != nil {
+		return nil, err
+	}
+
+	userID, err := parseID(p.Args, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return a.queryUser(ctx, userID)
+}
+
+func (a *app) users(ctx context.Context, p graphql.Params) (any, error) {
+	if err := a.authorize(ctx, role.Admin); err != nil {
+		return nil, err
+	}
+
+	pg, filter, orderBy, err := parseUsersArgs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	usrs, total, err := a.userBus.QueryWithCount(ctx, filter, orderBy, pg)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "query: %s", err)
+	}
+
+	list := userList{
+		Items:       usrs,
+		Total:       total,
+		Page:        pg.Number(),
+		RowsPerPage: pg.RowsPerPage(),
+	}
+
+	return list, nil
+}
+
+// tenant returns the tenant of the caller when no id is given. Other tenants
+// are visible only to ADMIN.
+func (a *app) tenant(ctx context.Context, p graphql.Params) (any, error) {
+	tenantID, err := parseOptionalID(p.Args, "id", mid.GetClaims(ctx).TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.queryTenant(ctx, tenantID)
+}
+
+// dashboard returns the dashboard of the caller when no id is given. Access
+// follows GET /v1/dashboards/{dashboard_id}: role policy plus the ACL.
+func (a *app) dashboard(ctx context.Context, p graphql.Params) (any, error) {
+	dashboardID, err := parseOptionalID(p.Args, "id", mid.GetClaims(ctx).DashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	if err := a.aclBus.ValidateAccess(ctx, userID, dashboardID, actions.Get); err != nil {
+		if errors.Is(err, aclbus.ErrAccessDenied) {
+			return nil, errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
+		}
+		return nil, errs.Errorf(errs.Internal, "authorize resource: resource[%s] action[%s]: %s", resource.Dashboard, actions.Get, err)
+	}
+
+	d, err := a.dashboardBus.QueryByID(ctx, dashboardID)
+	if err != nil {
+		if errors.Is(err, dashboardbus.ErrNotFound) {
+			return nil, errs.New(errs.NotFound, dashboardbus.ErrNotFound).WithReason(errs.ReasonResourceNotFound)
+		}
+		return nil, errs.Errorf(errs.Internal, "query dashboard: %s", err)
+	}
+
+	a.usageBus.RecordDashboardView(d.TenantID, userID)
+	a.activityBus.Record(ctx, activitybus.NewActivity{
+		UserID:     userID,
+		TenantID:   d.TenantID,
+		Action:     activitybus.ActionDashboardViewed,
+		EntityType: activitybus.EntityDashboard,
+		EntityID:   d.ID,
+	})
+
+	return d, nil
+}
+
+func (a *app) permissions(ctx context.Context, _ graphql.Params) (any, error) {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	perms, err := a.aclBus.QueryPermissions(ctx, userID)
+	if err != nil {
+		return nil, errs.Errorf(errs.InternalOnlyLog, "querypermissions: userID[%s]: %s", userID, err)
+	}
+
+	return perms, nil
+}
+
+// =============================================================================
+// Object resolvers
+
+func (a *app) dashboardTenant(ctx context.Context, p graphql.Params) (any, error) {
+	d := p.Source.(dashboardbus.Dashboard)
+	return a.queryTenant(ctx, d.TenantID)
+}
+
+func (a *app) dashboardPages(ctx context.Context, p graphql.Params) (any, error) {
+	d := p.Source.(dashboardbus.Dashboard)
+
+	pages, err := a.dashboardBus.QueryPages(ctx, d.ID)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "query pages: dashboardID[%s]: %s", d.ID, err)
+	}
+
+	return pages, nil
+}
+
+func (a *app) dashboardMyActions(ctx context.Context, p graphql.Params) (any, error) {
+	d := p.Source.(dashboardbus.Dashboard)
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	acts, err := a.aclBus.QueryActions(ctx, userID, d.ID)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "query actions: dashboardID[%s]: %s", d.ID, err)
+	}
+
+	return toAppActions(acts), nil
+}
+
+// private protects the contact fields of a user: only the user and ADMIN
+// can read them.
+func (a *app) private(get func(userbus.User) any) *graphql.Field {
+	return &graphql.Field{
+		Resolve: func(ctx context.Context, p graphql.Params) (any, error) {
+			usr := p.Source.(userbus.User)
+
+			userID, err := mid.GetUserID(ctx)
+			if err != nil || userID != usr.ID {
+				if err := a.authorize(ctx, role.Admin); err != nil {
+					return nil, err
+				}
+			}
+
+			return get(usr), nil
+		},
+	}
+}
+
+// =============================================================================
+
+func (a *app) authorize(ctx context.Context, allowedRoles ...role.Role) error {
+	if err := a.auth.Authorize(ctx, mid.GetClaims(ctx), allowedRoles...); err != nil {
+		return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
+	}
+
+	return nil
+}
+
+func (a *app) queryUser(ctx context.Context, userID uuid.UUID) (userbus.User, error) {
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return userbus.User{}, errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return userbus.User{}, errs.Errorf(errs.InternalOnlyLog, "querybyid: userID[%s]: %s", userID, err)
+	}
+
+	return usr, nil
+}
+
+func (a *app) queryTenant(ctx context.Context, tenantID uuid.UUID) (tenantbus.Tenant, error) {
+	if mid.GetClaims(ctx).TenantID != tenantID.String() {
+		if err := a.authorize(ctx, role.Admin); err != nil {
+			return tenantbus.Tenant{}, err
+		}
+	}
+
+	t, err := a.tenantBus.QueryByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return tenantbus.Tenant{}, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return tenantbus.Tenant{}, errs.Errorf(errs.InternalOnlyLog, "querybyid: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return t, nil
+}
+
+func parseID(args graphql.Args, arg string) (uuid.UUID, error) {
+	s, err := args.String(arg)
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors(arg, err)
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors(arg, err)
+	}
+
+	return id, nil
+}
+
+// parseOptionalID falls back to the id carried by the token.
+func parseOptionalID(args graphql.Args, arg string, fallback string) (uuid.UUID, error) {
+	if _, exists := args[arg]; !exists {
+		id, err := uuid.Parse(fallback)
+		if err != nil {
+			return uuid.Nil, errs.NewFieldErrors(arg, errors.New("required, the token has no default"))
+		}
+		return id, nil
+	}
+
+	return parseID(args, arg)
+}
+
+func parseUsersArgs(args graphql.Args) (page.Page, userbus.QueryFilter, order.By, error) {
+	var fieldErrors errs.FieldErrors
+
+	pageNumber, err := args.Int("page")
+	if err != nil {
+		fieldErrors.Add("page", err)
+	}
+
+	rows, err := args.Int("rows")
+	if err != nil {
+		fieldErrors.Add("rows", err)
+	}
+
+	var filter userbus.QueryFilter
+
+	if s, err := args.String("name"); err != nil || s != "" {
+		nme, perr := name.Parse(s)
+		switch {
+		case err != nil:
+			fieldErrors.Add("name", err)
+		case perr != nil:
+			fieldErrors.Add("name", perr)
+		default:
+			filter.Name = &nme
+		}
+	}
+
+	if s, err := args.String("email"); err != nil || s != "" {
+		addr, perr := mail.ParseAddress(s)
+		switch {
+		case err != nil:
+			fieldErrors.Add("email", err)
+		case perr != nil:
+			fieldErrors.Add("email", perr)
+		default:
+			filter.Email = addr
+		}
+	}
+
+	orderByArg, err := args.String("orderBy")
+	if err != nil {
+		fieldErrors.Add("orderBy", err)
+	}
+
+	if fieldErrors != nil {
+		return page.Page{}, userbus.QueryFilter{}, order.By{}, fieldErrors.ToError()
+	}
+
+	var pageArg, rowsArg string
+	if pageNumber != 0 {
+		pageArg = fmt.Sprint(pageNumber)
+	}
+	if rows != 0 {
+		rowsArg = fmt.Sprint(rows)
+	}
+
+	pg, err := page.Parse(pageArg, rowsArg)
+	if err != nil {
+		return page.Page{}, userbus.QueryFilter{}, order.By{}, errs.NewFieldErrors("page", err)
+	}
+
+	orderBy, err := order.Parse(orderByFields, orderByArg, userbus.DefaultOrderBy)
+	if err != nil {
+		return page.Page{}, userbus.QueryFilter{}, order.By{}, errs.NewFieldErrors("orderBy", err)
+	}
+
+	return pg, filter, orderBy, nil
+}
+
+// =============================================================================
+
+type ctxKey int
+
+const localeKey ctxKey = 1
+
+func setLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+func getLocale(ctx context.Context) string {
+	v, _ := ctx.Value(localeKey).(string)
+	return v
+}
@@ -0,0 +1,47 @@
+package graphqlapp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/foundation/graphql"
+)
+
+// Request represents a GraphQL query sent by the client.
+type Request struct {
+	Query         string         `json:"query" validate:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *Request) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app Request) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toGraphQLRequest(app Request) graphql.Request {
+	return graphql.Request{
+		Query:         app.Query,
+		OperationName: app.OperationName,
+		Variables:     app.Variables,
+	}
+}
+
+// Response represents the result of a query. Field errors are reported in
+// the errors list next to the data that could be resolved.
+type Response graphql.Response
+
+// Encode implements the web.Encoder interface.
+func (r Response) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(r)
+	return data, "application/json", err
+}
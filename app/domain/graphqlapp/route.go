@@ -0,0 +1,46 @@
+package graphqlapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log          *logger.Logger
+	Auth         *auth.Auth
+	UserBus      *userbus.Core
+	TenantBus    *tenantbus.Core
+	DashboardBus *dashboardbus.Core
+	ACLBus       *aclbus.Core
+	UsageBus     *usagebus.Core
+	ActivityBus  *activitybus.Core
+	RateLimiter  ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	api := newApp(cfg)
+
+	// A autorização é feita campo a campo pelos resolvers, com as mesmas
+	// regras das rotas REST equivalentes.
+
+	// POST /v1/graphql
+	app.HandlerFunc(http.MethodPost, version, "/graphql", api.query, authen, limit)
+}
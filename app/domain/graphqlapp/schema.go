@@ -0,0 +1,186 @@
+package graphqlapp
+
+import (
+	"context"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/foundation/graphql"
+)
+
+// maxDepth bounds how deep a query can nest. The deepest paths of the
+// schema, like dashboard.tenant, have three levels.
+const maxDepth = 5
+
+// maxNodes bounds the fields of a query with the fragments expanded, and
+// maxFields the fields written in a single selection set. Every field of
+// the schema fits in a query well under both.
+const (
+	maxNodes  = 500
+	maxFields = 50
+)
+
+var orderByFields = map[string]string{
+	"user_id": userbus.OrderByID,
+	"name":    userbus.OrderByName,
+	"email":   userbus.OrderByEmail,
+	"role":    userbus.OrderByRole,
+	"enabled": userbus.OrderByEnabled,
+}
+
+// userList is the page of users returned by the users query.
+type userList struct {
+	Items       []userbus.User
+	Total       int
+	Page        int
+	RowsPerPage int
+}
+
+// newSchema declares the types of the API. The values follow the formats of
+// the REST endpoints: ids as strings and times in RFC3339.
+//
+//	type Query {
+//	  me: User
+//	  user(id: ID!): User
+//	  users(page: Int, rows: Int, orderBy: String, name: String, email: String): UserList
+//	  tenant(id: ID): Tenant
+//	  dashboard(id: ID): Dashboard
+//	  permissions: Permissions
+//	}
+func newSchema(a *app) *graphql.Schema {
+	userType := &graphql.Object{Name: "User"}
+	userListType := &graphql.Object{Name: "UserList"}
+	tenantType := &graphql.Object{Name: "Tenant"}
+	dashboardType := &graphql.Object{Name: "Dashboard"}
+	pageType := &graphql.Object{Name: "Page"}
+	permissionsType := &graphql.Object{Name: "Permissions"}
+	typePermissionType := &graphql.Object{Name: "TypePermission"}
+	resourcePermissionType := &graphql.Object{Name: "ResourcePermission"}
+
+	userType.Fields = map[string]*graphql.Field{
+		"id":          leaf(func(u userbus.User) any { return u.ID.String() }),
+		"name":        leaf(func(u userbus.User) any { return u.Name.String() }),
+		"email":       a.private(func(u userbus.User) any { return u.Email.Address }),
+		"phone":       a.private(func(u userbus.User) any { return u.Phone.String() }),
+		"role":        leaf(func(u userbus.User) any { return u.Role.String() }),
+		"enabled":     leaf(func(u userbus.User) any { return u.Enabled }),
+		"dateCreated": leaf(func(u userbus.User) any { return u.CreatedAt.Format(time.RFC3339) }),
+		"dateUpdated": leaf(func(u userbus.User) any { return u.UpdatedAt.Format(time.RFC3339) }),
+	}
+
+	userListType.Fields = map[string]*graphql.Field{
+		"items":       object(userType, func(l userList) any { return l.Items }),
+		"total":       leaf(func(l userList) any { return l.Total }),
+		"page":        leaf(func(l userList) any { return l.Page }),
+		"rowsPerPage": leaf(func(l userList) any { return l.RowsPerPage }),
+	}
+
+	tenantType.Fields = map[string]*graphql.Field{
+		"id":        leaf(func(t tenantbus.Tenant) any { return t.ID.String() }),
+		"name":      leaf(func(t tenantbus.Tenant) any { return t.Name.String() }),
+		"slug":      leaf(func(t tenantbus.Tenant) any { return t.Slug.String() }),
+		"enabled":   leaf(func(t tenantbus.Tenant) any { return t.Enabled }),
+		"createdAt": leaf(func(t tenantbus.Tenant) any { return t.CreatedAt.Format(time.RFC3339) }),
+		"updatedAt": leaf(func(t tenantbus.Tenant) any { return t.UpdatedAt.Format(time.RFC3339) }),
+	}
+
+	dashboardType.Fields = map[string]*graphql.Field{
+		"id":        leaf(func(d dashboardbus.Dashboard) any { return d.ID.String() }),
+		"tenantId":  leaf(func(d dashboardbus.Dashboard) any { return d.TenantID.String() }),
+		"name":      leaf(func(d dashboardbus.Dashboard) any { return d.Name.String() }),
+		"domain":    leaf(func(d dashboardbus.Dashboard) any { return d.Domain }),
+		"logo":      leaf(func(d dashboardbus.Dashboard) any { return d.Logo }),
+		"createdAt": leaf(func(d dashboardbus.Dashboard) any { return d.CreatedAt.Format(time.RFC3339) }),
+		"updatedAt": leaf(func(d dashboardbus.Dashboard) any { return d.UpdatedAt.Format(time.RFC3339) }),
+		"tenant":    {Type: tenantType, Resolve: a.dashboardTenant},
+		"pages":     {Type: pageType, Resolve: a.dashboardPages},
+		"myActions": {Resolve: a.dashboardMyActions},
+	}
+
+	pageType.Fields = map[string]*graphql.Field{
+		"id":        leaf(func(p dashboardbus.Page) any { return p.ID.String() }),
+		"title":     leaf(func(p dashboardbus.Page) any { return p.Title }),
+		"layout":    leaf(func(p dashboardbus.Page) any { return p.Layout }),
+		"text":      leaf(func(p dashboardbus.Page) any { return p.Text }),
+		"order":     leaf(func(p dashboardbus.Page) any { return p.Order }),
+		"feedId":    leaf(func(p dashboardbus.Page) any { return p.FeedID }),
+		"createdAt": leaf(func(p dashboardbus.Page) any { return p.CreatedAt.Format(time.RFC3339) }),
+		"updatedAt": leaf(func(p dashboardbus.Page) any { return p.UpdatedAt.Format(time.RFC3339) }),
+	}
+
+	permissionsType.Fields = map[string]*graphql.Field{
+		"userId":    leaf(func(p aclbus.Permissions) any { return p.UserID.String() }),
+		"role":      leaf(func(p aclbus.Permissions) any { return p.Role.String() }),
+		"types":     object(typePermissionType, func(p aclbus.Permissions) any { return p.Types }),
+		"resources": object(resourcePermissionType, func(p aclbus.Permissions) any { return p.Resources }),
+	}
+
+	typePermissionType.Fields = map[string]*graphql.Field{
+		"resourceType": leaf(func(tp aclbus.TypePolicy) any { return tp.ResourceType.String() }),
+		"actions":      leaf(func(tp aclbus.TypePolicy) any { return toAppActions(tp.Actions) }),
+	}
+
+	resourcePermissionType.Fields = map[string]*graphql.Field{
+		"resourceId":   leaf(func(acl aclbus.ACL) any { return acl.ResourceID.String() }),
+		"resourceType": leaf(func(acl aclbus.ACL) any { return acl.ResourceType.String() }),
+		"actions":      leaf(func(acl aclbus.ACL) any { return toAppActions(acl.Actions) }),
+		"expiresAt": leaf(func(acl aclbus.ACL) any {
+			if acl.ExpiresAt == nil {
+				return nil
+			}
+			return acl.ExpiresAt.Format(time.RFC3339)
+		}),
+	}
+
+	query := graphql.Object{
+		Name: "Query",
+		Fields: map[string]*graphql.Field{
+			"me":          {Type: userType, Resolve: a.me},
+			"user":        {Type: userType, Args: []string{"id"}, Resolve: a.user},
+			"users":       {Type: userListType, Args: []string{"page", "rows", "orderBy", "name", "email"}, Resolve: a.users},
+			"tenant":      {Type: tenantType, Args: []string{"id"}, Resolve: a.tenant},
+			"dashboard":   {Type: dashboardType, Args: []string{"id"}, Resolve: a.dashboard},
+			"permissions": {Type: permissionsType, Resolve: a.permissions},
+		},
+	}
+
+	return &graphql.Schema{
+		Query:        &query,
+		MaxDepth:     maxDepth,
+		MaxNodes:     maxNodes,
+		MaxFields:    maxFields,
+		PresentError: a.presentError,
+	}
+}
+
+// leaf declares a field read straight from the source value.
+func leaf[T any](get func(T) any) *graphql.Field {
+	return &graphql.Field{
+		Resolve: func(_ context.Context, p graphql.Params) (any, error) {
+			return get(p.Source.(T)), nil
+		},
+	}
+}
+
+// object declares a field holding other objects already loaded with the
+// source value.
+func object[T any](typ *graphql.Object, get func(T) any) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(_ context.Context, p graphql.Params) (any, error) {
+			return get(p.Source.(T)), nil
+		},
+	}
+}
+
+func toAppActions(acts []actions.Action) []string {
+	app := make([]string, len(acts))
+	for i, a := range acts {
+		app[i] = a.String()
+	}
+	return app
+}
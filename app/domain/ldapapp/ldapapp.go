@@ -0,0 +1,155 @@
+// Package ldapapp maintains the app layer api for the ldap domain.
+package ldapapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// testTimeout bounds a connection test, so an unreachable host doesn't hold
+// the request until the write timeout.
+const testTimeout = 8 * time.Second
+
+type app struct {
+	ldapBus   *ldapbus.Core
+	tenantBus *tenantbus.Core
+}
+
+func newApp(ldapBus *ldapbus.Core, tenantBus *tenantbus.Core) *app {
+	return &app{
+		ldapBus:   ldapBus,
+		tenantBus: tenantBus,
+	}
+}
+
+// save configures the directory of a tenant.
+func (a *app) save(ctx context.Context, r *http.Request) web.Encoder {
+	var app SaveDirectory
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	sd, err := toBusSaveDirectory(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	d, err := a.ldapBus.Save(ctx, tenantID, sd)
+	if err != nil {
+		return toAppError(err, "save: tenantID[%s]: %s", tenantID)
+	}
+
+	return toAppDirectory(d)
+}
+
+// delete removes the directory of a tenant.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDirectory(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.ldapBus.Delete(ctx, d); err != nil {
+		return toAppError(err, "delete: tenantID[%s]: %s", d.TenantID)
+	}
+
+	return nil
+}
+
+// query returns the directory of a tenant.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDirectory(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppDirectory(d)
+}
+
+// test connects to the directory and binds with the service account.
+func (a *app) test(ctx context.Context, r *http.Request) web.Encoder {
+	d, errEnc := a.queryDirectory(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	err := a.ldapBus.Test(ctx, d)
+
+	result := TestResult{
+		OK:        err == nil,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ldapbus.ErrConnection):
+		result.Error = err.Error()
+	default:
+		return errs.Errorf(errs.Internal, "test: tenantID[%s]: %s", d.TenantID, err)
+	}
+
+	return result
+}
+
+// =============================================================================
+
+func (a *app) queryTenantID(ctx context.Context, r *http.Request) (uuid.UUID, *errs.Error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	if _, err := a.tenantBus.QueryByID(ctx, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return uuid.Nil, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return uuid.Nil, errs.Errorf(errs.Internal, "query tenant: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return tenantID, nil
+}
+
+func (a *app) queryDirectory(ctx context.Context, r *http.Request) (ldapbus.Directory, *errs.Error) {
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return ldapbus.Directory{}, errEnc
+	}
+
+	d, err := a.ldapBus.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return ldapbus.Directory{}, toAppError(err, "query: tenantID[%s]: %s", tenantID)
+	}
+
+	return d, nil
+}
+
+// toAppError translates the errors of the ldap core. The format must have a
+// verb for id followed by one for the error.
+func toAppError(err error, format string, id uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, ldapbus.ErrNotFound):
+		return errs.New(errs.NotFound, ldapbus.ErrNotFound).WithReason(errs.ReasonLDAPNotFound)
+	case errors.Is(err, ldapbus.ErrInvalidSettings), errors.Is(err, ldapbus.ErrInvalidRole):
+		return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonLDAPInvalid)
+	}
+
+	return errs.Errorf(errs.Internal, format, id, err)
+}
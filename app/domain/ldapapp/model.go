@@ -0,0 +1,165 @@
+package ldapapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Directory represents the directory of a tenant. The bind password is never
+// returned, only whether it is set.
+type Directory struct {
+	TenantID        string        `json:"tenantId"`
+	URL             string        `json:"url"`
+	StartTLS        bool          `json:"startTls"`
+	BindDN          string        `json:"bindDn"`
+	HasBindPassword bool          `json:"hasBindPassword"`
+	BaseDN          string        `json:"baseDn"`
+	UserFilter      string        `json:"userFilter"`
+	GroupAttribute  string        `json:"groupAttribute"`
+	NameAttribute   string        `json:"nameAttribute"`
+	RoleMappings    []RoleMapping `json:"roleMappings"`
+	DefaultRole     string        `json:"defaultRole,omitempty"`
+	Enabled         bool          `json:"enabled"`
+	CreatedAt       string        `json:"createdAt"`
+	UpdatedAt       string        `json:"updatedAt"`
+}
+
+// RoleMapping gives the role to the members of a group of the directory.
+type RoleMapping struct {
+	Group string `json:"group" validate:"required,max=512"`
+	Role  string `json:"role" validate:"required"`
+}
+
+// Encode implements the web.Encoder interface.
+func (d Directory) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppDirectory(bus ldapbus.Directory) Directory {
+	mappings := make([]RoleMapping, len(bus.RoleMappings))
+	for i, m := range bus.RoleMappings {
+		mappings[i] = RoleMapping{
+			Group: m.Group,
+			Role:  m.Role.String(),
+		}
+	}
+
+	d := Directory{
+		TenantID:        bus.TenantID.String(),
+		URL:             bus.URL,
+		StartTLS:        bus.StartTLS,
+		BindDN:          bus.BindDN,
+		HasBindPassword: len(bus.BindPassword) > 0,
+		BaseDN:          bus.BaseDN,
+		UserFilter:      bus.UserFilter,
+		GroupAttribute:  bus.GroupAttribute,
+		NameAttribute:   bus.NameAttribute,
+		RoleMappings:    mappings,
+		Enabled:         bus.Enabled,
+		CreatedAt:       bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       bus.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if bus.DefaultRole != nil {
+		d.DefaultRole = bus.DefaultRole.String()
+	}
+
+	return d
+}
+
+// =============================================================================
+
+// SaveDirectory defines the data needed to configure the directory of a
+// tenant. The bind password can be left out to keep the current one; empty
+// filter and attributes take the defaults.
+type SaveDirectory struct {
+	URL            string        `json:"url" validate:"required,max=255"`
+	StartTLS       bool          `json:"startTls"`
+	BindDN         string        `json:"bindDn" validate:"required,max=512"`
+	BindPassword   *string       `json:"bindPassword" validate:"omitempty,min=1"`
+	BaseDN         string        `json:"baseDn" validate:"required,max=512"`
+	UserFilter     string        `json:"userFilter" validate:"max=512"`
+	GroupAttribute string        `json:"groupAttribute" validate:"max=64"`
+	NameAttribute  string        `json:"nameAttribute" validate:"max=64"`
+	RoleMappings   []RoleMapping `json:"roleMappings" validate:"dive"`
+	DefaultRole    *string       `json:"defaultRole"`
+	Enabled        *bool         `json:"enabled"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *SaveDirectory) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app SaveDirectory) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusSaveDirectory(app SaveDirectory) (ldapbus.SaveDirectory, error) {
+	mappings := make([]ldapbus.RoleMapping, len(app.RoleMappings))
+	for i, m := range app.RoleMappings {
+		r, err := role.Parse(m.Role)
+		if err != nil {
+			return ldapbus.SaveDirectory{}, fmt.Errorf("parse roleMappings[%d]: %w", i, err)
+		}
+
+		mappings[i] = ldapbus.RoleMapping{
+			Group: m.Group,
+			Role:  r,
+		}
+	}
+
+	var defaultRole *role.Role
+	if app.DefaultRole != nil {
+		r, err := role.Parse(*app.DefaultRole)
+		if err != nil {
+			return ldapbus.SaveDirectory{}, fmt.Errorf("parse defaultRole: %w", err)
+		}
+		defaultRole = &r
+	}
+
+	enabled := true
+	if app.Enabled != nil {
+		enabled = *app.Enabled
+	}
+
+	return ldapbus.SaveDirectory{
+		URL:            app.URL,
+		StartTLS:       app.StartTLS,
+		BindDN:         app.BindDN,
+		BindPassword:   app.BindPassword,
+		BaseDN:         app.BaseDN,
+		UserFilter:     app.UserFilter,
+		GroupAttribute: app.GroupAttribute,
+		NameAttribute:  app.NameAttribute,
+		RoleMappings:   mappings,
+		DefaultRole:    defaultRole,
+		Enabled:        enabled,
+	}, nil
+}
+
+// =============================================================================
+
+// TestResult is the outcome of a connection test. A failed connection is
+// not an error of the request, the reason is reported in Error.
+type TestResult struct {
+	OK        bool   `json:"ok"`
+	LatencyMS int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Encode implements the web.Encoder interface.
+func (t TestResult) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(t)
+	return data, "application/json", err
+}
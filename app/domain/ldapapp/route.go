@@ -0,0 +1,39 @@
+package ldapapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	LDAPBus     *ldapbus.Core
+	TenantBus   *tenantbus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	// O diretório decide quem entra no cliente: só o ADMIN o configura.
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+
+	api := newApp(cfg.LDAPBus, cfg.TenantBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/tenants/{tenant_id}/ldap", api.query, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/tenants/{tenant_id}/ldap", api.save, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/tenants/{tenant_id}/ldap", api.delete, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/tenants/{tenant_id}/ldap/test", api.test, authen, limit, admin)
+}
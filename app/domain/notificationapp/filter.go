@@ -0,0 +1,52 @@
+package notificationapp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+)
+
+type queryParams struct {
+	Page   string
+	Rows   string
+	Kind   string
+	Unread string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	return queryParams{
+		Page:   values.Get("page"),
+		Rows:   values.Get("rows"),
+		Kind:   values.Get("kind"),
+		Unread: values.Get("unread"),
+	}
+}
+
+func parseFilter(qp queryParams) (notificationbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter notificationbus.QueryFilter
+
+	if qp.Kind != "" {
+		filter.Kind = &qp.Kind
+	}
+
+	if qp.Unread != "" {
+		unread, err := strconv.ParseBool(qp.Unread)
+		switch err {
+		case nil:
+			filter.Unread = &unread
+		default:
+			fieldErrors.Add("unread", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return notificationbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,78 @@
+package notificationapp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+)
+
+// Notification represents a message in the inbox of the user.
+type Notification struct {
+	ID          string         `json:"id"`
+	TenantID    string         `json:"tenantId,omitempty"`
+	Kind        string         `json:"kind"`
+	EntityType  string         `json:"entityType"`
+	EntityID    string         `json:"entityId,omitempty"`
+	Data        map[string]any `json:"data"`
+	Read        bool           `json:"read"`
+	DateRead    string         `json:"dateRead,omitempty"`
+	DateCreated string         `json:"dateCreated"`
+}
+
+// Encode implements the encoder interface.
+func (app Notification) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppNotification(bus notificationbus.Notification) Notification {
+	n := Notification{
+		ID:          bus.ID.String(),
+		Kind:        bus.Kind,
+		EntityType:  bus.EntityType,
+		Data:        bus.Data,
+		Read:        bus.ReadAt != nil,
+		DateCreated: bus.CreatedAt.Format(time.RFC3339),
+	}
+
+	if n.Data == nil {
+		n.Data = map[string]any{}
+	}
+
+	if bus.TenantID != uuid.Nil {
+		n.TenantID = bus.TenantID.String()
+	}
+
+	if bus.EntityID != uuid.Nil {
+		n.EntityID = bus.EntityID.String()
+	}
+
+	if bus.ReadAt != nil {
+		n.DateRead = bus.ReadAt.Format(time.RFC3339)
+	}
+
+	return n
+}
+
+func toAppNotifications(notifications []notificationbus.Notification) []Notification {
+	app := make([]Notification, len(notifications))
+	for i, n := range notifications {
+		app[i] = toAppNotification(n)
+	}
+	return app
+}
+
+// =============================================================================
+
+// Unread is the number of unread notifications, the badge of the bell.
+type Unread struct {
+	Count int `json:"count"`
+}
+
+// Encode implements the encoder interface.
+func (app Unread) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
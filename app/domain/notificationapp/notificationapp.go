@@ -0,0 +1,116 @@
+// Package notificationapp maintains the app layer api for the notification
+// domain. Every route works on the inbox of the authenticated user.
+package notificationapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	notificationBus *notificationbus.Core
+}
+
+func newApp(notificationBus *notificationbus.Core) *app {
+	return &app{
+		notificationBus: notificationBus,
+	}
+}
+
+// query returns the notifications of the user with paging, most recent
+// first.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	filter.UserID = &userID
+
+	notifications, err := a.notificationBus.Query(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "query: %s", err)
+	}
+
+	total, err := a.notificationBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "count: %s", err)
+	}
+
+	return query.NewResult(toAppNotifications(notifications), total, page)
+}
+
+// queryUnread returns the number of unread notifications of the user.
+func (a *app) queryUnread(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	n, err := a.notificationBus.CountUnread(ctx, userID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "countUnread: %s", err)
+	}
+
+	return Unread{Count: n}
+}
+
+// markRead marks a notification of the user as read.
+func (a *app) markRead(ctx context.Context, r *http.Request) web.Encoder {
+	notificationID, err := uuid.Parse(web.Param(r, "notification_id"))
+	if err != nil {
+		return errs.NewFieldErrors("notification_id", err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	if err := a.notificationBus.MarkRead(ctx, userID, notificationID); err != nil {
+		if errors.Is(err, notificationbus.ErrNotFound) {
+			return errs.New(errs.NotFound, notificationbus.ErrNotFound).WithReason(errs.ReasonNotificationNotFound)
+		}
+		return errs.Errorf(errs.Internal, "markRead: notificationID[%s]: %s", notificationID, err)
+	}
+
+	return nil
+}
+
+// markAllRead marks every notification of the user as read.
+func (a *app) markAllRead(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	if _, err := a.notificationBus.MarkAllRead(ctx, userID); err != nil {
+		return errs.Errorf(errs.Internal, "markAllRead: userID[%s]: %s", userID, err)
+	}
+
+	return nil
+}
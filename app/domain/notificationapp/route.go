@@ -0,0 +1,36 @@
+package notificationapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth            *auth.Auth
+	NotificationBus *notificationbus.Core
+	RateLimiter     ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, nil)
+
+	api := newApp(cfg.NotificationBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/notifications", api.query, authen, limit)
+	app.HandlerFunc(http.MethodGet, version, "/notifications/unread", api.queryUnread, authen, limit)
+	app.HandlerFunc(http.MethodPost, version, "/notifications/read", api.markAllRead, authen, limit)
+	app.HandlerFunc(http.MethodPost, version, "/notifications/{notification_id}/read", api.markRead, authen, limit)
+
+	// GET /v1/notifications/stream
+	app.HandlerFunc(http.MethodGet, version, "/notifications/stream", api.stream, authen, limit)
+}
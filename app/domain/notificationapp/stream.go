@@ -0,0 +1,112 @@
+package notificationapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+const (
+	// streamHeartbeat keeps the connection alive through the proxies.
+	streamHeartbeat = 25 * time.Second
+
+	// streamLifetime bounds a connection, so a shutdown does not wait for the
+	// streams; the client reconnects after streamRetry.
+	streamLifetime = 5 * time.Minute
+	streamRetry    = 3 * time.Second
+)
+
+// stream pushes the new notifications of the user as server-sent events. The
+// first event is the unread count, then a "notification" event for each new
+// notification. A "permissions" event tells the dashboard the ACLs or the
+// role of the user changed, so it reloads the permissions instead of waiting
+// for a 403. The route takes the bearer token, so the browser must open it
+// with fetch instead of EventSource.
+func (a *app) stream(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	w := web.GetWriter(ctx)
+	rc := http.NewResponseController(w)
+
+	// O WEB_WRITE_TIMEOUT derrubaria o stream; ele é limitado pelo
+	// streamLifetime.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		return errs.Errorf(errs.Unimplemented, "stream: %s", err)
+	}
+
+	unread, err := a.notificationBus.CountUnread(ctx, userID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "countUnread: %s", err)
+	}
+
+	ch, cancel := a.notificationBus.Subscribe(userID)
+	defer cancel()
+
+	access, cancelAccess := a.notificationBus.SubscribeAccess(userID)
+	defer cancelAccess()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	writeEvent(w, "unread", "", Unread{Count: unread})
+
+	if err := rc.Flush(); err != nil {
+		return web.NewNoResponse()
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	lifetime := time.NewTimer(streamLifetime)
+	defer lifetime.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return web.NewNoResponse()
+
+		case <-lifetime.C:
+			return web.NewNoResponse()
+
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+
+		case n := <-ch:
+			writeEvent(w, "notification", n.ID.String(), toAppNotification(n))
+
+		case <-access:
+			writeEvent(w, "permissions", "", struct{}{})
+		}
+
+		if err := rc.Flush(); err != nil {
+			return web.NewNoResponse()
+		}
+	}
+}
+
+// writeEvent writes a server-sent event with the value in JSON.
+func writeEvent(w io.Writer, event string, id string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
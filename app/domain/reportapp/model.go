@@ -0,0 +1,145 @@
+package reportapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+)
+
+// Report represents a report and the link to download it once done.
+type Report struct {
+	ID          string         `json:"id"`
+	DashboardID string         `json:"dashboardId"`
+	WidgetID    string         `json:"widgetId,omitempty"`
+	Format      string         `json:"format"`
+	Status      string         `json:"status"`
+	Params      map[string]any `json:"params,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	FileName    string         `json:"fileName,omitempty"`
+	Size        int64          `json:"size,omitempty"`
+	DownloadURL string         `json:"downloadUrl,omitempty"`
+	CreatedAt   string         `json:"createdAt"`
+	FinishedAt  string         `json:"finishedAt,omitempty"`
+	ExpiresAt   string         `json:"expiresAt"`
+
+	status int
+}
+
+// Encode implements the web.Encoder interface.
+func (r Report) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(r)
+	return data, "application/json", err
+}
+
+// HTTPStatus implements the web.httpStatus interface. A new report is
+// accepted, not yet rendered.
+func (r Report) HTTPStatus() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+
+	return r.status
+}
+
+// toAppReport converts the report. The link is only set when the report is
+// done, signed with the expiry and the signature.
+func toAppReport(bus reportbus.Report, expires int64, signature string) Report {
+	r := Report{
+		ID:          bus.ID.String(),
+		DashboardID: bus.DashboardID.String(),
+		Format:      bus.Format,
+		Status:      bus.Status,
+		Params:      bus.Params,
+		Error:       bus.Error,
+		FileName:    bus.FileName,
+		Size:        bus.Size,
+		CreatedAt:   bus.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:   bus.ExpiresAt.Format(time.RFC3339),
+	}
+
+	if bus.WidgetID != nil {
+		r.WidgetID = bus.WidgetID.String()
+	}
+
+	if bus.FinishedAt != nil {
+		r.FinishedAt = bus.FinishedAt.Format(time.RFC3339)
+	}
+
+	if signature != "" {
+		q := url.Values{
+			"expires":   {strconv.FormatInt(expires, 10)},
+			"signature": {signature},
+		}
+		r.DownloadURL = fmt.Sprintf("/v1/reports/%s/download?%s", bus.ID, q.Encode())
+	}
+
+	return r
+}
+
+// NewReport defines the data needed to request a report.
+type NewReport struct {
+	Format   string         `json:"format" validate:"required,oneof=csv xlsx pdf"`
+	WidgetID string         `json:"widgetId" validate:"omitempty,uuid"`
+	Params   map[string]any `json:"params"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewReport) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewReport) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+
+	for name, v := range app.Params {
+		switch v.(type) {
+		case string, float64, bool, nil:
+		default:
+			return errs.NewFieldErrors("params."+name, errors.New("must be a string, number, boolean or null"))
+		}
+	}
+
+	return nil
+}
+
+func toBusNewReport(app NewReport, tenantID uuid.UUID, dashboardID uuid.UUID, userID uuid.UUID) (reportbus.NewReport, error) {
+	nr := reportbus.NewReport{
+		TenantID:    tenantID,
+		DashboardID: dashboardID,
+		UserID:      userID,
+		Format:      app.Format,
+		Params:      app.Params,
+	}
+
+	if app.WidgetID != "" {
+		widgetID, err := uuid.Parse(app.WidgetID)
+		if err != nil {
+			return reportbus.NewReport{}, fmt.Errorf("parse widgetID: %w", err)
+		}
+		nr.WidgetID = &widgetID
+	}
+
+	return nr, nil
+}
+
+// File is the rendered report sent for download.
+type File struct {
+	data        []byte
+	contentType string
+}
+
+// Encode implements the web.Encoder interface.
+func (f File) Encode() ([]byte, string, error) {
+	return f.data, f.contentType, nil
+}
@@ -0,0 +1,179 @@
+// Package reportapp maintains the app layer api for the report domain.
+package reportapp
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type app struct {
+	auth          *auth.Auth
+	reportBus     *reportbus.Core
+	dashboardBus  *dashboardbus.Core
+	datasourceBus *datasourcebus.Core
+}
+
+func newApp(auth *auth.Auth, reportBus *reportbus.Core, dashboardBus *dashboardbus.Core, datasourceBus *datasourcebus.Core) *app {
+	return &app{
+		auth:          auth,
+		reportBus:     reportBus,
+		dashboardBus:  dashboardBus,
+		datasourceBus: datasourceBus,
+	}
+}
+
+// create requests a report of the dashboard, or of one of its widgets, and
+// returns it pending.
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewReport
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	dashboardID, err := uuid.Parse(web.Param(r, "dashboard_id"))
+	if err != nil {
+		return errs.NewFieldErrors("dashboard_id", err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	d, err := a.dashboardBus.QueryByID(ctx, dashboardID)
+	if err != nil {
+		if errors.Is(err, dashboardbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonResourceNotFound)
+		}
+		return errs.Errorf(errs.Internal, "query dashboard: dashboardID[%s]: %s", dashboardID, err)
+	}
+
+	nr, err := toBusNewReport(app, d.TenantID, d.ID, userID)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	// A ACL foi checada no dashboard da rota: o widget tem de ser dele.
+	if nr.WidgetID != nil {
+		w, err := a.datasourceBus.QueryWidget(ctx, *nr.WidgetID)
+		if err != nil && !errors.Is(err, datasourcebus.ErrWidgetNotFound) {
+			return errs.Errorf(errs.Internal, "query widget: widgetID[%s]: %s", *nr.WidgetID, err)
+		}
+
+		if err != nil || w.DashboardID != d.ID {
+			return errs.New(errs.NotFound, datasourcebus.ErrWidgetNotFound).WithReason(errs.ReasonWidgetNotFound)
+		}
+	}
+
+	rep, err := a.reportBus.Create(ctx, nr)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "create: dashboardID[%s]: %s", d.ID, err)
+	}
+
+	resp := toAppReport(rep, 0, "")
+	resp.status = http.StatusAccepted
+
+	return resp
+}
+
+// queryByID returns the status of a report, with the download link once it
+// is done. Only the user who requested it, or an ADMIN, sees it.
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	rep, errEnc := a.queryReport(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	if rep.UserID != userID && a.auth.Authorize(ctx, mid.GetClaims(ctx), role.Admin) != nil {
+		return errs.New(errs.NotFound, reportbus.ErrNotFound).WithReason(errs.ReasonReportNotFound)
+	}
+
+	if rep.Status != reportbus.StatusDone {
+		return toAppReport(rep, 0, "")
+	}
+
+	expires, signature := a.reportBus.Sign(rep)
+
+	return toAppReport(rep, expires, signature)
+}
+
+// download sends the file of a report. The link is signed, so the request
+// carries no token and can be opened by the browser.
+func (a *app) download(ctx context.Context, r *http.Request) web.Encoder {
+	reportID, err := uuid.Parse(web.Param(r, "report_id"))
+	if err != nil {
+		return errs.NewFieldErrors("report_id", err)
+	}
+
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		return errs.New(errs.PermissionDenied, reportbus.ErrInvalidSignature).WithReason(errs.ReasonReportLinkInvalid)
+	}
+
+	if err := a.reportBus.Verify(reportID, expires, r.URL.Query().Get("signature")); err != nil {
+		return errs.New(errs.PermissionDenied, err).WithReason(errs.ReasonReportLinkInvalid)
+	}
+
+	rep, errEnc := a.queryReport(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	file, err := a.reportBus.File(ctx, rep)
+	if err != nil {
+		switch {
+		case errors.Is(err, reportbus.ErrNotReady):
+			return errs.New(errs.FailedPrecondition, err).WithReason(errs.ReasonReportNotReady)
+		case errors.Is(err, reportbus.ErrExpired), errors.Is(err, reportbus.ErrNotFound):
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonReportNotFound)
+		}
+		return errs.Errorf(errs.Internal, "file: reportID[%s]: %s", rep.ID, err)
+	}
+
+	w := web.GetWriter(ctx)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Data)))
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	return File{
+		data:        file.Data,
+		contentType: file.ContentType,
+	}
+}
+
+// =============================================================================
+
+func (a *app) queryReport(ctx context.Context, r *http.Request) (reportbus.Report, *errs.Error) {
+	reportID, err := uuid.Parse(web.Param(r, "report_id"))
+	if err != nil {
+		return reportbus.Report{}, errs.NewFieldErrors("report_id", err)
+	}
+
+	rep, err := a.reportBus.QueryByID(ctx, reportID)
+	if err != nil {
+		if errors.Is(err, reportbus.ErrNotFound) {
+			return reportbus.Report{}, errs.New(errs.NotFound, reportbus.ErrNotFound).WithReason(errs.ReasonReportNotFound)
+		}
+		return reportbus.Report{}, errs.Errorf(errs.Internal, "query: reportID[%s]: %s", reportID, err)
+	}
+
+	return rep, nil
+}
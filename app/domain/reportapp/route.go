@@ -0,0 +1,47 @@
+package reportapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth          *auth.Auth
+	ACLBus        *aclbus.Core
+	ReportBus     *reportbus.Core
+	DashboardBus  *dashboardbus.Core
+	DatasourceBus *datasourcebus.Core
+	TenantBus     *tenantbus.Core
+	RateLimiter   ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	// Exportar é ver os dados: quem vê o dashboard pode pedir o relatório.
+	canGetDashboard := mid.AuthorizeResource(cfg.ACLBus, resource.Dashboard, actions.Get, "dashboard_id")
+
+	api := newApp(cfg.Auth, cfg.ReportBus, cfg.DashboardBus, cfg.DatasourceBus)
+
+	app.HandlerFunc(http.MethodPost, version, "/dashboards/{dashboard_id}/reports", api.create, authen, limit, canGetDashboard)
+	app.HandlerFunc(http.MethodGet, version, "/reports/{report_id}", api.queryByID, authen, limit)
+
+	// O link é assinado: o download não leva token.
+	app.HandlerFunc(http.MethodGet, version, "/reports/{report_id}/download", api.download, limit)
+}
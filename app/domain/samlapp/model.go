@@ -0,0 +1,218 @@
+package samlapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/saml"
+)
+
+// Provider represents the identity provider of a tenant, with the addresses
+// of the service provider to register in it.
+type Provider struct {
+	TenantID           string             `json:"tenantId"`
+	EntityID           string             `json:"entityId"`
+	SSOURL             string             `json:"ssoUrl"`
+	Certificate        string             `json:"certificate"`
+	EmailAttribute     string             `json:"emailAttribute"`
+	NameAttribute      string             `json:"nameAttribute"`
+	RoleAttribute      string             `json:"roleAttribute"`
+	RoleMappings       []RoleMapping      `json:"roleMappings"`
+	DefaultRole        string             `json:"defaultRole,omitempty"`
+	DashboardAttribute string             `json:"dashboardAttribute"`
+	DashboardMappings  []DashboardMapping `json:"dashboardMappings"`
+	Enabled            bool               `json:"enabled"`
+	SPEntityID         string             `json:"spEntityId"`
+	SPACSURL           string             `json:"spAcsUrl"`
+	CreatedAt          string             `json:"createdAt"`
+	UpdatedAt          string             `json:"updatedAt"`
+}
+
+// RoleMapping gives the role to the users with the value in the role
+// attribute.
+type RoleMapping struct {
+	Value string `json:"value" validate:"required,max=255"`
+	Role  string `json:"role" validate:"required"`
+}
+
+// DashboardMapping gives access to the dashboard to the users with the value
+// in the dashboard attribute.
+type DashboardMapping struct {
+	Value       string `json:"value" validate:"required,max=255"`
+	DashboardID string `json:"dashboardId" validate:"required,uuid"`
+}
+
+// Encode implements the web.Encoder interface.
+func (p Provider) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(p)
+	return data, "application/json", err
+}
+
+func toAppProvider(bus samlbus.Provider, sp saml.ServiceProvider) Provider {
+	roles := make([]RoleMapping, len(bus.RoleMappings))
+	for i, m := range bus.RoleMappings {
+		roles[i] = RoleMapping{
+			Value: m.Value,
+			Role:  m.Role.String(),
+		}
+	}
+
+	dashboards := make([]DashboardMapping, len(bus.DashboardMappings))
+	for i, m := range bus.DashboardMappings {
+		dashboards[i] = DashboardMapping{
+			Value:       m.Value,
+			DashboardID: m.DashboardID.String(),
+		}
+	}
+
+	p := Provider{
+		TenantID:           bus.TenantID.String(),
+		EntityID:           bus.EntityID,
+		SSOURL:             bus.SSOURL,
+		Certificate:        bus.Certificate,
+		EmailAttribute:     bus.EmailAttribute,
+		NameAttribute:      bus.NameAttribute,
+		RoleAttribute:      bus.RoleAttribute,
+		RoleMappings:       roles,
+		DashboardAttribute: bus.DashboardAttribute,
+		DashboardMappings:  dashboards,
+		Enabled:            bus.Enabled,
+		SPEntityID:         sp.EntityID,
+		SPACSURL:           sp.ACSURL,
+		CreatedAt:          bus.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:          bus.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if bus.DefaultRole != nil {
+		p.DefaultRole = bus.DefaultRole.String()
+	}
+
+	return p
+}
+
+// =============================================================================
+
+// SaveProvider defines the data needed to configure the identity provider
+// of a tenant. The certificate is the PEM of the signing certificate of the
+// provider; more than one can be given while it rolls its key.
+type SaveProvider struct {
+	EntityID           string             `json:"entityId" validate:"required,max=512"`
+	SSOURL             string             `json:"ssoUrl" validate:"required,url,max=1024"`
+	Certificate        string             `json:"certificate" validate:"required"`
+	EmailAttribute     string             `json:"emailAttribute" validate:"max=255"`
+	NameAttribute      string             `json:"nameAttribute" validate:"max=255"`
+	RoleAttribute      string             `json:"roleAttribute" validate:"max=255"`
+	RoleMappings       []RoleMapping      `json:"roleMappings" validate:"dive"`
+	DefaultRole        *string            `json:"defaultRole"`
+	DashboardAttribute string             `json:"dashboardAttribute" validate:"max=255"`
+	DashboardMappings  []DashboardMapping `json:"dashboardMappings" validate:"dive"`
+	Enabled            *bool              `json:"enabled"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *SaveProvider) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app SaveProvider) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusSaveProvider(app SaveProvider) (samlbus.SaveProvider, error) {
+	roles := make([]samlbus.RoleMapping, len(app.RoleMappings))
+	for i, m := range app.RoleMappings {
+		r, err := role.Parse(m.Role)
+		if err != nil {
+			return samlbus.SaveProvider{}, fmt.Errorf("parse roleMappings[%d]: %w", i, err)
+		}
+
+		roles[i] = samlbus.RoleMapping{
+			Value: m.Value,
+			Role:  r,
+		}
+	}
+
+	dashboards := make([]samlbus.DashboardMapping, len(app.DashboardMappings))
+	for i, m := range app.DashboardMappings {
+		dashboardID, err := uuid.Parse(m.DashboardID)
+		if err != nil {
+			return samlbus.SaveProvider{}, fmt.Errorf("parse dashboardMappings[%d]: %w", i, err)
+		}
+
+		dashboards[i] = samlbus.DashboardMapping{
+			Value:       m.Value,
+			DashboardID: dashboardID,
+		}
+	}
+
+	var defaultRole *role.Role
+	if app.DefaultRole != nil {
+		r, err := role.Parse(*app.DefaultRole)
+		if err != nil {
+			return samlbus.SaveProvider{}, fmt.Errorf("parse defaultRole: %w", err)
+		}
+		defaultRole = &r
+	}
+
+	enabled := true
+	if app.Enabled != nil {
+		enabled = *app.Enabled
+	}
+
+	return samlbus.SaveProvider{
+		EntityID:           app.EntityID,
+		SSOURL:             app.SSOURL,
+		Certificate:        app.Certificate,
+		EmailAttribute:     app.EmailAttribute,
+		NameAttribute:      app.NameAttribute,
+		RoleAttribute:      app.RoleAttribute,
+		RoleMappings:       roles,
+		DefaultRole:        defaultRole,
+		DashboardAttribute: app.DashboardAttribute,
+		DashboardMappings:  dashboards,
+		Enabled:            enabled,
+	}, nil
+}
+
+// =============================================================================
+
+// Metadata is the metadata document of the service provider.
+type Metadata []byte
+
+// Encode implements the web.Encoder interface.
+func (m Metadata) Encode() ([]byte, string, error) {
+	return m, "application/samlmetadata+xml", nil
+}
+
+// Redirect sends the browser to another location.
+type Redirect struct{}
+
+// Encode implements the web.Encoder interface.
+func (Redirect) Encode() ([]byte, string, error) {
+	return nil, "text/plain; charset=utf-8", nil
+}
+
+// HTTPStatus implements the web.httpStatus interface.
+func (Redirect) HTTPStatus() int {
+	return http.StatusSeeOther
+}
+
+func redirect(ctx context.Context, location string) Redirect {
+	w := web.GetWriter(ctx)
+	w.Header().Set("Location", location)
+	w.Header().Set("Cache-Control", "no-store")
+
+	return Redirect{}
+}
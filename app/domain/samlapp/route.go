@@ -0,0 +1,50 @@
+package samlapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	SAMLBus     *samlbus.Core
+	UserBus     *userbus.Core
+	TenantBus   *tenantbus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+
+	// O provedor de identidade decide quem entra no cliente: só o ADMIN o
+	// configura.
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+
+	// A resposta do provedor carrega a asserção, que vale como credencial.
+	noAudit := mid.NoAudit()
+
+	api := newApp(cfg)
+
+	app.HandlerFunc(http.MethodGet, version, "/tenants/{tenant_id}/saml", api.query, authen, limit, admin)
+	app.HandlerFunc(http.MethodPut, version, "/tenants/{tenant_id}/saml", api.save, authen, limit, admin)
+	app.HandlerFunc(http.MethodDelete, version, "/tenants/{tenant_id}/saml", api.delete, authen, limit, admin)
+
+	// As rotas do service provider são abertas: o navegador chega nelas
+	// vindo do provedor de identidade, ainda sem token.
+	app.HandlerFunc(http.MethodGet, version, "/saml/{tenant_id}/metadata", api.metadata, limit)
+	app.HandlerFunc(http.MethodGet, version, "/saml/{tenant_id}/login", api.login, limit)
+	app.HandlerFunc(http.MethodPost, version, "/saml/{tenant_id}/acs", api.acs, limit, noAudit)
+}
@@ -0,0 +1,274 @@
+// Package samlapp maintains the app layer api for the saml domain.
+package samlapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/saml"
+)
+
+// maxResponseSize bounds the form posted to the ACS. Signed responses have a
+// few kilobytes, base64 encoded.
+const maxResponseSize = 1 << 20
+
+type app struct {
+	auth      *auth.Auth
+	samlBus   *samlbus.Core
+	userBus   *userbus.Core
+	tenantBus *tenantbus.Core
+}
+
+func newApp(cfg Config) *app {
+	return &app{
+		auth:      cfg.Auth,
+		samlBus:   cfg.SAMLBus,
+		userBus:   cfg.UserBus,
+		tenantBus: cfg.TenantBus,
+	}
+}
+
+// save configures the identity provider of a tenant.
+func (a *app) save(ctx context.Context, r *http.Request) web.Encoder {
+	var app SaveProvider
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	sp, err := toBusSaveProvider(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	p, err := a.samlBus.Save(ctx, tenantID, sp)
+	if err != nil {
+		return toAppError(err, "save: tenantID[%s]: %s", tenantID)
+	}
+
+	return toAppProvider(p, serviceProvider(r, tenantID))
+}
+
+// delete removes the identity provider of a tenant.
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	p, errEnc := a.queryProvider(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	if err := a.samlBus.Delete(ctx, p); err != nil {
+		return toAppError(err, "delete: tenantID[%s]: %s", p.TenantID)
+	}
+
+	return nil
+}
+
+// query returns the identity provider of a tenant, with the addresses of
+// the service provider to register in it.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	p, errEnc := a.queryProvider(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppProvider(p, serviceProvider(r, p.TenantID))
+}
+
+// =============================================================================
+
+// metadata returns the metadata of the service provider of the tenant.
+func (a *app) metadata(ctx context.Context, r *http.Request) web.Encoder {
+	p, errEnc := a.queryEnabledProvider(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	data, err := a.samlBus.Metadata(serviceProvider(r, p.TenantID))
+	if err != nil {
+		return errs.Errorf(errs.Internal, "metadata: tenantID[%s]: %s", p.TenantID, err)
+	}
+
+	return Metadata(data)
+}
+
+// login sends the browser to the identity provider with an authentication
+// request. The relay state is the path the browser returns to.
+func (a *app) login(ctx context.Context, r *http.Request) web.Encoder {
+	p, errEnc := a.queryEnabledProvider(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	relayState := safePath(r.URL.Query().Get("relayState"))
+
+	location, err := a.samlBus.LoginURL(p, serviceProvider(r, p.TenantID), relayState)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "loginURL: tenantID[%s]: %s", p.TenantID, err)
+	}
+
+	return redirect(ctx, location)
+}
+
+// acs consumes the response the identity provider posts through the
+// browser. The token is handed to the front end in the fragment of the
+// redirect, which never reaches a server.
+func (a *app) acs(ctx context.Context, r *http.Request) web.Encoder {
+	r.Body = http.MaxBytesReader(web.GetWriter(ctx), r.Body, maxResponseSize)
+	if err := r.ParseForm(); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("parse form: %w", err))
+	}
+
+	samlResponse := r.PostForm.Get("SAMLResponse")
+	if samlResponse == "" {
+		return errs.NewFieldErrors("SAMLResponse", errors.New("is required"))
+	}
+
+	p, errEnc := a.queryEnabledProvider(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+
+	usr, td, err := a.samlBus.Authenticate(ctx, p, serviceProvider(r, p.TenantID), samlResponse, domain)
+	if err != nil {
+		switch {
+		case errors.Is(err, samlbus.ErrRejected), errors.Is(err, samlbus.ErrReplayed):
+			return errs.New(errs.Unauthenticated, err).WithReason(errs.ReasonSAMLRejected)
+		case errors.Is(err, samlbus.ErrNoRole), errors.Is(err, samlbus.ErrNoAccess), errors.Is(err, samlbus.ErrNotManaged):
+			return errs.New(errs.PermissionDenied, err).WithReason(errs.ReasonAccessDenied)
+		case errors.Is(err, userbus.ErrDisabled):
+			return errs.New(errs.PermissionDenied, auth.ErrUserDisabled).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "authenticate: tenantID[%s]: %s", p.TenantID, err)
+	}
+
+	// Como no login por senha, o USER e o TENANT_ADMIN carregam o tenant do
+	// vínculo no token; o ADMIN e o ANALYST não carregam nenhum.
+	if usr.Role.Equal(role.Admin) || usr.Role.Equal(role.Analyst) {
+		td.TenantID = uuid.Nil
+	}
+
+	token, err := a.auth.GenerateToken(td.TenantID, usr.ID, td.DashboardID, usr.Role)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "generateToken: userID[%s]: %s", usr.ID, err)
+	}
+
+	nl := userbus.NewLogin{
+		IP:        mid.GetClientIP(ctx),
+		UserAgent: r.UserAgent(),
+	}
+
+	if _, err := a.userBus.RecordLogin(ctx, usr, nl); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "recordLogin: userID[%s]: %s", usr.ID, err)
+	}
+
+	fragment := url.Values{"token": {token}}
+
+	return redirect(ctx, safePath(r.PostForm.Get("RelayState"))+"#"+fragment.Encode())
+}
+
+// =============================================================================
+
+func (a *app) queryTenantID(ctx context.Context, r *http.Request) (uuid.UUID, *errs.Error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return uuid.Nil, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	if _, err := a.tenantBus.QueryByID(ctx, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return uuid.Nil, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return uuid.Nil, errs.Errorf(errs.Internal, "query tenant: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return tenantID, nil
+}
+
+func (a *app) queryProvider(ctx context.Context, r *http.Request) (samlbus.Provider, *errs.Error) {
+	tenantID, errEnc := a.queryTenantID(ctx, r)
+	if errEnc != nil {
+		return samlbus.Provider{}, errEnc
+	}
+
+	p, err := a.samlBus.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return samlbus.Provider{}, toAppError(err, "query: tenantID[%s]: %s", tenantID)
+	}
+
+	return p, nil
+}
+
+// queryEnabledProvider returns the identity provider for the routes of the
+// service provider, where a disabled provider doesn't exist.
+func (a *app) queryEnabledProvider(ctx context.Context, r *http.Request) (samlbus.Provider, *errs.Error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return samlbus.Provider{}, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	p, err := a.samlBus.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return samlbus.Provider{}, toAppError(err, "query: tenantID[%s]: %s", tenantID)
+	}
+
+	if !p.Enabled {
+		return samlbus.Provider{}, errs.New(errs.NotFound, samlbus.ErrNotFound).WithReason(errs.ReasonSAMLNotFound)
+	}
+
+	return p, nil
+}
+
+// serviceProvider returns the addresses of the service provider of the
+// tenant on the host of the request, the one the identity provider knows.
+// The scheme and host come from the trusted proxies only.
+func serviceProvider(r *http.Request, tenantID uuid.UUID) saml.ServiceProvider {
+	ctx := r.Context()
+	base := fmt.Sprintf("%s://%s/v1/saml/%s", mid.GetScheme(ctx), mid.GetHost(ctx), tenantID)
+
+	return saml.ServiceProvider{
+		EntityID: base + "/metadata",
+		ACSURL:   base + "/acs",
+	}
+}
+
+// safePath returns the path if it stays on this host, or the root. It keeps
+// the relay state from sending the token to another site.
+func safePath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.ContainsAny(p, "\\#") {
+		return "/"
+	}
+
+	return p
+}
+
+// toAppError translates the errors of the saml core. The format must have a
+// verb for id followed by one for the error.
+func toAppError(err error, format string, id uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, samlbus.ErrNotFound):
+		return errs.New(errs.NotFound, samlbus.ErrNotFound).WithReason(errs.ReasonSAMLNotFound)
+	case errors.Is(err, samlbus.ErrInvalidSettings), errors.Is(err, samlbus.ErrInvalidRole):
+		return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonSAMLInvalid)
+	}
+
+	return errs.Errorf(errs.Internal, format, id, err)
+}
@@ -0,0 +1,109 @@
+// Package tenantapp exposes the tenant domain to the internal services over
+// gRPC. Tenants are managed by the admin tool, so there are no web routes.
+package tenantapp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/api/proto/spiv1"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth      *auth.Auth
+	TenantBus *tenantbus.Core
+}
+
+// RegisterGRPC binds the tenant service to the gRPC server.
+func RegisterGRPC(srv grpc.ServiceRegistrar, cfg Config) {
+	spiv1.RegisterTenantServiceServer(srv, &grpcServer{
+		auth:      cfg.Auth,
+		tenantBus: cfg.TenantBus,
+	})
+}
+
+type grpcServer struct {
+	spiv1.UnimplementedTenantServiceServer
+	auth      *auth.Auth
+	tenantBus *tenantbus.Core
+}
+
+// GetTenant returns a tenant by its ID. ADMIN reads any tenant and
+// TENANT_ADMIN only the tenant of the session.
+func (s *grpcServer) GetTenant(ctx context.Context, req *spiv1.GetTenantRequest) (*spiv1.Tenant, error) {
+	ctx, err := mid.GRPCAuthenticate(ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mid.GRPCAuthorize(ctx, s.auth, role.Admin, role.TenantAdmin); err != nil {
+		return nil, err
+	}
+
+	tenantID, err := uuid.Parse(req.GetTenantId())
+	if err != nil {
+		return nil, errs.NewFieldErrors("tenant_id", err)
+	}
+
+	if errEnc := mid.CheckTenant(ctx, tenantID); errEnc != nil {
+		return nil, errEnc
+	}
+
+	t, err := s.tenantBus.QueryByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return nil, errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return nil, errs.Errorf(errs.InternalOnlyLog, "querybyid: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return toProtoTenant(t), nil
+}
+
+// ResolveDomain returns the tenant and the dashboard published under the
+// domain, for any authenticated caller.
+func (s *grpcServer) ResolveDomain(ctx context.Context, req *spiv1.ResolveDomainRequest) (*spiv1.ResolveDomainResponse, error) {
+	ctx, err := mid.GRPCAuthenticate(ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetDomain() == "" {
+		return nil, errs.NewFieldErrors("domain", errors.New("domain is required"))
+	}
+
+	td, err := s.tenantBus.ResolveDomain(ctx, req.GetDomain())
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrDomainNotFound) {
+			return nil, errs.New(errs.NotFound, tenantbus.ErrDomainNotFound).WithReason(errs.ReasonDomainNotFound)
+		}
+		return nil, errs.Errorf(errs.InternalOnlyLog, "resolvedomain: domain[%s]: %s", req.GetDomain(), err)
+	}
+
+	resp := spiv1.ResolveDomainResponse{
+		TenantId:    td.TenantID.String(),
+		DashboardId: td.DashboardID.String(),
+	}
+
+	return &resp, nil
+}
+
+func toProtoTenant(bus tenantbus.Tenant) *spiv1.Tenant {
+	return &spiv1.Tenant{
+		Id:        bus.ID.String(),
+		Name:      bus.Name.String(),
+		Slug:      bus.Slug.String(),
+		Enabled:   bus.Enabled,
+		CreatedAt: timestamppb.New(bus.CreatedAt),
+		UpdatedAt: timestamppb.New(bus.UpdatedAt),
+	}
+}
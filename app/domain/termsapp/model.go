@@ -0,0 +1,151 @@
+package termsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+)
+
+// Terms represents a published version of the terms of service.
+type Terms struct {
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	PublishedBy string `json:"publishedBy,omitempty"`
+	PublishedAt string `json:"publishedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (t Terms) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(t)
+	return data, "application/json", err
+}
+
+func toAppTerms(bus termsbus.Terms) Terms {
+	t := Terms{
+		ID:          bus.ID.String(),
+		Version:     bus.Version,
+		Title:       bus.Title,
+		Body:        bus.Body,
+		PublishedAt: bus.PublishedAt.Format(time.RFC3339),
+	}
+
+	if bus.PublishedBy != uuid.Nil {
+		t.PublishedBy = bus.PublishedBy.String()
+	}
+
+	return t
+}
+
+// TermsList is the list of published versions of the terms of service.
+type TermsList struct {
+	Items []Terms `json:"items"`
+}
+
+// Encode implements the web.Encoder interface.
+func (tl TermsList) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(tl)
+	return data, "application/json", err
+}
+
+func toAppTermsList(bus []termsbus.Terms) TermsList {
+	items := make([]Terms, len(bus))
+	for i, t := range bus {
+		items[i] = toAppTerms(t)
+	}
+
+	return TermsList{
+		Items: items,
+	}
+}
+
+// =============================================================================
+
+// Latest is the version of the terms of service the authenticated user must
+// accept and whether it was accepted.
+type Latest struct {
+	Terms
+	Accepted bool `json:"accepted"`
+}
+
+// Encode implements the web.Encoder interface.
+func (l Latest) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(l)
+	return data, "application/json", err
+}
+
+// =============================================================================
+
+// Acceptance represents the record of a user accepting a version of the
+// terms of service.
+type Acceptance struct {
+	UserID     string `json:"userId"`
+	TermsID    string `json:"termsId"`
+	Version    string `json:"version"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	AcceptedAt string `json:"acceptedAt"`
+}
+
+// Encode implements the web.Encoder interface.
+func (a Acceptance) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(a)
+	return data, "application/json", err
+}
+
+func toAppAcceptance(bus termsbus.Acceptance) Acceptance {
+	return Acceptance{
+		UserID:     bus.UserID.String(),
+		TermsID:    bus.TermsID.String(),
+		Version:    bus.Version,
+		IP:         bus.IP,
+		UserAgent:  bus.UserAgent,
+		AcceptedAt: bus.AcceptedAt.Format(time.RFC3339),
+	}
+}
+
+func toAppAcceptances(bus []termsbus.Acceptance) []Acceptance {
+	app := make([]Acceptance, len(bus))
+	for i, a := range bus {
+		app[i] = toAppAcceptance(a)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// NewTerms defines the data needed to publish a new version of the terms of
+// service.
+type NewTerms struct {
+	Version string `json:"version" validate:"required,max=32"`
+	Title   string `json:"title" validate:"required,max=128"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *NewTerms) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewTerms) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusNewTerms(app NewTerms, publishedBy uuid.UUID) termsbus.NewTerms {
+	return termsbus.NewTerms{
+		Version:     app.Version,
+		Title:       app.Title,
+		Body:        app.Body,
+		PublishedBy: publishedBy,
+	}
+}
@@ -0,0 +1,42 @@
+package termsapp
+
+import (
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Auth        *auth.Auth
+	TermsBus    *termsbus.Core
+	RateLimiter ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group. Every route can be used before
+// the latest terms are accepted, including the admin ones, so a new version
+// never locks out who publishes it.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	noTerms := mid.NoTerms()
+	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, nil)
+	admin := mid.Authorize(cfg.Auth, role.Admin)
+
+	api := newApp(cfg.TermsBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/terms/latest", api.queryLatest, noTerms, authen, limit)
+	app.HandlerFunc(http.MethodGet, version, "/terms/acceptances", api.queryMine, noTerms, authen, limit)
+	app.HandlerFunc(http.MethodGet, version, "/terms/{terms_id}", api.queryByID, noTerms, authen, limit)
+	app.HandlerFunc(http.MethodPost, version, "/terms/{terms_id}/accept", api.accept, noTerms, authen, limit)
+
+	app.HandlerFunc(http.MethodGet, version, "/terms", api.query, noTerms, authen, limit, admin)
+	app.HandlerFunc(http.MethodPost, version, "/terms", api.publish, noTerms, authen, limit, admin)
+	app.HandlerFunc(http.MethodGet, version, "/terms/{terms_id}/acceptances", api.queryTermsAcceptances, noTerms, authen, limit, admin)
+}
@@ -0,0 +1,196 @@
+// Package termsapp maintains the app layer api for the terms of service
+// domain.
+package termsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// maxUserAgent is the size of the user_agent column.
+const maxUserAgent = 512
+
+type app struct {
+	termsBus *termsbus.Core
+}
+
+func newApp(termsBus *termsbus.Core) *app {
+	return &app{
+		termsBus: termsBus,
+	}
+}
+
+// queryLatest returns the version of the terms the user must accept and
+// whether it was accepted.
+func (a *app) queryLatest(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	t, err := a.termsBus.Check(ctx, userID)
+	switch {
+	case errors.Is(err, termsbus.ErrNotAccepted):
+		return Latest{Terms: toAppTerms(t)}
+	case err != nil:
+		return errs.Errorf(errs.Internal, "check: userID[%s]: %s", userID, err)
+	case t.ID == uuid.Nil:
+		return errs.New(errs.NotFound, termsbus.ErrNotFound).WithReason(errs.ReasonTermsNotFound)
+	}
+
+	return Latest{Terms: toAppTerms(t), Accepted: true}
+}
+
+// accept records that the user accepted the version of the terms of the
+// path, with the origin of the request.
+func (a *app) accept(ctx context.Context, r *http.Request) web.Encoder {
+	t, errEnc := a.queryTerms(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	ua := r.UserAgent()
+	if len(ua) > maxUserAgent {
+		ua = strings.ToValidUTF8(ua[:maxUserAgent], "")
+	}
+
+	na := termsbus.NewAcceptance{
+		UserID:    userID,
+		IP:        mid.GetClientIP(ctx),
+		UserAgent: ua,
+	}
+
+	acc, err := a.termsBus.Accept(ctx, t, na)
+	if err != nil {
+		return toAppError(err, "accept: termsID[%s]: %s", t.ID.String())
+	}
+
+	return toAppAcceptance(acc)
+}
+
+// queryMine returns the acceptances of the user with paging, the most recent
+// first.
+func (a *app) queryMine(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	return a.queryAcceptances(ctx, r, termsbus.AcceptanceFilter{UserID: &userID})
+}
+
+// query returns every published version of the terms, the latest first.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	terms, err := a.termsBus.QueryAll(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query: %s", err)
+	}
+
+	return toAppTermsList(terms)
+}
+
+// queryByID returns a version of the terms.
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	t, errEnc := a.queryTerms(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppTerms(t)
+}
+
+// publish adds a new version of the terms, which every user must accept.
+func (a *app) publish(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewTerms
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	t, err := a.termsBus.Publish(ctx, toBusNewTerms(app, userID))
+	if err != nil {
+		return toAppError(err, "publish: version[%s]: %s", app.Version)
+	}
+
+	return toAppTerms(t)
+}
+
+// queryTermsAcceptances returns the acceptances of the version of the terms
+// with paging, the most recent first.
+func (a *app) queryTermsAcceptances(ctx context.Context, r *http.Request) web.Encoder {
+	t, errEnc := a.queryTerms(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return a.queryAcceptances(ctx, r, termsbus.AcceptanceFilter{TermsID: &t.ID})
+}
+
+// =============================================================================
+
+func (a *app) queryAcceptances(ctx context.Context, r *http.Request, filter termsbus.AcceptanceFilter) web.Encoder {
+	values := r.URL.Query()
+
+	page, err := page.Parse(values.Get("page"), values.Get("rows"))
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	acceptances, err := a.termsBus.QueryAcceptances(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "queryAcceptances: %s", err)
+	}
+
+	total, err := a.termsBus.CountAcceptances(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "countAcceptances: %s", err)
+	}
+
+	return query.NewResult(toAppAcceptances(acceptances), total, page)
+}
+
+func (a *app) queryTerms(ctx context.Context, r *http.Request) (termsbus.Terms, *errs.Error) {
+	id, err := uuid.Parse(web.Param(r, "terms_id"))
+	if err != nil {
+		return termsbus.Terms{}, errs.NewFieldErrors("terms_id", err)
+	}
+
+	t, err := a.termsBus.QueryByID(ctx, id)
+	if err != nil {
+		return termsbus.Terms{}, toAppError(err, "query: termsID[%s]: %s", id.String())
+	}
+
+	return t, nil
+}
+
+func toAppError(err error, format string, key string) *errs.Error {
+	switch {
+	case errors.Is(err, termsbus.ErrNotFound):
+		return errs.New(errs.NotFound, termsbus.ErrNotFound).WithReason(errs.ReasonTermsNotFound)
+	case errors.Is(err, termsbus.ErrUniqueVersion):
+		return errs.New(errs.Aborted, termsbus.ErrUniqueVersion).WithReason(errs.ReasonTermsNotUnique)
+	case errors.Is(err, termsbus.ErrOutdated):
+		return errs.New(errs.FailedPrecondition, err).WithReason(errs.ReasonTermsOutdated)
+	}
+
+	return errs.Errorf(errs.Internal, format, key, err)
+}
@@ -0,0 +1,57 @@
+package usageapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+)
+
+// periodLayout is the format used to represent a metering period (month).
+const periodLayout = "2006-01"
+
+type queryParams struct {
+	StartPeriod string
+	EndPeriod   string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	return queryParams{
+		StartPeriod: values.Get("start_period"),
+		EndPeriod:   values.Get("end_period"),
+	}
+}
+
+func parseFilter(qp queryParams) (usagebus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter usagebus.QueryFilter
+
+	if qp.StartPeriod != "" {
+		t, err := time.Parse(periodLayout, qp.StartPeriod)
+		switch err {
+		case nil:
+			filter.StartPeriod = &t
+		default:
+			fieldErrors.Add("start_period", err)
+		}
+	}
+
+	if qp.EndPeriod != "" {
+		t, err := time.Parse(periodLayout, qp.EndPeriod)
+		switch err {
+		case nil:
+			filter.EndPeriod = &t
+		default:
+			fieldErrors.Add("end_period", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return usagebus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,48 @@
+package usageapp
+
+import (
+	"encoding/json"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+)
+
+// Usage represents the consumption of a tenant in one month.
+type Usage struct {
+	TenantID       string `json:"tenantId"`
+	Period         string `json:"period"`
+	ActiveUsers    int    `json:"activeUsers"`
+	DashboardViews int    `json:"dashboardViews"`
+	APICalls       int    `json:"apiCalls"`
+}
+
+func toAppUsage(bus usagebus.Usage) Usage {
+	return Usage{
+		TenantID:       bus.TenantID.String(),
+		Period:         bus.Period.Format(periodLayout),
+		ActiveUsers:    bus.ActiveUsers,
+		DashboardViews: bus.DashboardViews,
+		APICalls:       bus.APICalls,
+	}
+}
+
+// UsageReport is the usage history of a tenant.
+type UsageReport struct {
+	Items []Usage `json:"items"`
+}
+
+// Encode implements the web.Encoder interface.
+func (u UsageReport) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(u)
+	return data, "application/json", err
+}
+
+func toAppUsageReport(bus []usagebus.Usage) UsageReport {
+	items := make([]Usage, len(bus))
+	for i, u := range bus {
+		items[i] = toAppUsage(u)
+	}
+
+	return UsageReport{
+		Items: items,
+	}
+}
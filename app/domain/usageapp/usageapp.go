@@ -0,0 +1,56 @@
+// Package usageapp maintains the app layer api for the usage domain.
+package usageapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type app struct {
+	usageBus  *usagebus.Core
+	tenantBus *tenantbus.Core
+}
+
+func newApp(usageBus *usagebus.Core, tenantBus *tenantbus.Core) *app {
+	return &app{
+		usageBus:  usageBus,
+		tenantBus: tenantBus,
+	}
+}
+
+// queryByTenant returns the monthly usage history of a tenant.
+func (a *app) queryByTenant(ctx context.Context, r *http.Request) web.Encoder {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return errs.NewFieldErrors("tenant_id", err)
+	}
+
+	filter, err := parseFilter(parseQueryParams(r))
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.NewFieldErrors("filter", err)
+	}
+
+	if _, err := a.tenantBus.QueryByID(ctx, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return errs.New(errs.NotFound, tenantbus.ErrNotFound).WithReason(errs.ReasonTenantNotFound)
+		}
+		return errs.Errorf(errs.Internal, "query tenant: tenantID[%s]: %s", tenantID, err)
+	}
+
+	usage, err := a.usageBus.QueryByTenant(ctx, tenantID, filter)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "query usage: tenantID[%s]: %s", tenantID, err)
+	}
+
+	return toAppUsageReport(usage)
+}
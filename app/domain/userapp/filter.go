@@ -1,24 +1,30 @@
 package userapp
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/mail"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
 // queryParams struct interna para capturar os dados crus da URL.
 type queryParams struct {
 	Page             string
 	Rows             string
+	Cursor           string
 	OrderBy          string
 	ID               string
 	Name             string
 	Email            string
+	TenantID         string
 	StartCreatedDate string
 	EndCreatedDate   string
 }
@@ -30,10 +36,12 @@ func parseQueryParams(r *http.Request) queryParams {
 	return queryParams{
 		Page:             values.Get("page"),
 		Rows:             values.Get("rows"),
+		Cursor:           values.Get("cursor"),
 		OrderBy:          values.Get("orderBy"),
 		ID:               values.Get("user_id"),
 		Name:             values.Get("name"),
 		Email:            values.Get("email"),
+		TenantID:         values.Get("tenant_id"),
 		StartCreatedDate: values.Get("start_created_date"),
 		EndCreatedDate:   values.Get("end_created_date"),
 	}
@@ -75,6 +83,16 @@ func parseFilter(qp queryParams) (userbus.QueryFilter, error) {
 		}
 	}
 
+	if qp.TenantID != "" {
+		id, err := uuid.Parse(qp.TenantID)
+		switch err {
+		case nil:
+			filter.TenantID = &id
+		default:
+			fieldErrors.Add("tenant_id", err)
+		}
+	}
+
 	// Atenção: Mapeado para StartCreatedAt (conforme userbus/filter.go)
 	if qp.StartCreatedDate != "" {
 		t, err := time.Parse(time.RFC3339, qp.StartCreatedDate)
@@ -103,3 +121,41 @@ func parseFilter(qp queryParams) (userbus.QueryFilter, error) {
 
 	return filter, nil
 }
+
+// scopeFilter restricts the listing of users to the tenants of the caller.
+// Only an ADMIN lists every user and may filter by any tenant; the other
+// roles see the users of the tenant in the token or, when the token has
+// none, of the tenants they are a member of.
+func scopeFilter(ctx context.Context, filter *userbus.QueryFilter) *errs.Error {
+	rl, err := role.Parse(mid.GetClaims(ctx).Role)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	if rl.Equal(role.Admin) {
+		return nil
+	}
+
+	if filter.TenantID != nil {
+		return errs.New(errs.PermissionDenied, errors.New("only ADMIN may filter by tenant")).WithReason(errs.ReasonAccessDenied)
+	}
+
+	tenantID, err := mid.GetTenantID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	if tenantID != uuid.Nil {
+		filter.TenantID = &tenantID
+		return nil
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	filter.SharesTenantWith = &userID
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package userapp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/api/proto/spiv1"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterGRPC binds the user service to the gRPC server. The calls follow
+// the same policies as the routes: only ADMIN can read other users.
+func RegisterGRPC(srv grpc.ServiceRegistrar, cfg Config) {
+	spiv1.RegisterUserServiceServer(srv, &grpcServer{
+		auth:    cfg.Auth,
+		userBus: cfg.UserBus,
+	})
+}
+
+type grpcServer struct {
+	spiv1.UnimplementedUserServiceServer
+	auth    *auth.Auth
+	userBus *userbus.Core
+}
+
+// GetUser returns a user by its ID.
+func (s *grpcServer) GetUser(ctx context.Context, req *spiv1.GetUserRequest) (*spiv1.User, error) {
+	ctx, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, errs.NewFieldErrors("user_id", err)
+	}
+
+	usr, err := s.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return nil, errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return nil, errs.Errorf(errs.InternalOnlyLog, "querybyid: userID[%s]: %s", userID, err)
+	}
+
+	return toProtoUser(usr), nil
+}
+
+// ListUsers returns a list of users with paging.
+func (s *grpcServer) ListUsers(ctx context.Context, req *spiv1.ListUsersRequest) (*spiv1.ListUsersResponse, error) {
+	ctx, err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := queryParams{
+		OrderBy: req.GetOrderBy(),
+		Name:    req.GetName(),
+		Email:   req.GetEmail(),
+	}
+
+	if req.GetPage() != 0 {
+		qp.Page = strconv.Itoa(int(req.GetPage()))
+	}
+
+	if req.GetRows() != 0 {
+		qp.Rows = strconv.Itoa(int(req.GetRows()))
+	}
+
+	pg, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return nil, errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, userbus.DefaultOrderBy)
+	if err != nil {
+		return nil, errs.NewFieldErrors("order", err)
+	}
+
+	usrs, total, err := s.userBus.QueryWithCount(ctx, filter, orderBy, pg)
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "query: %s", err)
+	}
+
+	resp := spiv1.ListUsersResponse{
+		Users:       make([]*spiv1.User, len(usrs)),
+		Total:       int64(total),
+		Page:        int32(pg.Number()),
+		RowsPerPage: int32(pg.RowsPerPage()),
+	}
+
+	for i, usr := range usrs {
+		resp.Users[i] = toProtoUser(usr)
+	}
+
+	return &resp, nil
+}
+
+func (s *grpcServer) authorize(ctx context.Context) (context.Context, error) {
+	ctx, err := mid.GRPCAuthenticate(ctx, s.auth)
+	if err != nil {
+		return ctx, err
+	}
+
+	if err := mid.GRPCAuthorize(ctx, s.auth, role.Admin); err != nil {
+		return ctx, err
+	}
+
+	return ctx, nil
+}
+
+func toProtoUser(bus userbus.User) *spiv1.User {
+	return &spiv1.User{
+		Id:        bus.ID.String(),
+		Name:      bus.Name.String(),
+		Email:     bus.Email.Address,
+		Role:      bus.Role.String(),
+		Phone:     bus.Phone.String(),
+		Enabled:   bus.Enabled,
+		CreatedAt: timestamppb.New(bus.CreatedAt),
+		UpdatedAt: timestamppb.New(bus.UpdatedAt),
+	}
+}
@@ -3,10 +3,14 @@ package userapp
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/mail"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/types/name"
 	"github.com/jcpaschoal/spi-exata/business/types/password"
@@ -20,14 +24,17 @@ import (
 
 // User represents information about an individual user.
 type User struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Email       string `json:"email"`
-	Role        string `json:"role"`
-	Phone       string `json:"phone"`
-	Enabled     bool   `json:"enabled"`
-	DateCreated string `json:"dateCreated"`
-	DateUpdated string `json:"dateUpdated"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	PendingEmail string `json:"pendingEmail,omitempty"`
+	Role         string `json:"role"`
+	Phone        string `json:"phone"`
+	Enabled      bool   `json:"enabled"`
+	LastLoginAt  string `json:"lastLoginAt,omitempty"`
+	LastLoginIP  string `json:"lastLoginIp,omitempty"`
+	DateCreated  string `json:"dateCreated"`
+	DateUpdated  string `json:"dateUpdated"`
 }
 
 // Encode implements the web.Encoder interface.
@@ -37,16 +44,27 @@ func (u User) Encode() ([]byte, string, error) {
 }
 
 func toAppUser(bus userbus.User) User {
-	return User{
+	usr := User{
 		ID:          bus.ID.String(),
 		Name:        bus.Name.String(),
 		Email:       bus.Email.Address,
 		Role:        bus.Role.String(),
 		Phone:       bus.Phone.String(),
 		Enabled:     bus.Enabled,
+		LastLoginIP: bus.LastLoginIP,
 		DateCreated: bus.CreatedAt.Format(time.RFC3339),
 		DateUpdated: bus.UpdatedAt.Format(time.RFC3339),
 	}
+
+	if bus.PendingEmail != nil {
+		usr.PendingEmail = bus.PendingEmail.Address
+	}
+
+	if !bus.LastLoginAt.IsZero() {
+		usr.LastLoginAt = bus.LastLoginAt.Format(time.RFC3339)
+	}
+
+	return usr
 }
 
 func toAppUsers(users []userbus.User) []User {
@@ -57,6 +75,31 @@ func toAppUsers(users []userbus.User) []User {
 	return app
 }
 
+// =============================================================================
+// Login (Output)
+// =============================================================================
+
+// Login represents a successful authentication of a user.
+type Login struct {
+	ID          string `json:"id"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"userAgent"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAppLogins(logins []userbus.Login) []Login {
+	app := make([]Login, len(logins))
+	for i, l := range logins {
+		app[i] = Login{
+			ID:          l.ID.String(),
+			IP:          l.IP,
+			UserAgent:   l.UserAgent,
+			DateCreated: l.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return app
+}
+
 // =============================================================================
 // NewUser (Input)
 // =============================================================================
@@ -156,6 +199,70 @@ func toBusUpdateUserRole(app UpdateUserRole) (userbus.UpdateUser, error) {
 	return bus, nil
 }
 
+// =============================================================================
+// RoleChange (Output)
+// =============================================================================
+
+// RoleChange represents a role change waiting for, or decided by, a second
+// approver.
+type RoleChange struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	Role         string `json:"role"`
+	Status       string `json:"status"`
+	RequestedBy  string `json:"requestedBy"`
+	ReviewedBy   string `json:"reviewedBy,omitempty"`
+	DateCreated  string `json:"dateCreated"`
+	DateReviewed string `json:"dateReviewed,omitempty"`
+
+	status int
+}
+
+// Encode implements the web.Encoder interface.
+func (rc RoleChange) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(rc)
+	return data, "application/json", err
+}
+
+// HTTPStatus implements the web.httpStatus interface. A role change waiting
+// for approval is accepted, not yet applied.
+func (rc RoleChange) HTTPStatus() int {
+	if rc.status == 0 {
+		return http.StatusOK
+	}
+
+	return rc.status
+}
+
+func toAppRoleChange(bus rolechangebus.RoleChange) RoleChange {
+	rc := RoleChange{
+		ID:          bus.ID.String(),
+		UserID:      bus.UserID.String(),
+		Role:        bus.Role.String(),
+		Status:      bus.Status,
+		RequestedBy: bus.RequestedBy.String(),
+		DateCreated: bus.CreatedAt.Format(time.RFC3339),
+	}
+
+	if bus.ReviewedBy != nil {
+		rc.ReviewedBy = bus.ReviewedBy.String()
+	}
+
+	if bus.ReviewedAt != nil {
+		rc.DateReviewed = bus.ReviewedAt.Format(time.RFC3339)
+	}
+
+	return rc
+}
+
+func toAppRoleChanges(changes []rolechangebus.RoleChange) []RoleChange {
+	app := make([]RoleChange, len(changes))
+	for i, rc := range changes {
+		app[i] = toAppRoleChange(rc)
+	}
+	return app
+}
+
 // =============================================================================
 // UpdateUser (Input)
 // =============================================================================
@@ -230,3 +337,232 @@ func toBusUpdateUser(app UpdateUser) (userbus.UpdateUser, error) {
 
 	return bus, nil
 }
+
+// =============================================================================
+// UpdateMe (Input)
+// =============================================================================
+
+// UpdateMe defines the data a user can change in their own profile. Email,
+// role and status stay with the admins. Changing the password requires the
+// current one.
+type UpdateMe struct {
+	Name            *string `json:"name"`
+	Phone           *string `json:"phone"`
+	Password        *string `json:"password"`
+	PasswordConfirm *string `json:"passwordConfirm" validate:"required_with=Password,omitempty,eqfield=Password"`
+	CurrentPassword *string `json:"currentPassword" validate:"required_with=Password"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdateMe) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateMe) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdateMe(app UpdateMe) (userbus.UpdateUser, error) {
+	uu, err := toBusUpdateUser(UpdateUser{
+		Name:     app.Name,
+		Phone:    app.Phone,
+		Password: app.Password,
+	})
+	if err != nil {
+		return userbus.UpdateUser{}, err
+	}
+
+	return uu, nil
+}
+
+// =============================================================================
+// EmailChange (Input)
+// =============================================================================
+
+// RequestEmailChange defines the data to start an email change. The current
+// password is required so a stolen session can't take over the account.
+type RequestEmailChange struct {
+	Email           string `json:"email" validate:"required,email"`
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *RequestEmailChange) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app RequestEmailChange) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+// ConfirmEmailChange carries the token sent to the new address.
+type ConfirmEmailChange struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *ConfirmEmailChange) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app ConfirmEmailChange) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+// =============================================================================
+// Dashboards (Output)
+// =============================================================================
+
+// UserDashboard represents a dashboard the user can pick for the session.
+type UserDashboard struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenantId"`
+	Name     string `json:"name"`
+	Domain   string `json:"domain,omitempty"`
+	Default  bool   `json:"default"`
+	Current  bool   `json:"current"`
+}
+
+// UserDashboards is a collection of dashboards the user can pick.
+type UserDashboards []UserDashboard
+
+// Encode implements the web.Encoder interface.
+func (d UserDashboards) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func toAppUserDashboards(bus []tenantbus.Dashboard, defaultID uuid.UUID, currentID uuid.UUID) UserDashboards {
+	dashboards := make(UserDashboards, len(bus))
+	for i, d := range bus {
+		dashboards[i] = UserDashboard{
+			ID:       d.ID.String(),
+			TenantID: d.TenantID.String(),
+			Name:     d.Name,
+			Default:  d.ID == defaultID,
+			Current:  d.ID == currentID,
+		}
+
+		if d.Domain != nil {
+			dashboards[i].Domain = *d.Domain
+		}
+	}
+	return dashboards
+}
+
+// =============================================================================
+// Preferences (Output)
+// =============================================================================
+
+// Preferences represents the interface preferences of a user.
+type Preferences struct {
+	Theme              string                  `json:"theme"`
+	Locale             string                  `json:"locale"`
+	DefaultDashboardID string                  `json:"defaultDashboardId,omitempty"`
+	Notifications      NotificationPreferences `json:"notifications"`
+	DateUpdated        string                  `json:"dateUpdated,omitempty"`
+}
+
+// NotificationPreferences defines the channels the user accepts notifications
+// from.
+type NotificationPreferences struct {
+	Email bool `json:"email"`
+	InApp bool `json:"inApp"`
+}
+
+// Encode implements the web.Encoder interface.
+func (p Preferences) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(p)
+	return data, "application/json", err
+}
+
+func toAppPreferences(bus userbus.Preferences) Preferences {
+	prefs := Preferences{
+		Theme:  bus.Theme,
+		Locale: bus.Locale,
+		Notifications: NotificationPreferences{
+			Email: bus.Notifications.Email,
+			InApp: bus.Notifications.InApp,
+		},
+	}
+
+	if bus.DefaultDashboardID != uuid.Nil {
+		prefs.DefaultDashboardID = bus.DefaultDashboardID.String()
+	}
+
+	if !bus.UpdatedAt.IsZero() {
+		prefs.DateUpdated = bus.UpdatedAt.Format(time.RFC3339)
+	}
+
+	return prefs
+}
+
+// =============================================================================
+// UpdatePreferences (Input)
+// =============================================================================
+
+// UpdatePreferences defines the preferences to change, the missing ones are
+// kept. An empty defaultDashboardId clears the default dashboard.
+type UpdatePreferences struct {
+	Theme              *string                        `json:"theme" validate:"omitempty,oneof=light dark system"`
+	Locale             *string                        `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	DefaultDashboardID *string                        `json:"defaultDashboardId"`
+	Notifications      *UpdateNotificationPreferences `json:"notifications"`
+}
+
+// UpdateNotificationPreferences defines the notification channels to change.
+type UpdateNotificationPreferences struct {
+	Email *bool `json:"email"`
+	InApp *bool `json:"inApp"`
+}
+
+// Decode implements the web.Decoder interface.
+func (app *UpdatePreferences) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdatePreferences) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.New(errs.InvalidArgument, fmt.Errorf("validate: %w", err))
+	}
+	return nil
+}
+
+func toBusUpdatePreferences(app UpdatePreferences) (userbus.UpdatePreferences, error) {
+	up := userbus.UpdatePreferences{
+		Theme:  app.Theme,
+		Locale: app.Locale,
+	}
+
+	if app.DefaultDashboardID != nil {
+		dashID := uuid.Nil
+		if *app.DefaultDashboardID != "" {
+			var err error
+			dashID, err = uuid.Parse(*app.DefaultDashboardID)
+			if err != nil {
+				return userbus.UpdatePreferences{}, errs.NewFieldErrors("defaultDashboardId", err)
+			}
+		}
+		up.DefaultDashboardID = &dashID
+	}
+
+	if app.Notifications != nil {
+		up.NotifyEmail = app.Notifications.Email
+		up.NotifyInApp = app.Notifications.InApp
+	}
+
+	return up, nil
+}
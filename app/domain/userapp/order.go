@@ -1,7 +1,12 @@
 package userapp
 
 import (
+	"errors"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 )
 
 var orderByFields = map[string]string{
@@ -11,3 +16,27 @@ var orderByFields = map[string]string{
 	"role":    userbus.OrderByRole,
 	"enabled": userbus.OrderByEnabled,
 }
+
+// parsePage parses the offset page or, when the cursor is given, the keyset
+// page. The cursor carries the order it was created with and only works with
+// that same order.
+func parsePage(qp queryParams, orderBy order.By) (page.Page, *errs.Error) {
+	if qp.Cursor == "" {
+		pg, err := page.Parse(qp.Page, qp.Rows)
+		if err != nil {
+			return page.Page{}, errs.NewFieldErrors("page", err)
+		}
+		return pg, nil
+	}
+
+	if qp.Page != "" {
+		return page.Page{}, errs.NewFieldErrors("cursor", errors.New("page and cursor can't be used together"))
+	}
+
+	pg, err := page.ParseCursor(qp.Cursor, qp.Rows, orderBy)
+	if err != nil {
+		return page.Page{}, errs.NewFieldErrors("cursor", err)
+	}
+
+	return pg, nil
+}
@@ -0,0 +1,187 @@
+package userapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// requestRoleChange keeps the new role of the user pending until a second
+// ADMIN approves it.
+func (a *app) requestRoleChange(ctx context.Context, usr userbus.User, r role.Role) web.Encoder {
+	actorID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	rc, err := a.roleChangeBus.Request(ctx, rolechangebus.NewRoleChange{
+		UserID:      usr.ID,
+		Role:        r,
+		RequestedBy: actorID,
+	})
+	if err != nil {
+		if errors.Is(err, rolechangebus.ErrPending) {
+			return errs.New(errs.AlreadyExists, rolechangebus.ErrPending).WithReason(errs.ReasonRoleChangePending)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "request: userID[%s]: %s", usr.ID, err)
+	}
+
+	resp := toAppRoleChange(rc)
+	resp.status = http.StatusAccepted
+
+	return resp
+}
+
+// queryRoleChanges returns the role changes with paging, the most recent
+// first. The status and user_id parameters filter the list.
+func (a *app) queryRoleChanges(ctx context.Context, r *http.Request) web.Encoder {
+	values := r.URL.Query()
+
+	page, err := page.Parse(values.Get("page"), values.Get("rows"))
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	var filter rolechangebus.QueryFilter
+
+	if v := values.Get("status"); v != "" {
+		statuses := []string{rolechangebus.StatusPending, rolechangebus.StatusApproved, rolechangebus.StatusRejected}
+		if !slices.Contains(statuses, v) {
+			return errs.NewFieldErrors("status", fmt.Errorf("unknown status %q", v))
+		}
+		filter.Status = &v
+	}
+
+	if v := values.Get("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return errs.NewFieldErrors("user_id", err)
+		}
+		filter.UserID = &userID
+	}
+
+	changes, err := a.roleChangeBus.Query(ctx, filter, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "query: filter[%+v]: %s", filter, err)
+	}
+
+	total, err := a.roleChangeBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "count: filter[%+v]: %s", filter, err)
+	}
+
+	return query.NewResult(toAppRoleChanges(changes), total, page)
+}
+
+// queryRoleChangeByID returns a role change by its ID.
+func (a *app) queryRoleChangeByID(ctx context.Context, r *http.Request) web.Encoder {
+	rc, errEnc := a.queryRoleChange(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	return toAppRoleChange(rc)
+}
+
+// approveRoleChange applies the pending role of the user. The approver must
+// be another ADMIN than the one who asked for the change.
+func (a *app) approveRoleChange(ctx context.Context, r *http.Request) web.Encoder {
+	rc, errEnc := a.queryRoleChange(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	reviewerID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, rc.UserID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "query user: %s", err)
+	}
+
+	approved, err := a.roleChangeBus.Approve(ctx, rc, reviewerID)
+	if err != nil {
+		return reviewError(err, rc.ID)
+	}
+
+	if _, err := a.userBus.Update(ctx, usr, userbus.UpdateUser{Role: &approved.Role}); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "updaterole: userID[%s] role[%s]: %s", usr.ID, approved.Role, err)
+	}
+
+	if err := a.aclBus.SyncUserRole(ctx, usr.ID); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "syncuserrole: userID[%s]: %s", usr.ID, err)
+	}
+
+	a.record(ctx, activitybus.ActionUserRoleChanged, usr.ID)
+
+	return toAppRoleChange(approved)
+}
+
+// rejectRoleChange closes the pending change keeping the role of the user.
+func (a *app) rejectRoleChange(ctx context.Context, r *http.Request) web.Encoder {
+	rc, errEnc := a.queryRoleChange(ctx, r)
+	if errEnc != nil {
+		return errEnc
+	}
+
+	reviewerID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.Errorf(errs.Internal, "user id missing in context: %s", err)
+	}
+
+	rejected, err := a.roleChangeBus.Reject(ctx, rc, reviewerID)
+	if err != nil {
+		return reviewError(err, rc.ID)
+	}
+
+	return toAppRoleChange(rejected)
+}
+
+// queryRoleChange loads the role change referenced by the change_id path
+// parameter.
+func (a *app) queryRoleChange(ctx context.Context, r *http.Request) (rolechangebus.RoleChange, *errs.Error) {
+	changeID, err := uuid.Parse(r.PathValue("change_id"))
+	if err != nil {
+		return rolechangebus.RoleChange{}, errs.NewFieldErrors("change_id", err)
+	}
+
+	rc, err := a.roleChangeBus.QueryByID(ctx, changeID)
+	if err != nil {
+		if errors.Is(err, rolechangebus.ErrNotFound) {
+			return rolechangebus.RoleChange{}, errs.New(errs.NotFound, err).WithReason(errs.ReasonRoleChangeNotFound)
+		}
+		return rolechangebus.RoleChange{}, errs.Errorf(errs.InternalOnlyLog, "querybyid: changeID[%s]: %s", changeID, err)
+	}
+
+	return rc, nil
+}
+
+// reviewError maps the errors of approving or rejecting a role change.
+func reviewError(err error, changeID uuid.UUID) *errs.Error {
+	switch {
+	case errors.Is(err, rolechangebus.ErrNotPending):
+		return errs.New(errs.Aborted, rolechangebus.ErrNotPending).WithReason(errs.ReasonRoleChangeNotPending)
+	case errors.Is(err, rolechangebus.ErrSelfReview):
+		return errs.New(errs.PermissionDenied, rolechangebus.ErrSelfReview).WithReason(errs.ReasonRoleChangeSelfReview)
+	}
+
+	return errs.Errorf(errs.InternalOnlyLog, "review: changeID[%s]: %s", changeID, err)
+}
@@ -5,39 +5,119 @@ import (
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jmoiron/sqlx"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Auth    *auth.Auth
-	UserBus *userbus.Core
+	Log           *logger.Logger
+	DB            *sqlx.DB
+	Auth          *auth.Auth
+	UserBus       *userbus.Core
+	ACLBus        *aclbus.Core
+	ActivityBus   *activitybus.Core
+	TenantBus     *tenantbus.Core
+	RoleChangeBus *rolechangebus.Core
+	RateLimiter   ratelimit.Limiter
 }
 
 // Routes adds specific routes for this group.
-func Routes(app *web.App, cfg Config) {
+//
+// The parameter is not named app since route handlers that run inside a
+// transaction are referenced through the app type, e.g. (*app).updateRole.
+func Routes(a *web.App, cfg Config) {
 	const version = "v1"
 
 	// Middlewares
 	authen := mid.Authenticate(cfg.Auth)
+	limit := mid.RateLimit(cfg.RateLimiter, cfg.TenantBus)
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+
+	// O TENANT_ADMIN gerencia só os usuários do cliente da sessão.
+	manage := mid.Authorize(cfg.Auth, role.Admin, role.TenantAdmin)
+	inTenant := mid.AuthorizeTenantUser(cfg.TenantBus, "user_id")
+	admin := mid.Authorize(cfg.Auth, role.Admin)
 
 	// Instanciamos a API
-	api := newApp(cfg.UserBus)
+	api := newApp(cfg.UserBus, cfg.ACLBus, cfg.ActivityBus, cfg.TenantBus, cfg.RoleChangeBus)
 
 	// GET /users
-	app.HandlerFunc(http.MethodGet, version, "/users", api.query, authen, mid.Authorize(cfg.Auth, role.Admin))
+	// O ANALYST e o TENANT_ADMIN também listam, mas só os usuários dos seus
+	// clientes.
+	a.HandlerFunc(http.MethodGet, version, "/users", api.query, authen, limit, mid.Authorize(cfg.Auth, role.Admin, role.Analyst, role.TenantAdmin))
+
+	// GET /users/me
+	// O próprio usuário, sem a autorização de admin das rotas por user_id.
+	a.HandlerFunc(http.MethodGet, version, "/users/me", api.queryMe, authen, limit)
+
+	// PUT /users/me
+	a.HandlerFunc(http.MethodPut, version, "/users/me", api.updateMe, authen, limit)
+
+	// POST /users/me/email
+	// A troca de e-mail passa pela confirmação do novo endereço.
+	a.HandlerFunc(http.MethodPost, version, "/users/me/email", mid.WithTran(api.newWithTx, (*app).requestEmailChange), authen, limit, transaction)
+
+	// POST /users/email/confirm
+	// Sem autenticação: o token enviado ao novo endereço é a prova.
+	a.HandlerFunc(http.MethodPost, version, "/users/email/confirm", mid.WithTran(api.newWithTx, (*app).confirmEmailChange), limit, transaction)
+
+	// GET /users/me/preferences
+	a.HandlerFunc(http.MethodGet, version, "/users/me/preferences", api.queryPreferences, authen, limit)
+
+	// PUT /users/me/preferences
+	a.HandlerFunc(http.MethodPut, version, "/users/me/preferences", api.updatePreferences, authen, limit)
+
+	// GET /users/me/dashboards
+	// Os dashboards que o usuário pode escolher no login ou na renovação do
+	// token, com o padrão e o atual marcados.
+	a.HandlerFunc(http.MethodGet, version, "/users/me/dashboards", api.queryDashboards, authen, limit)
 
 	// GET /users/{user_id}
-	app.HandlerFunc(http.MethodGet, version, "/users/{user_id}", api.queryByID, authen, mid.Authorize(cfg.Auth, role.Admin))
+	a.HandlerFunc(http.MethodGet, version, "/users/{user_id}", api.queryByID, authen, limit, manage, inTenant)
+
+	// GET /users/{user_id}/logins
+	a.HandlerFunc(http.MethodGet, version, "/users/{user_id}/logins", api.queryLogins, authen, limit, manage, inTenant)
 
 	// POST /users
-	app.HandlerFunc(http.MethodPost, version, "/users", api.create, authen, mid.Authorize(cfg.Auth, role.Admin))
+	// O usuário criado pelo TENANT_ADMIN entra no cliente da sessão.
+	a.HandlerFunc(http.MethodPost, version, "/users", mid.WithTran(api.newWithTx, (*app).create), authen, limit, manage, transaction)
+
+	// PUT /users/{user_id}/role
+	a.HandlerFunc(http.MethodPut, version, "/users/{user_id}/role", mid.WithTran(api.newWithTx, (*app).updateRole), authen, limit, manage, inTenant, transaction)
+
+	// GET /role-changes
+	// As promoções a ADMIN aguardando o segundo aprovador, e as já decididas.
+	a.HandlerFunc(http.MethodGet, version, "/role-changes", api.queryRoleChanges, authen, limit, admin)
+
+	// GET /role-changes/{change_id}
+	a.HandlerFunc(http.MethodGet, version, "/role-changes/{change_id}", api.queryRoleChangeByID, authen, limit, admin)
+
+	// POST /role-changes/{change_id}/approve
+	// Quem pediu a troca não pode aprová-la; o novo perfil vale na mesma
+	// transação.
+	a.HandlerFunc(http.MethodPost, version, "/role-changes/{change_id}/approve", mid.WithTran(api.newWithTx, (*app).approveRoleChange), authen, limit, admin, transaction)
+
+	// POST /role-changes/{change_id}/reject
+	a.HandlerFunc(http.MethodPost, version, "/role-changes/{change_id}/reject", api.rejectRoleChange, authen, limit, admin)
+
+	// POST /users/{user_id}/restore
+	// Usuários removidos são apenas marcados; o admin pode trazê-los de volta.
+	a.HandlerFunc(http.MethodPost, version, "/users/{user_id}/restore", api.restore, authen, limit, manage, inTenant)
 
 	// PUT /users/{user_id}
-	app.HandlerFunc(http.MethodPut, version, "/me", api.update, authen)
+	// Desabilitar o usuário revoga os acessos dele na mesma transação.
+	a.HandlerFunc(http.MethodPut, version, "/me", mid.WithTran(api.newWithTx, (*app).update), authen, limit, transaction)
 
 	// DELETE /users/{user_id}
-	app.HandlerFunc(http.MethodDelete, version, "/me", api.delete, authen)
+	a.HandlerFunc(http.MethodDelete, version, "/me", mid.WithTran(api.newWithTx, (*app).delete), authen, limit, transaction)
 }
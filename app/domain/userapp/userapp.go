@@ -4,31 +4,83 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/mail"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/query"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/order"
 	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
 // app manages the set of app layer api functions for the user domain.
 // Nota: Até a struct pode ser privada (app) se só for usada aqui e no route.go
 type app struct {
-	userBus *userbus.Core
+	userBus       *userbus.Core
+	aclBus        *aclbus.Core
+	activityBus   *activitybus.Core
+	tenantBus     *tenantbus.Core
+	roleChangeBus *rolechangebus.Core
 }
 
 // newApp constructs a user app API for use.
-func newApp(userBus *userbus.Core) *app {
+func newApp(userBus *userbus.Core, aclBus *aclbus.Core, activityBus *activitybus.Core, tenantBus *tenantbus.Core, roleChangeBus *rolechangebus.Core) *app {
 	return &app{
-		userBus: userBus,
+		userBus:       userBus,
+		aclBus:        aclBus,
+		activityBus:   activityBus,
+		tenantBus:     tenantBus,
+		roleChangeBus: roleChangeBus,
 	}
 }
 
-// create adds a new user to the system.
+// newWithTx constructs a new app value with the domain apis using a store
+// transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := a.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	aclBus, err := a.aclBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantBus, err := a.tenantBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	roleChangeBus, err := a.roleChangeBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// O feed de atividades fica fora da transação: é informativo e uma falha
+	// ao gravá-lo não deve abortar a alteração.
+	return newApp(userBus, aclBus, a.activityBus, tenantBus, roleChangeBus), nil
+}
+
+// create adds a new user to the system. A TENANT_ADMIN creates the user in
+// its own tenant, with access to the dashboard of the session.
 func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 	var app NewUser
 	if err := web.Decode(r, &app); err != nil {
@@ -40,27 +92,55 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	if errEnc := checkTenantRole(ctx, nc.Role); errEnc != nil {
+		return errEnc
+	}
+
+	var td tenantbus.TenantDashboard
+	if !mid.IsAdmin(ctx) {
+		if td, err = sessionDashboard(ctx); err != nil {
+			return errs.New(errs.PermissionDenied, err).WithReason(errs.ReasonAccessDenied)
+		}
+
+		if nc.EmailScope, err = a.tenantBus.EmailScope(ctx, td.TenantID); err != nil {
+			return errs.Errorf(errs.InternalOnlyLog, "emailscope: tenantID[%s]: %s", td.TenantID, err)
+		}
+	}
+
 	usr, err := a.userBus.Create(ctx, nc)
 	if err != nil {
 		if errors.Is(err, userbus.ErrUniqueEmail) {
-			return errs.New(errs.Aborted, userbus.ErrUniqueEmail)
+			return errs.New(errs.Aborted, userbus.ErrUniqueEmail).WithReason(errs.ReasonUserEmailNotUnique)
 		}
 		if errors.Is(err, userbus.ErrUniquePhone) {
-			return errs.New(errs.Aborted, userbus.ErrUniquePhone)
+			return errs.New(errs.Aborted, userbus.ErrUniquePhone).WithReason(errs.ReasonUserPhoneNotUnique)
 		}
 		return errs.Errorf(errs.InternalOnlyLog, "create: usr[%+v]: %s", usr, err)
 	}
 
+	if td.DashboardID != uuid.Nil {
+		if err := a.tenantBus.GrantUserAccessToDashboard(ctx, usr.ID, td.DashboardID); err != nil {
+			return errs.Errorf(errs.InternalOnlyLog, "grantuseraccesstodashboard: userID[%s] dashboardID[%s]: %s", usr.ID, td.DashboardID, err)
+		}
+	}
+
+	a.record(ctx, activitybus.ActionUserCreated, usr.ID)
+
 	return toAppUser(usr)
 }
 
-// update updates an existing user.
+// update updates an existing user, conditional on If-Match when sent.
 func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 	var app UpdateUser
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	// O e-mail só muda pelo fluxo com confirmação do novo endereço.
+	if app.Email != nil {
+		return errs.NewFieldErrors("email", errors.New("use the email change confirmation flow"))
+	}
+
 	usr, err := mid.GetUser(ctx)
 	if err != nil {
 		return errs.Errorf(errs.Internal, "user missing in context: %s", err)
@@ -71,15 +151,290 @@ func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	version, errEnc := ifMatch(r, usr)
+	if errEnc != nil {
+		return errEnc
+	}
+	uu.Version = version
+
 	updUsr, err := a.userBus.Update(ctx, usr, uu)
 	if err != nil {
+		if errors.Is(err, userbus.ErrConflict) {
+			return errs.New(errs.Aborted, userbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+		}
 		return errs.Errorf(errs.InternalOnlyLog, "update: userID[%s] uu[%+v]: %s", usr.ID, uu, err)
 	}
 
+	a.record(ctx, activitybus.ActionUserUpdated, usr.ID)
+
+	return toAppUser(updUsr)
+}
+
+// queryMe returns the profile of the authenticated user, revalidated by the
+// client with the ETag.
+func (a *app) queryMe(ctx context.Context, r *http.Request) web.Encoder {
+	usr, err := a.me(ctx)
+	if err != nil {
+		return err
+	}
+
+	return web.Validated(r, toAppUser(usr), usr.UpdatedAt)
+}
+
+// updateMe updates the profile of the authenticated user. Only the name,
+// phone and password can be changed here.
+func (a *app) updateMe(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateMe
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, e := a.me(ctx)
+	if e != nil {
+		return e
+	}
+
+	uu, err := toBusUpdateMe(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	version, e := ifMatch(r, usr)
+	if e != nil {
+		return e
+	}
+	uu.Version = version
+
+	if app.Password != nil {
+		if _, err := a.userBus.Authenticate(ctx, emailTenant(usr), usr.Email, *app.CurrentPassword); err != nil {
+			if errors.Is(err, userbus.ErrAuthenticationFailure) {
+				return errs.NewFieldErrors("currentPassword", errors.New("current password does not match"))
+			}
+			return errs.Errorf(errs.InternalOnlyLog, "authenticate: userID[%s]: %s", usr.ID, err)
+		}
+	}
+
+	updUsr, err := a.userBus.Update(ctx, usr, uu)
+	if err != nil {
+		switch {
+		case errors.Is(err, userbus.ErrUniquePhone):
+			return errs.New(errs.Aborted, userbus.ErrUniquePhone).WithReason(errs.ReasonUserPhoneNotUnique)
+		case errors.Is(err, userbus.ErrConflict):
+			return errs.New(errs.Aborted, userbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "updateme: userID[%s]: %s", usr.ID, err)
+	}
+
+	a.record(ctx, activitybus.ActionUserUpdated, usr.ID)
+
+	return toAppUser(updUsr)
+}
+
+// requestEmailChange starts the change of the email of the authenticated
+// user. The new address receives a confirmation link and the current one
+// keeps working until the change is confirmed.
+func (a *app) requestEmailChange(ctx context.Context, r *http.Request) web.Encoder {
+	var app RequestEmailChange
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, e := a.me(ctx)
+	if e != nil {
+		return e
+	}
+
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return errs.NewFieldErrors("email", err)
+	}
+
+	if addr.Address == usr.Email.Address {
+		return errs.NewFieldErrors("email", errors.New("email is the current one"))
+	}
+
+	if _, err := a.userBus.Authenticate(ctx, emailTenant(usr), usr.Email, app.CurrentPassword); err != nil {
+		if errors.Is(err, userbus.ErrAuthenticationFailure) {
+			return errs.NewFieldErrors("currentPassword", errors.New("current password does not match"))
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "authenticate: userID[%s]: %s", usr.ID, err)
+	}
+
+	updUsr, err := a.userBus.RequestEmailChange(ctx, usr, *addr)
+	if err != nil {
+		if errors.Is(err, userbus.ErrUniqueEmail) {
+			return errs.New(errs.Aborted, userbus.ErrUniqueEmail).WithReason(errs.ReasonUserEmailNotUnique)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "requestemailchange: userID[%s]: %s", usr.ID, err)
+	}
+
 	return toAppUser(updUsr)
 }
 
-// updateRole updates an existing user's role.
+// confirmEmailChange applies the pending email of the token owner. The token
+// is the proof, so the link works without a session.
+func (a *app) confirmEmailChange(ctx context.Context, r *http.Request) web.Encoder {
+	var app ConfirmEmailChange
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, err := a.userBus.ConfirmEmailChange(ctx, app.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, userbus.ErrInvalidToken):
+			return errs.New(errs.InvalidArgument, err).WithReason(errs.ReasonInvalidToken)
+		case errors.Is(err, userbus.ErrUniqueEmail):
+			return errs.New(errs.Aborted, userbus.ErrUniqueEmail).WithReason(errs.ReasonUserEmailNotUnique)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "confirmemailchange: %s", err)
+	}
+
+	return toAppUser(usr)
+}
+
+// queryPreferences returns the interface preferences of the authenticated
+// user.
+func (a *app) queryPreferences(ctx context.Context, _ *http.Request) web.Encoder {
+	usr, e := a.me(ctx)
+	if e != nil {
+		return e
+	}
+
+	prefs, err := a.userBus.QueryPreferences(ctx, usr.ID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "querypreferences: userID[%s]: %s", usr.ID, err)
+	}
+
+	return toAppPreferences(prefs)
+}
+
+// queryDashboards returns the dashboards the authenticated user can pick for
+// the session: the ones granted to a USER, or the dashboards of the client of
+// the domain for the other roles. The default and the current dashboards are
+// flagged.
+func (a *app) queryDashboards(ctx context.Context, _ *http.Request) web.Encoder {
+	usr, e := a.me(ctx)
+	if e != nil {
+		return e
+	}
+
+	var dashboards []tenantbus.Dashboard
+
+	switch {
+	case usr.Role.Equal(role.User):
+		var err error
+		if dashboards, err = a.tenantBus.QueryUserDashboards(ctx, usr.ID); err != nil {
+			return errs.Errorf(errs.InternalOnlyLog, "queryuserdashboards: userID[%s]: %s", usr.ID, err)
+		}
+
+	default:
+		domain := strings.ToLower(auth.ExtractDomain(mid.GetHost(ctx)))
+
+		td, err := a.tenantBus.ResolveDomain(ctx, domain)
+		if err != nil {
+			if errors.Is(err, tenantbus.ErrDomainNotFound) {
+				return errs.New(errs.NotFound, tenantbus.ErrDomainNotFound).WithReason(errs.ReasonDomainNotFound)
+			}
+			return errs.Errorf(errs.InternalOnlyLog, "resolvedomain: domain[%s]: %s", domain, err)
+		}
+
+		if dashboards, err = a.tenantBus.QueryDashboards(ctx, td.TenantID); err != nil {
+			return errs.Errorf(errs.InternalOnlyLog, "querydashboards: tenantID[%s]: %s", td.TenantID, err)
+		}
+	}
+
+	prefs, err := a.userBus.QueryPreferences(ctx, usr.ID)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "querypreferences: userID[%s]: %s", usr.ID, err)
+	}
+
+	current, _ := mid.GetDashboardID(ctx)
+
+	return toAppUserDashboards(dashboards, prefs.DefaultDashboardID, current)
+}
+
+// updatePreferences changes the interface preferences of the authenticated
+// user. The default dashboard must be one the user can open.
+func (a *app) updatePreferences(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdatePreferences
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, e := a.me(ctx)
+	if e != nil {
+		return e
+	}
+
+	up, err := toBusUpdatePreferences(app)
+	if err != nil {
+		if v, ok := err.(*errs.Error); ok {
+			return v
+		}
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	if up.DefaultDashboardID != nil && *up.DefaultDashboardID != uuid.Nil {
+		if err := a.aclBus.ValidateAccess(ctx, usr.ID, *up.DefaultDashboardID, actions.Get); err != nil {
+			if errors.Is(err, aclbus.ErrAccessDenied) {
+				return errs.NewFieldErrors("defaultDashboardId", errors.New("dashboard not accessible"))
+			}
+			return errs.Errorf(errs.InternalOnlyLog, "validateaccess: userID[%s]: %s", usr.ID, err)
+		}
+	}
+
+	prefs, err := a.userBus.UpdatePreferences(ctx, usr.ID, up)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "updatepreferences: userID[%s]: %s", usr.ID, err)
+	}
+
+	return toAppPreferences(prefs)
+}
+
+// me loads the user identified by the token.
+func (a *app) me(ctx context.Context) (userbus.User, *errs.Error) {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return userbus.User{}, errs.Errorf(errs.Internal, "userID missing in context: %s", err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return userbus.User{}, errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return userbus.User{}, errs.Errorf(errs.InternalOnlyLog, "query user: userID[%s]: %s", userID, err)
+	}
+
+	return usr, nil
+}
+
+// ifMatch checks the If-Match of a write against the user as its GET returns
+// it. The version returned makes the update apply only to that user, so a
+// write between the check and the update is a conflict as well. Requests
+// without If-Match get no version.
+func ifMatch(r *http.Request, usr userbus.User) (*time.Time, *errs.Error) {
+	if r.Header.Get("If-Match") == "" {
+		return nil, nil
+	}
+
+	match, err := web.IfMatch(r, toAppUser(usr))
+	if err != nil {
+		return nil, errs.Errorf(errs.Internal, "ifmatch: userID[%s]: %s", usr.ID, err)
+	}
+
+	if !match {
+		return nil, errs.New(errs.Aborted, userbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+	}
+
+	return &usr.UpdatedAt, nil
+}
+
+// updateRole updates an existing user's role and drops the permissions
+// derived from the previous role, both inside the request transaction. A
+// promotion to ADMIN in a tenant with role approval is only recorded, and
+// applied when another ADMIN approves it.
 func (a *app) updateRole(ctx context.Context, r *http.Request) web.Encoder {
 	var app UpdateUserRole
 	if err := web.Decode(r, &app); err != nil {
@@ -95,7 +450,7 @@ func (a *app) updateRole(ctx context.Context, r *http.Request) web.Encoder {
 	usr, err := a.userBus.QueryByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, userbus.ErrNotFound) {
-			return errs.New(errs.NotFound, err)
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
 		}
 		return errs.Errorf(errs.InternalOnlyLog, "query user: %s", err)
 	}
@@ -105,11 +460,40 @@ func (a *app) updateRole(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	if errEnc := checkTenantRole(ctx, usr.Role, *uu.Role); errEnc != nil {
+		return errEnc
+	}
+
+	version, errEnc := ifMatch(r, usr)
+	if errEnc != nil {
+		return errEnc
+	}
+	uu.Version = version
+
+	approval, err := a.roleChangeBus.RequiresApproval(ctx, usr.ID, usr.Role, *uu.Role)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "requiresapproval: userID[%s]: %s", usr.ID, err)
+	}
+
+	// Nos clientes com quatro olhos a promoção a ADMIN espera outro ADMIN.
+	if approval {
+		return a.requestRoleChange(ctx, usr, *uu.Role)
+	}
+
 	updUsr, err := a.userBus.Update(ctx, usr, uu)
 	if err != nil {
+		if errors.Is(err, userbus.ErrConflict) {
+			return errs.New(errs.Aborted, userbus.ErrConflict).WithReason(errs.ReasonVersionConflict)
+		}
 		return errs.Errorf(errs.InternalOnlyLog, "updaterole: userID[%s] uu[%+v]: %s", usr.ID, uu, err)
 	}
 
+	if err := a.aclBus.SyncUserRole(ctx, usr.ID); err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "syncuserrole: userID[%s]: %s", usr.ID, err)
+	}
+
+	a.record(ctx, activitybus.ActionUserRoleChanged, usr.ID)
+
 	return toAppUser(updUsr)
 }
 
@@ -125,18 +509,37 @@ func (a *app) delete(ctx context.Context, _ *http.Request) web.Encoder {
 		return errs.Errorf(errs.InternalOnlyLog, "delete: userID[%s]: %s", usr.ID, err)
 	}
 
+	a.record(ctx, activitybus.ActionUserDeleted, usr.ID)
+
 	return nil
 }
 
-// query returns a list of users with paging.
-func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
-	qp := parseQueryParams(r)
+// restore brings back a deleted user.
+func (a *app) restore(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		return errs.NewFieldErrors("user_id", err)
+	}
 
-	page, err := page.Parse(qp.Page, qp.Rows)
+	usr, err := a.userBus.Restore(ctx, userID)
 	if err != nil {
-		return errs.NewFieldErrors("page", err)
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "restore: userID[%s]: %s", userID, err)
 	}
 
+	a.record(ctx, activitybus.ActionUserRestored, usr.ID)
+
+	return toAppUser(usr)
+}
+
+// query returns a list of users with paging. The next_cursor of the result
+// continues the listing with keyset paging, which is stable under concurrent
+// writes.
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
 	filter, err := parseFilter(qp)
 	if err != nil {
 		if v, ok := err.(*errs.Error); ok {
@@ -145,30 +548,131 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.NewFieldErrors("filter", err)
 	}
 
+	if errEnc := scopeFilter(ctx, &filter); errEnc != nil {
+		return errEnc
+	}
+
 	orderBy, err := order.Parse(orderByFields, qp.OrderBy, userbus.DefaultOrderBy)
 	if err != nil {
 		return errs.NewFieldErrors("order", err)
 	}
 
-	usrs, err := a.userBus.Query(ctx, filter, orderBy, page)
+	page, perr := parsePage(qp, orderBy)
+	if perr != nil {
+		return perr
+	}
+
+	usrs, total, err := a.userBus.QueryWithCount(ctx, filter, orderBy, page)
 	if err != nil {
 		return errs.Errorf(errs.Internal, "query: %s", err)
 	}
 
-	total, err := a.userBus.Count(ctx, filter)
+	return query.NewResult(toAppUsers(usrs), total, page).WithNextCursor(userbus.NextCursor(usrs, orderBy, page))
+}
+
+// queryLogins returns the login history of a user with paging, most recent
+// first.
+func (a *app) queryLogins(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	userID, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		return errs.NewFieldErrors("user_id", err)
+	}
+
+	if _, err := a.userBus.QueryByID(ctx, userID); err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err).WithReason(errs.ReasonUserNotFound)
+		}
+		return errs.Errorf(errs.InternalOnlyLog, "query user: %s", err)
+	}
+
+	logins, err := a.userBus.QueryLogins(ctx, userID, page)
+	if err != nil {
+		return errs.Errorf(errs.InternalOnlyLog, "querylogins: userID[%s]: %s", userID, err)
+	}
+
+	total, err := a.userBus.CountLogins(ctx, userID)
 	if err != nil {
-		return errs.Errorf(errs.Internal, "count: %s", err)
+		return errs.Errorf(errs.InternalOnlyLog, "countlogins: userID[%s]: %s", userID, err)
 	}
 
-	return query.NewResult(toAppUsers(usrs), total, page)
+	return query.NewResult(toAppLogins(logins), total, page)
 }
 
 // queryByID returns a user by its ID.
-func (a *app) queryByID(ctx context.Context, _ *http.Request) web.Encoder {
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
 	usr, err := mid.GetUser(ctx)
 	if err != nil {
 		return errs.Errorf(errs.Internal, "user missing in context: %s", err)
 	}
 
-	return toAppUser(usr)
+	return web.Validated(r, toAppUser(usr), usr.UpdatedAt)
+}
+
+// checkTenantRole keeps the roles other than ADMIN to the roles of a tenant.
+// ADMIN and ANALYST act across tenants, the role policies apply to the
+// resources of every tenant, so only ADMIN may give or take them.
+func checkTenantRole(ctx context.Context, roles ...role.Role) *errs.Error {
+	if mid.IsAdmin(ctx) {
+		return nil
+	}
+
+	for _, rl := range roles {
+		if !rl.Equal(role.User) && !rl.Equal(role.TenantAdmin) {
+			return errs.Errorf(errs.PermissionDenied, "only ADMIN may manage %s users", rl).WithReason(errs.ReasonAccessDenied)
+		}
+	}
+
+	return nil
+}
+
+// sessionDashboard returns the tenant and the dashboard of the session, the
+// ones a TENANT_ADMIN manages.
+func sessionDashboard(ctx context.Context) (tenantbus.TenantDashboard, error) {
+	tenantID, err := mid.GetTenantID(ctx)
+	if err != nil {
+		return tenantbus.TenantDashboard{}, err
+	}
+
+	dashboardID, err := mid.GetDashboardID(ctx)
+	if err != nil {
+		return tenantbus.TenantDashboard{}, err
+	}
+
+	if tenantID == uuid.Nil || dashboardID == uuid.Nil {
+		return tenantbus.TenantDashboard{}, errors.New("session has no tenant")
+	}
+
+	return tenantbus.TenantDashboard{TenantID: tenantID, DashboardID: dashboardID}, nil
+}
+
+// emailTenant returns the tenant the email of the user is scoped to, so the
+// password check finds the user and not another with the same email.
+func emailTenant(usr userbus.User) uuid.UUID {
+	if usr.EmailScope == nil {
+		return uuid.Nil
+	}
+
+	return *usr.EmailScope
+}
+
+// record adds an action of the authenticated user on the specified user to
+// the activity feed.
+func (a *app) record(ctx context.Context, action string, userID uuid.UUID) {
+	actorID, _ := mid.GetUserID(ctx)
+	tenantID, _ := mid.GetTenantID(ctx)
+
+	a.activityBus.Record(ctx, activitybus.NewActivity{
+		UserID:     actorID,
+		TenantID:   tenantID,
+		Action:     action,
+		EntityType: activitybus.EntityUser,
+		EntityID:   userID,
+	})
 }
@@ -11,6 +11,8 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/types/role"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
@@ -40,10 +42,28 @@ type KeyLookup interface {
 	PublicKey(kid string) (key string, err error)
 }
 
+// ActiveKIDLookup is implemented by the key lookups that choose the key used
+// to sign new tokens, like the keys kept in the database. The configured
+// ActiveKID is used for the other lookups.
+type ActiveKIDLookup interface {
+	ActiveKID() (kid string, err error)
+}
+
+// ActiveKID returns the kid of the key used to sign new tokens.
+func ActiveKID(keyLookup KeyLookup, configured string) (string, error) {
+	if l, ok := keyLookup.(ActiveKIDLookup); ok {
+		return l.ActiveKID()
+	}
+
+	return configured, nil
+}
+
 // Config represents information required to initialize auth.
 type Config struct {
 	Log       *logger.Logger
-	UserBus   *userbus.Core // Usado para validar se o usuário está ativo/enabled
+	UserBus   *userbus.Core   // Usado para validar se o usuário está ativo/enabled
+	LDAPBus   *ldapbus.Core   // Opcional: sem ele o login usa apenas a senha local
+	TenantBus *tenantbus.Core // Opcional: sem ele o login ignora os e-mails isolados por cliente
 	KeyLookup KeyLookup
 	Issuer    string
 	ActiveKID string
@@ -54,6 +74,8 @@ type Auth struct {
 	log       *logger.Logger
 	keyLookup KeyLookup
 	userBus   *userbus.Core
+	ldapBus   *ldapbus.Core
+	tenantBus *tenantbus.Core
 	method    jwt.SigningMethod
 	parser    *jwt.Parser
 	issuer    string
@@ -66,6 +88,8 @@ func New(cfg Config) *Auth {
 		log:       cfg.Log,
 		keyLookup: cfg.KeyLookup,
 		userBus:   cfg.UserBus,
+		ldapBus:   cfg.LDAPBus,
+		tenantBus: cfg.TenantBus,
 		method:    jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
 		parser:    jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
 		issuer:    cfg.Issuer,
@@ -101,13 +125,18 @@ func (a *Auth) GenerateToken(tenantID uuid.UUID, userID uuid.UUID, dashboardID u
 
 	token := jwt.NewWithClaims(a.method, claims)
 
+	kid, err := ActiveKID(a.keyLookup, a.activeKID)
+	if err != nil {
+		return "", fmt.Errorf("active kid lookup: %w", err)
+	}
+
 	// Define o KID no cabeçalho para que, na validação, saibamos qual chave pública usar.
-	token.Header["kid"] = a.activeKID
+	token.Header["kid"] = kid
 
 	// Recupera a chave privada correspondente ao ActiveKID
-	privateKeyPEM, err := a.keyLookup.PrivateKey(a.activeKID)
+	privateKeyPEM, err := a.keyLookup.PrivateKey(kid)
 	if err != nil {
-		return "", fmt.Errorf("private key lookup for kid %q: %w", a.activeKID, err)
+		return "", fmt.Errorf("private key lookup for kid %q: %w", kid, err)
 	}
 
 	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
@@ -240,15 +269,52 @@ func (a *Auth) verifySignatureAndClaims(tokenStr, pemStr string) error {
 	return nil
 }
 
-// verifySignatureAndClaims parses the token with the public key, validates the signature, and checks the issuer claim.
-func (a *Auth) Login(ctx context.Context, email mail.Address, password string) (userbus.User, error) {
+// Login authenticates the user through the directory of the domain or the
+// local password. A disabled user gets ErrUserDisabled once the password
+// checks out.
+func (a *Auth) Login(ctx context.Context, domain string, email mail.Address, password string) (userbus.User, error) {
+
+	// O diretório do domínio é tentado primeiro. Se ele recusa ou falha, a
+	// senha local ainda vale: os usuários criados pelo diretório têm uma
+	// senha local aleatória, e um admin continua entrando com a sua.
+	if a.ldapBus != nil {
+		usr, err := a.ldapBus.Authenticate(ctx, domain, email, password)
+		switch {
+		case err == nil:
+			return usr, nil
+		case errors.Is(err, userbus.ErrDisabled):
+			return userbus.User{}, ErrUserDisabled
+		case errors.Is(err, ldapbus.ErrNotManaged), errors.Is(err, userbus.ErrAuthenticationFailure):
+		default:
+			a.log.Error(ctx, "ldap: authenticate", "domain", domain, "err", err)
+		}
+	}
 
-	usr, err := a.userBus.Authenticate(ctx, email, password)
+	// O domínio leva ao cliente, que desfaz a ambiguidade quando o e-mail
+	// existe isolado no cliente e também no escopo global.
+	var tenantID uuid.UUID
+	if a.tenantBus != nil {
+		td, err := a.tenantBus.ResolveDomain(ctx, domain)
+		switch {
+		case err == nil:
+			tenantID = td.TenantID
+		case !errors.Is(err, tenantbus.ErrDomainNotFound):
+			return userbus.User{}, fmt.Errorf("resolve domain[%s]: %w", domain, err)
+		}
+	}
+
+	usr, err := a.userBus.Authenticate(ctx, tenantID, email, password)
 
 	if err != nil {
 		return userbus.User{}, fmt.Errorf("invalid credentials: %w", err)
 	}
 
+	// A senha confere antes, para que a resposta não revele a conta
+	// desabilitada a quem não a conhece.
+	if !usr.Enabled {
+		return userbus.User{}, ErrUserDisabled
+	}
+
 	return usr, nil
 }
 
@@ -0,0 +1,187 @@
+// Package debug provides handler support for the debugging endpoints.
+package debug
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/metrics"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// Mux registers all the debug routes from the standard library into a new mux
+// bypassing the use of the DefaultServerMux. Using the DefaultServerMux would
+// be a security risk since a dependency could inject a handler into our service
+// without us knowing it.
+func Mux(log *logger.Logger) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("/debug/build", buildInfo)
+	mux.HandleFunc("GET /debug/caches", caches)
+	mux.HandleFunc("GET /debug/loglevel", getLogLevel(log))
+	mux.HandleFunc("PUT /debug/loglevel", setLogLevel(log))
+
+	return mux
+}
+
+// buildInfo writes the module, dependency and build settings embedded in the
+// binary by the Go toolchain.
+func buildInfo(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "build information not available", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(info.String()))
+}
+
+// caches writes the runtime statistics of the in-memory caches. A cache with
+// a low hit ratio and a growing number of invalidations or flushes is being
+// dropped faster than it can warm up.
+func caches(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, cachestats.Snapshot())
+}
+
+// logLevelRequest changes the global level and the per module levels. A
+// module set to an empty string goes back to the global level.
+type logLevelRequest struct {
+	Level   *logger.Level     `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// getLogLevel writes the levels the logger is currently using.
+func getLogLevel(log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, log.Levels())
+	}
+}
+
+// setLogLevel changes the logger levels without restarting the service.
+func setLogLevel(log *logger.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req logLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("decoding request: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		modules := make(map[string]logger.Level, len(req.Modules))
+		for module, name := range req.Modules {
+			if name == "" {
+				continue
+			}
+
+			level, err := logger.ParseLevel(name)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("module %q: %s", module, err), http.StatusBadRequest)
+				return
+			}
+			modules[module] = level
+		}
+
+		if req.Level != nil {
+			log.SetLevel(*req.Level)
+		}
+
+		for module, name := range req.Modules {
+			if name == "" {
+				log.ResetModuleLevel(module)
+				continue
+			}
+			log.SetModuleLevel(module, modules[module])
+		}
+
+		log.Info(r.Context(), "debug", "status", "log levels changed", "levels", log.Levels())
+
+		writeJSON(w, http.StatusOK, log.Levels())
+	}
+}
+
+// crashReport is the crash report as shown on the debug server.
+type crashReport struct {
+	ID        string            `json:"id"`
+	TraceID   string            `json:"traceID,omitempty"`
+	UserID    string            `json:"userID,omitempty"`
+	TenantID  string            `json:"tenantID,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	Method    string            `json:"method"`
+	Route     string            `json:"route"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	BodyHash  string            `json:"bodyHash,omitempty"`
+	BodySize  int64             `json:"bodySize"`
+	Panic     string            `json:"panic"`
+	Stack     string            `json:"stack"`
+	CreatedAt string            `json:"createdAt"`
+}
+
+// Panics writes the latest crash reports, newest first. The limit query
+// parameter sets how many, up to 100.
+func Panics(crashBus *crashbus.Core) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 20
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+				return
+			}
+			limit = min(n, 100)
+		}
+
+		reports, err := crashBus.QueryRecent(r.Context(), limit)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("querying crash reports: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]crashReport, len(reports))
+		for i, cr := range reports {
+			resp[i] = crashReport{
+				ID:        cr.ID.String(),
+				TraceID:   cr.TraceID,
+				IP:        cr.IP,
+				Method:    cr.Method,
+				Route:     cr.Route,
+				Headers:   cr.Headers,
+				BodyHash:  cr.BodyHash,
+				BodySize:  cr.BodySize,
+				Panic:     cr.Panic,
+				Stack:     cr.Stack,
+				CreatedAt: cr.CreatedAt.Format(time.RFC3339),
+			}
+
+			if cr.UserID != uuid.Nil {
+				resp[i].UserID = cr.UserID.String()
+			}
+			if cr.TenantID != uuid.Nil {
+				resp[i].TenantID = cr.TenantID.String()
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,205 @@
+package errs
+
+import (
+	"strings"
+)
+
+// Reason is a stable, machine-readable identifier for an error response.
+// Clients should branch on the reason instead of parsing the message, which
+// is free-form text and may change at any time.
+type Reason string
+
+// String returns the string representation of the reason.
+func (r Reason) String() string {
+	return string(r)
+}
+
+// Set of reasons known by the system. Once published a reason must never be
+// renamed; add a new one instead.
+const (
+	ReasonUserNotFound          Reason = "USER_NOT_FOUND"
+	ReasonUserEmailNotUnique    Reason = "USER_EMAIL_NOT_UNIQUE"
+	ReasonUserPhoneNotUnique    Reason = "USER_PHONE_NOT_UNIQUE"
+	ReasonAuthFailed            Reason = "AUTHENTICATION_FAILED"
+	ReasonTenantNotFound        Reason = "TENANT_NOT_FOUND"
+	ReasonDomainNotFound        Reason = "DOMAIN_NOT_FOUND"
+	ReasonACLNotFound           Reason = "ACL_NOT_FOUND"
+	ReasonACLNotUnique          Reason = "ACL_NOT_UNIQUE"
+	ReasonACLNoActions          Reason = "ACL_NO_ACTIONS"
+	ReasonACLInvalidExpiry      Reason = "ACL_INVALID_EXPIRY"
+	ReasonACLAdminPolicy        Reason = "ACL_ADMIN_POLICY_IMMUTABLE"
+	ReasonResourceNotFound      Reason = "RESOURCE_NOT_FOUND"
+	ReasonAccessDenied          Reason = "ACCESS_DENIED"
+	ReasonRateLimited           Reason = "RATE_LIMITED"
+	ReasonPayloadTooLarge       Reason = "PAYLOAD_TOO_LARGE"
+	ReasonInvalidToken          Reason = "INVALID_TOKEN"
+	ReasonDatasourceNotFound    Reason = "DATASOURCE_NOT_FOUND"
+	ReasonDatasourceNotUnique   Reason = "DATASOURCE_NOT_UNIQUE"
+	ReasonDatasourceInUse       Reason = "DATASOURCE_IN_USE"
+	ReasonDatasourceInvalid     Reason = "DATASOURCE_INVALID"
+	ReasonWidgetNotFound        Reason = "WIDGET_NOT_FOUND"
+	ReasonWidgetNotBound        Reason = "WIDGET_NOT_BOUND"
+	ReasonDatasourceQueryFailed Reason = "DATASOURCE_QUERY_FAILED"
+	ReasonDatasourceTimeout     Reason = "DATASOURCE_TIMEOUT"
+	ReasonReportNotFound        Reason = "REPORT_NOT_FOUND"
+	ReasonReportNotReady        Reason = "REPORT_NOT_READY"
+	ReasonReportLinkInvalid     Reason = "REPORT_LINK_INVALID"
+	ReasonNotificationNotFound  Reason = "NOTIFICATION_NOT_FOUND"
+	ReasonFeatureNotFound       Reason = "FEATURE_NOT_FOUND"
+	ReasonFeatureNotUnique      Reason = "FEATURE_NOT_UNIQUE"
+	ReasonFeatureDisabled       Reason = "FEATURE_DISABLED"
+	ReasonAnnouncementNotFound  Reason = "ANNOUNCEMENT_NOT_FOUND"
+	ReasonTermsNotAccepted      Reason = "TERMS_NOT_ACCEPTED"
+	ReasonTermsNotFound         Reason = "TERMS_NOT_FOUND"
+	ReasonTermsNotUnique        Reason = "TERMS_NOT_UNIQUE"
+	ReasonTermsOutdated         Reason = "TERMS_OUTDATED"
+	ReasonSignUpDisabled        Reason = "SIGN_UP_DISABLED"
+	ReasonLDAPNotFound          Reason = "LDAP_NOT_FOUND"
+	ReasonLDAPInvalid           Reason = "LDAP_INVALID"
+	ReasonSAMLNotFound          Reason = "SAML_NOT_FOUND"
+	ReasonSAMLInvalid           Reason = "SAML_INVALID"
+	ReasonSAMLRejected          Reason = "SAML_REJECTED"
+	ReasonDomainTaken           Reason = "DOMAIN_TAKEN"
+	ReasonPrimaryDomain         Reason = "PRIMARY_DOMAIN"
+	ReasonBrandingNotFound      Reason = "NOT_FOUND"
+	ReasonBrandingInvalid       Reason = "INVALID_ARGUMENT"
+	ReasonACLTenantAdminPolicy  Reason = "ACL_TENANT_ADMIN_POLICY"
+	ReasonRoleChangeNotFound    Reason = "ROLE_CHANGE_NOT_FOUND"
+	ReasonRoleChangePending     Reason = "ROLE_CHANGE_PENDING"
+	ReasonRoleChangeNotPending  Reason = "ROLE_CHANGE_NOT_PENDING"
+	ReasonRoleChangeSelfReview  Reason = "ROLE_CHANGE_SELF_REVIEW"
+	ReasonVersionConflict       Reason = "VERSION_CONFLICT"
+)
+
+var catalog = map[Reason]string{
+	ReasonUserNotFound:          "The requested user does not exist.",
+	ReasonUserEmailNotUnique:    "Another user is already registered with this email.",
+	ReasonUserPhoneNotUnique:    "Another user is already registered with this phone.",
+	ReasonAuthFailed:            "The credentials provided are invalid.",
+	ReasonTenantNotFound:        "The requested tenant does not exist.",
+	ReasonDomainNotFound:        "No dashboard is published under this domain.",
+	ReasonACLNotFound:           "The requested ACL entry does not exist.",
+	ReasonACLNotUnique:          "The user already has an ACL entry for this resource.",
+	ReasonACLNoActions:          "At least one action must be granted.",
+	ReasonACLInvalidExpiry:      "The expiration must be in the future.",
+	ReasonACLAdminPolicy:        "The ADMIN role policy cannot be changed.",
+	ReasonResourceNotFound:      "The requested resource does not exist.",
+	ReasonAccessDenied:          "The caller is not allowed to perform this operation.",
+	ReasonRateLimited:           "Too many requests, retry after the given delay.",
+	ReasonPayloadTooLarge:       "The request body exceeds the size limit.",
+	ReasonInvalidToken:          "The confirmation token is invalid, expired or already used.",
+	ReasonDatasourceNotFound:    "The requested datasource does not exist.",
+	ReasonDatasourceNotUnique:   "The tenant already has a datasource with this name.",
+	ReasonDatasourceInUse:       "The datasource is still used by widgets.",
+	ReasonDatasourceInvalid:     "The datasource settings or credentials are invalid.",
+	ReasonWidgetNotFound:        "The requested widget does not exist.",
+	ReasonWidgetNotBound:        "The widget is not bound to a datasource.",
+	ReasonDatasourceQueryFailed: "The query of the widget failed on the datasource.",
+	ReasonDatasourceTimeout:     "The query of the widget took longer than allowed.",
+	ReasonReportNotFound:        "The requested report does not exist.",
+	ReasonReportNotReady:        "The report is still being generated or has failed.",
+	ReasonReportLinkInvalid:     "The download link is invalid or has expired.",
+	ReasonNotificationNotFound:  "The requested notification does not exist.",
+	ReasonFeatureNotFound:       "The requested feature flag does not exist.",
+	ReasonFeatureNotUnique:      "A feature flag with this key already exists.",
+	ReasonFeatureDisabled:       "This feature is not enabled for your account.",
+	ReasonAnnouncementNotFound:  "The requested announcement does not exist.",
+	ReasonTermsNotAccepted:      "The latest terms of service must be accepted before continuing.",
+	ReasonTermsNotFound:         "The requested terms of service do not exist.",
+	ReasonTermsNotUnique:        "Terms of service with this version already exist.",
+	ReasonTermsOutdated:         "Only the latest terms of service can be accepted.",
+	ReasonSignUpDisabled:        "Sign up is not available on this domain.",
+	ReasonLDAPNotFound:          "The tenant has no directory configured.",
+	ReasonLDAPInvalid:           "The directory settings are invalid.",
+	ReasonSAMLNotFound:          "The tenant has no SAML identity provider configured.",
+	ReasonSAMLInvalid:           "The SAML identity provider settings are invalid.",
+	ReasonSAMLRejected:          "The SAML response was rejected.",
+	ReasonDomainTaken:           "The domain is already in use by a dashboard.",
+	ReasonPrimaryDomain:         "The primary domain can't be removed; promote another domain first.",
+	ReasonBrandingNotFound:      "The tenant has no branding configured.",
+	ReasonBrandingInvalid:       "The branding settings are invalid.",
+	ReasonACLTenantAdminPolicy:  "The TENANT_ADMIN role cannot have role policies; grant access through ACLs instead.",
+	ReasonRoleChangeNotFound:    "The role change does not exist.",
+	ReasonRoleChangePending:     "The user already has a role change waiting for approval.",
+	ReasonRoleChangeNotPending:  "The role change was already approved or rejected.",
+	ReasonRoleChangeSelfReview:  "A role change must be reviewed by someone other than its requester.",
+	ReasonVersionConflict:       "The resource was changed by another request. Reload it and try again.",
+}
+
+// Catalog returns a copy of every documented reason with its description.
+func Catalog() map[Reason]string {
+	c := make(map[Reason]string, len(catalog))
+	for r, desc := range catalog {
+		c[r] = desc
+	}
+
+	return c
+}
+
+// defaultReason is used when the caller does not provide a more specific
+// reason, so every error still carries a stable identifier.
+func defaultReason(code ErrCode) Reason {
+	return Reason(strings.ToUpper(code.String()))
+}
+
+// reasonMessages holds the text sent to the client for a reason in locales
+// other than english. Errors without an entry keep their original message.
+var reasonMessages = map[string]map[Reason]string{
+	LocalePTBR: {
+		ReasonUserNotFound:              "O usuário informado não existe.",
+		ReasonUserEmailNotUnique:        "Já existe um usuário cadastrado com este e-mail.",
+		ReasonUserPhoneNotUnique:        "Já existe um usuário cadastrado com este telefone.",
+		ReasonAuthFailed:                "As credenciais informadas são inválidas.",
+		ReasonTenantNotFound:            "O cliente informado não existe.",
+		ReasonDomainNotFound:            "Nenhum dashboard está publicado neste domínio.",
+		ReasonACLNotFound:               "A permissão informada não existe.",
+		ReasonACLNotUnique:              "O usuário já possui uma permissão para este recurso.",
+		ReasonACLNoActions:              "Ao menos uma ação deve ser concedida.",
+		ReasonACLInvalidExpiry:          "A data de expiração deve estar no futuro.",
+		ReasonACLAdminPolicy:            "A política do perfil ADMIN não pode ser alterada.",
+		ReasonResourceNotFound:          "O recurso informado não existe.",
+		ReasonAccessDenied:              "Você não tem permissão para realizar esta operação.",
+		ReasonRateLimited:               "Muitas requisições, tente novamente mais tarde.",
+		ReasonPayloadTooLarge:           "O corpo da requisição excede o tamanho permitido.",
+		ReasonInvalidToken:              "O token de confirmação é inválido, expirou ou já foi usado.",
+		ReasonDatasourceNotFound:        "A fonte de dados informada não existe.",
+		ReasonDatasourceNotUnique:       "O cliente já possui uma fonte de dados com este nome.",
+		ReasonDatasourceInUse:           "A fonte de dados ainda é usada por widgets.",
+		ReasonDatasourceInvalid:         "As configurações ou credenciais da fonte de dados são inválidas.",
+		ReasonWidgetNotFound:            "O widget informado não existe.",
+		ReasonWidgetNotBound:            "O widget não está vinculado a uma fonte de dados.",
+		ReasonDatasourceQueryFailed:     "A consulta do widget falhou na fonte de dados.",
+		ReasonDatasourceTimeout:         "A consulta do widget excedeu o tempo permitido.",
+		ReasonReportNotFound:            "O relatório informado não existe.",
+		ReasonReportNotReady:            "O relatório ainda está sendo gerado ou falhou.",
+		ReasonReportLinkInvalid:         "O link de download é inválido ou expirou.",
+		ReasonNotificationNotFound:      "A notificação informada não existe.",
+		ReasonFeatureNotFound:           "A feature flag informada não existe.",
+		ReasonFeatureNotUnique:          "Já existe uma feature flag com essa chave.",
+		ReasonFeatureDisabled:           "Esta funcionalidade não está habilitada para a sua conta.",
+		ReasonAnnouncementNotFound:      "O aviso informado não existe.",
+		ReasonTermsNotAccepted:          "É preciso aceitar a versão mais recente dos termos de uso para continuar.",
+		ReasonTermsNotFound:             "Os termos de uso informados não existem.",
+		ReasonTermsNotUnique:            "Já existem termos de uso com esta versão.",
+		ReasonTermsOutdated:             "Apenas a versão mais recente dos termos de uso pode ser aceita.",
+		ReasonSignUpDisabled:            "O cadastro não está disponível neste domínio.",
+		ReasonLDAPNotFound:              "O cliente não tem diretório configurado.",
+		ReasonLDAPInvalid:               "As configurações do diretório são inválidas.",
+		ReasonSAMLNotFound:              "O cliente não tem provedor de identidade SAML configurado.",
+		ReasonSAMLInvalid:               "As configurações do provedor de identidade SAML são inválidas.",
+		ReasonSAMLRejected:              "A resposta SAML foi recusada.",
+		ReasonDomainTaken:               "O domínio já está em uso por um dashboard.",
+		ReasonPrimaryDomain:             "O domínio principal não pode ser removido; promova outro domínio antes.",
+		ReasonBrandingNotFound:          "O cliente não tem identidade visual configurada.",
+		ReasonBrandingInvalid:           "As configurações da identidade visual são inválidas.",
+		ReasonACLTenantAdminPolicy:      "O perfil TENANT_ADMIN não pode ter políticas por perfil; conceda o acesso por ACLs.",
+		ReasonRoleChangeNotFound:        "A troca de perfil não existe.",
+		ReasonRoleChangePending:         "O usuário já tem uma troca de perfil aguardando aprovação.",
+		ReasonRoleChangeNotPending:      "A troca de perfil já foi aprovada ou rejeitada.",
+		ReasonRoleChangeSelfReview:      "A troca de perfil deve ser avaliada por outra pessoa que não quem a pediu.",
+		ReasonVersionConflict:           "O registro foi alterado por outra requisição. Recarregue e tente novamente.",
+		defaultReason(Internal):         "Erro interno do servidor.",
+		defaultReason(Unauthenticated):  "Autenticação ausente ou inválida.",
+		defaultReason(PermissionDenied): "Acesso negado.",
+	},
+}
@@ -2,6 +2,8 @@ package errs
 
 import (
 	"net/http"
+
+	"google.golang.org/grpc/codes"
 )
 
 var (
@@ -116,6 +118,14 @@ var (
 	// system has been broken. If you see one of these errors,
 	// something is very broken. The error message is not sent to the client.
 	InternalOnlyLog = ErrCode{value: 19}
+
+	// PayloadTooLarge indicates the request body is larger than the limits
+	// the server is willing to process.
+	PayloadTooLarge = ErrCode{value: 20}
+
+	// LegalReasons indicates the operation is refused until a legal
+	// requirement is met, like accepting the latest terms of service.
+	LegalReasons = ErrCode{value: 21}
 )
 
 var codeNumbers = map[string]ErrCode{
@@ -139,6 +149,8 @@ var codeNumbers = map[string]ErrCode{
 	"unauthenticated":     Unauthenticated,
 	"too_many_requests":   TooManyRequests,
 	"internal_only_log":   InternalOnlyLog,
+	"payload_too_large":   PayloadTooLarge,
+	"legal_reasons":       LegalReasons,
 }
 
 var codeNames = map[ErrCode]string{
@@ -162,6 +174,8 @@ var codeNames = map[ErrCode]string{
 	Unauthenticated:    "unauthenticated",
 	TooManyRequests:    "too_many_requests",
 	InternalOnlyLog:    "internal_only_log",
+	PayloadTooLarge:    "payload_too_large",
+	LegalReasons:       "legal_reasons",
 }
 
 var httpStatus = map[ErrCode]int{
@@ -185,4 +199,33 @@ var httpStatus = map[ErrCode]int{
 	Unauthenticated:    http.StatusUnauthorized,
 	TooManyRequests:    http.StatusTooManyRequests,
 	InternalOnlyLog:    http.StatusInternalServerError,
+	PayloadTooLarge:    http.StatusRequestEntityTooLarge,
+	LegalReasons:       http.StatusUnavailableForLegalReasons,
+}
+
+// A numeração dos códigos não segue a do gRPC (NoContent ocupa o 1), por isso
+// o mapeamento é explícito.
+var grpcCodes = map[ErrCode]codes.Code{
+	None:               codes.OK,
+	NoContent:          codes.OK,
+	Canceled:           codes.Canceled,
+	Unknown:            codes.Unknown,
+	InvalidArgument:    codes.InvalidArgument,
+	DeadlineExceeded:   codes.DeadlineExceeded,
+	NotFound:           codes.NotFound,
+	AlreadyExists:      codes.AlreadyExists,
+	PermissionDenied:   codes.PermissionDenied,
+	ResourceExhausted:  codes.ResourceExhausted,
+	FailedPrecondition: codes.FailedPrecondition,
+	Aborted:            codes.Aborted,
+	OutOfRange:         codes.OutOfRange,
+	Unimplemented:      codes.Unimplemented,
+	Internal:           codes.Internal,
+	Unavailable:        codes.Unavailable,
+	DataLoss:           codes.DataLoss,
+	Unauthenticated:    codes.Unauthenticated,
+	TooManyRequests:    codes.ResourceExhausted,
+	InternalOnlyLog:    codes.Internal,
+	PayloadTooLarge:    codes.ResourceExhausted,
+	LegalReasons:       codes.FailedPrecondition,
 }
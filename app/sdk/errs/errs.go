@@ -3,8 +3,13 @@ package errs
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"runtime"
+	"strings"
+
+	"google.golang.org/grpc/status"
 )
 
 // ErrCode represents an error code in the system.
@@ -51,35 +56,72 @@ func (ec ErrCode) Equal(ec2 ErrCode) bool {
 // Error represents an error in the system.
 type Error struct {
 	Code     ErrCode `json:"code"`
+	Reason   Reason  `json:"reason"`
 	Message  string  `json:"message"`
 	FuncName string  `json:"-"`
 	FileName string  `json:"-"`
+	fields   FieldErrors
 }
 
-// New constructs an error based on an app error.
+// New constructs an error based on an app error. Errors caused by a request
+// body over the size limit are always reported as PayloadTooLarge.
 func New(code ErrCode, err error) *Error {
 	pc, filename, line, _ := runtime.Caller(1)
 
+	reason := defaultReason(code)
+
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		code = PayloadTooLarge
+		reason = ReasonPayloadTooLarge
+	}
+
 	return &Error{
 		Code:     code,
+		Reason:   reason,
 		Message:  err.Error(),
 		FuncName: runtime.FuncForPC(pc).Name(),
 		FileName: fmt.Sprintf("%s:%d", filename, line),
+		fields:   fieldsOf(err),
 	}
 }
 
+// fieldsOf keeps the field errors carried by err, even when they are already
+// inside another app error, so they can be translated later.
+func fieldsOf(err error) FieldErrors {
+	var fe FieldErrors
+	if errors.As(err, &fe) {
+		return fe
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.fields
+	}
+
+	return nil
+}
+
 // Errorf constructs an error based on a error message.
 func Errorf(code ErrCode, format string, v ...any) *Error {
 	pc, filename, line, _ := runtime.Caller(1)
 
 	return &Error{
 		Code:     code,
+		Reason:   defaultReason(code),
 		Message:  fmt.Sprintf(format, v...),
 		FuncName: runtime.FuncForPC(pc).Name(),
 		FileName: fmt.Sprintf("%s:%d", filename, line),
 	}
 }
 
+// WithReason replaces the default reason, derived from the code, with a
+// more specific one from the catalog.
+func (e *Error) WithReason(reason Reason) *Error {
+	e.Reason = reason
+	return e
+}
+
 // Error implements the error interface.
 func (e *Error) Error() string {
 	return e.Message
@@ -97,17 +139,46 @@ func (e *Error) HTTPStatus() int {
 	return httpStatus[e.Code]
 }
 
+// GRPCStatus implements the interface used by the grpc status package so an
+// error returned by a gRPC handler carries the matching code. The message is
+// prefixed with the reason, e.g. "USER_NOT_FOUND: ...".
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(grpcCodes[e.Code], e.Reason.String()+": "+e.Message)
+}
+
+// Localize returns a copy of the error with the message translated to the
+// locale. Field errors are translated one by one; other errors use the
+// catalog text for their reason when it exists, otherwise the message is
+// kept as is.
+func (e *Error) Localize(locale string) *Error {
+	le := *e
+
+	switch {
+	case e.fields != nil:
+		le.fields = e.fields.Localize(locale)
+		le.Message = strings.Replace(e.Message, e.fields.Error(), le.fields.Error(), 1)
+
+	default:
+		if msg, exists := reasonMessages[locale][e.Reason]; exists {
+			le.Message = msg
+		}
+	}
+
+	return &le
+}
+
 // Equal provides support for the go-cmp package and testing.
 func (e *Error) Equal(e2 *Error) bool {
-	return e.Code == e2.Code && e.Message == e2.Message
+	return e.Code == e2.Code && e.Reason == e2.Reason && e.Message == e2.Message
 }
 
 // =============================================================================
 
 // FieldError is used to indicate an error with a specific request field.
 type FieldError struct {
-	Field string `json:"field"`
-	Err   string `json:"error"`
+	Field        string `json:"field"`
+	Err          string `json:"error"`
+	translations map[string]string
 }
 
 // FieldErrors represents a collection of field errors.
@@ -133,6 +204,20 @@ func (fe *FieldErrors) Add(field string, err error) {
 	})
 }
 
+// Localize returns a copy of the field errors with every message that has a
+// translation for the locale replaced.
+func (fe FieldErrors) Localize(locale string) FieldErrors {
+	lfe := make(FieldErrors, len(fe))
+	for i, f := range fe {
+		lfe[i] = f
+		if msg, exists := f.translations[locale]; exists {
+			lfe[i].Err = msg
+		}
+	}
+
+	return lfe
+}
+
 // ToError converts the field errors to an Error.
 func (fe FieldErrors) ToError() *Error {
 	return New(InvalidArgument, fe)
@@ -0,0 +1,30 @@
+package errs
+
+import (
+	"strings"
+)
+
+// Set of locales supported for error messages.
+const (
+	LocaleEN   = "en"
+	LocalePTBR = "pt_BR"
+)
+
+// ParseLocale picks the first supported locale from an Accept-Language
+// header, ignoring quality values. Any portuguese variant is served in
+// pt-BR. English is used when nothing matches.
+func ParseLocale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		switch {
+		case tag == "pt" || strings.HasPrefix(tag, "pt-") || strings.HasPrefix(tag, "pt_"):
+			return LocalePTBR
+		case tag == "en" || strings.HasPrefix(tag, "en-") || strings.HasPrefix(tag, "en_"):
+			return LocaleEN
+		}
+	}
+
+	return LocaleEN
+}
@@ -5,9 +5,11 @@ import (
 	"strings"
 
 	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ptbr_translations "github.com/go-playground/validator/v10/translations/pt_BR"
 )
 
 // validate holds the settings and caches for validating request struct values.
@@ -16,6 +18,9 @@ var validate *validator.Validate
 // translator is a cache of locale and translation information.
 var translator ut.Translator
 
+// translators holds a translator for every supported locale.
+var translators = map[string]ut.Translator{}
+
 func init() {
 
 	// Instantiate a validator.
@@ -23,11 +28,20 @@ func init() {
 
 	// Create a translator for english so the error messages are
 	// more human-readable than technical.
-	translator, _ = ut.New(en.New(), en.New()).GetTranslator("en")
+	uni := ut.New(en.New(), en.New(), pt_BR.New())
+	translator, _ = uni.GetTranslator(LocaleEN)
 
 	// Register the english error messages for use.
 	en_translations.RegisterDefaultTranslations(validate, translator)
 
+	// Os usuários finais são brasileiros, então as mensagens também são
+	// registradas em pt-BR.
+	ptbr, _ := uni.GetTranslator(LocalePTBR)
+	ptbr_translations.RegisterDefaultTranslations(validate, ptbr)
+
+	translators[LocaleEN] = translator
+	translators[LocalePTBR] = ptbr
+
 	// Use JSON tag names for errors instead of Go struct names.
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
@@ -48,9 +62,15 @@ func Check(val any) error {
 
 		var fields FieldErrors
 		for _, verror := range verrors {
+			msgs := make(map[string]string, len(translators))
+			for locale, trans := range translators {
+				msgs[locale] = verror.Translate(trans)
+			}
+
 			fields = append(fields, FieldError{
-				Field: verror.Field(),
-				Err:   verror.Translate(translator),
+				Field:        verror.Field(),
+				Err:          verror.Translate(translator),
+				translations: msgs,
 			})
 		}
 
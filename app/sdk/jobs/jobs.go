@@ -0,0 +1,202 @@
+// Package jobs binds the background work of the business domains to the
+// worker, so the API and the worker binary schedule the same jobs.
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// Set of job names. Global jobs can also be run on demand by enqueueing a
+// task with the name.
+const (
+	ACLSweep           = "acl.sweep"
+	ResetTokensPurge   = "user.reset_tokens.purge"
+	RegistrationsPurge = "user.registrations.purge"
+	OutboxDispatch     = "outbox.dispatch"
+	UsageFlush         = "usage.flush"
+	ActivityPurge      = "activity.purge"
+	TasksPurge         = "worker.tasks.purge"
+	ReportsPurge       = "report.purge"
+	NotificationsPurge = "notification.purge"
+	AssertionsPurge    = "saml.assertions.purge"
+)
+
+// taskRetention is how long finished tasks are kept for inspection.
+const taskRetention = 7 * 24 * time.Hour
+
+// Config contains the buses the jobs work on. A nil bus leaves its jobs out.
+type Config struct {
+	Log             *logger.Logger
+	UserBus         *userbus.Core
+	ACLBus          *aclbus.Core
+	UsageBus        *usagebus.Core
+	OutboxBus       *outboxbus.Core
+	ActivityBus     *activitybus.Core
+	ReportBus       *reportbus.Core
+	NotificationBus *notificationbus.Core
+	SAMLBus         *samlbus.Core
+
+	// OutboxPublisher delivers the domain events every OutboxInterval. The
+	// events are kept in the outbox when it is nil.
+	OutboxPublisher outboxbus.Publisher
+	OutboxInterval  time.Duration
+}
+
+// Register schedules the jobs of the configured buses on the worker.
+func Register(w *worker.Worker, cfg Config) {
+	log := cfg.Log
+
+	if cfg.ACLBus != nil {
+
+		// Time limited grants are purged once they expire.
+		w.Schedule(worker.Job{
+			Name:     ACLSweep,
+			Schedule: worker.Every(time.Minute),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.ACLBus.PurgeExpired(ctx)
+				if n > 0 {
+					log.Info(ctx, "acl sweeper", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	if cfg.UserBus != nil {
+		w.Schedule(worker.Job{
+			Name:     ResetTokensPurge,
+			Schedule: worker.MustCron("@hourly"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.UserBus.PurgeExpiredResetTokens(ctx)
+				if n > 0 {
+					log.Info(ctx, "reset tokens purge", "purged", n)
+				}
+				return err
+			},
+		})
+
+		// Users who signed up and never confirmed the email are removed, so
+		// the address can sign up again.
+		w.Schedule(worker.Job{
+			Name:     RegistrationsPurge,
+			Schedule: worker.MustCron("@hourly"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.UserBus.PurgeExpiredRegistrations(ctx)
+				if n > 0 {
+					log.Info(ctx, "registrations purge", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	// Failed deliveries are retried by the dispatcher with backoff.
+	if cfg.OutboxBus != nil && cfg.OutboxPublisher != nil {
+		w.Schedule(worker.Job{
+			Name:     OutboxDispatch,
+			Schedule: worker.Every(cfg.OutboxInterval),
+			Run: func(ctx context.Context) error {
+				_, err := cfg.OutboxBus.DispatchAll(ctx, cfg.OutboxPublisher)
+				return err
+			},
+		})
+	}
+
+	// The usage counters are kept in memory, so every instance flushes its
+	// own, one last time on shutdown.
+	if cfg.UsageBus != nil {
+		w.Schedule(worker.Job{
+			Name:     UsageFlush,
+			Schedule: worker.Every(time.Minute),
+			Run:      cfg.UsageBus.Flush,
+			Local:    true,
+			Final:    true,
+		})
+	}
+
+	// The activity feed keeps only the retention of the activitybus.
+	if cfg.ActivityBus != nil {
+		w.Schedule(worker.Job{
+			Name:     ActivityPurge,
+			Schedule: worker.MustCron("30 3 * * *"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.ActivityBus.PurgeExpired(ctx)
+				if n > 0 {
+					log.Info(ctx, "activity purge", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	if cfg.NotificationBus != nil {
+		w.Schedule(worker.Job{
+			Name:     NotificationsPurge,
+			Schedule: worker.MustCron("40 3 * * *"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.NotificationBus.PurgeExpired(ctx)
+				if n > 0 {
+					log.Info(ctx, "notifications purge", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	// Reports are rendered on demand and kept for the retention of the
+	// reportbus.
+	if cfg.ReportBus != nil {
+		w.Handle(reportbus.TaskRender, cfg.ReportBus.Render)
+
+		w.Schedule(worker.Job{
+			Name:     ReportsPurge,
+			Schedule: worker.MustCron("45 3 * * *"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.ReportBus.PurgeExpired(ctx)
+				if n > 0 {
+					log.Info(ctx, "reports purge", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	// The assertions are kept only while they could be replayed.
+	if cfg.SAMLBus != nil {
+		w.Schedule(worker.Job{
+			Name:     AssertionsPurge,
+			Schedule: worker.MustCron("@hourly"),
+			Run: func(ctx context.Context) error {
+				n, err := cfg.SAMLBus.PurgeExpiredAssertions(ctx)
+				if n > 0 {
+					log.Info(ctx, "saml assertions purge", "purged", n)
+				}
+				return err
+			},
+		})
+	}
+
+	w.Schedule(worker.Job{
+		Name:     TasksPurge,
+		Schedule: worker.MustCron("0 3 * * *"),
+		Run: func(ctx context.Context) error {
+			n, err := w.PurgeFinished(ctx, time.Now().Add(-taskRetention))
+			if n > 0 {
+				log.Info(ctx, "tasks purge", "purged", n)
+			}
+			return err
+		},
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"expvar"
 	"runtime"
+	"sync/atomic"
 )
 
 // This holds the single instance of the metrics value needed for
@@ -16,9 +17,9 @@ var m metrics
 // safe to be accessed concurrently thanks to expvar. No extra abstraction is required.
 type metrics struct {
 	goroutines *expvar.Int
-	requests   *expvar.Int
-	errors     *expvar.Int
 	panics     *expvar.Int
+	requests   atomic.Int64
+	routes     *routes
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -28,10 +29,15 @@ type metrics struct {
 func init() {
 	m = metrics{
 		goroutines: expvar.NewInt("goroutines"),
-		requests:   expvar.NewInt("requests"),
-		errors:     expvar.NewInt("errors"),
 		panics:     expvar.NewInt("panics"),
+		routes:     newRoutes(),
 	}
+
+	// Os contadores globais de requisições e erros foram substituídos pelas
+	// séries por rota, que somadas dão os mesmos totais.
+	expvar.Publish("routes", expvar.Func(func() any {
+		return Routes()
+	}))
 }
 
 type ctxKey int
@@ -54,27 +60,6 @@ func AddGoroutines(ctx context.Context) int64 {
 	return 0
 }
 
-// AddRequests increments the request metric by 1.
-func AddRequests(ctx context.Context) int64 {
-	v, ok := ctx.Value(key).(*metrics)
-	if ok {
-		v.requests.Add(1)
-		return v.requests.Value()
-	}
-
-	return 0
-}
-
-// AddErrors increments the errors metric by 1.
-func AddErrors(ctx context.Context) int64 {
-	if v, ok := ctx.Value(key).(*metrics); ok {
-		v.errors.Add(1)
-		return v.errors.Value()
-	}
-
-	return 0
-}
-
 // AddPanics increments the panics metric by 1.
 func AddPanics(ctx context.Context) int64 {
 	if v, ok := ctx.Value(key).(*metrics); ok {
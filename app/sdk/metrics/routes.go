@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in milliseconds, of the latency
+// histogram of each series.
+var latencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// maxSeries caps the number of series kept. Once reached, the requests of
+// new tenants are counted under the "other" tenant.
+const maxSeries = 10000
+
+// seriesKey identifies the requests of a route, ending in a status class,
+// made by a tenant. The tenant is "none" for requests without one.
+type seriesKey struct {
+	route  string
+	class  string
+	tenant string
+}
+
+// series accumulates the requests of a key.
+type series struct {
+	count   int64
+	sum     float64
+	buckets []int64 // O último é o +Inf.
+}
+
+// Series is a snapshot of the requests of a route, status class and tenant.
+type Series struct {
+	Route   string           `json:"route"`
+	Class   string           `json:"class"`
+	Tenant  string           `json:"tenant"`
+	Count   int64            `json:"count"`
+	SumMS   float64          `json:"sum_ms"`
+	Buckets map[string]int64 `json:"buckets"`
+}
+
+type routes struct {
+	mu     sync.Mutex
+	series map[seriesKey]*series
+}
+
+func newRoutes() *routes {
+	return &routes{
+		series: make(map[seriesKey]*series),
+	}
+}
+
+func (rs *routes) observe(route string, status int, tenant string, took time.Duration) {
+	if tenant == "" {
+		tenant = "none"
+	}
+
+	key := seriesKey{route: route, class: StatusClass(status), tenant: tenant}
+	ms := float64(took) / float64(time.Millisecond)
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	s, exists := rs.series[key]
+	if !exists {
+		if len(rs.series) >= maxSeries {
+			key.tenant = "other"
+			s = rs.series[key]
+		}
+
+		if s == nil {
+			s = &series{buckets: make([]int64, len(latencyBuckets)+1)}
+			rs.series[key] = s
+		}
+	}
+
+	s.count++
+	s.sum += ms
+
+	i, _ := slices.BinarySearch(latencyBuckets, ms)
+	s.buckets[i]++
+}
+
+// snapshot returns the series sorted by route, class and tenant, with the
+// buckets cumulative as in a Prometheus histogram.
+func (rs *routes) snapshot() []Series {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	out := make([]Series, 0, len(rs.series))
+
+	for key, s := range rs.series {
+		buckets := make(map[string]int64, len(s.buckets))
+
+		var cum int64
+		for i, n := range s.buckets {
+			cum += n
+			buckets[bucketLabel(i)] = cum
+		}
+
+		out = append(out, Series{
+			Route:   key.route,
+			Class:   key.class,
+			Tenant:  key.tenant,
+			Count:   s.count,
+			SumMS:   s.sum,
+			Buckets: buckets,
+		})
+	}
+
+	slices.SortFunc(out, func(a, b Series) int {
+		return strings.Compare(a.Route+"\x00"+a.Class+"\x00"+a.Tenant, b.Route+"\x00"+b.Class+"\x00"+b.Tenant)
+	})
+
+	return out
+}
+
+func bucketLabel(i int) string {
+	if i == len(latencyBuckets) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(latencyBuckets[i], 'f', -1, 64)
+}
+
+// StatusClass returns the class of the status code, e.g. "4xx" for 404.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// =============================================================================
+
+// ObserveRequest records a handled request against its route, the class of
+// the status and the tenant, returning the number of requests handled so
+// far. The tenant is empty for requests without one.
+func ObserveRequest(ctx context.Context, route string, status int, tenant string, took time.Duration) int64 {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.routes.observe(route, status, tenant, took)
+		return v.requests.Add(1)
+	}
+
+	return 0
+}
+
+// Routes returns a snapshot of the per route series.
+func Routes() []Series {
+	return m.routes.snapshot()
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		var b strings.Builder
+
+		b.WriteString("# HELP http_requests_total Requests handled per route, status class and tenant.\n")
+		b.WriteString("# TYPE http_requests_total counter\n")
+
+		all := Routes()
+		for _, s := range all {
+			fmt.Fprintf(&b, "http_requests_total{%s} %d\n", labels(s), s.Count)
+		}
+
+		b.WriteString("# HELP http_request_duration_ms Latency of the requests in milliseconds.\n")
+		b.WriteString("# TYPE http_request_duration_ms histogram\n")
+
+		for _, s := range all {
+			l := labels(s)
+			for i := range len(latencyBuckets) + 1 {
+				le := bucketLabel(i)
+				fmt.Fprintf(&b, "http_request_duration_ms_bucket{%s,le=%q} %d\n", l, le, s.Buckets[le])
+			}
+			fmt.Fprintf(&b, "http_request_duration_ms_sum{%s} %g\n", l, s.SumMS)
+			fmt.Fprintf(&b, "http_request_duration_ms_count{%s} %d\n", l, s.Count)
+		}
+
+		b.WriteString("# TYPE goroutines gauge\n")
+		fmt.Fprintf(&b, "goroutines %d\n", m.goroutines.Value())
+
+		b.WriteString("# TYPE panics_total counter\n")
+		fmt.Fprintf(&b, "panics_total %d\n", m.panics.Value())
+
+		w.Write([]byte(b.String()))
+	})
+}
+
+func labels(s Series) string {
+	return fmt.Sprintf("route=%q,class=%q,tenant=%q", s.Route, s.Class, s.Tenant)
+}
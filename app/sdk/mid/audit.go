@@ -0,0 +1,179 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// auditBodyLimit is the largest body copied into the audit trail. Larger
+// bodies are still handed to the handler untouched but are not recorded.
+const auditBodyLimit = 64 << 10
+
+// redactedFields lists the substrings of JSON keys whose values never reach
+// the audit trail. A matching key hides its whole value, so the credentials
+// object of a data source, service account key included, is dropped at once.
+var redactedFields = []string{"password", "token", "secret", "credential", "private", "logo"}
+
+// Audit records every POST, PUT, PATCH and DELETE request in the audit trail
+// with the actor, tenant, client IP, resulting status and a redacted copy of
+// the body.
+// Routes can opt out with NoAudit. Failing to record is logged and never
+// changes the response.
+func Audit(log *logger.Logger, auditBus *auditbus.Core) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				return next(ctx, r)
+			}
+
+			var body []byte
+			if r.Body != nil {
+				data, err := io.ReadAll(io.LimitReader(r.Body, auditBodyLimit+1))
+				if err == nil && len(data) <= auditBodyLimit {
+					body = data
+				}
+				r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+			}
+
+			// O ator e o opt-out são preenchidos nos valores da requisição
+			// pelos middlewares seguintes.
+			ctx, v := values(ctx)
+
+			resp := next(ctx, r)
+
+			if v.NoAudit {
+				return resp
+			}
+
+			route := r.Pattern
+			if _, p, ok := strings.Cut(route, " "); ok {
+				route = p
+			}
+			if route == "" {
+				route = r.URL.Path
+			}
+
+			na := auditbus.NewAudit{
+				ActorID:  v.UserID,
+				TenantID: v.TenantID,
+				IP:       v.ClientIP,
+				Method:   r.Method,
+				Route:    route,
+				Status:   statusOf(resp),
+				Body:     redactBody(body),
+			}
+
+			if _, err := auditBus.Create(ctx, na); err != nil {
+				log.Error(ctx, "audit", "status", "recording request", "method", na.Method, "route", na.Route, "ERROR", err)
+			}
+
+			return resp
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// NoAudit opts the route out of the audit trail, e.g. for endpoints that
+// carry credentials or are too noisy to keep.
+func NoAudit() web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			if v := webcontext.Get(ctx); v != nil {
+				v.NoAudit = true
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// statusOf mirrors the status web.Respond will send for the response.
+func statusOf(resp web.Encoder) int {
+	switch v := resp.(type) {
+	case interface{ HTTPStatus() int }:
+		return v.HTTPStatus()
+
+	case error:
+		return http.StatusInternalServerError
+
+	default:
+		if resp == nil {
+			return http.StatusNoContent
+		}
+		return http.StatusOK
+	}
+}
+
+// redactBody returns the JSON body with every sensitive field replaced.
+// Bodies that are not JSON are not recorded.
+func redactBody(data []byte) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+
+	redacted, err := json.Marshal(redact(v))
+	if err != nil {
+		return nil
+	}
+
+	return redacted
+}
+
+func redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, fv := range val {
+			if isRedacted(k) {
+				val[k] = "[REDACTED]"
+				continue
+			}
+			val[k] = redact(fv)
+		}
+
+	case []any:
+		for i, iv := range val {
+			val[i] = redact(iv)
+		}
+	}
+
+	return v
+}
+
+func isRedacted(key string) bool {
+	key = strings.ToLower(key)
+	for _, f := range redactedFields {
+		if strings.Contains(key, f) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -15,48 +15,20 @@ import (
 
 // Authenticate valida o token JWT contido no header Authorization.
 // Também realiza o "Tenant Binding", verificando se o Tenant do token
-// corresponde ao Tenant da URL (se resolvido anteriormente).
+// corresponde ao Tenant da URL (se resolvido anteriormente), e exige o aceite
+// dos termos de uso quando o middleware Terms está ligado.
 func Authenticate(a *auth.Auth) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 
-			authStr := r.Header.Get("authorization")
-			if authStr == "" {
-				return errs.New(errs.Unauthenticated, errors.New("missing authorization header"))
-			}
-
-			parts := strings.Split(authStr, " ")
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				return errs.New(errs.Unauthenticated, errors.New("expected authorization header format: Bearer <token>"))
-			}
-
-			claims, err := a.Authenticate(ctx, authStr)
-			if err != nil {
-				return errs.New(errs.Unauthenticated, err)
-			}
-
-			userID, err := uuid.Parse(claims.Subject)
+			ctx, err := authenticate(ctx, a, r.Header.Get("authorization"))
 			if err != nil {
-				return errs.New(errs.Unauthenticated, fmt.Errorf("invalid user id: %w", err))
+				return err
 			}
 
-			dashID, err := uuid.Parse(claims.DashboardID)
-			if err != nil {
-				return errs.New(errs.Unauthenticated, fmt.Errorf("invalid dashboard id: %w", err))
-			}
-
-			var tdID uuid.UUID
-
-			if claims.TenantID != "" {
-				tdID, err = uuid.Parse(claims.TenantID)
-				if err != nil {
-					return errs.New(errs.Unauthenticated, fmt.Errorf("invalid tenant id: %w", err))
-				}
+			if err := checkTerms(ctx); err != nil {
+				return err
 			}
-			ctx = setUserID(ctx, userID)
-			ctx = setTenantID(ctx, tdID)
-			ctx = setDashboardID(ctx, dashID)
-			ctx = setClaims(ctx, claims)
 
 			return next(ctx, r)
 		}
@@ -66,3 +38,45 @@ func Authenticate(a *auth.Auth) web.MidFunc {
 
 	return m
 }
+
+// authenticate validates the authorization header value and stores the
+// claims and the identifiers they carry in the context. It is shared by the
+// web middleware and the gRPC interceptors.
+func authenticate(ctx context.Context, a *auth.Auth, authStr string) (context.Context, *errs.Error) {
+	if authStr == "" {
+		return ctx, errs.New(errs.Unauthenticated, errors.New("missing authorization header"))
+	}
+
+	parts := strings.Split(authStr, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return ctx, errs.New(errs.Unauthenticated, errors.New("expected authorization header format: Bearer <token>"))
+	}
+
+	claims, err := a.Authenticate(ctx, authStr)
+	if err != nil {
+		return ctx, errs.New(errs.Unauthenticated, err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return ctx, errs.New(errs.Unauthenticated, fmt.Errorf("invalid user id: %w", err))
+	}
+
+	dashID, err := uuid.Parse(claims.DashboardID)
+	if err != nil {
+		return ctx, errs.New(errs.Unauthenticated, fmt.Errorf("invalid dashboard id: %w", err))
+	}
+
+	var tdID uuid.UUID
+
+	if claims.TenantID != "" {
+		tdID, err = uuid.Parse(claims.TenantID)
+		if err != nil {
+			return ctx, errs.New(errs.Unauthenticated, fmt.Errorf("invalid tenant id: %w", err))
+		}
+	}
+
+	ctx = setIdentity(ctx, claims, userID, tdID, dashID)
+
+	return ctx, nil
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
 	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
@@ -22,7 +27,7 @@ func Authorize(ath *auth.Auth, allowedRoles ...role.Role) web.MidFunc {
 			}
 
 			if err := ath.Authorize(ctx, claims, allowedRoles...); err != nil {
-				return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err))
+				return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
 			}
 
 			return next(ctx, r)
@@ -33,3 +38,145 @@ func Authorize(ath *auth.Auth, allowedRoles ...role.Role) web.MidFunc {
 
 	return m
 }
+
+// AuthorizeResource checks if the authenticated user can perform the action
+// on the resource. When pathParam is informed the instance ID is taken from
+// the URL and validated against the role policies and the ACLs of the user,
+// otherwise only the role policy for the resource type is considered.
+func AuthorizeResource(aclBus *aclbus.Core, rsc resource.Resource, action actions.Action, pathParam string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			userID, err := GetUserID(ctx)
+			if err != nil {
+				return errs.New(errs.Unauthenticated, errors.New("user id missing from context: authorize called without authenticate?"))
+			}
+
+			switch pathParam {
+			case "":
+				err = aclBus.ValidateAccessToResource(ctx, userID, rsc, action)
+
+			default:
+				resourceID, perr := uuid.Parse(r.PathValue(pathParam))
+				if perr != nil {
+					return errs.NewFieldErrors(pathParam, perr)
+				}
+
+				err = aclBus.ValidateAccess(ctx, userID, resourceID, action)
+			}
+
+			if err != nil {
+				if errors.Is(err, aclbus.ErrAccessDenied) {
+					return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
+				}
+				return errs.Errorf(errs.Internal, "authorize resource: resource[%s] action[%s]: %s", rsc, action, err)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeTenant checks the tenant in the path is the tenant of the token.
+// ADMINs act on any tenant; the other roles, like TENANT_ADMIN, only on the
+// tenant of the session.
+func AuthorizeTenant(pathParam string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			tenantID, err := uuid.Parse(r.PathValue(pathParam))
+			if err != nil {
+				return errs.NewFieldErrors(pathParam, err)
+			}
+
+			if errEnc := CheckTenant(ctx, tenantID); errEnc != nil {
+				return errEnc
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeTenantUser checks the user in the path is a member of the tenant
+// of the token. ADMINs act on any user.
+func AuthorizeTenantUser(tenantBus *tenantbus.Core, pathParam string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			userID, err := uuid.Parse(r.PathValue(pathParam))
+			if err != nil {
+				return errs.NewFieldErrors(pathParam, err)
+			}
+
+			if errEnc := CheckTenantUser(ctx, tenantBus, userID); errEnc != nil {
+				return errEnc
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// IsAdmin reports whether the authenticated user is an ADMIN, the only role
+// that acts across tenants.
+func IsAdmin(ctx context.Context) bool {
+	return GetClaims(ctx).Role == role.Admin.String()
+}
+
+// CheckTenant checks the tenant is the tenant of the token, for the handlers
+// that take the tenant from the body or the resource. ADMINs pass for any
+// tenant.
+func CheckTenant(ctx context.Context, tenantID uuid.UUID) *errs.Error {
+	if IsAdmin(ctx) {
+		return nil
+	}
+
+	sessionID, err := GetTenantID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, errors.New("tenant id missing from context: authorize called without authenticate?"))
+	}
+
+	// Sem cliente no token não há escopo a conferir, e só o ADMIN age fora
+	// de um cliente.
+	if sessionID == uuid.Nil || sessionID != tenantID {
+		return errs.New(errs.PermissionDenied, fmt.Errorf("tenant[%s] is not the tenant of the session[%s]", tenantID, sessionID)).WithReason(errs.ReasonAccessDenied)
+	}
+
+	return nil
+}
+
+// CheckTenantUser checks the user is a member of the tenant of the token.
+// ADMINs pass for any user.
+func CheckTenantUser(ctx context.Context, tenantBus *tenantbus.Core, userID uuid.UUID) *errs.Error {
+	if IsAdmin(ctx) {
+		return nil
+	}
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, errors.New("tenant id missing from context: authorize called without authenticate?"))
+	}
+
+	if tenantID == uuid.Nil {
+		return errs.New(errs.PermissionDenied, errors.New("session has no tenant")).WithReason(errs.ReasonAccessDenied)
+	}
+
+	if err := tenantBus.CheckAccess(ctx, userID, tenantID); err != nil {
+		if errors.Is(err, tenantbus.ErrAccessDenied) {
+			return errs.New(errs.PermissionDenied, fmt.Errorf("user[%s] is not in tenant[%s]: %w", userID, tenantID, err)).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.Internal, "checkaccess: userID[%s] tenantID[%s]: %s", userID, tenantID, err)
+	}
+
+	return nil
+}
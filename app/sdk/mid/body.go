@@ -0,0 +1,22 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// MaxBodyBytes overrides the request body size limit for the route, e.g. to
+// allow larger uploads than the global limit or to tighten it.
+func MaxBodyBytes(maxBytes int64) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			return next(ctx, web.WithMaxBodyBytes(r, maxBytes))
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
-	"github.com/jcpaschoal/spi-exata/foundation/otel"
 )
 
 // Errors handles errors coming out of the call chain.
@@ -23,15 +22,13 @@ func Errors(log *logger.Logger) web.MidFunc {
 				return resp
 			}
 
-			_, span := otel.AddSpan(ctx, "app.sdk.mid.error")
-			span.RecordError(err)
-			defer span.End()
-
 			var appErr *errs.Error
 			if !errors.As(err, &appErr) {
 				appErr = errs.Errorf(errs.Internal, "Internal Server Error")
 			}
 
+			recordError(ctx, err, appErr)
+
 			log.Error(ctx, "handled error during request",
 				"err", err,
 				"source_err_file", path.Base(appErr.FileName),
@@ -41,6 +38,8 @@ func Errors(log *logger.Logger) web.MidFunc {
 				appErr = errs.Errorf(errs.Internal, "Internal Server Error")
 			}
 
+			appErr = appErr.Localize(errs.ParseLocale(r.Header.Get("Accept-Language")))
+
 			// Send the error to the web package so the error can be
 			// used as the response.
 
@@ -0,0 +1,79 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Features evaluates the feature flags for the authenticated user and stores
+// them in the context, where handlers read them with FeatureEnabled. It must
+// run after Authenticate.
+func Features(featureBus *featurebus.Core) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			v, ok := getIdentity(ctx)
+			if !ok {
+				return errs.New(errs.Unauthenticated, errors.New("identity missing from context: features called without authenticate?"))
+			}
+
+			set, err := featureBus.Evaluate(ctx, featurebus.Subject{UserID: v.UserID, TenantID: v.TenantID})
+			if err != nil {
+				return errs.Errorf(errs.Internal, "features: %s", err)
+			}
+
+			v.Features = set
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// RequireFeature answers not found while the flag is off for the
+// authenticated user, hiding a route still being rolled out. It must run
+// after Authenticate.
+func RequireFeature(featureBus *featurebus.Core, key string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			v, ok := getIdentity(ctx)
+			if !ok {
+				return errs.New(errs.Unauthenticated, errors.New("identity missing from context: require feature called without authenticate?"))
+			}
+
+			if !featureBus.Enabled(ctx, key, featurebus.Subject{UserID: v.UserID, TenantID: v.TenantID}) {
+				return errs.New(errs.NotFound, fmt.Errorf("feature %q is disabled", key)).WithReason(errs.ReasonFeatureDisabled)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// GetFeatures returns the flags evaluated by the Features middleware.
+func GetFeatures(ctx context.Context) featurebus.Set {
+	if v := webcontext.Get(ctx); v != nil {
+		return v.Features
+	}
+
+	return nil
+}
+
+// FeatureEnabled reports whether the flag is on for the request. Flags are
+// off when the Features middleware did not run.
+func FeatureEnabled(ctx context.Context, key string) bool {
+	return GetFeatures(ctx).Enabled(key)
+}
@@ -0,0 +1,289 @@
+package mid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/metrics"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	gotel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Os interceptors abaixo espelham os middlewares web para os serviços gRPC.
+// A autenticação não é um interceptor: assim como nas rotas, cada método
+// declara a política que exige.
+
+// GRPCOtel starts the span of the call, continuing the trace propagated by the
+// client in the metadata.
+func GRPCOtel(tracer trace.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = gotel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		ctx, span := tracer.Start(ctx, info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		ctx = otel.InjectTracing(ctx, tracer)
+
+		v := webcontext.Values{
+			TraceID: otel.GetTraceID(ctx),
+			Now:     time.Now(),
+		}
+		ctx = webcontext.Set(ctx, &v)
+
+		resp, err := handler(ctx, req)
+
+		setSpanIdentity(ctx, &v)
+
+		return resp, err
+	}
+}
+
+// GRPCLogger writes information about the call to the logs.
+func GRPCLogger(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		now := time.Now()
+
+		var remoteAddr string
+		if p, ok := peer.FromContext(ctx); ok {
+			remoteAddr = p.Addr.String()
+		}
+
+		log.Info(ctx, "request started", "method", info.FullMethod, "remoteaddr", remoteAddr)
+
+		resp, err := handler(ctx, req)
+
+		log.Info(ctx, "request completed", "method", info.FullMethod, "remoteaddr", remoteAddr,
+			"statuscode", status.Code(err).String(), "since", time.Since(now).String())
+
+		return resp, err
+	}
+}
+
+// GRPCErrors handles errors coming out of the call chain, converting them to
+// a gRPC status.
+func GRPCErrors(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		var appErr *errs.Error
+		if !errors.As(err, &appErr) {
+			if st, ok := status.FromError(err); ok {
+				otel.AddErrorEvent(ctx, err, st.Code() == codes.Internal || st.Code() == codes.Unknown,
+					attribute.String("rpc.grpc.status_code", st.Code().String()))
+				log.Error(ctx, "handled error during request", "err", err)
+				return nil, err
+			}
+			appErr = errs.Errorf(errs.Internal, "Internal Server Error")
+		}
+
+		recordError(ctx, err, appErr)
+
+		log.Error(ctx, "handled error during request",
+			"err", err,
+			"source_err_file", path.Base(appErr.FileName),
+			"source_err_func", path.Base(appErr.FuncName))
+
+		if appErr.Code == errs.InternalOnlyLog {
+			appErr = errs.Errorf(errs.Internal, "Internal Server Error")
+		}
+
+		var acceptLanguage string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("accept-language"); len(v) > 0 {
+				acceptLanguage = v[0]
+			}
+		}
+
+		return nil, appErr.Localize(errs.ParseLocale(acceptLanguage))
+	}
+}
+
+// GRPCMetrics updates program counters, counting each call against its
+// method as Metrics does for the routes.
+func GRPCMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = metrics.Set(ctx)
+		now := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusInternalServerError
+
+			var appErr *errs.Error
+			if errors.As(err, &appErr) {
+				status = appErr.HTTPStatus()
+			}
+		}
+
+		n := metrics.ObserveRequest(ctx, info.FullMethod, status, tenantOf(ctx), time.Since(now))
+
+		if n%1000 == 0 {
+			metrics.AddGoroutines(ctx)
+		}
+
+		return resp, err
+	}
+}
+
+// GRPCPanics recovers from panics and converts the panic to an error so it is
+// reported in GRPCMetrics and handled in GRPCErrors. A crash report is
+// recorded when crashBus is set, as done by Panics.
+func GRPCPanics(log *logger.Logger, crashBus *crashbus.Core) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				trace := debug.Stack()
+				err = errs.Errorf(errs.InternalOnlyLog, "PANIC [%v] TRACE[%s]", rec, string(trace))
+
+				metrics.AddPanics(ctx)
+
+				if crashBus == nil {
+					return
+				}
+
+				headers := make(map[string]string)
+				if md, ok := metadata.FromIncomingContext(ctx); ok {
+					for _, k := range panicHeaders {
+						if v := md.Get(k); len(v) > 0 {
+							headers[k] = v[0]
+						}
+					}
+				}
+
+				nr := crashbus.NewReport{
+					Method:  "GRPC",
+					Route:   info.FullMethod,
+					Headers: headers,
+					Panic:   fmt.Sprint(rec),
+					Stack:   string(trace),
+				}
+
+				if msg, ok := req.(proto.Message); ok {
+					if data, err := proto.Marshal(msg); err == nil && len(data) > 0 {
+						sum := sha256.Sum256(data)
+						nr.BodyHash = hex.EncodeToString(sum[:])
+						nr.BodySize = int64(len(data))
+					}
+				}
+
+				recordCrash(ctx, log, crashBus, nr)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// =============================================================================
+
+// GRPCAuthenticate validates the JWT sent in the authorization metadata and
+// returns the context carrying the claims, like Authenticate does for the web.
+func GRPCAuthenticate(ctx context.Context, a *auth.Auth) (context.Context, error) {
+	var authStr string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			authStr = v[0]
+		}
+	}
+
+	ctx, err := authenticate(ctx, a, authStr)
+	if err != nil {
+		return ctx, err
+	}
+
+	return ctx, nil
+}
+
+// GRPCAuthorize checks the authenticated user holds one of the roles.
+func GRPCAuthorize(ctx context.Context, ath *auth.Auth, allowedRoles ...role.Role) error {
+	claims := GetClaims(ctx)
+	if claims.Subject == "" {
+		return errs.New(errs.Unauthenticated, errors.New("claims missing from context: authorize called without authenticate?"))
+	}
+
+	if err := ath.Authorize(ctx, claims, allowedRoles...); err != nil {
+		return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
+	}
+
+	return nil
+}
+
+// GRPCAuthorizeResource checks if the authenticated user can perform the
+// action on the resource instance, considering the role policies and the
+// ACLs of the user.
+func GRPCAuthorizeResource(ctx context.Context, aclBus *aclbus.Core, rsc resource.Resource, action actions.Action, resourceID string) error {
+	userID, err := GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, errors.New("user id missing from context: authorize called without authenticate?"))
+	}
+
+	id, err := uuid.Parse(resourceID)
+	if err != nil {
+		return errs.NewFieldErrors("id", err)
+	}
+
+	if err := aclBus.ValidateAccess(ctx, userID, id, action); err != nil {
+		if errors.Is(err, aclbus.ErrAccessDenied) {
+			return errs.New(errs.PermissionDenied, fmt.Errorf("authorization failed: %w", err)).WithReason(errs.ReasonAccessDenied)
+		}
+		return errs.Errorf(errs.Internal, "authorize resource: resource[%s] action[%s]: %s", rsc, action, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// metadataCarrier adapts the gRPC metadata to the otel propagators.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	v := metadata.MD(mc).Get(key)
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+func (mc metadataCarrier) Set(key string, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -23,7 +23,7 @@ func Logger(log *logger.Logger) web.MidFunc {
 				path = fmt.Sprintf("%s?%s", path, r.URL.RawQuery)
 			}
 
-			log.Info(ctx, "request started", "method", r.Method, "path", path, "remoteaddr", r.RemoteAddr)
+			log.Info(ctx, "request started", "method", r.Method, "path", path, "remoteaddr", r.RemoteAddr, "clientip", GetClientIP(ctx))
 
 			resp := next(ctx, r)
 
@@ -37,7 +37,7 @@ func Logger(log *logger.Logger) web.MidFunc {
 				}
 			}
 
-			log.Info(ctx, "request completed", "method", r.Method, "path", path, "remoteaddr", r.RemoteAddr,
+			log.Info(ctx, "request completed", "method", r.Method, "path", path, "remoteaddr", r.RemoteAddr, "clientip", GetClientIP(ctx),
 				"statuscode", statusCode, "since", time.Since(now).String())
 
 			return resp
@@ -3,29 +3,30 @@ package mid
 import (
 	"context"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/metrics"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 )
 
-// Metrics updates program counters.
+// Metrics updates program counters. Each request is counted against its
+// route template, the class of the response status and the tenant.
 func Metrics() web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 			ctx = metrics.Set(ctx)
+			now := time.Now()
 
 			resp := next(ctx, r)
 
-			n := metrics.AddRequests(ctx)
+			n := metrics.ObserveRequest(ctx, r.Pattern, statusOf(resp), tenantOf(ctx), time.Since(now))
 
 			if n%1000 == 0 {
 				metrics.AddGoroutines(ctx)
 			}
 
-			if checkIsError(resp) != nil {
-				metrics.AddErrors(ctx)
-			}
-
 			return resp
 		}
 
@@ -34,3 +35,14 @@ func Metrics() web.MidFunc {
 
 	return m
 }
+
+// tenantOf returns the tenant of the request for the metrics, empty when the
+// request carries none.
+func tenantOf(ctx context.Context) string {
+	v := webcontext.Get(ctx)
+	if v == nil || v.TenantID == uuid.Nil {
+		return ""
+	}
+
+	return v.TenantID.String()
+}
@@ -3,9 +3,11 @@ package mid
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
@@ -22,52 +24,101 @@ func checkIsError(e web.Encoder) error {
 
 // =============================================================================
 
-type ctxKey int
+// values returns the values of the request, storing new ones in the context
+// when no middleware did it yet, e.g. on gRPC calls made without GRPCOtel.
+func values(ctx context.Context) (context.Context, *webcontext.Values) {
+	if v := webcontext.Get(ctx); v != nil {
+		return ctx, v
+	}
 
-const (
-	claimKey ctxKey = iota + 1
-	userIDKey
-	userKey
-	trKey
-	keyTenantID
-	dashboardID
-)
+	v := webcontext.Values{
+		Now: time.Now(),
+	}
+
+	return webcontext.Set(ctx, &v), &v
+}
+
+func setIdentity(ctx context.Context, claims auth.Claims, userID uuid.UUID, tenantID uuid.UUID, dashboardID uuid.UUID) context.Context {
+	ctx, v := values(ctx)
+
+	v.Authenticated = true
+	v.Claims = claims
+	v.UserID = userID
+	v.TenantID = tenantID
+	v.DashboardID = dashboardID
+
+	return ctx
+}
+
+// getIdentity returns the values of the request when it was authenticated.
+func getIdentity(ctx context.Context) (*webcontext.Values, bool) {
+	v := webcontext.Get(ctx)
+	if v == nil || !v.Authenticated {
+		return nil, false
+	}
 
-func setTenantID(ctx context.Context, tenantID uuid.UUID) context.Context {
-	return context.WithValue(ctx, keyTenantID, tenantID)
+	return v, true
 }
 
+// GetTenantID returns the tenant id from the context.
 func GetTenantID(ctx context.Context) (uuid.UUID, error) {
-	v, ok := ctx.Value(keyTenantID).(uuid.UUID)
+	v, ok := getIdentity(ctx)
 	if !ok {
 		return uuid.Nil, errors.New("tenant id not found in context")
 	}
-	return v, nil
-}
 
-func setDashboardID(ctx context.Context, tenantID uuid.UUID) context.Context {
-	return context.WithValue(ctx, keyTenantID, tenantID)
+	return v.TenantID, nil
 }
 
+// GetDashboardID returns the dashboard id from the context.
 func GetDashboardID(ctx context.Context) (uuid.UUID, error) {
-	v, ok := ctx.Value(keyTenantID).(uuid.UUID)
+	v, ok := getIdentity(ctx)
 	if !ok {
-		return uuid.Nil, errors.New("tenant id not found in context")
+		return uuid.Nil, errors.New("dashboard id not found in context")
 	}
-	return v, nil
+
+	return v.DashboardID, nil
 }
 
-func setClaims(ctx context.Context, claims auth.Claims) context.Context {
-	return context.WithValue(ctx, claimKey, claims)
+// GetClientIP returns the IP of the client resolved by the Proxy middleware,
+// or an empty string when the middleware did not run.
+func GetClientIP(ctx context.Context) string {
+	if v := webcontext.Get(ctx); v != nil {
+		return v.ClientIP
+	}
+
+	return ""
+}
+
+// GetHost returns the host the client sent the request to, as resolved by
+// the Proxy middleware, or an empty string when the middleware did not run.
+func GetHost(ctx context.Context) string {
+	if v := webcontext.Get(ctx); v != nil {
+		return v.Host
+	}
+
+	return ""
+}
+
+// GetScheme returns the scheme the client sent the request with, http or
+// https, as resolved by the Proxy middleware, or an empty string when the
+// middleware did not run.
+func GetScheme(ctx context.Context) string {
+	if v := webcontext.Get(ctx); v != nil {
+		return v.Scheme
+	}
+
+	return ""
 }
 
 // GetClaims returns the claims from the context.
 func GetClaims(ctx context.Context) auth.Claims {
-	v, ok := ctx.Value(claimKey).(auth.Claims)
+	v, ok := getIdentity(ctx)
 	if !ok {
 		return auth.Claims{}
 	}
-	return v
+
+	return v.Claims
 }
 
 // GetSubjectID returns the subject id from the claims.
@@ -82,44 +133,46 @@ func GetSubjectID(ctx context.Context) uuid.UUID {
 	return subjectID
 }
 
-func setUserID(ctx context.Context, userID uuid.UUID) context.Context {
-	return context.WithValue(ctx, userIDKey, userID)
-}
-
 // GetUserID returns the user id from the context.
 func GetUserID(ctx context.Context) (uuid.UUID, error) {
-	v, ok := ctx.Value(userIDKey).(uuid.UUID)
+	v, ok := getIdentity(ctx)
 	if !ok {
 		return uuid.UUID{}, errors.New("user id not found in context")
 	}
 
-	return v, nil
+	return v.UserID, nil
 }
 
 func setUser(ctx context.Context, usr userbus.User) context.Context {
-	return context.WithValue(ctx, userKey, usr)
+	ctx, v := values(ctx)
+	v.User = &usr
+
+	return ctx
 }
 
 // GetUser returns the user from the context.
 func GetUser(ctx context.Context) (userbus.User, error) {
-	v, ok := ctx.Value(userKey).(userbus.User)
-	if !ok {
+	v := webcontext.Get(ctx)
+	if v == nil || v.User == nil {
 		return userbus.User{}, errors.New("user not found in context")
 	}
 
-	return v, nil
+	return *v.User, nil
 }
 
 func setTran(ctx context.Context, tx sqldb.CommitRollbacker) context.Context {
-	return context.WithValue(ctx, trKey, tx)
+	ctx, v := values(ctx)
+	v.Tx = tx
+
+	return ctx
 }
 
 // GetTran retrieves the value that can manage a transaction.
 func GetTran(ctx context.Context) (sqldb.CommitRollbacker, error) {
-	v, ok := ctx.Value(trKey).(sqldb.CommitRollbacker)
-	if !ok {
+	v := webcontext.Get(ctx)
+	if v == nil || v.Tx == nil {
 		return nil, errors.New("transaction not found in context")
 	}
 
-	return v, nil
+	return v.Tx, nil
 }
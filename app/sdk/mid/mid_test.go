@@ -0,0 +1,119 @@
+package mid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+)
+
+// TestIdentityKeys checks that the tenant and the dashboard of a request are
+// kept apart: setting the dashboard ID never changes what GetTenantID
+// returns.
+func TestIdentityKeys(t *testing.T) {
+	userID := uuid.New()
+	tenantID := uuid.New()
+	dashboardID := uuid.New()
+
+	// Contexto como o deixado pelo Proxy, com os valores da requisição já
+	// guardados antes da autenticação.
+	withValues := func() context.Context {
+		return webcontext.Set(context.Background(), &webcontext.Values{Now: time.Now(), ClientIP: "10.0.0.1"})
+	}
+
+	tests := []struct {
+		name          string
+		ctx           func() context.Context
+		tenantID      uuid.UUID
+		dashboardID   uuid.UUID
+		wantTenant    uuid.UUID
+		wantDashboard uuid.UUID
+	}{
+		{
+			name:          "tenantAndDashboard",
+			ctx:           context.Background,
+			tenantID:      tenantID,
+			dashboardID:   dashboardID,
+			wantTenant:    tenantID,
+			wantDashboard: dashboardID,
+		},
+		{
+			name:          "dashboardWithoutTenant",
+			ctx:           context.Background,
+			dashboardID:   dashboardID,
+			wantTenant:    uuid.Nil,
+			wantDashboard: dashboardID,
+		},
+		{
+			name:          "tenantWithoutDashboard",
+			ctx:           context.Background,
+			tenantID:      tenantID,
+			wantTenant:    tenantID,
+			wantDashboard: uuid.Nil,
+		},
+		{
+			name:          "afterProxy",
+			ctx:           withValues,
+			tenantID:      tenantID,
+			dashboardID:   dashboardID,
+			wantTenant:    tenantID,
+			wantDashboard: dashboardID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := setIdentity(tt.ctx(), auth.Claims{}, userID, tt.tenantID, tt.dashboardID)
+
+			gotTenant, err := GetTenantID(ctx)
+			if err != nil {
+				t.Fatalf("GetTenantID: %s", err)
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("got tenant %s, want %s", gotTenant, tt.wantTenant)
+			}
+
+			gotDashboard, err := GetDashboardID(ctx)
+			if err != nil {
+				t.Fatalf("GetDashboardID: %s", err)
+			}
+			if gotDashboard != tt.wantDashboard {
+				t.Errorf("got dashboard %s, want %s", gotDashboard, tt.wantDashboard)
+			}
+
+			gotUser, err := GetUserID(ctx)
+			if err != nil {
+				t.Fatalf("GetUserID: %s", err)
+			}
+			if gotUser != userID {
+				t.Errorf("got user %s, want %s", gotUser, userID)
+			}
+		})
+	}
+}
+
+// TestIdentityUnauthenticated checks that the getters report an error
+// instead of zero IDs before the request is authenticated.
+func TestIdentityUnauthenticated(t *testing.T) {
+	tests := []struct {
+		name string
+		get  func(context.Context) (uuid.UUID, error)
+	}{
+		{name: "tenant", get: GetTenantID},
+		{name: "dashboard", get: GetDashboardID},
+		{name: "user", get: GetUserID},
+	}
+
+	ctx := webcontext.Set(context.Background(), &webcontext.Values{Now: time.Now()})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, err := tt.get(ctx); err == nil {
+				t.Errorf("got %s, want an error", id)
+			}
+		})
+	}
+}
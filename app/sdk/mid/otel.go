@@ -3,18 +3,39 @@ package mid
 import (
 	"context"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
 	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// Otel injects the tracing of the request and, as the first middleware of
+// the chain, stores the request values every other middleware fills. Once
+// the request is handled the identity found by the authentication is added
+// to the span of the request.
 func Otel(tracer trace.Tracer) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 			ctx = otel.InjectTracing(ctx, tracer)
 
-			return next(ctx, r)
+			v := webcontext.Values{
+				TraceID: otel.GetTraceID(ctx),
+				Now:     time.Now(),
+			}
+			ctx = webcontext.Set(ctx, &v)
+
+			otel.SetRoute(ctx, r.Pattern)
+
+			resp := next(ctx, r)
+
+			setSpanIdentity(ctx, &v)
+
+			return resp
 		}
 
 		return h
@@ -22,3 +43,34 @@ func Otel(tracer trace.Tracer) web.MidFunc {
 
 	return m
 }
+
+// setSpanIdentity adds the identity of the request to the span. Nothing is
+// added to anonymous requests and the tenant is left out when the token
+// carries none (ADMIN/ANALYST).
+func setSpanIdentity(ctx context.Context, v *webcontext.Values) {
+	if !v.Authenticated {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		otel.UserIDKey.String(v.UserID.String()),
+		otel.UserRoleKey.String(v.Claims.Role),
+		otel.DashboardIDKey.String(v.DashboardID.String()),
+	}
+
+	if v.TenantID != uuid.Nil {
+		attrs = append(attrs, otel.TenantIDKey.String(v.TenantID.String()))
+	}
+
+	otel.SetAttributes(ctx, attrs...)
+}
+
+// recordError adds the error to the span of the request. Only the errors
+// answered with a 5xx mark the span as failed; the others are part of the
+// business, like a validation failure or a missing permission.
+func recordError(ctx context.Context, err error, appErr *errs.Error) {
+	otel.AddErrorEvent(ctx, err, appErr.HTTPStatus() >= http.StatusInternalServerError,
+		attribute.String("error.code", appErr.Code.String()),
+		attribute.String("error.reason", appErr.Reason.String()),
+		attribute.Int("http.status_code", appErr.HTTPStatus()))
+}
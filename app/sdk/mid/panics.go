@@ -2,19 +2,51 @@ package mid
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
 	"net/http"
 	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
 	"github.com/jcpaschoal/spi-exata/app/sdk/metrics"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
 )
 
+// panicHeaders lists the request headers kept in a crash report. Anything
+// that may carry credentials, like Authorization or Cookie, is left out.
+var panicHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Content-Type",
+	"Content-Length",
+	"User-Agent",
+	"Traceparent",
+	"X-Request-Id",
+}
+
+// panicBodyLimit is the most of the body still unread at the time of the
+// panic that is read to complete its hash.
+const panicBodyLimit = 1 << 20
+
 // Panics recovers from panics and converts the panic to an error so it is
-// reported in Metrics and handled in Errors.
-func Panics() web.MidFunc {
+// reported in Metrics and handled in Errors. A crash report with the stack
+// and a sanitized snapshot of the request is recorded when crashBus is set.
+func Panics(log *logger.Logger, crashBus *crashbus.Core) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) (resp web.Encoder) {
+			var body *hashBody
+			if r.Body != nil && crashBus != nil {
+				body = &hashBody{ReadCloser: r.Body, hash: sha256.New()}
+				r.Body = body
+			}
 
 			// Defer a function to recover from a panic and set the err return
 			// variable after the fact.
@@ -24,6 +56,39 @@ func Panics() web.MidFunc {
 					resp = errs.Errorf(errs.InternalOnlyLog, "PANIC [%v] TRACE[%s]", rec, string(trace))
 
 					metrics.AddPanics(ctx)
+
+					if crashBus == nil {
+						return
+					}
+
+					route := r.Pattern
+					if _, p, ok := strings.Cut(route, " "); ok {
+						route = p
+					}
+					if route == "" {
+						route = r.URL.Path
+					}
+
+					headers := make(map[string]string)
+					for _, k := range panicHeaders {
+						if v := r.Header.Get(k); v != "" {
+							headers[k] = v
+						}
+					}
+
+					nr := crashbus.NewReport{
+						Method:  r.Method,
+						Route:   route,
+						Headers: headers,
+						Panic:   fmt.Sprint(rec),
+						Stack:   string(trace),
+					}
+
+					if body != nil {
+						nr.BodyHash, nr.BodySize = body.sum()
+					}
+
+					recordCrash(ctx, log, crashBus, nr)
 				}
 			}()
 
@@ -35,3 +100,48 @@ func Panics() web.MidFunc {
 
 	return m
 }
+
+// recordCrash completes the report with the identity of the request and
+// records it. Failing to record is logged and never changes the response.
+func recordCrash(ctx context.Context, log *logger.Logger, crashBus *crashbus.Core, nr crashbus.NewReport) {
+	if v := webcontext.Get(ctx); v != nil {
+		nr.TraceID = v.TraceID
+		nr.UserID = v.UserID
+		nr.TenantID = v.TenantID
+		nr.IP = v.ClientIP
+	}
+
+	// A requisição pode já ter sido cancelada pelo cliente.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if _, err := crashBus.Create(ctx, nr); err != nil {
+		log.Error(ctx, "panics", "status", "recording crash report", "method", nr.Method, "route", nr.Route, "ERROR", err)
+	}
+}
+
+// hashBody hashes the body as the handler reads it.
+type hashBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+func (b *hashBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.size += int64(n)
+
+	return n, err
+}
+
+// sum reads what the handler left of the body and returns its hash and size.
+func (b *hashBody) sum() (string, int64) {
+	io.Copy(io.Discard, io.LimitReader(b, panicBodyLimit))
+
+	if b.size == 0 {
+		return "", 0
+	}
+
+	return hex.EncodeToString(b.hash.Sum(nil)), b.size
+}
@@ -0,0 +1,193 @@
+package mid
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Proxy resolves the client IP and the host and scheme the client sent the
+// request to.
+// The Forwarded and X-Forwarded-* headers are only honored when the request
+// comes from one of the trusted proxies, otherwise any client could forge
+// them. With no trusted proxies the connection address and Host are used.
+func Proxy(trusted []netip.Prefix) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			ip := remoteIP(r.RemoteAddr)
+			host := r.Host
+
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+
+			if isTrusted(trusted, ip) {
+				hops := forwarded(r.Header)
+
+				// O cliente é o primeiro endereço, da direita para a esquerda,
+				// que não pertence a um dos proxies confiáveis. O host e o
+				// esquema vêm dos mesmos saltos: o que está à esquerda do
+				// cliente foi escrito por ele e não vale nada.
+				for i := len(hops) - 1; i >= 0; i-- {
+					hop := hops[i]
+
+					if hop.host != "" {
+						host = hop.host
+					}
+
+					if hop.proto == "http" || hop.proto == "https" {
+						scheme = hop.proto
+					}
+
+					if hop.ip == "" {
+						continue
+					}
+
+					ip = hop.ip
+					if !isTrusted(trusted, ip) {
+						break
+					}
+				}
+			}
+
+			ctx, v := values(ctx)
+			v.ClientIP = ip
+			v.Host = host
+			v.Scheme = scheme
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// hop is what one proxy of the chain reports about the request it received:
+// the address of the peer and the host and scheme it was sent to.
+type hop struct {
+	ip    string
+	host  string
+	proto string
+}
+
+// forwarded returns the hops reported by the proxies, from the farthest to
+// the nearest. The standard Forwarded header takes precedence over the
+// X-Forwarded-* headers. The X-Forwarded-Host and X-Forwarded-Proto values
+// are not tied to an address, so only the rightmost ones, written by the
+// nearest proxy, are kept, on the last hop.
+func forwarded(header http.Header) []hop {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []hop
+
+		for _, elem := range strings.Split(strings.Join(values, ","), ",") {
+			var h hop
+
+			for _, pair := range strings.Split(elem, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+
+				value = strings.Trim(value, `"`)
+
+				switch strings.ToLower(key) {
+				case "for":
+					h.ip = remoteIP(value)
+				case "host":
+					h.host = value
+				case "proto":
+					h.proto = strings.ToLower(value)
+				}
+			}
+
+			hops = append(hops, h)
+		}
+
+		return hops
+	}
+
+	var hops []hop
+	for _, v := range strings.Split(strings.Join(header.Values("X-Forwarded-For"), ","), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			hops = append(hops, hop{ip: remoteIP(v)})
+		}
+	}
+
+	if len(hops) == 0 {
+		hops = append(hops, hop{})
+	}
+
+	last := &hops[len(hops)-1]
+	last.host = lastValue(header.Values("X-Forwarded-Host"))
+	last.proto = strings.ToLower(lastValue(header.Values("X-Forwarded-Proto")))
+
+	return hops
+}
+
+// lastValue returns the rightmost value of a comma separated header.
+func lastValue(values []string) string {
+	list := strings.Split(strings.Join(values, ","), ",")
+	return strings.TrimSpace(list[len(list)-1])
+}
+
+// remoteIP removes the port and the IPv6 brackets from an address.
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return strings.Trim(addr, "[]")
+}
+
+func isTrusted(trusted []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseTrustedProxies parses the CIDRs of the trusted proxies. A single
+// address is accepted as a network of one host.
+func ParseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
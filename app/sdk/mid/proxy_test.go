@@ -0,0 +1,96 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+func TestProxy(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("parse: %s", err)
+	}
+
+	type want struct {
+		ip     string
+		host   string
+		scheme string
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		header http.Header
+		want   want
+	}{
+		{
+			name:   "untrusted",
+			remote: "203.0.113.7:4000",
+			header: http.Header{
+				"X-Forwarded-For":  {"198.51.100.1"},
+				"X-Forwarded-Host": {"evil.example.com"},
+			},
+			want: want{ip: "203.0.113.7", host: "acme.example.com", scheme: "http"},
+		},
+		{
+			name:   "xForwarded",
+			remote: "10.0.0.3:4000",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.7"},
+				"X-Forwarded-Host":  {"main.example.com"},
+				"X-Forwarded-Proto": {"https"},
+			},
+			want: want{ip: "203.0.113.7", host: "main.example.com", scheme: "https"},
+		},
+		{
+			name:   "xForwardedSpoofed",
+			remote: "10.0.0.3:4000",
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.1, 203.0.113.7"},
+				"X-Forwarded-Host":  {"evil.example.com, main.example.com"},
+				"X-Forwarded-Proto": {"http, https"},
+			},
+			want: want{ip: "203.0.113.7", host: "main.example.com", scheme: "https"},
+		},
+		{
+			name:   "forwardedSpoofed",
+			remote: "10.0.0.3:4000",
+			header: http.Header{
+				"Forwarded": {`for=198.51.100.1;host=evil.example.com;proto=http, for=203.0.113.7;host=main.example.com;proto=https`},
+			},
+			want: want{ip: "203.0.113.7", host: "main.example.com", scheme: "https"},
+		},
+		{
+			name:   "forwardedChain",
+			remote: "10.0.0.3:4000",
+			header: http.Header{
+				"Forwarded": {`for=198.51.100.1;host=evil.example.com`, `for=203.0.113.7;host=main.example.com;proto=https`, `for=10.0.0.2;host=internal`},
+			},
+			want: want{ip: "203.0.113.7", host: "main.example.com", scheme: "https"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got want
+			handler := func(ctx context.Context, r *http.Request) web.Encoder {
+				got = want{ip: GetClientIP(ctx), host: GetHost(ctx), scheme: GetScheme(ctx)}
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://acme.example.com/v1/users/me", nil)
+			r.RemoteAddr = tt.remote
+			r.Header = tt.header
+
+			Proxy(trusted)(handler)(context.Background(), r)
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// RateLimit rejects requests over the limit with 429 and a Retry-After
+// header. Authenticated requests are keyed by user, so it must run after
+// Authenticate on those routes. Anonymous requests are keyed by the tenant of
+// the host, as resolved by the Proxy middleware, or by client IP when the
+// host is not a domain of a tenant or tenantBus is nil. A nil limiter
+// disables the check.
+func RateLimit(limiter ratelimit.Limiter, tenantBus *tenantbus.Core) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			if limiter == nil {
+				return next(ctx, r)
+			}
+
+			res, err := limiter.Allow(ctx, rateLimitKey(ctx, r, tenantBus))
+			if err != nil {
+				// The limiter backend being down must not take the API down.
+				return next(ctx, r)
+			}
+
+			w := web.GetWriter(ctx)
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				return errs.New(errs.TooManyRequests, errors.New("rate limit exceeded")).WithReason(errs.ReasonRateLimited)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+func rateLimitKey(ctx context.Context, r *http.Request, tenantBus *tenantbus.Core) string {
+	if userID, err := GetUserID(ctx); err == nil && userID != uuid.Nil {
+		return "user:" + userID.String()
+	}
+
+	// Um domínio desconhecido, ou o banco fora do ar, cai para o IP: o
+	// limite não pode derrubar a requisição.
+	if domain := strings.ToLower(auth.ExtractDomain(GetHost(ctx))); tenantBus != nil && domain != "" {
+		if td, err := tenantBus.ResolveDomain(ctx, domain); err == nil {
+			return "tenant:" + td.TenantID.String()
+		}
+	}
+
+	ip := GetClientIP(ctx)
+	if ip == "" {
+		ip = remoteIP(r.RemoteAddr)
+	}
+
+	return "ip:" + ip
+}
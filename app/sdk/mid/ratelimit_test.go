@@ -0,0 +1,65 @@
+package mid
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores/tenantmemory"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	tenantID := uuid.New()
+	userID := uuid.New()
+
+	store := tenantmemory.NewStore()
+	store.AddDashboard(tenantID, uuid.New(), "acme.example.com")
+	tenantBus := tenantbus.NewCore(log, delegate.New(log), store)
+
+	tests := []struct {
+		name      string
+		host      string
+		userID    uuid.UUID
+		tenantBus *tenantbus.Core
+		want      string
+	}{
+		{name: "user", host: "acme.example.com", userID: userID, tenantBus: tenantBus, want: "user:" + userID.String()},
+		{name: "tenant", host: "acme.example.com", tenantBus: tenantBus, want: "tenant:" + tenantID.String()},
+		{name: "tenantWithPort", host: "ACME.example.com:8080", tenantBus: tenantBus, want: "tenant:" + tenantID.String()},
+		{name: "unknownHost", host: "unknown.example.com", tenantBus: tenantBus, want: "ip:203.0.113.7"},
+		{name: "noTenantBus", host: "acme.example.com", want: "ip:203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := func(ctx context.Context, r *http.Request) web.Encoder {
+				if tt.userID != uuid.Nil {
+					ctx = setIdentity(ctx, auth.Claims{}, tt.userID, uuid.Nil, uuid.Nil)
+				}
+				got = rateLimitKey(ctx, r, tt.tenantBus)
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+			r.Host = tt.host
+			r.RemoteAddr = "203.0.113.7:4000"
+
+			Proxy(nil)(handler)(context.Background(), r)
+
+			if got != tt.want {
+				t.Errorf("got key %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
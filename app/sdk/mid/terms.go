@@ -0,0 +1,74 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/app/sdk/webcontext"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Terms requires the authenticated users to accept the latest terms of
+// service. It only flags the request: Authenticate refuses it with
+// LegalReasons while the user has not accepted them, so the routes without
+// authentication are never affected. The routes used to read and accept the
+// terms opt out with NoTerms.
+func Terms(termsBus *termsbus.Core) web.MidFunc {
+	check := func(ctx context.Context, userID uuid.UUID) error {
+		_, err := termsBus.Check(ctx, userID)
+		return err
+	}
+
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			ctx, v := values(ctx)
+			v.TermsCheck = check
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// NoTerms lets the route be used before the latest terms of service are
+// accepted. It must come before Authenticate.
+func NoTerms() web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			if v := webcontext.Get(ctx); v != nil {
+				v.NoTerms = true
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// checkTerms refuses the authenticated user that has not accepted the latest
+// terms of service, when the Terms middleware asked for it.
+func checkTerms(ctx context.Context) *errs.Error {
+	v := webcontext.Get(ctx)
+	if v == nil || v.TermsCheck == nil || v.NoTerms {
+		return nil
+	}
+
+	if err := v.TermsCheck(ctx, v.UserID); err != nil {
+		if errors.Is(err, termsbus.ErrNotAccepted) {
+			return errs.New(errs.LegalReasons, err).WithReason(errs.ReasonTermsNotAccepted)
+		}
+		return errs.Errorf(errs.Internal, "terms: check: userID[%s]: %s", v.UserID, err)
+	}
+
+	return nil
+}
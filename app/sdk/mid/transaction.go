@@ -65,3 +65,20 @@ func BeginCommitRollback(log *logger.Logger, bgn sqldb.Beginner) web.MidFunc {
 
 	return m
 }
+
+// WithTran adapts an app method to run with the app value newWithTx builds
+// from the transaction BeginCommitRollback stored in the context, so every
+// bus call made by the handler joins the same transaction. The route must
+// also use the BeginCommitRollback middleware.
+func WithTran[T any](newWithTx func(ctx context.Context) (T, error), handler func(api T, ctx context.Context, r *http.Request) web.Encoder) web.HandlerFunc {
+	h := func(ctx context.Context, r *http.Request) web.Encoder {
+		api, err := newWithTx(ctx)
+		if err != nil {
+			return errs.Errorf(errs.Internal, "newWithTx: %s", err)
+		}
+
+		return handler(api, ctx, r)
+	}
+
+	return h
+}
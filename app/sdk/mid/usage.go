@@ -0,0 +1,42 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Usage counts the API call against the tenant of the authenticated user.
+// It wraps every route and reads the identity once the handler returns, so
+// only the calls accepted by Authenticate are metered. Calls made without a
+// tenant in the token (ADMIN/ANALYST) are not metered.
+func Usage(usageBus *usagebus.Core) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			// A identidade é preenchida nos valores da requisição pelo
+			// Authenticate da rota.
+			ctx, v := values(ctx)
+
+			resp := next(ctx, r)
+
+			if checkIsError(resp) != nil {
+				return resp
+			}
+
+			if !v.Authenticated || v.TenantID == uuid.Nil {
+				return resp
+			}
+
+			usageBus.RecordAPICall(v.TenantID, v.UserID)
+
+			return resp
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,94 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/app/sdk/errs"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+func TestUsage(t *testing.T) {
+	userID := uuid.New()
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		authen   bool
+		err      *errs.Error
+		want     int
+	}{
+		{name: "tenantUser", tenantID: tenantID, authen: true, want: 1},
+		{name: "public", tenantID: tenantID},
+		{name: "noTenant", authen: true},
+		{name: "failed", tenantID: tenantID, authen: true, err: errs.Errorf(errs.PermissionDenied, "denied")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storer{}
+			usageBus := usagebus.NewCore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), &store)
+
+			// O handler faz o papel do Authenticate da rota, que roda por
+			// dentro do middleware global.
+			handler := func(ctx context.Context, r *http.Request) web.Encoder {
+				if tt.authen {
+					ctx = setIdentity(ctx, auth.Claims{}, userID, tt.tenantID, uuid.Nil)
+				}
+				if tt.err != nil {
+					return tt.err
+				}
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/users/me", nil)
+			Usage(usageBus)(handler)(context.Background(), r)
+
+			if err := usageBus.Flush(context.Background()); err != nil {
+				t.Fatalf("flush: %s", err)
+			}
+
+			var got int
+			for _, d := range store.deltas {
+				if d.TenantID != tt.tenantID {
+					t.Errorf("got tenant %s, want %s", d.TenantID, tt.tenantID)
+				}
+				got += d.APICalls
+			}
+
+			if got != tt.want {
+				t.Errorf("got %d calls, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// storer keeps the deltas flushed by the usagebus.
+type storer struct {
+	deltas []usagebus.Delta
+}
+
+func (s *storer) NewWithTx(tx sqldb.CommitRollbacker) (usagebus.Storer, error) {
+	return s, nil
+}
+
+func (s *storer) Apply(ctx context.Context, delta usagebus.Delta) error {
+	s.deltas = append(s.deltas, delta)
+	return nil
+}
+
+func (s *storer) QueryByTenant(ctx context.Context, tenantID uuid.UUID, filter usagebus.QueryFilter) ([]usagebus.Usage, error) {
+	return nil, errors.New("not implemented")
+}
@@ -1,25 +1,68 @@
 package mux
 
 import (
+	"context"
+	"io/fs"
 	"net/http"
+	"net/netip"
+	"time"
 
 	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
 	"github.com/jcpaschoal/spi-exata/app/sdk/mid"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/health"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
 	"github.com/jmoiron/sqlx"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 // Options represent optional parameters.
 type Options struct {
-	corsOrigin []string
+	security     *Security
+	maxBodyBytes int64
+	strictDecode bool
+	static       fs.FS
+	staticDir    string
+	staticPath   string
 }
 
-// WithCORS provides configuration options for CORS.
-func WithCORS(origins []string) func(opts *Options) {
+// WithSecurity sets the CORS policy and the security headers, usually from
+// the SecurityProfile of the environment.
+func WithSecurity(sec Security) func(opts *Options) {
 	return func(opts *Options) {
-		opts.corsOrigin = origins
+		opts.security = &sec
+	}
+}
+
+// WithFileServer serves the dashboard SPA found in dir inside the static file
+// system under the path prefix.
+func WithFileServer(static fs.FS, dir string, path string) func(opts *Options) {
+	return func(opts *Options) {
+		opts.static = static
+		opts.staticDir = dir
+		opts.staticPath = path
+	}
+}
+
+// WithDecodeLimits sets the largest request body accepted by every route and
+// whether unknown JSON fields are rejected.
+func WithDecodeLimits(maxBodyBytes int64, strict bool) func(opts *Options) {
+	return func(opts *Options) {
+		opts.maxBodyBytes = maxBodyBytes
+		opts.strictDecode = strict
 	}
 }
 
@@ -32,11 +75,88 @@ type AuthConfig struct {
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Build      string
-	Log        *logger.Logger
-	DB         *sqlx.DB
-	Tracer     trace.Tracer
-	AuthConfig AuthConfig
+	Build       string
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	ReplicaDB   *sqlx.DB
+	Tracer      trace.Tracer
+	AuthConfig  AuthConfig
+	RateLimiter ratelimit.Limiter
+	Hasher      *passhash.Hasher
+	AuditBus    *auditbus.Core
+
+	// CrashBus records the panics recovered by the middleware. No report
+	// is kept when it is nil.
+	CrashBus *crashbus.Core
+
+	// TrustedProxies lists the networks of the reverse proxies allowed to
+	// report the client IP and host through the forwarding headers.
+	TrustedProxies []netip.Prefix
+
+	// LoginDomainField lets the login payload choose the domain. Meant for
+	// local development only.
+	LoginDomainField bool
+
+	// OutboxPublisher delivers the domain events every OutboxInterval. The
+	// events are kept in the outbox when it is nil.
+	OutboxPublisher outboxbus.Publisher
+	OutboxInterval  time.Duration
+
+	// OutboxCrypto seals the secrets of the events, such as the confirmation
+	// tokens of the emails. The secrets are dropped when it is nil.
+	OutboxCrypto *crypto.Box
+
+	// Worker runs the background jobs of the domains.
+	Worker *worker.Worker
+
+	// Redis shares the user cache between the instances. The cache is kept
+	// in the memory of each instance when it is nil.
+	Redis *redis.Client
+
+	// Health collects the dependency checks reported by the readiness
+	// probe. It fails as soon as the shutdown starts draining the instance.
+	Health *health.Registry
+
+	// GRPC receives the gRPC services of the domains. They are not exposed
+	// when it is nil.
+	GRPC *grpc.Server
+
+	// Crypto seals the credentials of the datasources and the bind password
+	// of the LDAP directories. The datasource and directory routes are not
+	// exposed, and logins only check local passwords, when it is nil.
+	// Datasource bounds the queries the widgets run against them.
+	Crypto     *crypto.Box
+	Datasource datasourcebus.Config
+
+	// Reports sets the retention of the reports and signs their download
+	// links. They are rendered from the datasources, so they share the
+	// Crypto switch.
+	Reports reportbus.Config
+
+	// TermsBus keeps the terms of service. While RequireTerms is set the
+	// authenticated users must accept the latest version before using any
+	// other route.
+	TermsBus     *termsbus.Core
+	RequireTerms bool
+
+	// UsageBus meters the authenticated API calls of every route against
+	// the tenant of the user. No call is metered when it is nil.
+	UsageBus *usagebus.Core
+}
+
+// GRPCServer constructs a gRPC server with the interceptors mirroring the web
+// middleware. The services are registered by the RouteAdder through
+// Config.GRPC.
+func GRPCServer(cfg Config) *grpc.Server {
+	return grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			mid.GRPCOtel(cfg.Tracer),
+			mid.GRPCLogger(cfg.Log),
+			mid.GRPCErrors(cfg.Log),
+			mid.GRPCMetrics(),
+			mid.GRPCPanics(cfg.Log, cfg.CrashBus),
+		),
+	)
 }
 
 // RouteAdder defines behavior that sets the routes to bind for an instance
@@ -47,14 +167,28 @@ type RouteAdder interface {
 
 // WebAPI constructs a http.Handler with all application routes bound.
 func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) http.Handler {
+	var terms web.MidFunc
+	if cfg.RequireTerms {
+		terms = mid.Terms(cfg.TermsBus)
+	}
+
+	var usage web.MidFunc
+	if cfg.UsageBus != nil {
+		usage = mid.Usage(cfg.UsageBus)
+	}
+
 	app := web.NewApp(
 		cfg.Log.Info,
 		cfg.Tracer,
 		mid.Otel(cfg.Tracer),
+		mid.Proxy(cfg.TrustedProxies),
 		mid.Logger(cfg.Log),
 		mid.Errors(cfg.Log),
+		mid.Audit(cfg.Log, cfg.AuditBus),
 		mid.Metrics(),
-		mid.Panics(),
+		usage,
+		mid.Panics(cfg.Log, cfg.CrashBus),
+		terms,
 	)
 
 	var opts Options
@@ -62,11 +196,22 @@ func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) h
 		option(&opts)
 	}
 
-	if len(opts.corsOrigin) > 0 {
-		app.EnableCORS(opts.corsOrigin)
+	if opts.security != nil {
+		app.SetCORS(opts.security.CORS)
+		app.SetSecurityHeaders(opts.security.Headers)
+	}
+
+	if opts.maxBodyBytes > 0 {
+		app.SetDecodeLimits(opts.maxBodyBytes, opts.strictDecode)
 	}
 
 	routeAdder.Add(app, cfg)
 
+	if opts.static != nil {
+		if err := app.FileServerSPA(opts.static, opts.staticDir, opts.staticPath); err != nil {
+			cfg.Log.Error(context.Background(), "startup", "status", "static file server not started", "ERROR", err)
+		}
+	}
+
 	return app
 }
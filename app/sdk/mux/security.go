@@ -0,0 +1,66 @@
+package mux
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+// Set of environments with their own CORS and security headers profile.
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Security represents the CORS policy and the security headers of the API.
+type Security struct {
+	CORS    web.CORS
+	Headers web.SecurityHeaders
+}
+
+// SecurityProfile returns the profile of the environment for the allowed
+// origins.
+//
+//   - development: also allows the SPA dev servers on localhost, with short
+//     preflight caching and no HSTS.
+//   - staging: only the origins, HSTS limited to the host for a day.
+//   - production: only the origins, which can't be "*", and the full HSTS.
+func SecurityProfile(env string, origins []string) (Security, error) {
+	cors := web.DefaultCORS(origins)
+	headers := web.DefaultSecurityHeaders()
+
+	switch env {
+	case EnvDevelopment:
+		cors.Origins = append(slices.Clone(origins), "http://localhost:*", "http://127.0.0.1:*")
+		cors.MaxAge = 10 * time.Minute
+
+		headers.HSTS = 0
+
+		// O servidor de desenvolvimento do SPA usa websocket para o reload.
+		headers.ContentSecurityPolicy = strings.Replace(headers.ContentSecurityPolicy,
+			"connect-src 'self'", "connect-src 'self' ws://localhost:* http://localhost:*", 1)
+
+	case EnvStaging:
+		cors.MaxAge = 2 * time.Hour
+
+		headers.HSTS = 24 * time.Hour
+		headers.HSTSSubdomains = false
+		headers.HSTSPreload = false
+
+	case EnvProduction:
+		if slices.Contains(origins, "*") {
+			return Security{}, fmt.Errorf("the allowed origins can't be \"*\" in %s", env)
+		}
+
+		cors.MaxAge = 2 * time.Hour
+
+	default:
+		return Security{}, fmt.Errorf("unknown environment %q: expected %s, %s or %s", env, EnvDevelopment, EnvStaging, EnvProduction)
+	}
+
+	return Security{CORS: cors, Headers: headers}, nil
+}
@@ -7,10 +7,11 @@ import (
 )
 
 type Result[T any] struct {
-	Items       []T `json:"items"`
-	Total       int `json:"total"`
-	Page        int `json:"page"`
-	RowsPerPage int `json:"rowsPerPage"`
+	Items       []T    `json:"items"`
+	Total       int    `json:"total"`
+	Page        int    `json:"page"`
+	RowsPerPage int    `json:"rowsPerPage"`
+	NextCursor  string `json:"next_cursor,omitempty"`
 }
 
 // NewResult constructs a result value to return query results.
@@ -23,6 +24,13 @@ func NewResult[T any](items []T, total int, page page.Page) Result[T] {
 	}
 }
 
+// WithNextCursor sets the cursor of the next page, for queries that support
+// keyset paging. An empty cursor means there are no more rows.
+func (r Result[T]) WithNextCursor(cursor string) Result[T] {
+	r.NextCursor = cursor
+	return r
+}
+
 // Encode implements the encoder interface.
 func (r Result[T]) Encode() ([]byte, string, error) {
 	data, err := json.Marshal(r)
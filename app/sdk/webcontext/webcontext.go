@@ -0,0 +1,85 @@
+// Package webcontext keeps every value of a request in a single struct, set
+// once in the context when the request starts. Middleware fills the struct as
+// the request goes down the chain instead of wrapping the context again for
+// each value.
+package webcontext
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/app/sdk/auth"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+)
+
+type ctxKey int
+
+const valuesKey ctxKey = 1
+
+// Values represent the state of a request.
+type Values struct {
+	TraceID string
+	Now     time.Time
+
+	// Origem do cliente, resolvida pelo middleware Proxy.
+	ClientIP string
+	Host     string
+	Scheme   string
+
+	// Identidade do token, preenchida pela autenticação. TenantID é
+	// uuid.Nil quando o token não carrega tenant (ADMIN/ANALYST).
+	Authenticated bool
+	Claims        auth.Claims
+	UserID        uuid.UUID
+	TenantID      uuid.UUID
+	DashboardID   uuid.UUID
+
+	User *userbus.User
+	Tx   sqldb.CommitRollbacker
+
+	// Flags avaliadas para a identidade, preenchidas pelo middleware
+	// Features.
+	Features featurebus.Set
+
+	// NoAudit tira a requisição da trilha de auditoria.
+	NoAudit bool
+
+	// Verificação do aceite dos termos de uso, ligada pelo middleware Terms
+	// e feita na autenticação. NoTerms libera as rotas usadas para ler e
+	// aceitar os termos.
+	TermsCheck func(ctx context.Context, userID uuid.UUID) error
+	NoTerms    bool
+}
+
+// Set stores the values in the context. It must be called once per request,
+// by the first middleware of the chain.
+func Set(ctx context.Context, v *Values) context.Context {
+	return context.WithValue(ctx, valuesKey, v)
+}
+
+// Get returns the values of the request, or nil when they were never set.
+func Get(ctx context.Context) *Values {
+	v, _ := ctx.Value(valuesKey).(*Values)
+	return v
+}
+
+// LogAttrs returns the identity of the request to add to its logs. It is
+// meant for logger.AddContext and returns nothing for anonymous requests.
+func LogAttrs(ctx context.Context) []slog.Attr {
+	v := Get(ctx)
+	if v == nil || !v.Authenticated {
+		return nil
+	}
+
+	attrs := []slog.Attr{slog.String("user_id", v.UserID.String())}
+
+	if v.TenantID != uuid.Nil {
+		attrs = append(attrs, slog.String("tenant_id", v.TenantID.String()))
+	}
+
+	return attrs
+}
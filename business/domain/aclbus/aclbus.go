@@ -0,0 +1,733 @@
+// Package aclbus provides business access to the access control domain. It
+// combines the role wide policies (role_policy) with the instance level grants
+// (acl) to decide what a user can do on a resource.
+package aclbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("acl not found")
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrUniqueACL        = errors.New("acl already exists for user and resource")
+	ErrNoActions        = errors.New("at least one action is required")
+	ErrAccessDenied     = errors.New("access denied")
+	ErrInvalidExpiry    = errors.New("expiration must be in the future")
+	ErrAdminPolicy      = errors.New("admin policy cannot be changed")
+
+	// ErrTenantAdminPolicy is returned when a policy is given to TENANT_ADMIN.
+	// The policies apply to the resources of every tenant, so a role limited
+	// to its own tenant cannot have any.
+	ErrTenantAdminPolicy = errors.New("tenant admin cannot have role policies")
+)
+
+// Storer defines the behavior required by the aclbus to interact with the database.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, acl ACL) error
+	Update(ctx context.Context, acl ACL) error
+	Delete(ctx context.Context, acl ACL) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]ACL, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, aclID uuid.UUID) (ACL, error)
+	QueryAll(ctx context.Context) ([]ACL, error)
+	QueryByUser(ctx context.Context, userID uuid.UUID) ([]ACL, error)
+	QueryRolePolicy(ctx context.Context, userID uuid.UUID) (RolePolicy, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+	QueryPolicies(ctx context.Context) ([]Policy, error)
+	UpsertPolicy(ctx context.Context, p Policy) error
+	CreateHistory(ctx context.Context, h History) error
+	QueryHistory(ctx context.Context, filter HistoryFilter, page page.Page) ([]History, error)
+	CountHistory(ctx context.Context, filter HistoryFilter) (int, error)
+	SyncUserRole(ctx context.Context, userID uuid.UUID) error
+	QueryResourceType(ctx context.Context, resourceID uuid.UUID) (resource.Resource, error)
+	QueryLineage(ctx context.Context, resourceID uuid.UUID) ([]uuid.UUID, error)
+	QueryAccess(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) (AccessInfo, error)
+	QueryAccessBatch(ctx context.Context, userID uuid.UUID, resourceIDs []uuid.UUID) (map[uuid.UUID]AccessInfo, error)
+	QueryTypeAccess(ctx context.Context, userID uuid.UUID, resourceType resource.Resource) (AccessInfo, error)
+	QueryTenantAccess(ctx context.Context, tenantID uuid.UUID) ([]UserAccess, error)
+}
+
+// Core manages the set of APIs for access control.
+type Core struct {
+	log       *logger.Logger
+	storer    Storer
+	outboxBus *outboxbus.Core
+}
+
+// NewCore constructs a core for acl api access. The grants of a user are
+// revoked when the user is disabled or deleted.
+func NewCore(log *logger.Logger, delegate *delegate.Delegate, storer Storer, outboxBus *outboxbus.Core) *Core {
+	c := Core{
+		log:       log,
+		storer:    storer,
+		outboxBus: outboxBus,
+	}
+
+	delegate.Register(userbus.DomainName, userbus.ActionDisabled, c.revokeUser)
+	delegate.Register(userbus.DomainName, userbus.ActionDeleted, c.revokeUser)
+
+	return &c
+}
+
+// NewWithTx constructs a new Core value replacing the Storer
+// value with a Storer value that is currently inside a transaction.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newWithTx: %w", err)
+	}
+
+	outboxBus, err := c.outboxBus.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newWithTx: %w", err)
+	}
+
+	// Built directly so the delegate functions are not registered again.
+	nc := Core{
+		log:       c.log,
+		storer:    storer,
+		outboxBus: outboxBus,
+	}
+
+	return &nc, nil
+}
+
+// Create grants a set of actions to a user on a resource instance. The change
+// is recorded in the audit trail on behalf of the actor, so callers should run
+// it inside a transaction.
+func (c *Core) Create(ctx context.Context, actorID uuid.UUID, na NewACL) (ACL, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.create")
+	defer span.End()
+
+	if len(na.Actions) == 0 {
+		return ACL{}, ErrNoActions
+	}
+
+	now := time.Now()
+
+	if na.ExpiresAt != nil && !na.ExpiresAt.After(now) {
+		return ACL{}, ErrInvalidExpiry
+	}
+
+	rt, err := c.storer.QueryResourceType(ctx, na.ResourceID)
+	if err != nil {
+		return ACL{}, fmt.Errorf("queryResourceType: resourceID[%s]: %w", na.ResourceID, err)
+	}
+
+	acl := ACL{
+		ID:           uuid.New(),
+		UserID:       na.UserID,
+		ResourceID:   na.ResourceID,
+		ResourceType: rt,
+		Actions:      compact(na.Actions),
+		ExpiresAt:    na.ExpiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := c.storer.Create(ctx, acl); err != nil {
+		return ACL{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.recordHistory(ctx, actorID, actions.Create, nil, acl); err != nil {
+		return ACL{}, err
+	}
+
+	if rt.Equal(resource.Dashboard) {
+		if err := c.addDashboardShared(ctx, actorID, acl); err != nil {
+			return ACL{}, err
+		}
+	}
+
+	return acl, nil
+}
+
+// Update replaces the set of actions granted by the ACL.
+func (c *Core) Update(ctx context.Context, actorID uuid.UUID, acl ACL, ua UpdateACL) (ACL, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.update")
+	defer span.End()
+
+	oldActions := acl.Actions
+
+	if ua.Actions != nil {
+		if len(ua.Actions) == 0 {
+			return ACL{}, ErrNoActions
+		}
+		acl.Actions = compact(ua.Actions)
+	}
+
+	acl.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, acl); err != nil {
+		return ACL{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.recordHistory(ctx, actorID, actions.Update, oldActions, acl); err != nil {
+		return ACL{}, err
+	}
+
+	return acl, nil
+}
+
+// Delete revokes the ACL.
+func (c *Core) Delete(ctx context.Context, actorID uuid.UUID, acl ACL) error {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, acl); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	// The revoked actions are the old snapshot, nothing remains granted.
+	revoked := acl
+	revoked.Actions = nil
+
+	if err := c.recordHistory(ctx, actorID, actions.Delete, acl.Actions, revoked); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing ACLs.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]ACL, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.query")
+	defer span.End()
+
+	acls, err := c.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return acls, nil
+}
+
+// Count returns the total number of ACLs.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the ACL by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, aclID uuid.UUID) (ACL, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryByID")
+	defer span.End()
+
+	acl, err := c.storer.QueryByID(ctx, aclID)
+	if err != nil {
+		return ACL{}, fmt.Errorf("query: aclID[%s]: %w", aclID, err)
+	}
+
+	return acl, nil
+}
+
+// GetAllPermissions returns every ACL in the system. It is meant to warm up
+// caches and should not be used on request paths.
+func (c *Core) GetAllPermissions(ctx context.Context) ([]ACL, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.getAllPermissions")
+	defer span.End()
+
+	acls, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryAll: %w", err)
+	}
+
+	return acls, nil
+}
+
+// QueryRoot returns the resource at the top of the hierarchy of the
+// resource, the dashboard the resource belongs to.
+func (c *Core) QueryRoot(ctx context.Context, resourceID uuid.UUID) (uuid.UUID, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryRoot")
+	defer span.End()
+
+	lineage, err := c.storer.QueryLineage(ctx, resourceID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("queryLineage: resourceID[%s]: %w", resourceID, err)
+	}
+
+	if len(lineage) == 0 {
+		return resourceID, nil
+	}
+
+	return lineage[len(lineage)-1], nil
+}
+
+// QueryTenantAccess returns the access matrix of the tenant: every member,
+// ordered by name, with the access to each dashboard of the tenant. A tenant
+// with no members, or that does not exist, has an empty matrix.
+func (c *Core) QueryTenantAccess(ctx context.Context, tenantID uuid.UUID) ([]UserAccess, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryTenantAccess")
+	defer span.End()
+
+	access, err := c.storer.QueryTenantAccess(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("queryTenantAccess: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return access, nil
+}
+
+// QueryPermissions returns the effective permissions of the user. ADMINs are
+// reported with every action on every resource type since they bypass the
+// policies.
+func (c *Core) QueryPermissions(ctx context.Context, userID uuid.UUID) (Permissions, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryPermissions")
+	defer span.End()
+
+	rp, err := c.storer.QueryRolePolicy(ctx, userID)
+	if err != nil {
+		return Permissions{}, fmt.Errorf("queryRolePolicy: userID[%s]: %w", userID, err)
+	}
+
+	acls, err := c.storer.QueryByUser(ctx, userID)
+	if err != nil {
+		return Permissions{}, fmt.Errorf("queryByUser: userID[%s]: %w", userID, err)
+	}
+
+	// Expired grants are kept until the sweeper runs but are not effective.
+	now := time.Now()
+	acls = slices.DeleteFunc(slices.Clone(acls), func(acl ACL) bool {
+		return acl.Expired(now)
+	})
+
+	types := rp.Policies
+	if rp.Role.Equal(role.Admin) {
+		types = make([]TypePolicy, len(rp.Policies))
+		for i, tp := range rp.Policies {
+			types[i] = TypePolicy{
+				ResourceType: tp.ResourceType,
+				Actions:      allActions,
+			}
+		}
+	}
+
+	perms := Permissions{
+		UserID:    userID,
+		Role:      rp.Role,
+		Types:     types,
+		Resources: acls,
+	}
+
+	return perms, nil
+}
+
+// SyncUserRole must be called whenever the role of a user changes so the
+// permissions derived from the previous role are no longer served.
+func (c *Core) SyncUserRole(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.syncUserRole")
+	defer span.End()
+
+	if err := c.storer.SyncUserRole(ctx, userID); err != nil {
+		return fmt.Errorf("syncUserRole: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// QueryHistory retrieves the audit trail of ACL changes, newest first.
+func (c *Core) QueryHistory(ctx context.Context, filter HistoryFilter, page page.Page) ([]History, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryHistory")
+	defer span.End()
+
+	history, err := c.storer.QueryHistory(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("queryHistory: %w", err)
+	}
+
+	return history, nil
+}
+
+// CountHistory returns the total number of audit records.
+func (c *Core) CountHistory(ctx context.Context, filter HistoryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.countHistory")
+	defer span.End()
+
+	return c.storer.CountHistory(ctx, filter)
+}
+
+// QueryPolicies returns the actions every role is granted per resource type.
+func (c *Core) QueryPolicies(ctx context.Context) ([]Policy, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryPolicies")
+	defer span.End()
+
+	policies, err := c.storer.QueryPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryPolicies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// UpdatePolicy replaces the actions the role is granted on the resource
+// type. An empty set revokes every action. ADMINs bypass the policies so
+// their policy cannot be changed, and TENANT_ADMINs cannot be granted any.
+func (c *Core) UpdatePolicy(ctx context.Context, p Policy) (Policy, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.updatePolicy")
+	defer span.End()
+
+	if p.Role.Equal(role.Admin) {
+		return Policy{}, ErrAdminPolicy
+	}
+
+	if p.Role.Equal(role.TenantAdmin) && len(p.Actions) > 0 {
+		return Policy{}, ErrTenantAdminPolicy
+	}
+
+	p.Actions = compact(p.Actions)
+
+	if err := c.storer.UpsertPolicy(ctx, p); err != nil {
+		return Policy{}, fmt.Errorf("upsertPolicy: role[%s] resourceType[%s]: %w", p.Role, p.ResourceType, err)
+	}
+
+	return p, nil
+}
+
+// PurgeExpired removes every time limited ACL that is no longer valid.
+func (c *Core) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.purgeExpired")
+	defer span.End()
+
+	n, err := c.storer.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleteExpired: %w", err)
+	}
+
+	return n, nil
+}
+
+// ValidateAccess checks if the user can perform the action on the resource
+// instance. ADMINs are always allowed, other roles are allowed when the role
+// policy grants the action for the resource type or when an ACL grants the
+// action on the instance. Returns ErrAccessDenied otherwise.
+func (c *Core) ValidateAccess(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID, action actions.Action) error {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.validateAccess")
+	defer span.End()
+
+	info, err := c.storer.QueryAccess(ctx, userID, resourceID)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return ErrAccessDenied
+		}
+		return fmt.Errorf("queryAccess: userID[%s] resourceID[%s]: %w", userID, resourceID, err)
+	}
+
+	if !info.Allows(action) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+// ValidateAccessBatch checks many actions of the user at once, reporting
+// for each one whether it is allowed. A resource that does not exist is
+// reported as denied, like in ValidateAccess.
+func (c *Core) ValidateAccessBatch(ctx context.Context, userID uuid.UUID, checks []AccessCheck) (map[AccessCheck]bool, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.validateAccessBatch")
+	defer span.End()
+
+	resourceIDs := make([]uuid.UUID, 0, len(checks))
+	for _, chk := range checks {
+		if !slices.Contains(resourceIDs, chk.ResourceID) {
+			resourceIDs = append(resourceIDs, chk.ResourceID)
+		}
+	}
+
+	infos, err := c.storer.QueryAccessBatch(ctx, userID, resourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("queryAccessBatch: userID[%s]: %w", userID, err)
+	}
+
+	result := make(map[AccessCheck]bool, len(checks))
+	for _, chk := range checks {
+		info, exists := infos[chk.ResourceID]
+		result[chk] = exists && info.Allows(chk.Action)
+	}
+
+	return result, nil
+}
+
+// Simulate explains the decision of ValidateAccess for the action of the
+// user on the resource: the admin bypass, the role policy and the ACLs on
+// the resource and its ancestors, in the order they are applied.
+func (c *Core) Simulate(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID, action actions.Action) (Simulation, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.simulate")
+	defer span.End()
+
+	info, err := c.storer.QueryAccess(ctx, userID, resourceID)
+	if err != nil {
+		return Simulation{}, fmt.Errorf("queryAccess: userID[%s] resourceID[%s]: %w", userID, resourceID, err)
+	}
+
+	lineage, err := c.storer.QueryLineage(ctx, resourceID)
+	if err != nil {
+		return Simulation{}, fmt.Errorf("queryLineage: resourceID[%s]: %w", resourceID, err)
+	}
+
+	acls, err := c.storer.QueryByUser(ctx, userID)
+	if err != nil {
+		return Simulation{}, fmt.Errorf("queryByUser: userID[%s]: %w", userID, err)
+	}
+
+	steps := []SimulationStep{
+		{
+			Rule:    RuleAdminBypass,
+			Matched: info.Role.Equal(role.Admin),
+		},
+		{
+			Rule:    RuleRolePolicy,
+			Matched: slices.ContainsFunc(info.RoleActions, action.Equal),
+			Actions: info.RoleActions,
+		},
+	}
+
+	steps = append(steps, aclSteps(lineage, acls, action, time.Now())...)
+
+	sim := Simulation{
+		UserID:       userID,
+		ResourceID:   resourceID,
+		ResourceType: info.ResourceType,
+		Action:       action,
+		Role:         info.Role,
+		Allowed:      info.Allows(action),
+		Steps:        steps,
+	}
+
+	for _, step := range steps {
+		if step.Matched {
+			sim.Rule = step.Rule
+			break
+		}
+	}
+
+	return sim, nil
+}
+
+// QueryActions returns the actions the user can perform on the resource
+// instance, following the same rules as ValidateAccess. An unknown resource
+// grants no actions.
+func (c *Core) QueryActions(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) ([]actions.Action, error) {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.queryActions")
+	defer span.End()
+
+	info, err := c.storer.QueryAccess(ctx, userID, resourceID)
+	if err != nil {
+		if errors.Is(err, ErrResourceNotFound) {
+			return []actions.Action{}, nil
+		}
+		return nil, fmt.Errorf("queryAccess: userID[%s] resourceID[%s]: %w", userID, resourceID, err)
+	}
+
+	return info.Actions(), nil
+}
+
+// ValidateAccessToResource checks if the user can perform the action on the
+// resource type as a whole, e.g. creating a new dashboard. Only the role
+// policy is considered since there is no instance to grant access to.
+func (c *Core) ValidateAccessToResource(ctx context.Context, userID uuid.UUID, resourceType resource.Resource, action actions.Action) error {
+	ctx, span := otel.AddSpan(ctx, "business.aclbus.validateAccessToResource")
+	defer span.End()
+
+	info, err := c.storer.QueryTypeAccess(ctx, userID, resourceType)
+	if err != nil {
+		return fmt.Errorf("queryTypeAccess: userID[%s] resourceType[%s]: %w", userID, resourceType, err)
+	}
+
+	if !info.Allows(action) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// Allows reports whether the access information grants the action.
+func (ai AccessInfo) Allows(action actions.Action) bool {
+	if ai.Role.Equal(role.Admin) {
+		return true
+	}
+
+	if slices.ContainsFunc(ai.RoleActions, action.Equal) {
+		return true
+	}
+
+	return slices.ContainsFunc(ai.ACLActions, action.Equal)
+}
+
+// Actions returns every action the access information grants.
+func (ai AccessInfo) Actions() []actions.Action {
+	if ai.Role.Equal(role.Admin) {
+		return slices.Clone(allActions)
+	}
+
+	return compact(append(slices.Clone(ai.RoleActions), ai.ACLActions...))
+}
+
+// recordHistory stores the before/after snapshot of a change to the ACL.
+func (c *Core) recordHistory(ctx context.Context, actorID uuid.UUID, op actions.Action, oldActions []actions.Action, acl ACL) error {
+	h := History{
+		ID:         uuid.New(),
+		ACLID:      acl.ID,
+		UserID:     acl.UserID,
+		ResourceID: acl.ResourceID,
+		ActorID:    actorID,
+		Operation:  op,
+		OldActions: oldActions,
+		NewActions: acl.Actions,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := c.storer.CreateHistory(ctx, h); err != nil {
+		return fmt.Errorf("createHistory: aclID[%s]: %w", acl.ID, err)
+	}
+
+	return nil
+}
+
+// addDashboardShared writes the event announcing a dashboard was shared with
+// a user to the outbox.
+func (c *Core) addDashboardShared(ctx context.Context, actorID uuid.UUID, acl ACL) error {
+	acts := make([]string, len(acl.Actions))
+	for i, a := range acl.Actions {
+		acts[i] = a.String()
+	}
+
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeDashboardShared,
+		AggregateID: acl.ResourceID,
+		DedupKey:    outboxbus.TypeDashboardShared + ":" + acl.ID.String(),
+		Payload: outboxbus.DashboardShared{
+			ACLID:       acl.ID,
+			DashboardID: acl.ResourceID,
+			UserID:      acl.UserID,
+			ActorID:     actorID,
+			Actions:     acts,
+			ExpiresAt:   acl.ExpiresAt,
+		},
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return fmt.Errorf("outbox: aclID[%s]: %w", acl.ID, err)
+	}
+
+	return nil
+}
+
+// EffectiveActions resolves the actions granted by ACLs on the first resource
+// of the lineage (the resource itself followed by its ancestors). An ACL on
+// the resource overrides anything inherited; otherwise the nearest ancestor
+// with an ACL that grants GET makes the resource readable.
+func EffectiveActions(lineage []uuid.UUID, acls []ACL, now time.Time) []actions.Action {
+	for depth, resourceID := range lineage {
+		idx := slices.IndexFunc(acls, func(acl ACL) bool {
+			return acl.ResourceID == resourceID && !acl.Expired(now)
+		})
+		if idx < 0 {
+			continue
+		}
+
+		if depth == 0 {
+			return acls[idx].Actions
+		}
+
+		if slices.ContainsFunc(acls[idx].Actions, actions.Get.Equal) {
+			return []actions.Action{actions.Get}
+		}
+
+		return []actions.Action{}
+	}
+
+	return []actions.Action{}
+}
+
+// aclSteps walks the lineage like EffectiveActions and reports the ACLs it
+// meets: the expired ones it skips and the one it applies.
+func aclSteps(lineage []uuid.UUID, acls []ACL, action actions.Action, now time.Time) []SimulationStep {
+	var steps []SimulationStep
+
+	for depth, resourceID := range lineage {
+		idx := slices.IndexFunc(acls, func(acl ACL) bool {
+			return acl.ResourceID == resourceID
+		})
+		if idx < 0 {
+			continue
+		}
+
+		acl := acls[idx]
+
+		step := SimulationStep{
+			Rule:       RuleInstanceACL,
+			Actions:    acl.Actions,
+			ACLID:      &acl.ID,
+			ResourceID: &acl.ResourceID,
+			ExpiresAt:  acl.ExpiresAt,
+			Expired:    acl.Expired(now),
+		}
+
+		// Herdado de um ancestral, o ACL só concede leitura.
+		if depth > 0 {
+			step.Rule = RuleInheritedACL
+			step.Actions = []actions.Action{}
+			if slices.ContainsFunc(acl.Actions, actions.Get.Equal) {
+				step.Actions = []actions.Action{actions.Get}
+			}
+		}
+
+		if step.Expired {
+			steps = append(steps, step)
+			continue
+		}
+
+		step.Matched = slices.ContainsFunc(step.Actions, action.Equal)
+		steps = append(steps, step)
+
+		break
+	}
+
+	if len(steps) == 0 {
+		steps = append(steps, SimulationStep{
+			Rule:    RuleInstanceACL,
+			Actions: []actions.Action{},
+		})
+	}
+
+	return steps
+}
+
+// allActions is the full set of actions an ADMIN is entitled to.
+var allActions = []actions.Action{actions.Create, actions.Delete, actions.Update, actions.Get}
+
+// compact removes duplicated actions keeping the original order.
+func compact(acts []actions.Action) []actions.Action {
+	out := make([]actions.Action, 0, len(acts))
+	for _, a := range acts {
+		if !slices.ContainsFunc(out, a.Equal) {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,329 @@
+package aclbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/aclmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// fixture holds a dashboard with a page, and users of every role. The
+// ANALYST role may read pages through its policy.
+type fixture struct {
+	core     *aclbus.Core
+	store    *aclmemory.Store
+	outbox   *outboxmemory.Store
+	delegate *delegate.Delegate
+
+	dash uuid.UUID
+	page uuid.UUID
+
+	admin   uuid.UUID
+	user    uuid.UUID
+	analyst uuid.UUID
+}
+
+func TestCreate(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		na      aclbus.NewACL
+		wantErr error
+	}{
+		{name: "noActions", na: aclbus.NewACL{UserID: f.user, ResourceID: f.dash}, wantErr: aclbus.ErrNoActions},
+		{name: "pastExpiry", na: aclbus.NewACL{UserID: f.user, ResourceID: f.dash, Actions: []actions.Action{actions.Get}, ExpiresAt: &past}, wantErr: aclbus.ErrInvalidExpiry},
+		{name: "unknownResource", na: aclbus.NewACL{UserID: f.user, ResourceID: uuid.New(), Actions: []actions.Action{actions.Get}}, wantErr: aclbus.ErrResourceNotFound},
+		{name: "dashboard", na: aclbus.NewACL{UserID: f.user, ResourceID: f.dash, Actions: []actions.Action{actions.Get, actions.Get}, ExpiresAt: &future}},
+		{name: "duplicate", na: aclbus.NewACL{UserID: f.user, ResourceID: f.dash, Actions: []actions.Action{actions.Update}}, wantErr: aclbus.ErrUniqueACL},
+		{name: "page", na: aclbus.NewACL{UserID: f.user, ResourceID: f.page, Actions: []actions.Action{actions.Update}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.core.Create(ctx, f.admin, tt.na); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("history", func(t *testing.T) {
+		history, err := f.core.QueryHistory(ctx, aclbus.HistoryFilter{UserID: &f.user}, page.MustParse("1", "10"))
+		if err != nil {
+			t.Fatalf("query history: %s", err)
+		}
+
+		if len(history) != 2 {
+			t.Fatalf("got %d records, want 2", len(history))
+		}
+		for _, h := range history {
+			if h.ActorID != f.admin || !h.Operation.Equal(actions.Create) {
+				t.Errorf("got actor %s op %s, want %s %s", h.ActorID, h.Operation, f.admin, actions.Create)
+			}
+		}
+	})
+
+	// Só o compartilhamento do dashboard vai para o outbox, com as ações
+	// sem repetição.
+	t.Run("outbox", func(t *testing.T) {
+		events := f.outbox.Events()
+		if len(events) != 1 {
+			t.Fatalf("got %d events, want 1", len(events))
+		}
+
+		e := events[0]
+		if e.Type != outboxbus.TypeDashboardShared || e.AggregateID != f.dash {
+			t.Fatalf("got event %s on %s, want %s on %s", e.Type, e.AggregateID, outboxbus.TypeDashboardShared, f.dash)
+		}
+
+		var got outboxbus.DashboardShared
+		if err := json.Unmarshal(e.Payload, &got); err != nil {
+			t.Fatalf("unmarshal: %s", err)
+		}
+		if got.UserID != f.user || got.ActorID != f.admin || !slices.Equal(got.Actions, []string{"GET"}) {
+			t.Errorf("got payload %+v", got)
+		}
+	})
+}
+
+func TestValidateAccess(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.createACL(t, f.user, f.dash, actions.Get, actions.Update)
+	f.createACL(t, f.analyst, f.dash, actions.Get)
+
+	// Uma ACL vencida é gravada direto no store, o core não aceita criá-la.
+	expired := time.Now().Add(-time.Minute)
+	if err := f.store.Create(ctx, aclbus.ACL{ID: uuid.New(), UserID: f.analyst, ResourceID: f.page, Actions: []actions.Action{actions.Update}, ExpiresAt: &expired}); err != nil {
+		t.Fatalf("create expired: %s", err)
+	}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		resourceID uuid.UUID
+		action     actions.Action
+		wantErr    error
+	}{
+		{name: "adminBypass", userID: f.admin, resourceID: f.page, action: actions.Delete},
+		{name: "instanceACL", userID: f.user, resourceID: f.dash, action: actions.Update},
+		{name: "instanceACLDenied", userID: f.user, resourceID: f.dash, action: actions.Delete, wantErr: aclbus.ErrAccessDenied},
+		{name: "inheritedGet", userID: f.user, resourceID: f.page, action: actions.Get},
+		{name: "inheritedUpdate", userID: f.user, resourceID: f.page, action: actions.Update, wantErr: aclbus.ErrAccessDenied},
+		{name: "rolePolicy", userID: f.analyst, resourceID: f.page, action: actions.Get},
+		{name: "expiredACL", userID: f.analyst, resourceID: f.page, action: actions.Update, wantErr: aclbus.ErrAccessDenied},
+		{name: "noACL", userID: f.admin, resourceID: uuid.New(), action: actions.Get, wantErr: aclbus.ErrAccessDenied},
+		{name: "unknownUser", userID: uuid.New(), resourceID: f.dash, action: actions.Get, wantErr: aclbus.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.core.ValidateAccess(ctx, tt.userID, tt.resourceID, tt.action); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		unknown := uuid.New()
+
+		checks := []aclbus.AccessCheck{
+			{ResourceID: f.dash, Action: actions.Update},
+			{ResourceID: f.dash, Action: actions.Delete},
+			{ResourceID: f.page, Action: actions.Get},
+			{ResourceID: unknown, Action: actions.Get},
+		}
+
+		got, err := f.core.ValidateAccessBatch(ctx, f.user, checks)
+		if err != nil {
+			t.Fatalf("validate batch: %s", err)
+		}
+
+		want := []bool{true, false, true, false}
+		for i, chk := range checks {
+			if got[chk] != want[i] {
+				t.Errorf("got %t for %s on %s, want %t", got[chk], chk.Action, chk.ResourceID, want[i])
+			}
+		}
+	})
+}
+
+func TestSimulate(t *testing.T) {
+	f := newFixture(t)
+	f.createACL(t, f.user, f.dash, actions.Get)
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		resourceID uuid.UUID
+		action     actions.Action
+		wantRule   string
+		allowed    bool
+	}{
+		{name: "admin", userID: f.admin, resourceID: f.page, action: actions.Delete, wantRule: aclbus.RuleAdminBypass, allowed: true},
+		{name: "role", userID: f.analyst, resourceID: f.page, action: actions.Get, wantRule: aclbus.RuleRolePolicy, allowed: true},
+		{name: "instance", userID: f.user, resourceID: f.dash, action: actions.Get, wantRule: aclbus.RuleInstanceACL, allowed: true},
+		{name: "inherited", userID: f.user, resourceID: f.page, action: actions.Get, wantRule: aclbus.RuleInheritedACL, allowed: true},
+		{name: "denied", userID: f.user, resourceID: f.page, action: actions.Update},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.Simulate(context.Background(), tt.userID, tt.resourceID, tt.action)
+			if err != nil {
+				t.Fatalf("simulate: %s", err)
+			}
+			if got.Allowed != tt.allowed || got.Rule != tt.wantRule {
+				t.Errorf("got allowed %t by %q, want %t by %q", got.Allowed, got.Rule, tt.allowed, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name    string
+		policy  aclbus.Policy
+		wantErr error
+	}{
+		{name: "admin", policy: aclbus.Policy{Role: role.Admin, ResourceType: resource.Page}, wantErr: aclbus.ErrAdminPolicy},
+		{name: "tenantAdminActions", policy: aclbus.Policy{Role: role.TenantAdmin, ResourceType: resource.Page, Actions: []actions.Action{actions.Get}}, wantErr: aclbus.ErrTenantAdminPolicy},
+		{name: "tenantAdminEmpty", policy: aclbus.Policy{Role: role.TenantAdmin, ResourceType: resource.Page}},
+		{name: "user", policy: aclbus.Policy{Role: role.User, ResourceType: resource.Dashboard, Actions: []actions.Action{actions.Get}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.core.UpdatePolicy(context.Background(), tt.policy); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A política do USER para dashboards passa a valer sem nenhuma ACL.
+	if err := f.core.ValidateAccess(context.Background(), f.user, f.dash, actions.Get); err != nil {
+		t.Fatalf("validate: %s", err)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+	}{
+		{name: "disabled", action: userbus.ActionDisabled},
+		{name: "deleted", action: userbus.ActionDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+
+			f.createACL(t, f.user, f.dash, actions.Get)
+			f.createACL(t, f.user, f.page, actions.Update)
+			f.createACL(t, f.analyst, f.dash, actions.Get)
+
+			data, err := userbus.ActionData(tt.action, userbus.ActionParams{UserID: f.user}, nil)
+			if err != nil {
+				t.Fatalf("action data: %s", err)
+			}
+
+			if err := f.delegate.Call(ctx, data); err != nil {
+				t.Fatalf("call: %s", err)
+			}
+
+			if n, err := f.core.Count(ctx, aclbus.QueryFilter{UserID: &f.user}); err != nil || n != 0 {
+				t.Fatalf("got %d acls of the user, err %v, want 0", n, err)
+			}
+			if n, err := f.core.Count(ctx, aclbus.QueryFilter{UserID: &f.analyst}); err != nil || n != 1 {
+				t.Fatalf("got %d acls of another user, err %v, want 1", n, err)
+			}
+
+			// As remoções ficam no histórico com o ator nulo.
+			history, err := f.core.QueryHistory(ctx, aclbus.HistoryFilter{ActorID: &uuid.Nil}, page.MustParse("1", "10"))
+			if err != nil {
+				t.Fatalf("query history: %s", err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("got %d system records, want 2", len(history))
+			}
+			for _, h := range history {
+				if h.UserID != f.user || !h.Operation.Equal(actions.Delete) || len(h.NewActions) != 0 {
+					t.Errorf("got record %+v", h)
+				}
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	store := aclmemory.NewStore()
+	outbox := outboxmemory.NewStore()
+	dlg := delegate.New(log)
+
+	f := fixture{
+		core:     aclbus.NewCore(log, dlg, store, outboxbus.NewCore(log, outbox, nil)),
+		store:    store,
+		outbox:   outbox,
+		delegate: dlg,
+		dash:     uuid.New(),
+		page:     uuid.New(),
+		admin:    uuid.New(),
+		user:     uuid.New(),
+		analyst:  uuid.New(),
+	}
+
+	store.AddResource(f.dash, resource.Dashboard, uuid.Nil)
+	store.AddResource(f.page, resource.Page, f.dash)
+
+	store.SetUserRole(f.admin, role.Admin)
+	store.SetUserRole(f.user, role.User)
+	store.SetUserRole(f.analyst, role.Analyst)
+
+	p := aclbus.Policy{Role: role.Analyst, ResourceType: resource.Page, Actions: []actions.Action{actions.Get}}
+	if _, err := f.core.UpdatePolicy(context.Background(), p); err != nil {
+		t.Fatalf("update policy: %s", err)
+	}
+
+	return f
+}
+
+func (f fixture) createACL(t *testing.T, userID uuid.UUID, resourceID uuid.UUID, acts ...actions.Action) aclbus.ACL {
+	t.Helper()
+
+	acl, err := f.core.Create(context.Background(), f.admin, aclbus.NewACL{UserID: userID, ResourceID: resourceID, Actions: acts})
+	if err != nil {
+		t.Fatalf("create acl: %s", err)
+	}
+
+	return acl
+}
@@ -0,0 +1,42 @@
+package aclbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+)
+
+// revokeUser removes every grant of a user that was disabled or deleted. The
+// history records the removals with the nil actor, since they were made by
+// the system and not by whoever changed the user.
+func (c *Core) revokeUser(ctx context.Context, data delegate.Data) error {
+	params, err := userbus.ParseActionParams(data.RawParams)
+	if err != nil {
+		return fmt.Errorf("parseActionParams: %w", err)
+	}
+
+	core := c
+	if data.Tx != nil {
+		if core, err = c.NewWithTx(data.Tx); err != nil {
+			return err
+		}
+	}
+
+	acls, err := core.storer.QueryByUser(ctx, params.UserID)
+	if err != nil {
+		return fmt.Errorf("queryByUser: userID[%s]: %w", params.UserID, err)
+	}
+
+	for _, acl := range acls {
+		if err := core.Delete(ctx, uuid.Nil, acl); err != nil {
+			return fmt.Errorf("delete: aclID[%s]: %w", acl.ID, err)
+		}
+	}
+
+	c.log.Info(ctx, "acl revoked", "userID", params.UserID, "action", data.Action, "acls", len(acls))
+
+	return nil
+}
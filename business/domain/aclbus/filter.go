@@ -0,0 +1,26 @@
+package aclbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID           *uuid.UUID
+	UserID       *uuid.UUID
+	ResourceID   *uuid.UUID
+	ResourceType *resource.Resource
+}
+
+// HistoryFilter holds the available fields the audit trail can be filtered on.
+type HistoryFilter struct {
+	ACLID          *uuid.UUID
+	UserID         *uuid.UUID
+	ResourceID     *uuid.UUID
+	ActorID        *uuid.UUID
+	StartCreatedAt *time.Time
+	EndCreatedAt   *time.Time
+}
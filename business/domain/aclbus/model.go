@@ -0,0 +1,162 @@
+package aclbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// ACL represents the set of actions a user was granted on a specific
+// resource instance (dashboard, page or subject).
+type ACL struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ResourceID   uuid.UUID
+	ResourceType resource.Resource
+	Actions      []actions.Action
+	ExpiresAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Expired reports whether the ACL is time limited and no longer valid at now.
+func (acl ACL) Expired(now time.Time) bool {
+	return acl.ExpiresAt != nil && !acl.ExpiresAt.After(now)
+}
+
+// NewACL contains information needed to grant access to a resource. A nil
+// ExpiresAt grants access until the ACL is revoked.
+type NewACL struct {
+	UserID     uuid.UUID
+	ResourceID uuid.UUID
+	Actions    []actions.Action
+	ExpiresAt  *time.Time
+}
+
+// UpdateACL contains information needed to update an ACL.
+type UpdateACL struct {
+	Actions []actions.Action
+}
+
+// AccessInfo holds everything needed to decide if a user can perform an
+// action on a resource instance: the role wide policy for the resource type
+// and the actions granted on the instance itself.
+type AccessInfo struct {
+	Role         role.Role
+	ResourceType resource.Resource
+	RoleActions  []actions.Action
+	ACLActions   []actions.Action
+}
+
+// Set of rules a decision of the access control comes from, in the order
+// they are applied.
+const (
+	RuleAdminBypass  = "ADMIN_BYPASS"
+	RuleRolePolicy   = "ROLE_POLICY"
+	RuleInstanceACL  = "INSTANCE_ACL"
+	RuleInheritedACL = "INHERITED_ACL"
+)
+
+// Simulation is the decision of the access control on an action of a user
+// on a resource, along with every rule considered to reach it. Rule is the
+// first rule that allows the action, empty when the action is denied.
+type Simulation struct {
+	UserID       uuid.UUID
+	ResourceID   uuid.UUID
+	ResourceType resource.Resource
+	Action       actions.Action
+	Role         role.Role
+	Allowed      bool
+	Rule         string
+	Steps        []SimulationStep
+}
+
+// SimulationStep is a rule evaluated by the simulation. The ACL steps carry
+// the ACL and the resource it is on, an ancestor for an inherited ACL.
+// Expired ACLs are listed but never match.
+type SimulationStep struct {
+	Rule       string
+	Matched    bool
+	Actions    []actions.Action
+	ACLID      *uuid.UUID
+	ResourceID *uuid.UUID
+	ExpiresAt  *time.Time
+	Expired    bool
+}
+
+// AccessCheck is an action to validate on a resource instance, one of the
+// many checked at once by ValidateAccessBatch.
+type AccessCheck struct {
+	ResourceID uuid.UUID
+	Action     actions.Action
+}
+
+// UserAccess is a row of the access matrix of a tenant: a member of the
+// tenant along with the access to every dashboard of that tenant.
+type UserAccess struct {
+	UserID     uuid.UUID
+	Name       string
+	Email      string
+	Role       role.Role
+	Enabled    bool
+	Dashboards []DashboardAccess
+}
+
+// DashboardAccess is the access of a user to a dashboard. Linked reports the
+// user_dashboard_access link, direct or through a group, and Actions the ones
+// granted by the ACL, empty when the user has no ACL on the dashboard.
+type DashboardAccess struct {
+	DashboardID uuid.UUID
+	Name        string
+	Linked      bool
+	Actions     []actions.Action
+	ExpiresAt   *time.Time
+}
+
+// TypePolicy is the set of actions a role was granted on every resource of
+// a given type.
+type TypePolicy struct {
+	ResourceType resource.Resource
+	Actions      []actions.Action
+}
+
+// RolePolicy is the role of a user along with the policies granted to that
+// role for each resource type.
+type RolePolicy struct {
+	Role     role.Role
+	Policies []TypePolicy
+}
+
+// Permissions is the effective set of actions a user can perform, combining
+// the role policies with the instance level ACLs.
+type Permissions struct {
+	UserID    uuid.UUID
+	Role      role.Role
+	Types     []TypePolicy
+	Resources []ACL
+}
+
+// Policy is the set of actions a role is granted on every resource of a
+// given type.
+type Policy struct {
+	Role         role.Role
+	ResourceType resource.Resource
+	Actions      []actions.Action
+}
+
+// History is an audit record of a change made to an ACL. Operation is the
+// action performed on the ACL itself (CREATE, UPDATE or DELETE).
+type History struct {
+	ID         uuid.UUID
+	ACLID      uuid.UUID
+	UserID     uuid.UUID
+	ResourceID uuid.UUID
+	ActorID    uuid.UUID
+	Operation  actions.Action
+	OldActions []actions.Action
+	NewActions []actions.Action
+	CreatedAt  time.Time
+}
@@ -0,0 +1,15 @@
+package aclbus
+
+import "github.com/jcpaschoal/spi-exata/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByCreatedAt, order.ASC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByID           = "a"
+	OrderByUserID       = "b"
+	OrderByResourceID   = "c"
+	OrderByResourceType = "d"
+	OrderByCreatedAt    = "e"
+)
@@ -0,0 +1,450 @@
+// Package aclcache contains acl related CRUD functionality with caching.
+// Access checks are resolved from memory by combining the cached role policy
+// of the user with the cached ACLs granted to that user.
+package aclcache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/viccon/sturdyc"
+)
+
+// Channel is the Postgres NOTIFY channel where the database announces changes
+// to acls, role policies and user roles. The payload is the affected user id
+// or "*" when every user is affected.
+const Channel = "acl_changes"
+
+// Store manages the set of APIs for acl data and caching.
+type Store struct {
+	log       *logger.Logger
+	storer    aclbus.Storer
+	acls      *sturdyc.Client[[]aclbus.ACL]
+	policies  *sturdyc.Client[aclbus.RolePolicy]
+	resources *sturdyc.Client[resource.Resource]
+	lineages  *sturdyc.Client[[]uuid.UUID]
+	stats     stats
+}
+
+// stats holds a recorder for each cache of the store.
+type stats struct {
+	acls      *cachestats.Recorder
+	policies  *cachestats.Recorder
+	resources *cachestats.Recorder
+	lineages  *cachestats.Recorder
+}
+
+// NewStore constructs the api for data and caching access.
+func NewStore(log *logger.Logger, storer aclbus.Storer, ttl time.Duration) *Store {
+	const capacity = 10000
+	const numShards = 10
+	const evictionPercentage = 10
+
+	st := stats{
+		acls:      cachestats.NewRecorder(),
+		policies:  cachestats.NewRecorder(),
+		resources: cachestats.NewRecorder(),
+		lineages:  cachestats.NewRecorder(),
+	}
+
+	return &Store{
+		log:       log,
+		storer:    storer,
+		acls:      sturdyc.New[[]aclbus.ACL](capacity, numShards, ttl, evictionPercentage, sturdyc.WithMetrics(st.acls)),
+		policies:  sturdyc.New[aclbus.RolePolicy](capacity, numShards, ttl, evictionPercentage, sturdyc.WithMetrics(st.policies)),
+		resources: sturdyc.New[resource.Resource](capacity, numShards, ttl, evictionPercentage, sturdyc.WithMetrics(st.resources)),
+		lineages:  sturdyc.New[[]uuid.UUID](capacity, numShards, ttl, evictionPercentage, sturdyc.WithMetrics(st.lineages)),
+		stats:     st,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (aclbus.Storer, error) {
+	txStorer, err := s.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log:       s.log,
+		storer:    txStorer,
+		acls:      s.acls,
+		policies:  s.policies,
+		resources: s.resources,
+		lineages:  s.lineages,
+		stats:     s.stats,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new acl into the database.
+func (s *Store) Create(ctx context.Context, acl aclbus.ACL) error {
+	if err := s.storer.Create(ctx, acl); err != nil {
+		return err
+	}
+
+	s.acls.Delete(acl.UserID.String())
+
+	return nil
+}
+
+// Update replaces the actions of an acl in the database.
+func (s *Store) Update(ctx context.Context, acl aclbus.ACL) error {
+	if err := s.storer.Update(ctx, acl); err != nil {
+		return err
+	}
+
+	s.acls.Delete(acl.UserID.String())
+
+	return nil
+}
+
+// Delete removes an acl from the database.
+func (s *Store) Delete(ctx context.Context, acl aclbus.ACL) error {
+	if err := s.storer.Delete(ctx, acl); err != nil {
+		return err
+	}
+
+	s.acls.Delete(acl.UserID.String())
+
+	return nil
+}
+
+// Query retrieves a list of existing acls from the database.
+func (s *Store) Query(ctx context.Context, filter aclbus.QueryFilter, orderBy order.By, page page.Page) ([]aclbus.ACL, error) {
+	return s.storer.Query(ctx, filter, orderBy, page)
+}
+
+// Count returns the total number of acls in the DB.
+func (s *Store) Count(ctx context.Context, filter aclbus.QueryFilter) (int, error) {
+	return s.storer.Count(ctx, filter)
+}
+
+// QueryByID gets the specified acl from the database.
+func (s *Store) QueryByID(ctx context.Context, aclID uuid.UUID) (aclbus.ACL, error) {
+	return s.storer.QueryByID(ctx, aclID)
+}
+
+// QueryAll retrieves every acl from the database.
+func (s *Store) QueryAll(ctx context.Context) ([]aclbus.ACL, error) {
+	return s.storer.QueryAll(ctx)
+}
+
+// QueryByUser retrieves every acl granted to the specified user.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID) ([]aclbus.ACL, error) {
+	key := userID.String()
+
+	if acls, ok := s.acls.Get(key); ok {
+		return acls, nil
+	}
+
+	start := time.Now()
+	acls, err := s.storer.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.stats.acls.Loaded(time.Since(start))
+	s.acls.Set(key, acls)
+
+	return acls, nil
+}
+
+// QueryRolePolicy retrieves the role of the user and the actions the role
+// grants on each resource type.
+func (s *Store) QueryRolePolicy(ctx context.Context, userID uuid.UUID) (aclbus.RolePolicy, error) {
+	key := userID.String()
+
+	if rp, ok := s.policies.Get(key); ok {
+		return rp, nil
+	}
+
+	start := time.Now()
+	rp, err := s.storer.QueryRolePolicy(ctx, userID)
+	if err != nil {
+		return aclbus.RolePolicy{}, err
+	}
+
+	s.stats.policies.Loaded(time.Since(start))
+	s.policies.Set(key, rp)
+
+	return rp, nil
+}
+
+// DeleteExpired removes the acls that expired before now. The affected users
+// are invalidated through the database notification, the cache itself never
+// serves an expired grant.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return s.storer.DeleteExpired(ctx, now)
+}
+
+// QueryPolicies retrieves the actions of every role for every resource type.
+func (s *Store) QueryPolicies(ctx context.Context) ([]aclbus.Policy, error) {
+	return s.storer.QueryPolicies(ctx)
+}
+
+// UpsertPolicy replaces the actions of the role for the resource type. The
+// cached policies of every user are dropped so the change is effective
+// immediately on this instance; other instances are notified by the database.
+func (s *Store) UpsertPolicy(ctx context.Context, p aclbus.Policy) error {
+	if err := s.storer.UpsertPolicy(ctx, p); err != nil {
+		return err
+	}
+
+	s.Invalidate("*")
+
+	return nil
+}
+
+// SyncUserRole drops the cached role policy and acls of the user.
+func (s *Store) SyncUserRole(ctx context.Context, userID uuid.UUID) error {
+	if err := s.storer.SyncUserRole(ctx, userID); err != nil {
+		return err
+	}
+
+	s.Invalidate(userID.String())
+
+	return nil
+}
+
+// CreateHistory inserts an audit record of a change made to an acl.
+func (s *Store) CreateHistory(ctx context.Context, h aclbus.History) error {
+	return s.storer.CreateHistory(ctx, h)
+}
+
+// QueryHistory retrieves the audit trail of acl changes.
+func (s *Store) QueryHistory(ctx context.Context, filter aclbus.HistoryFilter, page page.Page) ([]aclbus.History, error) {
+	return s.storer.QueryHistory(ctx, filter, page)
+}
+
+// CountHistory returns the total number of audit records.
+func (s *Store) CountHistory(ctx context.Context, filter aclbus.HistoryFilter) (int, error) {
+	return s.storer.CountHistory(ctx, filter)
+}
+
+// QueryResourceType returns the type of the specified resource instance. The
+// type of a resource never changes so it is safe to keep it until evicted.
+func (s *Store) QueryResourceType(ctx context.Context, resourceID uuid.UUID) (resource.Resource, error) {
+	key := resourceID.String()
+
+	if rt, ok := s.resources.Get(key); ok {
+		return rt, nil
+	}
+
+	start := time.Now()
+	rt, err := s.storer.QueryResourceType(ctx, resourceID)
+	if err != nil {
+		return resource.Resource{}, err
+	}
+
+	s.stats.resources.Loaded(time.Since(start))
+	s.resources.Set(key, rt)
+
+	return rt, nil
+}
+
+// QueryLineage returns the resource followed by its ancestors. Resources do
+// not move across the hierarchy so the lineage is kept until evicted.
+func (s *Store) QueryLineage(ctx context.Context, resourceID uuid.UUID) ([]uuid.UUID, error) {
+	key := resourceID.String()
+
+	if lineage, ok := s.lineages.Get(key); ok {
+		return lineage, nil
+	}
+
+	start := time.Now()
+	lineage, err := s.storer.QueryLineage(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.stats.lineages.Loaded(time.Since(start))
+	s.lineages.Set(key, lineage)
+
+	return lineage, nil
+}
+
+// QueryAccess builds the access information from the cached role policy,
+// ACLs and resource lineage of the user.
+func (s *Store) QueryAccess(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) (aclbus.AccessInfo, error) {
+	rt, err := s.QueryResourceType(ctx, resourceID)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	info, err := s.QueryTypeAccess(ctx, userID, rt)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	acls, err := s.QueryByUser(ctx, userID)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	lineage, err := s.QueryLineage(ctx, resourceID)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	info.ACLActions = aclbus.EffectiveActions(lineage, acls, time.Now())
+
+	return info, nil
+}
+
+// QueryTypeAccess builds the access information for the resource type from
+// the cached role policy of the user.
+func (s *Store) QueryTypeAccess(ctx context.Context, userID uuid.UUID, resourceType resource.Resource) (aclbus.AccessInfo, error) {
+	rp, err := s.QueryRolePolicy(ctx, userID)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	return typeAccess(rp, resourceType), nil
+}
+
+// QueryAccessBatch builds the access information of each resource from the
+// cache in one pass. The resources whose type or lineage is not cached are
+// retrieved from the database in a single query.
+func (s *Store) QueryAccessBatch(ctx context.Context, userID uuid.UUID, resourceIDs []uuid.UUID) (map[uuid.UUID]aclbus.AccessInfo, error) {
+	type cached struct {
+		rt      resource.Resource
+		lineage []uuid.UUID
+	}
+
+	hits := make(map[uuid.UUID]cached, len(resourceIDs))
+	var misses []uuid.UUID
+
+	for _, id := range resourceIDs {
+		key := id.String()
+
+		rt, ok := s.resources.Get(key)
+		if !ok {
+			misses = append(misses, id)
+			continue
+		}
+
+		lineage, ok := s.lineages.Get(key)
+		if !ok {
+			misses = append(misses, id)
+			continue
+		}
+
+		hits[id] = cached{rt: rt, lineage: lineage}
+	}
+
+	infos := make(map[uuid.UUID]aclbus.AccessInfo, len(resourceIDs))
+
+	if len(misses) > 0 {
+		// A consulta única dos recursos ausentes conta como uma leitura
+		// do cache de recursos.
+		start := time.Now()
+
+		var err error
+		if infos, err = s.storer.QueryAccessBatch(ctx, userID, misses); err != nil {
+			return nil, err
+		}
+
+		s.stats.resources.Loaded(time.Since(start))
+	}
+
+	if len(hits) == 0 {
+		return infos, nil
+	}
+
+	rp, err := s.QueryRolePolicy(ctx, userID)
+	if err != nil {
+		// Como em QueryAccess, um usuário inexistente não vê nenhum recurso.
+		if errors.Is(err, aclbus.ErrAccessDenied) {
+			return infos, nil
+		}
+		return nil, err
+	}
+
+	acls, err := s.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for id, c := range hits {
+		info := typeAccess(rp, c.rt)
+		info.ACLActions = aclbus.EffectiveActions(c.lineage, acls, now)
+		infos[id] = info
+	}
+
+	return infos, nil
+}
+
+// typeAccess builds the access information for the resource type from the
+// role policy.
+func typeAccess(rp aclbus.RolePolicy, resourceType resource.Resource) aclbus.AccessInfo {
+	info := aclbus.AccessInfo{
+		Role:         rp.Role,
+		ResourceType: resourceType,
+		RoleActions:  []actions.Action{},
+		ACLActions:   []actions.Action{},
+	}
+
+	idx := slices.IndexFunc(rp.Policies, func(tp aclbus.TypePolicy) bool {
+		return tp.ResourceType.Equal(resourceType)
+	})
+	if idx >= 0 {
+		info.RoleActions = rp.Policies[idx].Actions
+	}
+
+	return info
+}
+
+// QueryTenantAccess retrieves the access matrix of the tenant from the
+// database. The matrix is an admin listing and is not cached.
+func (s *Store) QueryTenantAccess(ctx context.Context, tenantID uuid.UUID) ([]aclbus.UserAccess, error) {
+	return s.storer.QueryTenantAccess(ctx, tenantID)
+}
+
+// Invalidate drops the cached permissions of the user announced in the
+// payload, or of every user when the payload is "*". It is meant to be fed
+// by sqldb.Listen on Channel so every instance sees changes made elsewhere.
+func (s *Store) Invalidate(payload string) {
+	if payload == "*" {
+		for _, key := range s.policies.ScanKeys() {
+			s.policies.Delete(key)
+		}
+		for _, key := range s.acls.ScanKeys() {
+			s.acls.Delete(key)
+		}
+		s.stats.policies.Flushed()
+		s.stats.acls.Flushed()
+		return
+	}
+
+	s.policies.Delete(payload)
+	s.acls.Delete(payload)
+	s.stats.policies.Invalidated(1)
+	s.stats.acls.Invalidated(1)
+}
+
+// Stats returns the runtime statistics of each cache of the store. The
+// entries of "policies" are the role policies cached, one per user. The
+// load times show how long the cache takes to refill after a flush.
+func (s *Store) Stats() map[string]cachestats.Stats {
+	return map[string]cachestats.Stats{
+		"acls":      s.stats.acls.Stats(s.acls.Size()),
+		"policies":  s.stats.policies.Stats(s.policies.Size()),
+		"resources": s.stats.resources.Stats(s.resources.Size()),
+		"lineages":  s.stats.lineages.Stats(s.lineages.Size()),
+	}
+}
@@ -0,0 +1,85 @@
+package aclcache_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/aclcache"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/aclmemory"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+func TestStatsLoads(t *testing.T) {
+	userID := uuid.New()
+	dashID := uuid.New()
+
+	mem := aclmemory.NewStore()
+	mem.SetUserRole(userID, role.User)
+	mem.AddResource(dashID, resource.Dashboard, uuid.Nil)
+
+	store := aclcache.NewStore(logger.New(io.Discard, logger.LevelInfo, "TEST", nil), mem, time.Minute)
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		run       func() error
+		wantLoads map[string]int64
+	}{
+		{
+			name: "miss",
+			run: func() error {
+				_, err := store.QueryAccess(ctx, userID, dashID)
+				return err
+			},
+			wantLoads: map[string]int64{"acls": 1, "policies": 1, "resources": 1, "lineages": 1},
+		},
+		{
+			name: "hit",
+			run: func() error {
+				_, err := store.QueryAccess(ctx, userID, dashID)
+				return err
+			},
+			wantLoads: map[string]int64{"acls": 1, "policies": 1, "resources": 1, "lineages": 1},
+		},
+		{
+			name: "refillAfterFlush",
+			run: func() error {
+				store.Invalidate("*")
+				_, err := store.QueryAccess(ctx, userID, dashID)
+				return err
+			},
+			wantLoads: map[string]int64{"acls": 2, "policies": 2, "resources": 1, "lineages": 1},
+		},
+		{
+			name: "batchMiss",
+			run: func() error {
+				_, err := store.QueryAccessBatch(ctx, userID, []uuid.UUID{uuid.New()})
+				return err
+			},
+			wantLoads: map[string]int64{"acls": 2, "policies": 2, "resources": 2, "lineages": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); err != nil {
+				t.Fatalf("run: %s", err)
+			}
+
+			for name, st := range store.Stats() {
+				if st.Loads != tt.wantLoads[name] {
+					t.Errorf("%s: got %d loads, want %d", name, st.Loads, tt.wantLoads[name])
+				}
+				if st.LoadMaxMs < st.LoadAvgMs {
+					t.Errorf("%s: got max %fms below the average %fms", name, st.LoadMaxMs, st.LoadAvgMs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,744 @@
+// Package acldb contains acl related CRUD functionality.
+package acldb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/dbarray"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for acl database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (aclbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new acl into the database.
+func (s *Store) Create(ctx context.Context, acl aclbus.ACL) error {
+	const q = `
+	INSERT INTO "public"."acl"
+		(acl_id, user_id, resource_id, actions, expires_at, created_at, updated_at)
+	VALUES
+		(:acl_id, :user_id, :resource_id, :actions, :expires_at, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBACL(acl)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) {
+			return fmt.Errorf("namedexeccontext: %w", aclbus.ErrUniqueACL)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the actions of an acl in the database.
+func (s *Store) Update(ctx context.Context, acl aclbus.ACL) error {
+	const q = `
+	UPDATE
+		"public"."acl"
+	SET
+		actions = :actions,
+		updated_at = :updated_at
+	WHERE
+		acl_id = :acl_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBACL(acl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an acl from the database.
+func (s *Store) Delete(ctx context.Context, acl aclbus.ACL) error {
+	const q = `
+	DELETE FROM
+		"public"."acl"
+	WHERE
+		acl_id = :acl_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBACL(acl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// selectACL is the base query used to read acls along with the name of the
+// resource type of the instance they grant access to.
+const selectACL = `
+	SELECT
+		a.acl_id, a.user_id, a.resource_id, a.actions, a.expires_at, a.created_at, a.updated_at,
+		rt.name AS resource_type
+	FROM
+		"public"."acl" AS a
+	JOIN
+		"public"."resource" AS r ON r.resource_id = a.resource_id
+	JOIN
+		"public"."resource_type" AS rt ON rt.resource_type_id = r.resource_type_id`
+
+// Query retrieves a list of existing acls from the database.
+func (s *Store) Query(ctx context.Context, filter aclbus.QueryFilter, orderBy order.By, page page.Page) ([]aclbus.ACL, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	buf := bytes.NewBufferString(selectACL)
+	applyFilter(filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbACLs []aclDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbACLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusACLs(dbACLs)
+}
+
+// Count returns the total number of acls in the DB.
+func (s *Store) Count(ctx context.Context, filter aclbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."acl" AS a
+	JOIN
+		"public"."resource" AS r ON r.resource_id = a.resource_id
+	JOIN
+		"public"."resource_type" AS rt ON rt.resource_type_id = r.resource_type_id`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified acl from the database.
+func (s *Store) QueryByID(ctx context.Context, aclID uuid.UUID) (aclbus.ACL, error) {
+	data := struct {
+		ID string `db:"acl_id"`
+	}{
+		ID: aclID.String(),
+	}
+
+	const q = selectACL + `
+	WHERE
+		a.acl_id = :acl_id`
+
+	var dbACL aclDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbACL); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return aclbus.ACL{}, fmt.Errorf("db: %w", aclbus.ErrNotFound)
+		}
+		return aclbus.ACL{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusACL(dbACL)
+}
+
+// QueryAll retrieves every acl from the database.
+func (s *Store) QueryAll(ctx context.Context) ([]aclbus.ACL, error) {
+	var dbACLs []aclDB
+	if err := sqldb.QuerySlice(ctx, s.log, s.db, selectACL, &dbACLs); err != nil {
+		return nil, fmt.Errorf("queryslice: %w", err)
+	}
+
+	return toBusACLs(dbACLs)
+}
+
+// QueryByUser retrieves every acl granted to the specified user.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID) ([]aclbus.ACL, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = selectACL + `
+	WHERE
+		a.user_id = :user_id`
+
+	var dbACLs []aclDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbACLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusACLs(dbACLs)
+}
+
+// QueryRolePolicy retrieves the role of the user and the actions the role
+// grants on each resource type.
+func (s *Store) QueryRolePolicy(ctx context.Context, userID uuid.UUID) (aclbus.RolePolicy, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		ro.name AS role,
+		rt.name AS resource_type,
+		COALESCE(rp.actions, '{}') AS actions
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS ro ON ro.role_id = u.role_id
+	CROSS JOIN
+		"public"."resource_type" AS rt
+	LEFT JOIN
+		"public"."role_policy" AS rp ON rp.role_id = u.role_id AND rp.resource_type_id = rt.resource_type_id
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL
+	ORDER BY
+		rt.resource_type_id`
+
+	var dbPolicies []policyDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbPolicies); err != nil {
+		return aclbus.RolePolicy{}, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	// Unknown users have no access at all.
+	if len(dbPolicies) == 0 {
+		return aclbus.RolePolicy{}, aclbus.ErrAccessDenied
+	}
+
+	return toBusRolePolicy(dbPolicies)
+}
+
+// DeleteExpired removes the acls that expired before now and returns how
+// many were removed.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."acl"
+		WHERE
+			expires_at IS NOT NULL AND expires_at <= :now
+		RETURNING acl_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryPolicies retrieves the actions of every role for every resource type.
+// Missing role_policy rows are reported with no actions.
+func (s *Store) QueryPolicies(ctx context.Context) ([]aclbus.Policy, error) {
+	const q = `
+	SELECT
+		ro.name AS role,
+		rt.name AS resource_type,
+		COALESCE(rp.actions, '{}') AS actions
+	FROM
+		"public"."role" AS ro
+	CROSS JOIN
+		"public"."resource_type" AS rt
+	LEFT JOIN
+		"public"."role_policy" AS rp ON rp.role_id = ro.role_id AND rp.resource_type_id = rt.resource_type_id
+	ORDER BY
+		ro.role_id, rt.resource_type_id`
+
+	var dbPolicies []policyDB
+	if err := sqldb.QuerySlice(ctx, s.log, s.db, q, &dbPolicies); err != nil {
+		return nil, fmt.Errorf("queryslice: %w", err)
+	}
+
+	return toBusPolicies(dbPolicies)
+}
+
+// UpsertPolicy replaces the actions of the role for the resource type.
+func (s *Store) UpsertPolicy(ctx context.Context, p aclbus.Policy) error {
+	data := struct {
+		Role         string         `db:"role"`
+		ResourceType string         `db:"resource_type"`
+		Actions      dbarray.String `db:"actions"`
+	}{
+		Role:         p.Role.String(),
+		ResourceType: p.ResourceType.String(),
+		Actions:      toDBActions(p.Actions),
+	}
+
+	const q = `
+	INSERT INTO "public"."role_policy"
+		(role_id, resource_type_id, actions)
+	SELECT
+		ro.role_id, rt.resource_type_id, CAST(:actions AS varchar[])
+	FROM
+		"public"."role" AS ro, "public"."resource_type" AS rt
+	WHERE
+		ro.name = :role AND rt.name = :resource_type
+	ON CONFLICT (role_id, resource_type_id) DO UPDATE SET
+		actions = EXCLUDED.actions`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// SyncUserRole is a no-op for the database since the role is always read
+// from the users table.
+func (s *Store) SyncUserRole(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+// CreateHistory inserts an audit record of a change made to an acl.
+func (s *Store) CreateHistory(ctx context.Context, h aclbus.History) error {
+	const q = `
+	INSERT INTO "public"."acl_history"
+		(history_id, acl_id, user_id, resource_id, actor_id, operation, old_actions, new_actions, created_at)
+	VALUES
+		(:history_id, :acl_id, :user_id, :resource_id, :actor_id, :operation, :old_actions, :new_actions, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBHistory(h)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryHistory retrieves the audit trail of acl changes, newest first.
+func (s *Store) QueryHistory(ctx context.Context, filter aclbus.HistoryFilter, page page.Page) ([]aclbus.History, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		history_id, acl_id, user_id, resource_id, actor_id, operation, old_actions, new_actions, created_at
+	FROM
+		"public"."acl_history"`
+
+	buf := bytes.NewBufferString(q)
+	applyHistoryFilter(filter, data, buf)
+
+	buf.WriteString(" ORDER BY created_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbHistory []historyDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbHistory); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusHistories(dbHistory)
+}
+
+// CountHistory returns the total number of audit records in the DB.
+func (s *Store) CountHistory(ctx context.Context, filter aclbus.HistoryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."acl_history"`
+
+	buf := bytes.NewBufferString(q)
+	applyHistoryFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryResourceType returns the type of the specified resource instance.
+func (s *Store) QueryResourceType(ctx context.Context, resourceID uuid.UUID) (resource.Resource, error) {
+	data := struct {
+		ID string `db:"resource_id"`
+	}{
+		ID: resourceID.String(),
+	}
+
+	const q = `
+	SELECT
+		rt.name
+	FROM
+		"public"."resource" AS r
+	JOIN
+		"public"."resource_type" AS rt ON rt.resource_type_id = r.resource_type_id
+	WHERE
+		r.resource_id = :resource_id`
+
+	var result struct {
+		Name string `db:"name"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return resource.Resource{}, aclbus.ErrResourceNotFound
+		}
+		return resource.Resource{}, fmt.Errorf("db: %w", err)
+	}
+
+	return resource.Parse(result.Name)
+}
+
+// QueryLineage returns the resource followed by its ancestors, nearest first.
+func (s *Store) QueryLineage(ctx context.Context, resourceID uuid.UUID) ([]uuid.UUID, error) {
+	data := struct {
+		ID string `db:"resource_id"`
+	}{
+		ID: resourceID.String(),
+	}
+
+	const q = `
+	WITH RECURSIVE lineage AS (
+		SELECT
+			CAST(:resource_id AS uuid) AS resource_id, 0 AS depth
+		UNION ALL
+		SELECT
+			h.parent_id, l.depth + 1
+		FROM
+			lineage AS l
+		JOIN
+			"public"."resource_hierarchy" AS h ON h.resource_id = l.resource_id
+	)
+	SELECT
+		resource_id
+	FROM
+		lineage
+	ORDER BY
+		depth`
+
+	var rows []struct {
+		ID uuid.UUID `db:"resource_id"`
+	}
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &rows); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	lineage := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		lineage[i] = row.ID
+	}
+
+	return lineage, nil
+}
+
+// QueryAccess retrieves the role policy and the acl of the user for the
+// specified resource instance in a single round trip.
+func (s *Store) QueryAccess(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) (aclbus.AccessInfo, error) {
+	data := struct {
+		UserID     string `db:"user_id"`
+		ResourceID string `db:"resource_id"`
+	}{
+		UserID:     userID.String(),
+		ResourceID: resourceID.String(),
+	}
+
+	// The acl actions follow aclbus.EffectiveActions: the acl on the resource
+	// itself wins, otherwise the nearest ancestor acl grants GET only.
+	const q = `
+	WITH RECURSIVE lineage AS (
+		SELECT
+			CAST(:resource_id AS uuid) AS resource_id, 0 AS depth
+		UNION ALL
+		SELECT
+			h.parent_id, l.depth + 1
+		FROM
+			lineage AS l
+		JOIN
+			"public"."resource_hierarchy" AS h ON h.resource_id = l.resource_id
+	),
+	nearest AS (
+		SELECT
+			l.depth, a.actions
+		FROM
+			lineage AS l
+		JOIN
+			"public"."acl" AS a ON a.resource_id = l.resource_id AND a.user_id = :user_id
+				AND (a.expires_at IS NULL OR a.expires_at > now())
+		ORDER BY
+			l.depth
+		LIMIT 1
+	)
+	SELECT
+		ro.name AS role,
+		rt.name AS resource_type,
+		COALESCE(rp.actions, '{}') AS role_actions,
+		COALESCE((
+			SELECT
+				CASE
+					WHEN n.depth = 0 THEN n.actions
+					WHEN 'GET' = ANY(n.actions) THEN CAST(ARRAY['GET'] AS varchar[])
+					ELSE CAST('{}' AS varchar[])
+				END
+			FROM
+				nearest AS n
+		), '{}') AS acl_actions
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS ro ON ro.role_id = u.role_id
+	CROSS JOIN
+		"public"."resource" AS r
+	JOIN
+		"public"."resource_type" AS rt ON rt.resource_type_id = r.resource_type_id
+	LEFT JOIN
+		"public"."role_policy" AS rp ON rp.role_id = u.role_id AND rp.resource_type_id = r.resource_type_id
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL AND r.resource_id = :resource_id`
+
+	var dbAccess accessDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAccess); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return aclbus.AccessInfo{}, aclbus.ErrResourceNotFound
+		}
+		return aclbus.AccessInfo{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAccessInfo(dbAccess)
+}
+
+// QueryAccessBatch retrieves the access of the user to each resource in a
+// single query. Resources that do not exist are left out of the result.
+func (s *Store) QueryAccessBatch(ctx context.Context, userID uuid.UUID, resourceIDs []uuid.UUID) (map[uuid.UUID]aclbus.AccessInfo, error) {
+	if len(resourceIDs) == 0 {
+		return map[uuid.UUID]aclbus.AccessInfo{}, nil
+	}
+
+	ids := make([]string, len(resourceIDs))
+	for i, id := range resourceIDs {
+		ids[i] = id.String()
+	}
+
+	data := struct {
+		UserID      string   `db:"user_id"`
+		ResourceIDs []string `db:"resource_ids"`
+	}{
+		UserID:      userID.String(),
+		ResourceIDs: ids,
+	}
+
+	// Same rules as QueryAccess, with the lineage of every resource walked
+	// at once and kept apart by the resource it started from.
+	const q = `
+	WITH RECURSIVE lineage AS (
+		SELECT
+			r.resource_id AS root_id, r.resource_id, 0 AS depth
+		FROM
+			"public"."resource" AS r
+		WHERE
+			r.resource_id IN (:resource_ids)
+		UNION ALL
+		SELECT
+			l.root_id, h.parent_id, l.depth + 1
+		FROM
+			lineage AS l
+		JOIN
+			"public"."resource_hierarchy" AS h ON h.resource_id = l.resource_id
+	),
+	nearest AS (
+		SELECT DISTINCT ON (l.root_id)
+			l.root_id, l.depth, a.actions
+		FROM
+			lineage AS l
+		JOIN
+			"public"."acl" AS a ON a.resource_id = l.resource_id AND a.user_id = :user_id
+				AND (a.expires_at IS NULL OR a.expires_at > now())
+		ORDER BY
+			l.root_id, l.depth
+	)
+	SELECT
+		r.resource_id,
+		ro.name AS role,
+		rt.name AS resource_type,
+		COALESCE(rp.actions, '{}') AS role_actions,
+		COALESCE(
+			CASE
+				WHEN n.depth = 0 THEN n.actions
+				WHEN 'GET' = ANY(n.actions) THEN CAST(ARRAY['GET'] AS varchar[])
+				ELSE CAST('{}' AS varchar[])
+			END, '{}') AS acl_actions
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS ro ON ro.role_id = u.role_id
+	CROSS JOIN
+		"public"."resource" AS r
+	JOIN
+		"public"."resource_type" AS rt ON rt.resource_type_id = r.resource_type_id
+	LEFT JOIN
+		"public"."role_policy" AS rp ON rp.role_id = u.role_id AND rp.resource_type_id = r.resource_type_id
+	LEFT JOIN
+		nearest AS n ON n.root_id = r.resource_id
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL AND r.resource_id IN (:resource_ids)`
+
+	var rows []accessBatchDB
+	if err := sqldb.NamedQuerySliceUsingIn(ctx, s.log, s.db, q, data, &rows); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	infos := make(map[uuid.UUID]aclbus.AccessInfo, len(rows))
+	for _, row := range rows {
+		info, err := toBusAccessInfo(row.accessDB)
+		if err != nil {
+			return nil, err
+		}
+		infos[row.ResourceID] = info
+	}
+
+	return infos, nil
+}
+
+// QueryTypeAccess retrieves the role policy of the user for the specified
+// resource type.
+func (s *Store) QueryTypeAccess(ctx context.Context, userID uuid.UUID, resourceType resource.Resource) (aclbus.AccessInfo, error) {
+	data := struct {
+		UserID       string `db:"user_id"`
+		ResourceType string `db:"resource_type"`
+	}{
+		UserID:       userID.String(),
+		ResourceType: resourceType.String(),
+	}
+
+	const q = `
+	SELECT
+		ro.name AS role,
+		rt.name AS resource_type,
+		COALESCE(rp.actions, '{}') AS role_actions,
+		CAST('{}' AS varchar[]) AS acl_actions
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS ro ON ro.role_id = u.role_id
+	JOIN
+		"public"."resource_type" AS rt ON rt.name = :resource_type
+	LEFT JOIN
+		"public"."role_policy" AS rp ON rp.role_id = u.role_id AND rp.resource_type_id = rt.resource_type_id
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL`
+
+	var dbAccess accessDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAccess); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return aclbus.AccessInfo{}, aclbus.ErrAccessDenied
+		}
+		return aclbus.AccessInfo{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAccessInfo(dbAccess)
+}
+
+// QueryTenantAccess retrieves the access matrix of the tenant in a single
+// query: one row per member and dashboard of the tenant, or a single row
+// with no dashboard when the tenant has none.
+func (s *Store) QueryTenantAccess(ctx context.Context, tenantID uuid.UUID) ([]aclbus.UserAccess, error) {
+	data := struct {
+		TenantID string    `db:"tenant_id"`
+		Now      time.Time `db:"now"`
+	}{
+		TenantID: tenantID.String(),
+		Now:      time.Now().UTC(),
+	}
+
+	const q = `
+	SELECT
+		u.user_id, u.name, u.email, ro.name AS role, u.enabled,
+		d.dashboard_id, d.name AS dashboard_name,
+		EXISTS (
+			SELECT 1
+			FROM "public"."user_dashboard_access" AS uda
+			LEFT JOIN "public"."user_group_member" AS gm ON gm.group_id = uda.group_id AND gm.user_id = u.user_id
+			WHERE uda.dashboard_id = d.dashboard_id AND (uda.user_id = u.user_id OR gm.user_id IS NOT NULL)
+		) AS linked,
+		a.actions, a.expires_at
+	FROM
+		"public"."tenant_membership" AS tm
+	JOIN
+		"public"."users" AS u ON u.user_id = tm.user_id AND u.deleted_at IS NULL
+	JOIN
+		"public"."role" AS ro ON ro.role_id = u.role_id
+	LEFT JOIN
+		"public"."dashboard" AS d ON d.tenant_id = tm.tenant_id
+	LEFT JOIN
+		"public"."acl" AS a ON a.user_id = u.user_id AND a.resource_id = d.dashboard_id
+			AND (a.expires_at IS NULL OR a.expires_at > :now)
+	WHERE
+		tm.tenant_id = :tenant_id
+	ORDER BY
+		u.name, u.user_id, d.name, d.dashboard_id`
+
+	var dbRows []userAccessDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbRows); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusUserAccess(dbRows)
+}
@@ -0,0 +1,273 @@
+package acldb_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus/stores/acldb"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboarddb"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	tenantdb "github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/dbtest"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+func Test_ACL(t *testing.T) {
+	db := dbtest.New(t, "Test_ACL")
+	store := acldb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	now := time.Now()
+
+	tnt := tenantbus.Tenant{
+		ID:        uuid.New(),
+		Name:      name.MustParse("Acme"),
+		Slug:      slug.MustParse("acme"),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tenantdb.NewStore(db.Log, db.DB).Create(ctx, tnt); err != nil {
+		t.Fatalf("create tenant: %s", err)
+	}
+
+	dash, err := dashboarddb.NewStore(db.Log, db.DB).Create(ctx, dashboardbus.Dashboard{
+		TenantID:  tnt.ID,
+		Name:      name.MustParse("Dashboard"),
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("create dashboard: %s", err)
+	}
+
+	users := userdb.NewStore(db.Log, db.DB)
+	analyst := createUser(t, users, "analyst@example.com", role.Analyst)
+	granted := createUser(t, users, "granted@example.com", role.User)
+	expired := createUser(t, users, "expired@example.com", role.User)
+	plain := createUser(t, users, "plain@example.com", role.User)
+
+	past := now.Add(-time.Hour)
+
+	grant := createACL(t, store, granted.ID, dash.ID, nil, actions.Get, actions.Update)
+	createACL(t, store, expired.ID, dash.ID, &past, actions.Get)
+
+	t.Run("queryByID", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			id      uuid.UUID
+			wantErr error
+		}{
+			{name: "found", id: grant.ID},
+			{name: "unknown", id: uuid.New(), wantErr: aclbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryByID(ctx, tt.id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+
+				if got.UserID != grant.UserID || got.ResourceID != grant.ResourceID {
+					t.Errorf("got acl of %s on %s, want %s on %s", got.UserID, got.ResourceID, grant.UserID, grant.ResourceID)
+				}
+				if !got.ResourceType.Equal(resource.Dashboard) {
+					t.Errorf("got resource type %s, want %s", got.ResourceType, resource.Dashboard)
+				}
+				assertActions(t, got.Actions, grant.Actions)
+			})
+		}
+	})
+
+	t.Run("createUnique", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			userID  uuid.UUID
+			wantErr error
+		}{
+			{name: "sameResource", userID: granted.ID, wantErr: aclbus.ErrUniqueACL},
+			{name: "otherUser", userID: analyst.ID},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				acl := newACL(tt.userID, dash.ID, nil, actions.Get)
+
+				if err := store.Create(ctx, acl); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("queryAccess", func(t *testing.T) {
+		tests := []struct {
+			name            string
+			userID          uuid.UUID
+			resourceID      uuid.UUID
+			wantRole        role.Role
+			wantRoleActions []actions.Action
+			wantACLActions  []actions.Action
+			wantErr         error
+		}{
+			{
+				name:            "rolePolicy",
+				userID:          analyst.ID,
+				resourceID:      dash.ID,
+				wantRole:        role.Analyst,
+				wantRoleActions: []actions.Action{actions.Update, actions.Get},
+				wantACLActions:  []actions.Action{actions.Get},
+			},
+			{
+				name:           "instanceACL",
+				userID:         granted.ID,
+				resourceID:     dash.ID,
+				wantRole:       role.User,
+				wantACLActions: []actions.Action{actions.Get, actions.Update},
+			},
+			{
+				name:       "expiredACL",
+				userID:     expired.ID,
+				resourceID: dash.ID,
+				wantRole:   role.User,
+			},
+			{
+				name:       "noACL",
+				userID:     plain.ID,
+				resourceID: dash.ID,
+				wantRole:   role.User,
+			},
+			{
+				name:       "unknownResource",
+				userID:     plain.ID,
+				resourceID: uuid.New(),
+				wantErr:    aclbus.ErrResourceNotFound,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryAccess(ctx, tt.userID, tt.resourceID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+
+				if !got.Role.Equal(tt.wantRole) {
+					t.Errorf("got role %s, want %s", got.Role, tt.wantRole)
+				}
+				if !got.ResourceType.Equal(resource.Dashboard) {
+					t.Errorf("got resource type %s, want %s", got.ResourceType, resource.Dashboard)
+				}
+				assertActions(t, got.RoleActions, tt.wantRoleActions)
+				assertActions(t, got.ACLActions, tt.wantACLActions)
+			})
+		}
+	})
+
+	t.Run("deleteExpired", func(t *testing.T) {
+		n, err := store.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			t.Fatalf("delete expired: %s", err)
+		}
+		if n != 1 {
+			t.Errorf("got %d acls deleted, want 1", n)
+		}
+
+		acls, err := store.QueryByUser(ctx, expired.ID)
+		if err != nil {
+			t.Fatalf("query by user: %s", err)
+		}
+		if len(acls) != 0 {
+			t.Errorf("got %d acls of the expired user, want 0", len(acls))
+		}
+	})
+}
+
+// =============================================================================
+
+func newACL(userID uuid.UUID, resourceID uuid.UUID, expiresAt *time.Time, acts ...actions.Action) aclbus.ACL {
+	now := time.Now()
+
+	return aclbus.ACL{
+		ID:           uuid.New(),
+		UserID:       userID,
+		ResourceID:   resourceID,
+		ResourceType: resource.Dashboard,
+		Actions:      acts,
+		ExpiresAt:    expiresAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func createACL(t *testing.T, store *acldb.Store, userID uuid.UUID, resourceID uuid.UUID, expiresAt *time.Time, acts ...actions.Action) aclbus.ACL {
+	t.Helper()
+
+	acl := newACL(userID, resourceID, expiresAt, acts...)
+	if err := store.Create(context.Background(), acl); err != nil {
+		t.Fatalf("create acl: %s", err)
+	}
+
+	return acl
+}
+
+func createUser(t *testing.T, store *userdb.Store, email string, rl role.Role) userbus.User {
+	t.Helper()
+
+	now := time.Now()
+
+	usr := userbus.User{
+		ID:           uuid.New(),
+		Name:         name.MustParse("Test User"),
+		Email:        mail.Address{Address: email},
+		Role:         rl,
+		PasswordHash: []byte("hash"),
+		Enabled:      true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := store.Create(context.Background(), usr); err != nil {
+		t.Fatalf("create user %s: %s", email, err)
+	}
+
+	return usr
+}
+
+// assertActions compares the actions regardless of their order.
+func assertActions(t *testing.T, got []actions.Action, want []actions.Action) {
+	t.Helper()
+
+	strs := func(acts []actions.Action) []string {
+		s := make([]string, len(acts))
+		for i, a := range acts {
+			s[i] = a.String()
+		}
+		slices.Sort(s)
+		return s
+	}
+
+	if g, w := strs(got), strs(want); !slices.Equal(g, w) {
+		t.Errorf("got actions %v, want %v", g, w)
+	}
+}
@@ -0,0 +1,76 @@
+package acldb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+)
+
+func applyFilter(filter aclbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.ID != nil {
+		data["acl_id"] = filter.ID.String()
+		wc = append(wc, "a.acl_id = :acl_id")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "a.user_id = :user_id")
+	}
+
+	if filter.ResourceID != nil {
+		data["resource_id"] = filter.ResourceID.String()
+		wc = append(wc, "a.resource_id = :resource_id")
+	}
+
+	if filter.ResourceType != nil {
+		data["resource_type"] = filter.ResourceType.String()
+		wc = append(wc, "rt.name = :resource_type")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
+
+func applyHistoryFilter(filter aclbus.HistoryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.ACLID != nil {
+		data["acl_id"] = filter.ACLID.String()
+		wc = append(wc, "acl_id = :acl_id")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.ResourceID != nil {
+		data["resource_id"] = filter.ResourceID.String()
+		wc = append(wc, "resource_id = :resource_id")
+	}
+
+	if filter.ActorID != nil {
+		data["actor_id"] = filter.ActorID.String()
+		wc = append(wc, "actor_id = :actor_id")
+	}
+
+	if filter.StartCreatedAt != nil {
+		data["start_created_at"] = filter.StartCreatedAt.UTC()
+		wc = append(wc, "created_at >= :start_created_at")
+	}
+
+	if filter.EndCreatedAt != nil {
+		data["end_created_at"] = filter.EndCreatedAt.UTC()
+		wc = append(wc, "created_at <= :end_created_at")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,364 @@
+package acldb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/dbarray"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type aclDB struct {
+	ID           uuid.UUID      `db:"acl_id"`
+	UserID       uuid.UUID      `db:"user_id"`
+	ResourceID   uuid.UUID      `db:"resource_id"`
+	ResourceType string         `db:"resource_type"`
+	Actions      dbarray.String `db:"actions"`
+	ExpiresAt    sql.NullTime   `db:"expires_at"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at"`
+}
+
+func toDBACL(bus aclbus.ACL) aclDB {
+	return aclDB{
+		ID:           bus.ID,
+		UserID:       bus.UserID,
+		ResourceID:   bus.ResourceID,
+		ResourceType: bus.ResourceType.String(),
+		Actions:      toDBActions(bus.Actions),
+		ExpiresAt:    toDBNullTime(bus.ExpiresAt),
+		CreatedAt:    bus.CreatedAt.UTC(),
+		UpdatedAt:    bus.UpdatedAt.UTC(),
+	}
+}
+
+func toBusACL(db aclDB) (aclbus.ACL, error) {
+	rt, err := resource.Parse(db.ResourceType)
+	if err != nil {
+		return aclbus.ACL{}, fmt.Errorf("parse resource type: %w", err)
+	}
+
+	acts, err := toBusActions(db.Actions)
+	if err != nil {
+		return aclbus.ACL{}, err
+	}
+
+	bus := aclbus.ACL{
+		ID:           db.ID,
+		UserID:       db.UserID,
+		ResourceID:   db.ResourceID,
+		ResourceType: rt,
+		Actions:      acts,
+		ExpiresAt:    toBusNullTime(db.ExpiresAt),
+		CreatedAt:    db.CreatedAt.In(time.Local),
+		UpdatedAt:    db.UpdatedAt.In(time.Local),
+	}
+
+	return bus, nil
+}
+
+func toBusACLs(dbs []aclDB) ([]aclbus.ACL, error) {
+	bus := make([]aclbus.ACL, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusACL(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+type accessDB struct {
+	Role         string         `db:"role"`
+	ResourceType string         `db:"resource_type"`
+	RoleActions  dbarray.String `db:"role_actions"`
+	ACLActions   dbarray.String `db:"acl_actions"`
+}
+
+// accessBatchDB is a row of QueryAccessBatch, the access to one of the
+// resources asked.
+type accessBatchDB struct {
+	ResourceID uuid.UUID `db:"resource_id"`
+	accessDB
+}
+
+func toBusAccessInfo(db accessDB) (aclbus.AccessInfo, error) {
+	r, err := role.Parse(db.Role)
+	if err != nil {
+		return aclbus.AccessInfo{}, fmt.Errorf("parse role: %w", err)
+	}
+
+	rt, err := resource.Parse(db.ResourceType)
+	if err != nil {
+		return aclbus.AccessInfo{}, fmt.Errorf("parse resource type: %w", err)
+	}
+
+	roleActs, err := toBusActions(db.RoleActions)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	aclActs, err := toBusActions(db.ACLActions)
+	if err != nil {
+		return aclbus.AccessInfo{}, err
+	}
+
+	info := aclbus.AccessInfo{
+		Role:         r,
+		ResourceType: rt,
+		RoleActions:  roleActs,
+		ACLActions:   aclActs,
+	}
+
+	return info, nil
+}
+
+// =============================================================================
+
+type policyDB struct {
+	Role         string         `db:"role"`
+	ResourceType string         `db:"resource_type"`
+	Actions      dbarray.String `db:"actions"`
+}
+
+func toBusRolePolicy(dbs []policyDB) (aclbus.RolePolicy, error) {
+	var rp aclbus.RolePolicy
+
+	for i, db := range dbs {
+		if i == 0 {
+			r, err := role.Parse(db.Role)
+			if err != nil {
+				return aclbus.RolePolicy{}, fmt.Errorf("parse role: %w", err)
+			}
+			rp.Role = r
+		}
+
+		rt, err := resource.Parse(db.ResourceType)
+		if err != nil {
+			return aclbus.RolePolicy{}, fmt.Errorf("parse resource type: %w", err)
+		}
+
+		acts, err := toBusActions(db.Actions)
+		if err != nil {
+			return aclbus.RolePolicy{}, err
+		}
+
+		rp.Policies = append(rp.Policies, aclbus.TypePolicy{
+			ResourceType: rt,
+			Actions:      acts,
+		})
+	}
+
+	return rp, nil
+}
+
+func toBusPolicies(dbs []policyDB) ([]aclbus.Policy, error) {
+	bus := make([]aclbus.Policy, len(dbs))
+
+	for i, db := range dbs {
+		r, err := role.Parse(db.Role)
+		if err != nil {
+			return nil, fmt.Errorf("parse role: %w", err)
+		}
+
+		rt, err := resource.Parse(db.ResourceType)
+		if err != nil {
+			return nil, fmt.Errorf("parse resource type: %w", err)
+		}
+
+		acts, err := toBusActions(db.Actions)
+		if err != nil {
+			return nil, err
+		}
+
+		bus[i] = aclbus.Policy{
+			Role:         r,
+			ResourceType: rt,
+			Actions:      acts,
+		}
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+func toDBActions(acts []actions.Action) dbarray.String {
+	db := make(dbarray.String, len(acts))
+	for i, a := range acts {
+		db[i] = a.String()
+	}
+
+	return db
+}
+
+func toBusActions(db dbarray.String) ([]actions.Action, error) {
+	acts := make([]actions.Action, len(db))
+	for i, v := range db {
+		a, err := actions.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("parse action: %w", err)
+		}
+		acts[i] = a
+	}
+
+	return acts, nil
+}
+
+func toDBNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func toBusNullTime(nt sql.NullTime) *time.Time {
+	if !nt.Valid {
+		return nil
+	}
+
+	t := nt.Time.In(time.Local)
+	return &t
+}
+
+// =============================================================================
+
+type historyDB struct {
+	ID         uuid.UUID      `db:"history_id"`
+	ACLID      uuid.UUID      `db:"acl_id"`
+	UserID     uuid.UUID      `db:"user_id"`
+	ResourceID uuid.UUID      `db:"resource_id"`
+	ActorID    uuid.UUID      `db:"actor_id"`
+	Operation  string         `db:"operation"`
+	OldActions dbarray.String `db:"old_actions"`
+	NewActions dbarray.String `db:"new_actions"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+func toDBHistory(bus aclbus.History) historyDB {
+	return historyDB{
+		ID:         bus.ID,
+		ACLID:      bus.ACLID,
+		UserID:     bus.UserID,
+		ResourceID: bus.ResourceID,
+		ActorID:    bus.ActorID,
+		Operation:  bus.Operation.String(),
+		OldActions: toDBActions(bus.OldActions),
+		NewActions: toDBActions(bus.NewActions),
+		CreatedAt:  bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusHistory(db historyDB) (aclbus.History, error) {
+	op, err := actions.Parse(db.Operation)
+	if err != nil {
+		return aclbus.History{}, fmt.Errorf("parse operation: %w", err)
+	}
+
+	oldActs, err := toBusActions(db.OldActions)
+	if err != nil {
+		return aclbus.History{}, err
+	}
+
+	newActs, err := toBusActions(db.NewActions)
+	if err != nil {
+		return aclbus.History{}, err
+	}
+
+	bus := aclbus.History{
+		ID:         db.ID,
+		ACLID:      db.ACLID,
+		UserID:     db.UserID,
+		ResourceID: db.ResourceID,
+		ActorID:    db.ActorID,
+		Operation:  op,
+		OldActions: oldActs,
+		NewActions: newActs,
+		CreatedAt:  db.CreatedAt.In(time.Local),
+	}
+
+	return bus, nil
+}
+
+func toBusHistories(dbs []historyDB) ([]aclbus.History, error) {
+	bus := make([]aclbus.History, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusHistory(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+type userAccessDB struct {
+	UserID        uuid.UUID      `db:"user_id"`
+	Name          string         `db:"name"`
+	Email         string         `db:"email"`
+	Role          string         `db:"role"`
+	Enabled       bool           `db:"enabled"`
+	DashboardID   uuid.NullUUID  `db:"dashboard_id"`
+	DashboardName sql.NullString `db:"dashboard_name"`
+	Linked        bool           `db:"linked"`
+	Actions       dbarray.String `db:"actions"`
+	ExpiresAt     sql.NullTime   `db:"expires_at"`
+}
+
+// toBusUserAccess groups the rows, ordered by user, into one entry per user.
+func toBusUserAccess(dbs []userAccessDB) ([]aclbus.UserAccess, error) {
+	bus := make([]aclbus.UserAccess, 0)
+
+	for _, db := range dbs {
+		if len(bus) == 0 || bus[len(bus)-1].UserID != db.UserID {
+			r, err := role.Parse(db.Role)
+			if err != nil {
+				return nil, fmt.Errorf("parse role: %w", err)
+			}
+
+			bus = append(bus, aclbus.UserAccess{
+				UserID:     db.UserID,
+				Name:       db.Name,
+				Email:      db.Email,
+				Role:       r,
+				Enabled:    db.Enabled,
+				Dashboards: []aclbus.DashboardAccess{},
+			})
+		}
+
+		if !db.DashboardID.Valid {
+			continue
+		}
+
+		acts, err := toBusActions(db.Actions)
+		if err != nil {
+			return nil, err
+		}
+
+		ua := &bus[len(bus)-1]
+		ua.Dashboards = append(ua.Dashboards, aclbus.DashboardAccess{
+			DashboardID: db.DashboardID.UUID,
+			Name:        db.DashboardName.String,
+			Linked:      db.Linked,
+			Actions:     acts,
+			ExpiresAt:   toBusNullTime(db.ExpiresAt),
+		})
+	}
+
+	return bus, nil
+}
@@ -0,0 +1,25 @@
+package acldb
+
+import (
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	aclbus.OrderByID:           "a.acl_id",
+	aclbus.OrderByUserID:       "a.user_id",
+	aclbus.OrderByResourceID:   "a.resource_id",
+	aclbus.OrderByResourceType: "rt.name",
+	aclbus.OrderByCreatedAt:    "a.created_at",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,560 @@
+// Package aclmemory contains an in-memory acl store used to exercise the
+// business layer without a database. Users and resources live in other
+// domains, so tests register them with SetUserRole, AddResource and
+// AddTenantMember.
+package aclmemory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/aclbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/actions"
+	"github.com/jcpaschoal/spi-exata/business/types/resource"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// roles and resourceTypes follow the ids in the seed so policies are listed
+// in the same order as the database store.
+var (
+	roles         = []role.Role{role.Admin, role.User, role.Analyst, role.TenantAdmin}
+	resourceTypes = []resource.Resource{resource.Dashboard, resource.Page, resource.Subject}
+)
+
+type resourceInfo struct {
+	resourceType resource.Resource
+	parentID     uuid.UUID
+}
+
+type policyKey struct {
+	role         string
+	resourceType string
+}
+
+// Store manages the set of APIs for acl access kept in memory.
+type Store struct {
+	mu        *sync.RWMutex
+	acls      map[uuid.UUID]aclbus.ACL
+	history   []aclbus.History
+	users     map[uuid.UUID]role.Role
+	resources map[uuid.UUID]resourceInfo
+	policies  map[policyKey][]actions.Action
+	members   map[uuid.UUID][]aclbus.UserAccess
+}
+
+// NewStore constructs an empty store with no role policies.
+func NewStore() *Store {
+	return &Store{
+		mu:        &sync.RWMutex{},
+		acls:      make(map[uuid.UUID]aclbus.ACL),
+		users:     make(map[uuid.UUID]role.Role),
+		resources: make(map[uuid.UUID]resourceInfo),
+		policies:  make(map[policyKey][]actions.Action),
+		members:   make(map[uuid.UUID][]aclbus.UserAccess),
+	}
+}
+
+// NewWithTx returns the same store. Changes are applied immediately and are
+// not undone if the transaction rolls back.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (aclbus.Storer, error) {
+	return s, nil
+}
+
+// SetUserRole registers the user with the specified role.
+func (s *Store) SetUserRole(userID uuid.UUID, r role.Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = r
+}
+
+// AddResource registers a resource instance. A uuid.Nil parentID adds a root
+// resource, like a dashboard.
+func (s *Store) AddResource(resourceID uuid.UUID, resourceType resource.Resource, parentID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resources[resourceID] = resourceInfo{
+		resourceType: resourceType,
+		parentID:     parentID,
+	}
+}
+
+// AddTenantMember registers the user as a member of the tenant. The
+// dashboards of the user access carry the dashboards of the tenant and the
+// links; the actions come from the acls in the store.
+func (s *Store) AddTenantMember(tenantID uuid.UUID, ua aclbus.UserAccess) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members[tenantID] = append(s.members[tenantID], ua)
+}
+
+// Create adds a new acl to the store.
+func (s *Store) Create(ctx context.Context, acl aclbus.ACL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.acls {
+		if other.UserID == acl.UserID && other.ResourceID == acl.ResourceID {
+			return fmt.Errorf("create: %w", aclbus.ErrUniqueACL)
+		}
+	}
+
+	s.acls[acl.ID] = acl
+
+	return nil
+}
+
+// Update replaces the actions of an acl in the store.
+func (s *Store) Update(ctx context.Context, acl aclbus.ACL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, exists := s.acls[acl.ID]
+	if !exists {
+		return nil
+	}
+
+	cur.Actions = acl.Actions
+	cur.UpdatedAt = acl.UpdatedAt
+	s.acls[acl.ID] = cur
+
+	return nil
+}
+
+// Delete removes an acl from the store.
+func (s *Store) Delete(ctx context.Context, acl aclbus.ACL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.acls, acl.ID)
+
+	return nil
+}
+
+// Query retrieves a list of existing acls from the store.
+func (s *Store) Query(ctx context.Context, filter aclbus.QueryFilter, orderBy order.By, page page.Page) ([]aclbus.ACL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	less, err := lessFunc(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	acls := s.filter(filter)
+	sort.SliceStable(acls, func(i, j int) bool {
+		return less(acls[i], acls[j])
+	})
+
+	return paginate(acls, page), nil
+}
+
+// Count returns the total number of acls in the store.
+func (s *Store) Count(ctx context.Context, filter aclbus.QueryFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.filter(filter)), nil
+}
+
+// QueryByID gets the specified acl from the store.
+func (s *Store) QueryByID(ctx context.Context, aclID uuid.UUID) (aclbus.ACL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	acl, exists := s.acls[aclID]
+	if !exists {
+		return aclbus.ACL{}, fmt.Errorf("memory: %w", aclbus.ErrNotFound)
+	}
+
+	return s.withType(acl), nil
+}
+
+// QueryAll retrieves every acl from the store.
+func (s *Store) QueryAll(ctx context.Context) ([]aclbus.ACL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filter(aclbus.QueryFilter{}), nil
+}
+
+// QueryByUser retrieves every acl granted to the specified user.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID) ([]aclbus.ACL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.filter(aclbus.QueryFilter{UserID: &userID}), nil
+}
+
+// QueryRolePolicy retrieves the role of the user and the actions the role
+// grants on each resource type.
+func (s *Store) QueryRolePolicy(ctx context.Context, userID uuid.UUID) (aclbus.RolePolicy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, exists := s.users[userID]
+	if !exists {
+		return aclbus.RolePolicy{}, aclbus.ErrAccessDenied
+	}
+
+	rp := aclbus.RolePolicy{
+		Role:     r,
+		Policies: make([]aclbus.TypePolicy, 0, len(resourceTypes)),
+	}
+
+	for _, rt := range resourceTypes {
+		rp.Policies = append(rp.Policies, aclbus.TypePolicy{
+			ResourceType: rt,
+			Actions:      s.policy(r, rt),
+		})
+	}
+
+	return rp, nil
+}
+
+// DeleteExpired removes the acls that expired before now and returns how
+// many were removed.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int
+	for id, acl := range s.acls {
+		if acl.Expired(now) {
+			delete(s.acls, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// QueryPolicies retrieves the actions of every role for every resource type.
+func (s *Store) QueryPolicies(ctx context.Context) ([]aclbus.Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policies := make([]aclbus.Policy, 0, len(roles)*len(resourceTypes))
+	for _, r := range roles {
+		for _, rt := range resourceTypes {
+			policies = append(policies, aclbus.Policy{
+				Role:         r,
+				ResourceType: rt,
+				Actions:      s.policy(r, rt),
+			})
+		}
+	}
+
+	return policies, nil
+}
+
+// UpsertPolicy replaces the actions of the role for the resource type.
+func (s *Store) UpsertPolicy(ctx context.Context, p aclbus.Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.policies[policyKey{role: p.Role.String(), resourceType: p.ResourceType.String()}] = slices.Clone(p.Actions)
+
+	return nil
+}
+
+// CreateHistory adds an audit record of a change made to an acl.
+func (s *Store) CreateHistory(ctx context.Context, h aclbus.History) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, h)
+
+	return nil
+}
+
+// QueryHistory retrieves the audit trail of acl changes, newest first.
+func (s *Store) QueryHistory(ctx context.Context, filter aclbus.HistoryFilter, page page.Page) ([]aclbus.History, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.filterHistory(filter)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+
+	return paginate(history, page), nil
+}
+
+// CountHistory returns the total number of audit records in the store.
+func (s *Store) CountHistory(ctx context.Context, filter aclbus.HistoryFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.filterHistory(filter)), nil
+}
+
+// SyncUserRole is a no-op since the role is set with SetUserRole.
+func (s *Store) SyncUserRole(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+// QueryResourceType returns the type of the specified resource instance.
+func (s *Store) QueryResourceType(ctx context.Context, resourceID uuid.UUID) (resource.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res, exists := s.resources[resourceID]
+	if !exists {
+		return resource.Resource{}, aclbus.ErrResourceNotFound
+	}
+
+	return res.resourceType, nil
+}
+
+// QueryLineage returns the resource followed by its ancestors, nearest first.
+func (s *Store) QueryLineage(ctx context.Context, resourceID uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lineage(resourceID), nil
+}
+
+// QueryAccess retrieves the role policy and the acl of the user for the
+// specified resource instance.
+func (s *Store) QueryAccess(ctx context.Context, userID uuid.UUID, resourceID uuid.UUID) (aclbus.AccessInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, userExists := s.users[userID]
+	res, resExists := s.resources[resourceID]
+	if !userExists || !resExists {
+		return aclbus.AccessInfo{}, aclbus.ErrResourceNotFound
+	}
+
+	info := aclbus.AccessInfo{
+		Role:         r,
+		ResourceType: res.resourceType,
+		RoleActions:  s.policy(r, res.resourceType),
+		ACLActions:   aclbus.EffectiveActions(s.lineage(resourceID), s.filter(aclbus.QueryFilter{UserID: &userID}), time.Now()),
+	}
+
+	return info, nil
+}
+
+// QueryAccessBatch retrieves the access of the user to each resource.
+// Resources that do not exist are left out of the result.
+func (s *Store) QueryAccessBatch(ctx context.Context, userID uuid.UUID, resourceIDs []uuid.UUID) (map[uuid.UUID]aclbus.AccessInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make(map[uuid.UUID]aclbus.AccessInfo, len(resourceIDs))
+
+	r, exists := s.users[userID]
+	if !exists {
+		return infos, nil
+	}
+
+	acls := s.filter(aclbus.QueryFilter{UserID: &userID})
+	now := time.Now()
+
+	for _, id := range resourceIDs {
+		res, exists := s.resources[id]
+		if !exists {
+			continue
+		}
+
+		infos[id] = aclbus.AccessInfo{
+			Role:         r,
+			ResourceType: res.resourceType,
+			RoleActions:  s.policy(r, res.resourceType),
+			ACLActions:   aclbus.EffectiveActions(s.lineage(id), acls, now),
+		}
+	}
+
+	return infos, nil
+}
+
+// QueryTypeAccess retrieves the role policy of the user for the specified
+// resource type.
+func (s *Store) QueryTypeAccess(ctx context.Context, userID uuid.UUID, resourceType resource.Resource) (aclbus.AccessInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, exists := s.users[userID]
+	if !exists {
+		return aclbus.AccessInfo{}, aclbus.ErrAccessDenied
+	}
+
+	info := aclbus.AccessInfo{
+		Role:         r,
+		ResourceType: resourceType,
+		RoleActions:  s.policy(r, resourceType),
+		ACLActions:   []actions.Action{},
+	}
+
+	return info, nil
+}
+
+// QueryTenantAccess retrieves the access matrix of the tenant, with the
+// members ordered by name.
+func (s *Store) QueryTenantAccess(ctx context.Context, tenantID uuid.UUID) ([]aclbus.UserAccess, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	members := make([]aclbus.UserAccess, len(s.members[tenantID]))
+
+	for i, ua := range s.members[tenantID] {
+		dashboards := make([]aclbus.DashboardAccess, len(ua.Dashboards))
+		for j, da := range ua.Dashboards {
+			da.Actions = nil
+			da.ExpiresAt = nil
+
+			for _, acl := range s.acls {
+				if acl.UserID != ua.UserID || acl.ResourceID != da.DashboardID {
+					continue
+				}
+				if acl.ExpiresAt != nil && !acl.ExpiresAt.After(now) {
+					continue
+				}
+				da.Actions = acl.Actions
+				da.ExpiresAt = acl.ExpiresAt
+			}
+
+			dashboards[j] = da
+		}
+
+		ua.Dashboards = dashboards
+		members[i] = ua
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+
+	return members, nil
+}
+
+// =============================================================================
+
+func (s *Store) policy(r role.Role, rt resource.Resource) []actions.Action {
+	acts, exists := s.policies[policyKey{role: r.String(), resourceType: rt.String()}]
+	if !exists {
+		return []actions.Action{}
+	}
+
+	return slices.Clone(acts)
+}
+
+func (s *Store) lineage(resourceID uuid.UUID) []uuid.UUID {
+	lineage := []uuid.UUID{resourceID}
+
+	for id := resourceID; ; {
+		res, exists := s.resources[id]
+		if !exists || res.parentID == uuid.Nil || slices.Contains(lineage, res.parentID) {
+			return lineage
+		}
+
+		lineage = append(lineage, res.parentID)
+		id = res.parentID
+	}
+}
+
+// withType fills the resource type the database store reads with a join.
+func (s *Store) withType(acl aclbus.ACL) aclbus.ACL {
+	if res, exists := s.resources[acl.ResourceID]; exists {
+		acl.ResourceType = res.resourceType
+	}
+
+	return acl
+}
+
+func (s *Store) filter(filter aclbus.QueryFilter) []aclbus.ACL {
+	acls := make([]aclbus.ACL, 0, len(s.acls))
+
+	for _, acl := range s.acls {
+		acl = s.withType(acl)
+
+		switch {
+		case filter.ID != nil && acl.ID != *filter.ID:
+			continue
+		case filter.UserID != nil && acl.UserID != *filter.UserID:
+			continue
+		case filter.ResourceID != nil && acl.ResourceID != *filter.ResourceID:
+			continue
+		case filter.ResourceType != nil && !acl.ResourceType.Equal(*filter.ResourceType):
+			continue
+		}
+
+		acls = append(acls, acl)
+	}
+
+	return acls
+}
+
+func (s *Store) filterHistory(filter aclbus.HistoryFilter) []aclbus.History {
+	history := make([]aclbus.History, 0, len(s.history))
+
+	for _, h := range s.history {
+		switch {
+		case filter.ACLID != nil && h.ACLID != *filter.ACLID:
+			continue
+		case filter.UserID != nil && h.UserID != *filter.UserID:
+			continue
+		case filter.ResourceID != nil && h.ResourceID != *filter.ResourceID:
+			continue
+		case filter.ActorID != nil && h.ActorID != *filter.ActorID:
+			continue
+		case filter.StartCreatedAt != nil && h.CreatedAt.Before(*filter.StartCreatedAt):
+			continue
+		case filter.EndCreatedAt != nil && h.CreatedAt.After(*filter.EndCreatedAt):
+			continue
+		}
+
+		history = append(history, h)
+	}
+
+	return history
+}
+
+func lessFunc(orderBy order.By) (func(a, b aclbus.ACL) bool, error) {
+	var less func(a, b aclbus.ACL) bool
+
+	switch orderBy.Field {
+	case aclbus.OrderByID:
+		less = func(a, b aclbus.ACL) bool { return a.ID.String() < b.ID.String() }
+	case aclbus.OrderByUserID:
+		less = func(a, b aclbus.ACL) bool { return a.UserID.String() < b.UserID.String() }
+	case aclbus.OrderByResourceID:
+		less = func(a, b aclbus.ACL) bool { return a.ResourceID.String() < b.ResourceID.String() }
+	case aclbus.OrderByResourceType:
+		less = func(a, b aclbus.ACL) bool { return a.ResourceType.String() < b.ResourceType.String() }
+	case aclbus.OrderByCreatedAt:
+		less = func(a, b aclbus.ACL) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return nil, fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	if orderBy.Direction == order.DESC {
+		return func(a, b aclbus.ACL) bool { return less(b, a) }, nil
+	}
+
+	return less, nil
+}
+
+func paginate[T any](items []T, page page.Page) []T {
+	offset := (page.Number() - 1) * page.RowsPerPage()
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := min(offset+page.RowsPerPage(), len(items))
+
+	return items[offset:end]
+}
@@ -0,0 +1,100 @@
+// Package activitybus provides business access to the activity feed, the
+// high-level actions of the users shown to the admins. The low-level record
+// of every state-changing request is kept by the auditbus.
+package activitybus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Retention is how long an activity is kept in the feed.
+const Retention = 90 * 24 * time.Hour
+
+// Storer defines the behavior required by the activitybus to interact with the database.
+type Storer interface {
+	Create(ctx context.Context, a Activity) error
+	Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Activity, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// Core manages the set of APIs for activity access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for activity api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// Record adds an activity to the feed. The feed is informational, so failing
+// to record is logged and never fails the action being recorded.
+func (c *Core) Record(ctx context.Context, na NewActivity) {
+	ctx, span := otel.AddSpan(ctx, "business.activitybus.record")
+	defer span.End()
+
+	if na.UserID == uuid.Nil {
+		return
+	}
+
+	a := Activity{
+		ID:         uuid.New(),
+		UserID:     na.UserID,
+		TenantID:   na.TenantID,
+		Action:     na.Action,
+		EntityType: na.EntityType,
+		EntityID:   na.EntityID,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, a); err != nil {
+		c.log.Error(ctx, "activity", "status", "recording activity", "userID", a.UserID, "action", a.Action, "ERROR", err)
+	}
+}
+
+// Query retrieves a list of activities from the feed, most recent first.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Activity, error) {
+	ctx, span := otel.AddSpan(ctx, "business.activitybus.query")
+	defer span.End()
+
+	activities, err := c.storer.Query(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return activities, nil
+}
+
+// Count returns the total number of activities matching the filter.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.activitybus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
+// PurgeExpired removes the activities older than the retention and returns
+// how many were removed.
+func (c *Core) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.activitybus.purgeExpired")
+	defer span.End()
+
+	n, err := c.storer.DeleteBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return 0, fmt.Errorf("deleteBefore: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,16 @@
+package activitybus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields an activity query can be filtered on.
+type QueryFilter struct {
+	UserID         *uuid.UUID
+	TenantID       *uuid.UUID
+	Action         *string
+	StartCreatedAt *time.Time
+	EndCreatedAt   *time.Time
+}
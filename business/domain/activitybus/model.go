@@ -0,0 +1,45 @@
+package activitybus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of actions recorded in the activity feed.
+const (
+	ActionDashboardViewed = "dashboard.viewed"
+	ActionUserCreated     = "user.created"
+	ActionUserUpdated     = "user.updated"
+	ActionUserRoleChanged = "user.role_changed"
+	ActionUserDeleted     = "user.deleted"
+	ActionUserRestored    = "user.restored"
+)
+
+// Set of entity types an activity can refer to.
+const (
+	EntityDashboard = "dashboard"
+	EntityUser      = "user"
+)
+
+// Activity represents a high-level action taken by a user, e.g. viewing a
+// dashboard or updating another user. TenantID is uuid.Nil when the action
+// was not made on behalf of a tenant.
+type Activity struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TenantID   uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	CreatedAt  time.Time
+}
+
+// NewActivity contains the information needed to record an activity.
+type NewActivity struct {
+	UserID     uuid.UUID
+	TenantID   uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+}
@@ -0,0 +1,122 @@
+// Package activitydb contains activity related CRUD functionality.
+package activitydb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for activity database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new activity into the database.
+func (s *Store) Create(ctx context.Context, a activitybus.Activity) error {
+	const q = `
+	INSERT INTO "public"."user_activity"
+		(activity_id, user_id, tenant_id, action, entity_type, entity_id, created_at)
+	VALUES
+		(:activity_id, :user_id, :tenant_id, :action, :entity_type, :entity_id, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBActivity(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of activities from the database, most recent first.
+func (s *Store) Query(ctx context.Context, filter activitybus.QueryFilter, page page.Page) ([]activitybus.Activity, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		activity_id, user_id, tenant_id, action, entity_type, entity_id, created_at
+	FROM
+		"public"."user_activity"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY created_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbActivities []activityDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbActivities); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusActivities(dbActivities), nil
+}
+
+// Count returns the total number of activities in the DB.
+func (s *Store) Count(ctx context.Context, filter activitybus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."user_activity"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// DeleteBefore removes the activities recorded before the specified time and
+// returns how many were removed.
+func (s *Store) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."user_activity"
+		WHERE
+			created_at < :before
+		RETURNING activity_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,42 @@
+package activitydb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+)
+
+func applyFilter(filter activitybus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.TenantID != nil {
+		data["tenant_id"] = filter.TenantID.String()
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.Action != nil {
+		data["action"] = *filter.Action
+		wc = append(wc, "action = :action")
+	}
+
+	if filter.StartCreatedAt != nil {
+		data["start_created_at"] = filter.StartCreatedAt.UTC()
+		wc = append(wc, "created_at >= :start_created_at")
+	}
+
+	if filter.EndCreatedAt != nil {
+		data["end_created_at"] = filter.EndCreatedAt.UTC()
+		wc = append(wc, "created_at <= :end_created_at")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,52 @@
+package activitydb
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/activitybus"
+)
+
+type activityDB struct {
+	ID         uuid.UUID     `db:"activity_id"`
+	UserID     uuid.UUID     `db:"user_id"`
+	TenantID   uuid.NullUUID `db:"tenant_id"`
+	Action     string        `db:"action"`
+	EntityType string        `db:"entity_type"`
+	EntityID   uuid.NullUUID `db:"entity_id"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+func toDBActivity(bus activitybus.Activity) activityDB {
+	return activityDB{
+		ID:         bus.ID,
+		UserID:     bus.UserID,
+		TenantID:   toDBNullUUID(bus.TenantID),
+		Action:     bus.Action,
+		EntityType: bus.EntityType,
+		EntityID:   toDBNullUUID(bus.EntityID),
+		CreatedAt:  bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusActivities(dbs []activityDB) []activitybus.Activity {
+	bus := make([]activitybus.Activity, len(dbs))
+
+	for i, db := range dbs {
+		bus[i] = activitybus.Activity{
+			ID:         db.ID,
+			UserID:     db.UserID,
+			TenantID:   db.TenantID.UUID,
+			Action:     db.Action,
+			EntityType: db.EntityType,
+			EntityID:   db.EntityID.UUID,
+			CreatedAt:  db.CreatedAt.In(time.Local),
+		}
+	}
+
+	return bus
+}
+
+func toDBNullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
@@ -0,0 +1,212 @@
+// Package announcementbus provides business access to the announcements, the
+// messages the admins publish in the banner of the dashboard, like
+// maintenance windows and new features, targeted to tenants or roles.
+package announcementbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("announcement not found")
+	ErrInvalidSeverity = errors.New("invalid severity")
+	ErrInvalidWindow   = errors.New("announcement must end after it starts")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, a Announcement) error
+	Update(ctx context.Context, a Announcement) error
+	Delete(ctx context.Context, a Announcement) error
+	Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Announcement, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, announcementID uuid.UUID) (Announcement, error)
+	QueryActive(ctx context.Context, now time.Time, tenantID uuid.UUID, r role.Role) ([]Announcement, error)
+}
+
+// Core manages the set of APIs for announcement access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for announcement api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// Create publishes a new announcement.
+func (c *Core) Create(ctx context.Context, na NewAnnouncement) (Announcement, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.create")
+	defer span.End()
+
+	now := time.Now()
+
+	a := Announcement{
+		ID:        uuid.New(),
+		Title:     na.Title,
+		Body:      na.Body,
+		Severity:  na.Severity,
+		TenantIDs: na.TenantIDs,
+		Roles:     na.Roles,
+		StartsAt:  na.StartsAt,
+		EndsAt:    na.EndsAt,
+		CreatedBy: na.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if a.Severity == "" {
+		a.Severity = SeverityInfo
+	}
+
+	if a.StartsAt.IsZero() {
+		a.StartsAt = now
+	}
+
+	if err := validate(a); err != nil {
+		return Announcement{}, err
+	}
+
+	if err := c.storer.Create(ctx, a); err != nil {
+		return Announcement{}, fmt.Errorf("create: %w", err)
+	}
+
+	return a, nil
+}
+
+// Update modifies an announcement.
+func (c *Core) Update(ctx context.Context, a Announcement, ua UpdateAnnouncement) (Announcement, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.update")
+	defer span.End()
+
+	if ua.Title != nil {
+		a.Title = *ua.Title
+	}
+
+	if ua.Body != nil {
+		a.Body = *ua.Body
+	}
+
+	if ua.Severity != nil {
+		a.Severity = *ua.Severity
+	}
+
+	if ua.TenantIDs != nil {
+		a.TenantIDs = *ua.TenantIDs
+	}
+
+	if ua.Roles != nil {
+		a.Roles = *ua.Roles
+	}
+
+	if ua.StartsAt != nil {
+		a.StartsAt = *ua.StartsAt
+	}
+
+	if ua.EndsAt != nil {
+		a.EndsAt = ua.EndsAt
+	}
+
+	if err := validate(a); err != nil {
+		return Announcement{}, err
+	}
+
+	a.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, a); err != nil {
+		return Announcement{}, fmt.Errorf("update: announcementID[%s]: %w", a.ID, err)
+	}
+
+	return a, nil
+}
+
+// Delete removes an announcement.
+func (c *Core) Delete(ctx context.Context, a Announcement) error {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, a); err != nil {
+		return fmt.Errorf("delete: announcementID[%s]: %w", a.ID, err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of announcements, the latest to start first.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Announcement, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.query")
+	defer span.End()
+
+	announcements, err := c.storer.Query(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return announcements, nil
+}
+
+// Count returns the total number of announcements matching the filter.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the announcement by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, announcementID uuid.UUID) (Announcement, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.queryByID")
+	defer span.End()
+
+	a, err := c.storer.QueryByID(ctx, announcementID)
+	if err != nil {
+		return Announcement{}, fmt.Errorf("query: announcementID[%s]: %w", announcementID, err)
+	}
+
+	return a, nil
+}
+
+// QueryActive retrieves the announcements shown now to the users of the
+// tenant with the role, the most severe first. A uuid.Nil tenant only gets
+// the announcements not targeted to tenants.
+func (c *Core) QueryActive(ctx context.Context, tenantID uuid.UUID, r role.Role) ([]Announcement, error) {
+	ctx, span := otel.AddSpan(ctx, "business.announcementbus.queryActive")
+	defer span.End()
+
+	announcements, err := c.storer.QueryActive(ctx, time.Now(), tenantID, r)
+	if err != nil {
+		return nil, fmt.Errorf("queryActive: tenantID[%s]: role[%s]: %w", tenantID, r, err)
+	}
+
+	return announcements, nil
+}
+
+// =============================================================================
+
+func validate(a Announcement) error {
+	if !slices.Contains(severities, a.Severity) {
+		return fmt.Errorf("%w: %q", ErrInvalidSeverity, a.Severity)
+	}
+
+	if a.EndsAt != nil && !a.EndsAt.After(a.StartsAt) {
+		return ErrInvalidWindow
+	}
+
+	return nil
+}
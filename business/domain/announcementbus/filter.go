@@ -0,0 +1,16 @@
+package announcementbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields an announcement query can be
+// filtered on. ActiveAt keeps the announcements shown at that time and
+// TenantID the ones shown to the tenant, including those for everyone.
+type QueryFilter struct {
+	ActiveAt *time.Time
+	TenantID *uuid.UUID
+	Severity *string
+}
@@ -0,0 +1,69 @@
+package announcementbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Set of severities of an announcement. The UI picks the color of the banner
+// from it and shows the critical ones first.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// Announcement represents a message published by the admins in the banner of
+// the dashboard. Empty TenantIDs or Roles target everyone and a nil EndsAt
+// keeps it active until it is ended or removed.
+type Announcement struct {
+	ID        uuid.UUID
+	Title     string
+	Body      string
+	Severity  string
+	TenantIDs []uuid.UUID
+	Roles     []role.Role
+	StartsAt  time.Time
+	EndsAt    *time.Time
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Active reports whether the announcement is shown at now.
+func (a Announcement) Active(now time.Time) bool {
+	if a.StartsAt.After(now) {
+		return false
+	}
+
+	return a.EndsAt == nil || a.EndsAt.After(now)
+}
+
+// NewAnnouncement contains the information needed to publish an announcement.
+type NewAnnouncement struct {
+	Title     string
+	Body      string
+	Severity  string
+	TenantIDs []uuid.UUID
+	Roles     []role.Role
+	StartsAt  time.Time
+	EndsAt    *time.Time
+	CreatedBy uuid.UUID
+}
+
+// UpdateAnnouncement contains the information that can be changed in an
+// announcement. A nil field is left untouched and an empty TenantIDs or Roles
+// targets everyone.
+type UpdateAnnouncement struct {
+	Title     *string
+	Body      *string
+	Severity  *string
+	TenantIDs *[]uuid.UUID
+	Roles     *[]role.Role
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+}
@@ -0,0 +1,199 @@
+// Package announcementdb contains announcement related CRUD functionality.
+package announcementdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxActive caps the announcements shown at once in the banner.
+const maxActive = 20
+
+// Store manages the set of APIs for announcement database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new announcement into the database.
+func (s *Store) Create(ctx context.Context, a announcementbus.Announcement) error {
+	const q = `
+	INSERT INTO "public"."announcement"
+		(announcement_id, title, body, severity, tenant_ids, roles, starts_at, ends_at, created_by, created_at, updated_at)
+	VALUES
+		(:announcement_id, :title, :body, :severity, :tenant_ids, :roles, :starts_at, :ends_at, :created_by, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAnnouncement(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces an announcement in the database.
+func (s *Store) Update(ctx context.Context, a announcementbus.Announcement) error {
+	const q = `
+	UPDATE
+		"public"."announcement"
+	SET
+		title = :title,
+		body = :body,
+		severity = :severity,
+		tenant_ids = :tenant_ids,
+		roles = :roles,
+		starts_at = :starts_at,
+		ends_at = :ends_at,
+		updated_at = :updated_at
+	WHERE
+		announcement_id = :announcement_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAnnouncement(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an announcement from the database.
+func (s *Store) Delete(ctx context.Context, a announcementbus.Announcement) error {
+	const q = `
+	DELETE FROM
+		"public"."announcement"
+	WHERE
+		announcement_id = :announcement_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAnnouncement(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of announcements from the database, the latest to
+// start first.
+func (s *Store) Query(ctx context.Context, filter announcementbus.QueryFilter, page page.Page) ([]announcementbus.Announcement, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		announcement_id, title, body, severity, tenant_ids, roles, starts_at, ends_at, created_by, created_at, updated_at
+	FROM
+		"public"."announcement"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY starts_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbAnnouncements []announcementDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbAnnouncements); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusAnnouncements(dbAnnouncements)
+}
+
+// Count returns the total number of announcements in the DB.
+func (s *Store) Count(ctx context.Context, filter announcementbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."announcement"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified announcement from the database.
+func (s *Store) QueryByID(ctx context.Context, announcementID uuid.UUID) (announcementbus.Announcement, error) {
+	data := struct {
+		ID string `db:"announcement_id"`
+	}{
+		ID: announcementID.String(),
+	}
+
+	const q = `
+	SELECT
+		announcement_id, title, body, severity, tenant_ids, roles, starts_at, ends_at, created_by, created_at, updated_at
+	FROM
+		"public"."announcement"
+	WHERE
+		announcement_id = :announcement_id`
+
+	var dbAnnouncement announcementDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAnnouncement); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return announcementbus.Announcement{}, fmt.Errorf("db: %w", announcementbus.ErrNotFound)
+		}
+		return announcementbus.Announcement{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAnnouncement(dbAnnouncement)
+}
+
+// QueryActive retrieves the announcements shown at now to the users of the
+// tenant with the role, the most severe first.
+func (s *Store) QueryActive(ctx context.Context, now time.Time, tenantID uuid.UUID, r role.Role) ([]announcementbus.Announcement, error) {
+	data := map[string]any{
+		"role":      r.String(),
+		"max_items": maxActive,
+	}
+
+	filter := announcementbus.QueryFilter{
+		ActiveAt: &now,
+		TenantID: &tenantID,
+	}
+
+	const q = `
+	SELECT
+		announcement_id, title, body, severity, tenant_ids, roles, starts_at, ends_at, created_by, created_at, updated_at
+	FROM
+		"public"."announcement"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" AND (cardinality(roles) = 0 OR CAST(:role AS varchar) = ANY(roles))")
+	buf.WriteString(" ORDER BY CASE severity WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END, starts_at DESC")
+	buf.WriteString(" LIMIT :max_items")
+
+	var dbAnnouncements []announcementDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbAnnouncements); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusAnnouncements(dbAnnouncements)
+}
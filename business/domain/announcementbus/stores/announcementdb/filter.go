@@ -0,0 +1,38 @@
+package announcementdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+)
+
+func applyFilter(filter announcementbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.ActiveAt != nil {
+		data["active_at"] = filter.ActiveAt.UTC()
+		wc = append(wc, "starts_at <= :active_at AND (ends_at IS NULL OR ends_at > :active_at)")
+	}
+
+	if filter.TenantID != nil {
+		switch *filter.TenantID {
+		case uuid.Nil:
+			wc = append(wc, "cardinality(tenant_ids) = 0")
+		default:
+			data["tenant_id"] = filter.TenantID.String()
+			wc = append(wc, "(cardinality(tenant_ids) = 0 OR CAST(:tenant_id AS uuid) = ANY(tenant_ids))")
+		}
+	}
+
+	if filter.Severity != nil {
+		data["severity"] = *filter.Severity
+		wc = append(wc, "severity = :severity")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,111 @@
+package announcementdb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/announcementbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/dbarray"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type announcementDB struct {
+	ID        uuid.UUID      `db:"announcement_id"`
+	Title     string         `db:"title"`
+	Body      string         `db:"body"`
+	Severity  string         `db:"severity"`
+	TenantIDs dbarray.String `db:"tenant_ids"`
+	Roles     dbarray.String `db:"roles"`
+	StartsAt  time.Time      `db:"starts_at"`
+	EndsAt    sql.NullTime   `db:"ends_at"`
+	CreatedBy uuid.NullUUID  `db:"created_by"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+func toDBAnnouncement(bus announcementbus.Announcement) announcementDB {
+	tenantIDs := make(dbarray.String, len(bus.TenantIDs))
+	for i, id := range bus.TenantIDs {
+		tenantIDs[i] = id.String()
+	}
+
+	roles := make(dbarray.String, len(bus.Roles))
+	for i, r := range bus.Roles {
+		roles[i] = r.String()
+	}
+
+	db := announcementDB{
+		ID:        bus.ID,
+		Title:     bus.Title,
+		Body:      bus.Body,
+		Severity:  bus.Severity,
+		TenantIDs: tenantIDs,
+		Roles:     roles,
+		StartsAt:  bus.StartsAt.UTC(),
+		CreatedBy: uuid.NullUUID{UUID: bus.CreatedBy, Valid: bus.CreatedBy != uuid.Nil},
+		CreatedAt: bus.CreatedAt.UTC(),
+		UpdatedAt: bus.UpdatedAt.UTC(),
+	}
+
+	if bus.EndsAt != nil {
+		db.EndsAt = sql.NullTime{Time: bus.EndsAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusAnnouncement(db announcementDB) (announcementbus.Announcement, error) {
+	tenantIDs := make([]uuid.UUID, len(db.TenantIDs))
+	for i, v := range db.TenantIDs {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return announcementbus.Announcement{}, fmt.Errorf("parse tenant id: %w", err)
+		}
+		tenantIDs[i] = id
+	}
+
+	roles := make([]role.Role, len(db.Roles))
+	for i, v := range db.Roles {
+		r, err := role.Parse(v)
+		if err != nil {
+			return announcementbus.Announcement{}, fmt.Errorf("parse role: %w", err)
+		}
+		roles[i] = r
+	}
+
+	bus := announcementbus.Announcement{
+		ID:        db.ID,
+		Title:     db.Title,
+		Body:      db.Body,
+		Severity:  db.Severity,
+		TenantIDs: tenantIDs,
+		Roles:     roles,
+		StartsAt:  db.StartsAt.In(time.Local),
+		CreatedBy: db.CreatedBy.UUID,
+		CreatedAt: db.CreatedAt.In(time.Local),
+		UpdatedAt: db.UpdatedAt.In(time.Local),
+	}
+
+	if db.EndsAt.Valid {
+		t := db.EndsAt.Time.In(time.Local)
+		bus.EndsAt = &t
+	}
+
+	return bus, nil
+}
+
+func toBusAnnouncements(dbs []announcementDB) ([]announcementbus.Announcement, error) {
+	bus := make([]announcementbus.Announcement, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusAnnouncement(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
@@ -0,0 +1,87 @@
+// Package auditbus provides business access to the audit trail of the
+// state-changing requests made against the API.
+package auditbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Storer defines the behavior required by the auditbus to interact with the database.
+type Storer interface {
+	Create(ctx context.Context, a Audit) error
+	CreateMany(ctx context.Context, as []Audit) error
+}
+
+// Core manages the set of APIs for audit access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for audit api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// Create records a new audit entry.
+func (c *Core) Create(ctx context.Context, na NewAudit) (Audit, error) {
+	ctx, span := otel.AddSpan(ctx, "business.auditbus.create")
+	defer span.End()
+
+	a := Audit{
+		ID:        uuid.New(),
+		ActorID:   na.ActorID,
+		TenantID:  na.TenantID,
+		IP:        na.IP,
+		Method:    na.Method,
+		Route:     na.Route,
+		Status:    na.Status,
+		Body:      na.Body,
+		CreatedAt: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, a); err != nil {
+		return Audit{}, fmt.Errorf("create: %w", err)
+	}
+
+	return a, nil
+}
+
+// CreateMany records a batch of audit entries at once, e.g. the changes made
+// by a bulk operation. Either every entry is recorded or none is.
+func (c *Core) CreateMany(ctx context.Context, nas []NewAudit) ([]Audit, error) {
+	ctx, span := otel.AddSpan(ctx, "business.auditbus.createMany")
+	defer span.End()
+
+	now := time.Now()
+
+	as := make([]Audit, len(nas))
+	for i, na := range nas {
+		as[i] = Audit{
+			ID:        uuid.New(),
+			ActorID:   na.ActorID,
+			TenantID:  na.TenantID,
+			IP:        na.IP,
+			Method:    na.Method,
+			Route:     na.Route,
+			Status:    na.Status,
+			Body:      na.Body,
+			CreatedAt: now,
+		}
+	}
+
+	if err := c.storer.CreateMany(ctx, as); err != nil {
+		return nil, fmt.Errorf("createMany: %w", err)
+	}
+
+	return as, nil
+}
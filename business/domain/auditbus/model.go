@@ -0,0 +1,36 @@
+package auditbus
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit represents a state-changing request made against the API. ActorID
+// and TenantID are uuid.Nil when the request was not authenticated or the
+// token carries no tenant. IP is empty when the change did not come from an
+// HTTP client, e.g. the admin CLI.
+type Audit struct {
+	ID        uuid.UUID
+	ActorID   uuid.UUID
+	TenantID  uuid.UUID
+	IP        string
+	Method    string
+	Route     string
+	Status    int
+	Body      json.RawMessage
+	CreatedAt time.Time
+}
+
+// NewAudit contains the information needed to record a request. The body
+// must already be redacted.
+type NewAudit struct {
+	ActorID  uuid.UUID
+	TenantID uuid.UUID
+	IP       string
+	Method   string
+	Route    string
+	Status   int
+	Body     json.RawMessage
+}
@@ -0,0 +1,68 @@
+// Package auditdb contains audit related CRUD functionality.
+package auditdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for audit database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new audit entry into the database.
+func (s *Store) Create(ctx context.Context, a auditbus.Audit) error {
+	const q = `
+	INSERT INTO "public"."audit_log"
+		(audit_id, actor_id, tenant_id, ip, method, route, status, body, created_at)
+	VALUES
+		(:audit_id, :actor_id, :tenant_id, :ip, :method, :route, :status, CAST(:body AS jsonb), :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAudit(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany inserts the audit entries with COPY, in a single transaction.
+func (s *Store) CreateMany(ctx context.Context, as []auditbus.Audit) error {
+	columns := []string{"audit_id", "actor_id", "tenant_id", "ip", "method", "route", "status", "body", "created_at"}
+
+	rows := make([][]any, len(as))
+	for i, a := range as {
+		dbAudit := toDBAudit(a)
+		rows[i] = []any{
+			dbAudit.ID,
+			dbAudit.ActorID,
+			dbAudit.TenantID,
+			dbAudit.IP,
+			dbAudit.Method,
+			dbAudit.Route,
+			dbAudit.Status,
+			dbAudit.Body,
+			dbAudit.CreatedAt,
+		}
+	}
+
+	if _, err := sqldb.CopyFrom(ctx, s.log, s.db, "public.audit_log", columns, rows); err != nil {
+		return fmt.Errorf("copyfrom: %w", err)
+	}
+
+	return nil
+}
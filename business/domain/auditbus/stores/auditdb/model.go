@@ -0,0 +1,39 @@
+package auditdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/auditbus"
+)
+
+type auditDB struct {
+	ID        uuid.UUID      `db:"audit_id"`
+	ActorID   uuid.NullUUID  `db:"actor_id"`
+	TenantID  uuid.NullUUID  `db:"tenant_id"`
+	IP        sql.NullString `db:"ip"`
+	Method    string         `db:"method"`
+	Route     string         `db:"route"`
+	Status    int            `db:"status"`
+	Body      sql.NullString `db:"body"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func toDBAudit(bus auditbus.Audit) auditDB {
+	return auditDB{
+		ID:        bus.ID,
+		ActorID:   toDBNullUUID(bus.ActorID),
+		TenantID:  toDBNullUUID(bus.TenantID),
+		IP:        sql.NullString{String: bus.IP, Valid: bus.IP != ""},
+		Method:    bus.Method,
+		Route:     bus.Route,
+		Status:    bus.Status,
+		Body:      sql.NullString{String: string(bus.Body), Valid: len(bus.Body) > 0},
+		CreatedAt: bus.CreatedAt.UTC(),
+	}
+}
+
+func toDBNullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
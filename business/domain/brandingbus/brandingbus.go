@@ -0,0 +1,169 @@
+// Package brandingbus provides business access to the branding of the
+// tenants: the logo and colors of the login page and the header and footer
+// of the emails.
+package brandingbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("branding not found")
+	ErrInvalidSettings = errors.New("invalid branding settings")
+)
+
+// MaxLogoSize is the largest logo accepted, in bytes.
+const MaxLogoSize = 1 << 20
+
+// logoTypes are the content types accepted for the logo. SVG is left out
+// because it can carry scripts and the logo is served without
+// authentication.
+var logoTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+var colorRE = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Save(ctx context.Context, b Branding) error
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+	QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Branding, error)
+}
+
+// Core manages the set of APIs for branding access.
+type Core struct {
+	log       *logger.Logger
+	storer    Storer
+	tenantBus *tenantbus.Core
+}
+
+// NewCore constructs a core for branding api access.
+func NewCore(log *logger.Logger, storer Storer, tenantBus *tenantbus.Core) *Core {
+	return &Core{
+		log:       log,
+		storer:    storer,
+		tenantBus: tenantBus,
+	}
+}
+
+// Save creates or replaces the branding of the tenant.
+func (c *Core) Save(ctx context.Context, tenantID uuid.UUID, sb SaveBranding) (Branding, error) {
+	ctx, span := otel.AddSpan(ctx, "business.brandingbus.save")
+	defer span.End()
+
+	sb.PrimaryColor = strings.ToLower(sb.PrimaryColor)
+	sb.SecondaryColor = strings.ToLower(sb.SecondaryColor)
+
+	if err := validate(sb); err != nil {
+		return Branding{}, err
+	}
+
+	now := time.Now()
+
+	b, err := c.storer.QueryByTenant(ctx, tenantID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		b = Branding{
+			TenantID:  tenantID,
+			CreatedAt: now,
+		}
+	case err != nil:
+		return Branding{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	if sb.Logo != nil {
+		b.Logo = sb.Logo
+		b.LogoContentType = http.DetectContentType(sb.Logo)
+	}
+
+	b.PrimaryColor = sb.PrimaryColor
+	b.SecondaryColor = sb.SecondaryColor
+	b.EmailHeader = sb.EmailHeader
+	b.EmailFooter = sb.EmailFooter
+	b.UpdatedAt = now
+
+	if err := c.storer.Save(ctx, b); err != nil {
+		return Branding{}, fmt.Errorf("save: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return b, nil
+}
+
+// Delete removes the branding of the tenant, which goes back to the default
+// look.
+func (c *Core) Delete(ctx context.Context, b Branding) error {
+	ctx, span := otel.AddSpan(ctx, "business.brandingbus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, b.TenantID); err != nil {
+		return fmt.Errorf("delete: tenantID[%s]: %w", b.TenantID, err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the branding of the tenant.
+func (c *Core) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Branding, error) {
+	ctx, span := otel.AddSpan(ctx, "business.brandingbus.queryByTenant")
+	defer span.End()
+
+	b, err := c.storer.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return Branding{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return b, nil
+}
+
+// QueryByDomain gets the branding of the tenant the domain belongs to.
+func (c *Core) QueryByDomain(ctx context.Context, domain string) (Branding, error) {
+	ctx, span := otel.AddSpan(ctx, "business.brandingbus.queryByDomain")
+	defer span.End()
+
+	td, err := c.tenantBus.ResolveDomain(ctx, domain)
+	if err != nil {
+		return Branding{}, fmt.Errorf("resolve domain[%s]: %w", domain, err)
+	}
+
+	b, err := c.storer.QueryByTenant(ctx, td.TenantID)
+	if err != nil {
+		return Branding{}, fmt.Errorf("query: tenantID[%s]: %w", td.TenantID, err)
+	}
+
+	return b, nil
+}
+
+// =============================================================================
+
+func validate(sb SaveBranding) error {
+	if !colorRE.MatchString(sb.PrimaryColor) || !colorRE.MatchString(sb.SecondaryColor) {
+		return fmt.Errorf("%w: colors must be in the #rrggbb form", ErrInvalidSettings)
+	}
+
+	if sb.Logo == nil {
+		return nil
+	}
+
+	if len(sb.Logo) == 0 || len(sb.Logo) > MaxLogoSize {
+		return fmt.Errorf("%w: logo must have up to %d bytes", ErrInvalidSettings, MaxLogoSize)
+	}
+
+	if ct := http.DetectContentType(sb.Logo); !slices.Contains(logoTypes, ct) {
+		return fmt.Errorf("%w: logo of type %s is not accepted", ErrInvalidSettings, ct)
+	}
+
+	return nil
+}
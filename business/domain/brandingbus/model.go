@@ -0,0 +1,32 @@
+package brandingbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Branding represents the visual identity of a tenant. The logo and the
+// colors are shown on the login page, before authentication; the email
+// header and footer wrap the emails sent to the users of the tenant.
+type Branding struct {
+	TenantID        uuid.UUID
+	Logo            []byte
+	LogoContentType string
+	PrimaryColor    string
+	SecondaryColor  string
+	EmailHeader     string
+	EmailFooter     string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// SaveBranding contains information needed to configure the branding of a
+// tenant. A nil logo keeps the current one.
+type SaveBranding struct {
+	Logo           []byte
+	PrimaryColor   string
+	SecondaryColor string
+	EmailHeader    string
+	EmailFooter    string
+}
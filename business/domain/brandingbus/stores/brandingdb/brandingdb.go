@@ -0,0 +1,102 @@
+// Package brandingdb contains branding related CRUD functionality.
+package brandingdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for branding database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Save inserts the branding of the tenant or replaces the current one.
+func (s *Store) Save(ctx context.Context, b brandingbus.Branding) error {
+	const q = `
+	INSERT INTO "public"."tenant_branding"
+		(tenant_id, logo, logo_content_type, primary_color, secondary_color, email_header,
+		 email_footer, created_at, updated_at)
+	VALUES
+		(:tenant_id, :logo, :logo_content_type, :primary_color, :secondary_color, :email_header,
+		 :email_footer, :created_at, :updated_at)
+	ON CONFLICT (tenant_id) DO UPDATE SET
+		logo = EXCLUDED.logo,
+		logo_content_type = EXCLUDED.logo_content_type,
+		primary_color = EXCLUDED.primary_color,
+		secondary_color = EXCLUDED.secondary_color,
+		email_header = EXCLUDED.email_header,
+		email_footer = EXCLUDED.email_footer,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBBranding(b)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the branding of the tenant from the database.
+func (s *Store) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."tenant_branding"
+	WHERE
+		tenant_id = :tenant_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the branding of the tenant from the database.
+func (s *Store) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (brandingbus.Branding, error) {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, logo, logo_content_type, primary_color, secondary_color, email_header,
+		email_footer, created_at, updated_at
+	FROM
+		"public"."tenant_branding"
+	WHERE
+		tenant_id = :tenant_id`
+
+	var dbBranding brandingDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbBranding); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return brandingbus.Branding{}, fmt.Errorf("db: %w", brandingbus.ErrNotFound)
+		}
+		return brandingbus.Branding{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusBranding(dbBranding), nil
+}
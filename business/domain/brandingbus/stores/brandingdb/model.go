@@ -0,0 +1,54 @@
+package brandingdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/brandingbus"
+)
+
+type brandingDB struct {
+	TenantID        uuid.UUID      `db:"tenant_id"`
+	Logo            []byte         `db:"logo"`
+	LogoContentType sql.NullString `db:"logo_content_type"`
+	PrimaryColor    string         `db:"primary_color"`
+	SecondaryColor  string         `db:"secondary_color"`
+	EmailHeader     string         `db:"email_header"`
+	EmailFooter     string         `db:"email_footer"`
+	CreatedAt       time.Time      `db:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at"`
+}
+
+func toDBBranding(bus brandingbus.Branding) brandingDB {
+	var contentType sql.NullString
+	if bus.Logo != nil {
+		contentType = sql.NullString{String: bus.LogoContentType, Valid: true}
+	}
+
+	return brandingDB{
+		TenantID:        bus.TenantID,
+		Logo:            bus.Logo,
+		LogoContentType: contentType,
+		PrimaryColor:    bus.PrimaryColor,
+		SecondaryColor:  bus.SecondaryColor,
+		EmailHeader:     bus.EmailHeader,
+		EmailFooter:     bus.EmailFooter,
+		CreatedAt:       bus.CreatedAt.UTC(),
+		UpdatedAt:       bus.UpdatedAt.UTC(),
+	}
+}
+
+func toBusBranding(db brandingDB) brandingbus.Branding {
+	return brandingbus.Branding{
+		TenantID:        db.TenantID,
+		Logo:            db.Logo,
+		LogoContentType: db.LogoContentType.String,
+		PrimaryColor:    db.PrimaryColor,
+		SecondaryColor:  db.SecondaryColor,
+		EmailHeader:     db.EmailHeader,
+		EmailFooter:     db.EmailFooter,
+		CreatedAt:       db.CreatedAt.In(time.Local),
+		UpdatedAt:       db.UpdatedAt.In(time.Local),
+	}
+}
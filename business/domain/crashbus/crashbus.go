@@ -0,0 +1,74 @@
+// Package crashbus provides business access to the reports of the panics
+// recovered while handling requests.
+package crashbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Storer defines the behavior required by the crashbus to interact with the database.
+type Storer interface {
+	Create(ctx context.Context, r Report) error
+	QueryRecent(ctx context.Context, limit int) ([]Report, error)
+}
+
+// Core manages the set of APIs for crash report access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for crash report api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// Create records a new crash report.
+func (c *Core) Create(ctx context.Context, nr NewReport) (Report, error) {
+	ctx, span := otel.AddSpan(ctx, "business.crashbus.create")
+	defer span.End()
+
+	r := Report{
+		ID:        uuid.New(),
+		TraceID:   nr.TraceID,
+		UserID:    nr.UserID,
+		TenantID:  nr.TenantID,
+		IP:        nr.IP,
+		Method:    nr.Method,
+		Route:     nr.Route,
+		Headers:   nr.Headers,
+		BodyHash:  nr.BodyHash,
+		BodySize:  nr.BodySize,
+		Panic:     nr.Panic,
+		Stack:     nr.Stack,
+		CreatedAt: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, r); err != nil {
+		return Report{}, fmt.Errorf("create: %w", err)
+	}
+
+	return r, nil
+}
+
+// QueryRecent retrieves the latest crash reports, newest first.
+func (c *Core) QueryRecent(ctx context.Context, limit int) ([]Report, error) {
+	ctx, span := otel.AddSpan(ctx, "business.crashbus.queryrecent")
+	defer span.End()
+
+	reports, err := c.storer.QueryRecent(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return reports, nil
+}
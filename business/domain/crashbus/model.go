@@ -0,0 +1,42 @@
+package crashbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report represents a panic recovered while handling a request. UserID and
+// TenantID are uuid.Nil when the request was not authenticated or the token
+// carries no tenant. The body is never kept, only its SHA-256 hash.
+type Report struct {
+	ID        uuid.UUID
+	TraceID   string
+	UserID    uuid.UUID
+	TenantID  uuid.UUID
+	IP        string
+	Method    string
+	Route     string
+	Headers   map[string]string
+	BodyHash  string
+	BodySize  int64
+	Panic     string
+	Stack     string
+	CreatedAt time.Time
+}
+
+// NewReport contains the information needed to record a panic. The headers
+// must already be sanitized.
+type NewReport struct {
+	TraceID  string
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+	IP       string
+	Method   string
+	Route    string
+	Headers  map[string]string
+	BodyHash string
+	BodySize int64
+	Panic    string
+	Stack    string
+}
@@ -0,0 +1,64 @@
+// Package crashdb contains crash report related CRUD functionality.
+package crashdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for crash report database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new crash report into the database.
+func (s *Store) Create(ctx context.Context, r crashbus.Report) error {
+	const q = `
+	INSERT INTO "public"."crash_reports"
+		(crash_id, trace_id, user_id, tenant_id, ip, method, route, headers, body_hash, body_size, panic, stack, created_at)
+	VALUES
+		(:crash_id, :trace_id, :user_id, :tenant_id, :ip, :method, :route, CAST(:headers AS jsonb), :body_hash, :body_size, :panic, :stack, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBReport(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRecent retrieves the latest crash reports, newest first.
+func (s *Store) QueryRecent(ctx context.Context, limit int) ([]crashbus.Report, error) {
+	data := map[string]any{
+		"limit": limit,
+	}
+
+	const q = `
+	SELECT
+		crash_id, trace_id, user_id, tenant_id, ip, method, route, headers, body_hash, body_size, panic, stack, created_at
+	FROM
+		"public"."crash_reports"
+	ORDER BY
+		created_at DESC
+	LIMIT :limit`
+
+	var dbReports []reportDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbReports); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusReports(dbReports), nil
+}
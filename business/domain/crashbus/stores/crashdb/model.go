@@ -0,0 +1,87 @@
+package crashdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/crashbus"
+)
+
+type reportDB struct {
+	ID        uuid.UUID      `db:"crash_id"`
+	TraceID   sql.NullString `db:"trace_id"`
+	UserID    uuid.NullUUID  `db:"user_id"`
+	TenantID  uuid.NullUUID  `db:"tenant_id"`
+	IP        sql.NullString `db:"ip"`
+	Method    string         `db:"method"`
+	Route     string         `db:"route"`
+	Headers   sql.NullString `db:"headers"`
+	BodyHash  sql.NullString `db:"body_hash"`
+	BodySize  int64          `db:"body_size"`
+	Panic     string         `db:"panic"`
+	Stack     string         `db:"stack"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func toDBReport(bus crashbus.Report) reportDB {
+	var headers sql.NullString
+	if len(bus.Headers) > 0 {
+		if data, err := json.Marshal(bus.Headers); err == nil {
+			headers = sql.NullString{String: string(data), Valid: true}
+		}
+	}
+
+	return reportDB{
+		ID:        bus.ID,
+		TraceID:   sql.NullString{String: bus.TraceID, Valid: bus.TraceID != ""},
+		UserID:    toDBNullUUID(bus.UserID),
+		TenantID:  toDBNullUUID(bus.TenantID),
+		IP:        sql.NullString{String: bus.IP, Valid: bus.IP != ""},
+		Method:    bus.Method,
+		Route:     bus.Route,
+		Headers:   headers,
+		BodyHash:  sql.NullString{String: bus.BodyHash, Valid: bus.BodyHash != ""},
+		BodySize:  bus.BodySize,
+		Panic:     bus.Panic,
+		Stack:     bus.Stack,
+		CreatedAt: bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusReport(db reportDB) crashbus.Report {
+	var headers map[string]string
+	if db.Headers.Valid {
+		json.Unmarshal([]byte(db.Headers.String), &headers)
+	}
+
+	return crashbus.Report{
+		ID:        db.ID,
+		TraceID:   db.TraceID.String,
+		UserID:    db.UserID.UUID,
+		TenantID:  db.TenantID.UUID,
+		IP:        db.IP.String,
+		Method:    db.Method,
+		Route:     db.Route,
+		Headers:   headers,
+		BodyHash:  db.BodyHash.String,
+		BodySize:  db.BodySize,
+		Panic:     db.Panic,
+		Stack:     db.Stack,
+		CreatedAt: db.CreatedAt.In(time.Local),
+	}
+}
+
+func toBusReports(dbs []reportDB) []crashbus.Report {
+	reports := make([]crashbus.Report, len(dbs))
+	for i, db := range dbs {
+		reports[i] = toBusReport(db)
+	}
+
+	return reports
+}
+
+func toDBNullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
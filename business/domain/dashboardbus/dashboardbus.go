@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,15 +13,26 @@ import (
 	"github.com/jcpaschoal/spi-exata/foundation/otel"
 )
 
-// ErrNotFound is returned when a dashboard is not found.
-var ErrNotFound = errors.New("dashboard not found")
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound       = errors.New("dashboard not found")
+	ErrDomainTaken    = errors.New("domain is already in use")
+	ErrDomainNotFound = errors.New("domain is not an alias of the dashboard")
+	ErrPrimaryDomain  = errors.New("primary domain can't be removed")
+	ErrConflict       = errors.New("dashboard was changed by another request")
+)
 
 // Storer defines the behavior required by the dashboardbus to interact with the database.
 type Storer interface {
 	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
 	Create(ctx context.Context, d Dashboard) (Dashboard, error)
 	QueryByID(ctx context.Context, dashboardID uuid.UUID) (Dashboard, error)
-	Update(ctx context.Context, d Dashboard) error
+	QueryIDByDomain(ctx context.Context, domain string) (uuid.UUID, error)
+	Update(ctx context.Context, d Dashboard, version *time.Time) error
+	QueryPages(ctx context.Context, dashboardID uuid.UUID) ([]Page, error)
+	QueryAliases(ctx context.Context, dashboardID uuid.UUID) ([]Domain, error)
+	CreateAlias(ctx context.Context, dm Domain) error
+	DeleteAlias(ctx context.Context, dm Domain) error
 }
 
 // Core manages the set of APIs for dashboard access.
@@ -63,7 +75,7 @@ func (c *Core) Create(ctx context.Context, nd NewDashboard) (Dashboard, error) {
 	d := Dashboard{
 		TenantID:  nd.TenantID,
 		Name:      nd.Name,
-		Domain:    nd.Domain,
+		Domain:    normalizeDomain(nd.Domain),
 		Logo:      nd.Logo,
 		CreatedAt: now,
 		UpdatedAt: now,
@@ -90,7 +102,43 @@ func (c *Core) QueryByID(ctx context.Context, dashboardID uuid.UUID) (Dashboard,
 	return dashboard, nil
 }
 
-// Update modifies data about a dashboard.
+// QueryByDomain finds the dashboard published under the domain, as its
+// primary domain or as an alias.
+func (c *Core) QueryByDomain(ctx context.Context, domain string) (Dashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.queryByDomain")
+	defer span.End()
+
+	domain = strings.ToLower(domain)
+
+	dashboardID, err := c.storer.QueryIDByDomain(ctx, domain)
+	if err != nil {
+		return Dashboard{}, fmt.Errorf("queryIDByDomain: domain[%s]: %w", domain, err)
+	}
+
+	dashboard, err := c.storer.QueryByID(ctx, dashboardID)
+	if err != nil {
+		return Dashboard{}, fmt.Errorf("query: dashboardID[%s]: %w", dashboardID, err)
+	}
+
+	return dashboard, nil
+}
+
+// QueryPages returns the pages of the dashboard in navigation order.
+func (c *Core) QueryPages(ctx context.Context, dashboardID uuid.UUID) ([]Page, error) {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.queryPages")
+	defer span.End()
+
+	pages, err := c.storer.QueryPages(ctx, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("queryPages: dashboardID[%s]: %w", dashboardID, err)
+	}
+
+	return pages, nil
+}
+
+// Update modifies data about a dashboard. With ud.Version set the change
+// only applies to the dashboard as it was at that version, ErrConflict is
+// returned when another request changed it first.
 func (c *Core) Update(ctx context.Context, d Dashboard, ud UpdateDashboard) (Dashboard, error) {
 	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.update")
 	defer span.End()
@@ -100,7 +148,7 @@ func (c *Core) Update(ctx context.Context, d Dashboard, ud UpdateDashboard) (Das
 	}
 
 	if ud.Domain != nil {
-		d.Domain = ud.Domain
+		d.Domain = normalizeDomain(ud.Domain)
 	}
 
 	if ud.Logo != nil {
@@ -109,9 +157,137 @@ func (c *Core) Update(ctx context.Context, d Dashboard, ud UpdateDashboard) (Das
 
 	d.UpdatedAt = time.Now()
 
-	if err := c.storer.Update(ctx, d); err != nil {
+	if err := c.storer.Update(ctx, d, ud.Version); err != nil {
 		return Dashboard{}, fmt.Errorf("update: %w", err)
 	}
 
 	return d, nil
 }
+
+// QueryDomains returns the domains the dashboard is published under, the
+// primary domain first.
+func (c *Core) QueryDomains(ctx context.Context, d Dashboard) ([]Domain, error) {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.queryDomains")
+	defer span.End()
+
+	aliases, err := c.storer.QueryAliases(ctx, d.ID)
+	if err != nil {
+		return nil, fmt.Errorf("queryAliases: dashboardID[%s]: %w", d.ID, err)
+	}
+
+	domains := make([]Domain, 0, len(aliases)+1)
+	if d.Domain != nil {
+		domains = append(domains, Domain{
+			Name:        *d.Domain,
+			DashboardID: d.ID,
+			Primary:     true,
+		})
+	}
+
+	return append(domains, aliases...), nil
+}
+
+// AddDomain publishes the dashboard under one more domain, as an alias of
+// the primary one.
+func (c *Core) AddDomain(ctx context.Context, d Dashboard, domain string) (Domain, error) {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.addDomain")
+	defer span.End()
+
+	domain = strings.ToLower(domain)
+
+	if d.Domain != nil && *d.Domain == domain {
+		return Domain{}, fmt.Errorf("addDomain: domain[%s]: %w", domain, ErrDomainTaken)
+	}
+
+	dm := Domain{
+		Name:        domain,
+		DashboardID: d.ID,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := c.storer.CreateAlias(ctx, dm); err != nil {
+		return Domain{}, fmt.Errorf("createAlias: domain[%s]: %w", domain, err)
+	}
+
+	return dm, nil
+}
+
+// RemoveDomain stops publishing the dashboard under an alias. The primary
+// domain is changed by promoting an alias or updating the dashboard.
+func (c *Core) RemoveDomain(ctx context.Context, d Dashboard, domain string) error {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.removeDomain")
+	defer span.End()
+
+	domain = strings.ToLower(domain)
+
+	if d.Domain != nil && *d.Domain == domain {
+		return fmt.Errorf("removeDomain: domain[%s]: %w", domain, ErrPrimaryDomain)
+	}
+
+	dm := Domain{
+		Name:        domain,
+		DashboardID: d.ID,
+	}
+
+	if err := c.storer.DeleteAlias(ctx, dm); err != nil {
+		return fmt.Errorf("deleteAlias: domain[%s]: %w", domain, err)
+	}
+
+	return nil
+}
+
+// PromoteDomain makes an alias the primary domain of the dashboard. The
+// former primary domain stays as an alias, so the clients still using it
+// keep working until it is removed. It must run inside a transaction.
+func (c *Core) PromoteDomain(ctx context.Context, d Dashboard, domain string) (Dashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.dashboardbus.promoteDomain")
+	defer span.End()
+
+	domain = strings.ToLower(domain)
+
+	if d.Domain != nil && *d.Domain == domain {
+		return d, nil
+	}
+
+	// O alias sai da tabela antes de virar o principal: um domínio não pode
+	// estar nas duas ao mesmo tempo.
+	if err := c.storer.DeleteAlias(ctx, Domain{Name: domain, DashboardID: d.ID}); err != nil {
+		return Dashboard{}, fmt.Errorf("deleteAlias: domain[%s]: %w", domain, err)
+	}
+
+	former := d.Domain
+
+	d.Domain = &domain
+	d.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, d, nil); err != nil {
+		return Dashboard{}, fmt.Errorf("update: %w", err)
+	}
+
+	if former != nil {
+		dm := Domain{
+			Name:        *former,
+			DashboardID: d.ID,
+			CreatedAt:   d.UpdatedAt,
+		}
+
+		if err := c.storer.CreateAlias(ctx, dm); err != nil {
+			return Dashboard{}, fmt.Errorf("createAlias: domain[%s]: %w", *former, err)
+		}
+	}
+
+	return d, nil
+}
+
+// =============================================================================
+
+// normalizeDomain lower cases the domain, the form the requests are resolved
+// with.
+func normalizeDomain(domain *string) *string {
+	if domain == nil {
+		return nil
+	}
+
+	d := strings.ToLower(*domain)
+	return &d
+}
@@ -0,0 +1,182 @@
+package dashboardbus_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboardmemory"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+func newCore(t *testing.T) (*dashboardbus.Core, dashboardbus.Dashboard, dashboardbus.Dashboard) {
+	t.Helper()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	core := dashboardbus.NewCore(log, dashboardmemory.NewStore())
+	ctx := context.Background()
+
+	create := func(domain string) dashboardbus.Dashboard {
+		d, err := core.Create(ctx, dashboardbus.NewDashboard{
+			TenantID: uuid.New(),
+			Name:     name.MustParse("Dashboard"),
+			Domain:   &domain,
+		})
+		if err != nil {
+			t.Fatalf("create %s: %s", domain, err)
+		}
+		return d
+	}
+
+	dash := create("Main.Example.com")
+	other := create("other.example.com")
+
+	if _, err := core.AddDomain(ctx, dash, "old.example.com"); err != nil {
+		t.Fatalf("add domain: %s", err)
+	}
+
+	return core, dash, other
+}
+
+func TestQueryByDomain(t *testing.T) {
+	core, dash, other := newCore(t)
+
+	tests := []struct {
+		name    string
+		domain  string
+		want    uuid.UUID
+		wantErr error
+	}{
+		{name: "primary", domain: "main.example.com", want: dash.ID},
+		{name: "upperCase", domain: "MAIN.example.com", want: dash.ID},
+		{name: "alias", domain: "old.example.com", want: dash.ID},
+		{name: "other", domain: "other.example.com", want: other.ID},
+		{name: "unknown", domain: "unknown.example.com", wantErr: dashboardbus.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := core.QueryByDomain(context.Background(), tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got.ID != tt.want {
+				t.Errorf("got dashboard %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDomain(t *testing.T) {
+	core, dash, _ := newCore(t)
+
+	tests := []struct {
+		name    string
+		domain  string
+		want    string
+		wantErr error
+	}{
+		{name: "ownPrimary", domain: "main.example.com", wantErr: dashboardbus.ErrDomainTaken},
+		{name: "otherPrimary", domain: "other.example.com", wantErr: dashboardbus.ErrDomainTaken},
+		{name: "existingAlias", domain: "OLD.example.com", wantErr: dashboardbus.ErrDomainTaken},
+		{name: "new", domain: "New.Example.com", want: "new.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := core.AddDomain(context.Background(), dash, tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("got domain %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveDomain(t *testing.T) {
+	core, dash, other := newCore(t)
+
+	tests := []struct {
+		name    string
+		dash    dashboardbus.Dashboard
+		domain  string
+		wantErr error
+	}{
+		{name: "primary", dash: dash, domain: "main.example.com", wantErr: dashboardbus.ErrPrimaryDomain},
+		{name: "aliasOfOther", dash: other, domain: "old.example.com", wantErr: dashboardbus.ErrDomainNotFound},
+		{name: "unknown", dash: dash, domain: "unknown.example.com", wantErr: dashboardbus.ErrDomainNotFound},
+		{name: "alias", dash: dash, domain: "Old.Example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := core.RemoveDomain(context.Background(), tt.dash, tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromoteDomain(t *testing.T) {
+	core, dash, _ := newCore(t)
+	ctx := context.Background()
+
+	promoted, err := core.PromoteDomain(ctx, dash, "old.example.com")
+	if err != nil {
+		t.Fatalf("promote: %s", err)
+	}
+
+	domains, err := core.QueryDomains(ctx, promoted)
+	if err != nil {
+		t.Fatalf("query domains: %s", err)
+	}
+
+	want := []dashboardbus.Domain{
+		{Name: "old.example.com", Primary: true},
+		{Name: "main.example.com"},
+	}
+
+	if len(domains) != len(want) {
+		t.Fatalf("got %d domains, want %d", len(domains), len(want))
+	}
+	for i, dm := range domains {
+		if dm.Name != want[i].Name || dm.Primary != want[i].Primary || dm.DashboardID != dash.ID {
+			t.Errorf("got domain %+v at %d, want %+v", dm, i, want[i])
+		}
+	}
+}
+
+func TestUpdateVersion(t *testing.T) {
+	core, dash, _ := newCore(t)
+
+	stale := dash.UpdatedAt.Add(-time.Minute)
+	taken := "other.example.com"
+	renamed := name.MustParse("Renamed")
+
+	tests := []struct {
+		name    string
+		ud      dashboardbus.UpdateDashboard
+		wantErr error
+	}{
+		{name: "stale", ud: dashboardbus.UpdateDashboard{Name: &renamed, Version: &stale}, wantErr: dashboardbus.ErrConflict},
+		{name: "domainTaken", ud: dashboardbus.UpdateDashboard{Domain: &taken}, wantErr: dashboardbus.ErrDomainTaken},
+		{name: "current", ud: dashboardbus.UpdateDashboard{Name: &renamed, Version: &dash.UpdatedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := core.Update(context.Background(), dash, tt.ud)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -18,6 +18,30 @@ type Dashboard struct {
 	UpdatedAt time.Time
 }
 
+// Domain represents a domain the dashboard is published under. The primary
+// domain is the Domain of the dashboard; the others are aliases that resolve
+// to the same dashboard while clients move between domains.
+type Domain struct {
+	Name        string
+	DashboardID uuid.UUID
+	Primary     bool
+	CreatedAt   time.Time
+}
+
+// Page represents a page of a dashboard, listed in the dashboard navigation
+// by Order.
+type Page struct {
+	ID          uuid.UUID
+	DashboardID uuid.UUID
+	Layout      string
+	Title       string
+	Text        *string
+	Order       *int
+	FeedID      *uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
 // NewDashboard contains information needed to create a new dashboard.
 type NewDashboard struct {
 	TenantID uuid.UUID
@@ -31,4 +55,9 @@ type UpdateDashboard struct {
 	Name   *name.Name
 	Domain *string
 	Logo   []byte
+
+	// Version is the UpdatedAt of the dashboard the change was based on.
+	// When set, the update fails with ErrConflict if the dashboard changed
+	// since.
+	Version *time.Time
 }
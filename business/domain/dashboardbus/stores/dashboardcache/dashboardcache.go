@@ -0,0 +1,149 @@
+// Package dashboardcache contains a dashboard cache over a dashboard store.
+// Every page view reads the dashboard and its pages, so both are cached and
+// dropped when the dashboard is updated.
+package dashboardcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cache"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// Store manages the set of APIs for dashboard access cached in a backend.
+type Store struct {
+	log        *logger.Logger
+	storer     dashboardbus.Storer
+	dashboards *cache.Cache[cachedDashboard]
+	pages      *cache.Cache[[]dashboardbus.Page]
+}
+
+// NewStore constructs the api for data access. Cached values expire after
+// ttl.
+func NewStore(log *logger.Logger, storer dashboardbus.Storer, backend cache.Backend, ttl time.Duration) *Store {
+	return &Store{
+		log:        log,
+		storer:     storer,
+		dashboards: cache.New[cachedDashboard](log, backend, "dashboard", ttl),
+		pages:      cache.New[[]dashboardbus.Page](log, backend, "dashboard.pages", ttl),
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the storer with one that
+// is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (dashboardbus.Storer, error) {
+	txStorer, err := s.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log:        s.log,
+		storer:     txStorer,
+		dashboards: s.dashboards,
+		pages:      s.pages,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new dashboard into the database.
+func (s *Store) Create(ctx context.Context, d dashboardbus.Dashboard) (dashboardbus.Dashboard, error) {
+	return s.storer.Create(ctx, d)
+}
+
+// Update replaces a dashboard in the database and drops it from the cache.
+func (s *Store) Update(ctx context.Context, d dashboardbus.Dashboard, version *time.Time) error {
+	if err := s.storer.Update(ctx, d, version); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, d.ID)
+
+	return nil
+}
+
+// QueryByID gets the specified dashboard from the cache or the database.
+func (s *Store) QueryByID(ctx context.Context, dashboardID uuid.UUID) (dashboardbus.Dashboard, error) {
+	cd, _, err := s.dashboards.GetOrFetch(ctx, dashboardID.String(), func(ctx context.Context) (cachedDashboard, error) {
+		d, err := s.storer.QueryByID(ctx, dashboardID)
+		if err != nil {
+			return cachedDashboard{}, err
+		}
+
+		return toCachedDashboard(d), nil
+	}, cache.WithTags(dashboardTag(dashboardID)))
+	if err != nil {
+		return dashboardbus.Dashboard{}, err
+	}
+
+	return toBusDashboard(cd)
+}
+
+// QueryIDByDomain gets the ID of the dashboard published under the domain
+// from the database. Domains move between dashboards, so the lookup is not
+// cached; the dashboard it leads to is.
+func (s *Store) QueryIDByDomain(ctx context.Context, domain string) (uuid.UUID, error) {
+	return s.storer.QueryIDByDomain(ctx, domain)
+}
+
+// QueryPages gets the pages of the dashboard from the cache or the database.
+func (s *Store) QueryPages(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Page, error) {
+	pages, _, err := s.pages.GetOrFetch(ctx, dashboardID.String(), func(ctx context.Context) ([]dashboardbus.Page, error) {
+		return s.storer.QueryPages(ctx, dashboardID)
+	}, cache.WithTags(dashboardTag(dashboardID)))
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// QueryAliases gets the aliases of the dashboard from the database. They
+// are read only when managing the domains, so they are not cached.
+func (s *Store) QueryAliases(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Domain, error) {
+	return s.storer.QueryAliases(ctx, dashboardID)
+}
+
+// CreateAlias inserts an alias of the dashboard into the database.
+func (s *Store) CreateAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	return s.storer.CreateAlias(ctx, dm)
+}
+
+// DeleteAlias removes an alias of the dashboard from the database.
+func (s *Store) DeleteAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	return s.storer.DeleteAlias(ctx, dm)
+}
+
+// Stats returns the runtime statistics of the caches.
+func (s *Store) Stats() map[string]cachestats.Stats {
+	return map[string]cachestats.Stats{
+		"dashboards": s.dashboards.Stats(),
+		"pages":      s.pages.Stats(),
+	}
+}
+
+// =============================================================================
+
+func dashboardTag(dashboardID uuid.UUID) string {
+	return "dashboard:" + dashboardID.String()
+}
+
+// invalidate drops the cached dashboard and pages. Failures are logged, the
+// values still expire with the ttl.
+func (s *Store) invalidate(ctx context.Context, dashboardID uuid.UUID) {
+	tag := dashboardTag(dashboardID)
+
+	if err := s.dashboards.Invalidate(ctx, tag); err != nil {
+		s.log.Error(ctx, "dashboardcache", "status", "invalidate", "dashboardID", dashboardID, "ERROR", err)
+	}
+
+	if err := s.pages.Invalidate(ctx, tag); err != nil {
+		s.log.Error(ctx, "dashboardcache", "status", "invalidate pages", "dashboardID", dashboardID, "ERROR", err)
+	}
+}
@@ -0,0 +1,51 @@
+package dashboardcache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+)
+
+// cachedDashboard is the dashboard as stored in the cache, with the name
+// kept as the plain string.
+type cachedDashboard struct {
+	ID        uuid.UUID `json:"id"`
+	TenantID  uuid.UUID `json:"tenantId"`
+	Name      string    `json:"name"`
+	Domain    *string   `json:"domain"`
+	Logo      []byte    `json:"logo"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func toCachedDashboard(bus dashboardbus.Dashboard) cachedDashboard {
+	return cachedDashboard{
+		ID:        bus.ID,
+		TenantID:  bus.TenantID,
+		Name:      bus.Name.String(),
+		Domain:    bus.Domain,
+		Logo:      bus.Logo,
+		CreatedAt: bus.CreatedAt,
+		UpdatedAt: bus.UpdatedAt,
+	}
+}
+
+func toBusDashboard(cd cachedDashboard) (dashboardbus.Dashboard, error) {
+	n, err := name.Parse(cd.Name)
+	if err != nil {
+		return dashboardbus.Dashboard{}, fmt.Errorf("parse name: %w", err)
+	}
+
+	return dashboardbus.Dashboard{
+		ID:        cd.ID,
+		TenantID:  cd.TenantID,
+		Name:      n,
+		Domain:    cd.Domain,
+		Logo:      cd.Logo,
+		CreatedAt: cd.CreatedAt,
+		UpdatedAt: cd.UpdatedAt,
+	}, nil
+}
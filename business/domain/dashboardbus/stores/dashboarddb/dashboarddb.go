@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
@@ -19,7 +20,7 @@ type Store struct {
 }
 
 // NewStore constructs the api for data access.
-func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
 	return &Store{
 		log: log,
 		db:  db,
@@ -65,6 +66,9 @@ func (s *Store) Create(ctx context.Context, d dashboardbus.Dashboard) (dashboard
 	}
 
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBDashboard(d), &result); err != nil {
+		if isDomainTaken(err) {
+			return dashboardbus.Dashboard{}, fmt.Errorf("namedquerystruct: %w", dashboardbus.ErrDomainTaken)
+		}
 		return dashboardbus.Dashboard{}, fmt.Errorf("namedquerystruct: %w", err)
 	}
 
@@ -99,8 +103,46 @@ func (s *Store) QueryByID(ctx context.Context, dashboardID uuid.UUID) (dashboard
 	return toBusDashboard(dbDash)
 }
 
-// Update replaces a dashboard record in the database.
-func (s *Store) Update(ctx context.Context, d dashboardbus.Dashboard) error {
+// QueryIDByDomain gets the ID of the dashboard published under the domain,
+// as its primary domain or as an alias.
+func (s *Store) QueryIDByDomain(ctx context.Context, domain string) (uuid.UUID, error) {
+	data := struct {
+		Domain string `db:"domain"`
+	}{
+		Domain: domain,
+	}
+
+	const q = `
+	SELECT
+		dashboard_id
+	FROM
+		"public"."dashboard"
+	WHERE
+		domain = :domain
+	UNION ALL
+	SELECT
+		dashboard_id
+	FROM
+		"public"."dashboard_domain"
+	WHERE
+		domain = :domain`
+
+	var dest struct {
+		ID uuid.UUID `db:"dashboard_id"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return uuid.Nil, fmt.Errorf("db: %w", dashboardbus.ErrNotFound)
+		}
+		return uuid.Nil, fmt.Errorf("db: %w", err)
+	}
+
+	return dest.ID, nil
+}
+
+// Update replaces a dashboard record in the database. With a version the
+// row is only replaced while its updated_at is still the version.
+func (s *Store) Update(ctx context.Context, d dashboardbus.Dashboard, version *time.Time) error {
 	const q = `
 	UPDATE
 		"public"."dashboard"
@@ -112,9 +154,150 @@ func (s *Store) Update(ctx context.Context, d dashboardbus.Dashboard) error {
 	WHERE
 		dashboard_id = :dashboard_id`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDashboard(d)); err != nil {
+	if version == nil {
+		if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDashboard(d)); err != nil {
+			if isDomainTaken(err) {
+				return fmt.Errorf("namedexeccontext: %w", dashboardbus.ErrDomainTaken)
+			}
+			return fmt.Errorf("namedexeccontext: %w", err)
+		}
+		return nil
+	}
+
+	// Nenhuma linha retornada é outra requisição que gravou depois da
+	// versão que o cliente leu.
+	const qv = q + ` AND updated_at = :version
+	RETURNING dashboard_id`
+
+	data := struct {
+		dashboardDB
+		Version time.Time `db:"version"`
+	}{
+		dashboardDB: toDBDashboard(d),
+		Version:     *version,
+	}
+
+	var dest struct {
+		ID uuid.UUID `db:"dashboard_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, qv, data, &dest); err != nil {
+		switch {
+		case errors.Is(err, sqldb.ErrDBNotFound):
+			return fmt.Errorf("namedquerystruct: %w", dashboardbus.ErrConflict)
+		case isDomainTaken(err):
+			return fmt.Errorf("namedquerystruct: %w", dashboardbus.ErrDomainTaken)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPages gets the pages of the dashboard from the database, pages
+// without an order are listed last.
+func (s *Store) QueryPages(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Page, error) {
+	data := struct {
+		ID string `db:"dashboard_id"`
+	}{
+		ID: dashboardID.String(),
+	}
+
+	const q = `
+	SELECT
+		p.page_id, p.dashboard_id, l.name AS layout, p.title, p.text, p."order", p.feed_id, p.created_at, p.updated_at
+	FROM
+		"public"."page" AS p
+	JOIN
+		"public"."layout" AS l ON l.layout_id = p.layout_id
+	WHERE
+		p.dashboard_id = :dashboard_id
+	ORDER BY
+		p."order" NULLS LAST, p.created_at`
+
+	var dbPages []pageDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbPages); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusPages(dbPages), nil
+}
+
+// QueryAliases gets the aliases of the dashboard from the database, oldest
+// first.
+func (s *Store) QueryAliases(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Domain, error) {
+	data := struct {
+		ID string `db:"dashboard_id"`
+	}{
+		ID: dashboardID.String(),
+	}
+
+	const q = `
+	SELECT
+		domain, dashboard_id, created_at
+	FROM
+		"public"."dashboard_domain"
+	WHERE
+		dashboard_id = :dashboard_id
+	ORDER BY
+		created_at, domain`
+
+	var dbDomains []domainDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDomains); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDomains(dbDomains), nil
+}
+
+// CreateAlias inserts an alias of the dashboard into the database. The
+// domain must not be in use by any dashboard, as primary or alias.
+func (s *Store) CreateAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	const q = `
+	INSERT INTO "public"."dashboard_domain"
+		(domain, dashboard_id, created_at)
+	VALUES
+		(:domain, :dashboard_id, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDomain(dm)); err != nil {
+		if isDomainTaken(err) {
+			return fmt.Errorf("namedexeccontext: %w", dashboardbus.ErrDomainTaken)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
 	return nil
 }
+
+// DeleteAlias removes an alias of the dashboard from the database.
+func (s *Store) DeleteAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	const q = `
+	DELETE FROM
+		"public"."dashboard_domain"
+	WHERE
+		domain = :domain AND
+		dashboard_id = :dashboard_id
+	RETURNING
+		domain, dashboard_id, created_at`
+
+	var deleted domainDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBDomain(dm), &deleted); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("db: %w", dashboardbus.ErrDomainNotFound)
+		}
+		return fmt.Errorf("db: %w", err)
+	}
+
+	return nil
+}
+
+// isDomainTaken reports whether the error is the violation of the domain
+// uniqueness, which covers the primary domains and the aliases.
+func isDomainTaken(err error) bool {
+	var dupErr sqldb.ErrDBDuplicatedEntry
+	if !errors.As(err, &dupErr) {
+		return false
+	}
+
+	return dupErr.Constraint == "uq_dashboard_domain" || dupErr.Constraint == "pk_dashboard_domain"
+}
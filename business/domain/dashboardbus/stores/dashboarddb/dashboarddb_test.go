@@ -0,0 +1,210 @@
+package dashboarddb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboarddb"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	tenantdb "github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores"
+	"github.com/jcpaschoal/spi-exata/business/sdk/dbtest"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+func Test_Dashboard(t *testing.T) {
+	db := dbtest.New(t, "Test_Dashboard")
+	store := dashboarddb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	now := time.Now()
+
+	tnt := tenantbus.Tenant{
+		ID:        uuid.New(),
+		Name:      name.MustParse("Acme"),
+		Slug:      slug.MustParse("acme"),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tenantdb.NewStore(db.Log, db.DB).Create(ctx, tnt); err != nil {
+		t.Fatalf("create tenant: %s", err)
+	}
+
+	dash := createDashboard(t, store, tnt.ID, "main.example.com")
+	other := createDashboard(t, store, tnt.ID, "other.example.com")
+
+	alias := dashboardbus.Domain{Name: "old.example.com", DashboardID: dash.ID, CreatedAt: now}
+	if err := store.CreateAlias(ctx, alias); err != nil {
+		t.Fatalf("create alias: %s", err)
+	}
+
+	t.Run("queryByID", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			id      uuid.UUID
+			want    dashboardbus.Dashboard
+			wantErr error
+		}{
+			{name: "dash", id: dash.ID, want: dash},
+			{name: "other", id: other.ID, want: other},
+			{name: "unknown", id: uuid.New(), wantErr: dashboardbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryByID(ctx, tt.id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr != nil {
+					return
+				}
+
+				switch {
+				case got.ID != tt.want.ID:
+					t.Errorf("got id %s, want %s", got.ID, tt.want.ID)
+				case got.TenantID != tt.want.TenantID:
+					t.Errorf("got tenant %s, want %s", got.TenantID, tt.want.TenantID)
+				case got.Domain == nil || *got.Domain != *tt.want.Domain:
+					t.Errorf("got domain %v, want %s", got.Domain, *tt.want.Domain)
+				case !got.Name.Equal(tt.want.Name):
+					t.Errorf("got name %s, want %s", got.Name, tt.want.Name)
+				}
+			})
+		}
+	})
+
+	t.Run("queryIDByDomain", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			domain  string
+			want    uuid.UUID
+			wantErr error
+		}{
+			{name: "primary", domain: "main.example.com", want: dash.ID},
+			{name: "alias", domain: "old.example.com", want: dash.ID},
+			{name: "other", domain: "other.example.com", want: other.ID},
+			{name: "unknown", domain: "unknown.example.com", wantErr: dashboardbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryIDByDomain(ctx, tt.domain)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("got dashboard %s, want %s", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("createAlias", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			domain  string
+			wantErr error
+		}{
+			{name: "primaryOfOther", domain: "other.example.com", wantErr: dashboardbus.ErrDomainTaken},
+			{name: "existingAlias", domain: "old.example.com", wantErr: dashboardbus.ErrDomainTaken},
+			{name: "new", domain: "new.example.com"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dm := dashboardbus.Domain{Name: tt.domain, DashboardID: dash.ID, CreatedAt: time.Now()}
+
+				if err := store.CreateAlias(ctx, dm); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("deleteAlias", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			domain      string
+			dashboardID uuid.UUID
+			wantErr     error
+		}{
+			{name: "otherDashboard", domain: "new.example.com", dashboardID: other.ID, wantErr: dashboardbus.ErrDomainNotFound},
+			{name: "primary", domain: "main.example.com", dashboardID: dash.ID, wantErr: dashboardbus.ErrDomainNotFound},
+			{name: "alias", domain: "new.example.com", dashboardID: dash.ID},
+			{name: "deleted", domain: "new.example.com", dashboardID: dash.ID, wantErr: dashboardbus.ErrDomainNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dm := dashboardbus.Domain{Name: tt.domain, DashboardID: tt.dashboardID}
+
+				if err := store.DeleteAlias(ctx, dm); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		current, err := store.QueryByID(ctx, other.ID)
+		if err != nil {
+			t.Fatalf("query: %s", err)
+		}
+
+		stale := current.UpdatedAt.Add(-time.Minute)
+		taken := "old.example.com"
+
+		tests := []struct {
+			name    string
+			domain  *string
+			version *time.Time
+			wantErr error
+		}{
+			{name: "stale", version: &stale, wantErr: dashboardbus.ErrConflict},
+			{name: "domainTaken", domain: &taken, wantErr: dashboardbus.ErrDomainTaken},
+			{name: "current", version: &current.UpdatedAt},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				upd := current
+				upd.UpdatedAt = current.UpdatedAt.Add(time.Second)
+				if tt.domain != nil {
+					upd.Domain = tt.domain
+				}
+
+				if err := store.Update(ctx, upd, tt.version); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
+
+// =============================================================================
+
+func createDashboard(t *testing.T, store *dashboarddb.Store, tenantID uuid.UUID, domain string) dashboardbus.Dashboard {
+	t.Helper()
+
+	// O Postgres guarda microssegundos.
+	now := time.Now().Truncate(time.Microsecond)
+
+	d, err := store.Create(context.Background(), dashboardbus.Dashboard{
+		TenantID:  tenantID,
+		Name:      name.MustParse("Dashboard " + domain[:4]),
+		Domain:    &domain,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("create dashboard %s: %s", domain, err)
+	}
+
+	return d
+}
@@ -58,3 +58,75 @@ func toBusDashboard(db dashboardDB) (dashboardbus.Dashboard, error) {
 		UpdatedAt: db.UpdatedAt.In(time.Local),
 	}, nil
 }
+
+type domainDB struct {
+	Domain      string    `db:"domain"`
+	DashboardID uuid.UUID `db:"dashboard_id"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+func toDBDomain(bus dashboardbus.Domain) domainDB {
+	return domainDB{
+		Domain:      bus.Name,
+		DashboardID: bus.DashboardID,
+		CreatedAt:   bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusDomains(dbs []domainDB) []dashboardbus.Domain {
+	domains := make([]dashboardbus.Domain, len(dbs))
+	for i, db := range dbs {
+		domains[i] = dashboardbus.Domain{
+			Name:        db.Domain,
+			DashboardID: db.DashboardID,
+			CreatedAt:   db.CreatedAt.In(time.Local),
+		}
+	}
+	return domains
+}
+
+type pageDB struct {
+	ID          uuid.UUID      `db:"page_id"`
+	DashboardID uuid.UUID      `db:"dashboard_id"`
+	Layout      string         `db:"layout"`
+	Title       string         `db:"title"`
+	Text        sql.NullString `db:"text"`
+	Order       sql.NullInt16  `db:"order"`
+	FeedID      uuid.NullUUID  `db:"feed_id"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+func toBusPage(db pageDB) dashboardbus.Page {
+	pg := dashboardbus.Page{
+		ID:          db.ID,
+		DashboardID: db.DashboardID,
+		Layout:      db.Layout,
+		Title:       db.Title,
+		CreatedAt:   db.CreatedAt.In(time.Local),
+		UpdatedAt:   db.UpdatedAt.In(time.Local),
+	}
+
+	if db.Text.Valid {
+		pg.Text = &db.Text.String
+	}
+
+	if db.Order.Valid {
+		order := int(db.Order.Int16)
+		pg.Order = &order
+	}
+
+	if db.FeedID.Valid {
+		pg.FeedID = &db.FeedID.UUID
+	}
+
+	return pg
+}
+
+func toBusPages(dbs []pageDB) []dashboardbus.Page {
+	pages := make([]dashboardbus.Page, len(dbs))
+	for i, db := range dbs {
+		pages[i] = toBusPage(db)
+	}
+	return pages
+}
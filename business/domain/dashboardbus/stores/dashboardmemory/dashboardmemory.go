@@ -0,0 +1,184 @@
+// Package dashboardmemory contains an in-memory dashboard store used to
+// exercise the business layer without a database.
+package dashboardmemory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+)
+
+// Store manages the set of APIs for dashboard access kept in memory.
+type Store struct {
+	mu         *sync.RWMutex
+	dashboards map[uuid.UUID]dashboardbus.Dashboard
+	aliases    map[string]dashboardbus.Domain
+}
+
+// NewStore constructs an empty store.
+func NewStore() *Store {
+	return &Store{
+		mu:         &sync.RWMutex{},
+		dashboards: make(map[uuid.UUID]dashboardbus.Dashboard),
+		aliases:    make(map[string]dashboardbus.Domain),
+	}
+}
+
+// NewWithTx returns the same store. Changes are applied immediately and are
+// not undone if the transaction rolls back.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (dashboardbus.Storer, error) {
+	return s, nil
+}
+
+// Create adds a new dashboard to the store, assigning its ID like the
+// resource row does in the database.
+func (s *Store) Create(ctx context.Context, d dashboardbus.Dashboard) (dashboardbus.Dashboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.domainTaken(d.ID, d.Domain) {
+		return dashboardbus.Dashboard{}, fmt.Errorf("memory: %w", dashboardbus.ErrDomainTaken)
+	}
+
+	d.ID = uuid.New()
+	s.dashboards[d.ID] = d
+
+	return d, nil
+}
+
+// QueryByID gets the specified dashboard from the store.
+func (s *Store) QueryByID(ctx context.Context, dashboardID uuid.UUID) (dashboardbus.Dashboard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, exists := s.dashboards[dashboardID]
+	if !exists {
+		return dashboardbus.Dashboard{}, fmt.Errorf("memory: %w", dashboardbus.ErrNotFound)
+	}
+
+	return d, nil
+}
+
+// QueryIDByDomain gets the ID of the dashboard published under the domain,
+// as its primary domain or as an alias.
+func (s *Store) QueryIDByDomain(ctx context.Context, domain string) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if alias, exists := s.aliases[domain]; exists {
+		return alias.DashboardID, nil
+	}
+
+	for id, d := range s.dashboards {
+		if d.Domain != nil && *d.Domain == domain {
+			return id, nil
+		}
+	}
+
+	return uuid.Nil, fmt.Errorf("memory: %w", dashboardbus.ErrNotFound)
+}
+
+// Update replaces a dashboard in the store. With a version the dashboard is
+// only replaced while its UpdatedAt is still the version.
+func (s *Store) Update(ctx context.Context, d dashboardbus.Dashboard, version *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.domainTaken(d.ID, d.Domain) {
+		return fmt.Errorf("memory: %w", dashboardbus.ErrDomainTaken)
+	}
+
+	stored, exists := s.dashboards[d.ID]
+	if version != nil && (!exists || !stored.UpdatedAt.Equal(*version)) {
+		return fmt.Errorf("memory: %w", dashboardbus.ErrConflict)
+	}
+
+	if exists {
+		s.dashboards[d.ID] = d
+	}
+
+	return nil
+}
+
+// QueryPages returns no pages, pages are not kept in memory.
+func (s *Store) QueryPages(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Page, error) {
+	return nil, nil
+}
+
+// QueryAliases returns the aliases of the dashboard, oldest first.
+func (s *Store) QueryAliases(ctx context.Context, dashboardID uuid.UUID) ([]dashboardbus.Domain, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var domains []dashboardbus.Domain
+	for _, dm := range s.aliases {
+		if dm.DashboardID == dashboardID {
+			domains = append(domains, dm)
+		}
+	}
+
+	slices.SortFunc(domains, func(a, b dashboardbus.Domain) int {
+		if n := a.CreatedAt.Compare(b.CreatedAt); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return domains, nil
+}
+
+// CreateAlias adds an alias of the dashboard to the store.
+func (s *Store) CreateAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.aliases[dm.Name]; exists || s.domainTaken(uuid.Nil, &dm.Name) {
+		return fmt.Errorf("memory: %w", dashboardbus.ErrDomainTaken)
+	}
+
+	s.aliases[dm.Name] = dm
+
+	return nil
+}
+
+// DeleteAlias removes an alias of the dashboard from the store.
+func (s *Store) DeleteAlias(ctx context.Context, dm dashboardbus.Domain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, exists := s.aliases[dm.Name]
+	if !exists || alias.DashboardID != dm.DashboardID {
+		return fmt.Errorf("memory: %w", dashboardbus.ErrDomainNotFound)
+	}
+
+	delete(s.aliases, dm.Name)
+
+	return nil
+}
+
+// domainTaken reports whether the domain is an alias or the primary domain
+// of a dashboard other than dashboardID. The lock must be held.
+func (s *Store) domainTaken(dashboardID uuid.UUID, domain *string) bool {
+	if domain == nil {
+		return false
+	}
+
+	if _, exists := s.aliases[*domain]; exists {
+		return true
+	}
+
+	for id, d := range s.dashboards {
+		if id != dashboardID && d.Domain != nil && *d.Domain == *domain {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,335 @@
+package datasourcebus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	bigQueryEndpoint = "https://bigquery.googleapis.com/bigquery/v2"
+	bigQueryScope    = "https://www.googleapis.com/auth/bigquery.readonly"
+	googleTokenURI   = "https://oauth2.googleapis.com/token"
+)
+
+// BigQuery connects to Google BigQuery through the REST API. The setting is
+// the project; the credential service_account holds the JSON key of a
+// service account, exchanged for an access token on every connection.
+type BigQuery struct {
+	client *http.Client
+}
+
+// NewBigQuery constructs the BigQuery driver.
+func NewBigQuery() *BigQuery {
+	return &BigQuery{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// serviceAccount is the part of the JSON key of a service account used to
+// request the tokens.
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// Validate implements the Driver interface.
+func (b *BigQuery) Validate(c Connection) error {
+	if c.Settings["project"] == "" {
+		return errors.New("project is required")
+	}
+
+	sa, err := parseServiceAccount(c.Credentials["service_account"])
+	if err != nil {
+		return err
+	}
+
+	if _, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey)); err != nil {
+		return fmt.Errorf("service_account private_key: %w", err)
+	}
+
+	return nil
+}
+
+// Test implements the Driver interface.
+func (b *BigQuery) Test(ctx context.Context, c Connection) error {
+	token, err := b.token(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets?maxResults=1", bigQueryEndpoint, url.PathEscape(c.Settings["project"]))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return b.do(req, nil)
+}
+
+// Query implements the Driver interface. The query runs as standard SQL with
+// the parameters bound by name, @name in the query text.
+func (b *BigQuery) Query(ctx context.Context, c Connection, q Query) (Result, error) {
+	token, err := b.token(ctx, c)
+	if err != nil {
+		return Result{}, err
+	}
+
+	params, err := bigQueryParams(q.Params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"query":           q.Text,
+		"useLegacySql":    false,
+		"parameterMode":   "NAMED",
+		"queryParameters": params,
+		"maxResults":      q.MaxRows + 1,
+		"timeoutMs":       timeout.Milliseconds(),
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("encode: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/queries", bigQueryEndpoint, url.PathEscape(c.Settings["project"]))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp struct {
+		JobComplete bool `json:"jobComplete"`
+		Schema      struct {
+			Fields []struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"fields"`
+		} `json:"schema"`
+		Rows []struct {
+			F []struct {
+				V any `json:"v"`
+			} `json:"f"`
+		} `json:"rows"`
+	}
+
+	if err := b.do(req, &resp); err != nil {
+		return Result{}, err
+	}
+
+	if !resp.JobComplete {
+		return Result{}, errors.New("query did not complete in time")
+	}
+
+	var result Result
+
+	for _, f := range resp.Schema.Fields {
+		result.Columns = append(result.Columns, f.Name)
+	}
+
+	for _, r := range resp.Rows {
+		if len(result.Rows) == q.MaxRows {
+			result.Truncated = true
+			break
+		}
+
+		row := make([]any, len(r.F))
+		for i, cell := range r.F {
+			row[i] = cell.V
+			if i < len(resp.Schema.Fields) {
+				row[i] = bigQueryValue(resp.Schema.Fields[i].Type, cell.V)
+			}
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// bigQueryParams builds the typed named parameters of a query.
+func bigQueryParams(params map[string]any) ([]any, error) {
+	out := make([]any, 0, len(params))
+
+	for name, v := range params {
+		var typ string
+
+		switch v := v.(type) {
+		case string:
+			typ = "STRING"
+		case bool:
+			typ = "BOOL"
+		case int, int32, int64:
+			typ = "INT64"
+		case float64:
+			typ = "FLOAT64"
+			if v == float64(int64(v)) {
+				typ = "INT64"
+			}
+		default:
+			return nil, fmt.Errorf("parameter %q: unsupported type %T", name, v)
+		}
+
+		out = append(out, map[string]any{
+			"name":           name,
+			"parameterType":  map[string]string{"type": typ},
+			"parameterValue": map[string]string{"value": fmt.Sprint(v)},
+		})
+	}
+
+	return out, nil
+}
+
+// bigQueryValue converts a cell, always encoded as a string by the API, to
+// the type of its column.
+func bigQueryValue(typ string, v any) any {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+
+	switch typ {
+	case "INTEGER", "INT64":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "FLOAT64", "NUMERIC", "BIGNUMERIC":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case "BOOLEAN", "BOOL":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return s
+}
+
+// token exchanges a JWT signed by the service account for an access token.
+func (b *BigQuery) token(ctx context.Context, c Connection) (string, error) {
+	sa, err := parseServiceAccount(c.Credentials["service_account"])
+	if err != nil {
+		return "", err
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(sa.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("service_account private_key: %w", err)
+	}
+
+	tokenURI := sa.TokenURI
+	if tokenURI == "" {
+		tokenURI = googleTokenURI
+	}
+
+	now := time.Now()
+
+	claims := struct {
+		jwt.RegisteredClaims
+		Scope string `json:"scope"`
+	}{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sa.ClientEmail,
+			Audience:  jwt.ClaimStrings{tokenURI},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Scope: bigQueryScope,
+	}
+
+	assertion := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	assertion.Header["kid"] = sa.PrivateKeyID
+
+	signed, err := assertion.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("signing assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := b.do(req, &resp); err != nil {
+		return "", fmt.Errorf("token: %w", err)
+	}
+
+	return resp.AccessToken, nil
+}
+
+// do sends the request and decodes the JSON response into v, when given.
+func (b *BigQuery) do(req *http.Request, v any) error {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: status %d: %s", req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: decode: %w", req.URL.Path, err)
+	}
+
+	return nil
+}
+
+func parseServiceAccount(document string) (serviceAccount, error) {
+	if document == "" {
+		return serviceAccount{}, errors.New("service_account is required")
+	}
+
+	var sa serviceAccount
+	if err := json.Unmarshal([]byte(document), &sa); err != nil {
+		return serviceAccount{}, fmt.Errorf("service_account: %w", err)
+	}
+
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return serviceAccount{}, errors.New("service_account: client_email and private_key are required")
+	}
+
+	return sa, nil
+}
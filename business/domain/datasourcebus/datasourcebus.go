@@ -0,0 +1,525 @@
+// Package datasourcebus provides business access to the datasources, the
+// connections of each tenant to the databases and APIs its widgets read
+// from. The credentials are sealed before reaching the store.
+package datasourcebus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cache"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("datasource not found")
+	ErrUniqueName      = errors.New("datasource name is not unique")
+	ErrUnknownKind     = errors.New("unknown datasource kind")
+	ErrInvalidSettings = errors.New("invalid datasource settings")
+	ErrInUse           = errors.New("datasource is used by widgets")
+	ErrConnection      = errors.New("datasource connection failed")
+	ErrWidgetNotFound  = errors.New("widget not found")
+	ErrTenantMismatch  = errors.New("datasource belongs to another tenant")
+	ErrEmptyQuery      = errors.New("query is required")
+	ErrWidgetNotBound  = errors.New("widget is not bound to a datasource")
+	ErrQuery           = errors.New("datasource query failed")
+)
+
+// Config represents the limits applied to the queries of the widgets.
+// MaxRows bounds the rows returned, QueryTimeout the time a query runs and
+// CacheTTL the time a result is reused; zero disables the cache.
+type Config struct {
+	MaxRows      int
+	QueryTimeout time.Duration
+	CacheTTL     time.Duration
+}
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, ds Datasource) error
+	Update(ctx context.Context, ds Datasource) error
+	Delete(ctx context.Context, ds Datasource) error
+	QueryByID(ctx context.Context, datasourceID uuid.UUID) (Datasource, error)
+	QueryByTenant(ctx context.Context, tenantID uuid.UUID) ([]Datasource, error)
+	QueryWidget(ctx context.Context, widgetID uuid.UUID) (Widget, error)
+	QueryWidgetsByDashboard(ctx context.Context, dashboardID uuid.UUID) ([]Widget, error)
+	UpdateWidget(ctx context.Context, w Widget) error
+}
+
+// Driver connects to one kind of datasource.
+type Driver interface {
+
+	// Validate checks the settings and credentials without connecting.
+	Validate(c Connection) error
+
+	// Test opens a connection and runs the cheapest call the datasource
+	// offers.
+	Test(ctx context.Context, c Connection) error
+
+	// Query runs the query and returns up to q.MaxRows rows.
+	Query(ctx context.Context, c Connection, q Query) (Result, error)
+}
+
+// Core manages the set of APIs for datasource access.
+type Core struct {
+	log     *logger.Logger
+	storer  Storer
+	box     *crypto.Box
+	drivers map[string]Driver
+	cfg     Config
+	results *cache.Cache[Result]
+}
+
+// NewCore constructs a core for datasource api access. The box seals the
+// credentials of the datasources and the backend keeps the query results.
+func NewCore(log *logger.Logger, storer Storer, box *crypto.Box, backend cache.Backend, cfg Config) *Core {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 5000
+	}
+
+	if cfg.QueryTimeout <= 0 {
+		cfg.QueryTimeout = 8 * time.Second
+	}
+
+	c := Core{
+		log:    log,
+		storer: storer,
+		box:    box,
+		drivers: map[string]Driver{
+			KindPostgres: NewPostgres(),
+			KindBigQuery: NewBigQuery(),
+			KindHTTP:     NewHTTP(),
+		},
+		cfg:     cfg,
+		results: cache.New[Result](log, backend, "widget", cfg.CacheTTL),
+	}
+
+	return &c
+}
+
+// NewWithTx constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:     c.log,
+		storer:  storer,
+		box:     c.box,
+		drivers: c.drivers,
+		cfg:     c.cfg,
+		results: c.results,
+	}, nil
+}
+
+// Kinds returns the kinds of datasource supported.
+func (c *Core) Kinds() []string {
+	return []string{KindPostgres, KindBigQuery, KindHTTP}
+}
+
+// Create adds a new datasource to the system.
+func (c *Core) Create(ctx context.Context, nd NewDatasource) (Datasource, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.create")
+	defer span.End()
+
+	drv, err := c.driver(nd.Kind)
+	if err != nil {
+		return Datasource{}, err
+	}
+
+	if err := drv.Validate(Connection{Settings: nd.Settings, Credentials: nd.Credentials}); err != nil {
+		return Datasource{}, fmt.Errorf("%w: %w", ErrInvalidSettings, err)
+	}
+
+	now := time.Now()
+
+	ds := Datasource{
+		ID:        uuid.New(),
+		TenantID:  nd.TenantID,
+		Name:      strings.TrimSpace(nd.Name),
+		Kind:      nd.Kind,
+		Settings:  nd.Settings,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if ds.Credentials, err = c.seal(ds.ID, nd.Credentials); err != nil {
+		return Datasource{}, err
+	}
+
+	if err := c.storer.Create(ctx, ds); err != nil {
+		return Datasource{}, fmt.Errorf("create: %w", err)
+	}
+
+	return ds, nil
+}
+
+// Update modifies information about a datasource.
+func (c *Core) Update(ctx context.Context, ds Datasource, ud UpdateDatasource) (Datasource, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.update")
+	defer span.End()
+
+	conn, err := c.connection(ds)
+	if err != nil {
+		return Datasource{}, err
+	}
+
+	if ud.Name != nil {
+		ds.Name = strings.TrimSpace(*ud.Name)
+	}
+
+	if ud.Settings != nil {
+		ds.Settings = ud.Settings
+		conn.Settings = ud.Settings
+	}
+
+	if ud.Credentials != nil {
+		conn.Credentials = ud.Credentials
+
+		if ds.Credentials, err = c.seal(ds.ID, ud.Credentials); err != nil {
+			return Datasource{}, err
+		}
+	}
+
+	drv, err := c.driver(ds.Kind)
+	if err != nil {
+		return Datasource{}, err
+	}
+
+	if err := drv.Validate(conn); err != nil {
+		return Datasource{}, fmt.Errorf("%w: %w", ErrInvalidSettings, err)
+	}
+
+	ds.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, ds); err != nil {
+		return Datasource{}, fmt.Errorf("update: %w", err)
+	}
+
+	c.invalidate(ctx, datasourceTag(ds.ID))
+
+	return ds, nil
+}
+
+// Delete removes the datasource. It fails with ErrInUse while a widget is
+// bound to it.
+func (c *Core) Delete(ctx context.Context, ds Datasource) error {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, ds); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	c.invalidate(ctx, datasourceTag(ds.ID))
+
+	return nil
+}
+
+// QueryByID finds the datasource by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, datasourceID uuid.UUID) (Datasource, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.queryByID")
+	defer span.End()
+
+	ds, err := c.storer.QueryByID(ctx, datasourceID)
+	if err != nil {
+		return Datasource{}, fmt.Errorf("query: datasourceID[%s]: %w", datasourceID, err)
+	}
+
+	return ds, nil
+}
+
+// QueryByTenant retrieves the datasources of the tenant ordered by name.
+func (c *Core) QueryByTenant(ctx context.Context, tenantID uuid.UUID) ([]Datasource, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.queryByTenant")
+	defer span.End()
+
+	dss, err := c.storer.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return dss, nil
+}
+
+// Test connects to the datasource with its stored credentials. The error
+// wraps ErrConnection when the datasource could not be reached.
+func (c *Core) Test(ctx context.Context, ds Datasource) error {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.test")
+	defer span.End()
+
+	drv, err := c.driver(ds.Kind)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.connection(ds)
+	if err != nil {
+		return err
+	}
+
+	if err := drv.Test(ctx, conn); err != nil {
+		return fmt.Errorf("%w: %w", ErrConnection, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// QueryWidget finds the data binding of the widget.
+func (c *Core) QueryWidget(ctx context.Context, widgetID uuid.UUID) (Widget, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.queryWidget")
+	defer span.End()
+
+	w, err := c.storer.QueryWidget(ctx, widgetID)
+	if err != nil {
+		return Widget{}, fmt.Errorf("query: widgetID[%s]: %w", widgetID, err)
+	}
+
+	return w, nil
+}
+
+// QueryWidgetsByDashboard retrieves the widgets of the dashboard bound to a
+// datasource, in the order they are shown.
+func (c *Core) QueryWidgetsByDashboard(ctx context.Context, dashboardID uuid.UUID) ([]Widget, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.queryWidgetsByDashboard")
+	defer span.End()
+
+	ws, err := c.storer.QueryWidgetsByDashboard(ctx, dashboardID)
+	if err != nil {
+		return nil, fmt.Errorf("query: dashboardID[%s]: %w", dashboardID, err)
+	}
+
+	return ws, nil
+}
+
+// BindWidget makes the widget read from the datasource with the query. The
+// datasource must belong to the tenant of the widget's dashboard.
+func (c *Core) BindWidget(ctx context.Context, w Widget, bw BindWidget) (Widget, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.bindWidget")
+	defer span.End()
+
+	if strings.TrimSpace(bw.Query) == "" {
+		return Widget{}, ErrEmptyQuery
+	}
+
+	ds, err := c.QueryByID(ctx, bw.DatasourceID)
+	if err != nil {
+		return Widget{}, err
+	}
+
+	if ds.TenantID != w.TenantID {
+		return Widget{}, ErrTenantMismatch
+	}
+
+	w.DatasourceID = &ds.ID
+	w.Query = bw.Query
+
+	if err := c.storer.UpdateWidget(ctx, w); err != nil {
+		return Widget{}, fmt.Errorf("updateWidget: %w", err)
+	}
+
+	c.invalidate(ctx, widgetTag(w.ID))
+
+	return w, nil
+}
+
+// UnbindWidget removes the data binding of the widget.
+func (c *Core) UnbindWidget(ctx context.Context, w Widget) (Widget, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.unbindWidget")
+	defer span.End()
+
+	w.DatasourceID = nil
+	w.Query = ""
+
+	if err := c.storer.UpdateWidget(ctx, w); err != nil {
+		return Widget{}, fmt.Errorf("updateWidget: %w", err)
+	}
+
+	c.invalidate(ctx, widgetTag(w.ID))
+
+	return w, nil
+}
+
+// Execute runs the query of the widget against its datasource with the
+// parameters. The rows are bounded by maxRows, when given, and by the
+// configured limit; the results are reused for the configured TTL, keyed by
+// the datasource version, the query and the parameters, and dropped when the
+// datasource or the widget change. Concurrent executions of the same query
+// share one call to the datasource. Driver
+// failures wrap ErrQuery and a timeout wraps context.DeadlineExceeded.
+func (c *Core) Execute(ctx context.Context, w Widget, params map[string]any, maxRows int) (Result, error) {
+	ctx, span := otel.AddSpan(ctx, "business.datasourcebus.execute")
+	defer span.End()
+
+	if w.DatasourceID == nil || strings.TrimSpace(w.Query) == "" {
+		return Result{}, ErrWidgetNotBound
+	}
+
+	if maxRows <= 0 || maxRows > c.cfg.MaxRows {
+		maxRows = c.cfg.MaxRows
+	}
+
+	ds, err := c.QueryByID(ctx, *w.DatasourceID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	drv, err := c.driver(ds.Kind)
+	if err != nil {
+		return Result{}, err
+	}
+
+	q := Query{
+		Text:    w.Query,
+		Params:  params,
+		MaxRows: maxRows,
+	}
+
+	fetch := func(ctx context.Context) (Result, error) {
+		conn, err := c.connection(ds)
+		if err != nil {
+			return Result{}, err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, c.cfg.QueryTimeout)
+		defer cancel()
+
+		result, err := drv.Query(ctx, conn, q)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return Result{}, fmt.Errorf("%w: %w", ErrQuery, context.DeadlineExceeded)
+			}
+			return Result{}, fmt.Errorf("%w: %w", ErrQuery, err)
+		}
+
+		result.ExecutedAt = time.Now()
+
+		return result, nil
+	}
+
+	key, err := resultKey(ds, q)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, cached, err := c.results.GetOrFetch(ctx, key, fetch, cache.WithTags(datasourceTag(ds.ID), widgetTag(w.ID)))
+	if err != nil {
+		return Result{}, err
+	}
+
+	result.Cached = cached
+
+	return result, nil
+}
+
+// Stats returns the runtime statistics of the result cache.
+func (c *Core) Stats() cachestats.Stats {
+	return c.results.Stats()
+}
+
+// =============================================================================
+
+// Os resultados são marcados com a datasource e o widget de origem, para
+// serem descartados quando um dos dois muda.
+
+func datasourceTag(datasourceID uuid.UUID) string {
+	return "datasource:" + datasourceID.String()
+}
+
+func widgetTag(widgetID uuid.UUID) string {
+	return "widget:" + widgetID.String()
+}
+
+// invalidate drops the cached results of the tags. A failure only delays
+// the change until the results expire, so it is logged.
+func (c *Core) invalidate(ctx context.Context, tags ...string) {
+	if err := c.results.Invalidate(ctx, tags...); err != nil {
+		c.log.Error(ctx, "datasourcebus", "status", "invalidate results", "tags", tags, "ERROR", err)
+	}
+}
+
+// resultKey identifies a query result. The datasource version is part of
+// the key, so results read with old settings are never served after an
+// update.
+func resultKey(ds Datasource, q Query) (string, error) {
+	params, err := json.Marshal(q.Params)
+	if err != nil {
+		return "", fmt.Errorf("encode params: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%d\x00", ds.ID, ds.UpdatedAt.UnixNano(), q.Text, q.MaxRows)
+	h.Write(params)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Core) driver(kind string) (Driver, error) {
+	drv, ok := c.drivers[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+	}
+
+	return drv, nil
+}
+
+// connection opens the credentials of the datasource.
+func (c *Core) connection(ds Datasource) (Connection, error) {
+	conn := Connection{
+		Settings: ds.Settings,
+	}
+
+	if len(ds.Credentials) == 0 {
+		return conn, nil
+	}
+
+	data, err := c.box.Open(ds.Credentials, ds.ID[:])
+	if err != nil {
+		return Connection{}, fmt.Errorf("open credentials: datasourceID[%s]: %w", ds.ID, err)
+	}
+
+	if err := json.Unmarshal(data, &conn.Credentials); err != nil {
+		return Connection{}, fmt.Errorf("decode credentials: datasourceID[%s]: %w", ds.ID, err)
+	}
+
+	return conn, nil
+}
+
+// seal encrypts the credentials bound to the datasource id, so they can't
+// be copied to another datasource.
+func (c *Core) seal(datasourceID uuid.UUID, credentials map[string]string) ([]byte, error) {
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("encode credentials: %w", err)
+	}
+
+	sealed, err := c.box.Seal(data, datasourceID[:])
+	if err != nil {
+		return nil, fmt.Errorf("seal credentials: %w", err)
+	}
+
+	return sealed, nil
+}
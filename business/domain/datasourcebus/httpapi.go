@@ -0,0 +1,231 @@
+package datasourcebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTTP reads JSON from an HTTP API. The settings are the base url and
+// rows_field, the field of the response object holding the rows when the
+// response is not an array; the credentials are the token and, optionally,
+// the header carrying it (Authorization, sent as a bearer token).
+//
+// The query is a path relative to the base url and the parameters are sent
+// in the query string.
+type HTTP struct {
+	client *http.Client
+}
+
+// NewHTTP constructs the HTTP API driver.
+func NewHTTP() *HTTP {
+	return &HTTP{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Validate implements the Driver interface.
+func (h *HTTP) Validate(c Connection) error {
+	raw := c.Settings["url"]
+	if raw == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q: expected an absolute http or https url", raw)
+	}
+
+	return nil
+}
+
+// Test implements the Driver interface.
+func (h *HTTP) Test(ctx context.Context, c Connection) error {
+	req, err := h.request(ctx, c, http.MethodGet, c.Settings["url"], nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("get: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Query implements the Driver interface.
+func (h *HTTP) Query(ctx context.Context, c Connection, q Query) (Result, error) {
+	endpoint, err := h.endpoint(c.Settings["url"], q)
+	if err != nil {
+		return Result{}, err
+	}
+
+	req, err := h.request(ctx, c, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Result{}, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("get: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var body any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Result{}, fmt.Errorf("decode: %w", err)
+	}
+
+	items, err := rowsOf(body, c.Settings["rows_field"])
+	if err != nil {
+		return Result{}, err
+	}
+
+	return toResult(items, q.MaxRows), nil
+}
+
+// endpoint resolves the query path against the base url and adds the
+// parameters to the query string.
+func (h *HTTP) endpoint(base string, q Query) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("url: %w", err)
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(q.Text))
+	if err != nil {
+		return "", fmt.Errorf("query: %w", err)
+	}
+
+	// A consulta é relativa à url base, nunca outro host.
+	if ref.IsAbs() || ref.Host != "" {
+		return "", errors.New("query: expected a path relative to the datasource url")
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(ref.Path, "/")
+
+	values := u.Query()
+	for k, v := range ref.Query() {
+		values[k] = v
+	}
+
+	for k, v := range q.Params {
+		values.Set(k, fmt.Sprint(v))
+	}
+
+	u.RawQuery = values.Encode()
+
+	return u.String(), nil
+}
+
+// rowsOf returns the array of rows of the response, the response itself or
+// the field of the response object.
+func rowsOf(body any, field string) ([]any, error) {
+	switch v := body.(type) {
+	case []any:
+		return v, nil
+
+	case map[string]any:
+		if field == "" {
+			field = "items"
+		}
+
+		items, ok := v[field].([]any)
+		if !ok {
+			return nil, fmt.Errorf("response has no array field %q", field)
+		}
+		return items, nil
+	}
+
+	return nil, errors.New("response is not an array or an object")
+}
+
+// toResult turns the JSON objects into a table. The columns are the fields
+// of the first object, in alphabetical order.
+func toResult(items []any, maxRows int) Result {
+	var result Result
+
+	if len(items) == 0 {
+		return result
+	}
+
+	if first, ok := items[0].(map[string]any); ok {
+		for k := range first {
+			result.Columns = append(result.Columns, k)
+		}
+		sort.Strings(result.Columns)
+	}
+
+	if len(result.Columns) == 0 {
+		result.Columns = []string{"value"}
+	}
+
+	for _, item := range items {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+
+		row := make([]any, len(result.Columns))
+
+		switch obj := item.(type) {
+		case map[string]any:
+			for i, col := range result.Columns {
+				row[i] = obj[col]
+			}
+		default:
+			row[0] = obj
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result
+}
+
+// request constructs a request to the API with the credentials set.
+func (h *HTTP) request(ctx context.Context, c Connection, method string, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if token := c.Credentials["token"]; token != "" {
+		header := c.Credentials["header"]
+
+		switch {
+		case header == "" || strings.EqualFold(header, "Authorization"):
+			req.Header.Set("Authorization", "Bearer "+token)
+		default:
+			req.Header.Set(header, token)
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,89 @@
+package datasourcebus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of datasource kinds supported.
+const (
+	KindPostgres = "postgres"
+	KindBigQuery = "bigquery"
+	KindHTTP     = "http"
+)
+
+// Datasource represents a connection of a tenant to the database or API the
+// widgets read from. Credentials holds the sealed credentials, they are only
+// opened by the core to connect.
+type Datasource struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Kind        string
+	Settings    map[string]string
+	Credentials []byte
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// NewDatasource contains information needed to create a new datasource.
+// The credentials are given in plain text and sealed by the core.
+type NewDatasource struct {
+	TenantID    uuid.UUID
+	Name        string
+	Kind        string
+	Settings    map[string]string
+	Credentials map[string]string
+}
+
+// UpdateDatasource contains information needed to update a datasource. The
+// credentials are replaced as a whole when given.
+type UpdateDatasource struct {
+	Name        *string
+	Settings    map[string]string
+	Credentials map[string]string
+}
+
+// Connection is what a driver needs to reach a datasource.
+type Connection struct {
+	Settings    map[string]string
+	Credentials map[string]string
+}
+
+// Widget represents the data binding of a widget (subject): the datasource
+// it reads from and the query it runs there. DashboardID and TenantID come
+// from the page the widget belongs to.
+type Widget struct {
+	ID           uuid.UUID
+	Title        string
+	DashboardID  uuid.UUID
+	TenantID     uuid.UUID
+	DatasourceID *uuid.UUID
+	Query        string
+}
+
+// BindWidget contains information needed to bind a widget to a datasource.
+type BindWidget struct {
+	DatasourceID uuid.UUID
+	Query        string
+}
+
+// Query is a query run against a datasource. The parameters are bound by
+// name, @name in the query text, by the driver; MaxRows bounds the rows
+// read from the datasource.
+type Query struct {
+	Text    string
+	Params  map[string]any
+	MaxRows int
+}
+
+// Result is the table returned by a query. Truncated reports rows were left
+// out by the limit and Cached that the result was served from the cache.
+type Result struct {
+	Columns    []string
+	Rows       [][]any
+	Truncated  bool
+	Cached     bool
+	ExecutedAt time.Time
+}
@@ -0,0 +1,164 @@
+package datasourcebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Postgres connects to a PostgreSQL database. The settings are host, port
+// (5432), database and sslmode (require); the credentials are user and
+// password. The sessions are read only.
+type Postgres struct{}
+
+// NewPostgres constructs the PostgreSQL driver.
+func NewPostgres() *Postgres {
+	return &Postgres{}
+}
+
+// Validate implements the Driver interface.
+func (p *Postgres) Validate(c Connection) error {
+	if c.Settings["host"] == "" {
+		return errors.New("host is required")
+	}
+
+	if c.Settings["database"] == "" {
+		return errors.New("database is required")
+	}
+
+	if port := c.Settings["port"]; port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+
+	switch c.Settings["sslmode"] {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("invalid sslmode %q", c.Settings["sslmode"])
+	}
+
+	if c.Credentials["user"] == "" {
+		return errors.New("user is required")
+	}
+
+	return nil
+}
+
+// Test implements the Driver interface.
+func (p *Postgres) Test(ctx context.Context, c Connection) error {
+	conn, err := p.connect(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if err := conn.Ping(ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	return nil
+}
+
+// Query implements the Driver interface. The parameters are bound by pgx
+// from the @name placeholders.
+func (p *Postgres) Query(ctx context.Context, c Connection, q Query) (Result, error) {
+	conn, err := p.connect(ctx, c)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(ctx, q.Text, pgx.NamedArgs(q.Params))
+	if err != nil {
+		return Result{}, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	var result Result
+
+	for _, fd := range rows.FieldDescriptions() {
+		result.Columns = append(result.Columns, fd.Name)
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == q.MaxRows {
+			result.Truncated = true
+			break
+		}
+
+		values, err := rows.Values()
+		if err != nil {
+			return Result{}, fmt.Errorf("values: %w", err)
+		}
+
+		for i, v := range values {
+			values[i] = pgValue(v)
+		}
+
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return Result{}, fmt.Errorf("rows: %w", err)
+	}
+
+	return result, nil
+}
+
+// pgValue converts the values pgx decodes into types encoded as expected in
+// JSON, uuids are returned by pgx as byte arrays.
+func pgValue(v any) any {
+	switch v := v.(type) {
+	case [16]byte:
+		return uuid.UUID(v).String()
+	default:
+		return v
+	}
+}
+
+func (p *Postgres) connect(ctx context.Context, c Connection) (*pgx.Conn, error) {
+	port := c.Settings["port"]
+	if port == "" {
+		port = "5432"
+	}
+
+	sslMode := c.Settings["sslmode"]
+	if sslMode == "" {
+		sslMode = "require"
+	}
+
+	q := make(url.Values)
+	q.Set("sslmode", sslMode)
+	q.Set("connect_timeout", "5")
+	q.Set("application_name", "spi-exata")
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Credentials["user"], c.Credentials["password"]),
+		Host:     net.JoinHostPort(c.Settings["host"], port),
+		Path:     c.Settings["database"],
+		RawQuery: q.Encode(),
+	}
+
+	cfg, err := pgx.ParseConfig(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	// As consultas dos widgets nunca alteram a base do cliente.
+	cfg.RuntimeParams["default_transaction_read_only"] = "on"
+
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	return conn, nil
+}
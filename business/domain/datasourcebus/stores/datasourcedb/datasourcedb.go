@@ -0,0 +1,248 @@
+// Package datasourcedb contains datasource related CRUD functionality.
+package datasourcedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for datasource database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (datasourcebus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new datasource into the database.
+func (s *Store) Create(ctx context.Context, ds datasourcebus.Datasource) error {
+	const q = `
+	INSERT INTO "public"."datasource"
+		(datasource_id, tenant_id, name, kind, settings, credentials, created_at, updated_at)
+	VALUES
+		(:datasource_id, :tenant_id, :name, :kind, :settings, :credentials, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDatasource(ds)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) && dupErr.Constraint == "uq_datasource_tenant_name" {
+			return fmt.Errorf("namedexeccontext: %w", datasourcebus.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a datasource document in the database.
+func (s *Store) Update(ctx context.Context, ds datasourcebus.Datasource) error {
+	const q = `
+	UPDATE
+		"public"."datasource"
+	SET
+		name = :name,
+		settings = :settings,
+		credentials = :credentials,
+		updated_at = :updated_at
+	WHERE
+		datasource_id = :datasource_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDatasource(ds)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) && dupErr.Constraint == "uq_datasource_tenant_name" {
+			return fmt.Errorf("namedexeccontext: %w", datasourcebus.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a datasource from the database.
+func (s *Store) Delete(ctx context.Context, ds datasourcebus.Datasource) error {
+	data := struct {
+		ID string `db:"datasource_id"`
+	}{
+		ID: ds.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."datasource"
+	WHERE
+		datasource_id = :datasource_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		var fkErr sqldb.ErrDBForeignKey
+		if errors.As(err, &fkErr) && fkErr.Constraint == "fk_subject_datasource" {
+			return fmt.Errorf("namedexeccontext: %w", datasourcebus.ErrInUse)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified datasource from the database.
+func (s *Store) QueryByID(ctx context.Context, datasourceID uuid.UUID) (datasourcebus.Datasource, error) {
+	data := struct {
+		ID string `db:"datasource_id"`
+	}{
+		ID: datasourceID.String(),
+	}
+
+	const q = `
+	SELECT
+		datasource_id, tenant_id, name, kind, settings, credentials, created_at, updated_at
+	FROM
+		"public"."datasource"
+	WHERE
+		datasource_id = :datasource_id`
+
+	var dbDS datasourceDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbDS); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return datasourcebus.Datasource{}, fmt.Errorf("db: %w", datasourcebus.ErrNotFound)
+		}
+		return datasourcebus.Datasource{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusDatasource(dbDS), nil
+}
+
+// QueryByTenant gets the datasources of the tenant ordered by name.
+func (s *Store) QueryByTenant(ctx context.Context, tenantID uuid.UUID) ([]datasourcebus.Datasource, error) {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		datasource_id, tenant_id, name, kind, settings, credentials, created_at, updated_at
+	FROM
+		"public"."datasource"
+	WHERE
+		tenant_id = :tenant_id
+	ORDER BY
+		name`
+
+	var dbDSs []datasourceDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDSs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDatasources(dbDSs), nil
+}
+
+// QueryWidget gets the data binding of the widget with the dashboard and
+// tenant of its page.
+func (s *Store) QueryWidget(ctx context.Context, widgetID uuid.UUID) (datasourcebus.Widget, error) {
+	data := struct {
+		ID string `db:"subject_id"`
+	}{
+		ID: widgetID.String(),
+	}
+
+	const q = `
+	SELECT
+		s.subject_id, s.title, p.dashboard_id, d.tenant_id, s.datasource_id, s.query
+	FROM
+		"public"."subject" AS s
+	JOIN
+		"public"."page" AS p ON p.page_id = s.page_id
+	JOIN
+		"public"."dashboard" AS d ON d.dashboard_id = p.dashboard_id
+	WHERE
+		s.subject_id = :subject_id`
+
+	var dbWidget widgetDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbWidget); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return datasourcebus.Widget{}, fmt.Errorf("db: %w", datasourcebus.ErrWidgetNotFound)
+		}
+		return datasourcebus.Widget{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusWidget(dbWidget), nil
+}
+
+// QueryWidgetsByDashboard gets the widgets of the dashboard bound to a
+// datasource, ordered by page and by their order in the page.
+func (s *Store) QueryWidgetsByDashboard(ctx context.Context, dashboardID uuid.UUID) ([]datasourcebus.Widget, error) {
+	data := struct {
+		ID string `db:"dashboard_id"`
+	}{
+		ID: dashboardID.String(),
+	}
+
+	const q = `
+	SELECT
+		s.subject_id, s.title, p.dashboard_id, d.tenant_id, s.datasource_id, s.query
+	FROM
+		"public"."subject" AS s
+	JOIN
+		"public"."page" AS p ON p.page_id = s.page_id
+	JOIN
+		"public"."dashboard" AS d ON d.dashboard_id = p.dashboard_id
+	WHERE
+		p.dashboard_id = :dashboard_id AND
+		s.datasource_id IS NOT NULL
+	ORDER BY
+		p."order" NULLS LAST, p.created_at, s."order"`
+
+	var dbWidgets []widgetDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbWidgets); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusWidgets(dbWidgets), nil
+}
+
+// UpdateWidget replaces the data binding of the widget.
+func (s *Store) UpdateWidget(ctx context.Context, w datasourcebus.Widget) error {
+	const q = `
+	UPDATE
+		"public"."subject"
+	SET
+		datasource_id = :datasource_id,
+		query = :query,
+		updated_at = now()
+	WHERE
+		subject_id = :subject_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBWidget(w)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package datasourcedb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+)
+
+type datasourceDB struct {
+	ID          uuid.UUID `db:"datasource_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	Name        string    `db:"name"`
+	Kind        string    `db:"kind"`
+	Settings    string    `db:"settings"`
+	Credentials []byte    `db:"credentials"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func toDBDatasource(bus datasourcebus.Datasource) datasourceDB {
+	settings := "{}"
+	if len(bus.Settings) > 0 {
+		if data, err := json.Marshal(bus.Settings); err == nil {
+			settings = string(data)
+		}
+	}
+
+	return datasourceDB{
+		ID:          bus.ID,
+		TenantID:    bus.TenantID,
+		Name:        bus.Name,
+		Kind:        bus.Kind,
+		Settings:    settings,
+		Credentials: bus.Credentials,
+		CreatedAt:   bus.CreatedAt.UTC(),
+		UpdatedAt:   bus.UpdatedAt.UTC(),
+	}
+}
+
+func toBusDatasource(db datasourceDB) datasourcebus.Datasource {
+	var settings map[string]string
+	json.Unmarshal([]byte(db.Settings), &settings)
+
+	return datasourcebus.Datasource{
+		ID:          db.ID,
+		TenantID:    db.TenantID,
+		Name:        db.Name,
+		Kind:        db.Kind,
+		Settings:    settings,
+		Credentials: db.Credentials,
+		CreatedAt:   db.CreatedAt.In(time.Local),
+		UpdatedAt:   db.UpdatedAt.In(time.Local),
+	}
+}
+
+func toBusDatasources(dbs []datasourceDB) []datasourcebus.Datasource {
+	bus := make([]datasourcebus.Datasource, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusDatasource(db)
+	}
+
+	return bus
+}
+
+type widgetDB struct {
+	ID           uuid.UUID      `db:"subject_id"`
+	Title        string         `db:"title"`
+	DashboardID  uuid.UUID      `db:"dashboard_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
+	DatasourceID uuid.NullUUID  `db:"datasource_id"`
+	Query        sql.NullString `db:"query"`
+}
+
+func toDBWidget(bus datasourcebus.Widget) widgetDB {
+	db := widgetDB{
+		ID:          bus.ID,
+		Title:       bus.Title,
+		DashboardID: bus.DashboardID,
+		TenantID:    bus.TenantID,
+		Query:       sql.NullString{String: bus.Query, Valid: bus.Query != ""},
+	}
+
+	if bus.DatasourceID != nil {
+		db.DatasourceID = uuid.NullUUID{UUID: *bus.DatasourceID, Valid: true}
+	}
+
+	return db
+}
+
+func toBusWidget(db widgetDB) datasourcebus.Widget {
+	w := datasourcebus.Widget{
+		ID:          db.ID,
+		Title:       db.Title,
+		DashboardID: db.DashboardID,
+		TenantID:    db.TenantID,
+		Query:       db.Query.String,
+	}
+
+	if db.DatasourceID.Valid {
+		w.DatasourceID = &db.DatasourceID.UUID
+	}
+
+	return w
+}
+
+func toBusWidgets(dbs []widgetDB) []datasourcebus.Widget {
+	ws := make([]datasourcebus.Widget, len(dbs))
+	for i, db := range dbs {
+		ws[i] = toBusWidget(db)
+	}
+
+	return ws
+}
@@ -0,0 +1,328 @@
+// Package featurebus provides business access to the feature flags, used to
+// roll new dashboard capabilities out to pilot clients first. The flags are
+// read on most requests, so the core evaluates them from a snapshot of the
+// table refreshed every TTL; a change made on another instance takes up to
+// the TTL to be seen.
+package featurebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("feature flag not found")
+	ErrUniqueKey       = errors.New("feature flag already exists")
+	ErrInvalidKey      = errors.New("invalid feature flag key")
+	ErrInvalidRollout  = errors.New("rollout must be between 0 and 100")
+	ErrInvalidOverride = errors.New("override must have either a tenant or a user")
+	ErrSubjectNotFound = errors.New("tenant or user of the override not found")
+)
+
+// keyPattern restricts the keys to what is safe in URLs and logs, e.g.
+// "dashboard.export-pdf".
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, f Flag) error
+	Update(ctx context.Context, f Flag) error
+	Delete(ctx context.Context, f Flag) error
+	QueryAll(ctx context.Context) ([]Flag, error)
+	QueryByKey(ctx context.Context, key string) (Flag, error)
+	SetOverride(ctx context.Context, key string, o Override) error
+	DeleteOverride(ctx context.Context, key string, o Override) error
+}
+
+// Core manages the set of APIs for feature flag access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	flags    []Flag
+	loadedAt time.Time
+}
+
+// NewCore constructs a core for feature flag api access. The flags are read
+// again from the store once the snapshot is older than ttl.
+func NewCore(log *logger.Logger, storer Storer, ttl time.Duration) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+		ttl:    ttl,
+	}
+}
+
+// Create adds a new flag.
+func (c *Core) Create(ctx context.Context, nf NewFlag) (Flag, error) {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.create")
+	defer span.End()
+
+	if !keyPattern.MatchString(nf.Key) {
+		return Flag{}, fmt.Errorf("%w: %q", ErrInvalidKey, nf.Key)
+	}
+
+	if nf.Rollout < 0 || nf.Rollout > 100 {
+		return Flag{}, ErrInvalidRollout
+	}
+
+	now := time.Now()
+
+	f := Flag{
+		Key:         nf.Key,
+		Description: nf.Description,
+		Enabled:     nf.Enabled,
+		Rollout:     nf.Rollout,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := c.storer.Create(ctx, f); err != nil {
+		return Flag{}, fmt.Errorf("create: %w", err)
+	}
+
+	c.reset()
+
+	return f, nil
+}
+
+// Update modifies a flag.
+func (c *Core) Update(ctx context.Context, f Flag, uf UpdateFlag) (Flag, error) {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.update")
+	defer span.End()
+
+	if uf.Description != nil {
+		f.Description = *uf.Description
+	}
+
+	if uf.Enabled != nil {
+		f.Enabled = *uf.Enabled
+	}
+
+	if uf.Rollout != nil {
+		if *uf.Rollout < 0 || *uf.Rollout > 100 {
+			return Flag{}, ErrInvalidRollout
+		}
+		f.Rollout = *uf.Rollout
+	}
+
+	f.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, f); err != nil {
+		return Flag{}, fmt.Errorf("update: key[%s]: %w", f.Key, err)
+	}
+
+	c.reset()
+
+	return f, nil
+}
+
+// Delete removes a flag and its overrides. Code still checking the flag sees
+// it off.
+func (c *Core) Delete(ctx context.Context, f Flag) error {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, f); err != nil {
+		return fmt.Errorf("delete: key[%s]: %w", f.Key, err)
+	}
+
+	c.reset()
+
+	return nil
+}
+
+// QueryAll returns every flag with its overrides, ordered by key.
+func (c *Core) QueryAll(ctx context.Context) ([]Flag, error) {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.queryAll")
+	defer span.End()
+
+	flags, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return flags, nil
+}
+
+// QueryByKey finds the flag by the specified key.
+func (c *Core) QueryByKey(ctx context.Context, key string) (Flag, error) {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.queryByKey")
+	defer span.End()
+
+	f, err := c.storer.QueryByKey(ctx, key)
+	if err != nil {
+		return Flag{}, fmt.Errorf("query: key[%s]: %w", key, err)
+	}
+
+	return f, nil
+}
+
+// SetOverride turns the flag on or off for a tenant or a user, replacing the
+// override they had.
+func (c *Core) SetOverride(ctx context.Context, f Flag, o Override) error {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.setOverride")
+	defer span.End()
+
+	if (o.TenantID == uuid.Nil) == (o.UserID == uuid.Nil) {
+		return ErrInvalidOverride
+	}
+
+	if err := c.storer.SetOverride(ctx, f.Key, o); err != nil {
+		return fmt.Errorf("setOverride: key[%s]: %w", f.Key, err)
+	}
+
+	c.reset()
+
+	return nil
+}
+
+// DeleteOverride removes the override of a tenant or a user, who fall back
+// to the rollout of the flag.
+func (c *Core) DeleteOverride(ctx context.Context, f Flag, o Override) error {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.deleteOverride")
+	defer span.End()
+
+	if (o.TenantID == uuid.Nil) == (o.UserID == uuid.Nil) {
+		return ErrInvalidOverride
+	}
+
+	if err := c.storer.DeleteOverride(ctx, f.Key, o); err != nil {
+		return fmt.Errorf("deleteOverride: key[%s]: %w", f.Key, err)
+	}
+
+	c.reset()
+
+	return nil
+}
+
+// =============================================================================
+
+// Evaluate returns the value of every flag for the subject.
+func (c *Core) Evaluate(ctx context.Context, s Subject) (Set, error) {
+	ctx, span := otel.AddSpan(ctx, "business.featurebus.evaluate")
+	defer span.End()
+
+	flags, err := c.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(Set, len(flags))
+	for _, f := range flags {
+		set[f.Key] = evaluate(f, s)
+	}
+
+	return set, nil
+}
+
+// Enabled reports whether the flag is on for the subject. A flag that can't
+// be read is off, so a failure never exposes an unfinished capability.
+func (c *Core) Enabled(ctx context.Context, key string, s Subject) bool {
+	flags, err := c.snapshot(ctx)
+	if err != nil {
+		c.log.Error(ctx, "featurebus", "status", "snapshot", "key", key, "ERROR", err)
+		return false
+	}
+
+	for _, f := range flags {
+		if f.Key == key {
+			return evaluate(f, s)
+		}
+	}
+
+	return false
+}
+
+// snapshot returns the flags, reading them again when the snapshot is older
+// than the TTL.
+func (c *Core) snapshot(ctx context.Context) ([]Flag, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.flags != nil && time.Since(c.loadedAt) < c.ttl {
+		return c.flags, nil
+	}
+
+	flags, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		// Uma falha momentânea mantém o snapshot anterior.
+		if c.flags != nil {
+			c.log.Error(ctx, "featurebus", "status", "refresh", "ERROR", err)
+			return c.flags, nil
+		}
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	if flags == nil {
+		flags = []Flag{}
+	}
+
+	c.flags = flags
+	c.loadedAt = time.Now()
+
+	return flags, nil
+}
+
+// reset drops the snapshot after a change made on this instance.
+func (c *Core) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flags = nil
+}
+
+// evaluate resolves the flag for the subject: the override of the user, then
+// the one of the tenant, then the rollout.
+func evaluate(f Flag, s Subject) bool {
+	var tenant *bool
+
+	for _, o := range f.Overrides {
+		switch {
+		case o.UserID != uuid.Nil && o.UserID == s.UserID:
+			return o.Enabled
+		case o.TenantID != uuid.Nil && o.TenantID == s.TenantID:
+			tenant = &o.Enabled
+		}
+	}
+
+	if tenant != nil {
+		return *tenant
+	}
+
+	if !f.Enabled {
+		return false
+	}
+
+	// Os usuários de um tenant entram juntos; o bucket depende da chave para
+	// que os pilotos de uma flag não sejam sempre os mesmos.
+	id := s.TenantID
+	if id == uuid.Nil {
+		id = s.UserID
+	}
+
+	return bucket(f.Key, id) < f.Rollout
+}
+
+// bucket places the id in one of 100 buckets of the flag.
+func bucket(key string, id uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{':'})
+	h.Write(id[:])
+
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,59 @@
+package featurebus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Flag represents a capability being rolled out. When Enabled, it is on for
+// Rollout percent of the tenants, and of the users without a tenant. The
+// overrides win over the rollout, the one of the user over the one of the
+// tenant.
+type Flag struct {
+	Key         string
+	Description string
+	Enabled     bool
+	Rollout     int
+	Overrides   []Override
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Override turns a flag on or off for a single tenant or user. Exactly one
+// of TenantID and UserID is set.
+type Override struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+	Enabled  bool
+}
+
+// NewFlag contains the information needed to create a flag.
+type NewFlag struct {
+	Key         string
+	Description string
+	Enabled     bool
+	Rollout     int
+}
+
+// UpdateFlag contains the information needed to update a flag. Fields that
+// are not set are left unchanged.
+type UpdateFlag struct {
+	Description *string
+	Enabled     *bool
+	Rollout     *int
+}
+
+// Subject is who a flag is evaluated for.
+type Subject struct {
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+}
+
+// Set is the value of every flag for a subject.
+type Set map[string]bool
+
+// Enabled reports whether the flag is on. Unknown flags are off.
+func (s Set) Enabled(key string) bool {
+	return s[key]
+}
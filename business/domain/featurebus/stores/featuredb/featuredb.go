@@ -0,0 +1,203 @@
+// Package featuredb contains feature flag related CRUD functionality.
+package featuredb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for feature flag database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new flag into the database.
+func (s *Store) Create(ctx context.Context, f featurebus.Flag) error {
+	const q = `
+	INSERT INTO "public"."feature_flag"
+		(flag_key, description, enabled, rollout, created_at, updated_at)
+	VALUES
+		(:flag_key, :description, :enabled, :rollout, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBFlag(f)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry{}) {
+			return fmt.Errorf("namedexeccontext: %w", featurebus.ErrUniqueKey)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a flag in the database.
+func (s *Store) Update(ctx context.Context, f featurebus.Flag) error {
+	const q = `
+	UPDATE
+		"public"."feature_flag"
+	SET
+		description = :description,
+		enabled = :enabled,
+		rollout = :rollout,
+		updated_at = :updated_at
+	WHERE
+		flag_key = :flag_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBFlag(f)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a flag and, by cascade, its overrides.
+func (s *Store) Delete(ctx context.Context, f featurebus.Flag) error {
+	const q = `
+	DELETE FROM
+		"public"."feature_flag"
+	WHERE
+		flag_key = :flag_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBFlag(f)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves every flag with its overrides, ordered by key.
+func (s *Store) QueryAll(ctx context.Context) ([]featurebus.Flag, error) {
+	const q = `
+	SELECT
+		flag_key, description, enabled, rollout, created_at, updated_at
+	FROM
+		"public"."feature_flag"
+	ORDER BY
+		flag_key`
+
+	var dbFlags []flagDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &dbFlags); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	const qo = `
+	SELECT
+		flag_key, tenant_id, user_id, enabled
+	FROM
+		"public"."feature_override"
+	ORDER BY
+		created_at`
+
+	var dbOverrides []overrideDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, qo, struct{}{}, &dbOverrides); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: overrides: %w", err)
+	}
+
+	return toBusFlags(dbFlags, dbOverrides), nil
+}
+
+// QueryByKey gets the specified flag with its overrides from the database.
+func (s *Store) QueryByKey(ctx context.Context, key string) (featurebus.Flag, error) {
+	data := struct {
+		Key string `db:"flag_key"`
+	}{
+		Key: key,
+	}
+
+	const q = `
+	SELECT
+		flag_key, description, enabled, rollout, created_at, updated_at
+	FROM
+		"public"."feature_flag"
+	WHERE
+		flag_key = :flag_key`
+
+	var dbFlag flagDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbFlag); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return featurebus.Flag{}, fmt.Errorf("db: %w", featurebus.ErrNotFound)
+		}
+		return featurebus.Flag{}, fmt.Errorf("db: %w", err)
+	}
+
+	const qo = `
+	SELECT
+		flag_key, tenant_id, user_id, enabled
+	FROM
+		"public"."feature_override"
+	WHERE
+		flag_key = :flag_key
+	ORDER BY
+		created_at`
+
+	var dbOverrides []overrideDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, qo, data, &dbOverrides); err != nil {
+		return featurebus.Flag{}, fmt.Errorf("namedqueryslice: overrides: %w", err)
+	}
+
+	return toBusFlag(dbFlag, dbOverrides), nil
+}
+
+// SetOverride inserts or replaces the override of a tenant or a user.
+func (s *Store) SetOverride(ctx context.Context, key string, o featurebus.Override) error {
+	const qt = `
+	INSERT INTO "public"."feature_override"
+		(flag_key, tenant_id, enabled)
+	VALUES
+		(:flag_key, :tenant_id, :enabled)
+	ON CONFLICT (flag_key, tenant_id) WHERE tenant_id IS NOT NULL DO UPDATE SET
+		enabled = EXCLUDED.enabled`
+
+	const qu = `
+	INSERT INTO "public"."feature_override"
+		(flag_key, user_id, enabled)
+	VALUES
+		(:flag_key, :user_id, :enabled)
+	ON CONFLICT (flag_key, user_id) WHERE user_id IS NOT NULL DO UPDATE SET
+		enabled = EXCLUDED.enabled`
+
+	q := qt
+	if o.UserID != uuid.Nil {
+		q = qu
+	}
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBOverride(key, o)); err != nil {
+		if errors.Is(err, sqldb.ErrDBForeignKey{}) {
+			return fmt.Errorf("namedexeccontext: %w", featurebus.ErrSubjectNotFound)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteOverride removes the override of a tenant or a user.
+func (s *Store) DeleteOverride(ctx context.Context, key string, o featurebus.Override) error {
+	const q = `
+	DELETE FROM
+		"public"."feature_override"
+	WHERE
+		flag_key = :flag_key AND
+		(tenant_id = :tenant_id OR user_id = :user_id)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBOverride(key, o)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package featuredb
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/featurebus"
+)
+
+type flagDB struct {
+	Key         string    `db:"flag_key"`
+	Description string    `db:"description"`
+	Enabled     bool      `db:"enabled"`
+	Rollout     int       `db:"rollout"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type overrideDB struct {
+	Key      string        `db:"flag_key"`
+	TenantID uuid.NullUUID `db:"tenant_id"`
+	UserID   uuid.NullUUID `db:"user_id"`
+	Enabled  bool          `db:"enabled"`
+}
+
+func toDBFlag(bus featurebus.Flag) flagDB {
+	return flagDB{
+		Key:         bus.Key,
+		Description: bus.Description,
+		Enabled:     bus.Enabled,
+		Rollout:     bus.Rollout,
+		CreatedAt:   bus.CreatedAt.UTC(),
+		UpdatedAt:   bus.UpdatedAt.UTC(),
+	}
+}
+
+func toDBOverride(key string, bus featurebus.Override) overrideDB {
+	return overrideDB{
+		Key:      key,
+		TenantID: uuid.NullUUID{UUID: bus.TenantID, Valid: bus.TenantID != uuid.Nil},
+		UserID:   uuid.NullUUID{UUID: bus.UserID, Valid: bus.UserID != uuid.Nil},
+		Enabled:  bus.Enabled,
+	}
+}
+
+func toBusFlag(db flagDB, overrides []overrideDB) featurebus.Flag {
+	f := featurebus.Flag{
+		Key:         db.Key,
+		Description: db.Description,
+		Enabled:     db.Enabled,
+		Rollout:     db.Rollout,
+		CreatedAt:   db.CreatedAt.In(time.Local),
+		UpdatedAt:   db.UpdatedAt.In(time.Local),
+	}
+
+	for _, o := range overrides {
+		if o.Key != db.Key {
+			continue
+		}
+
+		f.Overrides = append(f.Overrides, featurebus.Override{
+			TenantID: o.TenantID.UUID,
+			UserID:   o.UserID.UUID,
+			Enabled:  o.Enabled,
+		})
+	}
+
+	return f
+}
+
+func toBusFlags(dbs []flagDB, overrides []overrideDB) []featurebus.Flag {
+	bus := make([]featurebus.Flag, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusFlag(db, overrides)
+	}
+
+	return bus
+}
@@ -0,0 +1,106 @@
+// Package keybus provides business access to the signing keys domain. The keys
+// live in the database so every instance signs with the same key and a
+// rotation takes effect without redeploying files.
+package keybus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/keystore"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound  = errors.New("key not found")
+	ErrUniqueKID = errors.New("kid already exists")
+	ErrNoActive  = errors.New("no active key")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, k Key) error
+	Retire(ctx context.Context, notAfter time.Time) error
+	QueryValid(ctx context.Context, now time.Time) ([]Key, error)
+}
+
+// Core manages the set of APIs for signing key access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a core for signing key api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// NewWithTx constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:    c.log,
+		storer: storer,
+	}, nil
+}
+
+// Rotate adds a key and makes it the active one. The key active until now
+// keeps verifying the tokens it signed until retireAfter has passed, so it
+// should be at least the lifetime of a token.
+func (c *Core) Rotate(ctx context.Context, nk NewKey, retireAfter time.Duration) (Key, error) {
+	ctx, span := otel.AddSpan(ctx, "business.keybus.rotate")
+	defer span.End()
+
+	publicPEM, err := keystore.PublicPEM(nk.PrivatePEM)
+	if err != nil {
+		return Key{}, fmt.Errorf("publicPEM: %w", err)
+	}
+
+	now := time.Now()
+
+	if err := c.storer.Retire(ctx, now.Add(retireAfter)); err != nil {
+		return Key{}, fmt.Errorf("retire: %w", err)
+	}
+
+	k := Key{
+		KID:        nk.KID,
+		PrivatePEM: nk.PrivatePEM,
+		PublicPEM:  publicPEM,
+		Active:     true,
+		CreatedAt:  now,
+	}
+
+	if err := c.storer.Create(ctx, k); err != nil {
+		return Key{}, fmt.Errorf("create: %w", err)
+	}
+
+	return k, nil
+}
+
+// QueryValid retrieves the keys that can still verify tokens.
+func (c *Core) QueryValid(ctx context.Context) ([]Key, error) {
+	ctx, span := otel.AddSpan(ctx, "business.keybus.queryValid")
+	defer span.End()
+
+	keys, err := c.storer.QueryValid(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("queryValid: %w", err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,170 @@
+package keybus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// minReload is the least time between two reloads caused by an unknown kid,
+// so tokens with made up kids can't hammer the database.
+const minReload = 5 * time.Second
+
+// KeyStore implements the auth.KeyLookup interface over the keys in the
+// database. The keys are cached for ttl and a kid missing from the cache
+// forces a reload, so a key rotated by another instance is found right away.
+type KeyStore struct {
+	log  *logger.Logger
+	core *Core
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]Key
+	active   string
+	loadedAt time.Time
+}
+
+// NewKeyStore constructs a keystore caching the keys for ttl.
+func NewKeyStore(log *logger.Logger, core *Core, ttl time.Duration) *KeyStore {
+	return &KeyStore{
+		log:  log,
+		core: core,
+		ttl:  ttl,
+		keys: make(map[string]Key),
+	}
+}
+
+// Load reads the keys from the database. It fails when there is no active
+// key, so the service doesn't start unable to sign tokens.
+func (ks *KeyStore) Load(ctx context.Context) error {
+	if err := ks.load(ctx); err != nil {
+		return err
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.active == "" {
+		return ErrNoActive
+	}
+
+	return nil
+}
+
+// PrivateKey searches the keystore for a given kid and returns the private key.
+func (ks *KeyStore) PrivateKey(kid string) (string, error) {
+	k, err := ks.key(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return k.PrivatePEM, nil
+}
+
+// PublicKey searches the keystore for a given kid and returns the public key.
+func (ks *KeyStore) PublicKey(kid string) (string, error) {
+	k, err := ks.key(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return k.PublicPEM, nil
+}
+
+// ActiveKID returns the kid of the key used to sign new tokens.
+func (ks *KeyStore) ActiveKID() (string, error) {
+	ks.refresh(false)
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.active == "" {
+		return "", ErrNoActive
+	}
+
+	return ks.active, nil
+}
+
+func (ks *KeyStore) key(kid string) (Key, error) {
+	ks.refresh(false)
+
+	if k, ok := ks.lookup(kid); ok {
+		return k, nil
+	}
+
+	// A chave pode ter sido criada por outra instância depois da última
+	// leitura.
+	ks.refresh(true)
+
+	if k, ok := ks.lookup(kid); ok {
+		return k, nil
+	}
+
+	return Key{}, fmt.Errorf("kid %q: %w", kid, ErrNotFound)
+}
+
+func (ks *KeyStore) lookup(kid string) (Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	k, ok := ks.keys[kid]
+	if !ok || !k.Valid(time.Now()) {
+		return Key{}, false
+	}
+
+	return k, true
+}
+
+// refresh reloads the keys once the cache expires, or sooner when forced. A
+// failure keeps the cached keys so the database being down doesn't stop
+// the tokens from being verified.
+func (ks *KeyStore) refresh(force bool) {
+	ks.mu.RLock()
+	age := time.Since(ks.loadedAt)
+	ks.mu.RUnlock()
+
+	if age < ks.ttl && (!force || age < minReload) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := ks.load(ctx); err != nil {
+		ks.log.Error(ctx, "keystore", "status", "reloading signing keys", "ERROR", err)
+
+		// Evita tentar de novo a cada requisição enquanto o banco não volta.
+		ks.mu.Lock()
+		ks.loadedAt = time.Now().Add(-ks.ttl + minReload)
+		ks.mu.Unlock()
+	}
+}
+
+func (ks *KeyStore) load(ctx context.Context) error {
+	keys, err := ks.core.QueryValid(ctx)
+	if err != nil {
+		return fmt.Errorf("queryValid: %w", err)
+	}
+
+	byKID := make(map[string]Key, len(keys))
+	var active string
+
+	for _, k := range keys {
+		byKID[k.KID] = k
+		if k.Active {
+			active = k.KID
+		}
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys = byKID
+	ks.active = active
+	ks.loadedAt = time.Now()
+
+	return nil
+}
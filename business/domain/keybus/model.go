@@ -0,0 +1,24 @@
+package keybus
+
+import "time"
+
+// Key represents a key used to sign and verify the JWTs.
+type Key struct {
+	KID        string
+	PrivatePEM string
+	PublicPEM  string
+	Active     bool
+	NotAfter   time.Time
+	CreatedAt  time.Time
+}
+
+// Valid reports whether the key can still verify tokens at the time.
+func (k Key) Valid(now time.Time) bool {
+	return k.NotAfter.IsZero() || now.Before(k.NotAfter)
+}
+
+// NewKey is what we require from clients when adding a Key.
+type NewKey struct {
+	KID        string
+	PrivatePEM string
+}
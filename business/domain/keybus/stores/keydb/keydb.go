@@ -0,0 +1,109 @@
+// Package keydb contains signing key related CRUD functionality.
+package keydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/keybus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for signing key database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (keybus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new signing key into the database.
+func (s *Store) Create(ctx context.Context, k keybus.Key) error {
+	const q = `
+	INSERT INTO "public"."signing_keys"
+		(kid, private_pem, public_pem, active, not_after, created_at)
+	VALUES
+		(:kid, :private_pem, :public_pem, :active, :not_after, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBKey(k)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) && dupErr.Constraint == "pk_signing_keys" {
+			return fmt.Errorf("namedexeccontext: %w", keybus.ErrUniqueKID)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Retire deactivates the active key, keeping it valid until notAfter.
+func (s *Store) Retire(ctx context.Context, notAfter time.Time) error {
+	data := map[string]any{
+		"not_after": notAfter.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		"public"."signing_keys"
+	SET
+		active = false,
+		not_after = LEAST(COALESCE(not_after, :not_after), :not_after)
+	WHERE
+		active`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryValid retrieves the keys not expired at the time, the active first.
+func (s *Store) QueryValid(ctx context.Context, now time.Time) ([]keybus.Key, error) {
+	data := map[string]any{
+		"now": now.UTC(),
+	}
+
+	const q = `
+	SELECT
+		kid, private_pem, public_pem, active, not_after, created_at
+	FROM
+		"public"."signing_keys"
+	WHERE
+		not_after IS NULL OR not_after > :now
+	ORDER BY
+		active DESC, created_at DESC`
+
+	var dbKeys []keyDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbKeys); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusKeys(dbKeys), nil
+}
@@ -0,0 +1,50 @@
+package keydb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/keybus"
+)
+
+type keyDB struct {
+	KID        string       `db:"kid"`
+	PrivatePEM string       `db:"private_pem"`
+	PublicPEM  string       `db:"public_pem"`
+	Active     bool         `db:"active"`
+	NotAfter   sql.NullTime `db:"not_after"`
+	CreatedAt  time.Time    `db:"created_at"`
+}
+
+func toDBKey(bus keybus.Key) keyDB {
+	return keyDB{
+		KID:        bus.KID,
+		PrivatePEM: bus.PrivatePEM,
+		PublicPEM:  bus.PublicPEM,
+		Active:     bus.Active,
+		NotAfter:   sql.NullTime{Time: bus.NotAfter.UTC(), Valid: !bus.NotAfter.IsZero()},
+		CreatedAt:  bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusKeys(dbs []keyDB) []keybus.Key {
+	bus := make([]keybus.Key, len(dbs))
+
+	for i, db := range dbs {
+		var notAfter time.Time
+		if db.NotAfter.Valid {
+			notAfter = db.NotAfter.Time.In(time.Local)
+		}
+
+		bus[i] = keybus.Key{
+			KID:        db.KID,
+			PrivatePEM: db.PrivatePEM,
+			PublicPEM:  db.PublicPEM,
+			Active:     db.Active,
+			NotAfter:   notAfter,
+			CreatedAt:  db.CreatedAt.In(time.Local),
+		}
+	}
+
+	return bus
+}
@@ -0,0 +1,427 @@
+// Package ldapbus provides business access to the LDAP and Active Directory
+// servers of the tenants. A user who logs in through the domain of a tenant
+// with a directory is authenticated there and the local user is provisioned
+// on the first login.
+package ldapbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/ldap"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("directory not found")
+	ErrInvalidSettings = errors.New("invalid directory settings")
+	ErrInvalidRole     = errors.New("the directory can only grant the roles of a tenant")
+	ErrConnection      = errors.New("directory connection failed")
+
+	// ErrNotManaged is returned by Authenticate when the login is not for
+	// the directory: the domain has no directory, the user is not in it or
+	// the local user is not one the directory may manage. The caller falls
+	// back to the local password.
+	ErrNotManaged = errors.New("user not managed by a directory")
+)
+
+// Set of defaults for the settings left empty. The filter matches both
+// OpenLDAP and Active Directory accounts.
+const (
+	DefaultUserFilter     = "(|(mail={email})(userPrincipalName={email}))"
+	DefaultGroupAttribute = "memberOf"
+	DefaultNameAttribute  = "displayName"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Save(ctx context.Context, d Directory) error
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+	QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Directory, error)
+}
+
+// Core manages the set of APIs for directory access.
+type Core struct {
+	log       *logger.Logger
+	storer    Storer
+	box       *crypto.Box
+	userBus   *userbus.Core
+	tenantBus *tenantbus.Core
+}
+
+// NewCore constructs a core for directory api access. The box seals the
+// bind password of the directories.
+func NewCore(log *logger.Logger, storer Storer, box *crypto.Box, userBus *userbus.Core, tenantBus *tenantbus.Core) *Core {
+	return &Core{
+		log:       log,
+		storer:    storer,
+		box:       box,
+		userBus:   userBus,
+		tenantBus: tenantBus,
+	}
+}
+
+// Save creates or replaces the directory of the tenant.
+func (c *Core) Save(ctx context.Context, tenantID uuid.UUID, sd SaveDirectory) (Directory, error) {
+	ctx, span := otel.AddSpan(ctx, "business.ldapbus.save")
+	defer span.End()
+
+	if sd.UserFilter == "" {
+		sd.UserFilter = DefaultUserFilter
+	}
+
+	if sd.GroupAttribute == "" {
+		sd.GroupAttribute = DefaultGroupAttribute
+	}
+
+	if sd.NameAttribute == "" {
+		sd.NameAttribute = DefaultNameAttribute
+	}
+
+	if err := validate(sd); err != nil {
+		return Directory{}, err
+	}
+
+	now := time.Now()
+
+	d, err := c.storer.QueryByTenant(ctx, tenantID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		if sd.BindPassword == nil {
+			return Directory{}, fmt.Errorf("%w: bind password is required", ErrInvalidSettings)
+		}
+		d = Directory{
+			TenantID:  tenantID,
+			CreatedAt: now,
+		}
+	case err != nil:
+		return Directory{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	if sd.BindPassword != nil {
+		sealed, err := c.box.Seal([]byte(*sd.BindPassword), tenantID[:])
+		if err != nil {
+			return Directory{}, fmt.Errorf("seal bind password: %w", err)
+		}
+		d.BindPassword = sealed
+	}
+
+	d.URL = sd.URL
+	d.StartTLS = sd.StartTLS
+	d.BindDN = sd.BindDN
+	d.BaseDN = sd.BaseDN
+	d.UserFilter = sd.UserFilter
+	d.GroupAttribute = sd.GroupAttribute
+	d.NameAttribute = sd.NameAttribute
+	d.RoleMappings = sd.RoleMappings
+	d.DefaultRole = sd.DefaultRole
+	d.Enabled = sd.Enabled
+	d.UpdatedAt = now
+
+	if err := c.storer.Save(ctx, d); err != nil {
+		return Directory{}, fmt.Errorf("save: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return d, nil
+}
+
+// Delete removes the directory of the tenant. The users it provisioned are
+// kept and can only log in again with a local password.
+func (c *Core) Delete(ctx context.Context, d Directory) error {
+	ctx, span := otel.AddSpan(ctx, "business.ldapbus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, d.TenantID); err != nil {
+		return fmt.Errorf("delete: tenantID[%s]: %w", d.TenantID, err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the directory of the tenant.
+func (c *Core) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Directory, error) {
+	ctx, span := otel.AddSpan(ctx, "business.ldapbus.queryByTenant")
+	defer span.End()
+
+	d, err := c.storer.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return Directory{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return d, nil
+}
+
+// Test connects to the directory and binds with the service account.
+func (c *Core) Test(ctx context.Context, d Directory) error {
+	ctx, span := otel.AddSpan(ctx, "business.ldapbus.test")
+	defer span.End()
+
+	conn, err := c.connect(ctx, d)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+
+	return nil
+}
+
+// Authenticate validates the credentials against the directory of the
+// tenant that owns the domain. The local user is created on the first login,
+// as a member of the tenant with access to the dashboard of the domain, and
+// its role follows the groups of the directory on every login.
+//
+// A wrong password returns userbus.ErrAuthenticationFailure. ErrNotManaged
+// means the login is not for the directory.
+func (c *Core) Authenticate(ctx context.Context, domain string, email mail.Address, pass string) (userbus.User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.ldapbus.authenticate")
+	defer span.End()
+
+	td, err := c.tenantBus.ResolveDomain(ctx, domain)
+	if err != nil {
+		if errors.Is(err, tenantbus.ErrDomainNotFound) {
+			return userbus.User{}, ErrNotManaged
+		}
+		return userbus.User{}, fmt.Errorf("resolveDomain: %w", err)
+	}
+
+	d, err := c.storer.QueryByTenant(ctx, td.TenantID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return userbus.User{}, ErrNotManaged
+		}
+		return userbus.User{}, fmt.Errorf("query: tenantID[%s]: %w", td.TenantID, err)
+	}
+
+	if !d.Enabled {
+		return userbus.User{}, ErrNotManaged
+	}
+
+	entry, err := c.lookup(ctx, d, email, pass)
+	if err != nil {
+		return userbus.User{}, err
+	}
+
+	r, ok := d.roleFor(entry.Values(d.GroupAttribute))
+	if !ok || !tenantRole(r) {
+		return userbus.User{}, fmt.Errorf("dn[%s]: no group mapped to a role: %w", entry.DN, ErrNotManaged)
+	}
+
+	return c.provision(ctx, td, d, email, entry, r)
+}
+
+// =============================================================================
+
+// connect dials the directory and binds with the service account.
+func (c *Core) connect(ctx context.Context, d Directory) (*ldap.Conn, error) {
+	bindPassword, err := c.box.Open(d.BindPassword, d.TenantID[:])
+	if err != nil {
+		return nil, fmt.Errorf("open bind password: tenantID[%s]: %w", d.TenantID, err)
+	}
+
+	cfg := ldap.Config{
+		URL:      d.URL,
+		StartTLS: d.StartTLS,
+	}
+
+	conn, err := ldap.Dial(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConnection, err)
+	}
+
+	if err := conn.Bind(ctx, d.BindDN, string(bindPassword)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w: bind %s: %s", ErrConnection, d.BindDN, err)
+	}
+
+	return conn, nil
+}
+
+// lookup finds the entry of the email and binds as it with the password.
+func (c *Core) lookup(ctx context.Context, d Directory, email mail.Address, pass string) (ldap.Entry, error) {
+	conn, err := c.connect(ctx, d)
+	if err != nil {
+		return ldap.Entry{}, err
+	}
+	defer conn.Close()
+
+	sr := ldap.SearchRequest{
+		BaseDN:     d.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     strings.ReplaceAll(d.UserFilter, "{email}", ldap.EscapeFilter(email.Address)),
+		Attributes: []string{d.GroupAttribute, d.NameAttribute},
+		SizeLimit:  2,
+	}
+
+	entries, err := conn.Search(ctx, sr)
+	if err != nil {
+		return ldap.Entry{}, fmt.Errorf("search: %w", err)
+	}
+
+	switch len(entries) {
+	case 0:
+		return ldap.Entry{}, ErrNotManaged
+	case 1:
+	default:
+		return ldap.Entry{}, fmt.Errorf("search: email[%s] matches more than one entry", email.Address)
+	}
+
+	entry := entries[0]
+
+	if err := conn.Bind(ctx, entry.DN, pass); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return ldap.Entry{}, fmt.Errorf("bind: dn[%s]: %w", entry.DN, userbus.ErrAuthenticationFailure)
+		}
+		return ldap.Entry{}, fmt.Errorf("bind: dn[%s]: %w", entry.DN, err)
+	}
+
+	return entry, nil
+}
+
+// provision creates or updates the local user of the directory entry. Users
+// outside the tenant and the roles that act across tenants are never
+// touched: a directory only manages the members of its own tenant. A disabled
+// user stays disabled and gets no access.
+func (c *Core) provision(ctx context.Context, td tenantbus.TenantDashboard, d Directory, email mail.Address, entry ldap.Entry, r role.Role) (userbus.User, error) {
+	scope, err := c.tenantBus.EmailScope(ctx, td.TenantID)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("emailScope: tenantID[%s]: %w", td.TenantID, err)
+	}
+
+	usr, err := c.userBus.QueryByEmail(ctx, scope, email)
+	switch {
+	case errors.Is(err, userbus.ErrNotFound):
+		pass, err := password.Random()
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("random password: %w", err)
+		}
+
+		nu := userbus.NewUser{
+			Name:       directoryName(entry.Get(d.NameAttribute), email),
+			Email:      email,
+			Role:       r,
+			Password:   pass,
+			EmailScope: scope,
+		}
+
+		usr, err = c.userBus.Create(ctx, nu)
+		if err != nil {
+			// O e-mail ainda pertence a um usuário removido, que a consulta
+			// não enxerga; o diretório não o traz de volta.
+			if errors.Is(err, userbus.ErrUniqueEmail) {
+				return userbus.User{}, fmt.Errorf("create: email[%s]: %w", email.Address, ErrNotManaged)
+			}
+			return userbus.User{}, fmt.Errorf("create: email[%s]: %w", email.Address, err)
+		}
+
+		c.log.Info(ctx, "ldap: user provisioned", "userID", usr.ID, "tenantID", td.TenantID)
+
+	case err != nil:
+		return userbus.User{}, fmt.Errorf("queryByEmail: %w", err)
+
+	default:
+		if !tenantRole(usr.Role) {
+			return userbus.User{}, ErrNotManaged
+		}
+
+		// Quem não é membro de nenhum cliente também fica de fora: o
+		// diretório do cliente não pode assumir uma conta pelo e-mail.
+		tenantID, err := c.tenantBus.QueryTenantIDByUserID(ctx, usr.ID)
+		switch {
+		case errors.Is(err, tenantbus.ErrNotFound):
+			return userbus.User{}, ErrNotManaged
+		case err != nil:
+			return userbus.User{}, fmt.Errorf("queryTenantIDByUserID: userID[%s]: %w", usr.ID, err)
+		case tenantID != td.TenantID:
+			return userbus.User{}, ErrNotManaged
+		}
+
+		if !usr.Enabled {
+			return userbus.User{}, fmt.Errorf("userID[%s]: %w", usr.ID, userbus.ErrDisabled)
+		}
+
+		if !usr.Role.Equal(r) {
+			usr, err = c.userBus.Update(ctx, usr, userbus.UpdateUser{Role: &r})
+			if err != nil {
+				return userbus.User{}, fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+			}
+		}
+	}
+
+	// A concessão é idempotente e refeita a cada login, o que também
+	// completa um provisionamento interrompido.
+	if err := c.tenantBus.GrantUserAccessToDashboard(ctx, usr.ID, td.DashboardID); err != nil {
+		return userbus.User{}, fmt.Errorf("grantUserAccessToDashboard: userID[%s]: %w", usr.ID, err)
+	}
+
+	return usr, nil
+}
+
+// validate checks the settings of a directory.
+func validate(sd SaveDirectory) error {
+	u, err := url.Parse(sd.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Hostname() == "" {
+		return fmt.Errorf("%w: url must be ldap://host or ldaps://host", ErrInvalidSettings)
+	}
+
+	if sd.BindDN == "" || sd.BaseDN == "" {
+		return fmt.Errorf("%w: bind dn and base dn are required", ErrInvalidSettings)
+	}
+
+	if !strings.Contains(sd.UserFilter, "{email}") {
+		return fmt.Errorf("%w: user filter must contain {email}", ErrInvalidSettings)
+	}
+
+	if err := ldap.CheckFilter(strings.ReplaceAll(sd.UserFilter, "{email}", "x")); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSettings, err)
+	}
+
+	for _, m := range sd.RoleMappings {
+		if strings.TrimSpace(m.Group) == "" {
+			return fmt.Errorf("%w: role mapping without group", ErrInvalidSettings)
+		}
+		if !tenantRole(m.Role) {
+			return ErrInvalidRole
+		}
+	}
+
+	if sd.DefaultRole != nil && !tenantRole(*sd.DefaultRole) {
+		return ErrInvalidRole
+	}
+
+	return nil
+}
+
+// directoryName returns a valid name for the user, from the name in the
+// directory or the email.
+func directoryName(value string, email mail.Address) name.Name {
+	local, _, _ := strings.Cut(email.Address, "@")
+	local = strings.NewReplacer(".", " ", "_", " ").Replace(local)
+
+	n, err := name.Derive(value, local)
+	if err != nil {
+		return name.MustParse("Directory User")
+	}
+
+	return n
+}
+
+// tenantRole reports whether the role is one of the roles of a tenant, the
+// only ones a directory may grant. ADMIN and ANALYST act across tenants.
+func tenantRole(r role.Role) bool {
+	return r.Equal(role.User) || r.Equal(role.TenantAdmin)
+}
@@ -0,0 +1,73 @@
+package ldapbus
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Directory represents the LDAP or Active Directory server a tenant
+// authenticates its users against. BindPassword holds the sealed password of
+// the service account, it is only opened by the core to connect.
+type Directory struct {
+	TenantID       uuid.UUID
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindPassword   []byte
+	BaseDN         string
+	UserFilter     string
+	GroupAttribute string
+	NameAttribute  string
+	RoleMappings   []RoleMapping
+	DefaultRole    *role.Role
+	Enabled        bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RoleMapping gives the role to the members of the group, by the DN of the
+// group.
+type RoleMapping struct {
+	Group string
+	Role  role.Role
+}
+
+// roleFor returns the role of the first mapping whose group is among the
+// groups, or the default role. Group DNs are compared without regard to
+// case, as the directory does.
+func (d Directory) roleFor(groups []string) (role.Role, bool) {
+	for _, m := range d.RoleMappings {
+		for _, g := range groups {
+			if strings.EqualFold(strings.TrimSpace(g), m.Group) {
+				return m.Role, true
+			}
+		}
+	}
+
+	if d.DefaultRole != nil {
+		return *d.DefaultRole, true
+	}
+
+	return role.Role{}, false
+}
+
+// SaveDirectory contains information needed to configure the directory of a
+// tenant. The bind password is given in plain text and sealed by the core;
+// it can be left out to keep the current one. Empty attributes and filter
+// take the defaults.
+type SaveDirectory struct {
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindPassword   *string
+	BaseDN         string
+	UserFilter     string
+	GroupAttribute string
+	NameAttribute  string
+	RoleMappings   []RoleMapping
+	DefaultRole    *role.Role
+	Enabled        bool
+}
@@ -0,0 +1,107 @@
+// Package ldapdb contains directory related CRUD functionality.
+package ldapdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for directory database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Save inserts the directory of the tenant or replaces the current one.
+func (s *Store) Save(ctx context.Context, d ldapbus.Directory) error {
+	const q = `
+	INSERT INTO "public"."tenant_ldap"
+		(tenant_id, url, start_tls, bind_dn, bind_password, base_dn, user_filter, group_attribute,
+		 name_attribute, role_mappings, default_role, enabled, created_at, updated_at)
+	VALUES
+		(:tenant_id, :url, :start_tls, :bind_dn, :bind_password, :base_dn, :user_filter, :group_attribute,
+		 :name_attribute, :role_mappings, :default_role, :enabled, :created_at, :updated_at)
+	ON CONFLICT (tenant_id) DO UPDATE SET
+		url = EXCLUDED.url,
+		start_tls = EXCLUDED.start_tls,
+		bind_dn = EXCLUDED.bind_dn,
+		bind_password = EXCLUDED.bind_password,
+		base_dn = EXCLUDED.base_dn,
+		user_filter = EXCLUDED.user_filter,
+		group_attribute = EXCLUDED.group_attribute,
+		name_attribute = EXCLUDED.name_attribute,
+		role_mappings = EXCLUDED.role_mappings,
+		default_role = EXCLUDED.default_role,
+		enabled = EXCLUDED.enabled,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDirectory(d)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the directory of the tenant from the database.
+func (s *Store) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."tenant_ldap"
+	WHERE
+		tenant_id = :tenant_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the directory of the tenant from the database.
+func (s *Store) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (ldapbus.Directory, error) {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, url, start_tls, bind_dn, bind_password, base_dn, user_filter, group_attribute,
+		name_attribute, role_mappings, default_role, enabled, created_at, updated_at
+	FROM
+		"public"."tenant_ldap"
+	WHERE
+		tenant_id = :tenant_id`
+
+	var dbDir directoryDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbDir); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return ldapbus.Directory{}, fmt.Errorf("db: %w", ldapbus.ErrNotFound)
+		}
+		return ldapbus.Directory{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusDirectory(dbDir)
+}
@@ -0,0 +1,117 @@
+package ldapdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/ldapbus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type directoryDB struct {
+	TenantID       uuid.UUID      `db:"tenant_id"`
+	URL            string         `db:"url"`
+	StartTLS       bool           `db:"start_tls"`
+	BindDN         string         `db:"bind_dn"`
+	BindPassword   []byte         `db:"bind_password"`
+	BaseDN         string         `db:"base_dn"`
+	UserFilter     string         `db:"user_filter"`
+	GroupAttribute string         `db:"group_attribute"`
+	NameAttribute  string         `db:"name_attribute"`
+	RoleMappings   string         `db:"role_mappings"`
+	DefaultRole    sql.NullString `db:"default_role"`
+	Enabled        bool           `db:"enabled"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+type roleMappingDB struct {
+	Group string `json:"group"`
+	Role  string `json:"role"`
+}
+
+func toDBDirectory(bus ldapbus.Directory) directoryDB {
+	mappings := make([]roleMappingDB, len(bus.RoleMappings))
+	for i, m := range bus.RoleMappings {
+		mappings[i] = roleMappingDB{
+			Group: m.Group,
+			Role:  m.Role.String(),
+		}
+	}
+
+	roleMappings := "[]"
+	if data, err := json.Marshal(mappings); err == nil {
+		roleMappings = string(data)
+	}
+
+	var defaultRole sql.NullString
+	if bus.DefaultRole != nil {
+		defaultRole = sql.NullString{String: bus.DefaultRole.String(), Valid: true}
+	}
+
+	return directoryDB{
+		TenantID:       bus.TenantID,
+		URL:            bus.URL,
+		StartTLS:       bus.StartTLS,
+		BindDN:         bus.BindDN,
+		BindPassword:   bus.BindPassword,
+		BaseDN:         bus.BaseDN,
+		UserFilter:     bus.UserFilter,
+		GroupAttribute: bus.GroupAttribute,
+		NameAttribute:  bus.NameAttribute,
+		RoleMappings:   roleMappings,
+		DefaultRole:    defaultRole,
+		Enabled:        bus.Enabled,
+		CreatedAt:      bus.CreatedAt.UTC(),
+		UpdatedAt:      bus.UpdatedAt.UTC(),
+	}
+}
+
+func toBusDirectory(db directoryDB) (ldapbus.Directory, error) {
+	var mappings []roleMappingDB
+	if err := json.Unmarshal([]byte(db.RoleMappings), &mappings); err != nil {
+		return ldapbus.Directory{}, fmt.Errorf("parse role mappings: %w", err)
+	}
+
+	roleMappings := make([]ldapbus.RoleMapping, len(mappings))
+	for i, m := range mappings {
+		r, err := role.Parse(m.Role)
+		if err != nil {
+			return ldapbus.Directory{}, fmt.Errorf("parse role mapping: %w", err)
+		}
+
+		roleMappings[i] = ldapbus.RoleMapping{
+			Group: m.Group,
+			Role:  r,
+		}
+	}
+
+	var defaultRole *role.Role
+	if db.DefaultRole.Valid {
+		r, err := role.Parse(db.DefaultRole.String)
+		if err != nil {
+			return ldapbus.Directory{}, fmt.Errorf("parse default role: %w", err)
+		}
+		defaultRole = &r
+	}
+
+	return ldapbus.Directory{
+		TenantID:       db.TenantID,
+		URL:            db.URL,
+		StartTLS:       db.StartTLS,
+		BindDN:         db.BindDN,
+		BindPassword:   db.BindPassword,
+		BaseDN:         db.BaseDN,
+		UserFilter:     db.UserFilter,
+		GroupAttribute: db.GroupAttribute,
+		NameAttribute:  db.NameAttribute,
+		RoleMappings:   roleMappings,
+		DefaultRole:    defaultRole,
+		Enabled:        db.Enabled,
+		CreatedAt:      db.CreatedAt.In(time.Local),
+		UpdatedAt:      db.UpdatedAt.In(time.Local),
+	}, nil
+}
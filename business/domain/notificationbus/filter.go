@@ -0,0 +1,13 @@
+package notificationbus
+
+import (
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a notification query can be
+// filtered on.
+type QueryFilter struct {
+	UserID *uuid.UUID
+	Kind   *string
+	Unread *bool
+}
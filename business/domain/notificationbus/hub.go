@@ -0,0 +1,84 @@
+package notificationbus
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// subscriberBuffer is how many notifications a subscriber can fall behind
+// before new ones are dropped.
+const subscriberBuffer = 16
+
+// hub fans the values published for each user out to the subscribers of the
+// user.
+type hub[T any] struct {
+	mu     sync.Mutex
+	buffer int
+	subs   map[uuid.UUID]map[chan T]struct{}
+}
+
+func newHub[T any](buffer int) *hub[T] {
+	return &hub[T]{
+		buffer: buffer,
+		subs:   make(map[uuid.UUID]map[chan T]struct{}),
+	}
+}
+
+func (h *hub[T]) subscribe(userID uuid.UUID) (<-chan T, func()) {
+	ch := make(chan T, h.buffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	chs, exists := h.subs[userID]
+	if !exists {
+		chs = make(map[chan T]struct{})
+		h.subs[userID] = chs
+	}
+	chs[ch] = struct{}{}
+
+	var once sync.Once
+
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+		})
+	}
+
+	return ch, cancel
+}
+
+func (h *hub[T]) publish(userID uuid.UUID, v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[userID] {
+		send(ch, v)
+	}
+}
+
+func (h *hub[T]) broadcast(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, chs := range h.subs {
+		for ch := range chs {
+			send(ch, v)
+		}
+	}
+}
+
+// send delivers the value unless the subscriber is behind.
+func send[T any](ch chan T, v T) {
+	select {
+	case ch <- v:
+	default:
+	}
+}
@@ -0,0 +1,40 @@
+package notificationbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of kinds of notifications. The UI renders the text of each kind from
+// its Data.
+const (
+	KindInviteAccepted = "invite.accepted"
+	KindReportReady    = "report.ready"
+	KindReportFailed   = "report.failed"
+	KindAccessGranted  = "access.granted"
+)
+
+// Notification represents a message in the inbox of a user. TenantID and
+// EntityID are uuid.Nil when the notification does not refer to them.
+type Notification struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TenantID   uuid.UUID
+	Kind       string
+	EntityType string
+	EntityID   uuid.UUID
+	Data       map[string]any
+	ReadAt     *time.Time
+	CreatedAt  time.Time
+}
+
+// NewNotification contains the information needed to notify a user.
+type NewNotification struct {
+	UserID     uuid.UUID
+	TenantID   uuid.UUID
+	Kind       string
+	EntityType string
+	EntityID   uuid.UUID
+	Data       map[string]any
+}
@@ -0,0 +1,208 @@
+// Package notificationbus provides business access to the notifications, the
+// inbox of each user behind the bell of the dashboard. The notifications are
+// kept until read and purged after the retention; the API instances push the
+// new ones to the connected users.
+package notificationbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Retention is how long a notification is kept, read or not.
+const Retention = 90 * 24 * time.Hour
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound = errors.New("notification not found")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, n Notification) error
+	Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Notification, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID, now time.Time) error
+	MarkAllRead(ctx context.Context, userID uuid.UUID, now time.Time) (int, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// Core manages the set of APIs for notification access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+	hub    *hub[Notification]
+	access *hub[struct{}]
+}
+
+// NewCore constructs a core for notification api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+		hub:    newHub[Notification](subscriberBuffer),
+		access: newHub[struct{}](1),
+	}
+}
+
+// NewWithTx constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:    c.log,
+		storer: storer,
+		hub:    c.hub,
+		access: c.access,
+	}, nil
+}
+
+// Create adds a notification to the inbox of the user. Inside a transaction
+// it is only announced to the connected users on commit.
+func (c *Core) Create(ctx context.Context, nn NewNotification) (Notification, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.create")
+	defer span.End()
+
+	n := Notification{
+		ID:         uuid.New(),
+		UserID:     nn.UserID,
+		TenantID:   nn.TenantID,
+		Kind:       nn.Kind,
+		EntityType: nn.EntityType,
+		EntityID:   nn.EntityID,
+		Data:       nn.Data,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, n); err != nil {
+		return Notification{}, fmt.Errorf("create: %w", err)
+	}
+
+	return n, nil
+}
+
+// Query retrieves a list of notifications, most recent first.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, page page.Page) ([]Notification, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.query")
+	defer span.End()
+
+	notifications, err := c.storer.Query(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// Count returns the total number of notifications matching the filter.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
+// CountUnread returns the number of notifications the user has not read.
+func (c *Core) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.countUnread")
+	defer span.End()
+
+	unread := true
+
+	n, err := c.storer.Count(ctx, QueryFilter{UserID: &userID, Unread: &unread})
+	if err != nil {
+		return 0, fmt.Errorf("count: userID[%s]: %w", userID, err)
+	}
+
+	return n, nil
+}
+
+// MarkRead marks a notification of the user as read. Marking it again keeps
+// the time it was first read.
+func (c *Core) MarkRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.markRead")
+	defer span.End()
+
+	if err := c.storer.MarkRead(ctx, userID, notificationID, time.Now()); err != nil {
+		return fmt.Errorf("markRead: notificationID[%s]: %w", notificationID, err)
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every notification of the user as read and returns how
+// many were unread.
+func (c *Core) MarkAllRead(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.markAllRead")
+	defer span.End()
+
+	n, err := c.storer.MarkAllRead(ctx, userID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("markAllRead: userID[%s]: %w", userID, err)
+	}
+
+	return n, nil
+}
+
+// PurgeExpired removes the notifications older than the retention and
+// returns how many were removed.
+func (c *Core) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.notificationbus.purgeExpired")
+	defer span.End()
+
+	n, err := c.storer.DeleteBefore(ctx, time.Now().Add(-Retention))
+	if err != nil {
+		return 0, fmt.Errorf("deleteBefore: %w", err)
+	}
+
+	return n, nil
+}
+
+// =============================================================================
+
+// Subscribe returns a channel receiving the new notifications of the user
+// while the subscription lasts, and the function ending it. Notifications are
+// dropped when the subscriber falls behind; the inbox still has them.
+func (c *Core) Subscribe(userID uuid.UUID) (<-chan Notification, func()) {
+	return c.hub.subscribe(userID)
+}
+
+// Publish delivers a new notification to the subscribers of its user on this
+// instance. It is fed by the announcements of the database, so every
+// instance sees the notifications created by the others and by the worker.
+func (c *Core) Publish(n Notification) {
+	c.hub.publish(n.UserID, n)
+}
+
+// SubscribeAccess returns a channel signaled when the permissions of the user
+// change while the subscription lasts, and the function ending it. Changes
+// made while a signal is pending are merged into it.
+func (c *Core) SubscribeAccess(userID uuid.UUID) (<-chan struct{}, func()) {
+	return c.access.subscribe(userID)
+}
+
+// PublishAccessChange signals the subscribers of the user on this instance
+// that the ACLs or the role of the user changed.
+func (c *Core) PublishAccessChange(userID uuid.UUID) {
+	c.access.publish(userID, struct{}{})
+}
+
+// PublishAccessChangeAll signals every subscriber on this instance, for the
+// changes that reach every user like a role policy.
+func (c *Core) PublishAccessChangeAll() {
+	c.access.broadcast(struct{}{})
+}
@@ -0,0 +1,36 @@
+package notificationdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+)
+
+func applyFilter(filter notificationbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.Kind != nil {
+		data["kind"] = *filter.Kind
+		wc = append(wc, "kind = :kind")
+	}
+
+	if filter.Unread != nil {
+		switch *filter.Unread {
+		case true:
+			wc = append(wc, "read_at IS NULL")
+		default:
+			wc = append(wc, "read_at IS NOT NULL")
+		}
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,86 @@
+package notificationdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+)
+
+type notificationDB struct {
+	ID         uuid.UUID     `db:"notification_id"`
+	UserID     uuid.UUID     `db:"user_id"`
+	TenantID   uuid.NullUUID `db:"tenant_id"`
+	Kind       string        `db:"kind"`
+	EntityType string        `db:"entity_type"`
+	EntityID   uuid.NullUUID `db:"entity_id"`
+	Data       string        `db:"data"`
+	ReadAt     sql.NullTime  `db:"read_at"`
+	CreatedAt  time.Time     `db:"created_at"`
+}
+
+func toDBNotification(bus notificationbus.Notification) notificationDB {
+	data := "{}"
+	if len(bus.Data) > 0 {
+		if b, err := json.Marshal(bus.Data); err == nil {
+			data = string(b)
+		}
+	}
+
+	db := notificationDB{
+		ID:         bus.ID,
+		UserID:     bus.UserID,
+		TenantID:   toDBNullUUID(bus.TenantID),
+		Kind:       bus.Kind,
+		EntityType: bus.EntityType,
+		EntityID:   toDBNullUUID(bus.EntityID),
+		Data:       data,
+		CreatedAt:  bus.CreatedAt.UTC(),
+	}
+
+	if bus.ReadAt != nil {
+		db.ReadAt = sql.NullTime{Time: bus.ReadAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusNotification(db notificationDB) notificationbus.Notification {
+	var data map[string]any
+	if db.Data != "" {
+		_ = json.Unmarshal([]byte(db.Data), &data)
+	}
+
+	bus := notificationbus.Notification{
+		ID:         db.ID,
+		UserID:     db.UserID,
+		TenantID:   db.TenantID.UUID,
+		Kind:       db.Kind,
+		EntityType: db.EntityType,
+		EntityID:   db.EntityID.UUID,
+		Data:       data,
+		CreatedAt:  db.CreatedAt.In(time.Local),
+	}
+
+	if db.ReadAt.Valid {
+		t := db.ReadAt.Time.In(time.Local)
+		bus.ReadAt = &t
+	}
+
+	return bus
+}
+
+func toBusNotifications(dbs []notificationDB) []notificationbus.Notification {
+	bus := make([]notificationbus.Notification, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusNotification(db)
+	}
+
+	return bus
+}
+
+func toDBNullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
@@ -0,0 +1,211 @@
+// Package notificationdb contains notification related CRUD functionality.
+package notificationdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for notification database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (notificationbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new notification into the database.
+func (s *Store) Create(ctx context.Context, n notificationbus.Notification) error {
+	const q = `
+	INSERT INTO "public"."notification"
+		(notification_id, user_id, tenant_id, kind, entity_type, entity_id, data, created_at)
+	VALUES
+		(:notification_id, :user_id, :tenant_id, :kind, :entity_type, :entity_id, :data, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBNotification(n)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of notifications from the database, most recent
+// first.
+func (s *Store) Query(ctx context.Context, filter notificationbus.QueryFilter, page page.Page) ([]notificationbus.Notification, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		notification_id, user_id, tenant_id, kind, entity_type, entity_id, data, read_at, created_at
+	FROM
+		"public"."notification"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY created_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbNotifications []notificationDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbNotifications); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusNotifications(dbNotifications), nil
+}
+
+// Count returns the total number of notifications in the DB.
+func (s *Store) Count(ctx context.Context, filter notificationbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."notification"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// MarkRead sets the time the notification of the user was read, keeping the
+// first one.
+func (s *Store) MarkRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID, now time.Time) error {
+	data := struct {
+		ID     string    `db:"notification_id"`
+		UserID string    `db:"user_id"`
+		ReadAt time.Time `db:"read_at"`
+	}{
+		ID:     notificationID.String(),
+		UserID: userID.String(),
+		ReadAt: now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		"public"."notification"
+	SET
+		read_at = COALESCE(read_at, :read_at)
+	WHERE
+		notification_id = :notification_id AND
+		user_id = :user_id
+	RETURNING notification_id`
+
+	var dest struct {
+		ID uuid.UUID `db:"notification_id"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return notificationbus.ErrNotFound
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// MarkAllRead sets the read time of every unread notification of the user
+// and returns how many were changed.
+func (s *Store) MarkAllRead(ctx context.Context, userID uuid.UUID, now time.Time) (int, error) {
+	data := struct {
+		UserID string    `db:"user_id"`
+		ReadAt time.Time `db:"read_at"`
+	}{
+		UserID: userID.String(),
+		ReadAt: now.UTC(),
+	}
+
+	const q = `
+	WITH updated AS (
+		UPDATE
+			"public"."notification"
+		SET
+			read_at = :read_at
+		WHERE
+			user_id = :user_id AND
+			read_at IS NULL
+		RETURNING notification_id
+	)
+	SELECT count(1) FROM updated`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// DeleteBefore removes the notifications created before the specified time
+// and returns how many were removed.
+func (s *Store) DeleteBefore(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."notification"
+		WHERE
+			created_at < :before
+		RETURNING notification_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,44 @@
+package notificationdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+)
+
+// Channel is the Postgres NOTIFY channel where the database announces new
+// notifications. The payload is the inserted row in JSON.
+const Channel = "notifications"
+
+// ParseNotification decodes the payload announced on Channel.
+func ParseNotification(payload string) (notificationbus.Notification, error) {
+	var row struct {
+		ID         uuid.UUID      `json:"notification_id"`
+		UserID     uuid.UUID      `json:"user_id"`
+		TenantID   uuid.NullUUID  `json:"tenant_id"`
+		Kind       string         `json:"kind"`
+		EntityType string         `json:"entity_type"`
+		EntityID   uuid.NullUUID  `json:"entity_id"`
+		Data       map[string]any `json:"data"`
+		CreatedAt  time.Time      `json:"created_at"`
+	}
+
+	if err := json.Unmarshal([]byte(payload), &row); err != nil {
+		return notificationbus.Notification{}, err
+	}
+
+	n := notificationbus.Notification{
+		ID:         row.ID,
+		UserID:     row.UserID,
+		TenantID:   row.TenantID.UUID,
+		Kind:       row.Kind,
+		EntityType: row.EntityType,
+		EntityID:   row.EntityID.UUID,
+		Data:       row.Data,
+		CreatedAt:  row.CreatedAt.In(time.Local),
+	}
+
+	return n, nil
+}
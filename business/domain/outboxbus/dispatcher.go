@@ -0,0 +1,108 @@
+package outboxbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Settings of the dispatcher.
+const (
+	batchSize  = 50
+	claimLease = time.Minute
+	baseDelay  = 5 * time.Second
+	maxDelay   = time.Hour
+)
+
+// Dispatch publishes the pending events once and returns how many were
+// delivered. Events are claimed for a lease so several instances can dispatch
+// at the same time; a failed event is retried later with exponential backoff.
+func (c *Core) Dispatch(ctx context.Context, pub Publisher) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.outboxbus.dispatch")
+	defer span.End()
+
+	events, err := c.storer.Claim(ctx, time.Now(), claimLease, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	var published int
+	for _, e := range events {
+		err := c.openSecret(&e)
+		if err == nil {
+			err = pub.Publish(ctx, e)
+		}
+
+		if err != nil {
+			e.Attempts++
+			e.LastError = err.Error()
+			e.NextAttemptAt = time.Now().Add(retryDelay(e.Attempts))
+
+			c.log.Error(ctx, "outbox", "status", "publish failed", "eventID", e.ID, "type", e.Type, "attempts", e.Attempts, "ERROR", err)
+
+			if err := c.storer.MarkFailed(ctx, e); err != nil {
+				return published, fmt.Errorf("markFailed: eventID[%s]: %w", e.ID, err)
+			}
+			continue
+		}
+
+		if err := c.storer.MarkPublished(ctx, e.ID, time.Now()); err != nil {
+			return published, fmt.Errorf("markPublished: eventID[%s]: %w", e.ID, err)
+		}
+
+		published++
+	}
+
+	return published, nil
+}
+
+// DispatchAll publishes batches of pending events until the outbox has no
+// more events due and returns how many were published.
+func (c *Core) DispatchAll(ctx context.Context, pub Publisher) (int, error) {
+	var total int
+	for {
+		n, err := c.Dispatch(ctx, pub)
+		total += n
+
+		if err != nil {
+			return total, err
+		}
+
+		if n < batchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// openSecret replaces the sealed secret of the event with its value.
+func (c *Core) openSecret(e *Event) error {
+	if len(e.Secret) == 0 {
+		return nil
+	}
+
+	if c.box == nil {
+		return errors.New("open secret: no box configured")
+	}
+
+	secret, err := c.box.Open(e.Secret, e.ID[:])
+	if err != nil {
+		return fmt.Errorf("open secret: %w", err)
+	}
+	e.Secret = secret
+
+	return nil
+}
+
+// retryDelay returns the wait before the next attempt of an event that
+// failed the specified number of times.
+func retryDelay(attempts int) time.Duration {
+	d := baseDelay << min(attempts-1, 20)
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	return d
+}
@@ -0,0 +1,93 @@
+package outboxbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/foundation/mail"
+)
+
+// Sender delivers an email.
+type Sender interface {
+	Send(ctx context.Context, msg mail.Message) error
+}
+
+// MailConfig holds the addresses of the confirmation links. {token} is
+// replaced by the token of the link and {domain} by the domain the user
+// signed up through, e.g. "https://{domain}/register/confirm?token={token}".
+type MailConfig struct {
+	EmailChangeURL  string
+	RegistrationURL string
+}
+
+// Mail sends the confirmation links of the events carrying a token in their
+// secret. It runs inside the service, so the token never leaves it except in
+// the email to the user. Other events are ignored.
+type Mail struct {
+	sender Sender
+	cfg    MailConfig
+}
+
+// NewMail constructs a publisher sending the links through sender.
+func NewMail(sender Sender, cfg MailConfig) *Mail {
+	return &Mail{
+		sender: sender,
+		cfg:    cfg,
+	}
+}
+
+// Publish implements the Publisher interface.
+func (m *Mail) Publish(ctx context.Context, e Event) error {
+	// Sem segredo não há link: eventos gravados antes do segredo existir.
+	if len(e.Secret) == 0 {
+		return nil
+	}
+
+	token := url.QueryEscape(string(e.Secret))
+
+	var msg mail.Message
+
+	switch e.Type {
+	case TypeEmailChangeRequested:
+		var p EmailChangeRequested
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+
+		msg = mail.Message{
+			To:      p.Email,
+			Subject: "Confirme o seu novo e-mail",
+			Body: fmt.Sprintf("Para confirmar o novo e-mail da sua conta, acesse o link abaixo até %s:\n\n%s\n\nSe você não pediu a troca, ignore esta mensagem.\n",
+				p.ExpiresAt.UTC().Format("02/01/2006 15:04 MST"), link(m.cfg.EmailChangeURL, token, "")),
+		}
+
+	case TypeRegistrationRequested:
+		var p RegistrationRequested
+		if err := json.Unmarshal(e.Payload, &p); err != nil {
+			return fmt.Errorf("unmarshal: %w", err)
+		}
+
+		msg = mail.Message{
+			To:      p.Email,
+			Subject: "Confirme o seu cadastro",
+			Body: fmt.Sprintf("Para ativar a sua conta, acesse o link abaixo até %s:\n\n%s\n\nSe você não se cadastrou, ignore esta mensagem.\n",
+				p.ExpiresAt.UTC().Format("02/01/2006 15:04 MST"), link(m.cfg.RegistrationURL, token, p.Domain)),
+		}
+
+	default:
+		return nil
+	}
+
+	if err := m.sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("mail: type[%s]: %w", e.Type, err)
+	}
+
+	return nil
+}
+
+func link(tmpl string, token string, domain string) string {
+	return strings.NewReplacer("{token}", token, "{domain}", domain).Replace(tmpl)
+}
@@ -0,0 +1,113 @@
+package outboxbus
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of event types written to the outbox.
+const (
+	TypeUserCreated     = "user.created"
+	TypeRoleChanged     = "user.role_changed"
+	TypeDashboardShared = "dashboard.shared"
+
+	TypeEmailChangeRequested = "user.email_change_requested"
+	TypeEmailChanged         = "user.email_changed"
+
+	TypeRegistrationRequested = "user.registration_requested"
+	TypeRegistrationConfirmed = "user.registration_confirmed"
+)
+
+// Event is a domain event waiting in the outbox to be published. DedupKey is
+// unique per event so producers cannot write it twice and consumers can drop
+// redeliveries, since delivery is at least once.
+//
+// Secret is a value only the consumers inside the service may read, like the
+// token of a confirmation link. The store keeps it sealed and erases it once
+// the event is published; publishers receive it opened, and the broker and
+// webhook publishers never send it.
+type Event struct {
+	ID            uuid.UUID
+	Type          string
+	AggregateID   uuid.UUID
+	DedupKey      string
+	Payload       json.RawMessage
+	Secret        []byte
+	Attempts      int
+	LastError     string
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	PublishedAt   *time.Time
+}
+
+// NewEvent contains information needed to add an event to the outbox. When
+// DedupKey is empty the event ID is used. Secret is kept out of the payload,
+// see Event.
+type NewEvent struct {
+	Type        string
+	AggregateID uuid.UUID
+	DedupKey    string
+	Payload     any
+	Secret      string
+}
+
+// =============================================================================
+
+// UserCreated is the payload of TypeUserCreated.
+type UserCreated struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	Role   string    `json:"role"`
+}
+
+// RoleChanged is the payload of TypeRoleChanged.
+type RoleChanged struct {
+	UserID  uuid.UUID `json:"user_id"`
+	OldRole string    `json:"old_role"`
+	NewRole string    `json:"new_role"`
+}
+
+// EmailChangeRequested is the payload of TypeEmailChangeRequested. The
+// token of the confirmation link is the secret of the event, Mail sends the
+// link to the new address.
+type EmailChangeRequested struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EmailChanged is the payload of TypeEmailChanged, so the previous address
+// can be warned of the change.
+type EmailChanged struct {
+	UserID   uuid.UUID `json:"user_id"`
+	OldEmail string    `json:"old_email"`
+	NewEmail string    `json:"new_email"`
+}
+
+// RegistrationRequested is the payload of TypeRegistrationRequested. The
+// token of the confirmation link is the secret of the event, Mail sends the
+// link, on the domain the user signed up through, to the address.
+type RegistrationRequested struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	Domain    string    `json:"domain"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RegistrationConfirmed is the payload of TypeRegistrationConfirmed.
+type RegistrationConfirmed struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+}
+
+// DashboardShared is the payload of TypeDashboardShared.
+type DashboardShared struct {
+	ACLID       uuid.UUID  `json:"acl_id"`
+	DashboardID uuid.UUID  `json:"dashboard_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	ActorID     uuid.UUID  `json:"actor_id"`
+	Actions     []string   `json:"actions"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,107 @@
+// Package outboxbus provides business access to the event outbox. Cores add
+// events inside the same transaction as the change they describe and the
+// dispatcher publishes them afterwards, so an event is never lost nor sent
+// for a change that was rolled back.
+package outboxbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Storer defines the behavior required by the outboxbus to interact with the database.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, e Event) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, eventID uuid.UUID, now time.Time) error
+	MarkFailed(ctx context.Context, e Event) error
+}
+
+// Publisher delivers an event to a broker or webhook. It must only return nil
+// once the event was accepted by the other side.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Core manages the set of APIs for outbox access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+	box    *crypto.Box
+}
+
+// NewCore constructs a core for outbox api access. The box seals the secrets
+// of the events; without one the secrets are dropped, since no consumer
+// could read them back.
+func NewCore(log *logger.Logger, storer Storer, box *crypto.Box) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+		box:    box,
+	}
+}
+
+// NewWithTx constructs a new Core value replacing the Storer
+// value with a Storer value that is currently inside a transaction.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newWithTx: %w", err)
+	}
+
+	return NewCore(c.log, storer, c.box), nil
+}
+
+// Add writes a new event to the outbox. Callers must use a Core bound to the
+// transaction of the change for the event to be atomic with it. Adding an
+// event with a dedup key already in the outbox is a no-op.
+func (c *Core) Add(ctx context.Context, ne NewEvent) (Event, error) {
+	ctx, span := otel.AddSpan(ctx, "business.outboxbus.add")
+	defer span.End()
+
+	payload, err := json.Marshal(ne.Payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("marshal: type[%s]: %w", ne.Type, err)
+	}
+
+	now := time.Now()
+
+	e := Event{
+		ID:            uuid.New(),
+		Type:          ne.Type,
+		AggregateID:   ne.AggregateID,
+		DedupKey:      ne.DedupKey,
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+
+	if e.DedupKey == "" {
+		e.DedupKey = e.ID.String()
+	}
+
+	if ne.Secret != "" && c.box != nil {
+		sealed, err := c.box.Seal([]byte(ne.Secret), e.ID[:])
+		if err != nil {
+			return Event{}, fmt.Errorf("seal: type[%s]: %w", ne.Type, err)
+		}
+		e.Secret = sealed
+	}
+
+	if err := c.storer.Create(ctx, e); err != nil {
+		return Event{}, fmt.Errorf("create: type[%s]: %w", ne.Type, err)
+	}
+
+	e.Secret = nil
+
+	return e, nil
+}
@@ -0,0 +1,101 @@
+package outboxbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/events"
+)
+
+// envelope is the body every publisher sends for an event.
+type envelope struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func encode(e Event) ([]byte, error) {
+	body, err := json.Marshal(envelope{
+		ID:          e.ID,
+		Type:        e.Type,
+		AggregateID: e.AggregateID,
+		Payload:     e.Payload,
+		CreatedAt:   e.CreatedAt.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	return body, nil
+}
+
+// =============================================================================
+
+// Broker publishes events to a message broker. The topic is the prefix
+// followed by the event type, e.g. "spi.user.created", and the dedup key is
+// the message key.
+type Broker struct {
+	broker events.Broker
+	prefix string
+}
+
+// NewBroker constructs a publisher sending the events to broker.
+func NewBroker(broker events.Broker, prefix string) *Broker {
+	return &Broker{
+		broker: broker,
+		prefix: prefix,
+	}
+}
+
+// Publish implements the Publisher interface.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	body, err := encode(e)
+	if err != nil {
+		return err
+	}
+
+	topic := e.Type
+	if b.prefix != "" {
+		topic = b.prefix + "." + e.Type
+	}
+
+	msg := events.Message{
+		Topic: topic,
+		Key:   e.DedupKey,
+		Headers: map[string]string{
+			"Event-Id":   e.ID.String(),
+			"Event-Type": e.Type,
+		},
+		Body: body,
+	}
+
+	if err := b.broker.Publish(ctx, msg); err != nil {
+		return fmt.Errorf("broker: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// Publishers delivers each event to every publisher. An event is retried on
+// all of them if any fails, which the dedup key makes safe for consumers.
+type Publishers []Publisher
+
+// Publish implements the Publisher interface.
+func (ps Publishers) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	for _, p := range ps {
+		if err := p.Publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
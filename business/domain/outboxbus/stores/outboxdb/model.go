@@ -0,0 +1,76 @@
+package outboxdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+)
+
+type eventDB struct {
+	ID            uuid.UUID      `db:"event_id"`
+	Type          string         `db:"event_type"`
+	AggregateID   uuid.UUID      `db:"aggregate_id"`
+	DedupKey      string         `db:"dedup_key"`
+	Payload       string         `db:"payload"`
+	Secret        []byte         `db:"secret"`
+	Attempts      int            `db:"attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	CreatedAt     time.Time      `db:"created_at"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	PublishedAt   sql.NullTime   `db:"published_at"`
+}
+
+func toDBEvent(bus outboxbus.Event) eventDB {
+	db := eventDB{
+		ID:            bus.ID,
+		Type:          bus.Type,
+		AggregateID:   bus.AggregateID,
+		DedupKey:      bus.DedupKey,
+		Payload:       string(bus.Payload),
+		Secret:        bus.Secret,
+		Attempts:      bus.Attempts,
+		LastError:     sql.NullString{String: bus.LastError, Valid: bus.LastError != ""},
+		CreatedAt:     bus.CreatedAt.UTC(),
+		NextAttemptAt: bus.NextAttemptAt.UTC(),
+	}
+
+	if bus.PublishedAt != nil {
+		db.PublishedAt = sql.NullTime{Time: bus.PublishedAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusEvent(db eventDB) outboxbus.Event {
+	bus := outboxbus.Event{
+		ID:            db.ID,
+		Type:          db.Type,
+		AggregateID:   db.AggregateID,
+		DedupKey:      db.DedupKey,
+		Payload:       json.RawMessage(db.Payload),
+		Secret:        db.Secret,
+		Attempts:      db.Attempts,
+		LastError:     db.LastError.String,
+		CreatedAt:     db.CreatedAt.In(time.Local),
+		NextAttemptAt: db.NextAttemptAt.In(time.Local),
+	}
+
+	if db.PublishedAt.Valid {
+		t := db.PublishedAt.Time.In(time.Local)
+		bus.PublishedAt = &t
+	}
+
+	return bus
+}
+
+func toBusEvents(dbs []eventDB) []outboxbus.Event {
+	bus := make([]outboxbus.Event, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusEvent(db)
+	}
+
+	return bus
+}
@@ -0,0 +1,149 @@
+// Package outboxdb contains outbox related CRUD functionality.
+package outboxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for outbox database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (outboxbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new event into the outbox. An event with the same dedup
+// key is kept as is.
+func (s *Store) Create(ctx context.Context, e outboxbus.Event) error {
+	const q = `
+	INSERT INTO "public"."outbox"
+		(event_id, event_type, aggregate_id, dedup_key, payload, secret, attempts, created_at, next_attempt_at)
+	VALUES
+		(:event_id, :event_type, :aggregate_id, :dedup_key, CAST(:payload AS jsonb), :secret, :attempts, :created_at, :next_attempt_at)
+	ON CONFLICT (dedup_key) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(e)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Claim returns up to limit events due for delivery and postpones them by
+// lease, so other dispatchers skip them while they are being published.
+func (s *Store) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]outboxbus.Event, error) {
+	data := struct {
+		Now        time.Time `db:"now"`
+		LeaseUntil time.Time `db:"lease_until"`
+		Limit      int       `db:"limit"`
+	}{
+		Now:        now.UTC(),
+		LeaseUntil: now.Add(lease).UTC(),
+		Limit:      limit,
+	}
+
+	const q = `
+	UPDATE
+		"public"."outbox"
+	SET
+		next_attempt_at = :lease_until
+	WHERE
+		event_id IN (
+			SELECT
+				event_id
+			FROM
+				"public"."outbox"
+			WHERE
+				published_at IS NULL AND next_attempt_at <= :now
+			ORDER BY
+				created_at
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		event_id, event_type, aggregate_id, dedup_key, CAST(payload AS text) AS payload, secret, attempts,
+		last_error, created_at, next_attempt_at, published_at`
+
+	var dbEvents []eventDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEvents); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusEvents(dbEvents), nil
+}
+
+// MarkPublished records that the event was delivered and erases its secret.
+func (s *Store) MarkPublished(ctx context.Context, eventID uuid.UUID, now time.Time) error {
+	data := struct {
+		ID  uuid.UUID `db:"event_id"`
+		Now time.Time `db:"now"`
+	}{
+		ID:  eventID,
+		Now: now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		"public"."outbox"
+	SET
+		published_at = :now,
+		secret = NULL
+	WHERE
+		event_id = :event_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to try again.
+func (s *Store) MarkFailed(ctx context.Context, e outboxbus.Event) error {
+	const q = `
+	UPDATE
+		"public"."outbox"
+	SET
+		attempts = :attempts,
+		last_error = :last_error,
+		next_attempt_at = :next_attempt_at
+	WHERE
+		event_id = :event_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(e)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
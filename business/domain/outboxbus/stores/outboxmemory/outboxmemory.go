@@ -0,0 +1,133 @@
+// Package outboxmemory contains an in-memory outbox store used to exercise
+// the business layer without a database.
+package outboxmemory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+)
+
+// Store manages the set of APIs for outbox access kept in memory.
+type Store struct {
+	mu     *sync.RWMutex
+	events map[uuid.UUID]outboxbus.Event
+	ids    []uuid.UUID
+}
+
+// NewStore constructs an empty store.
+func NewStore() *Store {
+	return &Store{
+		mu:     &sync.RWMutex{},
+		events: make(map[uuid.UUID]outboxbus.Event),
+	}
+}
+
+// NewWithTx returns the same store. Changes are applied immediately and are
+// not undone if the transaction rolls back.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (outboxbus.Storer, error) {
+	return s, nil
+}
+
+// Events returns every event in the store, published or not, in the order
+// they were added. Tests use it to check what a change wrote to the outbox.
+func (s *Store) Events() []outboxbus.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]outboxbus.Event, len(s.ids))
+	for i, id := range s.ids {
+		e := s.events[id]
+		e.Secret = slices.Clone(e.Secret)
+		events[i] = e
+	}
+
+	return events
+}
+
+// Create adds a new event to the store. An event with the same dedup key is
+// kept as is.
+func (s *Store) Create(ctx context.Context, e outboxbus.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.events {
+		if other.DedupKey == e.DedupKey {
+			return nil
+		}
+	}
+
+	e.Secret = slices.Clone(e.Secret)
+	s.events[e.ID] = e
+	s.ids = append(s.ids, e.ID)
+
+	return nil
+}
+
+// Claim returns up to limit events due for delivery, oldest first, and
+// postpones them by lease.
+func (s *Store) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]outboxbus.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []outboxbus.Event
+	for _, id := range s.ids {
+		if len(due) == limit {
+			break
+		}
+
+		e := s.events[id]
+		if e.PublishedAt != nil || e.NextAttemptAt.After(now) {
+			continue
+		}
+
+		claimed := e
+		claimed.NextAttemptAt = now.Add(lease)
+		s.events[id] = claimed
+
+		e.Secret = slices.Clone(e.Secret)
+		due = append(due, e)
+	}
+
+	return due, nil
+}
+
+// MarkPublished records that the event was delivered and erases its secret.
+func (s *Store) MarkPublished(ctx context.Context, eventID uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, exists := s.events[eventID]
+	if !exists {
+		return nil
+	}
+
+	e.PublishedAt = &now
+	e.Secret = nil
+	s.events[eventID] = e
+
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to try again.
+func (s *Store) MarkFailed(ctx context.Context, e outboxbus.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, exists := s.events[e.ID]
+	if !exists {
+		return nil
+	}
+
+	cur.Attempts = e.Attempts
+	cur.LastError = e.LastError
+	cur.NextAttemptAt = e.NextAttemptAt
+	s.events[e.ID] = cur
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package outboxbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook publishes events as a JSON POST to a fixed URL. The dedup key is
+// sent in the Idempotency-Key header and, when a secret is set, the body is
+// signed with HMAC-SHA256 in the X-Signature header.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook constructs a publisher delivering to url.
+func NewWebhook(url string, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish implements the Publisher interface.
+func (w *Webhook) Publish(ctx context.Context, e Event) error {
+	body, err := encode(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", e.DedupKey)
+	req.Header.Set("X-Event-Type", e.Type)
+
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
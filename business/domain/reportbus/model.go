@@ -0,0 +1,66 @@
+package reportbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of report formats supported.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// Set of report statuses.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Report represents an export of the data of a dashboard, or of a single
+// widget when WidgetID is set. The file is kept until ExpiresAt.
+type Report struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	DashboardID uuid.UUID
+	WidgetID    *uuid.UUID
+	UserID      uuid.UUID
+	Format      string
+	Status      string
+	Params      map[string]any
+	Error       string
+	FileName    string
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
+	ExpiresAt   time.Time
+}
+
+// NewReport contains information needed to request a report.
+type NewReport struct {
+	TenantID    uuid.UUID
+	DashboardID uuid.UUID
+	WidgetID    *uuid.UUID
+	UserID      uuid.UUID
+	Format      string
+	Params      map[string]any
+}
+
+// File is the rendered report.
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Table is the data of a widget in a report.
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]any
+}
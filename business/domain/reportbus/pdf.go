@@ -0,0 +1,150 @@
+package reportbus
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Layout of the PDF: A4 landscape, Courier so the columns line up without
+// measuring the text.
+const (
+	pdfWidth     = 842
+	pdfHeight    = 595
+	pdfMargin    = 36
+	pdfFontSize  = 8
+	pdfLeading   = 10
+	pdfLineChars = (pdfWidth - 2*pdfMargin) * 10 / (6 * pdfFontSize)
+	pdfPageLines = (pdfHeight - 2*pdfMargin) / pdfLeading
+	pdfMaxColumn = 30
+)
+
+// renderPDF writes the tables as text, paginated. Characters outside of
+// Latin-1 are replaced, the standard fonts have no others.
+func renderPDF(title string, tables []Table) ([]byte, error) {
+	lines := []string{title, ""}
+
+	for _, t := range tables {
+		lines = append(lines, pdfTable(t)...)
+		lines = append(lines, "")
+	}
+
+	var pages [][]string
+	for len(lines) > 0 {
+		n := min(len(lines), pdfPageLines)
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	return pdfDocument(pages), nil
+}
+
+// pdfTable formats the table in fixed width columns.
+func pdfTable(t Table) []string {
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = min(utf8.RuneCountInString(c), pdfMaxColumn)
+	}
+
+	cells := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		cells[i] = make([]string, len(t.Columns))
+		for j := range t.Columns {
+			if j < len(row) {
+				cells[i][j] = strings.ReplaceAll(formatCell(row[j]), "\n", " ")
+			}
+			widths[j] = max(widths[j], min(utf8.RuneCountInString(cells[i][j]), pdfMaxColumn))
+		}
+	}
+
+	format := func(values []string) string {
+		var b strings.Builder
+		for i, v := range values {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			v = truncateRunes(v, widths[i])
+			b.WriteString(v)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)))
+		}
+		return truncateRunes(strings.TrimRight(b.String(), " "), pdfLineChars)
+	}
+
+	rule := make([]string, len(widths))
+	for i, w := range widths {
+		rule[i] = strings.Repeat("-", w)
+	}
+
+	lines := []string{t.Title, format(t.Columns), format(rule)}
+	for _, row := range cells {
+		lines = append(lines, format(row))
+	}
+
+	return lines
+}
+
+// pdfDocument writes the PDF objects: the catalog, the page tree, the font
+// and a page with its content stream per page of lines.
+func pdfDocument(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfHeight-pdfMargin-pdfFontSize)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+		}
+		content.WriteString("ET")
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfWidth, pdfHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfString escapes the text for a PDF string in the WinAnsi encoding.
+func pdfString(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package reportbus
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// renderer turns the tables of a report into a file.
+type renderer struct {
+	ext         string
+	contentType string
+	render      func(title string, tables []Table) ([]byte, error)
+}
+
+var renderers = map[string]renderer{
+	FormatCSV:  {ext: "csv", contentType: "text/csv; charset=utf-8", render: renderCSV},
+	FormatXLSX: {ext: "xlsx", contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", render: renderXLSX},
+	FormatPDF:  {ext: "pdf", contentType: "application/pdf", render: renderPDF},
+}
+
+// renderCSV writes the tables one after the other, each preceded by its
+// title and separated by an empty line.
+func renderCSV(title string, tables []Table) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// O BOM faz o Excel abrir o arquivo como UTF-8.
+	buf.WriteString("\uFEFF")
+
+	w := csv.NewWriter(&buf)
+
+	for i, t := range tables {
+		if i > 0 {
+			w.Write(nil)
+		}
+
+		if len(tables) > 1 {
+			w.Write([]string{t.Title})
+		}
+
+		w.Write(t.Columns)
+
+		for _, row := range t.Rows {
+			record := make([]string, len(row))
+			for j, v := range row {
+				record[j] = formatCell(v)
+			}
+			w.Write(record)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatCell returns the text of a value read from a datasource.
+func formatCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// numericCell reports whether the value is a number and returns its text.
+func numericCell(v any) (string, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		if _, err := v.Float64(); err == nil {
+			return v.String(), true
+		}
+	case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return formatCell(v), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,390 @@
+// Package reportbus provides business access to the reports, exports of the
+// data of a dashboard or of a widget to CSV, XLSX or PDF. Reports are
+// rendered in the background by the worker and downloaded through a signed
+// link until they expire.
+package reportbus
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/datasourcebus"
+	"github.com/jcpaschoal/spi-exata/business/domain/notificationbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// TaskRender is the name of the worker task rendering a report.
+const TaskRender = "report.render"
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("report not found")
+	ErrUnknownFormat    = errors.New("unknown report format")
+	ErrNotReady         = errors.New("report is not ready")
+	ErrExpired          = errors.New("report has expired")
+	ErrInvalidSignature = errors.New("invalid report signature")
+	ErrNoData           = errors.New("no widget bound to a datasource")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, r Report) error
+	Update(ctx context.Context, r Report) error
+	Finish(ctx context.Context, r Report, data []byte) error
+	QueryByID(ctx context.Context, reportID uuid.UUID) (Report, error)
+	QueryContent(ctx context.Context, reportID uuid.UUID) ([]byte, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// Queue schedules the rendering of the reports.
+type Queue interface {
+	Enqueue(ctx context.Context, name string, payload any, runAt time.Time) (worker.Task, error)
+}
+
+// Config represents the settings of the reports. Retention is how long a
+// file is kept, LinkTTL how long a download link is valid and SigningKey
+// the key signing the links.
+type Config struct {
+	Retention  time.Duration
+	LinkTTL    time.Duration
+	SigningKey []byte
+}
+
+// Core manages the set of APIs for report access.
+type Core struct {
+	log             *logger.Logger
+	storer          Storer
+	datasourceBus   *datasourcebus.Core
+	notificationBus *notificationbus.Core
+	queue           Queue
+	cfg             Config
+}
+
+// NewCore constructs a core for report api access.
+func NewCore(log *logger.Logger, storer Storer, datasourceBus *datasourcebus.Core, notificationBus *notificationbus.Core, queue Queue, cfg Config) *Core {
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+
+	if cfg.LinkTTL <= 0 {
+		cfg.LinkTTL = 15 * time.Minute
+	}
+
+	return &Core{
+		log:             log,
+		storer:          storer,
+		datasourceBus:   datasourceBus,
+		notificationBus: notificationBus,
+		queue:           queue,
+		cfg:             cfg,
+	}
+}
+
+// NewWithTx constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:             c.log,
+		storer:          storer,
+		datasourceBus:   c.datasourceBus,
+		notificationBus: c.notificationBus,
+		queue:           c.queue,
+		cfg:             c.cfg,
+	}, nil
+}
+
+// Create records the request of a report and schedules its rendering.
+func (c *Core) Create(ctx context.Context, nr NewReport) (Report, error) {
+	ctx, span := otel.AddSpan(ctx, "business.reportbus.create")
+	defer span.End()
+
+	if _, ok := renderers[nr.Format]; !ok {
+		return Report{}, fmt.Errorf("%w: %q", ErrUnknownFormat, nr.Format)
+	}
+
+	now := time.Now()
+
+	r := Report{
+		ID:          uuid.New(),
+		TenantID:    nr.TenantID,
+		DashboardID: nr.DashboardID,
+		WidgetID:    nr.WidgetID,
+		UserID:      nr.UserID,
+		Format:      nr.Format,
+		Status:      StatusPending,
+		Params:      nr.Params,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(c.cfg.Retention),
+	}
+
+	if err := c.storer.Create(ctx, r); err != nil {
+		return Report{}, fmt.Errorf("create: %w", err)
+	}
+
+	if _, err := c.queue.Enqueue(ctx, TaskRender, renderPayload{ReportID: r.ID}, time.Time{}); err != nil {
+		return Report{}, fmt.Errorf("enqueue: %w", err)
+	}
+
+	return r, nil
+}
+
+// QueryByID finds the report by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, reportID uuid.UUID) (Report, error) {
+	ctx, span := otel.AddSpan(ctx, "business.reportbus.queryByID")
+	defer span.End()
+
+	r, err := c.storer.QueryByID(ctx, reportID)
+	if err != nil {
+		return Report{}, fmt.Errorf("query: reportID[%s]: %w", reportID, err)
+	}
+
+	return r, nil
+}
+
+// File returns the rendered report.
+func (c *Core) File(ctx context.Context, r Report) (File, error) {
+	ctx, span := otel.AddSpan(ctx, "business.reportbus.file")
+	defer span.End()
+
+	if r.Status != StatusDone {
+		return File{}, ErrNotReady
+	}
+
+	if time.Now().After(r.ExpiresAt) {
+		return File{}, ErrExpired
+	}
+
+	data, err := c.storer.QueryContent(ctx, r.ID)
+	if err != nil {
+		return File{}, fmt.Errorf("query content: reportID[%s]: %w", r.ID, err)
+	}
+
+	return File{
+		Name:        r.FileName,
+		ContentType: r.ContentType,
+		Data:        data,
+	}, nil
+}
+
+// PurgeExpired deletes the reports past their retention.
+func (c *Core) PurgeExpired(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.reportbus.purgeExpired")
+	defer span.End()
+
+	n, err := c.storer.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleteExpired: %w", err)
+	}
+
+	return n, nil
+}
+
+// =============================================================================
+
+// renderPayload is the payload of the TaskRender task.
+type renderPayload struct {
+	ReportID uuid.UUID `json:"reportId"`
+}
+
+// Render is the worker handler of TaskRender. It runs the queries of the
+// widgets and stores the file. A report that can't be rendered is marked as
+// failed with the reason instead of being retried, the user asks again.
+func (c *Core) Render(ctx context.Context, payload json.RawMessage) error {
+	ctx, span := otel.AddSpan(ctx, "business.reportbus.render")
+	defer span.End()
+
+	var p renderPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	r, err := c.storer.QueryByID(ctx, p.ReportID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("query: reportID[%s]: %w", p.ReportID, err)
+	}
+
+	if r.Status == StatusDone || r.Status == StatusFailed {
+		return nil
+	}
+
+	r.Status = StatusRunning
+	if err := c.storer.Update(ctx, r); err != nil {
+		return fmt.Errorf("update: reportID[%s]: %w", r.ID, err)
+	}
+
+	file, err := c.render(ctx, r)
+
+	now := time.Now()
+	r.FinishedAt = &now
+
+	if err != nil {
+		c.log.Info(ctx, "reportbus", "status", "render failed", "reportID", r.ID, "ERROR", err)
+
+		r.Status = StatusFailed
+		r.Error = err.Error()
+
+		if err := c.storer.Update(ctx, r); err != nil {
+			return fmt.Errorf("update: reportID[%s]: %w", r.ID, err)
+		}
+
+		c.notify(ctx, r, notificationbus.KindReportFailed)
+
+		return nil
+	}
+
+	r.Status = StatusDone
+	r.FileName = file.Name
+	r.ContentType = file.ContentType
+	r.Size = int64(len(file.Data))
+
+	if err := c.storer.Finish(ctx, r, file.Data); err != nil {
+		return fmt.Errorf("finish: reportID[%s]: %w", r.ID, err)
+	}
+
+	c.notify(ctx, r, notificationbus.KindReportReady)
+
+	return nil
+}
+
+// notify tells the user who asked for the report it has finished. The report
+// is already stored, so a failure is only logged.
+func (c *Core) notify(ctx context.Context, r Report, kind string) {
+	data := map[string]any{
+		"dashboardId": r.DashboardID.String(),
+		"format":      r.Format,
+	}
+
+	if r.WidgetID != nil {
+		data["widgetId"] = r.WidgetID.String()
+	}
+
+	if r.FileName != "" {
+		data["fileName"] = r.FileName
+	}
+
+	nn := notificationbus.NewNotification{
+		UserID:     r.UserID,
+		TenantID:   r.TenantID,
+		Kind:       kind,
+		EntityType: "report",
+		EntityID:   r.ID,
+		Data:       data,
+	}
+
+	if _, err := c.notificationBus.Create(ctx, nn); err != nil {
+		c.log.Error(ctx, "reportbus", "status", "notify", "reportID", r.ID, "ERROR", err)
+	}
+}
+
+// render runs the queries of the report and renders the file.
+func (c *Core) render(ctx context.Context, r Report) (File, error) {
+	rd, ok := renderers[r.Format]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %q", ErrUnknownFormat, r.Format)
+	}
+
+	var widgets []datasourcebus.Widget
+
+	switch r.WidgetID {
+	case nil:
+		ws, err := c.datasourceBus.QueryWidgetsByDashboard(ctx, r.DashboardID)
+		if err != nil {
+			return File{}, err
+		}
+		widgets = ws
+
+	default:
+		w, err := c.datasourceBus.QueryWidget(ctx, *r.WidgetID)
+		if err != nil {
+			return File{}, err
+		}
+		widgets = []datasourcebus.Widget{w}
+	}
+
+	if len(widgets) == 0 {
+		return File{}, ErrNoData
+	}
+
+	tables := make([]Table, len(widgets))
+
+	for i, w := range widgets {
+		result, err := c.datasourceBus.Execute(ctx, w, r.Params, 0)
+		if err != nil {
+			return File{}, fmt.Errorf("widget %q: %w", w.Title, err)
+		}
+
+		tables[i] = Table{
+			Title:   w.Title,
+			Columns: result.Columns,
+			Rows:    result.Rows,
+		}
+	}
+
+	title := "Report " + r.CreatedAt.Format("2006-01-02")
+	if r.WidgetID != nil {
+		title = widgets[0].Title
+	}
+
+	data, err := rd.render(title, tables)
+	if err != nil {
+		return File{}, err
+	}
+
+	return File{
+		Name:        fmt.Sprintf("report-%s.%s", r.CreatedAt.Format("20060102-150405"), rd.ext),
+		ContentType: rd.contentType,
+		Data:        data,
+	}, nil
+}
+
+// =============================================================================
+
+// Sign returns the expiry and the signature of a download link of the
+// report, valid for the configured link TTL.
+func (c *Core) Sign(r Report) (int64, string) {
+	expires := time.Now().Add(c.cfg.LinkTTL).Unix()
+	return expires, c.signature(r.ID, expires)
+}
+
+// Verify checks the signature of a download link.
+func (c *Core) Verify(reportID uuid.UUID, expires int64, signature string) error {
+	if time.Now().Unix() > expires {
+		return ErrExpired
+	}
+
+	expected := c.signature(reportID, expires)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (c *Core) signature(reportID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, c.cfg.SigningKey)
+	mac.Write([]byte(reportID.String() + "." + strconv.FormatInt(expires, 10)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,95 @@
+package reportdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+)
+
+type reportDB struct {
+	ID          uuid.UUID      `db:"report_id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	DashboardID uuid.UUID      `db:"dashboard_id"`
+	WidgetID    uuid.NullUUID  `db:"widget_id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Format      string         `db:"format"`
+	Status      string         `db:"status"`
+	Params      string         `db:"params"`
+	Error       sql.NullString `db:"error"`
+	FileName    sql.NullString `db:"file_name"`
+	ContentType sql.NullString `db:"content_type"`
+	Size        int64          `db:"size"`
+	CreatedAt   time.Time      `db:"created_at"`
+	FinishedAt  sql.NullTime   `db:"finished_at"`
+	ExpiresAt   time.Time      `db:"expires_at"`
+}
+
+func toDBReport(bus reportbus.Report) reportDB {
+	params := "{}"
+	if len(bus.Params) > 0 {
+		if data, err := json.Marshal(bus.Params); err == nil {
+			params = string(data)
+		}
+	}
+
+	db := reportDB{
+		ID:          bus.ID,
+		TenantID:    bus.TenantID,
+		DashboardID: bus.DashboardID,
+		UserID:      bus.UserID,
+		Format:      bus.Format,
+		Status:      bus.Status,
+		Params:      params,
+		Error:       sql.NullString{String: bus.Error, Valid: bus.Error != ""},
+		FileName:    sql.NullString{String: bus.FileName, Valid: bus.FileName != ""},
+		ContentType: sql.NullString{String: bus.ContentType, Valid: bus.ContentType != ""},
+		Size:        bus.Size,
+		CreatedAt:   bus.CreatedAt.UTC(),
+		ExpiresAt:   bus.ExpiresAt.UTC(),
+	}
+
+	if bus.WidgetID != nil {
+		db.WidgetID = uuid.NullUUID{UUID: *bus.WidgetID, Valid: true}
+	}
+
+	if bus.FinishedAt != nil {
+		db.FinishedAt = sql.NullTime{Time: bus.FinishedAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusReport(db reportDB) reportbus.Report {
+	var params map[string]any
+	json.Unmarshal([]byte(db.Params), &params)
+
+	r := reportbus.Report{
+		ID:          db.ID,
+		TenantID:    db.TenantID,
+		DashboardID: db.DashboardID,
+		UserID:      db.UserID,
+		Format:      db.Format,
+		Status:      db.Status,
+		Params:      params,
+		Error:       db.Error.String,
+		FileName:    db.FileName.String,
+		ContentType: db.ContentType.String,
+		Size:        db.Size,
+		CreatedAt:   db.CreatedAt.In(time.Local),
+		ExpiresAt:   db.ExpiresAt.In(time.Local),
+	}
+
+	if db.WidgetID.Valid {
+		r.WidgetID = &db.WidgetID.UUID
+	}
+
+	if db.FinishedAt.Valid {
+		t := db.FinishedAt.Time.In(time.Local)
+		r.FinishedAt = &t
+	}
+
+	return r
+}
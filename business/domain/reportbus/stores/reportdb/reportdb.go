@@ -0,0 +1,196 @@
+// Package reportdb contains report related CRUD functionality.
+package reportdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/reportbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for report database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (reportbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new report into the database.
+func (s *Store) Create(ctx context.Context, r reportbus.Report) error {
+	const q = `
+	INSERT INTO "public"."report"
+		(report_id, tenant_id, dashboard_id, widget_id, user_id, format, status, params, created_at, expires_at)
+	VALUES
+		(:report_id, :tenant_id, :dashboard_id, :widget_id, :user_id, :format, :status, :params, :created_at, :expires_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBReport(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the status of a report.
+func (s *Store) Update(ctx context.Context, r reportbus.Report) error {
+	const q = `
+	UPDATE
+		"public"."report"
+	SET
+		status = :status,
+		error = :error,
+		finished_at = :finished_at
+	WHERE
+		report_id = :report_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBReport(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Finish stores the rendered file of a report along with its status.
+func (s *Store) Finish(ctx context.Context, r reportbus.Report, data []byte) error {
+	row := struct {
+		reportDB
+		Content []byte `db:"content"`
+	}{
+		reportDB: toDBReport(r),
+		Content:  data,
+	}
+
+	const q = `
+	UPDATE
+		"public"."report"
+	SET
+		status = :status,
+		error = :error,
+		file_name = :file_name,
+		content_type = :content_type,
+		size = :size,
+		content = :content,
+		finished_at = :finished_at
+	WHERE
+		report_id = :report_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, row); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified report from the database, without the file.
+func (s *Store) QueryByID(ctx context.Context, reportID uuid.UUID) (reportbus.Report, error) {
+	data := struct {
+		ID string `db:"report_id"`
+	}{
+		ID: reportID.String(),
+	}
+
+	const q = `
+	SELECT
+		report_id, tenant_id, dashboard_id, widget_id, user_id, format, status, params,
+		error, file_name, content_type, size, created_at, finished_at, expires_at
+	FROM
+		"public"."report"
+	WHERE
+		report_id = :report_id`
+
+	var dbReport reportDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbReport); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return reportbus.Report{}, fmt.Errorf("db: %w", reportbus.ErrNotFound)
+		}
+		return reportbus.Report{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusReport(dbReport), nil
+}
+
+// QueryContent gets the file of the specified report.
+func (s *Store) QueryContent(ctx context.Context, reportID uuid.UUID) ([]byte, error) {
+	data := struct {
+		ID string `db:"report_id"`
+	}{
+		ID: reportID.String(),
+	}
+
+	const q = `
+	SELECT
+		content
+	FROM
+		"public"."report"
+	WHERE
+		report_id = :report_id AND content IS NOT NULL`
+
+	var row struct {
+		Content []byte `db:"content"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &row); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return nil, fmt.Errorf("db: %w", reportbus.ErrNotFound)
+		}
+		return nil, fmt.Errorf("db: %w", err)
+	}
+
+	return row.Content, nil
+}
+
+// DeleteExpired removes the reports past their expiry and returns how many
+// were deleted.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."report"
+		WHERE
+			expires_at <= :now
+		RETURNING report_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,191 @@
+package reportbus
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// renderXLSX writes a workbook with a sheet per table. Only what a
+// spreadsheet needs to open the data is written: inline strings, numbers
+// and no styles.
+func renderXLSX(title string, tables []Table) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	names := sheetNames(tables)
+
+	files := []struct {
+		name string
+		body func(w io.Writer) error
+	}{
+		{"[Content_Types].xml", func(w io.Writer) error { return xlsxContentTypes(w, len(tables)) }},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", func(w io.Writer) error { return xlsxWorkbook(w, names) }},
+		{"xl/_rels/workbook.xml.rels", func(w io.Writer) error { return xlsxWorkbookRels(w, len(tables)) }},
+	}
+
+	for i, t := range tables {
+		files = append(files, struct {
+			name string
+			body func(w io.Writer) error
+		}{
+			name: fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1),
+			body: func(w io.Writer) error { return xlsxSheet(w, t) },
+		})
+	}
+
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: %s: %w", f.name, err)
+		}
+
+		if err := f.body(w); err != nil {
+			return nil, fmt.Errorf("xlsx: %s: %w", f.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+func xlsxContentTypes(w io.Writer, sheets int) error {
+	var b strings.Builder
+
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xlsxRootRels(w io.Writer) error {
+	_, err := io.WriteString(w, xmlHeader+
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>`+
+		`</Relationships>`)
+	return err
+}
+
+func xlsxWorkbook(w io.Writer, names []string) error {
+	var b strings.Builder
+
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range names {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xlsxWorkbookRels(w io.Writer, sheets int) error {
+	var b strings.Builder
+
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	b.WriteString(`</Relationships>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xlsxSheet(w io.Writer, t Table) error {
+	var b strings.Builder
+
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	row := make([]any, len(t.Columns))
+	for i, c := range t.Columns {
+		row[i] = c
+	}
+	xlsxRow(&b, row)
+
+	for _, r := range t.Rows {
+		xlsxRow(&b, r)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xlsxRow(b *strings.Builder, row []any) {
+	b.WriteString(`<row>`)
+	for _, v := range row {
+		if n, ok := numericCell(v); ok {
+			fmt.Fprintf(b, `<c><v>%s</v></c>`, n)
+			continue
+		}
+		fmt.Fprintf(b, `<c t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, xmlEscape(formatCell(v)))
+	}
+	b.WriteString(`</row>`)
+}
+
+// sheetNames returns unique sheet names within the limits of the format:
+// 31 characters and none of []:*?/\.
+func sheetNames(tables []Table) []string {
+	replacer := strings.NewReplacer("[", "(", "]", ")", ":", "-", "*", "-", "?", "", "/", "-", "\\", "-")
+
+	seen := make(map[string]bool)
+	names := make([]string, len(tables))
+
+	for i, t := range tables {
+		name := strings.TrimSpace(replacer.Replace(t.Title))
+		if name == "" {
+			name = "Sheet"
+		}
+
+		base := truncateRunes(name, 31)
+		name = base
+
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			suffix := " (" + strconv.Itoa(n) + ")"
+			name = truncateRunes(base, 31-len(suffix)) + suffix
+		}
+
+		seen[strings.ToLower(name)] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,11 @@
+package rolechangebus
+
+import (
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	UserID *uuid.UUID
+	Status *string
+}
@@ -0,0 +1,35 @@
+package rolechangebus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Set of role change statuses.
+const (
+	StatusPending  = "PENDING"
+	StatusApproved = "APPROVED"
+	StatusRejected = "REJECTED"
+)
+
+// RoleChange represents a change of the role of a user waiting for, or
+// decided by, a second approver.
+type RoleChange struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Role        role.Role
+	Status      string
+	RequestedBy uuid.UUID
+	ReviewedBy  *uuid.UUID
+	CreatedAt   time.Time
+	ReviewedAt  *time.Time
+}
+
+// NewRoleChange contains information needed to request a role change.
+type NewRoleChange struct {
+	UserID      uuid.UUID
+	Role        role.Role
+	RequestedBy uuid.UUID
+}
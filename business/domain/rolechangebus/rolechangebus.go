@@ -0,0 +1,197 @@
+// Package rolechangebus provides business access to the role changes that
+// need a second approver. A tenant with role approval turned on does not
+// promote its users to ADMIN at once: the change is kept pending until
+// another ADMIN approves or rejects it.
+package rolechangebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("role change not found")
+	ErrPending    = errors.New("user already has a pending role change")
+	ErrNotPending = errors.New("role change is not pending")
+	ErrSelfReview = errors.New("role change reviewed by its requester")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, rc RoleChange) error
+	Update(ctx context.Context, rc RoleChange) error
+	Query(ctx context.Context, filter QueryFilter, page page.Page) ([]RoleChange, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, changeID uuid.UUID) (RoleChange, error)
+}
+
+// Core manages the set of APIs for role change access.
+type Core struct {
+	log       *logger.Logger
+	storer    Storer
+	tenantBus *tenantbus.Core
+}
+
+// NewCore constructs a core for role change api access.
+func NewCore(log *logger.Logger, storer Storer, tenantBus *tenantbus.Core) *Core {
+	return &Core{
+		log:       log,
+		storer:    storer,
+		tenantBus: tenantBus,
+	}
+}
+
+// NewWithTx constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Core{
+		log:       c.log,
+		storer:    storer,
+		tenantBus: c.tenantBus,
+	}, nil
+}
+
+// RequiresApproval reports whether changing the role of the user from the
+// current role to the new one needs a second approver. Only the promotions
+// to ADMIN of the members of a tenant with role approval turned on do.
+func (c *Core) RequiresApproval(ctx context.Context, userID uuid.UUID, current role.Role, next role.Role) (bool, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.requiresApproval")
+	defer span.End()
+
+	if !next.Equal(role.Admin) || current.Equal(role.Admin) {
+		return false, nil
+	}
+
+	tenantID, err := c.tenantBus.QueryTenantIDByUserID(ctx, userID)
+	if err != nil {
+		// Sem cliente não há configuração a seguir.
+		if errors.Is(err, tenantbus.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("queryTenantIDByUserID: userID[%s]: %w", userID, err)
+	}
+
+	t, err := c.tenantBus.QueryByID(ctx, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("queryByID: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return t.RoleApproval, nil
+}
+
+// Request records a role change waiting for a second approver. A user has
+// at most one pending change; ErrPending is returned for a second one.
+func (c *Core) Request(ctx context.Context, nrc NewRoleChange) (RoleChange, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.request")
+	defer span.End()
+
+	rc := RoleChange{
+		ID:          uuid.New(),
+		UserID:      nrc.UserID,
+		Role:        nrc.Role,
+		Status:      StatusPending,
+		RequestedBy: nrc.RequestedBy,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, rc); err != nil {
+		return RoleChange{}, fmt.Errorf("create: %w", err)
+	}
+
+	return rc, nil
+}
+
+// Approve marks the pending change as approved by the reviewer, who cannot
+// be the requester. Applying the new role is left to the caller, in the same
+// transaction.
+func (c *Core) Approve(ctx context.Context, rc RoleChange, reviewerID uuid.UUID) (RoleChange, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.approve")
+	defer span.End()
+
+	return c.review(ctx, rc, reviewerID, StatusApproved)
+}
+
+// Reject marks the pending change as rejected by the reviewer, who cannot be
+// the requester. The role of the user is kept.
+func (c *Core) Reject(ctx context.Context, rc RoleChange, reviewerID uuid.UUID) (RoleChange, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.reject")
+	defer span.End()
+
+	return c.review(ctx, rc, reviewerID, StatusRejected)
+}
+
+// Query retrieves a list of role changes, the most recent first.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, page page.Page) ([]RoleChange, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.query")
+	defer span.End()
+
+	changes, err := c.storer.Query(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return changes, nil
+}
+
+// Count returns the total number of role changes.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the role change by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, changeID uuid.UUID) (RoleChange, error) {
+	ctx, span := otel.AddSpan(ctx, "business.rolechangebus.queryByID")
+	defer span.End()
+
+	rc, err := c.storer.QueryByID(ctx, changeID)
+	if err != nil {
+		return RoleChange{}, fmt.Errorf("query: changeID[%s]: %w", changeID, err)
+	}
+
+	return rc, nil
+}
+
+// =============================================================================
+
+func (c *Core) review(ctx context.Context, rc RoleChange, reviewerID uuid.UUID, status string) (RoleChange, error) {
+	if rc.Status != StatusPending {
+		return RoleChange{}, fmt.Errorf("changeID[%s] status[%s]: %w", rc.ID, rc.Status, ErrNotPending)
+	}
+
+	if rc.RequestedBy == reviewerID {
+		return RoleChange{}, fmt.Errorf("changeID[%s] reviewerID[%s]: %w", rc.ID, reviewerID, ErrSelfReview)
+	}
+
+	now := time.Now()
+
+	rc.Status = status
+	rc.ReviewedBy = &reviewerID
+	rc.ReviewedAt = &now
+
+	if err := c.storer.Update(ctx, rc); err != nil {
+		return RoleChange{}, fmt.Errorf("update: changeID[%s]: %w", rc.ID, err)
+	}
+
+	return rc, nil
+}
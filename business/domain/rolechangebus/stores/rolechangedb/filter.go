@@ -0,0 +1,27 @@
+package rolechangedb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+)
+
+func applyFilter(filter rolechangebus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "rc.user_id = :user_id")
+	}
+
+	if filter.Status != nil {
+		data["status"] = *filter.Status
+		wc = append(wc, "rc.status = :status")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,83 @@
+package rolechangedb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type roleChangeDB struct {
+	ID          uuid.UUID     `db:"change_id"`
+	UserID      uuid.UUID     `db:"user_id"`
+	Role        string        `db:"role"`
+	Status      string        `db:"status"`
+	RequestedBy uuid.UUID     `db:"requested_by"`
+	ReviewedBy  uuid.NullUUID `db:"reviewed_by"`
+	CreatedAt   time.Time     `db:"created_at"`
+	ReviewedAt  sql.NullTime  `db:"reviewed_at"`
+}
+
+func toDBRoleChange(bus rolechangebus.RoleChange) roleChangeDB {
+	db := roleChangeDB{
+		ID:          bus.ID,
+		UserID:      bus.UserID,
+		Role:        bus.Role.String(),
+		Status:      bus.Status,
+		RequestedBy: bus.RequestedBy,
+		CreatedAt:   bus.CreatedAt.UTC(),
+	}
+
+	if bus.ReviewedBy != nil {
+		db.ReviewedBy = uuid.NullUUID{UUID: *bus.ReviewedBy, Valid: true}
+	}
+
+	if bus.ReviewedAt != nil {
+		db.ReviewedAt = sql.NullTime{Time: bus.ReviewedAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusRoleChange(db roleChangeDB) (rolechangebus.RoleChange, error) {
+	r, err := role.Parse(db.Role)
+	if err != nil {
+		return rolechangebus.RoleChange{}, fmt.Errorf("parse role: %w", err)
+	}
+
+	bus := rolechangebus.RoleChange{
+		ID:          db.ID,
+		UserID:      db.UserID,
+		Role:        r,
+		Status:      db.Status,
+		RequestedBy: db.RequestedBy,
+		CreatedAt:   db.CreatedAt.In(time.Local),
+	}
+
+	if db.ReviewedBy.Valid {
+		bus.ReviewedBy = &db.ReviewedBy.UUID
+	}
+
+	if db.ReviewedAt.Valid {
+		reviewedAt := db.ReviewedAt.Time.In(time.Local)
+		bus.ReviewedAt = &reviewedAt
+	}
+
+	return bus, nil
+}
+
+func toBusRoleChanges(dbs []roleChangeDB) ([]rolechangebus.RoleChange, error) {
+	bus := make([]rolechangebus.RoleChange, len(dbs))
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusRoleChange(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
@@ -0,0 +1,176 @@
+// Package rolechangedb contains role change related CRUD functionality.
+package rolechangedb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/rolechangebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for role change database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (rolechangebus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new role change into the database.
+func (s *Store) Create(ctx context.Context, rc rolechangebus.RoleChange) error {
+	const q = `
+	INSERT INTO "public"."role_change"
+		(change_id, user_id, role_id, status, requested_by, created_at)
+	VALUES
+		(:change_id, :user_id, (SELECT role_id FROM "public"."role" WHERE name = :role), :status, :requested_by, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRoleChange(rc)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) && dupErr.Constraint == "uq_role_change_pending" {
+			return fmt.Errorf("namedexeccontext: %w", rolechangebus.ErrPending)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update stores the review of a pending role change. A change reviewed
+// meanwhile by someone else is not updated and ErrNotPending is returned.
+func (s *Store) Update(ctx context.Context, rc rolechangebus.RoleChange) error {
+	const q = `
+	UPDATE
+		"public"."role_change"
+	SET
+		status = :status,
+		reviewed_by = :reviewed_by,
+		reviewed_at = :reviewed_at
+	WHERE
+		change_id = :change_id AND status = 'PENDING'
+	RETURNING
+		change_id`
+
+	var dbRC struct {
+		ID uuid.UUID `db:"change_id"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBRoleChange(rc), &dbRC); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("db: %w", rolechangebus.ErrNotPending)
+		}
+		return fmt.Errorf("db: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of role changes from the database, the most recent
+// first.
+func (s *Store) Query(ctx context.Context, filter rolechangebus.QueryFilter, page page.Page) ([]rolechangebus.RoleChange, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		rc.change_id, rc.user_id, r.name AS role, rc.status, rc.requested_by,
+		rc.reviewed_by, rc.created_at, rc.reviewed_at
+	FROM
+		"public"."role_change" rc
+	JOIN
+		"public"."role" r ON r.role_id = rc.role_id`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY rc.created_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbChanges []roleChangeDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbChanges); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusRoleChanges(dbChanges)
+}
+
+// Count returns the total number of role changes in the DB.
+func (s *Store) Count(ctx context.Context, filter rolechangebus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."role_change" rc`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified role change from the database.
+func (s *Store) QueryByID(ctx context.Context, changeID uuid.UUID) (rolechangebus.RoleChange, error) {
+	data := struct {
+		ID string `db:"change_id"`
+	}{
+		ID: changeID.String(),
+	}
+
+	const q = `
+	SELECT
+		rc.change_id, rc.user_id, r.name AS role, rc.status, rc.requested_by,
+		rc.reviewed_by, rc.created_at, rc.reviewed_at
+	FROM
+		"public"."role_change" rc
+	JOIN
+		"public"."role" r ON r.role_id = rc.role_id
+	WHERE
+		rc.change_id = :change_id`
+
+	var dbRC roleChangeDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRC); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return rolechangebus.RoleChange{}, fmt.Errorf("db: %w", rolechangebus.ErrNotFound)
+		}
+		return rolechangebus.RoleChange{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusRoleChange(dbRC)
+}
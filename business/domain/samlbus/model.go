@@ -0,0 +1,91 @@
+package samlbus
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Provider represents the SAML identity provider of a tenant. Certificate
+// holds the PEM certificates the responses are signed with; more than one
+// is allowed while the provider rolls its key.
+//
+// The attributes are read by their Name in the assertion. The email comes
+// from the NameID when EmailAttribute is empty.
+type Provider struct {
+	TenantID           uuid.UUID
+	EntityID           string
+	SSOURL             string
+	Certificate        string
+	EmailAttribute     string
+	NameAttribute      string
+	RoleAttribute      string
+	RoleMappings       []RoleMapping
+	DefaultRole        *role.Role
+	DashboardAttribute string
+	DashboardMappings  []DashboardMapping
+	Enabled            bool
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// RoleMapping gives the role to the users with the value in the role
+// attribute.
+type RoleMapping struct {
+	Value string
+	Role  role.Role
+}
+
+// DashboardMapping gives access to the dashboard to the users with the value
+// in the dashboard attribute.
+type DashboardMapping struct {
+	Value       string
+	DashboardID uuid.UUID
+}
+
+// roleFor returns the role of the first mapping whose value is among the
+// values, or the default role.
+func (p Provider) roleFor(values []string) (role.Role, bool) {
+	for _, m := range p.RoleMappings {
+		if slices.Contains(values, m.Value) {
+			return m.Role, true
+		}
+	}
+
+	if p.DefaultRole != nil {
+		return *p.DefaultRole, true
+	}
+
+	return role.Role{}, false
+}
+
+// dashboardsFor returns the dashboards mapped to the values, without
+// repetition.
+func (p Provider) dashboardsFor(values []string) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, m := range p.DashboardMappings {
+		if slices.Contains(values, m.Value) && !slices.Contains(ids, m.DashboardID) {
+			ids = append(ids, m.DashboardID)
+		}
+	}
+
+	return ids
+}
+
+// SaveProvider contains information needed to configure the identity
+// provider of a tenant.
+type SaveProvider struct {
+	EntityID           string
+	SSOURL             string
+	Certificate        string
+	EmailAttribute     string
+	NameAttribute      string
+	RoleAttribute      string
+	RoleMappings       []RoleMapping
+	DefaultRole        *role.Role
+	DashboardAttribute string
+	DashboardMappings  []DashboardMapping
+	Enabled            bool
+}
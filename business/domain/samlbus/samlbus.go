@@ -0,0 +1,418 @@
+// Package samlbus provides business access to the SAML identity providers of
+// the tenants. The service provider side of the web browser SSO profile is
+// served per tenant: a signed response posted by the provider logs the user
+// in, and the local user is provisioned on the first login.
+package samlbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	"github.com/jcpaschoal/spi-exata/foundation/saml"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("identity provider not found")
+	ErrInvalidSettings = errors.New("invalid identity provider settings")
+	ErrInvalidRole     = errors.New("the identity provider can only grant the roles of a tenant")
+)
+
+// Set of error variables of the login.
+var (
+	ErrRejected   = errors.New("saml response rejected")
+	ErrReplayed   = errors.New("saml assertion already used")
+	ErrNoRole     = errors.New("no role mapped for the user")
+	ErrNoAccess   = errors.New("no dashboard mapped for the user")
+	ErrNotManaged = errors.New("user not managed by the identity provider")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Save(ctx context.Context, p Provider) error
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+	QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Provider, error)
+	SaveAssertion(ctx context.Context, tenantID uuid.UUID, assertionID string, expiresAt time.Time) error
+	DeleteExpiredAssertions(ctx context.Context, now time.Time) (int, error)
+}
+
+// Core manages the set of APIs for identity provider access.
+type Core struct {
+	log          *logger.Logger
+	storer       Storer
+	userBus      *userbus.Core
+	tenantBus    *tenantbus.Core
+	dashboardBus *dashboardbus.Core
+}
+
+// NewCore constructs a core for identity provider api access.
+func NewCore(log *logger.Logger, storer Storer, userBus *userbus.Core, tenantBus *tenantbus.Core, dashboardBus *dashboardbus.Core) *Core {
+	return &Core{
+		log:          log,
+		storer:       storer,
+		userBus:      userBus,
+		tenantBus:    tenantBus,
+		dashboardBus: dashboardBus,
+	}
+}
+
+// Save creates or replaces the identity provider of the tenant.
+func (c *Core) Save(ctx context.Context, tenantID uuid.UUID, sp SaveProvider) (Provider, error) {
+	ctx, span := otel.AddSpan(ctx, "business.samlbus.save")
+	defer span.End()
+
+	if err := c.validate(ctx, tenantID, sp); err != nil {
+		return Provider{}, err
+	}
+
+	now := time.Now()
+
+	p, err := c.storer.QueryByTenant(ctx, tenantID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		p = Provider{
+			TenantID:  tenantID,
+			CreatedAt: now,
+		}
+	case err != nil:
+		return Provider{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	p.EntityID = sp.EntityID
+	p.SSOURL = sp.SSOURL
+	p.Certificate = sp.Certificate
+	p.EmailAttribute = sp.EmailAttribute
+	p.NameAttribute = sp.NameAttribute
+	p.RoleAttribute = sp.RoleAttribute
+	p.RoleMappings = sp.RoleMappings
+	p.DefaultRole = sp.DefaultRole
+	p.DashboardAttribute = sp.DashboardAttribute
+	p.DashboardMappings = sp.DashboardMappings
+	p.Enabled = sp.Enabled
+	p.UpdatedAt = now
+
+	if err := c.storer.Save(ctx, p); err != nil {
+		return Provider{}, fmt.Errorf("save: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return p, nil
+}
+
+// Delete removes the identity provider of the tenant. The users it
+// provisioned are kept and can only log in again with a local password.
+func (c *Core) Delete(ctx context.Context, p Provider) error {
+	ctx, span := otel.AddSpan(ctx, "business.samlbus.delete")
+	defer span.End()
+
+	if err := c.storer.Delete(ctx, p.TenantID); err != nil {
+		return fmt.Errorf("delete: tenantID[%s]: %w", p.TenantID, err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the identity provider of the tenant.
+func (c *Core) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (Provider, error) {
+	ctx, span := otel.AddSpan(ctx, "business.samlbus.queryByTenant")
+	defer span.End()
+
+	p, err := c.storer.QueryByTenant(ctx, tenantID)
+	if err != nil {
+		return Provider{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return p, nil
+}
+
+// PurgeExpiredAssertions removes the used assertions that expired, which
+// can no longer be replayed anyway.
+func (c *Core) PurgeExpiredAssertions(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.samlbus.purgeExpiredAssertions")
+	defer span.End()
+
+	n, err := c.storer.DeleteExpiredAssertions(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleteExpiredAssertions: %w", err)
+	}
+
+	return n, nil
+}
+
+// =============================================================================
+
+// Metadata returns the metadata of the service provider, for the identity
+// provider to register it.
+func (c *Core) Metadata(sp saml.ServiceProvider) ([]byte, error) {
+	return saml.Metadata(sp)
+}
+
+// LoginURL returns the URL of the identity provider that starts a login.
+// The identity provider sends the relay state back to the ACS.
+func (c *Core) LoginURL(p Provider, sp saml.ServiceProvider, relayState string) (string, error) {
+	idp := saml.IdentityProvider{
+		EntityID: p.EntityID,
+		SSOURL:   p.SSOURL,
+	}
+
+	u, err := saml.AuthnRequestURL(sp, idp, relayState, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("authnRequestURL: %w", err)
+	}
+
+	return u, nil
+}
+
+// Authenticate validates the response posted by the identity provider and
+// returns the user with the tenant and dashboard of the login. The user is
+// created on the first login as a member of the tenant, the role follows the
+// role attribute and access is granted to the dashboards of the dashboard
+// attribute. Without dashboard mappings the dashboard of the domain of the
+// login is granted.
+func (c *Core) Authenticate(ctx context.Context, p Provider, sp saml.ServiceProvider, samlResponse string, domain string) (userbus.User, tenantbus.TenantDashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.samlbus.authenticate")
+	defer span.End()
+
+	certs, err := saml.ParseCertificates(p.Certificate)
+	if err != nil {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("parse certificates: tenantID[%s]: %w", p.TenantID, err)
+	}
+
+	idp := saml.IdentityProvider{
+		EntityID:     p.EntityID,
+		SSOURL:       p.SSOURL,
+		Certificates: certs,
+	}
+
+	asr, err := saml.ParseResponse(samlResponse, sp, idp, time.Now())
+	if err != nil {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("%w: %s", ErrRejected, err)
+	}
+
+	// A asserção vale uma vez só: o ID fica guardado até ela expirar.
+	if err := c.storer.SaveAssertion(ctx, p.TenantID, asr.ID, asr.NotOnOrAfter); err != nil {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("saveAssertion: tenantID[%s]: %w", p.TenantID, err)
+	}
+
+	rawEmail := asr.NameID
+	if p.EmailAttribute != "" {
+		rawEmail = first(asr.Values(p.EmailAttribute))
+	}
+
+	email, err := mail.ParseAddress(rawEmail)
+	if err != nil {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("%w: email %q: %s", ErrRejected, rawEmail, err)
+	}
+
+	r, ok := p.roleFor(asr.Values(p.RoleAttribute))
+	if !ok || !tenantRole(r) {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("email[%s]: %w", email.Address, ErrNoRole)
+	}
+
+	var domainDashboard uuid.UUID
+	td, err := c.tenantBus.ResolveDomain(ctx, domain)
+	switch {
+	case err == nil && td.TenantID == p.TenantID:
+		domainDashboard = td.DashboardID
+	case err != nil && !errors.Is(err, tenantbus.ErrDomainNotFound):
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("resolveDomain: %w", err)
+	}
+
+	dashboards := p.dashboardsFor(asr.Values(p.DashboardAttribute))
+	if len(p.DashboardMappings) == 0 && domainDashboard != uuid.Nil {
+		dashboards = []uuid.UUID{domainDashboard}
+	}
+
+	if len(dashboards) == 0 {
+		return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("email[%s]: %w", email.Address, ErrNoAccess)
+	}
+
+	usr, err := c.provision(ctx, p, *email, first(asr.Values(p.NameAttribute)), r)
+	if err != nil {
+		return userbus.User{}, tenantbus.TenantDashboard{}, err
+	}
+
+	// A concessão é idempotente e refeita a cada login, o que também
+	// completa um provisionamento interrompido.
+	for _, dashboardID := range dashboards {
+		if err := c.tenantBus.GrantUserAccessToDashboard(ctx, usr.ID, dashboardID); err != nil {
+			return userbus.User{}, tenantbus.TenantDashboard{}, fmt.Errorf("grantUserAccessToDashboard: userID[%s] dashboardID[%s]: %w", usr.ID, dashboardID, err)
+		}
+	}
+
+	td = tenantbus.TenantDashboard{
+		TenantID:    p.TenantID,
+		DashboardID: dashboards[0],
+	}
+
+	if slices.Contains(dashboards, domainDashboard) {
+		td.DashboardID = domainDashboard
+	}
+
+	return usr, td, nil
+}
+
+// =============================================================================
+
+// provision creates or updates the local user of the assertion. Users outside
+// the tenant and the roles that act across tenants are never touched: an
+// identity provider only manages the members of its own tenant. A disabled
+// user is rejected before any access is granted.
+func (c *Core) provision(ctx context.Context, p Provider, email mail.Address, displayName string, r role.Role) (userbus.User, error) {
+	scope, err := c.tenantBus.EmailScope(ctx, p.TenantID)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("emailScope: tenantID[%s]: %w", p.TenantID, err)
+	}
+
+	usr, err := c.userBus.QueryByEmail(ctx, scope, email)
+	switch {
+	case errors.Is(err, userbus.ErrNotFound):
+		pass, err := password.Random()
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("random password: %w", err)
+		}
+
+		local, _, _ := strings.Cut(email.Address, "@")
+		local = strings.NewReplacer(".", " ", "_", " ").Replace(local)
+
+		n, err := name.Derive(displayName, local)
+		if err != nil {
+			n = name.MustParse("SSO User")
+		}
+
+		nu := userbus.NewUser{
+			Name:       n,
+			Email:      email,
+			Role:       r,
+			Password:   pass,
+			EmailScope: scope,
+		}
+
+		usr, err = c.userBus.Create(ctx, nu)
+		if err != nil {
+			// O e-mail ainda pertence a um usuário removido, que a consulta
+			// não enxerga; o provedor não o traz de volta.
+			if errors.Is(err, userbus.ErrUniqueEmail) {
+				return userbus.User{}, fmt.Errorf("create: email[%s]: %w", email.Address, ErrNotManaged)
+			}
+			return userbus.User{}, fmt.Errorf("create: email[%s]: %w", email.Address, err)
+		}
+
+		c.log.Info(ctx, "saml: user provisioned", "userID", usr.ID, "tenantID", p.TenantID)
+
+		return usr, nil
+
+	case err != nil:
+		return userbus.User{}, fmt.Errorf("queryByEmail: %w", err)
+	}
+
+	if !tenantRole(usr.Role) {
+		return userbus.User{}, fmt.Errorf("userID[%s]: %w", usr.ID, ErrNotManaged)
+	}
+
+	// Quem não é membro de nenhum cliente também fica de fora: o provedor
+	// do cliente não pode assumir uma conta só por afirmar o e-mail dela.
+	tenantID, err := c.tenantBus.QueryTenantIDByUserID(ctx, usr.ID)
+	switch {
+	case errors.Is(err, tenantbus.ErrNotFound):
+		return userbus.User{}, fmt.Errorf("userID[%s]: %w", usr.ID, ErrNotManaged)
+	case err != nil:
+		return userbus.User{}, fmt.Errorf("queryTenantIDByUserID: userID[%s]: %w", usr.ID, err)
+	case tenantID != p.TenantID:
+		return userbus.User{}, fmt.Errorf("userID[%s]: %w", usr.ID, ErrNotManaged)
+	}
+
+	if !usr.Enabled {
+		return userbus.User{}, fmt.Errorf("userID[%s]: %w", usr.ID, userbus.ErrDisabled)
+	}
+
+	if !usr.Role.Equal(r) {
+		usr, err = c.userBus.Update(ctx, usr, userbus.UpdateUser{Role: &r})
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+		}
+	}
+
+	return usr, nil
+}
+
+// validate checks the settings of an identity provider. The mapped
+// dashboards must belong to the tenant.
+func (c *Core) validate(ctx context.Context, tenantID uuid.UUID, sp SaveProvider) error {
+	if strings.TrimSpace(sp.EntityID) == "" {
+		return fmt.Errorf("%w: entity id is required", ErrInvalidSettings)
+	}
+
+	u, err := url.Parse(sp.SSOURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: sso url must be an http(s) URL", ErrInvalidSettings)
+	}
+
+	if _, err := saml.ParseCertificates(sp.Certificate); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSettings, err)
+	}
+
+	if len(sp.RoleMappings) > 0 && sp.RoleAttribute == "" {
+		return fmt.Errorf("%w: role mappings need the role attribute", ErrInvalidSettings)
+	}
+
+	for _, m := range sp.RoleMappings {
+		if !tenantRole(m.Role) {
+			return ErrInvalidRole
+		}
+	}
+
+	if sp.DefaultRole != nil && !tenantRole(*sp.DefaultRole) {
+		return ErrInvalidRole
+	}
+
+	if len(sp.DashboardMappings) > 0 && sp.DashboardAttribute == "" {
+		return fmt.Errorf("%w: dashboard mappings need the dashboard attribute", ErrInvalidSettings)
+	}
+
+	for _, m := range sp.DashboardMappings {
+		d, err := c.dashboardBus.QueryByID(ctx, m.DashboardID)
+		if err != nil {
+			if errors.Is(err, dashboardbus.ErrNotFound) {
+				return fmt.Errorf("%w: dashboard %s not found", ErrInvalidSettings, m.DashboardID)
+			}
+			return fmt.Errorf("query dashboard: dashboardID[%s]: %w", m.DashboardID, err)
+		}
+
+		if d.TenantID != tenantID {
+			return fmt.Errorf("%w: dashboard %s belongs to another tenant", ErrInvalidSettings, m.DashboardID)
+		}
+	}
+
+	return nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// tenantRole reports whether the role is one of the roles of a tenant, the
+// only ones an identity provider may grant. ADMIN and ANALYST act across
+// tenants.
+func tenantRole(r role.Role) bool {
+	return r.Equal(role.User) || r.Equal(role.TenantAdmin)
+}
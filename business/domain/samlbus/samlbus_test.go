@@ -0,0 +1,186 @@
+package samlbus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/mail"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores/tenantmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/usermemory"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+)
+
+func TestProvision(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		setup   func(t *testing.T, f fixture) uuid.UUID
+		wantErr error
+	}{
+		{
+			name:  "new",
+			email: "new@acme.com",
+		},
+		{
+			name:  "member",
+			email: "member@acme.com",
+			setup: func(t *testing.T, f fixture) uuid.UUID {
+				return f.createUser(t, "member@acme.com", f.acme).ID
+			},
+		},
+		{
+			name:  "disabled",
+			email: "disabled@acme.com",
+			setup: func(t *testing.T, f fixture) uuid.UUID {
+				usr := f.createUser(t, "disabled@acme.com", f.acme)
+
+				enabled := false
+				if _, err := f.userBus.Update(context.Background(), usr, userbus.UpdateUser{Enabled: &enabled}); err != nil {
+					t.Fatalf("disable: %s", err)
+				}
+
+				return usr.ID
+			},
+			wantErr: userbus.ErrDisabled,
+		},
+		{
+			name:  "deleted",
+			email: "deleted@acme.com",
+			setup: func(t *testing.T, f fixture) uuid.UUID {
+				usr := f.createUser(t, "deleted@acme.com", f.acme)
+
+				if err := f.userBus.Delete(context.Background(), usr); err != nil {
+					t.Fatalf("delete: %s", err)
+				}
+
+				return usr.ID
+			},
+			wantErr: ErrNotManaged,
+		},
+		{
+			name:  "otherTenant",
+			email: "user@other.com",
+			setup: func(t *testing.T, f fixture) uuid.UUID {
+				return f.createUser(t, "user@other.com", f.other).ID
+			},
+			wantErr: ErrNotManaged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			var userID uuid.UUID
+			if tt.setup != nil {
+				userID = tt.setup(t, f)
+			}
+
+			p := Provider{TenantID: f.acme}
+
+			usr, err := f.core.provision(context.Background(), p, mail.Address{Address: tt.email}, "", role.User)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && userID != uuid.Nil && usr.ID != userID {
+				t.Errorf("got user %s, want %s", usr.ID, userID)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// fixture holds two tenants: acme, the tenant of the identity provider, and
+// other, which has its own members.
+type fixture struct {
+	core        *Core
+	userBus     *userbus.Core
+	tenantBus   *tenantbus.Core
+	tenantStore *tenantmemory.Store
+
+	acme  uuid.UUID
+	other uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	box, err := crypto.NewBox(make([]byte, crypto.KeySize))
+	if err != nil {
+		t.Fatalf("box: %s", err)
+	}
+
+	hasher, err := passhash.New(passhash.Config{Cost: 4, Workers: 1})
+	if err != nil {
+		t.Fatalf("hasher: %s", err)
+	}
+
+	dlg := delegate.New(log)
+	tenantStore := tenantmemory.NewStore()
+
+	f := fixture{
+		userBus:     userbus.NewCore(usermemory.NewStore(), outboxbus.NewCore(log, outboxmemory.NewStore(), box), dlg, hasher),
+		tenantBus:   tenantbus.NewCore(log, dlg, tenantStore),
+		tenantStore: tenantStore,
+	}
+
+	f.core = NewCore(log, nil, f.userBus, f.tenantBus, nil)
+
+	newTenant := func(s string) uuid.UUID {
+		tn, err := f.tenantBus.Create(context.Background(), tenantbus.NewTenant{
+			Name: name.MustParse("Tenant"),
+			Slug: slug.MustParse(s),
+		})
+		if err != nil {
+			t.Fatalf("create tenant %s: %s", s, err)
+		}
+
+		return tn.ID
+	}
+
+	f.acme = newTenant("acme")
+	f.other = newTenant("other")
+
+	return f
+}
+
+// createUser creates an enabled USER as a member of the tenant.
+func (f fixture) createUser(t *testing.T, email string, tenantID uuid.UUID) userbus.User {
+	t.Helper()
+
+	usr, err := f.userBus.Create(context.Background(), userbus.NewUser{
+		Name:     name.MustParse("Test User"),
+		Email:    mail.Address{Address: email},
+		Phone:    phone.MustParseNull(""),
+		Role:     role.User,
+		Password: password.MustParse("Secr3t!pass"),
+	})
+	if err != nil {
+		t.Fatalf("create %s: %s", email, err)
+	}
+
+	if err := f.tenantStore.AddUserToTenant(context.Background(), usr.ID, tenantID); err != nil {
+		t.Fatalf("add %s: %s", email, err)
+	}
+
+	return usr
+}
@@ -0,0 +1,142 @@
+package samldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type providerDB struct {
+	TenantID           uuid.UUID      `db:"tenant_id"`
+	EntityID           string         `db:"entity_id"`
+	SSOURL             string         `db:"sso_url"`
+	Certificate        string         `db:"certificate"`
+	EmailAttribute     string         `db:"email_attribute"`
+	NameAttribute      string         `db:"name_attribute"`
+	RoleAttribute      string         `db:"role_attribute"`
+	RoleMappings       string         `db:"role_mappings"`
+	DefaultRole        sql.NullString `db:"default_role"`
+	DashboardAttribute string         `db:"dashboard_attribute"`
+	DashboardMappings  string         `db:"dashboard_mappings"`
+	Enabled            bool           `db:"enabled"`
+	CreatedAt          time.Time      `db:"created_at"`
+	UpdatedAt          time.Time      `db:"updated_at"`
+}
+
+type roleMappingDB struct {
+	Value string `json:"value"`
+	Role  string `json:"role"`
+}
+
+type dashboardMappingDB struct {
+	Value       string    `json:"value"`
+	DashboardID uuid.UUID `json:"dashboardId"`
+}
+
+func toDBProvider(bus samlbus.Provider) providerDB {
+	roles := make([]roleMappingDB, len(bus.RoleMappings))
+	for i, m := range bus.RoleMappings {
+		roles[i] = roleMappingDB{
+			Value: m.Value,
+			Role:  m.Role.String(),
+		}
+	}
+
+	dashboards := make([]dashboardMappingDB, len(bus.DashboardMappings))
+	for i, m := range bus.DashboardMappings {
+		dashboards[i] = dashboardMappingDB(m)
+	}
+
+	roleMappings := "[]"
+	if data, err := json.Marshal(roles); err == nil {
+		roleMappings = string(data)
+	}
+
+	dashboardMappings := "[]"
+	if data, err := json.Marshal(dashboards); err == nil {
+		dashboardMappings = string(data)
+	}
+
+	var defaultRole sql.NullString
+	if bus.DefaultRole != nil {
+		defaultRole = sql.NullString{String: bus.DefaultRole.String(), Valid: true}
+	}
+
+	return providerDB{
+		TenantID:           bus.TenantID,
+		EntityID:           bus.EntityID,
+		SSOURL:             bus.SSOURL,
+		Certificate:        bus.Certificate,
+		EmailAttribute:     bus.EmailAttribute,
+		NameAttribute:      bus.NameAttribute,
+		RoleAttribute:      bus.RoleAttribute,
+		RoleMappings:       roleMappings,
+		DefaultRole:        defaultRole,
+		DashboardAttribute: bus.DashboardAttribute,
+		DashboardMappings:  dashboardMappings,
+		Enabled:            bus.Enabled,
+		CreatedAt:          bus.CreatedAt.UTC(),
+		UpdatedAt:          bus.UpdatedAt.UTC(),
+	}
+}
+
+func toBusProvider(db providerDB) (samlbus.Provider, error) {
+	var roles []roleMappingDB
+	if err := json.Unmarshal([]byte(db.RoleMappings), &roles); err != nil {
+		return samlbus.Provider{}, fmt.Errorf("parse role mappings: %w", err)
+	}
+
+	roleMappings := make([]samlbus.RoleMapping, len(roles))
+	for i, m := range roles {
+		r, err := role.Parse(m.Role)
+		if err != nil {
+			return samlbus.Provider{}, fmt.Errorf("parse role mapping: %w", err)
+		}
+
+		roleMappings[i] = samlbus.RoleMapping{
+			Value: m.Value,
+			Role:  r,
+		}
+	}
+
+	var dashboards []dashboardMappingDB
+	if err := json.Unmarshal([]byte(db.DashboardMappings), &dashboards); err != nil {
+		return samlbus.Provider{}, fmt.Errorf("parse dashboard mappings: %w", err)
+	}
+
+	dashboardMappings := make([]samlbus.DashboardMapping, len(dashboards))
+	for i, m := range dashboards {
+		dashboardMappings[i] = samlbus.DashboardMapping(m)
+	}
+
+	var defaultRole *role.Role
+	if db.DefaultRole.Valid {
+		r, err := role.Parse(db.DefaultRole.String)
+		if err != nil {
+			return samlbus.Provider{}, fmt.Errorf("parse default role: %w", err)
+		}
+		defaultRole = &r
+	}
+
+	return samlbus.Provider{
+		TenantID:           db.TenantID,
+		EntityID:           db.EntityID,
+		SSOURL:             db.SSOURL,
+		Certificate:        db.Certificate,
+		EmailAttribute:     db.EmailAttribute,
+		NameAttribute:      db.NameAttribute,
+		RoleAttribute:      db.RoleAttribute,
+		RoleMappings:       roleMappings,
+		DefaultRole:        defaultRole,
+		DashboardAttribute: db.DashboardAttribute,
+		DashboardMappings:  dashboardMappings,
+		Enabled:            db.Enabled,
+		CreatedAt:          db.CreatedAt.In(time.Local),
+		UpdatedAt:          db.UpdatedAt.In(time.Local),
+	}, nil
+}
@@ -0,0 +1,167 @@
+// Package samldb contains identity provider related CRUD functionality.
+package samldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/samlbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for identity provider database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Save inserts the identity provider of the tenant or replaces the current
+// one.
+func (s *Store) Save(ctx context.Context, p samlbus.Provider) error {
+	const q = `
+	INSERT INTO "public"."tenant_saml"
+		(tenant_id, entity_id, sso_url, certificate, email_attribute, name_attribute, role_attribute,
+		 role_mappings, default_role, dashboard_attribute, dashboard_mappings, enabled, created_at, updated_at)
+	VALUES
+		(:tenant_id, :entity_id, :sso_url, :certificate, :email_attribute, :name_attribute, :role_attribute,
+		 :role_mappings, :default_role, :dashboard_attribute, :dashboard_mappings, :enabled, :created_at, :updated_at)
+	ON CONFLICT (tenant_id) DO UPDATE SET
+		entity_id = EXCLUDED.entity_id,
+		sso_url = EXCLUDED.sso_url,
+		certificate = EXCLUDED.certificate,
+		email_attribute = EXCLUDED.email_attribute,
+		name_attribute = EXCLUDED.name_attribute,
+		role_attribute = EXCLUDED.role_attribute,
+		role_mappings = EXCLUDED.role_mappings,
+		default_role = EXCLUDED.default_role,
+		dashboard_attribute = EXCLUDED.dashboard_attribute,
+		dashboard_mappings = EXCLUDED.dashboard_mappings,
+		enabled = EXCLUDED.enabled,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProvider(p)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the identity provider of the tenant from the database.
+func (s *Store) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."tenant_saml"
+	WHERE
+		tenant_id = :tenant_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByTenant gets the identity provider of the tenant from the database.
+func (s *Store) QueryByTenant(ctx context.Context, tenantID uuid.UUID) (samlbus.Provider, error) {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, entity_id, sso_url, certificate, email_attribute, name_attribute, role_attribute,
+		role_mappings, default_role, dashboard_attribute, dashboard_mappings, enabled, created_at, updated_at
+	FROM
+		"public"."tenant_saml"
+	WHERE
+		tenant_id = :tenant_id`
+
+	var dbProv providerDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbProv); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return samlbus.Provider{}, fmt.Errorf("db: %w", samlbus.ErrNotFound)
+		}
+		return samlbus.Provider{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusProvider(dbProv)
+}
+
+// SaveAssertion records a consumed assertion. Recording the same assertion
+// twice returns samlbus.ErrReplayed.
+func (s *Store) SaveAssertion(ctx context.Context, tenantID uuid.UUID, assertionID string, expiresAt time.Time) error {
+	data := struct {
+		TenantID    string    `db:"tenant_id"`
+		AssertionID string    `db:"assertion_id"`
+		ExpiresAt   time.Time `db:"expires_at"`
+	}{
+		TenantID:    tenantID.String(),
+		AssertionID: assertionID,
+		ExpiresAt:   expiresAt.UTC(),
+	}
+
+	const q = `
+	INSERT INTO "public"."saml_assertion"
+		(tenant_id, assertion_id, expires_at)
+	VALUES
+		(:tenant_id, :assertion_id, :expires_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) {
+			return fmt.Errorf("namedexeccontext: %w", samlbus.ErrReplayed)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredAssertions removes the consumed assertions that expired.
+func (s *Store) DeleteExpiredAssertions(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."saml_assertion"
+		WHERE
+			expires_at <= :now
+		RETURNING assertion_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,39 @@
+package tenantbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+)
+
+// removeUserAccess drops the links between a user and the dashboards, and
+// the groups of the user, when the user action asks for it. Disabled users
+// keep them, so enabling the account again brings the dashboards back.
+func (c *Core) removeUserAccess(ctx context.Context, data delegate.Data) error {
+	params, err := userbus.ParseActionParams(data.RawParams)
+	if err != nil {
+		return fmt.Errorf("parseActionParams: %w", err)
+	}
+
+	if !params.RemoveDashboardAccess {
+		return nil
+	}
+
+	storer := c.storer
+	if data.Tx != nil {
+		if storer, err = c.storer.NewWithTx(data.Tx); err != nil {
+			return fmt.Errorf("newWithTx: %w", err)
+		}
+	}
+
+	n, err := storer.RemoveUserFromDashboards(ctx, params.UserID)
+	if err != nil {
+		return fmt.Errorf("removeUserFromDashboards: userID[%s]: %w", params.UserID, err)
+	}
+
+	c.log.Info(ctx, "dashboard access removed", "userID", params.UserID, "action", data.Action, "dashboards", n)
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package tenantbus
+
+import (
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID      *uuid.UUID
+	Name    *string
+	Slug    *slug.Slug
+	Enabled *bool
+}
@@ -0,0 +1,138 @@
+package tenantbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// CreateGroup adds a new group to the tenant.
+func (c *Core) CreateGroup(ctx context.Context, ng NewGroup) (Group, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.createGroup")
+	defer span.End()
+
+	now := time.Now()
+
+	g := Group{
+		ID:        uuid.New(),
+		TenantID:  ng.TenantID,
+		Name:      ng.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := c.storer.CreateGroup(ctx, g); err != nil {
+		return Group{}, fmt.Errorf("createGroup: %w", err)
+	}
+
+	return g, nil
+}
+
+// DeleteGroup removes the group, along with its members and the dashboards
+// granted to it.
+func (c *Core) DeleteGroup(ctx context.Context, g Group) error {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.deleteGroup")
+	defer span.End()
+
+	if err := c.storer.DeleteGroup(ctx, g); err != nil {
+		return fmt.Errorf("deleteGroup: groupID[%s]: %w", g.ID, err)
+	}
+
+	return nil
+}
+
+// QueryGroupByID finds the group by the specified ID.
+func (c *Core) QueryGroupByID(ctx context.Context, groupID uuid.UUID) (Group, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryGroupByID")
+	defer span.End()
+
+	g, err := c.storer.QueryGroupByID(ctx, groupID)
+	if err != nil {
+		return Group{}, fmt.Errorf("queryGroupByID: groupID[%s]: %w", groupID, err)
+	}
+
+	return g, nil
+}
+
+// QueryGroups returns the groups of the tenant, by name.
+func (c *Core) QueryGroups(ctx context.Context, tenantID uuid.UUID) ([]Group, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryGroups")
+	defer span.End()
+
+	groups, err := c.storer.QueryGroups(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("queryGroups: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return groups, nil
+}
+
+// AddGroupMember adds the user to the group. The user must be a member of
+// the tenant of the group. Adding a member again is a no-op.
+func (c *Core) AddGroupMember(ctx context.Context, g Group, userID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.addGroupMember")
+	defer span.End()
+
+	if err := c.storer.CheckTenantAccess(ctx, userID, g.TenantID); err != nil {
+		return fmt.Errorf("checkTenantAccess: userID[%s] tenantID[%s]: %w", userID, g.TenantID, err)
+	}
+
+	if err := c.storer.AddUserToGroup(ctx, g.ID, userID); err != nil {
+		return fmt.Errorf("addUserToGroup: groupID[%s] userID[%s]: %w", g.ID, userID, err)
+	}
+
+	return nil
+}
+
+// RemoveGroupMember removes the user from the group. The dashboards the user
+// was granted directly are kept.
+func (c *Core) RemoveGroupMember(ctx context.Context, g Group, userID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.removeGroupMember")
+	defer span.End()
+
+	if err := c.storer.RemoveUserFromGroup(ctx, g.ID, userID); err != nil {
+		return fmt.Errorf("removeUserFromGroup: groupID[%s] userID[%s]: %w", g.ID, userID, err)
+	}
+
+	return nil
+}
+
+// GrantGroupAccessToDashboard grants the dashboard to every member of the
+// group. The dashboard must belong to the tenant of the group.
+func (c *Core) GrantGroupAccessToDashboard(ctx context.Context, g Group, dashboardID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.grantGroupAccessToDashboard")
+	defer span.End()
+
+	tenantID, err := c.storer.QueryTenantIDByDashboardID(ctx, dashboardID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return fmt.Errorf("queryTenantIDByDashboardID[%s]: %w", dashboardID, ErrAccessDenied)
+	case err != nil:
+		return fmt.Errorf("queryTenantIDByDashboardID[%s]: %w", dashboardID, err)
+	case tenantID != g.TenantID:
+		return fmt.Errorf("dashboard[%s] not in tenant[%s]: %w", dashboardID, g.TenantID, ErrAccessDenied)
+	}
+
+	if err := c.storer.AddGroupToDashboard(ctx, g.ID, dashboardID, tenantID); err != nil {
+		return fmt.Errorf("addGroupToDashboard: groupID[%s] dashboardID[%s]: %w", g.ID, dashboardID, err)
+	}
+
+	return nil
+}
+
+// RevokeGroupAccessToDashboard removes the dashboard from the group. Members
+// that were granted the dashboard directly keep it.
+func (c *Core) RevokeGroupAccessToDashboard(ctx context.Context, g Group, dashboardID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.revokeGroupAccessToDashboard")
+	defer span.End()
+
+	if err := c.storer.RemoveGroupFromDashboard(ctx, g.ID, dashboardID); err != nil {
+		return fmt.Errorf("removeGroupFromDashboard: groupID[%s] dashboardID[%s]: %w", g.ID, dashboardID, err)
+	}
+
+	return nil
+}
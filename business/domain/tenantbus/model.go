@@ -4,16 +4,61 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
 )
 
+// NameRules are the rules a tenant name complies with. Tenant names are
+// company or agency names, longer than person names and with punctuation.
+var NameRules = name.Rules{
+	MinLength: 2,
+	MaxLength: 256,
+	Charset:   name.Organization,
+}
+
 // Tenant represents a client organization or workspace in the system.
 type Tenant struct {
 	ID        uuid.UUID
-	Name      string
-	Slug      string
+	Name      name.Name
+	Slug      slug.Slug
 	Enabled   bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// SelfRegistration lets users sign up through the domains of the tenant.
+	SelfRegistration bool
+
+	// ScopedEmails makes the emails of the members unique only inside the
+	// tenant, instead of across the whole system.
+	ScopedEmails bool
+
+	// RoleApproval makes the promotion of a member to ADMIN wait for a
+	// second approver.
+	RoleApproval bool
+}
+
+// GroupNameRules are the rules a group name complies with. Groups are
+// usually named after departments, so they follow the tenant names.
+var GroupNameRules = name.Rules{
+	MinLength: 2,
+	MaxLength: 100,
+	Charset:   name.Organization,
+}
+
+// Group represents a set of users of a tenant, like a department. The
+// dashboards granted to the group are granted to every member.
+type Group struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	Name      name.Name
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NewGroup contains information needed to create a new group.
+type NewGroup struct {
+	TenantID uuid.UUID
+	Name     name.Name
 }
 
 // UserDashboardAccess represents the granular permission link between a user and a dashboard.
@@ -22,14 +67,25 @@ type TenantDashboard struct {
 	DashboardID uuid.UUID
 }
 
+// Dashboard represents a dashboard listed to pick the context of a session.
+type Dashboard struct {
+	ID       uuid.UUID
+	TenantID uuid.UUID
+	Name     string
+	Domain   *string
+}
+
 // NewTenant contains information needed to create a new tenant.
 type NewTenant struct {
-	Name string
-	Slug string
+	Name name.Name
+	Slug slug.Slug
 }
 
 // UpdateTenant contains information needed to update a tenant.
 type UpdateTenant struct {
-	Name    *string
-	Enabled *bool
+	Name             *name.Name
+	Enabled          *bool
+	SelfRegistration *bool
+	ScopedEmails     *bool
+	RoleApproval     *bool
 }
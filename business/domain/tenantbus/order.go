@@ -0,0 +1,12 @@
+package tenantbus
+
+import "github.com/jcpaschoal/spi-exata/business/sdk/order"
+
+var DefaultOrderBy = order.NewBy(OrderByName, order.ASC)
+
+const (
+	OrderByID      = "a"
+	OrderByName    = "b"
+	OrderBySlug    = "c"
+	OrderByEnabled = "d"
+)
@@ -0,0 +1,192 @@
+package tenantdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+)
+
+// CreateGroup inserts a new group into the database.
+func (s *Store) CreateGroup(ctx context.Context, g tenantbus.Group) error {
+	const q = `
+	INSERT INTO "public"."user_group"
+		(group_id, tenant_id, name, created_at, updated_at)
+	VALUES
+		(:group_id, :tenant_id, :name, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBGroup(g)); err != nil {
+		var dupErr sqldb.ErrDBDuplicatedEntry
+		if errors.As(err, &dupErr) && dupErr.Constraint == "uq_user_group_name" {
+			return fmt.Errorf("namedexeccontext: %w", tenantbus.ErrUniqueGroupName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGroup removes a group from the database. The members and the
+// dashboard access of the group go with it.
+func (s *Store) DeleteGroup(ctx context.Context, g tenantbus.Group) error {
+	const q = `
+	DELETE FROM
+		"public"."user_group"
+	WHERE
+		group_id = :group_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBGroup(g)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryGroupByID gets the specified group from the database.
+func (s *Store) QueryGroupByID(ctx context.Context, groupID uuid.UUID) (tenantbus.Group, error) {
+	data := struct {
+		GroupID string `db:"group_id"`
+	}{
+		GroupID: groupID.String(),
+	}
+
+	const q = `
+	SELECT
+		group_id, tenant_id, name, created_at, updated_at
+	FROM
+		"public"."user_group"
+	WHERE
+		group_id = :group_id`
+
+	var dbGroup groupDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbGroup); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return tenantbus.Group{}, fmt.Errorf("db: %w", tenantbus.ErrGroupNotFound)
+		}
+		return tenantbus.Group{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusGroup(dbGroup)
+}
+
+// QueryGroups retrieves the groups of the tenant.
+func (s *Store) QueryGroups(ctx context.Context, tenantID uuid.UUID) ([]tenantbus.Group, error) {
+	data := struct {
+		TenantID string `db:"tenant_id"`
+	}{
+		TenantID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		group_id, tenant_id, name, created_at, updated_at
+	FROM
+		"public"."user_group"
+	WHERE
+		tenant_id = :tenant_id
+	ORDER BY
+		name, group_id`
+
+	var dbGroups []groupDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbGroups); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusGroups(dbGroups)
+}
+
+// AddUserToGroup inserts a record into user_group_member.
+func (s *Store) AddUserToGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	data := struct {
+		GroupID string `db:"group_id"`
+		UserID  string `db:"user_id"`
+	}{
+		GroupID: groupID.String(),
+		UserID:  userID.String(),
+	}
+
+	const q = `
+	INSERT INTO "public"."user_group_member" (group_id, user_id, created_at)
+	VALUES (:group_id, :user_id, NOW())
+	ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveUserFromGroup deletes a record from user_group_member.
+func (s *Store) RemoveUserFromGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	data := struct {
+		GroupID string `db:"group_id"`
+		UserID  string `db:"user_id"`
+	}{
+		GroupID: groupID.String(),
+		UserID:  userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."user_group_member"
+	WHERE
+		group_id = :group_id AND user_id = :user_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// AddGroupToDashboard inserts a group record into user_dashboard_access.
+func (s *Store) AddGroupToDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error {
+	data := struct {
+		GroupID     string `db:"group_id"`
+		DashboardID string `db:"dashboard_id"`
+		TenantID    string `db:"tenant_id"`
+	}{
+		GroupID:     groupID.String(),
+		DashboardID: dashboardID.String(),
+		TenantID:    tenantID.String(),
+	}
+
+	const q = `
+	INSERT INTO "public"."user_dashboard_access" (group_id, dashboard_id, tenant_id, created_at)
+	VALUES (:group_id, :dashboard_id, :tenant_id, NOW())
+	ON CONFLICT (group_id, dashboard_id) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveGroupFromDashboard deletes the group record from
+// user_dashboard_access.
+func (s *Store) RemoveGroupFromDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID) error {
+	data := struct {
+		GroupID     string `db:"group_id"`
+		DashboardID string `db:"dashboard_id"`
+	}{
+		GroupID:     groupID.String(),
+		DashboardID: dashboardID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."user_dashboard_access"
+	WHERE
+		group_id = :group_id AND dashboard_id = :dashboard_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
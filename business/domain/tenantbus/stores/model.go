@@ -1,40 +1,149 @@
 package tenantdb
 
 import (
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
 )
 
 // tenantDB represents the structure of the tenant table in the database.
 type tenantDB struct {
-	ID        uuid.UUID `db:"tenant_id"`
+	ID               uuid.UUID `db:"tenant_id"`
+	Name             string    `db:"name"`
+	Slug             string    `db:"slug"`
+	Enabled          bool      `db:"enabled"`
+	SelfRegistration bool      `db:"self_registration"`
+	ScopedEmails     bool      `db:"scoped_emails"`
+	RoleApproval     bool      `db:"role_approval"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+func toDBTenant(bus tenantbus.Tenant) tenantDB {
+	return tenantDB{
+		ID:               bus.ID,
+		Name:             bus.Name.String(),
+		Slug:             bus.Slug.String(),
+		Enabled:          bus.Enabled,
+		SelfRegistration: bus.SelfRegistration,
+		ScopedEmails:     bus.ScopedEmails,
+		RoleApproval:     bus.RoleApproval,
+		CreatedAt:        bus.CreatedAt,
+		UpdatedAt:        bus.UpdatedAt,
+	}
+}
+
+func toBusTenant(db tenantDB) (tenantbus.Tenant, error) {
+	n, err := name.ParseWith(db.Name, tenantbus.NameRules)
+	if err != nil {
+		return tenantbus.Tenant{}, fmt.Errorf("parse name: %w", err)
+	}
+
+	s, err := slug.Parse(db.Slug)
+	if err != nil {
+		return tenantbus.Tenant{}, fmt.Errorf("parse slug: %w", err)
+	}
+
+	bus := tenantbus.Tenant{
+		ID:               db.ID,
+		Name:             n,
+		Slug:             s,
+		Enabled:          db.Enabled,
+		SelfRegistration: db.SelfRegistration,
+		ScopedEmails:     db.ScopedEmails,
+		RoleApproval:     db.RoleApproval,
+		CreatedAt:        db.CreatedAt,
+		UpdatedAt:        db.UpdatedAt,
+	}
+
+	return bus, nil
+}
+
+func toBusTenants(dbs []tenantDB) ([]tenantbus.Tenant, error) {
+	bus := make([]tenantbus.Tenant, len(dbs))
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusTenant(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bus, nil
+}
+
+// dashboardDB represents a dashboard listed from the dashboard table.
+type dashboardDB struct {
+	ID       uuid.UUID      `db:"dashboard_id"`
+	TenantID uuid.UUID      `db:"tenant_id"`
+	Name     string         `db:"name"`
+	Domain   sql.NullString `db:"domain"`
+}
+
+func toBusDashboards(dbs []dashboardDB) []tenantbus.Dashboard {
+	dashboards := make([]tenantbus.Dashboard, len(dbs))
+	for i, db := range dbs {
+		dashboards[i] = tenantbus.Dashboard{
+			ID:       db.ID,
+			TenantID: db.TenantID,
+			Name:     db.Name,
+		}
+
+		if db.Domain.Valid {
+			dashboards[i].Domain = &db.Domain.String
+		}
+	}
+	return dashboards
+}
+
+// groupDB represents the structure of the user_group table in the database.
+type groupDB struct {
+	ID        uuid.UUID `db:"group_id"`
+	TenantID  uuid.UUID `db:"tenant_id"`
 	Name      string    `db:"name"`
-	Slug      string    `db:"slug"`
-	Enabled   bool      `db:"enabled"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 }
 
-func toDBTenant(bus tenantbus.Tenant) tenantDB {
-	return tenantDB{
+func toDBGroup(bus tenantbus.Group) groupDB {
+	return groupDB{
 		ID:        bus.ID,
-		Name:      bus.Name,
-		Slug:      bus.Slug,
-		Enabled:   bus.Enabled,
+		TenantID:  bus.TenantID,
+		Name:      bus.Name.String(),
 		CreatedAt: bus.CreatedAt,
 		UpdatedAt: bus.UpdatedAt,
 	}
 }
 
-func toBusTenant(db tenantDB) tenantbus.Tenant {
-	return tenantbus.Tenant{
+func toBusGroup(db groupDB) (tenantbus.Group, error) {
+	n, err := name.ParseWith(db.Name, tenantbus.GroupNameRules)
+	if err != nil {
+		return tenantbus.Group{}, fmt.Errorf("parse name: %w", err)
+	}
+
+	bus := tenantbus.Group{
 		ID:        db.ID,
-		Name:      db.Name,
-		Slug:      db.Slug,
-		Enabled:   db.Enabled,
+		TenantID:  db.TenantID,
+		Name:      n,
 		CreatedAt: db.CreatedAt,
 		UpdatedAt: db.UpdatedAt,
 	}
+
+	return bus, nil
+}
+
+func toBusGroups(dbs []groupDB) ([]tenantbus.Group, error) {
+	bus := make([]tenantbus.Group, len(dbs))
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusGroup(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bus, nil
 }
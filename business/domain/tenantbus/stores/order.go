@@ -0,0 +1,24 @@
+package tenantdb
+
+import (
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	tenantbus.OrderByID:      "tenant_id",
+	tenantbus.OrderByName:    "name",
+	tenantbus.OrderBySlug:    "slug",
+	tenantbus.OrderByEnabled: "enabled",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -1,13 +1,17 @@
 package tenantdb
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
 	"github.com/jmoiron/sqlx"
 )
@@ -19,7 +23,7 @@ type Store struct {
 }
 
 // NewStore constructs the api for data access.
-func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
 	return &Store{
 		log: log,
 		db:  db,
@@ -46,14 +50,14 @@ func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (tenantbus.Storer, error) {
 func (s *Store) Create(ctx context.Context, t tenantbus.Tenant) error {
 	const q = `
 	INSERT INTO "public"."tenant"
-		(tenant_id, name, slug, enabled, created_at, updated_at)
+		(tenant_id, name, slug, enabled, self_registration, scoped_emails, role_approval, created_at, updated_at)
 	VALUES
-		(:tenant_id, :name, :slug, :enabled, :created_at, :updated_at)`
+		(:tenant_id, :name, :slug, :enabled, :self_registration, :scoped_emails, :role_approval, :created_at, :updated_at)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBTenant(t)); err != nil {
 		var dupErr sqldb.ErrDBDuplicatedEntry
 		if errors.As(err, &dupErr) {
-			if dupErr.Column == "slug" || dupErr.Column == "uq_tenant_slug" {
+			if dupErr.Column == "slug" || dupErr.Constraint == "uq_tenant_slug" {
 				return fmt.Errorf("namedexeccontext: %w", tenantbus.ErrUniqueSlug)
 			}
 		}
@@ -71,11 +75,17 @@ func (s *Store) Update(ctx context.Context, t tenantbus.Tenant) error {
 	SET 
 		name = :name,
 		enabled = :enabled,
+		self_registration = :self_registration,
+		scoped_emails = :scoped_emails,
+		role_approval = :role_approval,
 		updated_at = :updated_at
 	WHERE
 		tenant_id = :tenant_id`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBTenant(t)); err != nil {
+		if isEmailConflict(err) {
+			return fmt.Errorf("namedexeccontext: %w", tenantbus.ErrEmailConflict)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
@@ -97,6 +107,123 @@ func (s *Store) Delete(ctx context.Context, t tenantbus.Tenant) error {
 	return nil
 }
 
+// Query retrieves a list of existing tenants from the database.
+func (s *Store) Query(ctx context.Context, filter tenantbus.QueryFilter, orderBy order.By, page page.Page) ([]tenantbus.Tenant, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, name, slug, enabled, self_registration, scoped_emails, role_approval, created_at, updated_at
+	FROM
+		"public"."tenant"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbTenants []tenantDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbTenants); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusTenants(dbTenants)
+}
+
+// QueryWithCount retrieves a page of tenants along with the total number of
+// tenants that match the filter in a single round trip. The total comes from
+// a window function, computed before the paging.
+func (s *Store) QueryWithCount(ctx context.Context, filter tenantbus.QueryFilter, orderBy order.By, page page.Page) ([]tenantbus.Tenant, int, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, name, slug, enabled, self_registration, scoped_emails, role_approval, created_at, updated_at,
+		count(1) OVER() AS total
+	FROM
+		"public"."tenant"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbTenants []struct {
+		tenantDB
+		Total int `db:"total"`
+	}
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbTenants); err != nil {
+		return nil, 0, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	// Uma página vazia além da primeira não traz o total na linha.
+	if len(dbTenants) == 0 {
+		if page.Number() == 1 {
+			return []tenantbus.Tenant{}, 0, nil
+		}
+
+		total, err := s.Count(ctx, filter)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return []tenantbus.Tenant{}, total, nil
+	}
+
+	tenants := make([]tenantDB, len(dbTenants))
+	for i, dt := range dbTenants {
+		tenants[i] = dt.tenantDB
+	}
+
+	ts, err := toBusTenants(tenants)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ts, dbTenants[0].Total, nil
+}
+
+// Count returns the total number of tenants in the DB.
+func (s *Store) Count(ctx context.Context, filter tenantbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."tenant"`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
 // QueryByID gets the specified tenant from the database.
 func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenantbus.Tenant, error) {
 	data := struct {
@@ -107,7 +234,7 @@ func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenantbus.Te
 
 	const q = `
 	SELECT
-		tenant_id, name, slug, enabled, created_at, updated_at
+		tenant_id, name, slug, enabled, self_registration, scoped_emails, role_approval, created_at, updated_at
 	FROM
 		"public"."tenant"
 	WHERE 
@@ -121,15 +248,15 @@ func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenantbus.Te
 		return tenantbus.Tenant{}, fmt.Errorf("db: %w", err)
 	}
 
-	return toBusTenant(dbT), nil
+	return toBusTenant(dbT)
 }
 
 // QueryIDBySlug retrieves the tenant ID for the specified slug.
-func (s *Store) QueryIDBySlug(ctx context.Context, slug string) (uuid.UUID, error) {
+func (s *Store) QueryIDBySlug(ctx context.Context, slug slug.Slug) (uuid.UUID, error) {
 	data := struct {
 		Slug string `db:"slug"`
 	}{
-		Slug: slug,
+		Slug: slug.String(),
 	}
 
 	const q = `
@@ -154,7 +281,8 @@ func (s *Store) QueryIDBySlug(ctx context.Context, slug string) (uuid.UUID, erro
 	return result.ID, nil
 }
 
-// QueryByDomain retrieves the TenantID and DashboardID associated with a specific domain.
+// QueryByDomain retrieves the TenantID and DashboardID associated with a specific domain,
+// either the primary domain of the dashboard or one of its aliases.
 func (s *Store) QueryByDomain(ctx context.Context, domain string) (tenantbus.TenantDashboard, error) {
 	data := struct {
 		Domain string `db:"domain"`
@@ -162,13 +290,24 @@ func (s *Store) QueryByDomain(ctx context.Context, domain string) (tenantbus.Ten
 		Domain: domain,
 	}
 
+	// O domínio pode ser o principal do dashboard ou um dos seus aliases.
 	const q = `
 	SELECT
-		tenant_id, dashboard_id
+		d.tenant_id, d.dashboard_id
 	FROM
-		"public"."dashboard"
+		"public"."dashboard" AS d
 	WHERE
-		domain = :domain`
+		d.domain = :domain
+	UNION ALL
+	SELECT
+		d.tenant_id, d.dashboard_id
+	FROM
+		"public"."dashboard_domain" AS dd
+	JOIN
+		"public"."dashboard" AS d ON d.dashboard_id = dd.dashboard_id
+	WHERE
+		dd.domain = :domain
+	LIMIT 1`
 
 	var result struct {
 		TenantID    uuid.UUID `db:"tenant_id"`
@@ -222,9 +361,8 @@ func (s *Store) CheckTenantAccess(ctx context.Context, userID uuid.UUID, tenantI
 }
 
 // CheckUserDashboardAccess checks granular permissions for a user on a dashboard.
-// Uses the composite PK (user_id, dashboard_id).
-// CheckUserDashboardAccess checks granular permissions for a user on a dashboard.
-// Uses the composite PK (user_id, dashboard_id) and validates the tenant context.
+// The dashboard is granted to the user directly or to a group the user is a
+// member of, and the grant must be in the tenant context.
 func (s *Store) CheckUserDashboardAccess(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error {
 	data := struct {
 		UserID      string `db:"user_id"`
@@ -240,11 +378,14 @@ func (s *Store) CheckUserDashboardAccess(ctx context.Context, userID uuid.UUID,
 	SELECT
 		1
 	FROM
-		"public"."user_dashboard_access"
+		"public"."user_dashboard_access" AS a
+	LEFT JOIN
+		"public"."user_group_member" AS gm ON gm.group_id = a.group_id AND gm.user_id = :user_id
 	WHERE
-		user_id = :user_id 
-		AND dashboard_id = :dashboard_id 
-		AND tenant_id = :tenant_id`
+		a.dashboard_id = :dashboard_id
+		AND a.tenant_id = :tenant_id
+		AND (a.user_id = :user_id OR gm.user_id IS NOT NULL)
+	LIMIT 1`
 
 	var result struct {
 		Exists int `db:"?column?"`
@@ -335,6 +476,9 @@ func (s *Store) AddUserToTenant(ctx context.Context, userID uuid.UUID, tenantID
 	// Nota: Como a PK é user_id (1:1 strict), atualizamos o tenant se o usuário já tiver um.
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		if isEmailConflict(err) {
+			return fmt.Errorf("namedexeccontext: %w", tenantbus.ErrEmailConflict)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
@@ -364,3 +508,103 @@ func (s *Store) AddUserToDashboard(ctx context.Context, userID uuid.UUID, dashbo
 
 	return nil
 }
+
+// RemoveUserFromDashboards removes every dashboard access of the user, along
+// with the groups the user is a member of, and returns how many dashboards
+// were granted to the user directly.
+func (s *Store) RemoveUserFromDashboards(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	WITH groups AS (
+		DELETE FROM
+			"public"."user_group_member"
+		WHERE
+			user_id = :user_id
+	),
+	deleted AS (
+		DELETE FROM
+			"public"."user_dashboard_access"
+		WHERE
+			user_id = :user_id
+		RETURNING dashboard_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryUserDashboards retrieves the dashboards granted to the user, directly
+// or through the groups.
+func (s *Store) QueryUserDashboards(ctx context.Context, userID uuid.UUID) ([]tenantbus.Dashboard, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT DISTINCT
+		d.dashboard_id, d.tenant_id, d.name, d.domain
+	FROM
+		"public"."user_dashboard_access" AS a
+	JOIN
+		"public"."dashboard" AS d ON d.dashboard_id = a.dashboard_id AND d.tenant_id = a.tenant_id
+	LEFT JOIN
+		"public"."user_group_member" AS gm ON gm.group_id = a.group_id AND gm.user_id = :user_id
+	WHERE
+		a.user_id = :user_id OR gm.user_id IS NOT NULL
+	ORDER BY
+		d.name, d.dashboard_id`
+
+	var dbDashboards []dashboardDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDashboards); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDashboards(dbDashboards), nil
+}
+
+// QueryDashboards retrieves the dashboards of the tenant.
+func (s *Store) QueryDashboards(ctx context.Context, tenantID uuid.UUID) ([]tenantbus.Dashboard, error) {
+	data := struct {
+		TenantID string `db:"tenant_id"`
+	}{
+		TenantID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		dashboard_id, tenant_id, name, domain
+	FROM
+		"public"."dashboard"
+	WHERE
+		tenant_id = :tenant_id
+	ORDER BY
+		name, dashboard_id`
+
+	var dbDashboards []dashboardDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDashboards); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDashboards(dbDashboards), nil
+}
+
+// isEmailConflict reports whether the error is the email uniqueness of the
+// users, which the triggers check when the scope of the emails changes.
+func isEmailConflict(err error) bool {
+	var dupErr sqldb.ErrDBDuplicatedEntry
+	return errors.As(err, &dupErr) && dupErr.Constraint == "uq_users_email"
+}
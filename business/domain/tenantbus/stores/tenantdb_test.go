@@ -0,0 +1,335 @@
+package tenantdb_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/dashboardbus/stores/dashboarddb"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	tenantdb "github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/dbtest"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+func Test_Tenant(t *testing.T) {
+	db := dbtest.New(t, "Test_Tenant")
+	store := tenantdb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	acme := createTenant(t, store, "acme")
+	other := createTenant(t, store, "other")
+
+	dashboards := dashboarddb.NewStore(db.Log, db.DB)
+	dash := createDashboard(t, dashboards, acme.ID, "acme.example.com")
+	otherDash := createDashboard(t, dashboards, other.ID, "other.example.com")
+
+	if err := dashboards.CreateAlias(ctx, dashboardbus.Domain{Name: "old.acme.example.com", DashboardID: dash.ID, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("create alias: %s", err)
+	}
+
+	users := userdb.NewStore(db.Log, db.DB)
+	direct := createUser(t, users, "direct@example.com")
+	grouped := createUser(t, users, "grouped@example.com")
+	outsider := createUser(t, users, "outsider@example.com")
+
+	for _, id := range []uuid.UUID{direct.ID, grouped.ID} {
+		if err := store.AddUserToTenant(ctx, id, acme.ID); err != nil {
+			t.Fatalf("add user to tenant: %s", err)
+		}
+	}
+
+	if err := store.AddUserToDashboard(ctx, direct.ID, dash.ID, acme.ID); err != nil {
+		t.Fatalf("add user to dashboard: %s", err)
+	}
+
+	grp := tenantbus.Group{
+		ID:        uuid.New(),
+		TenantID:  acme.ID,
+		Name:      name.MustParse("Finance"),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := store.CreateGroup(ctx, grp); err != nil {
+		t.Fatalf("create group: %s", err)
+	}
+	if err := store.AddUserToGroup(ctx, grp.ID, grouped.ID); err != nil {
+		t.Fatalf("add user to group: %s", err)
+	}
+	if err := store.AddGroupToDashboard(ctx, grp.ID, dash.ID, acme.ID); err != nil {
+		t.Fatalf("add group to dashboard: %s", err)
+	}
+
+	t.Run("checkUserDashboardAccess", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			userID      uuid.UUID
+			dashboardID uuid.UUID
+			tenantID    uuid.UUID
+			wantErr     error
+		}{
+			{name: "direct", userID: direct.ID, dashboardID: dash.ID, tenantID: acme.ID},
+			{name: "group", userID: grouped.ID, dashboardID: dash.ID, tenantID: acme.ID},
+			{name: "otherTenant", userID: direct.ID, dashboardID: dash.ID, tenantID: other.ID, wantErr: tenantbus.ErrAccessDenied},
+			{name: "otherDashboard", userID: direct.ID, dashboardID: otherDash.ID, tenantID: other.ID, wantErr: tenantbus.ErrAccessDenied},
+			{name: "notGranted", userID: outsider.ID, dashboardID: dash.ID, tenantID: acme.ID, wantErr: tenantbus.ErrAccessDenied},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := store.CheckUserDashboardAccess(ctx, tt.userID, tt.dashboardID, tt.tenantID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("checkTenantAccess", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			userID   uuid.UUID
+			tenantID uuid.UUID
+			wantErr  error
+		}{
+			{name: "member", userID: direct.ID, tenantID: acme.ID},
+			{name: "otherTenant", userID: direct.ID, tenantID: other.ID, wantErr: tenantbus.ErrAccessDenied},
+			{name: "notMember", userID: outsider.ID, tenantID: acme.ID, wantErr: tenantbus.ErrAccessDenied},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := store.CheckTenantAccess(ctx, tt.userID, tt.tenantID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("queryTenantIDByUserID", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			userID  uuid.UUID
+			want    uuid.UUID
+			wantErr error
+		}{
+			{name: "member", userID: grouped.ID, want: acme.ID},
+			{name: "notMember", userID: outsider.ID, wantErr: tenantbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryTenantIDByUserID(ctx, tt.userID)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("got tenant %s, want %s", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("queryByDomain", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			domain  string
+			want    tenantbus.TenantDashboard
+			wantErr error
+		}{
+			{name: "primary", domain: "acme.example.com", want: tenantbus.TenantDashboard{TenantID: acme.ID, DashboardID: dash.ID}},
+			{name: "alias", domain: "old.acme.example.com", want: tenantbus.TenantDashboard{TenantID: acme.ID, DashboardID: dash.ID}},
+			{name: "otherTenant", domain: "other.example.com", want: tenantbus.TenantDashboard{TenantID: other.ID, DashboardID: otherDash.ID}},
+			{name: "unknown", domain: "unknown.example.com", wantErr: tenantbus.ErrDomainNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryByDomain(ctx, tt.domain)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("queryUserDashboards", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			userID uuid.UUID
+			want   []uuid.UUID
+		}{
+			{name: "direct", userID: direct.ID, want: []uuid.UUID{dash.ID}},
+			{name: "group", userID: grouped.ID, want: []uuid.UUID{dash.ID}},
+			{name: "notGranted", userID: outsider.ID},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryUserDashboards(ctx, tt.userID)
+				if err != nil {
+					t.Fatalf("query: %s", err)
+				}
+
+				if len(got) != len(tt.want) {
+					t.Fatalf("got %d dashboards, want %d", len(got), len(tt.want))
+				}
+				for i, id := range tt.want {
+					if got[i].ID != id {
+						t.Errorf("got dashboard %s at %d, want %s", got[i].ID, i, id)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("createUnique", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			slug    string
+			wantErr error
+		}{
+			{name: "slug", slug: "acme", wantErr: tenantbus.ErrUniqueSlug},
+			{name: "new", slug: "globex"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := store.Create(ctx, newTenant(tt.slug)); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("createGroupUnique", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			tenantID uuid.UUID
+			group    string
+			wantErr  error
+		}{
+			{name: "sameTenant", tenantID: acme.ID, group: "Finance", wantErr: tenantbus.ErrUniqueGroupName},
+			{name: "otherTenant", tenantID: other.ID, group: "Finance"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				g := tenantbus.Group{
+					ID:        uuid.New(),
+					TenantID:  tt.tenantID,
+					Name:      name.MustParse(tt.group),
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}
+
+				if err := store.CreateGroup(ctx, g); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+}
+
+// BenchmarkCheckUserDashboardAccess measures the access check made on every
+// request in a dashboard context.
+func BenchmarkCheckUserDashboardAccess(b *testing.B) {
+	db := dbtest.New(b, "BenchmarkCheckUserDashboardAccess")
+	store := tenantdb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	tnt := createTenant(b, store, "acme")
+	dash := createDashboard(b, dashboarddb.NewStore(db.Log, db.DB), tnt.ID, "acme.example.com")
+	usr := createUser(b, userdb.NewStore(db.Log, db.DB), "user@example.com")
+
+	if err := store.AddUserToDashboard(ctx, usr.ID, dash.ID, tnt.ID); err != nil {
+		b.Fatalf("add user to dashboard: %s", err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := store.CheckUserDashboardAccess(ctx, usr.ID, dash.ID, tnt.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// =============================================================================
+
+func newTenant(s string) tenantbus.Tenant {
+	now := time.Now()
+
+	return tenantbus.Tenant{
+		ID:        uuid.New(),
+		Name:      name.MustParse("Tenant " + s),
+		Slug:      slug.MustParse(s),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func createTenant(t testing.TB, store *tenantdb.Store, s string) tenantbus.Tenant {
+	t.Helper()
+
+	tnt := newTenant(s)
+	if err := store.Create(context.Background(), tnt); err != nil {
+		t.Fatalf("create tenant %s: %s", s, err)
+	}
+
+	return tnt
+}
+
+func createDashboard(t testing.TB, store *dashboarddb.Store, tenantID uuid.UUID, domain string) dashboardbus.Dashboard {
+	t.Helper()
+
+	now := time.Now()
+
+	d, err := store.Create(context.Background(), dashboardbus.Dashboard{
+		TenantID:  tenantID,
+		Name:      name.MustParse("Dashboard"),
+		Domain:    &domain,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("create dashboard %s: %s", domain, err)
+	}
+
+	return d
+}
+
+func createUser(t testing.TB, store *userdb.Store, email string) userbus.User {
+	t.Helper()
+
+	now := time.Now()
+
+	usr := userbus.User{
+		ID:           uuid.New(),
+		Name:         name.MustParse("Test User"),
+		Email:        mail.Address{Address: email},
+		Role:         role.User,
+		PasswordHash: []byte("hash"),
+		Enabled:      true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := store.Create(context.Background(), usr); err != nil {
+		t.Fatalf("create user %s: %s", email, err)
+	}
+
+	return usr
+}
@@ -0,0 +1,557 @@
+// Package tenantmemory contains an in-memory tenant store used to exercise
+// the business layer without a database.
+package tenantmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+)
+
+type dashboard struct {
+	tenantID uuid.UUID
+	domain   string
+}
+
+type access struct {
+	userID      uuid.UUID
+	dashboardID uuid.UUID
+}
+
+type groupMember struct {
+	groupID uuid.UUID
+	userID  uuid.UUID
+}
+
+type groupAccess struct {
+	groupID     uuid.UUID
+	dashboardID uuid.UUID
+}
+
+// Store manages the set of APIs for tenant access kept in memory.
+type Store struct {
+	mu           *sync.RWMutex
+	tenants      map[uuid.UUID]tenantbus.Tenant
+	dashboards   map[uuid.UUID]dashboard
+	members      map[uuid.UUID]uuid.UUID
+	access       map[access]uuid.UUID
+	groups       map[uuid.UUID]tenantbus.Group
+	groupMembers map[groupMember]struct{}
+	groupAccess  map[groupAccess]uuid.UUID
+}
+
+// NewStore constructs an empty store.
+func NewStore() *Store {
+	return &Store{
+		mu:           &sync.RWMutex{},
+		tenants:      make(map[uuid.UUID]tenantbus.Tenant),
+		dashboards:   make(map[uuid.UUID]dashboard),
+		members:      make(map[uuid.UUID]uuid.UUID),
+		access:       make(map[access]uuid.UUID),
+		groups:       make(map[uuid.UUID]tenantbus.Group),
+		groupMembers: make(map[groupMember]struct{}),
+		groupAccess:  make(map[groupAccess]uuid.UUID),
+	}
+}
+
+// NewWithTx returns the same store. Changes are applied immediately and are
+// not undone if the transaction rolls back.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (tenantbus.Storer, error) {
+	return s, nil
+}
+
+// AddDashboard registers a dashboard published under domain, standing in for
+// the dashboard table that the database store reads.
+func (s *Store) AddDashboard(tenantID uuid.UUID, dashboardID uuid.UUID, domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dashboards[dashboardID] = dashboard{
+		tenantID: tenantID,
+		domain:   domain,
+	}
+}
+
+// Create adds a new tenant to the store.
+func (s *Store) Create(ctx context.Context, t tenantbus.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.tenants {
+		if other.Slug.Equal(t.Slug) {
+			return fmt.Errorf("create: %w", tenantbus.ErrUniqueSlug)
+		}
+	}
+
+	s.tenants[t.ID] = t
+
+	return nil
+}
+
+// Update replaces a tenant in the store.
+func (s *Store) Update(ctx context.Context, t tenantbus.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tenants[t.ID]; exists {
+		s.tenants[t.ID] = t
+	}
+
+	return nil
+}
+
+// Delete removes a tenant from the store.
+func (s *Store) Delete(ctx context.Context, t tenantbus.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tenants, t.ID)
+
+	return nil
+}
+
+// Query retrieves a list of existing tenants from the store.
+func (s *Store) Query(ctx context.Context, filter tenantbus.QueryFilter, orderBy order.By, page page.Page) ([]tenantbus.Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	less, err := lessFunc(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := s.filter(filter)
+	sort.SliceStable(tenants, func(i, j int) bool {
+		return less(tenants[i], tenants[j])
+	})
+
+	offset := (page.Number() - 1) * page.RowsPerPage()
+	if offset >= len(tenants) {
+		return []tenantbus.Tenant{}, nil
+	}
+
+	end := min(offset+page.RowsPerPage(), len(tenants))
+
+	return tenants[offset:end], nil
+}
+
+// Count returns the total number of tenants in the store.
+func (s *Store) Count(ctx context.Context, filter tenantbus.QueryFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.filter(filter)), nil
+}
+
+// QueryWithCount retrieves a page of tenants along with the total number of
+// tenants that match the filter.
+func (s *Store) QueryWithCount(ctx context.Context, filter tenantbus.QueryFilter, orderBy order.By, page page.Page) ([]tenantbus.Tenant, int, error) {
+	tenants, err := s.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return tenants, total, nil
+}
+
+// QueryByID gets the specified tenant from the store.
+func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenantbus.Tenant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, exists := s.tenants[tenantID]
+	if !exists {
+		return tenantbus.Tenant{}, fmt.Errorf("memory: %w", tenantbus.ErrNotFound)
+	}
+
+	return t, nil
+}
+
+// QueryIDBySlug returns the tenant ID for the specified slug.
+func (s *Store) QueryIDBySlug(ctx context.Context, slug slug.Slug) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tenants {
+		if t.Slug.Equal(slug) {
+			return t.ID, nil
+		}
+	}
+
+	return uuid.Nil, tenantbus.ErrNotFound
+}
+
+// QueryByDomain retrieves the tenant and dashboard published under domain.
+func (s *Store) QueryByDomain(ctx context.Context, domain string) (tenantbus.TenantDashboard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, d := range s.dashboards {
+		if d.domain == domain {
+			return tenantbus.TenantDashboard{TenantID: d.tenantID, DashboardID: id}, nil
+		}
+	}
+
+	return tenantbus.TenantDashboard{}, tenantbus.ErrDomainNotFound
+}
+
+// CheckTenantAccess checks if a user is a member of a tenant.
+func (s *Store) CheckTenantAccess(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id, exists := s.members[userID]; !exists || id != tenantID {
+		return tenantbus.ErrAccessDenied
+	}
+
+	return nil
+}
+
+// CheckUserDashboardAccess checks if the user was granted the dashboard in
+// the tenant, directly or through a group.
+func (s *Store) CheckUserDashboardAccess(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id, exists := s.access[access{userID: userID, dashboardID: dashboardID}]; exists && id == tenantID {
+		return nil
+	}
+
+	for key, id := range s.groupAccess {
+		if key.dashboardID != dashboardID || id != tenantID {
+			continue
+		}
+
+		if _, member := s.groupMembers[groupMember{groupID: key.groupID, userID: userID}]; member {
+			return nil
+		}
+	}
+
+	return tenantbus.ErrAccessDenied
+}
+
+// QueryTenantIDByUserID retrieves the tenant the user belongs to.
+func (s *Store) QueryTenantIDByUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenantID, exists := s.members[userID]
+	if !exists {
+		return uuid.Nil, tenantbus.ErrNotFound
+	}
+
+	return tenantID, nil
+}
+
+// QueryTenantIDByDashboardID retrieves the tenant owning the dashboard.
+func (s *Store) QueryTenantIDByDashboardID(ctx context.Context, dashboardID uuid.UUID) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, exists := s.dashboards[dashboardID]
+	if !exists {
+		return uuid.Nil, tenantbus.ErrNotFound
+	}
+
+	return d.tenantID, nil
+}
+
+// AddUserToTenant sets the tenant of the user, replacing any previous one as
+// the membership table does. The user leaves the groups of the previous
+// tenant.
+func (s *Store) AddUserToTenant(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members[userID] = tenantID
+
+	for key := range s.groupMembers {
+		if key.userID == userID && s.groups[key.groupID].TenantID != tenantID {
+			delete(s.groupMembers, key)
+		}
+	}
+
+	return nil
+}
+
+// AddUserToDashboard grants the user access to the dashboard. Granting it
+// again is a no-op.
+func (s *Store) AddUserToDashboard(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := access{userID: userID, dashboardID: dashboardID}
+	if _, exists := s.access[key]; !exists {
+		s.access[key] = tenantID
+	}
+
+	return nil
+}
+
+// RemoveUserFromDashboards removes every dashboard access of the user,
+// along with the groups of the user.
+func (s *Store) RemoveUserFromDashboards(ctx context.Context, userID uuid.UUID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.groupMembers {
+		if key.userID == userID {
+			delete(s.groupMembers, key)
+		}
+	}
+
+	var n int
+	for key := range s.access {
+		if key.userID == userID {
+			delete(s.access, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// QueryUserDashboards returns the dashboards granted to the user, directly
+// or through the groups. The store keeps no dashboard names.
+func (s *Store) QueryUserDashboards(ctx context.Context, userID uuid.UUID) ([]tenantbus.Dashboard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	granted := make(map[uuid.UUID]uuid.UUID)
+	for key, tenantID := range s.access {
+		if key.userID == userID {
+			granted[key.dashboardID] = tenantID
+		}
+	}
+
+	for key, tenantID := range s.groupAccess {
+		if _, member := s.groupMembers[groupMember{groupID: key.groupID, userID: userID}]; member {
+			granted[key.dashboardID] = tenantID
+		}
+	}
+
+	var dashboards []tenantbus.Dashboard
+	for dashboardID, tenantID := range granted {
+		d := s.toBusDashboard(dashboardID)
+		d.TenantID = tenantID
+		dashboards = append(dashboards, d)
+	}
+
+	sortDashboards(dashboards)
+
+	return dashboards, nil
+}
+
+// QueryDashboards returns the dashboards of the tenant.
+func (s *Store) QueryDashboards(ctx context.Context, tenantID uuid.UUID) ([]tenantbus.Dashboard, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dashboards []tenantbus.Dashboard
+	for id, d := range s.dashboards {
+		if d.tenantID == tenantID {
+			dashboards = append(dashboards, s.toBusDashboard(id))
+		}
+	}
+
+	sortDashboards(dashboards)
+
+	return dashboards, nil
+}
+
+// CreateGroup adds a new group to the store.
+func (s *Store) CreateGroup(ctx context.Context, g tenantbus.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.groups {
+		if other.TenantID == g.TenantID && other.Name.Equal(g.Name) {
+			return fmt.Errorf("createGroup: %w", tenantbus.ErrUniqueGroupName)
+		}
+	}
+
+	s.groups[g.ID] = g
+
+	return nil
+}
+
+// DeleteGroup removes a group from the store, with its members and the
+// dashboard access of the group.
+func (s *Store) DeleteGroup(ctx context.Context, g tenantbus.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.groups, g.ID)
+
+	for key := range s.groupMembers {
+		if key.groupID == g.ID {
+			delete(s.groupMembers, key)
+		}
+	}
+
+	for key := range s.groupAccess {
+		if key.groupID == g.ID {
+			delete(s.groupAccess, key)
+		}
+	}
+
+	return nil
+}
+
+// QueryGroupByID gets the specified group from the store.
+func (s *Store) QueryGroupByID(ctx context.Context, groupID uuid.UUID) (tenantbus.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, exists := s.groups[groupID]
+	if !exists {
+		return tenantbus.Group{}, fmt.Errorf("memory: %w", tenantbus.ErrGroupNotFound)
+	}
+
+	return g, nil
+}
+
+// QueryGroups returns the groups of the tenant, by name.
+func (s *Store) QueryGroups(ctx context.Context, tenantID uuid.UUID) ([]tenantbus.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var groups []tenantbus.Group
+	for _, g := range s.groups {
+		if g.TenantID == tenantID {
+			groups = append(groups, g)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name.String() < groups[j].Name.String()
+	})
+
+	return groups, nil
+}
+
+// AddUserToGroup adds the user to the group. Adding it again is a no-op.
+func (s *Store) AddUserToGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groupMembers[groupMember{groupID: groupID, userID: userID}] = struct{}{}
+
+	return nil
+}
+
+// RemoveUserFromGroup removes the user from the group.
+func (s *Store) RemoveUserFromGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.groupMembers, groupMember{groupID: groupID, userID: userID})
+
+	return nil
+}
+
+// AddGroupToDashboard grants the group access to the dashboard. Granting it
+// again is a no-op.
+func (s *Store) AddGroupToDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := groupAccess{groupID: groupID, dashboardID: dashboardID}
+	if _, exists := s.groupAccess[key]; !exists {
+		s.groupAccess[key] = tenantID
+	}
+
+	return nil
+}
+
+// RemoveGroupFromDashboard removes the access of the group to the dashboard.
+func (s *Store) RemoveGroupFromDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.groupAccess, groupAccess{groupID: groupID, dashboardID: dashboardID})
+
+	return nil
+}
+
+// =============================================================================
+
+func (s *Store) toBusDashboard(dashboardID uuid.UUID) tenantbus.Dashboard {
+	d := s.dashboards[dashboardID]
+
+	dashboard := tenantbus.Dashboard{
+		ID:       dashboardID,
+		TenantID: d.tenantID,
+	}
+
+	if d.domain != "" {
+		domain := d.domain
+		dashboard.Domain = &domain
+	}
+
+	return dashboard
+}
+
+func sortDashboards(dashboards []tenantbus.Dashboard) {
+	sort.Slice(dashboards, func(i, j int) bool {
+		return dashboards[i].ID.String() < dashboards[j].ID.String()
+	})
+}
+
+func (s *Store) filter(filter tenantbus.QueryFilter) []tenantbus.Tenant {
+	tenants := make([]tenantbus.Tenant, 0, len(s.tenants))
+
+	for _, t := range s.tenants {
+		switch {
+		case filter.ID != nil && t.ID != *filter.ID:
+			continue
+		case filter.Name != nil && !strings.Contains(strings.ToLower(t.Name.String()), strings.ToLower(*filter.Name)):
+			continue
+		case filter.Slug != nil && !t.Slug.Equal(*filter.Slug):
+			continue
+		case filter.Enabled != nil && t.Enabled != *filter.Enabled:
+			continue
+		}
+
+		tenants = append(tenants, t)
+	}
+
+	return tenants
+}
+
+func lessFunc(orderBy order.By) (func(a, b tenantbus.Tenant) bool, error) {
+	var less func(a, b tenantbus.Tenant) bool
+
+	switch orderBy.Field {
+	case tenantbus.OrderByID:
+		less = func(a, b tenantbus.Tenant) bool { return a.ID.String() < b.ID.String() }
+	case tenantbus.OrderByName:
+		less = func(a, b tenantbus.Tenant) bool { return a.Name.String() < b.Name.String() }
+	case tenantbus.OrderBySlug:
+		less = func(a, b tenantbus.Tenant) bool { return a.Slug.String() < b.Slug.String() }
+	case tenantbus.OrderByEnabled:
+		less = func(a, b tenantbus.Tenant) bool { return !a.Enabled && b.Enabled }
+	default:
+		return nil, fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	if orderBy.Direction == order.DESC {
+		return func(a, b tenantbus.Tenant) bool { return less(b, a) }, nil
+	}
+
+	return less, nil
+}
@@ -0,0 +1,323 @@
+package tenantbus_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/tenantbus/stores/tenantmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// fixture holds two tenants: acme publishes main.example.com and owns a
+// second dashboard granted to the Finance group; other publishes
+// other.example.com.
+type fixture struct {
+	core     *tenantbus.Core
+	delegate *delegate.Delegate
+
+	acme      tenantbus.Tenant
+	other     tenantbus.Tenant
+	dash      uuid.UUID
+	finance   uuid.UUID
+	otherDash uuid.UUID
+	group     tenantbus.Group
+
+	direct   uuid.UUID
+	member   uuid.UUID
+	outsider uuid.UUID
+}
+
+func TestCreate(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name    string
+		slug    string
+		wantErr error
+	}{
+		{name: "duplicate", slug: "acme", wantErr: tenantbus.ErrUniqueSlug},
+		{name: "new", slug: "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.core.Create(context.Background(), tenantbus.NewTenant{
+				Name: name.MustParse("Tenant"),
+				Slug: slug.MustParse(tt.slug),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserAccessToDashboard(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		domain  string
+		want    tenantbus.TenantDashboard
+		wantErr error
+	}{
+		{name: "direct", userID: f.direct, domain: "main.example.com", want: tenantbus.TenantDashboard{TenantID: f.acme.ID, DashboardID: f.dash}},
+		{name: "notGranted", userID: f.member, domain: "main.example.com", wantErr: tenantbus.ErrAccessDenied},
+		{name: "otherTenant", userID: f.direct, domain: "other.example.com", wantErr: tenantbus.ErrAccessDenied},
+		{name: "unknownDomain", userID: f.direct, domain: "unknown.example.com", wantErr: tenantbus.ErrDomainNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.AuthorizeUserAccessToDashboard(context.Background(), tt.userID, tt.domain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserDashboard(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		dashboardID uuid.UUID
+		want        tenantbus.TenantDashboard
+		wantErr     error
+	}{
+		{name: "domainDashboard", userID: f.direct, dashboardID: f.dash, want: tenantbus.TenantDashboard{TenantID: f.acme.ID, DashboardID: f.dash}},
+		{name: "group", userID: f.member, dashboardID: f.finance, want: tenantbus.TenantDashboard{TenantID: f.acme.ID, DashboardID: f.finance}},
+		{name: "notGranted", userID: f.direct, dashboardID: f.finance, wantErr: tenantbus.ErrAccessDenied},
+		{name: "otherTenant", userID: f.outsider, dashboardID: f.otherDash, wantErr: tenantbus.ErrAccessDenied},
+		{name: "unknownDashboard", userID: f.direct, dashboardID: uuid.New(), wantErr: tenantbus.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.AuthorizeUserDashboard(context.Background(), tt.userID, "main.example.com", tt.dashboardID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeDashboard(t *testing.T) {
+	f := newFixture(t)
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		dashboardID uuid.UUID
+		want        tenantbus.TenantDashboard
+		wantErr     error
+	}{
+		{name: "direct", userID: f.direct, dashboardID: f.dash, want: tenantbus.TenantDashboard{TenantID: f.acme.ID, DashboardID: f.dash}},
+		{name: "otherTenant", userID: f.outsider, dashboardID: f.otherDash, want: tenantbus.TenantDashboard{TenantID: f.other.ID, DashboardID: f.otherDash}},
+		{name: "notGranted", userID: f.outsider, dashboardID: f.dash, wantErr: tenantbus.ErrAccessDenied},
+		{name: "unknown", userID: f.direct, dashboardID: uuid.New(), wantErr: tenantbus.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.AuthorizeDashboard(context.Background(), tt.userID, tt.dashboardID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroup(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	t.Run("createUnique", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			tenantID uuid.UUID
+			wantErr  error
+		}{
+			{name: "sameTenant", tenantID: f.acme.ID, wantErr: tenantbus.ErrUniqueGroupName},
+			{name: "otherTenant", tenantID: f.other.ID},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := f.core.CreateGroup(ctx, tenantbus.NewGroup{TenantID: tt.tenantID, Name: f.group.Name})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("addMember", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			userID  uuid.UUID
+			wantErr error
+		}{
+			{name: "member", userID: f.direct},
+			{name: "otherTenant", userID: f.outsider, wantErr: tenantbus.ErrAccessDenied},
+			{name: "noTenant", userID: uuid.New(), wantErr: tenantbus.ErrAccessDenied},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := f.core.AddGroupMember(ctx, f.group, tt.userID); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("grantDashboard", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			dashboardID uuid.UUID
+			wantErr     error
+		}{
+			{name: "sameTenant", dashboardID: f.dash},
+			{name: "otherTenant", dashboardID: f.otherDash, wantErr: tenantbus.ErrAccessDenied},
+			{name: "unknown", dashboardID: uuid.New(), wantErr: tenantbus.ErrAccessDenied},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := f.core.GrantGroupAccessToDashboard(ctx, f.group, tt.dashboardID); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("revokeDashboard", func(t *testing.T) {
+		if err := f.core.RevokeGroupAccessToDashboard(ctx, f.group, f.finance); err != nil {
+			t.Fatalf("revoke: %s", err)
+		}
+
+		if _, err := f.core.AuthorizeDashboard(ctx, f.member, f.finance); !errors.Is(err, tenantbus.ErrAccessDenied) {
+			t.Fatalf("got error %v, want %v", err, tenantbus.ErrAccessDenied)
+		}
+	})
+}
+
+func TestRemoveUserAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		remove  bool
+		wantErr error
+	}{
+		{name: "disabled", action: userbus.ActionDisabled, remove: false},
+		{name: "deleted", action: userbus.ActionDeleted, remove: true, wantErr: tenantbus.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+
+			params := userbus.ActionParams{UserID: f.member, RemoveDashboardAccess: tt.remove}
+			data, err := userbus.ActionData(tt.action, params, nil)
+			if err != nil {
+				t.Fatalf("action data: %s", err)
+			}
+
+			if err := f.delegate.Call(ctx, data); err != nil {
+				t.Fatalf("call: %s", err)
+			}
+
+			if _, err := f.core.AuthorizeDashboard(ctx, f.member, f.finance); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	store := tenantmemory.NewStore()
+	dlg := delegate.New(log)
+	core := tenantbus.NewCore(log, dlg, store)
+	ctx := context.Background()
+
+	createTenant := func(s string) tenantbus.Tenant {
+		tnt, err := core.Create(ctx, tenantbus.NewTenant{Name: name.MustParse("Tenant"), Slug: slug.MustParse(s)})
+		if err != nil {
+			t.Fatalf("create tenant %s: %s", s, err)
+		}
+		return tnt
+	}
+
+	f := fixture{
+		core:      core,
+		delegate:  dlg,
+		acme:      createTenant("acme"),
+		other:     createTenant("other"),
+		dash:      uuid.New(),
+		finance:   uuid.New(),
+		otherDash: uuid.New(),
+		direct:    uuid.New(),
+		member:    uuid.New(),
+		outsider:  uuid.New(),
+	}
+
+	store.AddDashboard(f.acme.ID, f.dash, "main.example.com")
+	store.AddDashboard(f.acme.ID, f.finance, "")
+	store.AddDashboard(f.other.ID, f.otherDash, "other.example.com")
+
+	grant := func(userID uuid.UUID, dashboardID uuid.UUID) {
+		if err := core.GrantUserAccessToDashboard(ctx, userID, dashboardID); err != nil {
+			t.Fatalf("grant: %s", err)
+		}
+	}
+
+	grant(f.direct, f.dash)
+	grant(f.outsider, f.otherDash)
+
+	// O membro entra no tenant sem acesso direto a nenhum dashboard.
+	if err := store.AddUserToTenant(ctx, f.member, f.acme.ID); err != nil {
+		t.Fatalf("add member: %s", err)
+	}
+
+	g, err := core.CreateGroup(ctx, tenantbus.NewGroup{TenantID: f.acme.ID, Name: name.MustParse("Finance")})
+	if err != nil {
+		t.Fatalf("create group: %s", err)
+	}
+	f.group = g
+
+	if err := core.AddGroupMember(ctx, g, f.member); err != nil {
+		t.Fatalf("add group member: %s", err)
+	}
+	if err := core.GrantGroupAccessToDashboard(ctx, g, f.finance); err != nil {
+		t.Fatalf("grant group: %s", err)
+	}
+
+	return f
+}
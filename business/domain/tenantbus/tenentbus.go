@@ -7,7 +7,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/slug"
 	"github.com/jcpaschoal/spi-exata/foundation/logger"
 	"github.com/jcpaschoal/spi-exata/foundation/otel"
 )
@@ -17,6 +22,14 @@ var (
 	ErrDomainNotFound = errors.New("domain not found")
 	ErrAccessDenied   = errors.New("access denied")
 	ErrUniqueSlug     = errors.New("slug is not unique")
+
+	// ErrEmailConflict is returned when the members of a tenant would share
+	// the email with other users in the same scope, like when the scoped
+	// emails of a tenant are turned off.
+	ErrEmailConflict = errors.New("email is used by another user in the same scope")
+
+	ErrGroupNotFound   = errors.New("group not found")
+	ErrUniqueGroupName = errors.New("group name is not unique in the tenant")
 )
 
 // Storer defines the behavior required by the tenantbus to interact with the database.
@@ -27,9 +40,12 @@ type Storer interface {
 	Create(ctx context.Context, t Tenant) error
 	Update(ctx context.Context, t Tenant) error
 	Delete(ctx context.Context, t Tenant) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Tenant, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryWithCount(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Tenant, int, error)
 	QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error)
 
-	QueryIDBySlug(ctx context.Context, slug string) (uuid.UUID, error)
+	QueryIDBySlug(ctx context.Context, slug slug.Slug) (uuid.UUID, error)
 	QueryByDomain(ctx context.Context, domain string) (TenantDashboard, error)
 
 	CheckTenantAccess(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID) error
@@ -38,6 +54,19 @@ type Storer interface {
 	QueryTenantIDByDashboardID(ctx context.Context, dashboardID uuid.UUID) (uuid.UUID, error)
 	AddUserToTenant(ctx context.Context, userID uuid.UUID, tenantID uuid.UUID) error
 	AddUserToDashboard(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error
+	RemoveUserFromDashboards(ctx context.Context, userID uuid.UUID) (int, error)
+	QueryUserDashboards(ctx context.Context, userID uuid.UUID) ([]Dashboard, error)
+	QueryDashboards(ctx context.Context, tenantID uuid.UUID) ([]Dashboard, error)
+
+	// Groups
+	CreateGroup(ctx context.Context, g Group) error
+	DeleteGroup(ctx context.Context, g Group) error
+	QueryGroupByID(ctx context.Context, groupID uuid.UUID) (Group, error)
+	QueryGroups(ctx context.Context, tenantID uuid.UUID) ([]Group, error)
+	AddUserToGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error
+	RemoveUserFromGroup(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error
+	AddGroupToDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID, tenantID uuid.UUID) error
+	RemoveGroupFromDashboard(ctx context.Context, groupID uuid.UUID, dashboardID uuid.UUID) error
 }
 
 // Core manages the set of APIs for tenant access.
@@ -46,12 +75,18 @@ type Core struct {
 	log    *logger.Logger
 }
 
-// NewCore constructs a core for tenant api access.
-func NewCore(log *logger.Logger, storer Storer) *Core {
-	return &Core{
+// NewCore constructs a core for tenant api access. The dashboard access of a
+// user is removed when the user is deleted.
+func NewCore(log *logger.Logger, delegate *delegate.Delegate, storer Storer) *Core {
+	c := Core{
 		storer: storer,
 		log:    log,
 	}
+
+	delegate.Register(userbus.DomainName, userbus.ActionDisabled, c.removeUserAccess)
+	delegate.Register(userbus.DomainName, userbus.ActionDeleted, c.removeUserAccess)
+
+	return &c
 }
 
 // NewWithTx constructs a new Core value replacing the Storer
@@ -62,7 +97,13 @@ func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
 		return nil, fmt.Errorf("newWithTx: %w", err)
 	}
 
-	return NewCore(c.log, storer), nil
+	// Montado direto para não registrar de novo as funções do delegate.
+	nc := Core{
+		storer: storer,
+		log:    c.log,
+	}
+
+	return &nc, nil
 }
 
 // Create adds a new tenant to the system.
@@ -101,6 +142,18 @@ func (c *Core) Update(ctx context.Context, t Tenant, ut UpdateTenant) (Tenant, e
 		t.Enabled = *ut.Enabled
 	}
 
+	if ut.SelfRegistration != nil {
+		t.SelfRegistration = *ut.SelfRegistration
+	}
+
+	if ut.ScopedEmails != nil {
+		t.ScopedEmails = *ut.ScopedEmails
+	}
+
+	if ut.RoleApproval != nil {
+		t.RoleApproval = *ut.RoleApproval
+	}
+
 	t.UpdatedAt = time.Now()
 
 	if err := c.storer.Update(ctx, t); err != nil {
@@ -122,6 +175,41 @@ func (c *Core) Delete(ctx context.Context, t Tenant) error {
 	return nil
 }
 
+// Query retrieves a list of existing tenants.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Tenant, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.query")
+	defer span.End()
+
+	tenants, err := c.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// QueryWithCount retrieves a page of tenants along with the total number of
+// tenants that match the filter, in a single round trip to the store.
+func (c *Core) QueryWithCount(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Tenant, int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryWithCount")
+	defer span.End()
+
+	tenants, total, err := c.storer.QueryWithCount(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("queryWithCount: %w", err)
+	}
+
+	return tenants, total, nil
+}
+
+// Count returns the total number of tenants.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.count")
+	defer span.End()
+
+	return c.storer.Count(ctx, filter)
+}
+
 // QueryByID finds the tenant by the specified ID.
 func (c *Core) QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error) {
 	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryByID")
@@ -135,8 +223,27 @@ func (c *Core) QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error
 	return tenant, nil
 }
 
-// QueryIDBySlug returns the tenant ID for the specified slug string.
-func (c *Core) QueryIDBySlug(ctx context.Context, slug string) (uuid.UUID, error) {
+// EmailScope returns the scope the emails of the members of the tenant are
+// unique in: the tenant itself when it scopes the emails, nil for the whole
+// system.
+func (c *Core) EmailScope(ctx context.Context, tenantID uuid.UUID) (*uuid.UUID, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.emailScope")
+	defer span.End()
+
+	t, err := c.storer.QueryByID(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	if !t.ScopedEmails {
+		return nil, nil
+	}
+
+	return &t.ID, nil
+}
+
+// QueryIDBySlug returns the tenant ID for the specified slug.
+func (c *Core) QueryIDBySlug(ctx context.Context, slug slug.Slug) (uuid.UUID, error) {
 	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryIDBySlug")
 	defer span.End()
 
@@ -149,7 +256,8 @@ func (c *Core) QueryIDBySlug(ctx context.Context, slug string) (uuid.UUID, error
 }
 
 // ResolveDomain translates a domain string (e.g. "sales.corp.com") into the corresponding
-// TenantDashboard context (TenantID and DashboardID).
+// TenantDashboard context (TenantID and DashboardID). Aliases resolve to the same dashboard
+// as its primary domain.
 func (c *Core) ResolveDomain(ctx context.Context, domain string) (TenantDashboard, error) {
 	ctx, span := otel.AddSpan(ctx, "business.tenantbus.resolveDomain")
 	defer span.End()
@@ -209,6 +317,114 @@ func (c *Core) AuthorizeUserAccessToDashboard(ctx context.Context, userID uuid.U
 	return td, nil
 }
 
+// ResolveDomainDashboard returns the dashboard in the context of the tenant of
+// the domain. The dashboard must belong to that tenant, so a session started
+// on a domain can only move between the dashboards of its client.
+func (c *Core) ResolveDomainDashboard(ctx context.Context, domain string, dashboardID uuid.UUID) (TenantDashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.resolveDomainDashboard")
+	defer span.End()
+
+	td, err := c.ResolveDomain(ctx, domain)
+	if err != nil {
+		return TenantDashboard{}, fmt.Errorf("resolveDomain: %w", err)
+	}
+
+	if td.DashboardID == dashboardID {
+		return td, nil
+	}
+
+	tenantID, err := c.storer.QueryTenantIDByDashboardID(ctx, dashboardID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return TenantDashboard{}, fmt.Errorf("queryTenantIDByDashboardID[%s]: %w", dashboardID, ErrAccessDenied)
+	case err != nil:
+		return TenantDashboard{}, fmt.Errorf("queryTenantIDByDashboardID[%s]: %w", dashboardID, err)
+	case tenantID != td.TenantID:
+		return TenantDashboard{}, fmt.Errorf("dashboard[%s] not in tenant[%s]: %w", dashboardID, td.TenantID, ErrAccessDenied)
+	}
+
+	return TenantDashboard{TenantID: tenantID, DashboardID: dashboardID}, nil
+}
+
+// AuthorizeUserDashboard checks the user was granted the dashboard, which
+// must belong to the tenant of the domain. It is how a user picks one of the
+// dashboards of the client other than the one published under the domain.
+func (c *Core) AuthorizeUserDashboard(ctx context.Context, userID uuid.UUID, domain string, dashboardID uuid.UUID) (TenantDashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.authorizeUserDashboard")
+	defer span.End()
+
+	td, err := c.ResolveDomainDashboard(ctx, domain, dashboardID)
+	if err != nil {
+		return TenantDashboard{}, err
+	}
+
+	if err := c.storer.CheckUserDashboardAccess(ctx, userID, td.DashboardID, td.TenantID); err != nil {
+		return TenantDashboard{}, fmt.Errorf("checkUserDashboardAccess[%s]: %w", userID, err)
+	}
+
+	return td, nil
+}
+
+// ResolveDashboard returns the dashboard with the tenant that owns it.
+func (c *Core) ResolveDashboard(ctx context.Context, dashboardID uuid.UUID) (TenantDashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.resolveDashboard")
+	defer span.End()
+
+	tenantID, err := c.storer.QueryTenantIDByDashboardID(ctx, dashboardID)
+	if err != nil {
+		return TenantDashboard{}, fmt.Errorf("queryTenantIDByDashboardID[%s]: %w", dashboardID, err)
+	}
+
+	return TenantDashboard{TenantID: tenantID, DashboardID: dashboardID}, nil
+}
+
+// AuthorizeDashboard checks the user was granted the dashboard, in whatever
+// tenant owns it. A dashboard that does not exist is denied.
+func (c *Core) AuthorizeDashboard(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID) (TenantDashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.authorizeDashboard")
+	defer span.End()
+
+	td, err := c.ResolveDashboard(ctx, dashboardID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return TenantDashboard{}, fmt.Errorf("resolveDashboard: %w", ErrAccessDenied)
+		}
+		return TenantDashboard{}, fmt.Errorf("resolveDashboard: %w", err)
+	}
+
+	if err := c.storer.CheckUserDashboardAccess(ctx, userID, td.DashboardID, td.TenantID); err != nil {
+		return TenantDashboard{}, fmt.Errorf("checkUserDashboardAccess[%s]: %w", userID, err)
+	}
+
+	return td, nil
+}
+
+// QueryUserDashboards returns the dashboards the user was granted, by name.
+func (c *Core) QueryUserDashboards(ctx context.Context, userID uuid.UUID) ([]Dashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryUserDashboards")
+	defer span.End()
+
+	dashboards, err := c.storer.QueryUserDashboards(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("queryUserDashboards[%s]: %w", userID, err)
+	}
+
+	return dashboards, nil
+}
+
+// QueryDashboards returns the dashboards of the tenant, by name.
+func (c *Core) QueryDashboards(ctx context.Context, tenantID uuid.UUID) ([]Dashboard, error) {
+	ctx, span := otel.AddSpan(ctx, "business.tenantbus.queryDashboards")
+	defer span.End()
+
+	dashboards, err := c.storer.QueryDashboards(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("queryDashboards[%s]: %w", tenantID, err)
+	}
+
+	return dashboards, nil
+}
+
 func (c *Core) GrantUserAccessToDashboard(ctx context.Context, userID uuid.UUID, dashboardID uuid.UUID) error {
 	ctx, span := otel.AddSpan(ctx, "business.tenantbus.grantUserAccessToDashboard")
 	defer span.End()
@@ -0,0 +1,12 @@
+package termsbus
+
+import (
+	"github.com/google/uuid"
+)
+
+// AcceptanceFilter holds the available fields an acceptance query can be
+// filtered on.
+type AcceptanceFilter struct {
+	UserID  *uuid.UUID
+	TermsID *uuid.UUID
+}
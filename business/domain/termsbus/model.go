@@ -0,0 +1,46 @@
+package termsbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Terms represents a published version of the terms of service. A version is
+// never changed once published.
+type Terms struct {
+	ID          uuid.UUID
+	Version     string
+	Title       string
+	Body        string
+	PublishedBy uuid.UUID
+	PublishedAt time.Time
+}
+
+// NewTerms contains the information needed to publish a new version of the
+// terms of service.
+type NewTerms struct {
+	Version     string
+	Title       string
+	Body        string
+	PublishedBy uuid.UUID
+}
+
+// Acceptance represents the record of a user accepting a version of the
+// terms of service, with the origin of the request.
+type Acceptance struct {
+	UserID     uuid.UUID
+	TermsID    uuid.UUID
+	Version    string
+	IP         string
+	UserAgent  string
+	AcceptedAt time.Time
+}
+
+// NewAcceptance contains the information needed to record that a user
+// accepted the terms of service.
+type NewAcceptance struct {
+	UserID    uuid.UUID
+	IP        string
+	UserAgent string
+}
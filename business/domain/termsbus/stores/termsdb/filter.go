@@ -0,0 +1,27 @@
+package termsdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+)
+
+func applyFilter(filter termsbus.AcceptanceFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if filter.UserID != nil {
+		data["user_id"] = filter.UserID.String()
+		wc = append(wc, "ta.user_id = :user_id")
+	}
+
+	if filter.TermsID != nil {
+		data["terms_id"] = filter.TermsID.String()
+		wc = append(wc, "ta.terms_id = :terms_id")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,90 @@
+package termsdb
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+)
+
+type termsDB struct {
+	ID          uuid.UUID     `db:"terms_id"`
+	Version     string        `db:"version"`
+	Title       string        `db:"title"`
+	Body        string        `db:"body"`
+	PublishedBy uuid.NullUUID `db:"published_by"`
+	PublishedAt time.Time     `db:"published_at"`
+}
+
+func toDBTerms(bus termsbus.Terms) termsDB {
+	return termsDB{
+		ID:          bus.ID,
+		Version:     bus.Version,
+		Title:       bus.Title,
+		Body:        bus.Body,
+		PublishedBy: uuid.NullUUID{UUID: bus.PublishedBy, Valid: bus.PublishedBy != uuid.Nil},
+		PublishedAt: bus.PublishedAt.UTC(),
+	}
+}
+
+func toBusTerms(db termsDB) termsbus.Terms {
+	return termsbus.Terms{
+		ID:          db.ID,
+		Version:     db.Version,
+		Title:       db.Title,
+		Body:        db.Body,
+		PublishedBy: db.PublishedBy.UUID,
+		PublishedAt: db.PublishedAt.In(time.Local),
+	}
+}
+
+func toBusTermsSlice(dbs []termsDB) []termsbus.Terms {
+	bus := make([]termsbus.Terms, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusTerms(db)
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+type acceptanceDB struct {
+	UserID     uuid.UUID `db:"user_id"`
+	TermsID    uuid.UUID `db:"terms_id"`
+	Version    string    `db:"version"`
+	IP         string    `db:"ip"`
+	UserAgent  string    `db:"user_agent"`
+	AcceptedAt time.Time `db:"accepted_at"`
+}
+
+func toDBAcceptance(bus termsbus.Acceptance) acceptanceDB {
+	return acceptanceDB{
+		UserID:     bus.UserID,
+		TermsID:    bus.TermsID,
+		Version:    bus.Version,
+		IP:         bus.IP,
+		UserAgent:  bus.UserAgent,
+		AcceptedAt: bus.AcceptedAt.UTC(),
+	}
+}
+
+func toBusAcceptance(db acceptanceDB) termsbus.Acceptance {
+	return termsbus.Acceptance{
+		UserID:     db.UserID,
+		TermsID:    db.TermsID,
+		Version:    db.Version,
+		IP:         db.IP,
+		UserAgent:  db.UserAgent,
+		AcceptedAt: db.AcceptedAt.In(time.Local),
+	}
+}
+
+func toBusAcceptances(dbs []acceptanceDB) []termsbus.Acceptance {
+	bus := make([]termsbus.Acceptance, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusAcceptance(db)
+	}
+
+	return bus
+}
@@ -0,0 +1,216 @@
+// Package termsdb contains terms of service related CRUD functionality.
+package termsdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/termsbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for terms of service database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new version of the terms into the database.
+func (s *Store) Create(ctx context.Context, t termsbus.Terms) error {
+	const q = `
+	INSERT INTO "public"."terms"
+		(terms_id, version, title, body, published_by, published_at)
+	VALUES
+		(:terms_id, :version, :title, :body, :published_by, :published_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBTerms(t)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry{}) {
+			return fmt.Errorf("namedexeccontext: %w", termsbus.ErrUniqueVersion)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves every version of the terms, the latest first.
+func (s *Store) QueryAll(ctx context.Context) ([]termsbus.Terms, error) {
+	const q = `
+	SELECT
+		terms_id, version, title, body, published_by, published_at
+	FROM
+		"public"."terms"
+	ORDER BY
+		published_at DESC`
+
+	var dbTerms []termsDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &dbTerms); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusTermsSlice(dbTerms), nil
+}
+
+// QueryByID gets the specified version of the terms from the database.
+func (s *Store) QueryByID(ctx context.Context, termsID uuid.UUID) (termsbus.Terms, error) {
+	data := struct {
+		ID string `db:"terms_id"`
+	}{
+		ID: termsID.String(),
+	}
+
+	const q = `
+	SELECT
+		terms_id, version, title, body, published_by, published_at
+	FROM
+		"public"."terms"
+	WHERE
+		terms_id = :terms_id`
+
+	var dbTerms termsDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTerms); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return termsbus.Terms{}, fmt.Errorf("db: %w", termsbus.ErrNotFound)
+		}
+		return termsbus.Terms{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusTerms(dbTerms), nil
+}
+
+// QueryLatest gets the version of the terms published last.
+func (s *Store) QueryLatest(ctx context.Context) (termsbus.Terms, error) {
+	const q = `
+	SELECT
+		terms_id, version, title, body, published_by, published_at
+	FROM
+		"public"."terms"
+	ORDER BY
+		published_at DESC
+	LIMIT 1`
+
+	var dbTerms termsDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, struct{}{}, &dbTerms); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return termsbus.Terms{}, fmt.Errorf("db: %w", termsbus.ErrNotFound)
+		}
+		return termsbus.Terms{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusTerms(dbTerms), nil
+}
+
+// CreateAcceptance records the acceptance of a version of the terms, keeping
+// the first record when the user accepted it before.
+func (s *Store) CreateAcceptance(ctx context.Context, a termsbus.Acceptance) error {
+	const q = `
+	INSERT INTO "public"."terms_acceptance"
+		(user_id, terms_id, ip, user_agent, accepted_at)
+	VALUES
+		(:user_id, :terms_id, :ip, :user_agent, :accepted_at)
+	ON CONFLICT (user_id, terms_id) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAcceptance(a)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAcceptance gets the acceptance of a version of the terms by the user.
+func (s *Store) QueryAcceptance(ctx context.Context, userID uuid.UUID, termsID uuid.UUID) (termsbus.Acceptance, error) {
+	data := struct {
+		UserID  string `db:"user_id"`
+		TermsID string `db:"terms_id"`
+	}{
+		UserID:  userID.String(),
+		TermsID: termsID.String(),
+	}
+
+	const q = `
+	SELECT
+		ta.user_id, ta.terms_id, t.version, ta.ip, ta.user_agent, ta.accepted_at
+	FROM
+		"public"."terms_acceptance" ta
+	JOIN
+		"public"."terms" t ON t.terms_id = ta.terms_id
+	WHERE
+		ta.user_id = :user_id AND
+		ta.terms_id = :terms_id`
+
+	var dbAcceptance acceptanceDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAcceptance); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return termsbus.Acceptance{}, fmt.Errorf("db: %w", termsbus.ErrNotFound)
+		}
+		return termsbus.Acceptance{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAcceptance(dbAcceptance), nil
+}
+
+// QueryAcceptances retrieves a list of acceptances from the database, the
+// most recent first.
+func (s *Store) QueryAcceptances(ctx context.Context, filter termsbus.AcceptanceFilter, page page.Page) ([]termsbus.Acceptance, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		ta.user_id, ta.terms_id, t.version, ta.ip, ta.user_agent, ta.accepted_at
+	FROM
+		"public"."terms_acceptance" ta
+	JOIN
+		"public"."terms" t ON t.terms_id = ta.terms_id`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY ta.accepted_at DESC")
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbAcceptances []acceptanceDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbAcceptances); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusAcceptances(dbAcceptances), nil
+}
+
+// CountAcceptances returns the total number of acceptances in the DB.
+func (s *Store) CountAcceptances(ctx context.Context, filter termsbus.AcceptanceFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."terms_acceptance" ta`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,275 @@
+// Package termsbus provides business access to the terms of service and the
+// record of the users accepting them, required by the public-sector clients.
+// Every authenticated request checks the user accepted the latest version,
+// so the core keeps the latest version for a TTL and remembers the users
+// that accepted it; a version published on another instance takes up to the
+// TTL to be required here.
+package termsbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound      = errors.New("terms not found")
+	ErrUniqueVersion = errors.New("terms version already exists")
+	ErrOutdated      = errors.New("only the latest terms can be accepted")
+	ErrNotAccepted   = errors.New("latest terms not accepted")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, t Terms) error
+	QueryAll(ctx context.Context) ([]Terms, error)
+	QueryByID(ctx context.Context, termsID uuid.UUID) (Terms, error)
+	QueryLatest(ctx context.Context) (Terms, error)
+	CreateAcceptance(ctx context.Context, a Acceptance) error
+	QueryAcceptance(ctx context.Context, userID uuid.UUID, termsID uuid.UUID) (Acceptance, error)
+	QueryAcceptances(ctx context.Context, filter AcceptanceFilter, page page.Page) ([]Acceptance, error)
+	CountAcceptances(ctx context.Context, filter AcceptanceFilter) (int, error)
+}
+
+// Core manages the set of APIs for terms of service access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	latest   *Terms
+	loadedAt time.Time
+	accepted map[uuid.UUID]struct{}
+}
+
+// NewCore constructs a core for terms of service api access. The latest
+// version is read again from the store once it is older than ttl.
+func NewCore(log *logger.Logger, storer Storer, ttl time.Duration) *Core {
+	return &Core{
+		log:      log,
+		storer:   storer,
+		ttl:      ttl,
+		accepted: make(map[uuid.UUID]struct{}),
+	}
+}
+
+// Publish adds a new version of the terms, which becomes the latest one and
+// must be accepted by every user.
+func (c *Core) Publish(ctx context.Context, nt NewTerms) (Terms, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.publish")
+	defer span.End()
+
+	t := Terms{
+		ID:          uuid.New(),
+		Version:     nt.Version,
+		Title:       nt.Title,
+		Body:        nt.Body,
+		PublishedBy: nt.PublishedBy,
+		PublishedAt: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, t); err != nil {
+		return Terms{}, fmt.Errorf("create: %w", err)
+	}
+
+	c.reset()
+
+	return t, nil
+}
+
+// QueryAll retrieves every version of the terms, the latest first.
+func (c *Core) QueryAll(ctx context.Context) ([]Terms, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.queryAll")
+	defer span.End()
+
+	terms, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return terms, nil
+}
+
+// QueryByID finds the version of the terms by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, termsID uuid.UUID) (Terms, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.queryByID")
+	defer span.End()
+
+	t, err := c.storer.QueryByID(ctx, termsID)
+	if err != nil {
+		return Terms{}, fmt.Errorf("query: termsID[%s]: %w", termsID, err)
+	}
+
+	return t, nil
+}
+
+// QueryLatest returns the version of the terms the users must accept. It
+// returns ErrNotFound while no version was published.
+func (c *Core) QueryLatest(ctx context.Context) (Terms, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.queryLatest")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.loadLatest(ctx)
+}
+
+// Accept records that the user accepted the version of the terms. Only the
+// latest version can be accepted and accepting it again keeps the first
+// record.
+func (c *Core) Accept(ctx context.Context, t Terms, na NewAcceptance) (Acceptance, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.accept")
+	defer span.End()
+
+	latest, err := c.QueryLatest(ctx)
+	if err != nil {
+		return Acceptance{}, fmt.Errorf("queryLatest: %w", err)
+	}
+
+	if latest.ID != t.ID {
+		return Acceptance{}, fmt.Errorf("%w: latest version is %q", ErrOutdated, latest.Version)
+	}
+
+	a := Acceptance{
+		UserID:     na.UserID,
+		TermsID:    t.ID,
+		Version:    t.Version,
+		IP:         na.IP,
+		UserAgent:  na.UserAgent,
+		AcceptedAt: time.Now(),
+	}
+
+	if err := c.storer.CreateAcceptance(ctx, a); err != nil {
+		return Acceptance{}, fmt.Errorf("createAcceptance: userID[%s]: termsID[%s]: %w", a.UserID, t.ID, err)
+	}
+
+	a, err = c.storer.QueryAcceptance(ctx, na.UserID, t.ID)
+	if err != nil {
+		return Acceptance{}, fmt.Errorf("queryAcceptance: userID[%s]: termsID[%s]: %w", na.UserID, t.ID, err)
+	}
+
+	c.remember(t.ID, na.UserID)
+
+	return a, nil
+}
+
+// QueryAcceptances retrieves a list of acceptances, the most recent first.
+func (c *Core) QueryAcceptances(ctx context.Context, filter AcceptanceFilter, page page.Page) ([]Acceptance, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.queryAcceptances")
+	defer span.End()
+
+	acceptances, err := c.storer.QueryAcceptances(ctx, filter, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return acceptances, nil
+}
+
+// CountAcceptances returns the total number of acceptances matching the
+// filter.
+func (c *Core) CountAcceptances(ctx context.Context, filter AcceptanceFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.countAcceptances")
+	defer span.End()
+
+	return c.storer.CountAcceptances(ctx, filter)
+}
+
+// Check returns ErrNotAccepted, along with the latest version, when the user
+// has not accepted it yet. Nothing is required while no version was
+// published.
+func (c *Core) Check(ctx context.Context, userID uuid.UUID) (Terms, error) {
+	ctx, span := otel.AddSpan(ctx, "business.termsbus.check")
+	defer span.End()
+
+	c.mu.Lock()
+	latest, err := c.loadLatest(ctx)
+	_, accepted := c.accepted[userID]
+	c.mu.Unlock()
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return Terms{}, nil
+	case err != nil:
+		return Terms{}, err
+	case accepted:
+		return latest, nil
+	}
+
+	if _, err := c.storer.QueryAcceptance(ctx, userID, latest.ID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return latest, fmt.Errorf("%w: version %q", ErrNotAccepted, latest.Version)
+		}
+		return Terms{}, fmt.Errorf("queryAcceptance: userID[%s]: %w", userID, err)
+	}
+
+	c.remember(latest.ID, userID)
+
+	return latest, nil
+}
+
+// loadLatest returns the latest version, reading it again when it is older
+// than the TTL. The users that accepted a version are forgotten once a new
+// one is published. The caller must hold the mutex.
+func (c *Core) loadLatest(ctx context.Context) (Terms, error) {
+	if !c.loadedAt.IsZero() && time.Since(c.loadedAt) < c.ttl {
+		if c.latest == nil {
+			return Terms{}, ErrNotFound
+		}
+		return *c.latest, nil
+	}
+
+	t, err := c.storer.QueryLatest(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.latest = nil
+		c.loadedAt = time.Now()
+		return Terms{}, ErrNotFound
+
+	case err != nil:
+		// Uma falha momentânea mantém a versão anterior.
+		if c.latest != nil {
+			c.log.Error(ctx, "termsbus", "status", "refresh", "ERROR", err)
+			return *c.latest, nil
+		}
+		return Terms{}, fmt.Errorf("queryLatest: %w", err)
+	}
+
+	if c.latest == nil || c.latest.ID != t.ID {
+		c.accepted = make(map[uuid.UUID]struct{})
+	}
+
+	c.latest = &t
+	c.loadedAt = time.Now()
+
+	return t, nil
+}
+
+// remember keeps the user that accepted the version while it is the latest.
+func (c *Core) remember(termsID uuid.UUID, userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.latest != nil && c.latest.ID == termsID {
+		c.accepted[userID] = struct{}{}
+	}
+}
+
+// reset drops the latest version after one is published on this instance.
+func (c *Core) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.loadedAt = time.Time{}
+}
@@ -0,0 +1,32 @@
+package usagebus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Usage represents the metered consumption of a tenant during one month.
+type Usage struct {
+	TenantID       uuid.UUID
+	Period         time.Time
+	ActiveUsers    int
+	DashboardViews int
+	APICalls       int
+}
+
+// Delta represents the counters accumulated in memory for a tenant and
+// period that still need to be persisted by the aggregator.
+type Delta struct {
+	TenantID       uuid.UUID
+	Period         time.Time
+	DashboardViews int
+	APICalls       int
+	UserIDs        []uuid.UUID
+}
+
+// QueryFilter holds the available fields a usage query can be filtered on.
+type QueryFilter struct {
+	StartPeriod *time.Time
+	EndPeriod   *time.Time
+}
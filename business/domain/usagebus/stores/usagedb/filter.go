@@ -0,0 +1,19 @@
+package usagedb
+
+import (
+	"bytes"
+
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+)
+
+func applyFilter(filter usagebus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	if filter.StartPeriod != nil {
+		data["start_period"] = usagebus.PeriodOf(*filter.StartPeriod)
+		buf.WriteString(" AND tu.period >= :start_period")
+	}
+
+	if filter.EndPeriod != nil {
+		data["end_period"] = usagebus.PeriodOf(*filter.EndPeriod)
+		buf.WriteString(" AND tu.period <= :end_period")
+	}
+}
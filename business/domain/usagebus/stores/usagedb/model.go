@@ -0,0 +1,59 @@
+package usagedb
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/dbarray"
+)
+
+type usageDB struct {
+	TenantID       uuid.UUID `db:"tenant_id"`
+	Period         time.Time `db:"period"`
+	ActiveUsers    int       `db:"active_users"`
+	DashboardViews int       `db:"dashboard_views"`
+	APICalls       int       `db:"api_calls"`
+}
+
+type deltaDB struct {
+	TenantID       uuid.UUID      `db:"tenant_id"`
+	Period         time.Time      `db:"period"`
+	DashboardViews int            `db:"dashboard_views"`
+	APICalls       int            `db:"api_calls"`
+	UserIDs        dbarray.String `db:"user_ids"`
+}
+
+func toDBDelta(bus usagebus.Delta) deltaDB {
+	userIDs := make(dbarray.String, len(bus.UserIDs))
+	for i, id := range bus.UserIDs {
+		userIDs[i] = id.String()
+	}
+
+	return deltaDB{
+		TenantID:       bus.TenantID,
+		Period:         bus.Period.UTC(),
+		DashboardViews: bus.DashboardViews,
+		APICalls:       bus.APICalls,
+		UserIDs:        userIDs,
+	}
+}
+
+func toBusUsage(db usageDB) usagebus.Usage {
+	return usagebus.Usage{
+		TenantID:       db.TenantID,
+		Period:         db.Period.UTC(),
+		ActiveUsers:    db.ActiveUsers,
+		DashboardViews: db.DashboardViews,
+		APICalls:       db.APICalls,
+	}
+}
+
+func toBusUsages(dbs []usageDB) []usagebus.Usage {
+	bus := make([]usagebus.Usage, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusUsage(db)
+	}
+
+	return bus
+}
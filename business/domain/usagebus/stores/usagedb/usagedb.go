@@ -0,0 +1,108 @@
+// Package usagedb contains usage related CRUD functionality.
+package usagedb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/usagebus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// reportTimeout bounds the usage report, which scans every period of the
+// tenant.
+const reportTimeout = 5 * time.Second
+
+// Store manages the set of APIs for usage database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (usagebus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Apply increments the monthly counters of a tenant and registers the users
+// seen in the period. Both writes happen in a single statement so a partial
+// delta is never persisted.
+func (s *Store) Apply(ctx context.Context, delta usagebus.Delta) error {
+	const q = `
+	WITH counters AS (
+		INSERT INTO "public"."tenant_usage"
+			(tenant_id, period, dashboard_views, api_calls)
+		VALUES
+			(:tenant_id, :period, :dashboard_views, :api_calls)
+		ON CONFLICT (tenant_id, period) DO UPDATE SET
+			dashboard_views = "tenant_usage".dashboard_views + EXCLUDED.dashboard_views,
+			api_calls = "tenant_usage".api_calls + EXCLUDED.api_calls
+	)
+	INSERT INTO "public"."tenant_usage_user"
+		(tenant_id, period, user_id)
+	SELECT
+		:tenant_id, :period, CAST(u.user_id AS uuid)
+	FROM
+		unnest(CAST(:user_ids AS text[])) AS u(user_id)
+	ON CONFLICT DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDelta(delta)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByTenant retrieves the monthly usage of a tenant ordered by period.
+func (s *Store) QueryByTenant(ctx context.Context, tenantID uuid.UUID, filter usagebus.QueryFilter) ([]usagebus.Usage, error) {
+	data := map[string]any{
+		"tenant_id": tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		tu.tenant_id, tu.period, tu.dashboard_views, tu.api_calls,
+		(SELECT count(1) FROM "public"."tenant_usage_user" AS tuu
+			WHERE tuu.tenant_id = tu.tenant_id AND tuu.period = tu.period) AS active_users
+	FROM
+		"public"."tenant_usage" AS tu
+	WHERE
+		tu.tenant_id = :tenant_id`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(" ORDER BY tu.period ASC")
+
+	ctx = sqldb.WithQueryTimeout(ctx, reportTimeout)
+
+	var dbUsage []usageDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbUsage); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusUsages(dbUsage), nil
+}
@@ -0,0 +1,181 @@
+// Package usagebus provides business access to the tenant usage metering
+// domain used for billing and reporting.
+package usagebus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Storer defines the behavior required by the usagebus to interact with the database.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Apply(ctx context.Context, delta Delta) error
+	QueryByTenant(ctx context.Context, tenantID uuid.UUID, filter QueryFilter) ([]Usage, error)
+}
+
+// counter accumulates the usage of a tenant for one period between flushes.
+type counter struct {
+	dashboardViews int
+	apiCalls       int
+	users          map[uuid.UUID]struct{}
+}
+
+type counterKey struct {
+	tenantID uuid.UUID
+	period   time.Time
+}
+
+// Core manages the set of APIs for usage metering.
+type Core struct {
+	log     *logger.Logger
+	storer  Storer
+	mu      sync.Mutex
+	pending map[counterKey]*counter
+}
+
+// NewCore constructs a core for usage api access.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:     log,
+		storer:  storer,
+		pending: make(map[counterKey]*counter),
+	}
+}
+
+// NewWithTx constructs a new Core value replacing the Storer
+// value with a Storer value that is currently inside a transaction.
+// The in-memory counters are not shared with the new value.
+func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
+	storer, err := c.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newWithTx: %w", err)
+	}
+
+	return NewCore(c.log, storer), nil
+}
+
+// RecordAPICall counts an API call made by the user on behalf of the tenant.
+// The user is also counted as active for the current period.
+func (c *Core) RecordAPICall(tenantID uuid.UUID, userID uuid.UUID) {
+	c.record(tenantID, userID, func(cnt *counter) {
+		cnt.apiCalls++
+	})
+}
+
+// RecordDashboardView counts a dashboard view made by the user on behalf of
+// the tenant.
+func (c *Core) RecordDashboardView(tenantID uuid.UUID, userID uuid.UUID) {
+	c.record(tenantID, userID, func(cnt *counter) {
+		cnt.dashboardViews++
+	})
+}
+
+func (c *Core) record(tenantID uuid.UUID, userID uuid.UUID, fn func(cnt *counter)) {
+	if tenantID == uuid.Nil {
+		return
+	}
+
+	key := counterKey{
+		tenantID: tenantID,
+		period:   PeriodOf(time.Now()),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cnt, exists := c.pending[key]
+	if !exists {
+		cnt = &counter{users: make(map[uuid.UUID]struct{})}
+		c.pending[key] = cnt
+	}
+
+	fn(cnt)
+
+	if userID != uuid.Nil {
+		cnt.users[userID] = struct{}{}
+	}
+}
+
+// Flush persists the counters accumulated since the last flush. Counters that
+// fail to be written are merged back so they are retried on the next flush.
+func (c *Core) Flush(ctx context.Context) error {
+	ctx, span := otel.AddSpan(ctx, "business.usagebus.flush")
+	defer span.End()
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[counterKey]*counter)
+	c.mu.Unlock()
+
+	var firstErr error
+
+	for key, cnt := range pending {
+		delta := Delta{
+			TenantID:       key.tenantID,
+			Period:         key.period,
+			DashboardViews: cnt.dashboardViews,
+			APICalls:       cnt.apiCalls,
+			UserIDs:        make([]uuid.UUID, 0, len(cnt.users)),
+		}
+
+		for userID := range cnt.users {
+			delta.UserIDs = append(delta.UserIDs, userID)
+		}
+
+		if err := c.storer.Apply(ctx, delta); err != nil {
+			c.merge(key, cnt)
+
+			if firstErr == nil {
+				firstErr = fmt.Errorf("apply: tenantID[%s] period[%s]: %w", key.tenantID, key.period.Format("2006-01"), err)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// merge adds the counter back into the pending set.
+func (c *Core) merge(key counterKey, cnt *counter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cur, exists := c.pending[key]
+	if !exists {
+		c.pending[key] = cnt
+		return
+	}
+
+	cur.dashboardViews += cnt.dashboardViews
+	cur.apiCalls += cnt.apiCalls
+	for userID := range cnt.users {
+		cur.users[userID] = struct{}{}
+	}
+}
+
+// QueryByTenant retrieves the monthly usage of the specified tenant.
+func (c *Core) QueryByTenant(ctx context.Context, tenantID uuid.UUID, filter QueryFilter) ([]Usage, error) {
+	ctx, span := otel.AddSpan(ctx, "business.usagebus.queryByTenant")
+	defer span.End()
+
+	usage, err := c.storer.QueryByTenant(ctx, tenantID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return usage, nil
+}
+
+// PeriodOf returns the first instant of the month, in UTC, the specified time
+// belongs to. Usage is always metered per calendar month.
+func PeriodOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,63 @@
+package userbus
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+)
+
+// DomainName represents the name of this domain for delegate calls.
+const DomainName = "user"
+
+// Set of delegate actions for the user domain.
+const (
+	// ActionDisabled is called when an enabled user is disabled.
+	ActionDisabled = "disabled"
+
+	// ActionDeleted is called when a user is deleted.
+	ActionDeleted = "deleted"
+)
+
+// ActionParams represents the parameters of the user delegate actions.
+type ActionParams struct {
+	UserID uuid.UUID
+
+	// RemoveDashboardAccess asks the cascade to also drop the links between
+	// the user and the dashboards. Only deletes set it: a disabled user keeps
+	// the links, so enabling the account again restores the dashboards.
+	RemoveDashboardAccess bool
+}
+
+// Marshal returns the params in the delegate wire format.
+func (p *ActionParams) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+// ActionData constructs the delegate data for a user action.
+func ActionData(action string, params ActionParams, tx sqldb.CommitRollbacker) (delegate.Data, error) {
+	raw, err := params.Marshal()
+	if err != nil {
+		return delegate.Data{}, err
+	}
+
+	data := delegate.Data{
+		Domain:    DomainName,
+		Action:    action,
+		RawParams: raw,
+		Tx:        tx,
+	}
+
+	return data, nil
+}
+
+// ParseActionParams parses the params of a user delegate action.
+func ParseActionParams(data []byte) (ActionParams, error) {
+	var params ActionParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return ActionParams{}, err
+	}
+
+	return params, nil
+}
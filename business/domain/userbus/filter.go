@@ -6,12 +6,20 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
 )
 
+// QueryFilter holds the available fields a query can be filtered on.
+// SharesTenantWith restricts the result to the users who are members of a
+// tenant the given user is a member of.
 type QueryFilter struct {
-	ID             *uuid.UUID
-	Name           *name.Name
-	Email          *mail.Address
-	StartCreatedAt *time.Time
-	EndCreatedAt   *time.Time
+	ID               *uuid.UUID
+	Name             *name.Name
+	Email            *mail.Address
+	Role             *role.Role
+	TenantID         *uuid.UUID
+	SharesTenantWith *uuid.UUID
+	Enabled          *bool
+	StartCreatedAt   *time.Time
+	EndCreatedAt     *time.Time
 }
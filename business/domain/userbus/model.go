@@ -18,11 +18,26 @@ type User struct {
 	Role         role.Role
 	PasswordHash []byte
 	Phone        phone.Null
+	PendingEmail *mail.Address // Novo e-mail aguardando confirmação.
+	EmailScope   *uuid.UUID    // Cliente em que o e-mail é único, nil para o sistema todo.
 	Enabled      bool
+	LastLoginAt  time.Time // Zero se o usuário nunca fez login.
+	LastLoginIP  string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
 
+// EmailKey returns the key that identifies the email in its scope, the
+// address alone for the scope of the whole system. The caches index the
+// users by it.
+func EmailKey(scope *uuid.UUID, address string) string {
+	if scope == nil {
+		return address
+	}
+
+	return scope.String() + "/" + address
+}
+
 // NewUser contains information needed to create a new user.
 type NewUser struct {
 	Name     name.Name
@@ -30,6 +45,10 @@ type NewUser struct {
 	Phone    phone.Null
 	Role     role.Role
 	Password password.Password
+
+	// EmailScope is the tenant the email is unique in, when the user is
+	// created for a tenant with scoped emails. See tenantbus.Core.EmailScope.
+	EmailScope *uuid.UUID
 }
 
 // UpdateUser contains information needed to update a user.
@@ -40,4 +59,86 @@ type UpdateUser struct {
 	Phone    *phone.Null
 	Password *password.Password
 	Enabled  *bool
+
+	// Version is the UpdatedAt of the user the change was based on. When
+	// set, the update fails with ErrConflict if the user changed since.
+	Version *time.Time
+}
+
+// Login represents a successful authentication of a user.
+type Login struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+// NewLogin contains the request information recorded on a login.
+type NewLogin struct {
+	IP        string
+	UserAgent string
+}
+
+// Set of themes of the interface.
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+// Preferences represents the interface preferences of a user.
+type Preferences struct {
+	Theme              string
+	Locale             string
+	DefaultDashboardID uuid.UUID // uuid.Nil quando o usuário não escolheu.
+	Notifications      NotificationPreferences
+	UpdatedAt          time.Time
+}
+
+// NotificationPreferences defines the channels the user accepts notifications
+// from.
+type NotificationPreferences struct {
+	Email bool
+	InApp bool
+}
+
+// DefaultPreferences returns the preferences of a user who never saved any.
+// Fields missing in a saved document also take these values.
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Theme:  ThemeSystem,
+		Locale: "pt-BR",
+		Notifications: NotificationPreferences{
+			Email: true,
+			InApp: true,
+		},
+	}
+}
+
+// UpdatePreferences contains information needed to update the preferences of
+// a user.
+type UpdatePreferences struct {
+	Theme              *string
+	Locale             *string
+	DefaultDashboardID *uuid.UUID
+	NotifyEmail        *bool
+	NotifyInApp        *bool
+}
+
+// EmailChange is the pending confirmation of an email change. Only the hash
+// of the token is kept.
+type EmailChange struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// Registration is the pending confirmation of the email of a user who signed
+// up. The user stays disabled until it is confirmed. Only the hash of the
+// token is kept.
+type Registration struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
 }
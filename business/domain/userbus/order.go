@@ -1,6 +1,11 @@
 package userbus
 
-import "github.com/jcpaschoal/spi-exata/business/sdk/order"
+import (
+	"strconv"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+)
 
 var DefaultOrderBy = order.NewBy(OrderByID, order.ASC)
 
@@ -11,3 +16,27 @@ const (
 	OrderByRole    = "d"
 	OrderByEnabled = "e"
 )
+
+// NextCursor returns the cursor of the page after the users, or an empty
+// string when the page was not full and there are no more users.
+func NextCursor(usrs []User, orderBy order.By, pg page.Page) string {
+	if len(usrs) == 0 || len(usrs) < pg.RowsPerPage() {
+		return ""
+	}
+
+	last := usrs[len(usrs)-1]
+	id := last.ID.String()
+
+	switch orderBy.Field {
+	case OrderByName:
+		return page.NewCursor(orderBy, last.Name.String(), id)
+	case OrderByEmail:
+		return page.NewCursor(orderBy, last.Email.Address, id)
+	case OrderByRole:
+		return page.NewCursor(orderBy, last.Role.String(), id)
+	case OrderByEnabled:
+		return page.NewCursor(orderBy, strconv.FormatBool(last.Enabled), id)
+	default:
+		return page.NewCursor(orderBy, id)
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
 	"github.com/jcpaschoal/spi-exata/business/sdk/order"
 	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
@@ -18,6 +20,7 @@ type Store struct {
 	log    *logger.Logger
 	storer userbus.Storer
 	cache  *sturdyc.Client[userbus.User]
+	stats  *cachestats.Recorder
 }
 
 func NewStore(log *logger.Logger, storer userbus.Storer, ttl time.Duration) *Store {
@@ -25,10 +28,13 @@ func NewStore(log *logger.Logger, storer userbus.Storer, ttl time.Duration) *Sto
 	const numShards = 10
 	const evictionPercentage = 10
 
+	stats := cachestats.NewRecorder()
+
 	return &Store{
 		log:    log,
 		storer: storer,
-		cache:  sturdyc.New[userbus.User](capacity, numShards, ttl, evictionPercentage),
+		cache:  sturdyc.New[userbus.User](capacity, numShards, ttl, evictionPercentage, sturdyc.WithMetrics(stats)),
+		stats:  stats,
 	}
 }
 
@@ -42,6 +48,7 @@ func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (userbus.Storer, error) {
 		log:    s.log,
 		storer: txStorer,
 		cache:  s.cache,
+		stats:  s.stats,
 	}
 
 	return &store, nil
@@ -59,8 +66,8 @@ func (s *Store) Create(ctx context.Context, usr userbus.User) error {
 }
 
 // Update replaces a user document in the database.
-func (s *Store) Update(ctx context.Context, usr userbus.User) error {
-	if err := s.storer.Update(ctx, usr); err != nil {
+func (s *Store) Update(ctx context.Context, usr userbus.User, version *time.Time) error {
+	if err := s.storer.Update(ctx, usr, version); err != nil {
 		return err
 	}
 
@@ -80,11 +87,22 @@ func (s *Store) Delete(ctx context.Context, usr userbus.User) error {
 	return nil
 }
 
+// Restore brings back a deleted user. Deleted users are not cached, the user
+// is cached on the next read.
+func (s *Store) Restore(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.Restore(ctx, userID)
+}
+
 // Query retrieves a list of existing users from the database.
 func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, error) {
 	return s.storer.Query(ctx, filter, orderBy, page)
 }
 
+// QueryWithCount retrieves a page of users and the total from the database.
+func (s *Store) QueryWithCount(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, int, error) {
+	return s.storer.QueryWithCount(ctx, filter, orderBy, page)
+}
+
 // Count returns the total number of cards in the DB.
 func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, error) {
 	return s.storer.Count(ctx, filter)
@@ -107,14 +125,14 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User,
 	return usr, nil
 }
 
-// QueryByEmail gets the specified user from the database by email.
-func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.User, error) {
-	cachedUsr, ok := s.readCache(email.Address)
+// QueryByEmail gets the user with the email in the scope from the database.
+func (s *Store) QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (userbus.User, error) {
+	cachedUsr, ok := s.readCache(userbus.EmailKey(scope, email.Address))
 	if ok {
 		return cachedUsr, nil
 	}
 
-	usr, err := s.storer.QueryByEmail(ctx, email)
+	usr, err := s.storer.QueryByEmail(ctx, scope, email)
 	if err != nil {
 		return userbus.User{}, err
 	}
@@ -124,6 +142,110 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.U
 	return usr, nil
 }
 
+// DeleteExpiredResetTokens removes the expired password reset tokens. The
+// tokens are not cached.
+func (s *Store) DeleteExpiredResetTokens(ctx context.Context, now time.Time) (int, error) {
+	return s.storer.DeleteExpiredResetTokens(ctx, now)
+}
+
+// CreateLogin records the login and refreshes the cached user with the last
+// login. The history is not cached.
+func (s *Store) CreateLogin(ctx context.Context, usr userbus.User, login userbus.Login) error {
+	if err := s.storer.CreateLogin(ctx, usr, login); err != nil {
+		return err
+	}
+
+	s.writeCache(usr)
+
+	return nil
+}
+
+// QueryLogins retrieves the login history of the user from the database.
+func (s *Store) QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]userbus.Login, error) {
+	return s.storer.QueryLogins(ctx, userID, page)
+}
+
+// CountLogins returns the total number of logins of the user.
+func (s *Store) CountLogins(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.storer.CountLogins(ctx, userID)
+}
+
+// QueryPreferences gets the preferences of the user from the database. The
+// preferences are not cached.
+func (s *Store) QueryPreferences(ctx context.Context, userID uuid.UUID) (userbus.Preferences, error) {
+	return s.storer.QueryPreferences(ctx, userID)
+}
+
+// SavePreferences creates or replaces the preferences of the user.
+func (s *Store) SavePreferences(ctx context.Context, userID uuid.UUID, prefs userbus.Preferences) error {
+	return s.storer.SavePreferences(ctx, userID, prefs)
+}
+
+// SaveEmailChange creates or replaces the pending email change of the user.
+// The tokens are not cached.
+func (s *Store) SaveEmailChange(ctx context.Context, ec userbus.EmailChange) error {
+	return s.storer.SaveEmailChange(ctx, ec)
+}
+
+// QueryEmailChange gets the pending email change by the hash of its token.
+func (s *Store) QueryEmailChange(ctx context.Context, tokenHash string) (userbus.EmailChange, error) {
+	return s.storer.QueryEmailChange(ctx, tokenHash)
+}
+
+// DeleteEmailChange removes the pending email change of the user.
+func (s *Store) DeleteEmailChange(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.DeleteEmailChange(ctx, userID)
+}
+
+// SaveRegistration creates or replaces the pending registration of the user.
+// The tokens are not cached.
+func (s *Store) SaveRegistration(ctx context.Context, reg userbus.Registration) error {
+	return s.storer.SaveRegistration(ctx, reg)
+}
+
+// QueryRegistration gets the pending registration by the hash of its token.
+func (s *Store) QueryRegistration(ctx context.Context, tokenHash string) (userbus.Registration, error) {
+	return s.storer.QueryRegistration(ctx, tokenHash)
+}
+
+// DeleteRegistration removes the pending registration of the user.
+func (s *Store) DeleteRegistration(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.DeleteRegistration(ctx, userID)
+}
+
+// DeleteExpiredRegistrations removes the users who did not confirm the
+// registration in time. They are dropped from the cache by the notification
+// of the database.
+func (s *Store) DeleteExpiredRegistrations(ctx context.Context, now time.Time) (int, error) {
+	return s.storer.DeleteExpiredRegistrations(ctx, now)
+}
+
+// Invalidate drops the cached user announced in the payload. It is meant to be
+// fed by sqldb.Listen on userdb.Channel so a role change or a disable made by any
+// instance is seen by the auth check of every other one right away.
+func (s *Store) Invalidate(payload string) {
+	n, err := userdb.ParseNotification(payload)
+	if err != nil {
+		s.log.Error(context.Background(), "usercache", "status", "invalid notification", "payload", payload, "ERROR", err)
+		return
+	}
+
+	// O e-mail atual pode ser outro se a alteração trocou o endereço.
+	if usr, ok := s.readCache(n.UserID.String()); ok {
+		s.deleteCache(usr)
+	}
+
+	s.cache.Delete(n.UserID.String())
+	s.cache.Delete(userbus.EmailKey(n.EmailScope, n.Email))
+	s.stats.Invalidated(1)
+}
+
+// Stats returns the runtime statistics of the cache. Every user is cached
+// twice, by id and by email, so there are two entries per user.
+func (s *Store) Stats() cachestats.Stats {
+	return s.stats.Stats(s.cache.Size())
+}
+
 // readCache performs a safe search in the cache for the specified key.
 func (s *Store) readCache(key string) (userbus.User, bool) {
 	usr, exists := s.cache.Get(key)
@@ -137,11 +259,11 @@ func (s *Store) readCache(key string) (userbus.User, bool) {
 // writeCache performs a safe write to the cache for the specified userbus.
 func (s *Store) writeCache(bus userbus.User) {
 	s.cache.Set(bus.ID.String(), bus)
-	s.cache.Set(bus.Email.Address, bus)
+	s.cache.Set(userbus.EmailKey(bus.EmailScope, bus.Email.Address), bus)
 }
 
 // deleteCache performs a safe removal from the cache for the specified userbus.
 func (s *Store) deleteCache(bus userbus.User) {
 	s.cache.Delete(bus.ID.String())
-	s.cache.Delete(bus.Email.Address)
+	s.cache.Delete(userbus.EmailKey(bus.EmailScope, bus.Email.Address))
 }
@@ -9,21 +9,42 @@ import (
 )
 
 func applyFilter(filter userbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
-	var wc []string
+	// Usuários removidos nunca aparecem nas consultas.
+	wc := []string{"u.deleted_at IS NULL"}
 
 	if filter.ID != nil {
 		data["user_id"] = filter.ID
-		wc = append(wc, "user_id = :user_id")
+		wc = append(wc, "u.user_id = :user_id")
 	}
 
 	if filter.Name != nil {
 		data["name"] = fmt.Sprintf("%%%s%%", filter.Name)
-		wc = append(wc, "name LIKE :name")
+		wc = append(wc, "u.name LIKE :name")
 	}
 
 	if filter.Email != nil {
 		data["email"] = filter.Email.String()
-		wc = append(wc, "email = :email")
+		wc = append(wc, "u.email = :email")
+	}
+
+	if filter.Role != nil {
+		data["role"] = filter.Role.String()
+		wc = append(wc, "r.name = :role")
+	}
+
+	if filter.TenantID != nil {
+		data["tenant_id"] = filter.TenantID
+		wc = append(wc, `EXISTS (SELECT 1 FROM "public"."tenant_membership" AS tm WHERE tm.user_id = u.user_id AND tm.tenant_id = :tenant_id)`)
+	}
+
+	if filter.SharesTenantWith != nil {
+		data["shares_tenant_with"] = filter.SharesTenantWith
+		wc = append(wc, `EXISTS (SELECT 1 FROM "public"."tenant_membership" AS tm JOIN "public"."tenant_membership" AS cm ON cm.tenant_id = tm.tenant_id WHERE tm.user_id = u.user_id AND cm.user_id = :shares_tenant_with)`)
+	}
+
+	if filter.Enabled != nil {
+		data["enabled"] = *filter.Enabled
+		wc = append(wc, "u.enabled = :enabled")
 	}
 
 	if filter.StartCreatedAt != nil {
@@ -36,8 +57,6 @@ func applyFilter(filter userbus.QueryFilter, data map[string]any, buf *bytes.Buf
 		wc = append(wc, "date_created <= :end_date_created")
 	}
 
-	if len(wc) > 0 {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(strings.Join(wc, " AND "))
-	}
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
 }
@@ -2,6 +2,7 @@ package userdb
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/mail"
 	"time"
@@ -20,13 +21,17 @@ type userDB struct {
 	Role         string         `db:"role"`
 	PasswordHash []byte         `db:"password_hash"`
 	Phone        sql.NullString `db:"phone"`
+	PendingEmail sql.NullString `db:"pending_email"`
+	EmailScope   uuid.NullUUID  `db:"email_scope"`
 	Enabled      bool           `db:"enabled"`
+	LastLoginAt  sql.NullTime   `db:"last_login_at"`
+	LastLoginIP  sql.NullString `db:"last_login_ip"`
 	CreatedAt    time.Time      `db:"created_at"`
 	UpdatedAt    time.Time      `db:"updated_at"`
 }
 
 func toDBUser(bus userbus.User) userDB {
-	return userDB{
+	db := userDB{
 		ID:           bus.ID,
 		Name:         bus.Name.String(),
 		Email:        bus.Email.Address,
@@ -34,9 +39,21 @@ func toDBUser(bus userbus.User) userDB {
 		PasswordHash: bus.PasswordHash,
 		Phone:        phone.ToSQLNullString(bus.Phone),
 		Enabled:      bus.Enabled,
+		LastLoginAt:  sql.NullTime{Time: bus.LastLoginAt.UTC(), Valid: !bus.LastLoginAt.IsZero()},
+		LastLoginIP:  sql.NullString{String: bus.LastLoginIP, Valid: bus.LastLoginIP != ""},
 		CreatedAt:    bus.CreatedAt.UTC(),
 		UpdatedAt:    bus.UpdatedAt.UTC(),
 	}
+
+	if bus.PendingEmail != nil {
+		db.PendingEmail = sql.NullString{String: bus.PendingEmail.Address, Valid: true}
+	}
+
+	if bus.EmailScope != nil {
+		db.EmailScope = uuid.NullUUID{UUID: *bus.EmailScope, Valid: true}
+	}
+
+	return db
 }
 
 func toBusUser(db userDB) (userbus.User, error) {
@@ -67,10 +84,24 @@ func toBusUser(db userDB) (userbus.User, error) {
 		PasswordHash: db.PasswordHash,
 		Enabled:      db.Enabled,
 		Phone:        phone,
+		LastLoginIP:  db.LastLoginIP.String,
 		CreatedAt:    db.CreatedAt.In(time.Local),
 		UpdatedAt:    db.UpdatedAt.In(time.Local),
 	}
 
+	if db.LastLoginAt.Valid {
+		bus.LastLoginAt = db.LastLoginAt.Time.In(time.Local)
+	}
+
+	if db.PendingEmail.Valid {
+		bus.PendingEmail = &mail.Address{Address: db.PendingEmail.String}
+	}
+
+	if db.EmailScope.Valid {
+		scope := db.EmailScope.UUID
+		bus.EmailScope = &scope
+	}
+
 	return bus, nil
 }
 
@@ -87,3 +118,158 @@ func toBusUsers(dbs []userDB) ([]userbus.User, error) {
 
 	return bus, nil
 }
+
+// =============================================================================
+
+type loginDB struct {
+	ID        uuid.UUID `db:"login_id"`
+	UserID    uuid.UUID `db:"user_id"`
+	IP        string    `db:"ip"`
+	UserAgent string    `db:"user_agent"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func toDBLogin(bus userbus.Login) loginDB {
+	return loginDB{
+		ID:        bus.ID,
+		UserID:    bus.UserID,
+		IP:        bus.IP,
+		UserAgent: bus.UserAgent,
+		CreatedAt: bus.CreatedAt.UTC(),
+	}
+}
+
+func toBusLogins(dbs []loginDB) []userbus.Login {
+	bus := make([]userbus.Login, len(dbs))
+
+	for i, db := range dbs {
+		bus[i] = userbus.Login{
+			ID:        db.ID,
+			UserID:    db.UserID,
+			IP:        db.IP,
+			UserAgent: db.UserAgent,
+			CreatedAt: db.CreatedAt.In(time.Local),
+		}
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+type preferencesDB struct {
+	UserID      uuid.UUID `db:"user_id"`
+	Preferences string    `db:"preferences"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// preferencesDoc is the JSON document kept in the preferences column.
+type preferencesDoc struct {
+	Theme              string     `json:"theme"`
+	Locale             string     `json:"locale"`
+	DefaultDashboardID *uuid.UUID `json:"defaultDashboardId,omitempty"`
+	Notifications      struct {
+		Email bool `json:"email"`
+		InApp bool `json:"inApp"`
+	} `json:"notifications"`
+}
+
+func toDBPreferences(userID uuid.UUID, bus userbus.Preferences) (preferencesDB, error) {
+	var doc preferencesDoc
+	doc.Theme = bus.Theme
+	doc.Locale = bus.Locale
+	doc.Notifications.Email = bus.Notifications.Email
+	doc.Notifications.InApp = bus.Notifications.InApp
+
+	if bus.DefaultDashboardID != uuid.Nil {
+		doc.DefaultDashboardID = &bus.DefaultDashboardID
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return preferencesDB{}, fmt.Errorf("marshal: %w", err)
+	}
+
+	db := preferencesDB{
+		UserID:      userID,
+		Preferences: string(data),
+		UpdatedAt:   bus.UpdatedAt.UTC(),
+	}
+
+	return db, nil
+}
+
+func toBusPreferences(db preferencesDB) (userbus.Preferences, error) {
+	bus := userbus.DefaultPreferences()
+
+	// Campos ausentes no documento mantêm os valores padrão.
+	var doc preferencesDoc
+	doc.Theme = bus.Theme
+	doc.Locale = bus.Locale
+	doc.Notifications.Email = bus.Notifications.Email
+	doc.Notifications.InApp = bus.Notifications.InApp
+
+	if err := json.Unmarshal([]byte(db.Preferences), &doc); err != nil {
+		return userbus.Preferences{}, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	bus.Theme = doc.Theme
+	bus.Locale = doc.Locale
+	bus.Notifications.Email = doc.Notifications.Email
+	bus.Notifications.InApp = doc.Notifications.InApp
+	bus.UpdatedAt = db.UpdatedAt.In(time.Local)
+
+	if doc.DefaultDashboardID != nil {
+		bus.DefaultDashboardID = *doc.DefaultDashboardID
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+type emailChangeDB struct {
+	UserID    uuid.UUID `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBEmailChange(bus userbus.EmailChange) emailChangeDB {
+	return emailChangeDB{
+		UserID:    bus.UserID,
+		TokenHash: bus.TokenHash,
+		ExpiresAt: bus.ExpiresAt.UTC(),
+	}
+}
+
+func toBusEmailChange(db emailChangeDB) userbus.EmailChange {
+	return userbus.EmailChange{
+		UserID:    db.UserID,
+		TokenHash: db.TokenHash,
+		ExpiresAt: db.ExpiresAt.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+type registrationDB struct {
+	UserID    uuid.UUID `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBRegistration(bus userbus.Registration) registrationDB {
+	return registrationDB{
+		UserID:    bus.UserID,
+		TokenHash: bus.TokenHash,
+		ExpiresAt: bus.ExpiresAt.UTC(),
+	}
+}
+
+func toBusRegistration(db registrationDB) userbus.Registration {
+	return userbus.Registration{
+		UserID:    db.UserID,
+		TokenHash: db.TokenHash,
+		ExpiresAt: db.ExpiresAt.In(time.Local),
+	}
+}
@@ -0,0 +1,31 @@
+package userdb
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// Channel is the Postgres NOTIFY channel where the database announces updates
+// and deletes of users. The payload is a Notification in JSON.
+const Channel = "user_changes"
+
+// Notification is the payload announced on Channel. Email is the address the
+// user had before the change, the key a stale entry is cached under.
+type Notification struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+
+	// EmailScope completes the email key of the cache. See userbus.EmailKey.
+	EmailScope *uuid.UUID `json:"email_scope"`
+}
+
+// ParseNotification decodes the payload announced on Channel.
+func ParseNotification(payload string) (Notification, error) {
+	var n Notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return Notification{}, err
+	}
+
+	return n, nil
+}
@@ -1,10 +1,12 @@
 package userdb
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
 	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 )
 
 var orderByFields = map[string]string{
@@ -15,11 +17,73 @@ var orderByFields = map[string]string{
 	userbus.OrderByEnabled: "enabled",
 }
 
+// keysetColumns maps the order fields to the columns compared with the
+// cursor. The WHERE clause can't use the aliases of the ORDER BY.
+var keysetColumns = map[string]string{
+	userbus.OrderByID:      "u.user_id",
+	userbus.OrderByName:    "u.name",
+	userbus.OrderByEmail:   "u.email",
+	userbus.OrderByRole:    "r.name",
+	userbus.OrderByEnabled: "u.enabled",
+}
+
+// countedKeysetColumns are the keysetColumns of the query with the total,
+// where the cursor is compared with the columns of the subquery.
+var countedKeysetColumns = map[string]string{
+	userbus.OrderByID:      "u.user_id",
+	userbus.OrderByName:    "u.name",
+	userbus.OrderByEmail:   "u.email",
+	userbus.OrderByRole:    "u.role",
+	userbus.OrderByEnabled: "u.enabled",
+}
+
+// orderByClause orders by the field and, as the tiebreaker that keyset paging
+// depends on, by the user id.
 func orderByClause(orderBy order.By) (string, error) {
 	by, exists := orderByFields[orderBy.Field]
 	if !exists {
 		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
 	}
 
-	return " ORDER BY " + by + " " + orderBy.Direction, nil
+	if orderBy.Field == userbus.OrderByID {
+		return " ORDER BY " + by + " " + orderBy.Direction, nil
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction + ", user_id " + orderBy.Direction, nil
+}
+
+// applyCursor restricts the query to the rows after the cursor of the page,
+// comparing the columns given by cols. It must follow a WHERE, which
+// applyFilter always writes.
+func applyCursor(cols map[string]string, orderBy order.By, pg page.Page, data map[string]any, buf *bytes.Buffer) error {
+	cursor, ok := pg.Cursor()
+	if !ok {
+		return nil
+	}
+
+	col, exists := cols[orderBy.Field]
+	if !exists {
+		return fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	op := ">"
+	if orderBy.Direction == order.DESC {
+		op = "<"
+	}
+
+	switch {
+	case orderBy.Field == userbus.OrderByID && len(cursor.Values) == 1:
+		data["cursor_id"] = cursor.Values[0]
+		fmt.Fprintf(buf, " AND u.user_id %s :cursor_id", op)
+
+	case orderBy.Field != userbus.OrderByID && len(cursor.Values) == 2:
+		data["cursor_value"] = cursor.Values[0]
+		data["cursor_id"] = cursor.Values[1]
+		fmt.Fprintf(buf, " AND (%s, u.user_id) %s (:cursor_value, :cursor_id)", col, op)
+
+	default:
+		return fmt.Errorf("cursor does not match the order")
+	}
+
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
@@ -23,7 +24,7 @@ type Store struct {
 }
 
 // NewStore constructs the api for data access.
-func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
 	return &Store{
 		log: log,
 		db:  db,
@@ -52,17 +53,17 @@ func (s *Store) Create(ctx context.Context, usr userbus.User) error {
 	// Pegamos o role_id da tabela 'role' baseado no nome (:role) passado no struct
 	const q = `
 	INSERT INTO "public"."users"
-		(user_id, role_id, name, email, password, phone, enabled, created_at, updated_at)
+		(user_id, role_id, name, email, email_scope, password, phone, enabled, created_at, updated_at)
 	VALUES
-		(:user_id, (SELECT role_id FROM "public"."role" WHERE name = :role), :name, :email, :password_hash, :phone, :enabled, :created_at, :updated_at)`
+		(:user_id, (SELECT role_id FROM "public"."role" WHERE name = :role), :name, :email, :email_scope, :password_hash, :phone, :enabled, :created_at, :updated_at)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
 		var dupErr sqldb.ErrDBDuplicatedEntry
 		if errors.As(err, &dupErr) {
-			switch dupErr.Column {
-			case "email", "uq_user_email":
+			switch {
+			case dupErr.Column == "email" || dupErr.Constraint == "uq_users_email":
 				return fmt.Errorf("namedexeccontext: %w", userbus.ErrUniqueEmail)
-			case "phone", "uq_user_phone":
+			case dupErr.Column == "phone" || dupErr.Constraint == "uq_users_phone":
 				return fmt.Errorf("namedexeccontext: %w", userbus.ErrUniquePhone)
 			}
 		}
@@ -72,8 +73,9 @@ func (s *Store) Create(ctx context.Context, usr userbus.User) error {
 	return nil
 }
 
-// Update replaces a user document in the database.
-func (s *Store) Update(ctx context.Context, usr userbus.User) error {
+// Update replaces a user document in the database. With a version the row
+// is only replaced while its updated_at is still the version.
+func (s *Store) Update(ctx context.Context, usr userbus.User, version *time.Time) error {
 	// Truque SQL: Update do role_id usando subquery
 	const q = `
 	UPDATE
@@ -81,45 +83,125 @@ func (s *Store) Update(ctx context.Context, usr userbus.User) error {
 	SET 
 		name = :name,
 		email = :email,
+		pending_email = :pending_email,
 		phone = :phone,
 		role_id = (SELECT role_id FROM "public"."role" WHERE name = :role),
 		password = :password_hash,
 		enabled = :enabled,
 		updated_at = :updated_at
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND deleted_at IS NULL`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
-		var dupErr sqldb.ErrDBDuplicatedEntry
-		if errors.As(err, &dupErr) {
-			switch dupErr.Column {
-			case "email", "uq_user_email":
-				return userbus.ErrUniqueEmail
-			case "phone", "uq_user_phone":
-				return userbus.ErrUniquePhone
-			}
+	if version == nil {
+		if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+			return updateError(err)
 		}
-		return fmt.Errorf("namedexeccontext: %w", err)
+		return nil
+	}
+
+	// A versão confere o updated_at que o cliente leu; nenhuma linha
+	// retornada é outra requisição que gravou antes, ou apagou o usuário.
+	const qv = q + ` AND updated_at = :version
+	RETURNING user_id`
+
+	data := struct {
+		userDB
+		Version time.Time `db:"version"`
+	}{
+		userDB:  toDBUser(usr),
+		Version: *version,
+	}
+
+	var dest struct {
+		ID uuid.UUID `db:"user_id"`
+	}
+
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, qv, data, &dest); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", userbus.ErrConflict)
+		}
+		return updateError(err)
 	}
 
 	return nil
 }
 
-// Delete removes a user from the database.
+// updateError translates the unique violations of an update.
+func updateError(err error) error {
+	var dupErr sqldb.ErrDBDuplicatedEntry
+	if errors.As(err, &dupErr) {
+		switch {
+		case dupErr.Column == "email" || dupErr.Constraint == "uq_users_email":
+			return userbus.ErrUniqueEmail
+		case dupErr.Column == "phone" || dupErr.Constraint == "uq_users_phone":
+			return userbus.ErrUniquePhone
+		}
+	}
+	return fmt.Errorf("namedexeccontext: %w", err)
+}
+
+// Delete marks a user as deleted. The row is kept so the ACL and audit
+// records still point to it.
 func (s *Store) Delete(ctx context.Context, usr userbus.User) error {
+	data := struct {
+		ID  string    `db:"user_id"`
+		Now time.Time `db:"now"`
+	}{
+		ID:  usr.ID.String(),
+		Now: time.Now().UTC(),
+	}
+
 	const q = `
-	DELETE FROM
+	UPDATE
 		"public"."users"
+	SET
+		deleted_at = :now,
+		updated_at = :now
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND deleted_at IS NULL`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
 	return nil
 }
 
+// Restore clears the deletion mark of a user. It returns ErrNotFound when the
+// user does not exist or is not deleted.
+func (s *Store) Restore(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		ID  string    `db:"user_id"`
+		Now time.Time `db:"now"`
+	}{
+		ID:  userID.String(),
+		Now: time.Now().UTC(),
+	}
+
+	const q = `
+	UPDATE
+		"public"."users"
+	SET
+		deleted_at = NULL,
+		updated_at = :now
+	WHERE
+		user_id = :user_id AND deleted_at IS NOT NULL
+	RETURNING
+		user_id`
+
+	var restored struct {
+		ID uuid.UUID `db:"user_id"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &restored); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return fmt.Errorf("db: %w", userbus.ErrNotFound)
+		}
+		return fmt.Errorf("db: %w", err)
+	}
+
+	return nil
+}
+
 // Query retrieves a list of existing users from the database.
 func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, error) {
 	data := map[string]any{
@@ -132,7 +214,8 @@ func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy o
 	// Alias 'password_hash' é necessário pois no banco é 'password' mas no struct é 'password_hash'
 	const q = `
 	SELECT
-		u.user_id, u.name, u.email, u.password AS password_hash, u.phone, u.enabled, u.created_at, u.updated_at,
+		u.user_id, u.name, u.email, u.pending_email, u.email_scope, u.password AS password_hash, u.phone, u.enabled, u.last_login_at, u.last_login_ip,
+		u.created_at, u.updated_at,
 		r.name AS role
 	FROM
 		"public"."users" AS u
@@ -142,6 +225,10 @@ func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy o
 	buf := bytes.NewBufferString(q)
 	applyFilter(filter, data, buf)
 
+	if err := applyCursor(keysetColumns, orderBy, page, data, buf); err != nil {
+		return nil, err
+	}
+
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
 		return nil, err
@@ -158,6 +245,78 @@ func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy o
 	return toBusUsers(dbUsrs)
 }
 
+// QueryWithCount retrieves a page of users along with the total number of
+// users that match the filter in a single round trip. The total comes from a
+// window function over the filtered users, before the cursor and the paging.
+func (s *Store) QueryWithCount(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, int, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		u.user_id, u.name, u.email, u.pending_email, u.email_scope, u.password AS password_hash, u.phone, u.enabled, u.last_login_at, u.last_login_ip,
+		u.created_at, u.updated_at,
+		r.name AS role,
+		count(1) OVER() AS total
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS r ON r.role_id = u.role_id`
+
+	buf := bytes.NewBufferString("SELECT * FROM (")
+	buf.WriteString(q)
+	applyFilter(filter, data, buf)
+	buf.WriteString(") AS u WHERE true")
+
+	if err := applyCursor(countedKeysetColumns, orderBy, page, data, buf); err != nil {
+		return nil, 0, err
+	}
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbUsrs []struct {
+		userDB
+		Total int `db:"total"`
+	}
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbUsrs); err != nil {
+		return nil, 0, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	// Uma página vazia além da primeira não traz o total na linha.
+	if len(dbUsrs) == 0 {
+		if _, ok := page.Cursor(); !ok && page.Number() == 1 {
+			return []userbus.User{}, 0, nil
+		}
+
+		total, err := s.Count(ctx, filter)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return []userbus.User{}, total, nil
+	}
+
+	users := make([]userDB, len(dbUsrs))
+	for i, du := range dbUsrs {
+		users[i] = du.userDB
+	}
+
+	usrs, err := toBusUsers(users)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return usrs, dbUsrs[0].Total, nil
+}
+
 // Count returns the total number of users in the DB.
 func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, error) {
 	data := map[string]any{}
@@ -166,7 +325,9 @@ func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, err
 	SELECT
 		count(1)
 	FROM
-		"public"."users" AS u` // Alias 'u' caso o filtro use prefixo u.
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS r ON r.role_id = u.role_id` // O filtro por role usa r.name.
 
 	buf := bytes.NewBufferString(q)
 	applyFilter(filter, data, buf)
@@ -191,14 +352,15 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User,
 
 	const q = `
 	SELECT
-		u.user_id, u.name, u.email, u.password AS password_hash, u.phone, u.enabled, u.created_at, u.updated_at,
+		u.user_id, u.name, u.email, u.pending_email, u.email_scope, u.password AS password_hash, u.phone, u.enabled, u.last_login_at, u.last_login_ip,
+		u.created_at, u.updated_at,
 		r.name AS role
 	FROM
 		"public"."users" AS u
 	JOIN
 		"public"."role" AS r ON r.role_id = u.role_id
-	WHERE 
-		u.user_id = :user_id`
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL`
 
 	var dbUsr userDB
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
@@ -211,24 +373,31 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User,
 	return toBusUser(dbUsr)
 }
 
-// QueryByEmail gets the specified user from the database by email.
-func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.User, error) {
+// QueryByEmail gets the user with the email in the scope from the database.
+// A nil scope is the whole system.
+func (s *Store) QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (userbus.User, error) {
 	data := struct {
-		Email string `db:"email"`
+		Email string        `db:"email"`
+		Scope uuid.NullUUID `db:"email_scope"`
 	}{
 		Email: email.Address,
 	}
 
+	if scope != nil {
+		data.Scope = uuid.NullUUID{UUID: *scope, Valid: true}
+	}
+
 	const q = `
 	SELECT
-		u.user_id, u.name, u.email, u.password AS password_hash, u.phone, u.enabled, u.created_at, u.updated_at,
+		u.user_id, u.name, u.email, u.pending_email, u.email_scope, u.password AS password_hash, u.phone, u.enabled, u.last_login_at, u.last_login_ip,
+		u.created_at, u.updated_at,
 		r.name AS role
 	FROM
 		"public"."users" AS u
 	JOIN
 		"public"."role" AS r ON r.role_id = u.role_id
 	WHERE
-		u.email = :email`
+		u.email = :email AND u.email_scope IS NOT DISTINCT FROM :email_scope AND u.deleted_at IS NULL`
 
 	var dbUsr userDB
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
@@ -240,3 +409,326 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.U
 
 	return toBusUser(dbUsr)
 }
+
+// DeleteExpiredResetTokens removes the password reset tokens that expired
+// before now and returns how many were removed.
+func (s *Store) DeleteExpiredResetTokens(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."password_reset_token"
+		WHERE
+			expires_at <= :now
+		RETURNING user_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// CreateLogin updates the last login of the user and adds the login to the
+// history in a single statement.
+func (s *Store) CreateLogin(ctx context.Context, usr userbus.User, login userbus.Login) error {
+	const q = `
+	WITH last_login AS (
+		UPDATE
+			"public"."users"
+		SET
+			last_login_at = :created_at,
+			last_login_ip = :ip
+		WHERE
+			user_id = :user_id
+	)
+	INSERT INTO "public"."user_login"
+		(login_id, user_id, ip, user_agent, created_at)
+	VALUES
+		(:login_id, :user_id, :ip, :user_agent, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBLogin(login)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryLogins retrieves the login history of the user, most recent first.
+func (s *Store) QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]userbus.Login, error) {
+	data := map[string]any{
+		"user_id":       userID.String(),
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		login_id, user_id, ip, user_agent, created_at
+	FROM
+		"public"."user_login"
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		created_at DESC
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbLogins []loginDB
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbLogins); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusLogins(dbLogins), nil
+}
+
+// CountLogins returns the total number of logins of the user.
+func (s *Store) CountLogins(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		"public"."user_login"
+	WHERE
+		user_id = :user_id`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryPreferences gets the preferences of the user from the database.
+func (s *Store) QueryPreferences(ctx context.Context, userID uuid.UUID) (userbus.Preferences, error) {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		user_id, CAST(preferences AS text) AS preferences, updated_at
+	FROM
+		"public"."user_preferences"
+	WHERE
+		user_id = :user_id`
+
+	var dbPrefs preferencesDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbPrefs); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return userbus.Preferences{}, fmt.Errorf("db: %w", userbus.ErrNotFound)
+		}
+		return userbus.Preferences{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusPreferences(dbPrefs)
+}
+
+// SavePreferences creates or replaces the preferences of the user.
+func (s *Store) SavePreferences(ctx context.Context, userID uuid.UUID, prefs userbus.Preferences) error {
+	dbPrefs, err := toDBPreferences(userID, prefs)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	INSERT INTO "public"."user_preferences"
+		(user_id, preferences, updated_at)
+	VALUES
+		(:user_id, CAST(:preferences AS jsonb), :updated_at)
+	ON CONFLICT (user_id) DO UPDATE SET
+		preferences = EXCLUDED.preferences,
+		updated_at = EXCLUDED.updated_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbPrefs); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// SaveEmailChange creates or replaces the pending email change of the user.
+func (s *Store) SaveEmailChange(ctx context.Context, ec userbus.EmailChange) error {
+	const q = `
+	INSERT INTO "public"."email_change_token"
+		(user_id, token_hash, expires_at)
+	VALUES
+		(:user_id, :token_hash, :expires_at)
+	ON CONFLICT (user_id) DO UPDATE SET
+		token_hash = EXCLUDED.token_hash,
+		expires_at = EXCLUDED.expires_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEmailChange(ec)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryEmailChange gets the pending email change by the hash of its token.
+func (s *Store) QueryEmailChange(ctx context.Context, tokenHash string) (userbus.EmailChange, error) {
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		user_id, token_hash, expires_at
+	FROM
+		"public"."email_change_token"
+	WHERE
+		token_hash = :token_hash`
+
+	var dbEC emailChangeDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbEC); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return userbus.EmailChange{}, fmt.Errorf("db: %w", userbus.ErrNotFound)
+		}
+		return userbus.EmailChange{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusEmailChange(dbEC), nil
+}
+
+// DeleteEmailChange removes the pending email change of the user.
+func (s *Store) DeleteEmailChange(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."email_change_token"
+	WHERE
+		user_id = :user_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// SaveRegistration creates or replaces the pending registration of the user.
+func (s *Store) SaveRegistration(ctx context.Context, reg userbus.Registration) error {
+	const q = `
+	INSERT INTO "public"."registration_token"
+		(user_id, token_hash, expires_at)
+	VALUES
+		(:user_id, :token_hash, :expires_at)
+	ON CONFLICT (user_id) DO UPDATE SET
+		token_hash = EXCLUDED.token_hash,
+		expires_at = EXCLUDED.expires_at`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRegistration(reg)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRegistration gets the pending registration by the hash of its token.
+func (s *Store) QueryRegistration(ctx context.Context, tokenHash string) (userbus.Registration, error) {
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		user_id, token_hash, expires_at
+	FROM
+		"public"."registration_token"
+	WHERE
+		token_hash = :token_hash`
+
+	var dbReg registrationDB
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbReg); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return userbus.Registration{}, fmt.Errorf("db: %w", userbus.ErrNotFound)
+		}
+		return userbus.Registration{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusRegistration(dbReg), nil
+}
+
+// DeleteRegistration removes the pending registration of the user.
+func (s *Store) DeleteRegistration(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		"public"."registration_token"
+	WHERE
+		user_id = :user_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRegistrations removes for good the users whose registration
+// expired before now without being confirmed, and returns how many were
+// removed. The memberships and the tokens go by cascade.
+func (s *Store) DeleteExpiredRegistrations(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."users" AS u
+		USING
+			"public"."registration_token" AS r
+		WHERE
+			r.user_id = u.user_id AND
+			r.expires_at <= :now AND
+			NOT u.enabled
+		RETURNING u.user_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,284 @@
+package userdb_test
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/dbtest"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+func Test_User(t *testing.T) {
+	db := dbtest.New(t, "Test_User")
+	store := userdb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	admin := createUser(t, store, "admin@example.com", role.Admin)
+	usr := createUser(t, store, "user@example.com", role.User)
+
+	deleted := createUser(t, store, "deleted@example.com", role.User)
+	if err := store.Delete(ctx, deleted); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+
+	t.Run("queryByID", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			id      uuid.UUID
+			want    userbus.User
+			wantErr error
+		}{
+			{name: "admin", id: admin.ID, want: admin},
+			{name: "user", id: usr.ID, want: usr},
+			{name: "deleted", id: deleted.ID, wantErr: userbus.ErrNotFound},
+			{name: "unknown", id: uuid.New(), wantErr: userbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryByID(ctx, tt.id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == nil {
+					assertUser(t, got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("queryByEmail", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			email   string
+			want    userbus.User
+			wantErr error
+		}{
+			{name: "user", email: usr.Email.Address, want: usr},
+			{name: "deleted", email: deleted.Email.Address, wantErr: userbus.ErrNotFound},
+			{name: "unknown", email: "nobody@example.com", wantErr: userbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.QueryByEmail(ctx, nil, mail.Address{Address: tt.email})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == nil {
+					assertUser(t, got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("createUnique", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			email   string
+			phone   string
+			wantErr error
+		}{
+			{name: "email", email: usr.Email.Address, wantErr: userbus.ErrUniqueEmail},
+			{name: "phone", email: "phone@example.com", phone: usr.Phone.String(), wantErr: userbus.ErrUniquePhone},
+			{name: "new", email: "new@example.com", phone: "+5511999990003"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				nu := newUser(tt.email, role.User)
+				nu.Phone = phone.MustParseNull(tt.phone)
+
+				if err := store.Create(ctx, nu); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+	})
+
+	t.Run("updateVersion", func(t *testing.T) {
+		stale := usr.UpdatedAt.Add(-time.Minute)
+
+		tests := []struct {
+			name    string
+			version *time.Time
+			wantErr error
+		}{
+			{name: "stale", version: &stale, wantErr: userbus.ErrConflict},
+			{name: "current", version: &usr.UpdatedAt},
+			{name: "unversioned"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				upd := usr
+				upd.Name = name.MustParse("Renamed " + tt.name)
+				upd.UpdatedAt = usr.UpdatedAt.Add(time.Second)
+
+				if err := store.Update(ctx, upd, tt.version); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+
+				got, err := store.QueryByID(ctx, usr.ID)
+				if err != nil {
+					t.Fatalf("query: %s", err)
+				}
+
+				if tt.wantErr == nil {
+					if !got.Name.Equal(upd.Name) {
+						t.Fatalf("got name %q, want %q", got.Name, upd.Name)
+					}
+					usr = got
+				}
+			})
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			id      uuid.UUID
+			wantErr error
+		}{
+			{name: "deleted", id: deleted.ID},
+			{name: "notDeleted", id: admin.ID, wantErr: userbus.ErrNotFound},
+			{name: "unknown", id: uuid.New(), wantErr: userbus.ErrNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := store.Restore(ctx, tt.id); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			})
+		}
+
+		if _, err := store.QueryByID(ctx, deleted.ID); err != nil {
+			t.Fatalf("restored user: %s", err)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		admins := role.Admin
+		users := role.User
+
+		tests := []struct {
+			name   string
+			filter userbus.QueryFilter
+			want   []uuid.UUID
+		}{
+			{name: "role", filter: userbus.QueryFilter{Role: &admins}, want: []uuid.UUID{admin.ID}},
+			{name: "id", filter: userbus.QueryFilter{ID: &usr.ID}, want: []uuid.UUID{usr.ID}},
+			{name: "roleAndID", filter: userbus.QueryFilter{Role: &users, ID: &admin.ID}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := store.Query(ctx, tt.filter, userbus.DefaultOrderBy, page.MustParse("1", "10"))
+				if err != nil {
+					t.Fatalf("query: %s", err)
+				}
+
+				if len(got) != len(tt.want) {
+					t.Fatalf("got %d users, want %d", len(got), len(tt.want))
+				}
+				for i, id := range tt.want {
+					if got[i].ID != id {
+						t.Errorf("got user %s at %d, want %s", got[i].ID, i, id)
+					}
+				}
+
+				n, err := store.Count(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("count: %s", err)
+				}
+				if n != len(tt.want) {
+					t.Errorf("got count %d, want %d", n, len(tt.want))
+				}
+			})
+		}
+	})
+}
+
+// BenchmarkQueryByID measures the hot lookup of the authenticated user, with
+// the named query compiled once and the statement prepared once per
+// connection.
+func BenchmarkQueryByID(b *testing.B) {
+	db := dbtest.New(b, "BenchmarkQueryByID")
+	store := userdb.NewStore(db.Log, db.DB)
+	ctx := context.Background()
+
+	usr := newUser("admin@example.com", role.Admin)
+	if err := store.Create(ctx, usr); err != nil {
+		b.Fatalf("create: %s", err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := store.QueryByID(ctx, usr.ID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// =============================================================================
+
+var phones = map[string]string{
+	"admin@example.com":   "+5511999990000",
+	"user@example.com":    "+5511999990001",
+	"deleted@example.com": "+5511999990002",
+}
+
+func newUser(email string, rl role.Role) userbus.User {
+	// O Postgres guarda microssegundos.
+	now := time.Now().Truncate(time.Microsecond)
+
+	return userbus.User{
+		ID:           uuid.New(),
+		Name:         name.MustParse("Test User"),
+		Email:        mail.Address{Address: email},
+		Role:         rl,
+		PasswordHash: []byte("hash"),
+		Phone:        phone.MustParseNull(phones[email]),
+		Enabled:      true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func createUser(t *testing.T, store *userdb.Store, email string, rl role.Role) userbus.User {
+	t.Helper()
+
+	usr := newUser(email, rl)
+	if err := store.Create(context.Background(), usr); err != nil {
+		t.Fatalf("create %s: %s", email, err)
+	}
+
+	return usr
+}
+
+func assertUser(t *testing.T, got userbus.User, want userbus.User) {
+	t.Helper()
+
+	switch {
+	case got.ID != want.ID:
+		t.Errorf("got id %s, want %s", got.ID, want.ID)
+	case got.Email.Address != want.Email.Address:
+		t.Errorf("got email %s, want %s", got.Email.Address, want.Email.Address)
+	case !got.Role.Equal(want.Role):
+		t.Errorf("got role %s, want %s", got.Role, want.Role)
+	case !got.Phone.Equal(want.Phone):
+		t.Errorf("got phone %s, want %s", got.Phone, want.Phone)
+	case !got.CreatedAt.Equal(want.CreatedAt):
+		t.Errorf("got created at %s, want %s", got.CreatedAt, want.CreatedAt)
+	}
+}
@@ -0,0 +1,504 @@
+// Package usermemory contains an in-memory user store used to exercise the
+// business layer without a database.
+package usermemory
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+// Store manages the set of APIs for user access kept in memory.
+type Store struct {
+	mu      *sync.RWMutex
+	users   map[uuid.UUID]userbus.User
+	deleted map[uuid.UUID]userbus.User
+	logins  map[uuid.UUID][]userbus.Login
+	prefs   map[uuid.UUID]userbus.Preferences
+	changes map[uuid.UUID]userbus.EmailChange
+	regs    map[uuid.UUID]userbus.Registration
+}
+
+// NewStore constructs an empty store.
+func NewStore() *Store {
+	return &Store{
+		mu:      &sync.RWMutex{},
+		users:   make(map[uuid.UUID]userbus.User),
+		deleted: make(map[uuid.UUID]userbus.User),
+		logins:  make(map[uuid.UUID][]userbus.Login),
+		prefs:   make(map[uuid.UUID]userbus.Preferences),
+		changes: make(map[uuid.UUID]userbus.EmailChange),
+		regs:    make(map[uuid.UUID]userbus.Registration),
+	}
+}
+
+// NewWithTx returns the same store. Changes are applied immediately and are
+// not undone if the transaction rolls back.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (userbus.Storer, error) {
+	return s, nil
+}
+
+// Create adds a new user to the store.
+func (s *Store) Create(ctx context.Context, usr userbus.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkUnique(usr); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	s.users[usr.ID] = usr
+
+	return nil
+}
+
+// Update replaces a user in the store. With a version the user is only
+// replaced while its UpdatedAt is still the version.
+func (s *Store) Update(ctx context.Context, usr userbus.User, version *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkUnique(usr); err != nil {
+		return err
+	}
+
+	stored, exists := s.users[usr.ID]
+	if version != nil && (!exists || !stored.UpdatedAt.Equal(*version)) {
+		return fmt.Errorf("memory: %w", userbus.ErrConflict)
+	}
+
+	if exists {
+		s.users[usr.ID] = usr
+	}
+
+	return nil
+}
+
+// Delete removes a user from the store.
+func (s *Store) Delete(ctx context.Context, usr userbus.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, exists := s.users[usr.ID]; exists {
+		s.deleted[usr.ID] = stored
+		delete(s.users, usr.ID)
+	}
+
+	return nil
+}
+
+// Restore brings back a deleted user.
+func (s *Store) Restore(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usr, exists := s.deleted[userID]
+	if !exists {
+		return fmt.Errorf("memory: %w", userbus.ErrNotFound)
+	}
+
+	s.users[userID] = usr
+	delete(s.deleted, userID)
+
+	return nil
+}
+
+// Query retrieves a list of existing users from the store.
+func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	less, err := lessFunc(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	usrs := s.filter(filter)
+	sort.SliceStable(usrs, func(i, j int) bool {
+		return less(usrs[i], usrs[j])
+	})
+
+	if cursor, ok := page.Cursor(); ok {
+		at, err := cursorUser(orderBy, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		usrs = slices.DeleteFunc(usrs, func(usr userbus.User) bool {
+			return !less(at, usr)
+		})
+	}
+
+	offset := (page.Number() - 1) * page.RowsPerPage()
+	if offset >= len(usrs) {
+		return []userbus.User{}, nil
+	}
+
+	end := min(offset+page.RowsPerPage(), len(usrs))
+
+	return usrs[offset:end], nil
+}
+
+// Count returns the total number of users in the store.
+func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.filter(filter)), nil
+}
+
+// QueryWithCount retrieves a page of users along with the total number of
+// users that match the filter.
+func (s *Store) QueryWithCount(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, int, error) {
+	usrs, err := s.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return usrs, total, nil
+}
+
+// QueryByID gets the specified user from the store.
+func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usr, exists := s.users[userID]
+	if !exists {
+		return userbus.User{}, fmt.Errorf("memory: %w", userbus.ErrNotFound)
+	}
+
+	return usr, nil
+}
+
+// QueryByEmail gets the user with the email in the scope from the store.
+func (s *Store) QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (userbus.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := userbus.EmailKey(scope, email.Address)
+	for _, usr := range s.users {
+		if userbus.EmailKey(usr.EmailScope, usr.Email.Address) == key {
+			return usr, nil
+		}
+	}
+
+	return userbus.User{}, fmt.Errorf("memory: %w", userbus.ErrNotFound)
+}
+
+// DeleteExpiredResetTokens does nothing, the store keeps no password reset
+// tokens.
+func (s *Store) DeleteExpiredResetTokens(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+// CreateLogin updates the last login of the user and adds the login to the
+// history.
+func (s *Store) CreateLogin(ctx context.Context, usr userbus.User, login userbus.Login) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, exists := s.users[usr.ID]; exists {
+		stored.LastLoginAt = login.CreatedAt
+		stored.LastLoginIP = login.IP
+		s.users[usr.ID] = stored
+	}
+
+	s.logins[login.UserID] = append(s.logins[login.UserID], login)
+
+	return nil
+}
+
+// QueryLogins retrieves the login history of the user, most recent first.
+func (s *Store) QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]userbus.Login, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	logins := s.logins[userID]
+
+	offset := (page.Number() - 1) * page.RowsPerPage()
+	if offset >= len(logins) {
+		return []userbus.Login{}, nil
+	}
+
+	end := min(offset+page.RowsPerPage(), len(logins))
+
+	// O histórico é mantido em ordem de chegada.
+	result := make([]userbus.Login, 0, end-offset)
+	for i := len(logins) - 1 - offset; i >= len(logins)-end; i-- {
+		result = append(result, logins[i])
+	}
+
+	return result, nil
+}
+
+// CountLogins returns the total number of logins of the user.
+func (s *Store) CountLogins(ctx context.Context, userID uuid.UUID) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.logins[userID]), nil
+}
+
+// QueryPreferences gets the preferences of the user from the store.
+func (s *Store) QueryPreferences(ctx context.Context, userID uuid.UUID) (userbus.Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs, exists := s.prefs[userID]
+	if !exists {
+		return userbus.Preferences{}, fmt.Errorf("memory: %w", userbus.ErrNotFound)
+	}
+
+	return prefs, nil
+}
+
+// SavePreferences creates or replaces the preferences of the user.
+func (s *Store) SavePreferences(ctx context.Context, userID uuid.UUID, prefs userbus.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prefs[userID] = prefs
+
+	return nil
+}
+
+// SaveEmailChange creates or replaces the pending email change of the user.
+func (s *Store) SaveEmailChange(ctx context.Context, ec userbus.EmailChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes[ec.UserID] = ec
+
+	return nil
+}
+
+// QueryEmailChange gets the pending email change by the hash of its token.
+func (s *Store) QueryEmailChange(ctx context.Context, tokenHash string) (userbus.EmailChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ec := range s.changes {
+		if ec.TokenHash == tokenHash {
+			return ec, nil
+		}
+	}
+
+	return userbus.EmailChange{}, fmt.Errorf("memory: %w", userbus.ErrNotFound)
+}
+
+// DeleteEmailChange removes the pending email change of the user.
+func (s *Store) DeleteEmailChange(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.changes, userID)
+
+	return nil
+}
+
+// SaveRegistration creates or replaces the pending registration of the user.
+func (s *Store) SaveRegistration(ctx context.Context, reg userbus.Registration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.regs[reg.UserID] = reg
+
+	return nil
+}
+
+// QueryRegistration gets the pending registration by the hash of its token.
+func (s *Store) QueryRegistration(ctx context.Context, tokenHash string) (userbus.Registration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, reg := range s.regs {
+		if reg.TokenHash == tokenHash {
+			return reg, nil
+		}
+	}
+
+	return userbus.Registration{}, fmt.Errorf("memory: %w", userbus.ErrNotFound)
+}
+
+// DeleteRegistration removes the pending registration of the user.
+func (s *Store) DeleteRegistration(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.regs, userID)
+
+	return nil
+}
+
+// DeleteExpiredRegistrations removes for good the users whose registration
+// expired before now without being confirmed.
+func (s *Store) DeleteExpiredRegistrations(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for userID, reg := range s.regs {
+		if reg.ExpiresAt.After(now) {
+			continue
+		}
+
+		if usr, exists := s.users[userID]; exists && !usr.Enabled {
+			delete(s.users, userID)
+			delete(s.logins, userID)
+			delete(s.prefs, userID)
+			delete(s.changes, userID)
+			n++
+		}
+
+		delete(s.regs, userID)
+	}
+
+	return n, nil
+}
+
+// =============================================================================
+
+// checkUnique mirrors the unique constraints of the users table.
+// checkUnique also looks at the deleted users, as the unique constraints of
+// the database do.
+func (s *Store) checkUnique(usr userbus.User) error {
+	for _, users := range []map[uuid.UUID]userbus.User{s.users, s.deleted} {
+		for _, other := range users {
+			if other.ID == usr.ID {
+				continue
+			}
+
+			if userbus.EmailKey(other.EmailScope, other.Email.Address) == userbus.EmailKey(usr.EmailScope, usr.Email.Address) {
+				return userbus.ErrUniqueEmail
+			}
+
+			if usr.Phone.String() != "NULL" && other.Phone.Equal(usr.Phone) {
+				return userbus.ErrUniquePhone
+			}
+		}
+	}
+
+	return nil
+}
+
+// filter ignores TenantID: the store keeps no tenant memberships.
+func (s *Store) filter(filter userbus.QueryFilter) []userbus.User {
+	usrs := make([]userbus.User, 0, len(s.users))
+
+	for _, usr := range s.users {
+		switch {
+		case filter.ID != nil && usr.ID != *filter.ID:
+			continue
+		case filter.Name != nil && !strings.Contains(usr.Name.String(), filter.Name.String()):
+			continue
+		case filter.Email != nil && usr.Email.Address != filter.Email.Address:
+			continue
+		case filter.Role != nil && !usr.Role.Equal(*filter.Role):
+			continue
+		case filter.Enabled != nil && usr.Enabled != *filter.Enabled:
+			continue
+		case filter.StartCreatedAt != nil && usr.CreatedAt.Before(*filter.StartCreatedAt):
+			continue
+		case filter.EndCreatedAt != nil && usr.CreatedAt.After(*filter.EndCreatedAt):
+			continue
+		}
+
+		usrs = append(usrs, usr)
+	}
+
+	return usrs
+}
+
+func lessFunc(orderBy order.By) (func(a, b userbus.User) bool, error) {
+	var less func(a, b userbus.User) bool
+
+	switch orderBy.Field {
+	case userbus.OrderByID:
+		less = func(a, b userbus.User) bool { return a.ID.String() < b.ID.String() }
+	case userbus.OrderByName:
+		less = func(a, b userbus.User) bool { return a.Name.String() < b.Name.String() }
+	case userbus.OrderByEmail:
+		less = func(a, b userbus.User) bool { return a.Email.Address < b.Email.Address }
+	case userbus.OrderByRole:
+		less = func(a, b userbus.User) bool { return a.Role.String() < b.Role.String() }
+	case userbus.OrderByEnabled:
+		less = func(a, b userbus.User) bool { return !a.Enabled && b.Enabled }
+	default:
+		return nil, fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	// O ID desempata, como no banco, para o cursor apontar uma posição única.
+	byField := less
+	less = func(a, b userbus.User) bool {
+		switch {
+		case byField(a, b):
+			return true
+		case byField(b, a):
+			return false
+		}
+		return a.ID.String() < b.ID.String()
+	}
+
+	if orderBy.Direction == order.DESC {
+		return func(a, b userbus.User) bool { return less(b, a) }, nil
+	}
+
+	return less, nil
+}
+
+// cursorUser rebuilds the position of the cursor as a user, with only the
+// fields compared by the order.
+func cursorUser(orderBy order.By, cursor page.Cursor) (userbus.User, error) {
+	var usr userbus.User
+
+	id, err := uuid.Parse(cursor.Values[len(cursor.Values)-1])
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("cursor: %w", err)
+	}
+	usr.ID = id
+
+	if orderBy.Field == userbus.OrderByID {
+		return usr, nil
+	}
+
+	if len(cursor.Values) != 2 {
+		return userbus.User{}, fmt.Errorf("cursor does not match the order")
+	}
+	value := cursor.Values[0]
+
+	switch orderBy.Field {
+	case userbus.OrderByName:
+		usr.Name, err = name.Parse(value)
+	case userbus.OrderByEmail:
+		usr.Email = mail.Address{Address: value}
+	case userbus.OrderByRole:
+		usr.Role, err = role.Parse(value)
+	case userbus.OrderByEnabled:
+		usr.Enabled, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("cursor: %w", err)
+	}
+
+	return usr, nil
+}
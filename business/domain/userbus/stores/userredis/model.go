@@ -0,0 +1,93 @@
+package userredis
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+)
+
+type cachedUser struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	PasswordHash []byte     `json:"passwordHash"`
+	Phone        string     `json:"phone"`
+	PendingEmail string     `json:"pendingEmail"`
+	EmailScope   *uuid.UUID `json:"emailScope,omitempty"`
+	Enabled      bool       `json:"enabled"`
+	LastLoginAt  time.Time  `json:"lastLoginAt"`
+	LastLoginIP  string     `json:"lastLoginIp"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func toCachedUser(bus userbus.User) cachedUser {
+	cu := cachedUser{
+		ID:           bus.ID,
+		Name:         bus.Name.String(),
+		Email:        bus.Email.Address,
+		Role:         bus.Role.String(),
+		PasswordHash: bus.PasswordHash,
+		Phone:        phone.ToSQLNullString(bus.Phone).String,
+		Enabled:      bus.Enabled,
+		LastLoginAt:  bus.LastLoginAt.UTC(),
+		LastLoginIP:  bus.LastLoginIP,
+		EmailScope:   bus.EmailScope,
+		CreatedAt:    bus.CreatedAt.UTC(),
+		UpdatedAt:    bus.UpdatedAt.UTC(),
+	}
+
+	if bus.PendingEmail != nil {
+		cu.PendingEmail = bus.PendingEmail.Address
+	}
+
+	return cu
+}
+
+func toBusUser(cu cachedUser) (userbus.User, error) {
+	usrRole, err := role.Parse(cu.Role)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("parse role: %w", err)
+	}
+
+	nme, err := name.Parse(cu.Name)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("parse name: %w", err)
+	}
+
+	phn, err := phone.ParseNull(cu.Phone)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("parse phone: %w", err)
+	}
+
+	bus := userbus.User{
+		ID:           cu.ID,
+		Name:         nme,
+		Email:        mail.Address{Address: cu.Email},
+		Role:         usrRole,
+		PasswordHash: cu.PasswordHash,
+		Phone:        phn,
+		Enabled:      cu.Enabled,
+		LastLoginIP:  cu.LastLoginIP,
+		EmailScope:   cu.EmailScope,
+		CreatedAt:    cu.CreatedAt.In(time.Local),
+		UpdatedAt:    cu.UpdatedAt.In(time.Local),
+	}
+
+	if cu.PendingEmail != "" {
+		bus.PendingEmail = &mail.Address{Address: cu.PendingEmail}
+	}
+
+	if !cu.LastLoginAt.IsZero() {
+		bus.LastLoginAt = cu.LastLoginAt.In(time.Local)
+	}
+
+	return bus, nil
+}
@@ -0,0 +1,324 @@
+// Package userredis contains a user cache shared by every instance through
+// Redis. Writes invalidate the cached user, so a change made by one instance
+// is never served stale by the others.
+package userredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/userdb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+	"github.com/jcpaschoal/spi-exata/business/sdk/page"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+)
+
+const keyPrefix = "spi:user:"
+
+// Store manages the set of APIs for user access cached in Redis.
+type Store struct {
+	log    *logger.Logger
+	storer userbus.Storer
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewStore constructs the api for data access. Cached users expire after ttl.
+func NewStore(log *logger.Logger, storer userbus.Storer, client *redis.Client, ttl time.Duration) *Store {
+	return &Store{
+		log:    log,
+		storer: storer,
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the storer with one that
+// is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (userbus.Storer, error) {
+	txStorer, err := s.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log:    s.log,
+		storer: txStorer,
+		client: s.client,
+		ttl:    s.ttl,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new user into the database. The user is cached on the
+// first read.
+func (s *Store) Create(ctx context.Context, usr userbus.User) error {
+	return s.storer.Create(ctx, usr)
+}
+
+// Update replaces a user document in the database and invalidates the cache.
+// Inside a transaction the entry is removed before the commit, a concurrent
+// read can cache the previous version until the ttl.
+func (s *Store) Update(ctx context.Context, usr userbus.User, version *time.Time) error {
+	if err := s.storer.Update(ctx, usr, version); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, usr)
+
+	return nil
+}
+
+// Delete removes a user from the database and invalidates the cache.
+func (s *Store) Delete(ctx context.Context, usr userbus.User) error {
+	if err := s.storer.Delete(ctx, usr); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, usr)
+
+	return nil
+}
+
+// Restore brings back a deleted user. Deleted users are not cached, the user
+// is cached on the next read.
+func (s *Store) Restore(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.Restore(ctx, userID)
+}
+
+// Query retrieves a list of existing users from the database.
+func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, error) {
+	return s.storer.Query(ctx, filter, orderBy, page)
+}
+
+// QueryWithCount retrieves a page of users and the total from the database.
+func (s *Store) QueryWithCount(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, int, error) {
+	return s.storer.QueryWithCount(ctx, filter, orderBy, page)
+}
+
+// Count returns the total number of users in the DB.
+func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, error) {
+	return s.storer.Count(ctx, filter)
+}
+
+// QueryByID gets the specified user from the cache or the database.
+func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User, error) {
+	if usr, ok := s.readUser(ctx, userID); ok {
+		return usr, nil
+	}
+
+	usr, err := s.storer.QueryByID(ctx, userID)
+	if err != nil {
+		return userbus.User{}, err
+	}
+
+	s.writeCache(ctx, usr)
+
+	return usr, nil
+}
+
+// QueryByEmail gets the specified user from the cache or the database by
+// email.
+func (s *Store) QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (userbus.User, error) {
+	if usr, ok := s.readByEmail(ctx, scope, email); ok {
+		return usr, nil
+	}
+
+	usr, err := s.storer.QueryByEmail(ctx, scope, email)
+	if err != nil {
+		return userbus.User{}, err
+	}
+
+	s.writeCache(ctx, usr)
+
+	return usr, nil
+}
+
+// DeleteExpiredResetTokens removes the expired password reset tokens. The
+// tokens are not cached.
+func (s *Store) DeleteExpiredResetTokens(ctx context.Context, now time.Time) (int, error) {
+	return s.storer.DeleteExpiredResetTokens(ctx, now)
+}
+
+// CreateLogin records the login and invalidates the cache, the cached user
+// carries the last login. The history is not cached.
+func (s *Store) CreateLogin(ctx context.Context, usr userbus.User, login userbus.Login) error {
+	if err := s.storer.CreateLogin(ctx, usr, login); err != nil {
+		return err
+	}
+
+	s.invalidate(ctx, usr)
+
+	return nil
+}
+
+// QueryLogins retrieves the login history of the user from the database.
+func (s *Store) QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]userbus.Login, error) {
+	return s.storer.QueryLogins(ctx, userID, page)
+}
+
+// CountLogins returns the total number of logins of the user.
+func (s *Store) CountLogins(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.storer.CountLogins(ctx, userID)
+}
+
+// QueryPreferences gets the preferences of the user from the database. The
+// preferences are not cached.
+func (s *Store) QueryPreferences(ctx context.Context, userID uuid.UUID) (userbus.Preferences, error) {
+	return s.storer.QueryPreferences(ctx, userID)
+}
+
+// SavePreferences creates or replaces the preferences of the user.
+func (s *Store) SavePreferences(ctx context.Context, userID uuid.UUID, prefs userbus.Preferences) error {
+	return s.storer.SavePreferences(ctx, userID, prefs)
+}
+
+// SaveEmailChange creates or replaces the pending email change of the user.
+// The tokens are not cached.
+func (s *Store) SaveEmailChange(ctx context.Context, ec userbus.EmailChange) error {
+	return s.storer.SaveEmailChange(ctx, ec)
+}
+
+// QueryEmailChange gets the pending email change by the hash of its token.
+func (s *Store) QueryEmailChange(ctx context.Context, tokenHash string) (userbus.EmailChange, error) {
+	return s.storer.QueryEmailChange(ctx, tokenHash)
+}
+
+// DeleteEmailChange removes the pending email change of the user.
+func (s *Store) DeleteEmailChange(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.DeleteEmailChange(ctx, userID)
+}
+
+// SaveRegistration creates or replaces the pending registration of the user.
+// The tokens are not cached.
+func (s *Store) SaveRegistration(ctx context.Context, reg userbus.Registration) error {
+	return s.storer.SaveRegistration(ctx, reg)
+}
+
+// QueryRegistration gets the pending registration by the hash of its token.
+func (s *Store) QueryRegistration(ctx context.Context, tokenHash string) (userbus.Registration, error) {
+	return s.storer.QueryRegistration(ctx, tokenHash)
+}
+
+// DeleteRegistration removes the pending registration of the user.
+func (s *Store) DeleteRegistration(ctx context.Context, userID uuid.UUID) error {
+	return s.storer.DeleteRegistration(ctx, userID)
+}
+
+// DeleteExpiredRegistrations removes the users who did not confirm the
+// registration in time. The removed users were never enabled, so a cached
+// copy cannot authenticate and is left to expire.
+func (s *Store) DeleteExpiredRegistrations(ctx context.Context, now time.Time) (int, error) {
+	return s.storer.DeleteExpiredRegistrations(ctx, now)
+}
+
+// =============================================================================
+
+// O e-mail aponta para o ID: após uma troca de e-mail a chave antiga deixa de
+// casar com o usuário e a consulta cai no banco.
+
+func idKey(userID uuid.UUID) string {
+	return keyPrefix + "id:" + userID.String()
+}
+
+func emailKey(scope *uuid.UUID, email string) string {
+	return keyPrefix + "email:" + userbus.EmailKey(scope, email)
+}
+
+// readUser returns the cached user. Cache failures are logged and reported
+// as a miss, so the database still answers when Redis is down.
+func (s *Store) readUser(ctx context.Context, userID uuid.UUID) (userbus.User, bool) {
+	data, err := s.client.Get(ctx, idKey(userID))
+	if err != nil {
+		if !errors.Is(err, redis.ErrNil) {
+			s.log.Error(ctx, "userredis", "status", "read cache", "userID", userID, "ERROR", err)
+		}
+		return userbus.User{}, false
+	}
+
+	var cu cachedUser
+	if err := json.Unmarshal(data, &cu); err != nil {
+		s.log.Error(ctx, "userredis", "status", "decode cache", "userID", userID, "ERROR", err)
+		return userbus.User{}, false
+	}
+
+	usr, err := toBusUser(cu)
+	if err != nil {
+		s.log.Error(ctx, "userredis", "status", "decode cache", "userID", userID, "ERROR", err)
+		return userbus.User{}, false
+	}
+
+	return usr, true
+}
+
+func (s *Store) readByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (userbus.User, bool) {
+	data, err := s.client.Get(ctx, emailKey(scope, email.Address))
+	if err != nil {
+		if !errors.Is(err, redis.ErrNil) {
+			s.log.Error(ctx, "userredis", "status", "read cache", "email", email.Address, "ERROR", err)
+		}
+		return userbus.User{}, false
+	}
+
+	userID, err := uuid.ParseBytes(data)
+	if err != nil {
+		return userbus.User{}, false
+	}
+
+	usr, ok := s.readUser(ctx, userID)
+	if !ok || userbus.EmailKey(usr.EmailScope, usr.Email.Address) != userbus.EmailKey(scope, email.Address) {
+		return userbus.User{}, false
+	}
+
+	return usr, true
+}
+
+func (s *Store) writeCache(ctx context.Context, usr userbus.User) {
+	data, err := json.Marshal(toCachedUser(usr))
+	if err != nil {
+		s.log.Error(ctx, "userredis", "status", "encode cache", "userID", usr.ID, "ERROR", err)
+		return
+	}
+
+	if err := s.client.Set(ctx, idKey(usr.ID), data, s.ttl); err != nil {
+		s.log.Error(ctx, "userredis", "status", "write cache", "userID", usr.ID, "ERROR", err)
+		return
+	}
+
+	if err := s.client.Set(ctx, emailKey(usr.EmailScope, usr.Email.Address), []byte(usr.ID.String()), s.ttl); err != nil {
+		s.log.Error(ctx, "userredis", "status", "write cache", "userID", usr.ID, "ERROR", err)
+	}
+}
+
+// Invalidate removes the cached user announced in the payload. Redis is shared
+// by the instances, but changes made outside the api, by the worker or the
+// admin tooling, only reach it through sqldb.Listen on userdb.Channel.
+func (s *Store) Invalidate(payload string) {
+	ctx := context.Background()
+
+	n, err := userdb.ParseNotification(payload)
+	if err != nil {
+		s.log.Error(ctx, "userredis", "status", "invalid notification", "payload", payload, "ERROR", err)
+		return
+	}
+
+	if _, err := s.client.Del(ctx, idKey(n.UserID), emailKey(n.EmailScope, n.Email)); err != nil {
+		s.log.Error(ctx, "userredis", "status", "invalidate cache", "userID", n.UserID, "ERROR", err)
+	}
+}
+
+// invalidate removes the cached user. A failure is only logged: the change is
+// already in the database and the entry expires with the ttl.
+func (s *Store) invalidate(ctx context.Context, usr userbus.User) {
+	if _, err := s.client.Del(ctx, idKey(usr.ID), emailKey(usr.EmailScope, usr.Email.Address)); err != nil {
+		s.log.Error(ctx, "userredis", "status", "invalidate cache", "userID", usr.ID, "ERROR", err)
+	}
+}
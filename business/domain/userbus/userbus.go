@@ -2,17 +2,23 @@ package userbus
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/mail"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
 	"github.com/jcpaschoal/spi-exata/business/sdk/order"
 	"github.com/jcpaschoal/spi-exata/business/sdk/page"
 	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
 	"github.com/jcpaschoal/spi-exata/foundation/otel"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
 )
 
 var (
@@ -20,26 +26,62 @@ var (
 	ErrUniqueEmail           = errors.New("email is not unique")
 	ErrUniquePhone           = errors.New("Phone is not unique")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrDisabled              = errors.New("user is disabled")
+	ErrInvalidToken          = errors.New("invalid or expired token")
+	ErrConflict              = errors.New("user was changed by another request")
 )
 
+// EmailChangeTTL is how long the confirmation of an email change is valid.
+const EmailChangeTTL = 24 * time.Hour
+
+// RegistrationTTL is how long a user who signed up has to confirm the email.
+const RegistrationTTL = 48 * time.Hour
+
 type Storer interface {
 	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
 	Create(ctx context.Context, usr User) error
-	Update(ctx context.Context, usr User) error
+	Update(ctx context.Context, usr User, version *time.Time) error
 	Delete(ctx context.Context, usr User) error
+	Restore(ctx context.Context, userID uuid.UUID) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]User, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryWithCount(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]User, int, error)
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
-	QueryByEmail(ctx context.Context, email mail.Address) (User, error)
+	QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (User, error)
+	DeleteExpiredResetTokens(ctx context.Context, now time.Time) (int, error)
+	CreateLogin(ctx context.Context, usr User, login Login) error
+	QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]Login, error)
+	CountLogins(ctx context.Context, userID uuid.UUID) (int, error)
+	QueryPreferences(ctx context.Context, userID uuid.UUID) (Preferences, error)
+	SavePreferences(ctx context.Context, userID uuid.UUID, prefs Preferences) error
+	SaveEmailChange(ctx context.Context, ec EmailChange) error
+	QueryEmailChange(ctx context.Context, tokenHash string) (EmailChange, error)
+	DeleteEmailChange(ctx context.Context, userID uuid.UUID) error
+	SaveRegistration(ctx context.Context, reg Registration) error
+	QueryRegistration(ctx context.Context, tokenHash string) (Registration, error)
+	DeleteRegistration(ctx context.Context, userID uuid.UUID) error
+	DeleteExpiredRegistrations(ctx context.Context, now time.Time) (int, error)
 }
 
 type Core struct {
-	storer Storer
+	storer    Storer
+	outboxBus *outboxbus.Core
+	delegate  *delegate.Delegate
+	hasher    *passhash.Hasher
+	tx        sqldb.CommitRollbacker
 }
 
-func NewCore(storer Storer) *Core {
+// NewCore constructs a core for user api access. User events are written to
+// the outbox, so changes that emit them should run inside a transaction.
+// Disabling or deleting a user cascades to the domains registered in the
+// delegate, which join the same transaction. Passwords are hashed on the
+// bounded pool of the hasher.
+func NewCore(storer Storer, outboxBus *outboxbus.Core, delegate *delegate.Delegate, hasher *passhash.Hasher) *Core {
 	return &Core{
-		storer: storer,
+		storer:    storer,
+		outboxBus: outboxBus,
+		delegate:  delegate,
+		hasher:    hasher,
 	}
 }
 
@@ -50,7 +92,13 @@ func (c *Core) NewWithTx(tx sqldb.CommitRollbacker) (*Core, error) {
 		return nil, err
 	}
 
-	nc := NewCore(storer)
+	outboxBus, err := c.outboxBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	nc := NewCore(storer, outboxBus, c.delegate, c.hasher)
+	nc.tx = tx
 
 	return nc, nil
 
@@ -61,9 +109,13 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 	ctx, span := otel.AddSpan(ctx, "business.userbus.create")
 	defer span.End()
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(nu.Password.String()), bcrypt.DefaultCost)
+	return c.create(ctx, nu, true)
+}
+
+func (c *Core) create(ctx context.Context, nu NewUser, enabled bool) (User, error) {
+	hash, err := c.hasher.Hash(ctx, nu.Password.String())
 	if err != nil {
-		return User{}, fmt.Errorf("generateFromPassword: %w", err)
+		return User{}, fmt.Errorf("hash: %w", err)
 	}
 
 	now := time.Now()
@@ -75,7 +127,8 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 		PasswordHash: hash,
 		Role:         nu.Role,
 		Phone:        nu.Phone,
-		Enabled:      true,
+		EmailScope:   nu.EmailScope,
+		Enabled:      enabled,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -84,9 +137,27 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 		return User{}, fmt.Errorf("create: %w", err)
 	}
 
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeUserCreated,
+		AggregateID: usr.ID,
+		DedupKey:    outboxbus.TypeUserCreated + ":" + usr.ID.String(),
+		Payload: outboxbus.UserCreated{
+			UserID: usr.ID,
+			Email:  usr.Email.Address,
+			Role:   usr.Role.String(),
+		},
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return User{}, fmt.Errorf("outbox: %w", err)
+	}
+
 	return usr, nil
 }
 
+// Update modifies the user. With uu.Version set the change only applies to
+// the user as it was at that version, ErrConflict is returned when another
+// request changed it first.
 func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
 
 	ctx, span := otel.AddSpan(ctx, "business.userbus.update")
@@ -100,14 +171,15 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		usr.Email = *uu.Email
 	}
 
+	oldRole := usr.Role
 	if uu.Role != nil {
 		usr.Role = *uu.Role
 	}
 
 	if uu.Password != nil {
-		pw, err := bcrypt.GenerateFromPassword([]byte(uu.Password.String()), bcrypt.DefaultCost)
+		pw, err := c.hasher.Hash(ctx, uu.Password.String())
 		if err != nil {
-			return User{}, fmt.Errorf("generatefrompassword: %w", err)
+			return User{}, fmt.Errorf("hash: %w", err)
 		}
 		usr.PasswordHash = pw
 	}
@@ -116,16 +188,40 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		usr.Phone = *uu.Phone
 	}
 
+	wasEnabled := usr.Enabled
 	if uu.Enabled != nil {
 		usr.Enabled = *uu.Enabled
 	}
 
 	usr.UpdatedAt = time.Now()
 
-	if err := c.storer.Update(ctx, usr); err != nil {
+	if err := c.storer.Update(ctx, usr, uu.Version); err != nil {
 		return User{}, fmt.Errorf("update: %w", err)
 	}
 
+	if !usr.Role.Equal(oldRole) {
+		ne := outboxbus.NewEvent{
+			Type:        outboxbus.TypeRoleChanged,
+			AggregateID: usr.ID,
+			DedupKey:    fmt.Sprintf("%s:%s:%d", outboxbus.TypeRoleChanged, usr.ID, usr.UpdatedAt.UnixNano()),
+			Payload: outboxbus.RoleChanged{
+				UserID:  usr.ID,
+				OldRole: oldRole.String(),
+				NewRole: usr.Role.String(),
+			},
+		}
+
+		if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+			return User{}, fmt.Errorf("outbox: %w", err)
+		}
+	}
+
+	if wasEnabled && !usr.Enabled {
+		if err := c.cascade(ctx, ActionDisabled, ActionParams{UserID: usr.ID}); err != nil {
+			return User{}, err
+		}
+	}
+
 	return usr, nil
 }
 
@@ -137,9 +233,71 @@ func (c *Core) Delete(ctx context.Context, usr User) error {
 	if err := c.storer.Delete(ctx, usr); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
+
+	params := ActionParams{
+		UserID:                usr.ID,
+		RemoveDashboardAccess: true,
+	}
+
+	if err := c.cascade(ctx, ActionDeleted, params); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// Restore brings back a deleted user, who can authenticate again with the
+// same permissions as before the deletion.
+func (c *Core) Restore(ctx context.Context, userID uuid.UUID) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.restore")
+	defer span.End()
+
+	if err := c.storer.Restore(ctx, userID); err != nil {
+		return User{}, fmt.Errorf("restore: userID[%s]: %w", userID, err)
+	}
+
+	usr, err := c.storer.QueryByID(ctx, userID)
+	if err != nil {
+		return User{}, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// cascade calls the domains registered for the action, inside the transaction
+// of the core when there is one. Without a transaction a failure leaves the
+// user changed and only part of the cascade applied.
+func (c *Core) cascade(ctx context.Context, action string, params ActionParams) error {
+	if c.delegate == nil {
+		return nil
+	}
+
+	data, err := ActionData(action, params, c.tx)
+	if err != nil {
+		return fmt.Errorf("actionData: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, data); err != nil {
+		return fmt.Errorf("delegate: userID[%s]: %w", params.UserID, err)
+	}
+
+	return nil
+}
+
+// PurgeExpiredResetTokens removes the password reset tokens that already
+// expired. They can no longer be redeemed and only occupy the table.
+func (c *Core) PurgeExpiredResetTokens(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.purgeExpiredResetTokens")
+	defer span.End()
+
+	n, err := c.storer.DeleteExpiredResetTokens(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleteExpiredResetTokens: %w", err)
+	}
+
+	return n, nil
+}
+
 // Query retrieves a list of existing users.
 func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]User, error) {
 
@@ -154,6 +312,20 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return users, nil
 }
 
+// QueryWithCount retrieves a page of users along with the total number of
+// users that match the filter, in a single round trip to the store.
+func (c *Core) QueryWithCount(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]User, int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.queryWithCount")
+	defer span.End()
+
+	users, total, err := c.storer.QueryWithCount(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, 0, fmt.Errorf("queryWithCount: %w", err)
+	}
+
+	return users, total, nil
+}
+
 // Count returns the total number of users.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	ctx, span := otel.AddSpan(ctx, "business.userbus.count")
@@ -176,13 +348,15 @@ func (c *Core) QueryByID(ctx context.Context, userID uuid.UUID) (User, error) {
 	return user, nil
 }
 
-// QueryByEmail finds the user by a specified user email.
-func (c *Core) QueryByEmail(ctx context.Context, email mail.Address) (User, error) {
+// QueryByEmail finds the user with the email in the scope. A nil scope is
+// the whole system; see tenantbus.Core.EmailScope for the scope of the
+// members of a tenant.
+func (c *Core) QueryByEmail(ctx context.Context, scope *uuid.UUID, email mail.Address) (User, error) {
 
 	ctx, span := otel.AddSpan(ctx, "business.userbus.queryByEmail")
 	defer span.End()
 
-	user, err := c.storer.QueryByEmail(ctx, email)
+	user, err := c.storer.QueryByEmail(ctx, scope, email)
 	if err != nil {
 		return User{}, fmt.Errorf("query: email[%s]: %w", email, err)
 	}
@@ -193,19 +367,434 @@ func (c *Core) QueryByEmail(ctx context.Context, email mail.Address) (User, erro
 // Authenticate finds a user by their email and verifies their password. On
 // success it returns a Claims User representing this user. The claims can be
 // used to generate a token for future authentication.
-func (c *Core) Authenticate(ctx context.Context, email mail.Address, password string) (User, error) {
+//
+// The email is looked up first among the users scoped to the tenant the
+// login comes from, then in the scope of the whole system. With uuid.Nil as
+// tenantID only the whole system is looked at.
+//
+// An unknown email and a wrong password fail the same way, with the same
+// error and the same bcrypt work, so the caller cannot tell them apart.
+func (c *Core) Authenticate(ctx context.Context, tenantID uuid.UUID, email mail.Address, password string) (User, error) {
 
 	ctx, span := otel.AddSpan(ctx, "business.userbus.authenticate")
 	defer span.End()
 
-	usr, err := c.QueryByEmail(ctx, email)
+	usr, err := c.queryLoginEmail(ctx, tenantID, email)
 	if err != nil {
-		return User{}, fmt.Errorf("query: email[%s]: %w", email, err)
+		if !errors.Is(err, ErrNotFound) {
+			return User{}, fmt.Errorf("query: email[%s]: %w", email, err)
+		}
+
+		c.hasher.CompareDummy(ctx, password)
+		return User{}, fmt.Errorf("compare: %w", ErrAuthenticationFailure)
+	}
+
+	if err := c.hasher.Compare(ctx, usr.PasswordHash, password); err != nil {
+		if ctx.Err() != nil {
+			return User{}, fmt.Errorf("compare: %w", err)
+		}
+		return User{}, fmt.Errorf("compare: %w", ErrAuthenticationFailure)
+	}
+
+	if c.hasher.NeedsRehash(usr.PasswordHash) {
+		if err := c.rehash(ctx, usr, password); err != nil {
+			return User{}, fmt.Errorf("rehash: userID[%s]: %w", usr.ID, err)
+		}
+	}
+
+	return usr, nil
+}
+
+// rehash replaces the hash of the user with one at the cost of the hasher,
+// the cost the dummy hash of an unknown email is made at. Otherwise a user
+// created by the tools, at the default cost, fails faster than an unknown
+// email on a machine calibrated to a higher cost.
+func (c *Core) rehash(ctx context.Context, usr User, password string) error {
+	hash, err := c.hasher.Hash(ctx, password)
+	if err != nil {
+		return fmt.Errorf("hash: %w", err)
+	}
+
+	// O updated_at fica como está: a troca do hash não é uma alteração do
+	// usuário. Se outra requisição gravou antes, o hash é refeito no próximo
+	// login.
+	version := usr.UpdatedAt
+	usr.PasswordHash = hash
+
+	if err := c.storer.Update(ctx, usr, &version); err != nil && !errors.Is(err, ErrConflict) {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// queryLoginEmail finds the user who logs in with the email through the
+// tenant. A user scoped to the tenant comes before a user of the whole
+// system with the same email.
+func (c *Core) queryLoginEmail(ctx context.Context, tenantID uuid.UUID, email mail.Address) (User, error) {
+	if tenantID != uuid.Nil {
+		usr, err := c.storer.QueryByEmail(ctx, &tenantID, email)
+		if !errors.Is(err, ErrNotFound) {
+			return usr, err
+		}
+	}
+
+	return c.storer.QueryByEmail(ctx, nil, email)
+}
+
+// RecordLogin registers a successful authentication of the user, updating the
+// last login and adding it to the login history.
+func (c *Core) RecordLogin(ctx context.Context, usr User, nl NewLogin) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.recordLogin")
+	defer span.End()
+
+	login := Login{
+		ID:        uuid.New(),
+		UserID:    usr.ID,
+		IP:        nl.IP,
+		UserAgent: nl.UserAgent,
+		CreatedAt: time.Now(),
+	}
+
+	usr.LastLoginAt = login.CreatedAt
+	usr.LastLoginIP = login.IP
+
+	if err := c.storer.CreateLogin(ctx, usr, login); err != nil {
+		return User{}, fmt.Errorf("createLogin: userID[%s]: %w", usr.ID, err)
+	}
+
+	return usr, nil
+}
+
+// QueryLogins retrieves the login history of the user, most recent first.
+func (c *Core) QueryLogins(ctx context.Context, userID uuid.UUID, page page.Page) ([]Login, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.queryLogins")
+	defer span.End()
+
+	logins, err := c.storer.QueryLogins(ctx, userID, page)
+	if err != nil {
+		return nil, fmt.Errorf("queryLogins: userID[%s]: %w", userID, err)
+	}
+
+	return logins, nil
+}
+
+// CountLogins returns the total number of logins of the user.
+func (c *Core) CountLogins(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.countLogins")
+	defer span.End()
+
+	return c.storer.CountLogins(ctx, userID)
+}
+
+// QueryPreferences retrieves the preferences of the user. A user who never
+// saved preferences gets the defaults.
+func (c *Core) QueryPreferences(ctx context.Context, userID uuid.UUID) (Preferences, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.queryPreferences")
+	defer span.End()
+
+	prefs, err := c.storer.QueryPreferences(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return DefaultPreferences(), nil
+		}
+		return Preferences{}, fmt.Errorf("queryPreferences: userID[%s]: %w", userID, err)
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences changes the informed preferences of the user and keeps
+// the others.
+func (c *Core) UpdatePreferences(ctx context.Context, userID uuid.UUID, up UpdatePreferences) (Preferences, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.updatePreferences")
+	defer span.End()
+
+	prefs, err := c.QueryPreferences(ctx, userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+
+	if up.Theme != nil {
+		prefs.Theme = *up.Theme
+	}
+
+	if up.Locale != nil {
+		prefs.Locale = *up.Locale
+	}
+
+	if up.DefaultDashboardID != nil {
+		prefs.DefaultDashboardID = *up.DefaultDashboardID
+	}
+
+	if up.NotifyEmail != nil {
+		prefs.Notifications.Email = *up.NotifyEmail
+	}
+
+	if up.NotifyInApp != nil {
+		prefs.Notifications.InApp = *up.NotifyInApp
+	}
+
+	prefs.UpdatedAt = time.Now()
+
+	if err := c.storer.SavePreferences(ctx, userID, prefs); err != nil {
+		return Preferences{}, fmt.Errorf("savePreferences: userID[%s]: %w", userID, err)
+	}
+
+	return prefs, nil
+}
+
+// RequestEmailChange keeps the new email as pending and issues the token that
+// confirms it. The token goes only to the new address, through the outbox, so
+// the change must run inside a transaction. A new request replaces the
+// previous one.
+func (c *Core) RequestEmailChange(ctx context.Context, usr User, email mail.Address) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.requestEmailChange")
+	defer span.End()
+
+	other, err := c.storer.QueryByEmail(ctx, usr.EmailScope, email)
+	switch {
+	case err == nil && other.ID != usr.ID:
+		return User{}, fmt.Errorf("query: email[%s]: %w", email.Address, ErrUniqueEmail)
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return User{}, fmt.Errorf("query: email[%s]: %w", email.Address, err)
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return User{}, fmt.Errorf("newToken: %w", err)
+	}
+
+	now := time.Now()
+
+	usr.PendingEmail = &email
+	usr.UpdatedAt = now
+
+	if err := c.storer.Update(ctx, usr, nil); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	ec := EmailChange{
+		UserID:    usr.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(EmailChangeTTL),
+	}
+
+	if err := c.storer.SaveEmailChange(ctx, ec); err != nil {
+		return User{}, fmt.Errorf("saveEmailChange: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(password)); err != nil {
-		return User{}, fmt.Errorf("compareHashAndPassword: %w", ErrAuthenticationFailure)
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeEmailChangeRequested,
+		AggregateID: usr.ID,
+		DedupKey:    outboxbus.TypeEmailChangeRequested + ":" + tokenHash,
+		Payload: outboxbus.EmailChangeRequested{
+			UserID:    usr.ID,
+			Email:     email.Address,
+			ExpiresAt: ec.ExpiresAt,
+		},
+		Secret: token,
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return User{}, fmt.Errorf("outbox: %w", err)
 	}
 
 	return usr, nil
 }
+
+// ConfirmEmailChange applies the pending email of the user who owns the
+// token. The token can be used only once. It must run inside a transaction.
+func (c *Core) ConfirmEmailChange(ctx context.Context, token string) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.confirmEmailChange")
+	defer span.End()
+
+	ec, err := c.storer.QueryEmailChange(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, ErrInvalidToken
+		}
+		return User{}, fmt.Errorf("queryEmailChange: %w", err)
+	}
+
+	if time.Now().After(ec.ExpiresAt) {
+		return User{}, ErrInvalidToken
+	}
+
+	usr, err := c.storer.QueryByID(ctx, ec.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, ErrInvalidToken
+		}
+		return User{}, fmt.Errorf("query: userID[%s]: %w", ec.UserID, err)
+	}
+
+	if usr.PendingEmail == nil {
+		return User{}, ErrInvalidToken
+	}
+
+	oldEmail := usr.Email
+
+	usr.Email = *usr.PendingEmail
+	usr.PendingEmail = nil
+	usr.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, usr, nil); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.storer.DeleteEmailChange(ctx, usr.ID); err != nil {
+		return User{}, fmt.Errorf("deleteEmailChange: %w", err)
+	}
+
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeEmailChanged,
+		AggregateID: usr.ID,
+		DedupKey:    outboxbus.TypeEmailChanged + ":" + ec.TokenHash,
+		Payload: outboxbus.EmailChanged{
+			UserID:   usr.ID,
+			OldEmail: oldEmail.Address,
+			NewEmail: usr.Email.Address,
+		},
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return User{}, fmt.Errorf("outbox: %w", err)
+	}
+
+	return usr, nil
+}
+
+// Register creates the user of a self registration. The user starts
+// disabled and the token that confirms the email goes to the address,
+// through the outbox, with the domain the user signed up through. It must
+// run inside a transaction.
+func (c *Core) Register(ctx context.Context, nu NewUser, domain string) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.register")
+	defer span.End()
+
+	usr, err := c.create(ctx, nu, false)
+	if err != nil {
+		return User{}, err
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return User{}, fmt.Errorf("newToken: %w", err)
+	}
+
+	reg := Registration{
+		UserID:    usr.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: usr.CreatedAt.Add(RegistrationTTL),
+	}
+
+	if err := c.storer.SaveRegistration(ctx, reg); err != nil {
+		return User{}, fmt.Errorf("saveRegistration: %w", err)
+	}
+
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeRegistrationRequested,
+		AggregateID: usr.ID,
+		DedupKey:    outboxbus.TypeRegistrationRequested + ":" + tokenHash,
+		Payload: outboxbus.RegistrationRequested{
+			UserID:    usr.ID,
+			Email:     usr.Email.Address,
+			Domain:    domain,
+			ExpiresAt: reg.ExpiresAt,
+		},
+		Secret: token,
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return User{}, fmt.Errorf("outbox: %w", err)
+	}
+
+	return usr, nil
+}
+
+// ConfirmRegistration enables the user who owns the token, proving the email
+// belongs to them. The token can be used only once. It must run inside a
+// transaction.
+func (c *Core) ConfirmRegistration(ctx context.Context, token string) (User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.confirmRegistration")
+	defer span.End()
+
+	reg, err := c.storer.QueryRegistration(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, ErrInvalidToken
+		}
+		return User{}, fmt.Errorf("queryRegistration: %w", err)
+	}
+
+	if time.Now().After(reg.ExpiresAt) {
+		return User{}, ErrInvalidToken
+	}
+
+	usr, err := c.storer.QueryByID(ctx, reg.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return User{}, ErrInvalidToken
+		}
+		return User{}, fmt.Errorf("query: userID[%s]: %w", reg.UserID, err)
+	}
+
+	usr.Enabled = true
+	usr.UpdatedAt = time.Now()
+
+	if err := c.storer.Update(ctx, usr, nil); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.storer.DeleteRegistration(ctx, usr.ID); err != nil {
+		return User{}, fmt.Errorf("deleteRegistration: %w", err)
+	}
+
+	ne := outboxbus.NewEvent{
+		Type:        outboxbus.TypeRegistrationConfirmed,
+		AggregateID: usr.ID,
+		DedupKey:    outboxbus.TypeRegistrationConfirmed + ":" + reg.TokenHash,
+		Payload: outboxbus.RegistrationConfirmed{
+			UserID: usr.ID,
+			Email:  usr.Email.Address,
+		},
+	}
+
+	if _, err := c.outboxBus.Add(ctx, ne); err != nil {
+		return User{}, fmt.Errorf("outbox: %w", err)
+	}
+
+	return usr, nil
+}
+
+// PurgeExpiredRegistrations removes the users who signed up and did not
+// confirm the email in time, so the address can be used again.
+func (c *Core) PurgeExpiredRegistrations(ctx context.Context) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.userbus.purgeExpiredRegistrations")
+	defer span.End()
+
+	n, err := c.storer.DeleteExpiredRegistrations(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleteExpiredRegistrations: %w", err)
+	}
+
+	return n, nil
+}
+
+// newToken generates a random token and the hash kept in the database.
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,453 @@
+package userbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/outboxbus/stores/outboxmemory"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus"
+	"github.com/jcpaschoal/spi-exata/business/domain/userbus/stores/usermemory"
+	"github.com/jcpaschoal/spi-exata/business/sdk/delegate"
+	"github.com/jcpaschoal/spi-exata/business/types/name"
+	"github.com/jcpaschoal/spi-exata/business/types/password"
+	"github.com/jcpaschoal/spi-exata/business/types/phone"
+	"github.com/jcpaschoal/spi-exata/business/types/role"
+	"github.com/jcpaschoal/spi-exata/foundation/crypto"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+)
+
+// fixture holds a core whose events are sealed into an in-memory outbox and
+// whose cascades are recorded.
+type fixture struct {
+	core      *userbus.Core
+	users     *usermemory.Store
+	outbox    *outboxbus.Core
+	store     *outboxmemory.Store
+	published *recorder
+	cascades  *[]delegate.Data
+}
+
+func TestAuthenticate(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	tenantID := uuid.New()
+
+	global := f.createUser(t, "ana@example.com", "global-pass", nil)
+	scoped := f.createUser(t, "ana@example.com", "scoped-pass", &tenantID)
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		email    string
+		password string
+		want     uuid.UUID
+		wantErr  error
+	}{
+		{name: "global", email: "ana@example.com", password: "global-pass", want: global.ID},
+		{name: "scopedFirst", tenantID: tenantID, email: "ana@example.com", password: "scoped-pass", want: scoped.ID},
+		{name: "scopedNoFallback", tenantID: tenantID, email: "ana@example.com", password: "global-pass", wantErr: userbus.ErrAuthenticationFailure},
+		{name: "otherTenant", tenantID: uuid.New(), email: "ana@example.com", password: "global-pass", want: global.ID},
+		{name: "wrongPassword", email: "ana@example.com", password: "wrong-pass", wantErr: userbus.ErrAuthenticationFailure},
+		{name: "unknownEmail", email: "nobody@example.com", password: "global-pass", wantErr: userbus.ErrAuthenticationFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.Authenticate(ctx, tt.tenantID, mail.Address{Address: tt.email}, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got.ID != tt.want {
+				t.Errorf("got user %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuthenticateRehash logs in a user whose hash has a cost other than the
+// cost of the hasher, as the users created by the tools have, and checks the
+// hash is replaced by one at the cost the dummy hash is made at.
+func TestAuthenticateRehash(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	usr := f.createUser(t, "ana@example.com", "secret-pass", nil)
+
+	hasher, err := passhash.New(passhash.Config{Cost: 5, Workers: 1})
+	if err != nil {
+		t.Fatalf("hasher: %s", err)
+	}
+
+	core := userbus.NewCore(f.users, f.outbox, delegate.New(logger.New(io.Discard, logger.LevelInfo, "TEST", nil)), hasher)
+
+	if _, err := core.Authenticate(ctx, uuid.Nil, mail.Address{Address: "ana@example.com"}, "secret-pass"); err != nil {
+		t.Fatalf("authenticate: %s", err)
+	}
+
+	got, err := core.QueryByID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("query: %s", err)
+	}
+
+	if hasher.NeedsRehash(got.PasswordHash) {
+		t.Errorf("got hash %s, want one at cost %d", got.PasswordHash[:7], hasher.Cost())
+	}
+
+	if !got.UpdatedAt.Equal(usr.UpdatedAt) {
+		t.Errorf("got updated at %s, want %s", got.UpdatedAt, usr.UpdatedAt)
+	}
+
+	if _, err := core.Authenticate(ctx, uuid.Nil, mail.Address{Address: "ana@example.com"}, "secret-pass"); err != nil {
+		t.Fatalf("authenticate with the new hash: %s", err)
+	}
+}
+
+func TestCreateUnique(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	tenantID := uuid.New()
+	f.createUser(t, "ana@example.com", "secret", nil)
+
+	tests := []struct {
+		name    string
+		email   string
+		scope   *uuid.UUID
+		wantErr error
+	}{
+		{name: "sameScope", email: "ana@example.com", wantErr: userbus.ErrUniqueEmail},
+		{name: "otherScope", email: "ana@example.com", scope: &tenantID},
+		{name: "otherEmail", email: "bia@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.core.Create(ctx, newUser(tt.email, "secret", tt.scope))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Só os três usuários criados geram o evento.
+	if n := len(f.store.Events()); n != 3 {
+		t.Errorf("got %d events, want 3", n)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	usr := f.createUser(t, "ana@example.com", "secret", nil)
+
+	stale := usr.UpdatedAt.Add(-time.Minute)
+	renamed := name.MustParse("Renamed")
+	analyst := role.Analyst
+	disabled := false
+
+	tests := []struct {
+		name         string
+		uu           userbus.UpdateUser
+		wantErr      error
+		wantEvent    string
+		wantCascades int
+	}{
+		{name: "stale", uu: userbus.UpdateUser{Name: &renamed, Version: &stale}, wantErr: userbus.ErrConflict},
+		{name: "name", uu: userbus.UpdateUser{Name: &renamed}},
+		{name: "role", uu: userbus.UpdateUser{Role: &analyst}, wantEvent: outboxbus.TypeRoleChanged},
+		{name: "disable", uu: userbus.UpdateUser{Enabled: &disabled}, wantCascades: 1},
+		{name: "disabledAgain", uu: userbus.UpdateUser{Enabled: &disabled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(f.store.Events())
+			*f.cascades = nil
+
+			got, err := f.core.Update(ctx, usr, tt.uu)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				usr = got
+			}
+
+			events := f.store.Events()[before:]
+			switch {
+			case tt.wantEvent == "" && len(events) != 0:
+				t.Errorf("got %d events, want none", len(events))
+			case tt.wantEvent != "" && (len(events) != 1 || events[0].Type != tt.wantEvent):
+				t.Errorf("got events %+v, want one %s", events, tt.wantEvent)
+			}
+
+			if len(*f.cascades) != tt.wantCascades {
+				t.Fatalf("got %d cascades, want %d", len(*f.cascades), tt.wantCascades)
+			}
+			for _, data := range *f.cascades {
+				assertCascade(t, data, userbus.ActionDisabled, usr.ID, false)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	usr := f.createUser(t, "ana@example.com", "secret", nil)
+
+	if err := f.core.Delete(ctx, usr); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+
+	if len(*f.cascades) != 1 {
+		t.Fatalf("got %d cascades, want 1", len(*f.cascades))
+	}
+	assertCascade(t, (*f.cascades)[0], userbus.ActionDeleted, usr.ID, true)
+
+	if _, err := f.core.QueryByID(ctx, usr.ID); !errors.Is(err, userbus.ErrNotFound) {
+		t.Fatalf("got error %v, want %v", err, userbus.ErrNotFound)
+	}
+
+	// O e-mail segue reservado enquanto o usuário pode ser restaurado.
+	if _, err := f.core.Create(ctx, newUser("ana@example.com", "secret", nil)); !errors.Is(err, userbus.ErrUniqueEmail) {
+		t.Fatalf("got error %v, want %v", err, userbus.ErrUniqueEmail)
+	}
+
+	if _, err := f.core.Restore(ctx, usr.ID); err != nil {
+		t.Fatalf("restore: %s", err)
+	}
+	if _, err := f.core.Authenticate(ctx, uuid.Nil, usr.Email, "secret"); err != nil {
+		t.Fatalf("authenticate restored: %s", err)
+	}
+}
+
+func TestEmailChange(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	usr := f.createUser(t, "ana@example.com", "secret", nil)
+	f.createUser(t, "bia@example.com", "secret", nil)
+
+	t.Run("taken", func(t *testing.T) {
+		_, err := f.core.RequestEmailChange(ctx, usr, mail.Address{Address: "bia@example.com"})
+		if !errors.Is(err, userbus.ErrUniqueEmail) {
+			t.Fatalf("got error %v, want %v", err, userbus.ErrUniqueEmail)
+		}
+	})
+
+	newEmail := mail.Address{Address: "ana.new@example.com"}
+
+	usr, err := f.core.RequestEmailChange(ctx, usr, newEmail)
+	if err != nil {
+		t.Fatalf("request: %s", err)
+	}
+	if usr.PendingEmail == nil || usr.PendingEmail.Address != newEmail.Address {
+		t.Fatalf("got pending email %v, want %s", usr.PendingEmail, newEmail.Address)
+	}
+
+	token := f.dispatchSecret(t, outboxbus.TypeEmailChangeRequested)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "unknown", token: "unknown", wantErr: userbus.ErrInvalidToken},
+		{name: "valid", token: token},
+		{name: "reused", token: token, wantErr: userbus.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.ConfirmEmailChange(ctx, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Email.Address != newEmail.Address || got.PendingEmail != nil) {
+				t.Errorf("got email %s pending %v, want %s", got.Email.Address, got.PendingEmail, newEmail.Address)
+			}
+		})
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	usr, err := f.core.Register(ctx, newUser("ana@example.com", "secret", nil), "main.example.com")
+	if err != nil {
+		t.Fatalf("register: %s", err)
+	}
+	if usr.Enabled {
+		t.Fatal("got an enabled user, want it disabled until the email is confirmed")
+	}
+
+	token := f.dispatchSecret(t, outboxbus.TypeRegistrationRequested)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "unknown", token: "unknown", wantErr: userbus.ErrInvalidToken},
+		{name: "valid", token: token},
+		{name: "reused", token: token, wantErr: userbus.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.core.ConfirmRegistration(ctx, tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.ID != usr.ID || !got.Enabled) {
+				t.Errorf("got user %s enabled %t, want %s enabled", got.ID, got.Enabled, usr.ID)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// recorder is a publisher that keeps the events it receives.
+type recorder struct {
+	events []outboxbus.Event
+}
+
+func (r *recorder) Publish(ctx context.Context, e outboxbus.Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	box, err := crypto.NewBox(make([]byte, crypto.KeySize))
+	if err != nil {
+		t.Fatalf("box: %s", err)
+	}
+
+	hasher, err := passhash.New(passhash.Config{Cost: 4, Workers: 1})
+	if err != nil {
+		t.Fatalf("hasher: %s", err)
+	}
+
+	var cascades []delegate.Data
+	record := func(ctx context.Context, data delegate.Data) error {
+		cascades = append(cascades, data)
+		return nil
+	}
+
+	dlg := delegate.New(log)
+	dlg.Register(userbus.DomainName, userbus.ActionDisabled, record)
+	dlg.Register(userbus.DomainName, userbus.ActionDeleted, record)
+
+	store := outboxmemory.NewStore()
+	outbox := outboxbus.NewCore(log, store, box)
+
+	users := usermemory.NewStore()
+
+	f := fixture{
+		core:      userbus.NewCore(users, outbox, dlg, hasher),
+		users:     users,
+		outbox:    outbox,
+		store:     store,
+		published: &recorder{},
+		cascades:  &cascades,
+	}
+
+	return f
+}
+
+func newUser(email string, pass string, scope *uuid.UUID) userbus.NewUser {
+	return userbus.NewUser{
+		Name:       name.MustParse("Test User"),
+		Email:      mail.Address{Address: email},
+		Phone:      phone.MustParseNull(""),
+		Role:       role.User,
+		Password:   password.MustParse(pass),
+		EmailScope: scope,
+	}
+}
+
+func (f fixture) createUser(t *testing.T, email string, pass string, scope *uuid.UUID) userbus.User {
+	t.Helper()
+
+	usr, err := f.core.Create(context.Background(), newUser(email, pass, scope))
+	if err != nil {
+		t.Fatalf("create %s: %s", email, err)
+	}
+
+	return usr
+}
+
+// dispatchSecret publishes the pending events and returns the opened secret
+// of the last event of the type. The secret never reaches the payload, and
+// the store erases it once the event is published.
+func (f fixture) dispatchSecret(t *testing.T, eventType string) string {
+	t.Helper()
+
+	if _, err := f.outbox.DispatchAll(context.Background(), f.published); err != nil {
+		t.Fatalf("dispatch: %s", err)
+	}
+
+	var secret string
+	for _, e := range f.published.events {
+		if e.Type != eventType {
+			continue
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			t.Fatalf("unmarshal: %s", err)
+		}
+		if _, exists := payload["token"]; exists {
+			t.Fatalf("got the token in the payload of %s", e.Type)
+		}
+
+		secret = string(e.Secret)
+	}
+
+	if secret == "" {
+		t.Fatalf("got no secret for %s", eventType)
+	}
+
+	for _, e := range f.store.Events() {
+		if len(e.Secret) != 0 {
+			t.Fatalf("got the secret of %s kept after publishing", e.Type)
+		}
+	}
+
+	return secret
+}
+
+func assertCascade(t *testing.T, data delegate.Data, action string, userID uuid.UUID, removeAccess bool) {
+	t.Helper()
+
+	params, err := userbus.ParseActionParams(data.RawParams)
+	if err != nil {
+		t.Fatalf("parse params: %s", err)
+	}
+
+	switch {
+	case data.Action != action:
+		t.Errorf("got action %s, want %s", data.Action, action)
+	case params.UserID != userID:
+		t.Errorf("got user %s, want %s", params.UserID, userID)
+	case params.RemoveDashboardAccess != removeAccess:
+		t.Errorf("got remove access %t, want %t", params.RemoveDashboardAccess, removeAccess)
+	}
+}
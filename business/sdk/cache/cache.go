@@ -0,0 +1,197 @@
+// Package cache provides a read-through cache for values that are costly to
+// compute, like the queries run against the databases of the clients. The
+// values live in a Backend, in the memory of the instance or in Redis, with
+// a TTL per key and tags to drop groups of keys at once. Concurrent misses
+// of the same key share a single fetch.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrMiss is returned by a backend when the key is not cached.
+var ErrMiss = errors.New("cache: miss")
+
+// Backend stores the encoded values.
+type Backend interface {
+
+	// Get returns the value of the key or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value for ttl and adds the key to the tags.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error
+
+	// Invalidate drops the keys of the tags and returns how many existed.
+	Invalidate(ctx context.Context, tags ...string) (int, error)
+
+	// Len returns the number of keys kept, or zero when the backend can't
+	// tell cheaply.
+	Len() int
+}
+
+// Option changes how a single value is cached.
+type Option func(o *options)
+
+type options struct {
+	ttl  time.Duration
+	tags []string
+}
+
+// WithTTL overrides the TTL of the cache for the value.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithTags adds the value to the tags, dropped together by Invalidate.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Cache keeps values of type T in a backend. The values are stored as JSON,
+// so the types of T must survive a round trip; numbers decoded into an
+// interface are kept as json.Number to preserve their precision.
+type Cache[T any] struct {
+	log     *logger.Logger
+	backend Backend
+	prefix  string
+	ttl     time.Duration
+	group   *singleflight.Group
+	stats   *cachestats.Recorder
+}
+
+// New constructs a cache over the backend. The name namespaces the keys and
+// tags, so caches of different types can share a backend; ttl is the
+// default lifetime of the values.
+func New[T any](log *logger.Logger, backend Backend, name string, ttl time.Duration) *Cache[T] {
+	return &Cache[T]{
+		log:     log,
+		backend: backend,
+		prefix:  "spi:cache:" + name + ":",
+		ttl:     ttl,
+		group:   &singleflight.Group{},
+		stats:   cachestats.NewRecorder(),
+	}
+}
+
+// GetOrFetch returns the cached value of the key or calls fetch and caches
+// what it returns. The bool reports the value did not come from this call's
+// fetch: it was cached or shared by a concurrent call. Errors of fetch are
+// never cached. Backend failures are logged and handled as a miss, so the
+// source still answers when the cache is down.
+func (c *Cache[T]) GetOrFetch(ctx context.Context, key string, fetch func(ctx context.Context) (T, error), opts ...Option) (T, bool, error) {
+	o := options{ttl: c.ttl}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	key = c.prefix + key
+
+	if v, ok := c.read(ctx, key); ok {
+		c.stats.CacheHit()
+		return v, true, nil
+	}
+
+	c.stats.CacheMiss()
+
+	res, err, shared := c.group.Do(key, func() (any, error) {
+		v, err := fetch(ctx)
+		if err != nil {
+			return v, err
+		}
+
+		c.write(ctx, key, v, o)
+
+		return v, nil
+	})
+
+	v, _ := res.(T)
+	if err != nil {
+		return v, false, err
+	}
+
+	return v, shared, nil
+}
+
+// Invalidate drops every value added to the tags.
+func (c *Cache[T]) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	n, err := c.backend.Invalidate(ctx, c.tags(tags)...)
+	if err != nil {
+		return fmt.Errorf("invalidate: %w", err)
+	}
+
+	c.stats.Invalidated(n)
+
+	return nil
+}
+
+// Stats returns the runtime statistics of the cache. The entries are those
+// of the whole backend.
+func (c *Cache[T]) Stats() cachestats.Stats {
+	return c.stats.Stats(c.backend.Len())
+}
+
+// =============================================================================
+
+func (c *Cache[T]) read(ctx context.Context, key string) (T, bool) {
+	var v T
+
+	data, err := c.backend.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			c.log.Error(ctx, "cache", "status", "read", "key", key, "ERROR", err)
+		}
+		return v, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		c.log.Error(ctx, "cache", "status", "decode", "key", key, "ERROR", err)
+		return v, false
+	}
+
+	return v, true
+}
+
+func (c *Cache[T]) write(ctx context.Context, key string, v T, o options) {
+	if o.ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		c.log.Error(ctx, "cache", "status", "encode", "key", key, "ERROR", err)
+		return
+	}
+
+	if err := c.backend.Set(ctx, key, data, o.ttl, c.tags(o.tags)); err != nil {
+		c.log.Error(ctx, "cache", "status", "write", "key", key, "ERROR", err)
+	}
+}
+
+func (c *Cache[T]) tags(tags []string) []string {
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		out[i] = c.prefix + "tag:" + tag
+	}
+
+	return out
+}
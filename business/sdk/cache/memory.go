@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps the values in the memory of the instance. When full, the
+// expired values are dropped first and then the oldest ones.
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]memoryItem
+	tags     map[string]map[string]struct{}
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// NewMemory constructs a backend holding up to capacity values.
+func NewMemory(capacity int) *Memory {
+	return &Memory{
+		capacity: capacity,
+		items:    make(map[string]memoryItem),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+// Get implements the Backend interface.
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, exists := m.items[key]
+	if !exists {
+		return nil, ErrMiss
+	}
+
+	if time.Now().After(item.expires) {
+		m.remove(key)
+		return nil, ErrMiss
+	}
+
+	return item.value, nil
+}
+
+// Set implements the Backend interface.
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(key)
+
+	if len(m.items) >= m.capacity {
+		m.evict()
+	}
+
+	m.items[key] = memoryItem{
+		value:   value,
+		expires: time.Now().Add(ttl),
+		tags:    tags,
+	}
+
+	for _, tag := range tags {
+		keys, exists := m.tags[tag]
+		if !exists {
+			keys = make(map[string]struct{})
+			m.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// Invalidate implements the Backend interface.
+func (m *Memory) Invalidate(ctx context.Context, tags ...string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if _, exists := m.items[key]; exists {
+				m.remove(key)
+				n++
+			}
+		}
+		delete(m.tags, tag)
+	}
+
+	return n, nil
+}
+
+// Len implements the Backend interface.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.items)
+}
+
+// remove drops the key and its tag references. The caller holds the lock.
+func (m *Memory) remove(key string) {
+	item, exists := m.items[key]
+	if !exists {
+		return
+	}
+
+	delete(m.items, key)
+
+	for _, tag := range item.tags {
+		delete(m.tags[tag], key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
+
+// evict makes room for one value. The caller holds the lock.
+func (m *Memory) evict() {
+	now := time.Now()
+
+	var oldest string
+	var oldestExpires time.Time
+
+	for key, item := range m.items {
+		if now.After(item.expires) {
+			m.remove(key)
+			continue
+		}
+
+		if oldest == "" || item.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, item.expires
+		}
+	}
+
+	if len(m.items) >= m.capacity && oldest != "" {
+		m.remove(oldest)
+	}
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+)
+
+// Redis keeps the values in Redis, shared by every instance. Each tag is a
+// set holding its keys; the set lives as long as its longest lived key.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis constructs a backend over the client.
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{
+		client: client,
+	}
+}
+
+// Get implements the Backend interface.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return nil, ErrMiss
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Set implements the Backend interface.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags []string) error {
+	if err := r.client.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if _, err := r.client.Do(ctx, "SADD", tag, key); err != nil {
+			return fmt.Errorf("tag %s: %w", tag, err)
+		}
+
+		if err := r.extend(ctx, tag, ttl); err != nil {
+			return fmt.Errorf("tag %s: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Invalidate implements the Backend interface.
+func (r *Redis) Invalidate(ctx context.Context, tags ...string) (int, error) {
+	var n int
+
+	for _, tag := range tags {
+		v, err := r.client.Do(ctx, "SMEMBERS", tag)
+		if err != nil {
+			return n, fmt.Errorf("tag %s: %w", tag, err)
+		}
+
+		members, _ := v.([]any)
+
+		keys := make([]string, 0, len(members))
+		for _, m := range members {
+			if b, ok := m.([]byte); ok {
+				keys = append(keys, string(b))
+			}
+		}
+
+		deleted, err := r.client.Del(ctx, keys...)
+		if err != nil {
+			return n, fmt.Errorf("tag %s: %w", tag, err)
+		}
+		n += int(deleted)
+
+		if _, err := r.client.Del(ctx, tag); err != nil {
+			return n, fmt.Errorf("tag %s: %w", tag, err)
+		}
+	}
+
+	return n, nil
+}
+
+// Len implements the Backend interface. The keys are shared with other
+// caches in Redis, so they are not counted.
+func (r *Redis) Len() int {
+	return 0
+}
+
+// extend makes the tag live at least for ttl.
+func (r *Redis) extend(ctx context.Context, tag string, ttl time.Duration) error {
+	v, err := r.client.Do(ctx, "PTTL", tag)
+	if err != nil {
+		return err
+	}
+
+	// Após o SADD uma tag nova tem PTTL -1, sem expiração, e cai aqui.
+	if left, ok := v.(int64); ok && left >= ttl.Milliseconds() {
+		return nil
+	}
+
+	_, err = r.client.Do(ctx, "PEXPIRE", tag, strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
@@ -0,0 +1,165 @@
+// Package cachestats collects the runtime statistics of the in-memory caches
+// and publishes them through expvar, so the operators can tell a cache that
+// works from one that keeps being invalidated and refilled.
+package cachestats
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats represents the statistics of a cache. Invalidations count the keys
+// dropped because the data changed, usually announced by the database, and
+// Flushes count the times the whole cache was dropped at once. Loads count
+// the misses filled from the store, with the average and slowest time spent
+// reading them in milliseconds.
+type Stats struct {
+	Entries       int     `json:"entries"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRatio      float64 `json:"hitRatio"`
+	Evictions     int64   `json:"evictions"`
+	Invalidations int64   `json:"invalidations"`
+	Flushes       int64   `json:"flushes"`
+	Loads         int64   `json:"loads"`
+	LoadAvgMs     float64 `json:"loadAvgMs"`
+	LoadMaxMs     float64 `json:"loadMaxMs"`
+}
+
+// Recorder counts the operations of a cache. It implements the
+// sturdyc.MetricsRecorder interface.
+type Recorder struct {
+	hits          atomic.Int64
+	misses        atomic.Int64
+	evictions     atomic.Int64
+	invalidations atomic.Int64
+	flushes       atomic.Int64
+	loads         atomic.Int64
+	loadTotal     atomic.Int64
+	loadMax       atomic.Int64
+}
+
+// NewRecorder constructs a recorder with every counter at zero.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Invalidated counts keys dropped because the data changed.
+func (r *Recorder) Invalidated(n int) {
+	r.invalidations.Add(int64(n))
+}
+
+// Flushed counts the whole cache being dropped at once.
+func (r *Recorder) Flushed() {
+	r.flushes.Add(1)
+}
+
+// Loaded records the time spent reading a missing entry from the store
+// before filling the cache with it.
+func (r *Recorder) Loaded(d time.Duration) {
+	r.loads.Add(1)
+	r.loadTotal.Add(int64(d))
+
+	for {
+		cur := r.loadMax.Load()
+		if int64(d) <= cur || r.loadMax.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+// Stats returns the counters along with the number of entries of the cache.
+func (r *Recorder) Stats(entries int) Stats {
+	s := Stats{
+		Entries:       entries,
+		Hits:          r.hits.Load(),
+		Misses:        r.misses.Load(),
+		Evictions:     r.evictions.Load(),
+		Invalidations: r.invalidations.Load(),
+		Flushes:       r.flushes.Load(),
+		Loads:         r.loads.Load(),
+		LoadMaxMs:     milliseconds(r.loadMax.Load()),
+	}
+
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRatio = float64(s.Hits) / float64(total)
+	}
+
+	if s.Loads > 0 {
+		s.LoadAvgMs = milliseconds(r.loadTotal.Load() / s.Loads)
+	}
+
+	return s
+}
+
+// milliseconds converts the nanoseconds of a duration to milliseconds.
+func milliseconds(ns int64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
+
+// CacheHit implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) CacheHit() { r.hits.Add(1) }
+
+// CacheMiss implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) CacheMiss() { r.misses.Add(1) }
+
+// ForcedEviction implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) ForcedEviction() { r.evictions.Add(1) }
+
+// EntriesEvicted implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) EntriesEvicted(n int) { r.evictions.Add(int64(n)) }
+
+// AsynchronousRefresh implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) AsynchronousRefresh() {}
+
+// SynchronousRefresh implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) SynchronousRefresh() {}
+
+// MissingRecord implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) MissingRecord() {}
+
+// ShardIndex implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) ShardIndex(int) {}
+
+// CacheBatchRefreshSize implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) CacheBatchRefreshSize(int) {}
+
+// ObserveCacheSize implements the sturdyc.MetricsRecorder interface.
+func (r *Recorder) ObserveCacheSize(func() int) {}
+
+// =============================================================================
+
+var (
+	mu     sync.RWMutex
+	caches = make(map[string]func() any)
+)
+
+func init() {
+	expvar.Publish("caches", expvar.Func(func() any {
+		return Snapshot()
+	}))
+}
+
+// Register adds the statistics of a cache under the name, replacing any
+// cache registered before with the same name.
+func Register(name string, fn func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	caches[name] = fn
+}
+
+// Snapshot returns the statistics of every registered cache.
+func Snapshot() map[string]any {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make(map[string]any, len(caches))
+	for name, fn := range caches {
+		out[name] = fn()
+	}
+
+	return out
+}
@@ -0,0 +1,143 @@
+// Package dbtest contains supporting code for running tests that hit the DB.
+// It connects to the Postgres started by docker compose (or any server set in
+// the DBTEST_* variables), creates a database for every test, applies the
+// migrations and the reference data, and drops the database at the end.
+//
+// Each test gets a database instead of a schema because the migrations
+// qualify every object with the "public" schema.
+package dbtest
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb/migrate"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed seed.sql
+var seed string
+
+// Database owns the state for running tests against an isolated database.
+type Database struct {
+	DB  *sqlx.DB
+	Log *logger.Logger
+}
+
+// New creates a database for the test with the schema and reference data in
+// place. The test is skipped when no database server is reachable, so unit
+// test runs do not depend on docker. Benchmarks use it as well.
+func New(t testing.TB, testName string) *Database {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cfg := sqldb.Config{
+		User:       env("DBTEST_USER", "postgres"),
+		Password:   env("DBTEST_PASSWORD", "postgres"),
+		Host:       env("DBTEST_HOST", "localhost:5432"),
+		Name:       env("DBTEST_NAME", "postgres"),
+		DisableTLS: true,
+	}
+
+	admin, err := sqldb.Open(cfg)
+	if err != nil {
+		t.Fatalf("opening database connection: %s", err)
+	}
+	defer admin.Close()
+
+	// Sem servidor o teste é pulado logo, sem esperar o prazo todo.
+	statusCtx, statusCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer statusCancel()
+
+	if err := sqldb.StatusCheck(statusCtx, admin); err != nil {
+		t.Skipf("database not available at %s: %s", cfg.Host, err)
+	}
+
+	dbName := databaseName(testName)
+
+	if _, err := admin.ExecContext(ctx, fmt.Sprintf(`CREATE DATABASE %q`, dbName)); err != nil {
+		t.Fatalf("creating database %s: %s", dbName, err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		admin, err := sqldb.Open(cfg)
+		if err != nil {
+			t.Logf("opening database connection to drop %s: %s", dbName, err)
+			return
+		}
+		defer admin.Close()
+
+		if _, err := admin.ExecContext(ctx, fmt.Sprintf(`DROP DATABASE IF EXISTS %q WITH (FORCE)`, dbName)); err != nil {
+			t.Logf("dropping database %s: %s", dbName, err)
+		}
+	})
+
+	cfg.Name = dbName
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		t.Fatalf("opening database %s: %s", dbName, err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	if _, err := migrate.Migrate(ctx, db); err != nil {
+		t.Fatalf("migrating database %s: %s", dbName, err)
+	}
+
+	if _, err := db.ExecContext(ctx, seed); err != nil {
+		t.Fatalf("seeding database %s: %s", dbName, err)
+	}
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", nil)
+
+	// Os logs só são mostrados quando o teste falha.
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(buf.String())
+		}
+	})
+
+	return &Database{
+		DB:  db,
+		Log: log,
+	}
+}
+
+var invalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// databaseName builds a unique, valid database name for the test.
+func databaseName(testName string) string {
+	name := invalidChars.ReplaceAllString(strings.ToLower(testName), "_")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	return fmt.Sprintf("test_%s_%08x", name, rand.Uint32())
+}
+
+func env(key string, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
@@ -0,0 +1,21 @@
+-- Dados de referência mínimos para os testes: perfis, tipos de recurso e
+-- políticas por perfil. Mantido em sincronia com a seção 1 do seed.sql.
+
+INSERT INTO "public"."role" ("role_id", "name") VALUES
+    (1, 'ADMIN'),
+    (2, 'USER'),
+    (3, 'ANALYST'),
+    (4, 'TENANT_ADMIN');
+
+INSERT INTO "public"."resource_type" ("resource_type_id", "name") VALUES
+    (1, 'DASHBOARD'),
+    (2, 'PAGE'),
+    (3, 'SUBJECT');
+
+INSERT INTO "public"."role_policy" ("role_id", "resource_type_id", "actions") VALUES
+    (1, 1, '{CREATE,DELETE,UPDATE,GET}'),
+    (1, 2, '{CREATE,DELETE,UPDATE,GET}'),
+    (1, 3, '{CREATE,DELETE,UPDATE,GET}'),
+    (3, 1, '{UPDATE,GET}'),
+    (3, 2, '{UPDATE,GET}'),
+    (3, 3, '{UPDATE,GET}');
@@ -0,0 +1,72 @@
+// Package delegate lets a domain notify other domains of a change without
+// importing them. The domain that owns the change calls the delegate and the
+// interested domains register the functions that react to it.
+package delegate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+// Data represents the change being delegated.
+type Data struct {
+	Domain    string
+	Action    string
+	RawParams []byte
+
+	// Tx is the transaction the change runs in, nil when there is none. The
+	// registered functions join it so the whole cascade commits or rolls back
+	// together with the change.
+	Tx sqldb.CommitRollbacker
+}
+
+// Func represents a function that reacts to a delegated change.
+type Func func(ctx context.Context, data Data) error
+
+// Delegate manages the set of functions registered for each domain action.
+type Delegate struct {
+	log   *logger.Logger
+	funcs map[string]map[string][]Func
+}
+
+// New constructs a delegate with no registered functions.
+func New(log *logger.Logger) *Delegate {
+	return &Delegate{
+		log:   log,
+		funcs: make(map[string]map[string][]Func),
+	}
+}
+
+// Register adds a function to be called for the specified domain and action.
+// Functions must be registered during startup, before any call is made.
+func (d *Delegate) Register(domain string, action string, fn Func) {
+	actions, exists := d.funcs[domain]
+	if !exists {
+		actions = make(map[string][]Func)
+		d.funcs[domain] = actions
+	}
+
+	actions[action] = append(actions[action], fn)
+}
+
+// Call executes the functions registered for the domain and action of the
+// data, in the order they were registered. The first error stops the cascade
+// and is returned, so the caller can roll the change back.
+func (d *Delegate) Call(ctx context.Context, data Data) error {
+	ctx, span := otel.AddSpan(ctx, "business.sdk.delegate.call")
+	defer span.End()
+
+	for _, fn := range d.funcs[data.Domain][data.Action] {
+		d.log.Debug(ctx, "delegate call", "domain", data.Domain, "action", data.Action)
+
+		if err := fn(ctx, data); err != nil {
+			return fmt.Errorf("%s.%s: %w", data.Domain, data.Action, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package page
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/order"
+)
+
+// Cursor marks the last row of a page for keyset paging. The next page
+// starts right after the row, so rows written meanwhile don't shift the
+// results like an offset does.
+type Cursor struct {
+	OrderBy order.By `json:"o"`
+
+	// Values holds the values of the last row for the order by field and, as
+	// the tiebreaker, the unique key of the row.
+	Values []string `json:"v"`
+}
+
+// NewCursor constructs the opaque cursor of a row. The values must follow the
+// order by field and end with the unique key of the row.
+func NewCursor(orderBy order.By, values ...string) string {
+	data, err := json.Marshal(Cursor{OrderBy: orderBy, Values: values})
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor parses the opaque cursor and the rows per page. The cursor is
+// only valid for the same order it was created with.
+func ParseCursor(cursor string, rowsPerPage string, orderBy order.By) (Page, error) {
+	p, err := Parse("", rowsPerPage)
+	if err != nil {
+		return Page{}, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Page{}, fmt.Errorf("invalid cursor")
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) == 0 {
+		return Page{}, fmt.Errorf("invalid cursor")
+	}
+
+	if c.OrderBy != orderBy {
+		return Page{}, fmt.Errorf("cursor does not match the order")
+	}
+
+	p.cursor = &c
+
+	return p, nil
+}
+
+// Cursor returns the cursor the page starts after, if the page uses keyset
+// paging.
+func (p Page) Cursor() (Cursor, bool) {
+	if p.cursor == nil {
+		return Cursor{}, false
+	}
+
+	return Cursor{OrderBy: p.cursor.OrderBy, Values: slices.Clone(p.cursor.Values)}, true
+}
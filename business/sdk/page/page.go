@@ -6,10 +6,12 @@ import (
 	"strconv"
 )
 
-// Page represents the requested page and rows per page.
+// Page represents the requested page and rows per page. A page parsed from a
+// cursor starts after the cursor instead of at an offset.
 type Page struct {
 	number int
 	rows   int
+	cursor *Cursor
 }
 
 // Parse parses the strings and validates the values are in reason.
@@ -64,6 +66,10 @@ func MustParse(page string, rowsPerPage string) Page {
 
 // String implements the stringer interface.
 func (p Page) String() string {
+	if p.cursor != nil {
+		return fmt.Sprintf("cursor: %v rows: %d", p.cursor.Values, p.rows)
+	}
+
 	return fmt.Sprintf("page: %d rows: %d", p.number, p.rows)
 }
 
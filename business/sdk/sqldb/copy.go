@@ -0,0 +1,134 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CopyChunkSize is the number of rows sent by each COPY of CopyFrom.
+const CopyChunkSize = 5000
+
+// ErrCopyInTransaction is returned when CopyFrom is called with a transaction.
+// The COPY protocol is only reachable through a connection of the pool.
+var ErrCopyInTransaction = errors.New("copy cannot run inside a database/sql transaction")
+
+// CopyError reports the row of the batch that made the COPY fail. It wraps
+// the database error, so errors.Is still matches ErrDBDuplicatedEntry.
+type CopyError struct {
+	Row int
+	Err error
+}
+
+func (e *CopyError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+func (e *CopyError) Unwrap() error {
+	return e.Err
+}
+
+// CopyFrom inserts the rows into the table, e.g. "public.audit_log", with
+// the Postgres COPY protocol, CopyChunkSize rows at a time. Every chunk runs
+// in the same transaction, so either all the rows are inserted or none.
+//
+// The values of each row follow the order of columns. A failure caused by a
+// row, like a unique violation, is returned as a *CopyError.
+func CopyFrom(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, table string, columns []string, rows [][]any) (n int64, err error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+
+	defer func() {
+		if err != nil {
+			log.Infoc(ctx, 5, "database.CopyFrom", "table", table, "rows", len(rows), "ERROR", err)
+		}
+
+		logSlow(ctx, log, 6, "database.CopyFrom", "COPY "+table, start)
+	}()
+
+	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.copy", attribute.String("table", table), attribute.Int("rows", len(rows)))
+	defer span.End()
+
+	var sdb *sqlx.DB
+	switch v := db.(type) {
+	case *sqlx.DB:
+		sdb = v
+	case *Router:
+		sdb = v.primary
+	default:
+		return 0, ErrCopyInTransaction
+	}
+
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	ident := pgx.Identifier(strings.Split(table, "."))
+
+	err = WithConn(ctx, sdb, func(conn *pgx.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("begin: %w", err)
+		}
+		defer tx.Rollback(ctx)
+
+		for i := 0; i < len(rows); i += CopyChunkSize {
+			chunk := rows[i:min(i+CopyChunkSize, len(rows))]
+
+			copied, err := tx.CopyFrom(ctx, ident, columns, pgx.CopyFromRows(chunk))
+			if err != nil {
+				return toCopyError(err, i)
+			}
+			n += copied
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// copyLine matches the line Postgres reports in the context of a COPY
+// error, e.g. "COPY audit_log, line 3".
+var copyLine = regexp.MustCompile(`COPY [^,]+, line (\d+)`)
+
+// toCopyError maps the error to the package errors and, when Postgres tells
+// which line of the chunk failed, to the row of the whole batch.
+func toCopyError(err error, offset int) error {
+	dbErr := toDBError(err)
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return dbErr
+	}
+
+	m := copyLine.FindStringSubmatch(pgErr.Where)
+	if m == nil {
+		return dbErr
+	}
+
+	line, _ := strconv.Atoi(m[1])
+
+	return &CopyError{Row: offset + line - 1, Err: dbErr}
+}
@@ -0,0 +1,54 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Listen subscribes to the Postgres NOTIFY channel and calls fn with the
+// payload of every notification received. A dedicated connection is taken
+// from the pool for the subscription and it is re-established on failure.
+// Listen blocks until the context is cancelled.
+func Listen(ctx context.Context, log *logger.Logger, db *sqlx.DB, channel string, fn func(payload string)) {
+	const maxBackoff = 30 * time.Second
+	backoff := time.Second
+
+	for {
+		err := listen(ctx, db, channel, fn)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Error(ctx, "sqldb: listen", "channel", channel, "err", err, "retry", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func listen(ctx context.Context, db *sqlx.DB, channel string, fn func(payload string)) error {
+	return WithConn(ctx, db, func(pc *pgx.Conn) error {
+		if _, err := pc.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("wait: %w", err)
+			}
+
+			fn(n.Payload)
+		}
+	})
+}
@@ -0,0 +1,343 @@
+// Package migrate contains the database schema, migrations and the support
+// to apply or roll them back. Migrations are SQL files embedded in the binary,
+// named <version>_<description>.sql, with "-- +goose Up" and "-- +goose Down"
+// sections.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID is the key of the advisory lock held while migrating, so several
+// instances starting at the same time do not apply the same migration.
+const lockID = 7_172_026
+
+// ErrNoMigration is returned by Rollback when nothing has been applied.
+var ErrNoMigration = errors.New("no migration to roll back")
+
+// Migration is a single versioned change to the schema.
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// Migrate applies every migration that is not yet recorded in the database,
+// each in its own transaction, and returns the ones applied.
+func Migrate(ctx context.Context, db *sqlx.DB) ([]Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := versions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if current[m.Version] {
+				continue
+			}
+
+			const q = `INSERT INTO "public"."schema_migrations" (version, name) VALUES ($1, $2)`
+			if err := execTx(ctx, conn, m.up, q, m.Version, m.Name); err != nil {
+				return fmt.Errorf("migrate: version[%d] name[%s]: %w", m.Version, m.Name, err)
+			}
+
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Rollback reverts the most recent migration applied to the database and
+// returns it.
+func Rollback(ctx context.Context, db *sqlx.DB) (Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return Migration{}, err
+	}
+
+	var rolled Migration
+
+	err = withLock(ctx, db, func(conn *sql.Conn) error {
+		current, err := versions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if !current[m.Version] {
+				continue
+			}
+
+			const q = `DELETE FROM "public"."schema_migrations" WHERE version = $1`
+			if err := execTx(ctx, conn, m.down, q, m.Version); err != nil {
+				return fmt.Errorf("rollback: version[%d] name[%s]: %w", m.Version, m.Name, err)
+			}
+
+			rolled = m
+			return nil
+		}
+
+		return ErrNoMigration
+	})
+
+	return rolled, err
+}
+
+// Pending returns the migrations Migrate would apply, without changing the
+// database.
+func Pending(ctx context.Context, db *sqlx.DB) ([]Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !current[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+// Latest returns the migration Rollback would revert, without changing the
+// database.
+func Latest(ctx context.Context, db *sqlx.DB) (Migration, error) {
+	migrations, err := load()
+	if err != nil {
+		return Migration{}, err
+	}
+
+	current, err := appliedVersions(ctx, db)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if current[migrations[i].Version] {
+			return migrations[i], nil
+		}
+	}
+
+	return Migration{}, ErrNoMigration
+}
+
+// =============================================================================
+
+// withLock runs fn on a single connection holding the migration lock, after
+// making sure the table tracking the applied versions exists.
+func withLock(ctx context.Context, db *sqlx.DB, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	const q = `
+	CREATE TABLE IF NOT EXISTS "public"."schema_migrations" (
+		version    bigint NOT NULL PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`
+
+	if _, err := conn.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// versions returns the set of versions already applied.
+func versions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM "public"."schema_migrations"`)
+	if err != nil {
+		return nil, fmt.Errorf("query versions: %w", err)
+	}
+	defer rows.Close()
+
+	current := make(map[int]bool)
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("scan version: %w", err)
+		}
+		current[v] = true
+	}
+
+	return current, rows.Err()
+}
+
+// appliedVersions reads the applied versions without the lock and without
+// creating the tracking table, which is missing on a new database.
+func appliedVersions(ctx context.Context, db *sqlx.DB) (map[int]bool, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('"public"."schema_migrations"') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check schema_migrations: %w", err)
+	}
+
+	if !exists {
+		return map[int]bool{}, nil
+	}
+
+	return versions(ctx, conn)
+}
+
+// execTx runs the migration script and the bookkeeping statement in the same
+// transaction. The script has no arguments so it is sent with the simple
+// protocol, which allows several statements at once.
+func execTx(ctx context.Context, conn *sql.Conn, script string, q string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Migrações podem demorar mais que o limite configurado para a API.
+	if _, err := tx.ExecContext(ctx, `SET LOCAL statement_timeout = 0`); err != nil {
+		return fmt.Errorf("statement timeout: %w", err)
+	}
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("exec: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// load reads and parses every embedded migration ordered by version.
+func load() ([]Migration, error) {
+	names, err := fs.Glob(files, "sql/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int]string)
+
+	for _, name := range names {
+		m, err := parse(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, exists := seen[m.Version]; exists {
+			return nil, fmt.Errorf("migration version %d used by %s and %s", m.Version, other, name)
+		}
+		seen[m.Version] = name
+
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parse splits a migration file into its up and down sections.
+func parse(name string) (Migration, error) {
+	base := strings.TrimSuffix(path.Base(name), ".sql")
+
+	num, desc, _ := strings.Cut(base, "_")
+	version, err := strconv.Atoi(num)
+	if err != nil {
+		return Migration{}, fmt.Errorf("migration %s: name must start with a version number", name)
+	}
+
+	data, err := files.ReadFile(name)
+	if err != nil {
+		return Migration{}, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	var up, down strings.Builder
+	var section *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &up
+			continue
+		case "-- +goose Down":
+			section = &down
+			continue
+		}
+
+		if section != nil {
+			section.WriteString(line)
+			section.WriteByte('\n')
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Migration{}, fmt.Errorf("scan %s: %w", name, err)
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return Migration{}, fmt.Errorf("migration %s: missing -- +goose Up section", name)
+	}
+
+	m := Migration{
+		Version: version,
+		Name:    desc,
+		up:      up.String(),
+		down:    down.String(),
+	}
+
+	return m, nil
+}
@@ -7,7 +7,8 @@
  * ==============================================================================================
  */
 
-BEGIN;
+-- +goose Up
+-- A migração roda dentro de uma transação aberta pelo pacote migrate.
 
 -- 1. CONFIGURAÇÕES
 SET client_min_messages TO WARNING;
@@ -210,4 +211,144 @@ ALTER TABLE "public"."subject" ALTER COLUMN "result" SET STORAGE EXTERNAL;
 CREATE INDEX "idx_subject_page_order" ON "public"."subject" ("page_id", "order");
 CREATE INDEX "idx_subject_result_gin" ON "public"."subject" USING GIN ("result" jsonb_path_ops);
 
-COMMIT;
\ No newline at end of file
+-- 11. MEDIÇÃO DE USO (Billing / Relatórios)
+CREATE TABLE "public"."tenant_usage" (
+                                         "tenant_id"       uuid NOT NULL,
+                                         "period"          date NOT NULL,
+                                         "dashboard_views" bigint NOT NULL DEFAULT 0,
+                                         "api_calls"       bigint NOT NULL DEFAULT 0,
+
+                                         CONSTRAINT "pk_tenant_usage" PRIMARY KEY ("tenant_id", "period"),
+                                         CONSTRAINT "fk_usage_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- Usuários ativos no período (contados via DISTINCT natural da PK)
+CREATE TABLE "public"."tenant_usage_user" (
+                                              "tenant_id" uuid NOT NULL,
+                                              "period"    date NOT NULL,
+                                              "user_id"   uuid NOT NULL,
+
+                                              CONSTRAINT "pk_tenant_usage_user" PRIMARY KEY ("tenant_id", "period", "user_id"),
+                                              CONSTRAINT "fk_usage_user_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- 12. CONTROLE DE ACESSO (RBAC + ACL)
+-- Ações concedidas a um papel para todo um tipo de recurso
+CREATE TABLE "public"."role_policy" (
+                                        "role_id"          smallint NOT NULL,
+                                        "resource_type_id" smallint NOT NULL,
+                                        "actions"          varchar(16)[] NOT NULL DEFAULT '{}',
+
+                                        CONSTRAINT "pk_role_policy" PRIMARY KEY ("role_id", "resource_type_id"),
+                                        CONSTRAINT "fk_policy_role" FOREIGN KEY ("role_id") REFERENCES "public"."role"("role_id") ON DELETE CASCADE,
+                                        CONSTRAINT "fk_policy_resource_type" FOREIGN KEY ("resource_type_id") REFERENCES "public"."resource_type"("resource_type_id") ON DELETE CASCADE
+);
+
+-- Ações concedidas a um usuário sobre uma instância específica de recurso
+CREATE TABLE "public"."acl" (
+                                "acl_id"      uuid NOT NULL,
+                                "user_id"     uuid NOT NULL,
+                                "resource_id" uuid NOT NULL,
+                                "actions"     varchar(16)[] NOT NULL,
+                                "expires_at"  timestamptz,
+                                "created_at"  timestamptz NOT NULL DEFAULT now(),
+                                "updated_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                CONSTRAINT "pk_acl" PRIMARY KEY ("acl_id"),
+                                CONSTRAINT "uq_acl_user_resource" UNIQUE ("user_id", "resource_id"),
+                                CONSTRAINT "fk_acl_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                CONSTRAINT "fk_acl_resource" FOREIGN KEY ("resource_id") REFERENCES "public"."resource"("resource_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_acl_resource" ON "public"."acl" ("resource_id");
+CREATE INDEX "idx_acl_expires_at" ON "public"."acl" ("expires_at") WHERE "expires_at" IS NOT NULL;
+
+-- Hierarquia de recursos (DASHBOARD > PAGE > SUBJECT) usada na herança de permissões
+CREATE VIEW "public"."resource_hierarchy" AS
+SELECT "page_id" AS "resource_id", "dashboard_id" AS "parent_id" FROM "public"."page"
+UNION ALL
+SELECT "subject_id" AS "resource_id", "page_id" AS "parent_id" FROM "public"."subject" WHERE "page_id" IS NOT NULL;
+
+-- Trilha de auditoria das ACLs (sem FKs para sobreviver à remoção do usuário/recurso)
+CREATE TABLE "public"."acl_history" (
+                                        "history_id"  uuid NOT NULL,
+                                        "acl_id"      uuid NOT NULL,
+                                        "user_id"     uuid NOT NULL,
+                                        "resource_id" uuid NOT NULL,
+                                        "actor_id"    uuid NOT NULL,
+                                        "operation"   varchar(16) NOT NULL,
+                                        "old_actions" varchar(16)[] NOT NULL DEFAULT '{}',
+                                        "new_actions" varchar(16)[] NOT NULL DEFAULT '{}',
+                                        "created_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                        CONSTRAINT "pk_acl_history" PRIMARY KEY ("history_id")
+);
+CREATE INDEX "idx_acl_history_user" ON "public"."acl_history" ("user_id", "created_at" DESC);
+CREATE INDEX "idx_acl_history_resource" ON "public"."acl_history" ("resource_id", "created_at" DESC);
+
+-- Auditoria das requisições que alteram estado (sem FKs, como acl_history)
+CREATE TABLE "public"."audit_log" (
+                                      "audit_id"   uuid NOT NULL,
+                                      "actor_id"   uuid,
+                                      "tenant_id"  uuid,
+                                      "method"     varchar(10) NOT NULL,
+                                      "route"      text NOT NULL,
+                                      "status"     integer NOT NULL,
+                                      "body"       jsonb,
+                                      "created_at" timestamptz NOT NULL DEFAULT now(),
+
+                                      CONSTRAINT "pk_audit_log" PRIMARY KEY ("audit_id")
+);
+CREATE INDEX "idx_audit_log_actor" ON "public"."audit_log" ("actor_id", "created_at" DESC);
+CREATE INDEX "idx_audit_log_tenant" ON "public"."audit_log" ("tenant_id", "created_at" DESC);
+
+-- Notificação de alterações de permissão para invalidar caches de todas as instâncias.
+-- O payload é o user_id afetado ou '*' quando a mudança atinge todos os usuários.
+CREATE FUNCTION "public"."notify_acl_change"() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'role_policy' THEN
+        PERFORM pg_notify('acl_changes', '*');
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('acl_changes', OLD.user_id::text);
+    ELSE
+        PERFORM pg_notify('acl_changes', NEW.user_id::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_acl_notify" AFTER INSERT OR UPDATE OR DELETE ON "public"."acl"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_acl_change"();
+
+CREATE TRIGGER "trg_role_policy_notify" AFTER INSERT OR UPDATE OR DELETE ON "public"."role_policy"
+    FOR EACH STATEMENT EXECUTE FUNCTION "public"."notify_acl_change"();
+
+CREATE TRIGGER "trg_users_role_notify" AFTER UPDATE OF "role_id" OR DELETE ON "public"."users"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_acl_change"();
+
+-- +goose Down
+-- Remove os objetos na ordem inversa das dependências; a tabela de controle
+-- das migrações não é afetada.
+DROP FUNCTION IF EXISTS "public"."notify_acl_change"() CASCADE;
+DROP VIEW IF EXISTS "public"."resource_hierarchy";
+DROP TABLE IF EXISTS "public"."audit_log" CASCADE;
+DROP TABLE IF EXISTS "public"."acl_history" CASCADE;
+DROP TABLE IF EXISTS "public"."acl" CASCADE;
+DROP TABLE IF EXISTS "public"."role_policy" CASCADE;
+DROP TABLE IF EXISTS "public"."tenant_usage_user" CASCADE;
+DROP TABLE IF EXISTS "public"."tenant_usage" CASCADE;
+DROP TABLE IF EXISTS "public"."subject" CASCADE;
+DROP TABLE IF EXISTS "public"."page" CASCADE;
+DROP TABLE IF EXISTS "public"."feed" CASCADE;
+DROP TABLE IF EXISTS "public"."user_dashboard_access" CASCADE;
+DROP TABLE IF EXISTS "public"."dashboard" CASCADE;
+DROP TABLE IF EXISTS "public"."resource" CASCADE;
+DROP TABLE IF EXISTS "public"."tenant_membership" CASCADE;
+DROP TABLE IF EXISTS "public"."password_reset_token" CASCADE;
+DROP TABLE IF EXISTS "public"."users" CASCADE;
+DROP TABLE IF EXISTS "public"."widget_type" CASCADE;
+DROP TABLE IF EXISTS "public"."feed_category" CASCADE;
+DROP TABLE IF EXISTS "public"."layout" CASCADE;
+DROP TABLE IF EXISTS "public"."resource_type" CASCADE;
+DROP TABLE IF EXISTS "public"."role" CASCADE;
+DROP TABLE IF EXISTS "public"."tenant" CASCADE;
+DROP TYPE IF EXISTS "actions_enum";
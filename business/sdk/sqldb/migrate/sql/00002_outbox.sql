@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- Eventos de domínio gravados na mesma transação da alteração e publicados
+-- depois pelo dispatcher (entrega pelo menos uma vez).
+CREATE TABLE "public"."outbox" (
+                                   "event_id"        uuid NOT NULL,
+                                   "event_type"      varchar(100) NOT NULL,
+                                   "aggregate_id"    uuid NOT NULL,
+                                   "dedup_key"       varchar(255) NOT NULL,
+                                   "payload"         jsonb NOT NULL,
+                                   "attempts"        integer NOT NULL DEFAULT 0,
+                                   "last_error"      text,
+                                   "created_at"      timestamptz NOT NULL DEFAULT now(),
+                                   "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+                                   "published_at"    timestamptz,
+
+                                   CONSTRAINT "pk_outbox" PRIMARY KEY ("event_id"),
+                                   CONSTRAINT "uq_outbox_dedup_key" UNIQUE ("dedup_key")
+);
+CREATE INDEX "idx_outbox_pending" ON "public"."outbox" ("next_attempt_at") WHERE "published_at" IS NULL;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."outbox" CASCADE;
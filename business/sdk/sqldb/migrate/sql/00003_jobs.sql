@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Agenda dos jobs globais do worker. O lock garante uma execução por vez
+-- entre todas as instâncias.
+CREATE TABLE "public"."job_schedule" (
+                                         "name"         varchar(100) NOT NULL,
+                                         "next_run_at"  timestamptz NOT NULL,
+                                         "locked_by"    varchar(255),
+                                         "locked_until" timestamptz,
+                                         "last_run_at"  timestamptz,
+                                         "last_error"   text,
+
+                                         CONSTRAINT "pk_job_schedule" PRIMARY KEY ("name")
+);
+
+-- Tarefas sob demanda, reprocessadas com backoff até esgotar as tentativas.
+CREATE TABLE "public"."job_queue" (
+                                      "task_id"      uuid NOT NULL,
+                                      "name"         varchar(100) NOT NULL,
+                                      "payload"      jsonb NOT NULL,
+                                      "status"       varchar(20) NOT NULL DEFAULT 'pending',
+                                      "attempts"     integer NOT NULL DEFAULT 0,
+                                      "max_attempts" integer NOT NULL,
+                                      "last_error"   text,
+                                      "run_at"       timestamptz NOT NULL DEFAULT now(),
+                                      "created_at"   timestamptz NOT NULL DEFAULT now(),
+                                      "finished_at"  timestamptz,
+
+                                      CONSTRAINT "pk_job_queue" PRIMARY KEY ("task_id"),
+                                      CONSTRAINT "ck_job_queue_status" CHECK ("status" IN ('pending', 'done', 'failed'))
+);
+CREATE INDEX "idx_job_queue_pending" ON "public"."job_queue" ("run_at") WHERE "status" = 'pending';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."job_queue" CASCADE;
+DROP TABLE IF EXISTS "public"."job_schedule" CASCADE;
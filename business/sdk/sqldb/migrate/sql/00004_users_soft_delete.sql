@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Usuários removidos continuam na tabela para não quebrar as referências da
+-- ACL e da auditoria, e podem ser restaurados por um admin.
+ALTER TABLE "public"."users" ADD COLUMN "deleted_at" timestamptz;
+
+-- +goose Down
+
+DELETE FROM "public"."users" WHERE "deleted_at" IS NOT NULL;
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- Último acesso fica no próprio usuário para as listagens; o histórico
+-- completo fica em user_login.
+ALTER TABLE "public"."users"
+    ADD COLUMN "last_login_at" timestamptz,
+    ADD COLUMN "last_login_ip" varchar(45);
+
+CREATE TABLE "public"."user_login" (
+                                       "login_id"   uuid NOT NULL,
+                                       "user_id"    uuid NOT NULL,
+                                       "ip"         varchar(45) NOT NULL,
+                                       "user_agent" text NOT NULL,
+                                       "created_at" timestamptz NOT NULL DEFAULT now(),
+
+                                       CONSTRAINT "pk_user_login" PRIMARY KEY ("login_id"),
+                                       CONSTRAINT "fk_user_login_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_user_login_user" ON "public"."user_login" ("user_id", "created_at" DESC);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."user_login" CASCADE;
+
+ALTER TABLE "public"."users"
+    DROP COLUMN IF EXISTS "last_login_ip",
+    DROP COLUMN IF EXISTS "last_login_at";
@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Preferências da interface (tema, idioma, dashboard padrão, notificações).
+-- O documento é livre para o frontend evoluir sem migrações; o backend só
+-- interpreta os campos conhecidos.
+CREATE TABLE "public"."user_preferences" (
+                                             "user_id"     uuid NOT NULL,
+                                             "preferences" jsonb NOT NULL DEFAULT '{}',
+                                             "updated_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                             CONSTRAINT "pk_user_preferences" PRIMARY KEY ("user_id"),
+                                             CONSTRAINT "fk_user_preferences_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."user_preferences" CASCADE;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- A troca de e-mail só vale depois que o novo endereço confirma o token. Até
+-- lá o endereço fica em pending_email e o login continua com o atual.
+ALTER TABLE "public"."users" ADD COLUMN "pending_email" varchar(120);
+
+CREATE TABLE "public"."email_change_token" (
+                                               "user_id"    uuid NOT NULL,
+                                               "token_hash" varchar NOT NULL,
+                                               "expires_at" timestamptz NOT NULL,
+
+                                               CONSTRAINT "pk_email_change_token" PRIMARY KEY ("user_id"),
+                                               CONSTRAINT "uq_email_change_token_hash" UNIQUE ("token_hash"),
+                                               CONSTRAINT "fk_email_change_token_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."email_change_token" CASCADE;
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "pending_email";
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Notificação de alterações de usuário para invalidar os caches de todas as
+-- instâncias. O payload leva o user_id e o e-mail anterior, as duas chaves do
+-- cache; o login só mexe em last_login_* e não notifica.
+CREATE FUNCTION "public"."notify_user_change"() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object('user_id', OLD.user_id, 'email', OLD.email)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_users_notify"
+    AFTER UPDATE OF "role_id", "name", "email", "phone", "password", "enabled", "deleted_at", "pending_email" OR DELETE
+    ON "public"."users"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_user_change"();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS "trg_users_notify" ON "public"."users";
+DROP FUNCTION IF EXISTS "public"."notify_user_change"();
@@ -0,0 +1,53 @@
+-- +goose Up
+
+-- Os telefones passam a ser gravados em E.164 (+5511912345678), o que o
+-- pacote phone já produz. Os números sem código de país são do Brasil.
+CREATE TEMPORARY TABLE "phone_e164" ON COMMIT DROP AS
+SELECT
+    user_id,
+    created_at,
+    CASE
+        WHEN btrim(phone) LIKE '+%' THEN '+' || regexp_replace(phone, '\D', '', 'g')
+        WHEN regexp_replace(phone, '\D', '', 'g') LIKE '00%' THEN '+' || substr(regexp_replace(phone, '\D', '', 'g'), 3)
+        ELSE '+55' || regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^0', '')
+    END AS e164
+FROM
+    "public"."users"
+WHERE
+    phone IS NOT NULL;
+
+-- Números que não são válidos não seriam lidos pela aplicação: ficam vazios.
+DELETE FROM "phone_e164"
+WHERE
+    e164 !~ '^\+[1-9][0-9]{7,14}$'
+    OR (e164 LIKE '+55%' AND e164 !~ '^\+55[1-9]{2}(9[0-9]{8}|[0-9]{8})$');
+
+-- O mesmo número digitado de formas diferentes fica com o usuário mais antigo.
+DELETE FROM "phone_e164" AS p
+USING (
+    SELECT user_id, row_number() OVER (PARTITION BY e164 ORDER BY created_at, user_id) AS rn
+    FROM "phone_e164"
+) AS r
+WHERE
+    r.user_id = p.user_id AND r.rn > 1;
+
+UPDATE "public"."users" AS u
+SET
+    phone = NULL
+WHERE
+    u.phone IS NOT NULL
+    AND NOT EXISTS (SELECT 1 FROM "phone_e164" AS p WHERE p.user_id = u.user_id);
+
+UPDATE "public"."users" AS u
+SET
+    phone = p.e164
+FROM
+    "phone_e164" AS p
+WHERE
+    p.user_id = u.user_id;
+
+ALTER TABLE "public"."users" ADD CONSTRAINT "uq_users_phone" UNIQUE ("phone");
+
+-- +goose Down
+
+ALTER TABLE "public"."users" DROP CONSTRAINT IF EXISTS "uq_users_phone";
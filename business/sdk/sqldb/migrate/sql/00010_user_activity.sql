@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- Feed de atividades dos usuários para o painel de admin. Diferente do
+-- audit_log, guarda apenas ações de alto nível e é expurgado após a retenção.
+-- Sem FK para o tenant, como audit_log; o usuário leva as atividades junto.
+CREATE TABLE "public"."user_activity" (
+                                          "activity_id" uuid NOT NULL,
+                                          "user_id"     uuid NOT NULL,
+                                          "tenant_id"   uuid,
+                                          "action"      varchar(64) NOT NULL,
+                                          "entity_type" varchar(32) NOT NULL,
+                                          "entity_id"   uuid,
+                                          "created_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                          CONSTRAINT "pk_user_activity" PRIMARY KEY ("activity_id"),
+                                          CONSTRAINT "fk_user_activity_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_user_activity_user" ON "public"."user_activity" ("user_id", "created_at" DESC);
+CREATE INDEX "idx_user_activity_created" ON "public"."user_activity" ("created_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."user_activity" CASCADE;
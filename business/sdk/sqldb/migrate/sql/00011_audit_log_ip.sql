@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- IP do cliente resolvido pelo middleware Proxy; nulo para o CLI de admin.
+ALTER TABLE "public"."audit_log"
+    ADD COLUMN "ip" varchar(45);
+
+-- +goose Down
+
+ALTER TABLE "public"."audit_log"
+    DROP COLUMN IF EXISTS "ip";
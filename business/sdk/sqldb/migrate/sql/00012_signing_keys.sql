@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- Chaves de assinatura dos JWTs compartilhadas por todas as instâncias. Só
+-- uma chave fica ativa para assinar; as aposentadas continuam validando os
+-- tokens já emitidos até not_after.
+CREATE TABLE "public"."signing_keys" (
+                                         "kid"         varchar(64) NOT NULL,
+                                         "private_pem" text NOT NULL,
+                                         "public_pem"  text NOT NULL,
+                                         "active"      boolean NOT NULL DEFAULT false,
+                                         "not_after"   timestamptz,
+                                         "created_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                         CONSTRAINT "pk_signing_keys" PRIMARY KEY ("kid")
+);
+CREATE UNIQUE INDEX "uq_signing_keys_active" ON "public"."signing_keys" ("active") WHERE "active";
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."signing_keys" CASCADE;
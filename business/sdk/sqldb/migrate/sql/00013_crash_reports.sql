@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- Pânicos recuperados pelo middleware Panics, com um retrato sanitizado da
+-- requisição para a triagem. O corpo nunca é guardado, só o hash.
+CREATE TABLE "public"."crash_reports" (
+                                          "crash_id"   uuid NOT NULL,
+                                          "trace_id"   varchar(64),
+                                          "user_id"    uuid,
+                                          "tenant_id"  uuid,
+                                          "ip"         varchar(45),
+                                          "method"     varchar(10) NOT NULL,
+                                          "route"      text NOT NULL,
+                                          "headers"    jsonb,
+                                          "body_hash"  varchar(64),
+                                          "body_size"  bigint NOT NULL DEFAULT 0,
+                                          "panic"      text NOT NULL,
+                                          "stack"      text NOT NULL,
+                                          "created_at" timestamptz NOT NULL DEFAULT now(),
+
+                                          CONSTRAINT "pk_crash_reports" PRIMARY KEY ("crash_id")
+);
+CREATE INDEX "idx_crash_reports_created" ON "public"."crash_reports" ("created_at" DESC);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."crash_reports" CASCADE;
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- Conexões de cada cliente com as bases de onde os widgets leem os dados. As
+-- credenciais são cifradas pela aplicação (AES-GCM) e nunca são devolvidas
+-- pela API.
+CREATE TABLE "public"."datasource" (
+                                       "datasource_id" uuid NOT NULL,
+                                       "tenant_id"     uuid NOT NULL,
+                                       "name"          varchar(256) NOT NULL,
+                                       "kind"          varchar(16) NOT NULL,
+                                       "settings"      jsonb NOT NULL DEFAULT '{}',
+                                       "credentials"   bytea,
+                                       "created_at"    timestamptz NOT NULL DEFAULT now(),
+                                       "updated_at"    timestamptz NOT NULL DEFAULT now(),
+
+                                       CONSTRAINT "pk_datasource" PRIMARY KEY ("datasource_id"),
+                                       CONSTRAINT "uq_datasource_tenant_name" UNIQUE ("tenant_id", "name"),
+                                       CONSTRAINT "fk_datasource_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- Cada widget (subject) pode ler de uma datasource do mesmo cliente com a
+-- consulta guardada aqui.
+ALTER TABLE "public"."subject"
+    ADD COLUMN "datasource_id" uuid,
+    ADD COLUMN "query" text,
+    ADD CONSTRAINT "fk_subject_datasource" FOREIGN KEY ("datasource_id") REFERENCES "public"."datasource"("datasource_id") ON DELETE RESTRICT;
+CREATE INDEX "idx_subject_datasource" ON "public"."subject" ("datasource_id") WHERE "datasource_id" IS NOT NULL;
+
+-- +goose Down
+
+ALTER TABLE "public"."subject"
+    DROP COLUMN IF EXISTS "query",
+    DROP COLUMN IF EXISTS "datasource_id";
+DROP TABLE IF EXISTS "public"."datasource" CASCADE;
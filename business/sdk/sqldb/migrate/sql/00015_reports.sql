@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- Exportações de dashboards e widgets geradas em segundo plano. O arquivo
+-- fica na própria linha até expirar e é baixado por um link assinado.
+CREATE TABLE "public"."report" (
+                                   "report_id"    uuid NOT NULL,
+                                   "tenant_id"    uuid NOT NULL,
+                                   "dashboard_id" uuid NOT NULL,
+                                   "widget_id"    uuid,
+                                   "user_id"      uuid NOT NULL,
+                                   "format"       varchar(8) NOT NULL,
+                                   "status"       varchar(16) NOT NULL,
+                                   "params"       jsonb NOT NULL DEFAULT '{}',
+                                   "error"        text,
+                                   "file_name"    varchar(256),
+                                   "content_type" varchar(128),
+                                   "size"         bigint NOT NULL DEFAULT 0,
+                                   "content"      bytea,
+                                   "created_at"   timestamptz NOT NULL DEFAULT now(),
+                                   "finished_at"  timestamptz,
+                                   "expires_at"   timestamptz NOT NULL,
+
+                                   CONSTRAINT "pk_report" PRIMARY KEY ("report_id"),
+                                   CONSTRAINT "fk_report_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE,
+                                   CONSTRAINT "fk_report_dashboard" FOREIGN KEY ("dashboard_id") REFERENCES "public"."dashboard"("dashboard_id") ON DELETE CASCADE,
+                                   CONSTRAINT "fk_report_widget" FOREIGN KEY ("widget_id") REFERENCES "public"."subject"("subject_id") ON DELETE CASCADE
+);
+ALTER TABLE "public"."report" ALTER COLUMN "content" SET STORAGE EXTERNAL;
+CREATE INDEX "idx_report_user" ON "public"."report" ("user_id", "created_at" DESC);
+CREATE INDEX "idx_report_expires" ON "public"."report" ("expires_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."report" CASCADE;
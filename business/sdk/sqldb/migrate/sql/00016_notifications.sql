@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- Caixa de entrada de cada usuário, o sino do dashboard. O conteúdo fica em
+-- data e o texto é montado pela interface a partir do kind, no idioma dela.
+CREATE TABLE "public"."notification" (
+                                         "notification_id" uuid NOT NULL,
+                                         "user_id"         uuid NOT NULL,
+                                         "tenant_id"       uuid,
+                                         "kind"            varchar(64) NOT NULL,
+                                         "entity_type"     varchar(32) NOT NULL,
+                                         "entity_id"       uuid,
+                                         "data"            jsonb NOT NULL DEFAULT '{}',
+                                         "read_at"         timestamptz,
+                                         "created_at"      timestamptz NOT NULL DEFAULT now(),
+
+                                         CONSTRAINT "pk_notification" PRIMARY KEY ("notification_id"),
+                                         CONSTRAINT "fk_notification_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                         CONSTRAINT "fk_notification_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_notification_user" ON "public"."notification" ("user_id", "created_at" DESC);
+CREATE INDEX "idx_notification_unread" ON "public"."notification" ("user_id") WHERE "read_at" IS NULL;
+CREATE INDEX "idx_notification_created" ON "public"."notification" ("created_at");
+
+-- Anuncia cada notificação nova às instâncias da API, que a repassam às
+-- conexões SSE do usuário. O NOTIFY só sai no commit, então uma notificação
+-- de uma transação desfeita nunca é entregue. O payload é limitado a 8000
+-- bytes, o data deve ficar pequeno.
+CREATE FUNCTION "public"."notify_notification"() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('notifications', row_to_json(NEW)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_notification_notify"
+    AFTER INSERT
+    ON "public"."notification"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_notification"();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS "trg_notification_notify" ON "public"."notification";
+DROP FUNCTION IF EXISTS "public"."notify_notification"();
+DROP TABLE IF EXISTS "public"."notification" CASCADE;
@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Flags das funcionalidades em liberação. Com enabled a flag vale para o
+-- percentual rollout dos tenants (ou dos usuários sem tenant); as exceções
+-- por tenant e por usuário ficam em feature_override e vencem a regra geral.
+CREATE TABLE "public"."feature_flag" (
+                                         "flag_key"    varchar(64) NOT NULL,
+                                         "description" text NOT NULL DEFAULT '',
+                                         "enabled"     boolean NOT NULL DEFAULT false,
+                                         "rollout"     smallint NOT NULL DEFAULT 100,
+                                         "created_at"  timestamptz NOT NULL DEFAULT now(),
+                                         "updated_at"  timestamptz NOT NULL DEFAULT now(),
+
+                                         CONSTRAINT "pk_feature_flag" PRIMARY KEY ("flag_key"),
+                                         CONSTRAINT "ck_feature_flag_rollout" CHECK ("rollout" BETWEEN 0 AND 100)
+);
+
+-- Cada exceção é de um tenant ou de um usuário, nunca dos dois.
+CREATE TABLE "public"."feature_override" (
+                                             "flag_key"   varchar(64) NOT NULL,
+                                             "tenant_id"  uuid,
+                                             "user_id"    uuid,
+                                             "enabled"    boolean NOT NULL,
+                                             "created_at" timestamptz NOT NULL DEFAULT now(),
+
+                                             CONSTRAINT "fk_feature_override_flag" FOREIGN KEY ("flag_key") REFERENCES "public"."feature_flag"("flag_key") ON DELETE CASCADE,
+                                             CONSTRAINT "fk_feature_override_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE,
+                                             CONSTRAINT "fk_feature_override_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                             CONSTRAINT "ck_feature_override_subject" CHECK (("tenant_id" IS NULL) <> ("user_id" IS NULL))
+);
+CREATE UNIQUE INDEX "uq_feature_override_tenant" ON "public"."feature_override" ("flag_key", "tenant_id") WHERE "tenant_id" IS NOT NULL;
+CREATE UNIQUE INDEX "uq_feature_override_user" ON "public"."feature_override" ("flag_key", "user_id") WHERE "user_id" IS NOT NULL;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."feature_override" CASCADE;
+DROP TABLE IF EXISTS "public"."feature_flag" CASCADE;
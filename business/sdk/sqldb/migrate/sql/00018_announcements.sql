@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- Avisos publicados pelos administradores no banner do dashboard (janelas de
+-- manutenção, novidades). Sem tenants e sem roles o aviso vale para todos;
+-- sem ends_at fica ativo até ser encerrado ou removido.
+CREATE TABLE "public"."announcement" (
+                                         "announcement_id" uuid NOT NULL,
+                                         "title"           varchar(128) NOT NULL,
+                                         "body"            text NOT NULL DEFAULT '',
+                                         "severity"        varchar(16) NOT NULL DEFAULT 'info',
+                                         "tenant_ids"      uuid[] NOT NULL DEFAULT '{}',
+                                         "roles"           varchar(16)[] NOT NULL DEFAULT '{}',
+                                         "starts_at"       timestamptz NOT NULL,
+                                         "ends_at"         timestamptz,
+                                         "created_by"      uuid,
+                                         "created_at"      timestamptz NOT NULL DEFAULT now(),
+                                         "updated_at"      timestamptz NOT NULL DEFAULT now(),
+
+                                         CONSTRAINT "pk_announcement" PRIMARY KEY ("announcement_id"),
+                                         CONSTRAINT "fk_announcement_created_by" FOREIGN KEY ("created_by") REFERENCES "public"."users"("user_id") ON DELETE SET NULL,
+                                         CONSTRAINT "ck_announcement_severity" CHECK ("severity" IN ('info', 'warning', 'critical')),
+                                         CONSTRAINT "ck_announcement_window" CHECK ("ends_at" IS NULL OR "ends_at" > "starts_at")
+);
+CREATE INDEX "idx_announcement_window" ON "public"."announcement" ("starts_at", "ends_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."announcement" CASCADE;
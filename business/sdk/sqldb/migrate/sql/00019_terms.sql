@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- Versões dos termos de uso. Uma versão publicada nunca é alterada nem
+-- removida: o aceite de cada usuário aponta para o texto que ele leu. A
+-- versão vigente é a publicada por último.
+CREATE TABLE "public"."terms" (
+                                  "terms_id"     uuid NOT NULL,
+                                  "version"      varchar(32) NOT NULL,
+                                  "title"        varchar(128) NOT NULL,
+                                  "body"         text NOT NULL,
+                                  "published_by" uuid,
+                                  "published_at" timestamptz NOT NULL DEFAULT now(),
+
+                                  CONSTRAINT "pk_terms" PRIMARY KEY ("terms_id"),
+                                  CONSTRAINT "uq_terms_version" UNIQUE ("version"),
+                                  CONSTRAINT "fk_terms_published_by" FOREIGN KEY ("published_by") REFERENCES "public"."users"("user_id") ON DELETE SET NULL
+);
+CREATE INDEX "idx_terms_published" ON "public"."terms" ("published_at" DESC);
+
+-- Registro do aceite de cada versão por usuário, com a origem da requisição
+-- exigida pela auditoria do setor público.
+CREATE TABLE "public"."terms_acceptance" (
+                                             "user_id"     uuid NOT NULL,
+                                             "terms_id"    uuid NOT NULL,
+                                             "ip"          varchar(45) NOT NULL DEFAULT '',
+                                             "user_agent"  varchar(512) NOT NULL DEFAULT '',
+                                             "accepted_at" timestamptz NOT NULL DEFAULT now(),
+
+                                             CONSTRAINT "pk_terms_acceptance" PRIMARY KEY ("user_id", "terms_id"),
+                                             CONSTRAINT "fk_terms_acceptance_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                             CONSTRAINT "fk_terms_acceptance_terms" FOREIGN KEY ("terms_id") REFERENCES "public"."terms"("terms_id") ON DELETE RESTRICT
+);
+CREATE INDEX "idx_terms_acceptance_terms" ON "public"."terms_acceptance" ("terms_id", "accepted_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."terms_acceptance" CASCADE;
+DROP TABLE IF EXISTS "public"."terms" CASCADE;
@@ -0,0 +1,25 @@
+-- +goose Up
+
+-- O auto-cadastro é liberado por tenant, pelo domínio do dashboard. Fica
+-- desligado até um admin habilitar.
+ALTER TABLE "public"."tenant" ADD COLUMN "self_registration" boolean NOT NULL DEFAULT false;
+
+-- O usuário que se cadastra nasce desabilitado e só é habilitado quando o
+-- e-mail confirma o token. Cadastros não confirmados a tempo são removidos
+-- junto com o usuário, liberando o e-mail.
+CREATE TABLE "public"."registration_token" (
+                                               "user_id"    uuid NOT NULL,
+                                               "token_hash" varchar NOT NULL,
+                                               "expires_at" timestamptz NOT NULL,
+
+                                               CONSTRAINT "pk_registration_token" PRIMARY KEY ("user_id"),
+                                               CONSTRAINT "uq_registration_token_hash" UNIQUE ("token_hash"),
+                                               CONSTRAINT "fk_registration_token_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_registration_token_expires" ON "public"."registration_token" ("expires_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."registration_token" CASCADE;
+ALTER TABLE "public"."tenant" DROP COLUMN IF EXISTS "self_registration";
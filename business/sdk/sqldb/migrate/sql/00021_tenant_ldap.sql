@@ -0,0 +1,29 @@
+-- +goose Up
+
+-- Diretório LDAP/Active Directory de cada cliente. Com ele habilitado, o login
+-- no domínio do cliente valida a senha no diretório e cria o usuário local no
+-- primeiro acesso. A senha do bind de serviço é cifrada pela aplicação
+-- (AES-GCM) e nunca é devolvida pela API.
+CREATE TABLE "public"."tenant_ldap" (
+                                        "tenant_id"       uuid NOT NULL,
+                                        "url"             varchar(255) NOT NULL,
+                                        "start_tls"       boolean NOT NULL DEFAULT false,
+                                        "bind_dn"         varchar(512) NOT NULL,
+                                        "bind_password"   bytea NOT NULL,
+                                        "base_dn"         varchar(512) NOT NULL,
+                                        "user_filter"     varchar(512) NOT NULL,
+                                        "group_attribute" varchar(64) NOT NULL,
+                                        "name_attribute"  varchar(64) NOT NULL,
+                                        "role_mappings"   jsonb NOT NULL DEFAULT '[]',
+                                        "default_role"    varchar(16),
+                                        "enabled"         boolean NOT NULL DEFAULT true,
+                                        "created_at"      timestamptz NOT NULL DEFAULT now(),
+                                        "updated_at"      timestamptz NOT NULL DEFAULT now(),
+
+                                        CONSTRAINT "pk_tenant_ldap" PRIMARY KEY ("tenant_id"),
+                                        CONSTRAINT "fk_tenant_ldap_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."tenant_ldap" CASCADE;
@@ -0,0 +1,42 @@
+-- +goose Up
+
+-- Provedor de identidade SAML de cada cliente. O login chega assinado pelo
+-- provedor no ACS e o usuário local é criado no primeiro acesso, com papel e
+-- dashboards tirados dos atributos da asserção.
+CREATE TABLE "public"."tenant_saml" (
+                                        "tenant_id"           uuid NOT NULL,
+                                        "entity_id"           varchar(512) NOT NULL,
+                                        "sso_url"             varchar(1024) NOT NULL,
+                                        "certificate"         text NOT NULL,
+                                        "email_attribute"     varchar(255) NOT NULL DEFAULT '',
+                                        "name_attribute"      varchar(255) NOT NULL DEFAULT '',
+                                        "role_attribute"      varchar(255) NOT NULL DEFAULT '',
+                                        "role_mappings"       jsonb NOT NULL DEFAULT '[]',
+                                        "default_role"        varchar(16),
+                                        "dashboard_attribute" varchar(255) NOT NULL DEFAULT '',
+                                        "dashboard_mappings"  jsonb NOT NULL DEFAULT '[]',
+                                        "enabled"             boolean NOT NULL DEFAULT true,
+                                        "created_at"          timestamptz NOT NULL DEFAULT now(),
+                                        "updated_at"          timestamptz NOT NULL DEFAULT now(),
+
+                                        CONSTRAINT "pk_tenant_saml" PRIMARY KEY ("tenant_id"),
+                                        CONSTRAINT "fk_tenant_saml_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- Asserções já consumidas, guardadas até expirarem para recusar a
+-- reapresentação da mesma resposta.
+CREATE TABLE "public"."saml_assertion" (
+                                           "tenant_id"    uuid NOT NULL,
+                                           "assertion_id" varchar(255) NOT NULL,
+                                           "expires_at"   timestamptz NOT NULL,
+
+                                           CONSTRAINT "pk_saml_assertion" PRIMARY KEY ("tenant_id", "assertion_id"),
+                                           CONSTRAINT "fk_saml_assertion_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_saml_assertion_expires_at" ON "public"."saml_assertion" ("expires_at");
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."saml_assertion" CASCADE;
+DROP TABLE IF EXISTS "public"."tenant_saml" CASCADE;
@@ -0,0 +1,58 @@
+-- +goose Up
+
+-- Domínios adicionais (aliases) de cada dashboard. O domínio principal
+-- continua em dashboard.domain; os aliases resolvem para o mesmo dashboard, o
+-- que permite publicar o domínio novo antes de retirar o antigo.
+CREATE TABLE "public"."dashboard_domain" (
+                                             "domain"       varchar(255) NOT NULL,
+                                             "dashboard_id" uuid NOT NULL,
+                                             "created_at"   timestamptz NOT NULL DEFAULT now(),
+
+                                             CONSTRAINT "pk_dashboard_domain" PRIMARY KEY ("domain"),
+                                             CONSTRAINT "fk_dashboard_domain_dashboard" FOREIGN KEY ("dashboard_id") REFERENCES "public"."dashboard"("dashboard_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_dashboard_domain_dashboard" ON "public"."dashboard_domain" ("dashboard_id");
+
+-- Um domínio resolve para um único dashboard, seja principal ou alias. A
+-- unicidade entre as duas tabelas é garantida aqui e reportada como violação
+-- de uq_dashboard_domain, a mesma do domínio principal. O lock por domínio
+-- serializa as gravações concorrentes do mesmo valor.
+CREATE FUNCTION "public"."check_dashboard_domain"() RETURNS trigger AS $$
+BEGIN
+    IF NEW.domain IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_advisory_xact_lock(hashtext('dashboard_domain:' || NEW.domain));
+
+    IF TG_TABLE_NAME = 'dashboard' THEN
+        PERFORM 1 FROM "public"."dashboard_domain" WHERE domain = NEW.domain;
+    ELSE
+        PERFORM 1 FROM "public"."dashboard" WHERE domain = NEW.domain;
+    END IF;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'domain % is already in use', NEW.domain
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'uq_dashboard_domain';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_dashboard_domain_unique"
+    BEFORE INSERT OR UPDATE OF "domain"
+    ON "public"."dashboard"
+    FOR EACH ROW EXECUTE FUNCTION "public"."check_dashboard_domain"();
+
+CREATE TRIGGER "trg_dashboard_alias_unique"
+    BEFORE INSERT OR UPDATE OF "domain"
+    ON "public"."dashboard_domain"
+    FOR EACH ROW EXECUTE FUNCTION "public"."check_dashboard_domain"();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS "trg_dashboard_alias_unique" ON "public"."dashboard_domain";
+DROP TRIGGER IF EXISTS "trg_dashboard_domain_unique" ON "public"."dashboard";
+DROP FUNCTION IF EXISTS "public"."check_dashboard_domain"();
+DROP TABLE IF EXISTS "public"."dashboard_domain" CASCADE;
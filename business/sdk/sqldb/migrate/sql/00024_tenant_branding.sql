@@ -0,0 +1,27 @@
+-- +goose Up
+
+-- Identidade visual de cada cliente: logo, cores e o cabeçalho e rodapé dos
+-- e-mails. A página de login lê o logo e as cores antes da autenticação, pelo
+-- domínio; o cabeçalho e o rodapé ficam só para quem monta os e-mails.
+CREATE TABLE "public"."tenant_branding" (
+                                            "tenant_id"         uuid NOT NULL,
+                                            "logo"              bytea,
+                                            "logo_content_type" varchar(64),
+                                            "primary_color"     varchar(7) NOT NULL,
+                                            "secondary_color"   varchar(7) NOT NULL,
+                                            "email_header"      text NOT NULL DEFAULT '',
+                                            "email_footer"      text NOT NULL DEFAULT '',
+                                            "created_at"        timestamptz NOT NULL DEFAULT now(),
+                                            "updated_at"        timestamptz NOT NULL DEFAULT now(),
+
+                                            CONSTRAINT "pk_tenant_branding" PRIMARY KEY ("tenant_id"),
+                                            CONSTRAINT "fk_tenant_branding_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+-- O logo já chega comprimido (PNG, JPEG, GIF ou WebP): não adianta o TOAST
+-- tentar comprimir de novo.
+ALTER TABLE "public"."tenant_branding" ALTER COLUMN "logo" SET STORAGE EXTERNAL;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."tenant_branding" CASCADE;
@@ -0,0 +1,93 @@
+-- +goose Up
+
+-- Por padrão o e-mail é único em todo o sistema. Um cliente com e-mails
+-- isolados tem os e-mails únicos só entre os seus membros: dois órgãos podem
+-- ter usuários com a mesma caixa compartilhada. O login desfaz a ambiguidade
+-- pelo domínio, que leva ao cliente.
+ALTER TABLE "public"."tenant" ADD COLUMN "scoped_emails" boolean NOT NULL DEFAULT false;
+
+-- email_scope é o cliente em que o e-mail é único, NULL para o escopo global.
+-- Não referencia tenant: o escopo continua valendo depois que o usuário sai
+-- do cliente, para a saída não gerar conflito.
+ALTER TABLE "public"."users" ADD COLUMN "email_scope" uuid;
+
+ALTER TABLE "public"."users" DROP CONSTRAINT "uq_users_email";
+ALTER TABLE "public"."users" ADD CONSTRAINT "uq_users_email" UNIQUE NULLS NOT DISTINCT ("email_scope", "email");
+
+-- O escopo acompanha o vínculo do usuário e a configuração do cliente. Um
+-- conflito aparece como violação de uq_users_email na gravação do vínculo ou
+-- do cliente.
+CREATE FUNCTION "public"."sync_membership_email_scope"() RETURNS trigger AS $$
+BEGIN
+    UPDATE "public"."users" AS u
+    SET email_scope = CASE WHEN t.scoped_emails THEN t.tenant_id END
+    FROM "public"."tenant" AS t
+    WHERE t.tenant_id = NEW.tenant_id AND u.user_id = NEW.user_id
+      AND u.email_scope IS DISTINCT FROM CASE WHEN t.scoped_emails THEN t.tenant_id END;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_tenant_membership_email_scope"
+    AFTER INSERT OR UPDATE OF "tenant_id"
+    ON "public"."tenant_membership"
+    FOR EACH ROW EXECUTE FUNCTION "public"."sync_membership_email_scope"();
+
+CREATE FUNCTION "public"."sync_tenant_email_scope"() RETURNS trigger AS $$
+BEGIN
+    UPDATE "public"."users" AS u
+    SET email_scope = CASE WHEN NEW.scoped_emails THEN NEW.tenant_id END
+    FROM "public"."tenant_membership" AS tm
+    WHERE tm.tenant_id = NEW.tenant_id AND u.user_id = tm.user_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_tenant_email_scope"
+    AFTER UPDATE OF "scoped_emails"
+    ON "public"."tenant"
+    FOR EACH ROW
+    WHEN (OLD.scoped_emails IS DISTINCT FROM NEW.scoped_emails)
+    EXECUTE FUNCTION "public"."sync_tenant_email_scope"();
+
+-- O cache por e-mail passa a ser chaveado também pelo escopo, que entra no
+-- payload da notificação.
+CREATE OR REPLACE FUNCTION "public"."notify_user_change"() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object('user_id', OLD.user_id, 'email', OLD.email, 'email_scope', OLD.email_scope)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER "trg_users_notify" ON "public"."users";
+CREATE TRIGGER "trg_users_notify"
+    AFTER UPDATE OF "role_id", "name", "email", "phone", "password", "enabled", "deleted_at", "pending_email", "email_scope" OR DELETE
+    ON "public"."users"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_user_change"();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS "trg_users_notify" ON "public"."users";
+CREATE OR REPLACE FUNCTION "public"."notify_user_change"() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object('user_id', OLD.user_id, 'email', OLD.email)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER "trg_users_notify"
+    AFTER UPDATE OF "role_id", "name", "email", "phone", "password", "enabled", "deleted_at", "pending_email" OR DELETE
+    ON "public"."users"
+    FOR EACH ROW EXECUTE FUNCTION "public"."notify_user_change"();
+
+DROP TRIGGER IF EXISTS "trg_tenant_email_scope" ON "public"."tenant";
+DROP FUNCTION IF EXISTS "public"."sync_tenant_email_scope"();
+DROP TRIGGER IF EXISTS "trg_tenant_membership_email_scope" ON "public"."tenant_membership";
+DROP FUNCTION IF EXISTS "public"."sync_membership_email_scope"();
+
+ALTER TABLE "public"."users" DROP CONSTRAINT IF EXISTS "uq_users_email";
+ALTER TABLE "public"."users" DROP COLUMN IF EXISTS "email_scope";
+ALTER TABLE "public"."users" ADD CONSTRAINT "uq_users_email" UNIQUE ("email");
+
+ALTER TABLE "public"."tenant" DROP COLUMN IF EXISTS "scoped_emails";
@@ -0,0 +1,82 @@
+-- +goose Up
+
+-- Grupos de usuários de um cliente, como um departamento. O acesso dado ao
+-- grupo vale para todos os membros, então incluir alguém no departamento é
+-- uma única inclusão no grupo.
+CREATE TABLE "public"."user_group" (
+                                       "group_id"   uuid NOT NULL DEFAULT uuidv7(),
+                                       "tenant_id"  uuid NOT NULL,
+                                       "name"       varchar(100) NOT NULL,
+                                       "created_at" timestamptz NOT NULL DEFAULT now(),
+                                       "updated_at" timestamptz NOT NULL DEFAULT now(),
+
+                                       CONSTRAINT "pk_user_group" PRIMARY KEY ("group_id"),
+                                       CONSTRAINT "uq_user_group_name" UNIQUE ("tenant_id", "name"),
+                                       CONSTRAINT "fk_user_group_tenant" FOREIGN KEY ("tenant_id") REFERENCES "public"."tenant"("tenant_id") ON DELETE CASCADE
+);
+
+CREATE TABLE "public"."user_group_member" (
+                                              "group_id"   uuid NOT NULL,
+                                              "user_id"    uuid NOT NULL,
+                                              "created_at" timestamptz NOT NULL DEFAULT now(),
+
+                                              CONSTRAINT "pk_user_group_member" PRIMARY KEY ("group_id", "user_id"),
+                                              CONSTRAINT "fk_group_member_group" FOREIGN KEY ("group_id") REFERENCES "public"."user_group"("group_id") ON DELETE CASCADE,
+                                              CONSTRAINT "fk_group_member_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE
+);
+CREATE INDEX "idx_group_member_user" ON "public"."user_group_member" ("user_id");
+
+-- Uma linha de acesso referencia um usuário ou um grupo, nunca os dois. A
+-- chave primária passa a ser própria; as unicidades por usuário e por grupo
+-- ficam em constraints, e como NULLs são distintos as linhas de grupo não
+-- conflitam no ON CONFLICT (user_id, dashboard_id) das concessões diretas.
+ALTER TABLE "public"."user_dashboard_access"
+    DROP CONSTRAINT "pk_user_dashboard_access",
+    ADD COLUMN "access_id" uuid NOT NULL DEFAULT uuidv7(),
+    ADD COLUMN "group_id" uuid,
+    ALTER COLUMN "user_id" DROP NOT NULL,
+    ADD CONSTRAINT "pk_user_dashboard_access" PRIMARY KEY ("access_id"),
+    ADD CONSTRAINT "uq_access_user_dashboard" UNIQUE ("user_id", "dashboard_id"),
+    ADD CONSTRAINT "uq_access_group_dashboard" UNIQUE ("group_id", "dashboard_id"),
+    ADD CONSTRAINT "ck_access_subject" CHECK (num_nonnulls("user_id", "group_id") = 1),
+    ADD CONSTRAINT "fk_access_group" FOREIGN KEY ("group_id") REFERENCES "public"."user_group"("group_id") ON DELETE CASCADE;
+
+-- Quem troca de cliente ou deixa de ser membro sai dos grupos do cliente
+-- anterior, para não levar o acesso dos grupos junto.
+CREATE FUNCTION "public"."sync_group_membership"() RETURNS trigger AS $$
+BEGIN
+    DELETE FROM "public"."user_group_member" AS gm
+    USING "public"."user_group" AS g
+    WHERE gm.group_id = g.group_id
+      AND gm.user_id = OLD.user_id
+      AND (TG_OP = 'DELETE' OR g.tenant_id <> NEW.tenant_id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_membership_groups"
+    AFTER UPDATE OF "tenant_id" OR DELETE
+    ON "public"."tenant_membership"
+    FOR EACH ROW EXECUTE FUNCTION "public"."sync_group_membership"();
+
+-- +goose Down
+
+DROP TRIGGER IF EXISTS "trg_membership_groups" ON "public"."tenant_membership";
+DROP FUNCTION IF EXISTS "public"."sync_group_membership"();
+
+DELETE FROM "public"."user_dashboard_access" WHERE "group_id" IS NOT NULL;
+
+ALTER TABLE "public"."user_dashboard_access"
+    DROP CONSTRAINT "fk_access_group",
+    DROP CONSTRAINT "ck_access_subject",
+    DROP CONSTRAINT "uq_access_group_dashboard",
+    DROP CONSTRAINT "uq_access_user_dashboard",
+    DROP CONSTRAINT "pk_user_dashboard_access",
+    DROP COLUMN "group_id",
+    DROP COLUMN "access_id",
+    ALTER COLUMN "user_id" SET NOT NULL,
+    ADD CONSTRAINT "pk_user_dashboard_access" PRIMARY KEY ("user_id", "dashboard_id");
+
+DROP TABLE IF EXISTS "public"."user_group_member" CASCADE;
+DROP TABLE IF EXISTS "public"."user_group" CASCADE;
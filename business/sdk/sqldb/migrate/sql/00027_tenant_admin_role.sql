@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Administrador de um único cliente: gerencia usuários, acessos e a
+-- identidade visual do próprio cliente. Não recebe políticas por perfil,
+-- que valem para os recursos de todos os clientes.
+INSERT INTO "public"."role" ("role_id", "name") VALUES
+    (4, 'TENANT_ADMIN')
+ON CONFLICT ("role_id") DO NOTHING;
+
+-- +goose Down
+
+-- Os administradores de cliente voltam a ser usuários comuns.
+UPDATE "public"."users" SET "role_id" = 2 WHERE "role_id" = 4;
+DELETE FROM "public"."role_policy" WHERE "role_id" = 4;
+DELETE FROM "public"."role" WHERE "role_id" = 4;
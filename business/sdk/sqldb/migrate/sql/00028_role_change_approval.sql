@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- Clientes com exigência de compliance pedem um segundo aprovador para
+-- promover alguém a ADMIN (quatro olhos).
+ALTER TABLE "public"."tenant" ADD COLUMN "role_approval" boolean NOT NULL DEFAULT false;
+
+-- Trocas de perfil aguardando o segundo aprovador. O pedido decidido fica
+-- como registro de quem pediu e de quem aprovou ou rejeitou.
+CREATE TABLE "public"."role_change" (
+                                        "change_id"    uuid NOT NULL DEFAULT uuidv7(),
+                                        "user_id"      uuid NOT NULL,
+                                        "role_id"      smallint NOT NULL,
+                                        "status"       varchar(20) NOT NULL DEFAULT 'PENDING',
+                                        "requested_by" uuid NOT NULL,
+                                        "reviewed_by"  uuid,
+                                        "created_at"   timestamptz NOT NULL DEFAULT now(),
+                                        "reviewed_at"  timestamptz,
+
+                                        CONSTRAINT "pk_role_change" PRIMARY KEY ("change_id"),
+                                        CONSTRAINT "ck_role_change_status" CHECK ("status" IN ('PENDING', 'APPROVED', 'REJECTED')),
+                                        CONSTRAINT "fk_role_change_user" FOREIGN KEY ("user_id") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                        CONSTRAINT "fk_role_change_role" FOREIGN KEY ("role_id") REFERENCES "public"."role"("role_id"),
+                                        CONSTRAINT "fk_role_change_requester" FOREIGN KEY ("requested_by") REFERENCES "public"."users"("user_id") ON DELETE CASCADE,
+                                        CONSTRAINT "fk_role_change_reviewer" FOREIGN KEY ("reviewed_by") REFERENCES "public"."users"("user_id") ON DELETE SET NULL
+);
+
+-- Um único pedido pendente por usuário; a lista de pendências é a consulta
+-- mais comum.
+CREATE UNIQUE INDEX "uq_role_change_pending" ON "public"."role_change" ("user_id") WHERE "status" = 'PENDING';
+CREATE INDEX "idx_role_change_status" ON "public"."role_change" ("status", "created_at" DESC);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS "public"."role_change" CASCADE;
+ALTER TABLE "public"."tenant" DROP COLUMN IF EXISTS "role_approval";
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Valor que só os consumidores internos leem, como o token de um link de
+-- confirmação. Fica cifrado fora do payload, nunca vai para brokers nem
+-- webhooks e é apagado quando o evento é publicado.
+ALTER TABLE "public"."outbox" ADD COLUMN "secret" bytea;
+
+-- Os eventos gravados antes traziam o token aberto no payload.
+UPDATE "public"."outbox"
+SET "payload" = "payload" - 'token'
+WHERE "event_type" = 'user.email_change_requested';
+
+-- +goose Down
+
+ALTER TABLE "public"."outbox" DROP COLUMN IF EXISTS "secret";
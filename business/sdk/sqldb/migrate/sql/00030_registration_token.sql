@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- O token do cadastro também passa para o segredo do evento; os eventos
+-- gravados antes o traziam aberto no payload.
+UPDATE "public"."outbox"
+SET "payload" = "payload" - 'token'
+WHERE "event_type" = 'user.registration_requested';
+
+-- +goose Down
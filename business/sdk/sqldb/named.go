@@ -0,0 +1,261 @@
+package sqldb
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"unicode"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/cachestats"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// namedCacheSize caps the compiled named queries kept. Queries built from
+// filters change with the filter, so only the most recently used are kept.
+const namedCacheSize = 1024
+
+// compiledQuery is a named query translated to positional parameters once,
+// instead of on every call like sqlx does.
+type compiledQuery struct {
+	question string // Com ?, como sqlx.In espera.
+	dollar   string // Com $n, como o Postgres espera.
+	names    []string
+}
+
+type namedEntry struct {
+	query string
+	cq    compiledQuery
+}
+
+// namedCache is an LRU of compiled named queries.
+type namedCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	stats   *cachestats.Recorder
+}
+
+var named = namedCache{
+	entries: make(map[string]*list.Element),
+	order:   list.New(),
+	stats:   cachestats.NewRecorder(),
+}
+
+func init() {
+	cachestats.Register("sqldb.named", func() any {
+		named.mu.Lock()
+		defer named.mu.Unlock()
+
+		return named.stats.Stats(named.order.Len())
+	})
+}
+
+// compile returns the compiled form of the query from the cache, compiling
+// and keeping it on a miss.
+func (c *namedCache) compile(query string) (compiledQuery, error) {
+	c.mu.Lock()
+	if e, exists := c.entries[query]; exists {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		c.stats.CacheHit()
+		return e.Value.(*namedEntry).cq, nil
+	}
+	c.mu.Unlock()
+	c.stats.CacheMiss()
+
+	cq, err := compileNamed(query)
+	if err != nil {
+		return compiledQuery{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[query]; !exists {
+		c.entries[query] = c.order.PushFront(&namedEntry{query: query, cq: cq})
+
+		if c.order.Len() > namedCacheSize {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*namedEntry).query)
+			c.stats.ForcedEviction()
+		}
+	}
+
+	return cq, nil
+}
+
+// =============================================================================
+
+// errUnsupportedArg reports an argument the cached binding does not handle,
+// like the slices of a batch insert. Those are bound by sqlx.
+var errUnsupportedArg = errors.New("unsupported named argument")
+
+// mapper resolves the named parameters to struct fields with the same rules
+// sqlx uses by default, the db tag or the lower case field name.
+var mapper = sync.OnceValue(func() *reflectx.Mapper {
+	return reflectx.NewMapperFunc("db", sqlx.NameMapper)
+})
+
+// bindNamed returns the query with $n parameters and its arguments taken
+// from data, a struct or a map[string]any.
+func bindNamed(db sqlx.ExtContext, query string, data any) (string, []any, error) {
+	cq, err := named.compile(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args, err := bindArgs(cq.names, data)
+	if err != nil {
+		if errors.Is(err, errUnsupportedArg) {
+			return db.BindNamed(query, data)
+		}
+		return "", nil, err
+	}
+
+	return cq.dollar, args, nil
+}
+
+// bindNamedIn is bindNamed for queries with an IN clause, where the slices
+// in data are expanded into a parameter per element.
+func bindNamedIn(db sqlx.ExtContext, query string, data any) (string, []any, error) {
+	q, args, err := bindQuestion(query, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q, args, err = sqlx.In(q, args...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return db.Rebind(q), args, nil
+}
+
+// bindQuestion returns the query with ? parameters and its arguments.
+func bindQuestion(query string, data any) (string, []any, error) {
+	cq, err := named.compile(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args, err := bindArgs(cq.names, data)
+	if err != nil {
+		if errors.Is(err, errUnsupportedArg) {
+			return sqlx.Named(query, data)
+		}
+		return "", nil, err
+	}
+
+	return cq.question, args, nil
+}
+
+func bindArgs(names []string, data any) ([]any, error) {
+	if m, ok := data.(map[string]any); ok {
+		args := make([]any, len(names))
+		for i, name := range names {
+			v, exists := m[name]
+			if !exists {
+				return nil, fmt.Errorf("could not find name %s in %#v", name, data)
+			}
+			args[i] = v
+		}
+		return args, nil
+	}
+
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, errUnsupportedArg
+	}
+
+	traversals := mapper().TraversalsByName(v.Type(), names)
+
+	args := make([]any, len(names))
+	for i, t := range traversals {
+		if len(t) == 0 {
+			return nil, fmt.Errorf("could not find name %s in %#v", names[i], data)
+		}
+		args[i] = reflectx.FieldByIndexesReadOnly(v, t).Interface()
+	}
+
+	return args, nil
+}
+
+// compileNamed translates the :name parameters to positional ones following
+// the rules of sqlx, so the queries bound here and by sqlx are the same: "::"
+// is a literal colon and ":=" is left untouched.
+func compileNamed(query string) (compiledQuery, error) {
+	qs := []byte(query)
+	question := make([]byte, 0, len(qs))
+	dollar := make([]byte, 0, len(qs)+8)
+	names := make([]string, 0, 10)
+
+	isNameByte := func(b byte) bool {
+		return unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+	}
+
+	inName := false
+	last := len(qs) - 1
+	name := make([]byte, 0, 10)
+
+	for i, b := range qs {
+		switch {
+		case b == ':':
+			if inName && i > 0 && qs[i-1] == ':' {
+				question = append(question, ':')
+				dollar = append(dollar, ':')
+				inName = false
+				continue
+			}
+			if inName {
+				return compiledQuery{}, errors.New("unexpected `:` while reading named param at " + strconv.Itoa(i))
+			}
+			inName = true
+			name = name[:0]
+
+		case inName && i > 0 && b == '=' && len(name) == 0:
+			question = append(question, ':', '=')
+			dollar = append(dollar, ':', '=')
+			inName = false
+
+		case inName && (isNameByte(b) || b == '_' || b == '.') && i != last:
+			name = append(name, b)
+
+		case inName:
+			inName = false
+			if i == last && isNameByte(b) {
+				name = append(name, b)
+			}
+
+			names = append(names, string(name))
+			question = append(question, '?')
+			dollar = append(dollar, '$')
+			dollar = strconv.AppendInt(dollar, int64(len(names)), 10)
+
+			if i != last || !isNameByte(b) {
+				question = append(question, b)
+				dollar = append(dollar, b)
+			}
+
+		default:
+			question = append(question, b)
+			dollar = append(dollar, b)
+		}
+	}
+
+	cq := compiledQuery{
+		question: string(question),
+		dollar:   string(dollar),
+		names:    names,
+	}
+
+	return cq, nil
+}
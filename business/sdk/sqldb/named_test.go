@@ -0,0 +1,135 @@
+package sqldb
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Queries of the hot paths, as written in userdb.QueryByID and
+// tenantdb.CheckUserDashboardAccess.
+const (
+	queryByID = `
+	SELECT
+		u.user_id, u.name, u.email, u.pending_email, u.email_scope, u.password AS password_hash, u.phone, u.enabled, u.last_login_at, u.last_login_ip,
+		u.created_at, u.updated_at,
+		r.name AS role
+	FROM
+		"public"."users" AS u
+	JOIN
+		"public"."role" AS r ON r.role_id = u.role_id
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL`
+
+	checkUserDashboardAccess = `
+	SELECT
+		1
+	FROM
+		"public"."user_dashboard_access" AS a
+	LEFT JOIN
+		"public"."user_group_member" AS gm ON gm.group_id = a.group_id AND gm.user_id = :user_id
+	WHERE
+		a.dashboard_id = :dashboard_id
+		AND a.tenant_id = :tenant_id
+		AND (a.user_id = :user_id OR gm.user_id IS NOT NULL)
+	LIMIT 1`
+)
+
+type queryByIDData struct {
+	ID string `db:"user_id"`
+}
+
+type accessData struct {
+	UserID      string `db:"user_id"`
+	DashboardID string `db:"dashboard_id"`
+	TenantID    string `db:"tenant_id"`
+}
+
+var hotQueries = []struct {
+	name  string
+	query string
+	data  any
+}{
+	{
+		name:  "QueryByID",
+		query: queryByID,
+		data:  queryByIDData{ID: "0198f4b2-6c1e-7d3a-9b8f-2a4c6e8f0a1b"},
+	},
+	{
+		name:  "CheckUserDashboardAccess",
+		query: checkUserDashboardAccess,
+		data: accessData{
+			UserID:      "0198f4b2-6c1e-7d3a-9b8f-2a4c6e8f0a1b",
+			DashboardID: "0198f4b2-7a2d-7e4b-8c9d-3b5d7f9a1c2e",
+			TenantID:    "0198f4b2-8b3e-7f5c-9dae-4c6e8a0b2d3f",
+		},
+	},
+}
+
+// TestBindNamed checks the cached binding against sqlx, which it replaces
+// on the hot paths.
+func TestBindNamed(t *testing.T) {
+	db := sqlx.NewDb(nil, "pgx")
+
+	tests := []struct {
+		name  string
+		query string
+		data  any
+	}{
+		{name: "QueryByID", query: hotQueries[0].query, data: hotQueries[0].data},
+		{name: "CheckUserDashboardAccess", query: hotQueries[1].query, data: hotQueries[1].data},
+		{name: "map", query: `SELECT * FROM t WHERE a = :a AND b = :b`, data: map[string]any{"a": 1, "b": "x"}},
+		{name: "pointer", query: `SELECT * FROM t WHERE user_id = :user_id LIMIT 1`, data: &queryByIDData{ID: "id"}},
+		{name: "cast", query: `SELECT CAST(:user_id AS uuid), now()::date`, data: queryByIDData{ID: "id"}},
+		{name: "assign", query: `SELECT set_config('a', :user_id, false) WHERE x := 1`, data: queryByIDData{ID: "id"}},
+		{name: "trailing", query: `SELECT * FROM t WHERE user_id = :user_id`, data: queryByIDData{ID: "id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotQuery, gotArgs, err := bindNamed(db, tt.query, tt.data)
+			if err != nil {
+				t.Fatalf("bindNamed: %s", err)
+			}
+
+			wantQuery, wantArgs, err := db.BindNamed(tt.query, tt.data)
+			if err != nil {
+				t.Fatalf("sqlx: %s", err)
+			}
+
+			if gotQuery != wantQuery {
+				t.Errorf("got query\n%s\nwant\n%s", gotQuery, wantQuery)
+			}
+			if !slices.Equal(gotArgs, wantArgs) {
+				t.Errorf("got args %v, want %v", gotArgs, wantArgs)
+			}
+		})
+	}
+}
+
+// BenchmarkBindNamed compares the cached binding of the hot queries with
+// the binding of sqlx, which compiles the query on every call.
+func BenchmarkBindNamed(b *testing.B) {
+	db := sqlx.NewDb(nil, "pgx")
+
+	for _, hq := range hotQueries {
+		b.Run(hq.name+"/cached", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, _, err := bindNamed(db, hq.query, hq.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(hq.name+"/sqlx", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, _, err := db.BindNamed(hq.query, hq.data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WithConn takes a connection from the pool and runs fn with the native pgx
+// connection underneath it, for features database/sql does not expose.
+func WithConn(ctx context.Context, db *sqlx.DB, fn func(conn *pgx.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("native access requires the pgx driver")
+		}
+
+		return fn(sc.Conn())
+	})
+}
+
+// SendBatch sends every query of the batch to the database in a single round
+// trip. The batch runs in an implicit transaction: if one statement fails
+// none of them are applied.
+func SendBatch(ctx context.Context, log *logger.Logger, db *sqlx.DB, batch *pgx.Batch) (err error) {
+	defer func() {
+		if err != nil {
+			log.Infoc(ctx, 5, "database.SendBatch", "queries", batch.Len(), "ERROR", err)
+		}
+	}()
+
+	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.batch", attribute.Int("queries", batch.Len()))
+	defer span.End()
+
+	return WithConn(ctx, db, func(conn *pgx.Conn) error {
+		if err := conn.SendBatch(ctx, batch).Close(); err != nil {
+			return toDBError(err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,120 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Postgres error codes that are safe to retry since the statement was
+// rolled back by the server.
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// RetryPolicy controls how the helpers retry transient failures.
+// MaxAttempts includes the first call, so 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var (
+	retryMu     sync.RWMutex
+	retryPolicy = RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+)
+
+// SetRetryPolicy replaces the retry policy used by the query and exec
+// helpers. Values not set keep the defaults.
+func SetRetryPolicy(p RetryPolicy) {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+
+	if p.MaxAttempts > 0 {
+		retryPolicy.MaxAttempts = p.MaxAttempts
+	}
+	if p.BaseDelay > 0 {
+		retryPolicy.BaseDelay = p.BaseDelay
+	}
+	if p.MaxDelay > 0 {
+		retryPolicy.MaxDelay = p.MaxDelay
+	}
+}
+
+// withRetry calls fn again while it fails with a transient error, waiting a
+// jittered exponential backoff between attempts and adding an event to the
+// span for each retry. Statements running inside a transaction are never
+// retried: after a failure the transaction is aborted and only the whole
+// transaction can be run again.
+func withRetry(ctx context.Context, span trace.Span, db sqlx.ExtContext, readOnly bool, fn func() error) error {
+	if _, ok := db.(*sqlx.Tx); ok {
+		return fn()
+	}
+
+	retryMu.RLock()
+	p := retryPolicy
+	retryMu.RUnlock()
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !isTransient(err, readOnly) {
+			return err
+		}
+
+		delay := backoff(p, attempt)
+
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("delay", delay.String()),
+			attribute.String("error", err.Error()),
+		))
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
+
+// isTransient reports whether err is worth retrying. A connection reset is
+// only retried for reads or when the driver knows nothing was sent, since a
+// write may have been applied before the connection dropped.
+func isTransient(err error, readOnly bool) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+	}
+
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	return readOnly && errors.Is(err, syscall.ECONNRESET)
+}
+
+// backoff returns the delay before the next attempt using full jitter.
+func backoff(p RetryPolicy, attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
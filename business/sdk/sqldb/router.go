@@ -0,0 +1,83 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Router implements sqlx.ExtContext sending read-only queries to a replica
+// and every other statement to the primary. Transactions are always begun on
+// the primary so work done through NewWithTx never reaches the replica.
+//
+// Reads from the replica may lag behind the primary; stores whose reads must
+// see the latest writes should use the primary directly.
+type Router struct {
+	primary *sqlx.DB
+	replica *sqlx.DB
+}
+
+// NewRouter constructs a router between the primary and the replica. When no
+// replica is configured the primary is returned as is.
+func NewRouter(primary *sqlx.DB, replica *sqlx.DB) sqlx.ExtContext {
+	if replica == nil {
+		return primary
+	}
+
+	return &Router{
+		primary: primary,
+		replica: replica,
+	}
+}
+
+// DriverName returns the driverName of the primary.
+func (r *Router) DriverName() string {
+	return r.primary.DriverName()
+}
+
+// Rebind transforms a query from QUESTION to the DB driver's bindvar type.
+func (r *Router) Rebind(query string) string {
+	return r.primary.Rebind(query)
+}
+
+// BindNamed binds a query using the DB driver's bindvar type.
+func (r *Router) BindNamed(query string, arg any) (string, []any, error) {
+	return r.primary.BindNamed(query, arg)
+}
+
+// QueryContext queries the replica when the query only reads data.
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.route(query).QueryContext(ctx, query, args...)
+}
+
+// QueryxContext queries the replica when the query only reads data.
+func (r *Router) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	return r.route(query).QueryxContext(ctx, query, args...)
+}
+
+// QueryRowxContext queries the replica when the query only reads data.
+func (r *Router) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	return r.route(query).QueryRowxContext(ctx, query, args...)
+}
+
+// ExecContext always runs on the primary.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// route picks the database for a query. Row locks can only be taken on the
+// primary.
+func (r *Router) route(query string) *sqlx.DB {
+	if !isSelect(query) {
+		return r.primary
+	}
+
+	upper := strings.ToUpper(query)
+	if strings.Contains(upper, "FOR UPDATE") || strings.Contains(upper, "FOR SHARE") {
+		return r.primary
+	}
+
+	return r.replica
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,20 +19,28 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
-// lib/pq errorCodeNames
-// https://github.com/lib/pq/blob/master/error.go#L178
+// Postgres error codes.
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
 const (
-	uniqueViolation = "23505"
-	undefinedTable  = "42P01"
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	undefinedTable      = "42P01"
 )
 
 // Set of error variables for CRUD operations.
 
+// ErrDBDuplicatedEntry is returned when a unique constraint is violated.
+// Postgres reports the constraint name; the column is only filled in when
+// the server provides it.
 type ErrDBDuplicatedEntry struct {
-	Column string
+	Column     string
+	Constraint string
 }
 
 func (e ErrDBDuplicatedEntry) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("duplicated entry for constraint %q", e.Constraint)
+	}
 	return fmt.Sprintf("duplicated entry for column %q", e.Column)
 }
 func (e ErrDBDuplicatedEntry) Is(target error) bool {
@@ -39,6 +48,20 @@ func (e ErrDBDuplicatedEntry) Is(target error) bool {
 	return ok
 }
 
+// ErrDBForeignKey is returned when a row is still referenced by another, or
+// references one that does not exist.
+type ErrDBForeignKey struct {
+	Constraint string
+}
+
+func (e ErrDBForeignKey) Error() string {
+	return fmt.Sprintf("foreign key violation for constraint %q", e.Constraint)
+}
+func (e ErrDBForeignKey) Is(target error) bool {
+	_, ok := target.(ErrDBForeignKey)
+	return ok
+}
+
 var (
 	ErrDBNotFound     = sql.ErrNoRows
 	ErrUndefinedTable = errors.New("undefined table")
@@ -46,14 +69,21 @@ var (
 
 // Config is the required properties to use the database.
 type Config struct {
-	User         string
-	Password     string
-	Host         string
-	Name         string
-	Schema       string
-	MaxIdleConns int
-	MaxOpenConns int
-	DisableTLS   bool
+	User             string
+	Password         string
+	Host             string
+	Name             string
+	ReplicaHost      string
+	Schema           string
+	MaxIdleConns     int
+	MaxOpenConns     int
+	DisableTLS       bool
+	StatementTimeout time.Duration
+
+	// StatementCache is the number of prepared statements pgx keeps on each
+	// connection, evicting the least recently used. Zero keeps the pgx
+	// default.
+	StatementCache int
 }
 
 // Open knows how to open a database connection based on the configuration.
@@ -70,6 +100,16 @@ func Open(cfg Config) (*sqlx.DB, error) {
 		q.Set("search_path", cfg.Schema)
 	}
 
+	if cfg.StatementCache > 0 {
+		q.Set("statement_cache_capacity", strconv.Itoa(cfg.StatementCache))
+	}
+
+	// Parâmetros desconhecidos pelo pgx são enviados como parâmetros de
+	// sessão, então o limite vale para toda conexão do pool.
+	if cfg.StatementTimeout > 0 {
+		q.Set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+
 	u := url.URL{
 		Scheme:   "postgres",
 		User:     url.UserPassword(cfg.User, cfg.Password),
@@ -88,6 +128,19 @@ func Open(cfg Config) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// OpenReplica opens a connection to the read replica using the same
+// configuration as the primary with ReplicaHost as the host. It returns nil
+// when no replica is configured.
+func OpenReplica(cfg Config) (*sqlx.DB, error) {
+	if cfg.ReplicaHost == "" {
+		return nil, nil
+	}
+
+	cfg.Host = cfg.ReplicaHost
+
+	return Open(cfg)
+}
+
 // StatusCheck returns nil if it can successfully talk to the database. It
 // returns a non-nil error otherwise.
 func StatusCheck(ctx context.Context, db *sqlx.DB) error {
@@ -132,39 +185,66 @@ func ExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, qu
 // logging and tracing where field replacement is necessary.
 func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (err error) {
 	q := queryString(query, data)
+	start := time.Now()
 
 	defer func() {
+		caller := 5
+		if _, ok := data.(struct{}); ok {
+			caller = 6
+		}
+
 		if err != nil {
-			switch data.(type) {
-			case struct{}:
-				log.Infoc(ctx, 6, "database.NamedExecContext", "query", q, "ERROR", err)
-			default:
-				log.Infoc(ctx, 5, "database.NamedExecContext", "query", q, "ERROR", err)
-			}
+			log.Infoc(ctx, caller, "database.NamedExecContext", "query", q, "ERROR", err)
 		}
+
+		logSlow(ctx, log, caller+1, "database.NamedExecContext", query, start)
 	}()
 
 	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.exec", attribute.String("query", q))
 	defer span.End()
 
-	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
-		var pqerr *pgconn.PgError
-		if errors.As(err, &pqerr) {
-			switch pqerr.Code {
-			case undefinedTable:
-				return ErrUndefinedTable
-			case uniqueViolation:
-				return ErrDBDuplicatedEntry{
-					Column: pqerr.ColumnName,
-				}
-			}
-		}
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
+
+	bound, args, err := bindNamed(db, query, data)
+	if err != nil {
 		return err
 	}
 
+	exec := func() error {
+		_, err := db.ExecContext(ctx, bound, args...)
+		return err
+	}
+
+	if err := withRetry(ctx, span, db, false, exec); err != nil {
+		return toDBError(err)
+	}
+
 	return nil
 }
 
+// toDBError converts the pgx error details into the package errors.
+func toDBError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case undefinedTable:
+			return ErrUndefinedTable
+		case uniqueViolation:
+			return ErrDBDuplicatedEntry{
+				Column:     pgErr.ColumnName,
+				Constraint: pgErr.ConstraintName,
+			}
+		case foreignKeyViolation:
+			return ErrDBForeignKey{
+				Constraint: pgErr.ConstraintName,
+			}
+		}
+	}
+
+	return err
+}
+
 // QuerySlice is a helper function for executing queries that return a
 // collection of data to be unmarshalled into a slice.
 func QuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, dest *[]T) error {
@@ -187,39 +267,32 @@ func NamedQuerySliceUsingIn[T any](ctx context.Context, log *logger.Logger, db s
 
 func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest *[]T, withIn bool) (err error) {
 	q := queryString(query, data)
+	start := time.Now()
 
 	defer func() {
 		if err != nil {
 			log.Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
 		}
+
+		logSlow(ctx, log, 7, "database.NamedQuerySlice", query, start)
 	}()
 
 	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.queryslice", attribute.String("query", q))
 	defer span.End()
 
-	var rows *sqlx.Rows
-
-	switch withIn {
-	case true:
-		rows, err = func() (*sqlx.Rows, error) {
-			named, args, err := sqlx.Named(query, data)
-			if err != nil {
-				return nil, err
-			}
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
-			query, args, err := sqlx.In(named, args...)
-			if err != nil {
-				return nil, err
-			}
-
-			query = db.Rebind(query)
-			return db.QueryxContext(ctx, query, args...)
-		}()
+	var rows *sqlx.Rows
 
-	default:
-		rows, err = sqlx.NamedQueryContext(ctx, db, query, data)
+	queryx := func() error {
+		var err error
+		rows, err = namedQueryx(ctx, db, query, data, withIn)
+		return err
 	}
 
+	err = withRetry(ctx, span, db, isSelect(query), queryx)
+
 	if err != nil {
 		var pqerr *pgconn.PgError
 		if errors.As(err, &pqerr) && pqerr.Code == undefinedTable {
@@ -263,39 +336,32 @@ func NamedQueryStructUsingIn(ctx context.Context, log *logger.Logger, db sqlx.Ex
 
 func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest any, withIn bool) (err error) {
 	q := queryString(query, data)
+	start := time.Now()
 
 	defer func() {
 		if err != nil {
 			log.Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
 		}
+
+		logSlow(ctx, log, 7, "database.NamedQuerySlice", query, start)
 	}()
 
 	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.query", attribute.String("query", q))
 	defer span.End()
 
-	var rows *sqlx.Rows
+	ctx, cancel := queryContext(ctx)
+	defer cancel()
 
-	switch withIn {
-	case true:
-		rows, err = func() (*sqlx.Rows, error) {
-			named, args, err := sqlx.Named(query, data)
-			if err != nil {
-				return nil, err
-			}
-
-			query, args, err := sqlx.In(named, args...)
-			if err != nil {
-				return nil, err
-			}
-
-			query = db.Rebind(query)
-			return db.QueryxContext(ctx, query, args...)
-		}()
+	var rows *sqlx.Rows
 
-	default:
-		rows, err = sqlx.NamedQueryContext(ctx, db, query, data)
+	queryx := func() error {
+		var err error
+		rows, err = namedQueryx(ctx, db, query, data, withIn)
+		return err
 	}
 
+	err = withRetry(ctx, span, db, isSelect(query), queryx)
+
 	if err != nil {
 		var pqerr *pgconn.PgError
 		if errors.As(err, &pqerr) && pqerr.Code == undefinedTable {
@@ -316,9 +382,31 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	return nil
 }
 
+// namedQueryx runs the query after binding the named parameters. The
+// compiled form of the query is cached and the prepared statement is cached
+// by pgx on each connection, so a hot query is parsed only once.
+func namedQueryx(ctx context.Context, db sqlx.ExtContext, query string, data any, withIn bool) (*sqlx.Rows, error) {
+	bind := bindNamed
+	if withIn {
+		bind = bindNamedIn
+	}
+
+	query, args, err := bind(db, query, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryxContext(ctx, query, args...)
+}
+
+// isSelect reports whether the query only reads data.
+func isSelect(query string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT")
+}
+
 // queryString provides a pretty print version of the query and parameters.
 func queryString(query string, args any) string {
-	query, params, err := sqlx.Named(query, args)
+	query, params, err := bindQuestion(query, args)
 	if err != nil {
 		return err.Error()
 	}
@@ -0,0 +1,59 @@
+package sqldb
+
+import (
+	"context"
+	"expvar"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// PublishStats exposes the connection pool statistics of the database under
+// the expvar name, so they are served by /debug/vars.
+func PublishStats(name string, db *sqlx.DB) {
+	expvar.Publish(name, expvar.Func(func() any {
+		s := db.Stats()
+
+		return map[string]any{
+			"max_open":            s.MaxOpenConnections,
+			"open":                s.OpenConnections,
+			"in_use":              s.InUse,
+			"idle":                s.Idle,
+			"wait_count":          s.WaitCount,
+			"wait_duration_ms":    s.WaitDuration.Milliseconds(),
+			"max_idle_closed":     s.MaxIdleClosed,
+			"max_idle_time_close": s.MaxIdleTimeClosed,
+			"max_lifetime_closed": s.MaxLifetimeClosed,
+		}
+	}))
+}
+
+// slowQuery holds the threshold, in nanoseconds, above which statements are
+// logged. Zero disables the log.
+var slowQuery atomic.Int64
+
+// SetSlowQueryThreshold sets the duration above which statements run by the
+// helpers are logged as slow. Zero disables the log.
+func SetSlowQueryThreshold(d time.Duration) {
+	slowQuery.Store(int64(d))
+}
+
+// logSlow logs the statement when it took longer than the threshold. Only
+// the statement with its named placeholders is logged, never the parameter
+// values. The trace id is added by the logger.
+func logSlow(ctx context.Context, log *logger.Logger, caller int, helper string, query string, start time.Time) {
+	threshold := time.Duration(slowQuery.Load())
+	if threshold <= 0 {
+		return
+	}
+
+	d := time.Since(start)
+	if d < threshold {
+		return
+	}
+
+	log.Warnc(ctx, caller, helper, "status", "slow query", "query", strings.Join(strings.Fields(query), " "), "duration", d.String())
+}
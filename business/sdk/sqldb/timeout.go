@@ -0,0 +1,26 @@
+package sqldb
+
+import (
+	"context"
+	"time"
+)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout returns a context that bounds every statement the helpers
+// run with it to d, independently of the request deadline. Use it for
+// reporting queries that must not hold an API worker for long.
+func WithQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, d)
+}
+
+// queryContext applies the per-query timeout set with WithQueryTimeout, if
+// any, to a single statement.
+func queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Value(queryTimeoutKey{}).(time.Duration)
+	if !ok || d <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, d)
+}
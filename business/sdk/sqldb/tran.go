@@ -2,6 +2,7 @@ package sqldb
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -21,7 +22,8 @@ type CommitRollbacker interface {
 
 // DBBeginner implements the Beginner interface,
 type DBBeginner struct {
-	sqlxDB *sqlx.DB
+	sqlxDB           *sqlx.DB
+	statementTimeout time.Duration
 }
 
 // NewBeginner constructs a value that implements the beginner interface.
@@ -31,10 +33,33 @@ func NewBeginner(sqlxDB *sqlx.DB) *DBBeginner {
 	}
 }
 
+// WithStatementTimeout returns a beginner whose transactions abort any
+// statement running longer than d, overriding the connection setting for
+// the transaction only.
+func (db *DBBeginner) WithStatementTimeout(d time.Duration) *DBBeginner {
+	return &DBBeginner{
+		sqlxDB:           db.sqlxDB,
+		statementTimeout: d,
+	}
+}
+
 // Begin implements the Beginner interface and returns a concrete value that
 // implements the CommitRollbacker interface.
 func (db *DBBeginner) Begin() (CommitRollbacker, error) {
-	return db.sqlxDB.Beginx()
+	tx, err := db.sqlxDB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	if db.statementTimeout > 0 {
+		q := fmt.Sprintf("SET LOCAL statement_timeout = %d", db.statementTimeout.Milliseconds())
+		if _, err := tx.Exec(q); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("set statement timeout: %w", err)
+		}
+	}
+
+	return tx, nil
 }
 
 // GetExtContext is a helper function that extracts the sqlx value
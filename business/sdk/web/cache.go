@@ -0,0 +1,136 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type httpHeader interface {
+	HTTPHeader() http.Header
+}
+
+// validated is a response the client revalidates instead of downloading it
+// again.
+type validated struct {
+	data        []byte
+	contentType string
+	err         error
+	header      http.Header
+	status      int
+}
+
+// Validated wraps a response to a GET so the client can revalidate it with
+// If-None-Match or If-Modified-Since. The ETag is the hash of the encoded
+// body, so it changes with any field of the response; modified is sent as
+// Last-Modified unless zero. A request whose validators still match is
+// answered with 304 Not Modified and no body.
+func Validated(r *http.Request, resp Encoder, modified time.Time) Encoder {
+	data, contentType, err := resp.Encode()
+	if err != nil {
+		return validated{err: err}
+	}
+
+	etag := etagOf(data)
+
+	// As respostas são do usuário da sessão: o navegador guarda, mas
+	// revalida a cada uso, e proxies compartilhados não guardam.
+	v := validated{
+		data:        data,
+		contentType: contentType,
+		header: http.Header{
+			"Etag":          {etag},
+			"Cache-Control": {"private, no-cache"},
+		},
+		status: http.StatusOK,
+	}
+
+	if !modified.IsZero() {
+		v.header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		v.status = http.StatusNotModified
+	}
+
+	return v
+}
+
+// Encode implements the Encoder interface.
+func (v validated) Encode() ([]byte, string, error) {
+	return v.data, v.contentType, v.err
+}
+
+// HTTPStatus implements the httpStatus interface.
+func (v validated) HTTPStatus() int {
+	if v.err != nil {
+		return http.StatusInternalServerError
+	}
+
+	return v.status
+}
+
+// HTTPHeader implements the httpHeader interface.
+func (v validated) HTTPHeader() http.Header {
+	return v.header
+}
+
+// IfMatch reports whether the If-Match precondition of a write holds for the
+// current representation of the resource, the one its GET is answered with.
+// A request without If-Match always matches.
+func IfMatch(r *http.Request, current Encoder) (bool, error) {
+	im := r.Header.Get("If-Match")
+	if im == "" {
+		return true, nil
+	}
+
+	data, _, err := current.Encode()
+	if err != nil {
+		return false, err
+	}
+
+	etag := etagOf(data)
+
+	// If-Match usa a comparação forte: uma tag fraca nunca confere.
+	for tag := range strings.SplitSeq(im, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// etagOf returns the strong ETag of an encoded body.
+func etagOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports whether the copy the client holds is still current.
+// If-None-Match takes precedence over If-Modified-Since, as in RFC 9110.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for tag := range strings.SplitSeq(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(ims)
+}
@@ -0,0 +1,150 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/web"
+)
+
+type dashboard struct {
+	Name string `json:"name"`
+	Logo string `json:"logo"`
+}
+
+func (d dashboard) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(d)
+	return data, "application/json", err
+}
+
+func TestValidated(t *testing.T) {
+	resp := dashboard{Name: "Sales", Logo: "data:image/png;base64,AAAA"}
+	modified := time.Date(2026, 10, 16, 9, 0, 0, 500, time.UTC)
+
+	// Primeira leitura, sem validadores, para saber a tag da resposta.
+	first := respond(t, resp, modified, nil)
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("got no ETag")
+	}
+
+	changed := respond(t, dashboard{Name: "Sales", Logo: "data:image/png;base64,BBBB"}, modified, nil)
+	if changed.Header().Get("ETag") == etag {
+		t.Fatal("got the same ETag for a different body")
+	}
+
+	lastModified := modified.Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		modified time.Time
+		header   http.Header
+		want     int
+	}{
+		{name: "first", modified: modified, want: http.StatusOK},
+		{name: "etag", modified: modified, header: http.Header{"If-None-Match": {etag}}, want: http.StatusNotModified},
+		{name: "weakEtag", modified: modified, header: http.Header{"If-None-Match": {"W/" + etag}}, want: http.StatusNotModified},
+		{name: "etagList", modified: modified, header: http.Header{"If-None-Match": {`"other", ` + etag}}, want: http.StatusNotModified},
+		{name: "anyEtag", modified: modified, header: http.Header{"If-None-Match": {"*"}}, want: http.StatusNotModified},
+		{name: "otherEtag", modified: modified, header: http.Header{"If-None-Match": {`"other"`}}, want: http.StatusOK},
+		{name: "sameDate", modified: modified, header: http.Header{"If-Modified-Since": {lastModified}}, want: http.StatusNotModified},
+		{name: "newerDate", modified: modified, header: http.Header{"If-Modified-Since": {modified.Add(time.Hour).Format(http.TimeFormat)}}, want: http.StatusNotModified},
+		{name: "olderDate", modified: modified, header: http.Header{"If-Modified-Since": {modified.Add(-time.Second).Format(http.TimeFormat)}}, want: http.StatusOK},
+		{name: "badDate", modified: modified, header: http.Header{"If-Modified-Since": {"yesterday"}}, want: http.StatusOK},
+		{name: "etagOverDate", modified: modified, header: http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}}, want: http.StatusOK},
+		{name: "noModified", header: http.Header{"If-Modified-Since": {lastModified}}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := respond(t, resp, tt.modified, tt.header)
+
+			if w.Code != tt.want {
+				t.Fatalf("got status %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("got ETag %s, want %s", got, etag)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "private, no-cache" {
+				t.Errorf("got Cache-Control %q, want %q", got, "private, no-cache")
+			}
+
+			wantLastModified := lastModified
+			if tt.modified.IsZero() {
+				wantLastModified = ""
+			}
+			if got := w.Header().Get("Last-Modified"); got != wantLastModified {
+				t.Errorf("got Last-Modified %q, want %q", got, wantLastModified)
+			}
+
+			switch tt.want {
+			case http.StatusOK:
+				want, _, _ := resp.Encode()
+				if w.Body.String() != string(want) {
+					t.Errorf("got body %s, want %s", w.Body, want)
+				}
+			case http.StatusNotModified:
+				if w.Body.Len() != 0 {
+					t.Errorf("got body %s, want none", w.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	current := dashboard{Name: "Sales"}
+	etag := respond(t, current, time.Time{}, nil).Header().Get("ETag")
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{name: "absent", want: true},
+		{name: "current", ifMatch: etag, want: true},
+		{name: "list", ifMatch: `"other", ` + etag, want: true},
+		{name: "any", ifMatch: "*", want: true},
+		{name: "weak", ifMatch: "W/" + etag, want: false},
+		{name: "stale", ifMatch: `"other"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/v1/dashboard", nil)
+			if tt.ifMatch != "" {
+				r.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got, err := web.IfMatch(r, current)
+			if err != nil {
+				t.Fatalf("ifMatch: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func respond(t *testing.T, resp web.Encoder, modified time.Time, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/dashboard", nil)
+	for k, vs := range header {
+		r.Header[k] = vs
+	}
+
+	w := httptest.NewRecorder()
+	if err := web.Respond(context.Background(), w, web.Validated(r, resp, modified)); err != nil {
+		t.Fatalf("respond: %s", err)
+	}
+
+	return w
+}
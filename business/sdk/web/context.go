@@ -13,6 +13,7 @@ type ctxKey int
 const (
 	tracerKey ctxKey = iota + 1
 	writerKey
+	decodeKey
 )
 
 func setTracer(ctx context.Context, tracer trace.Tracer) context.Context {
@@ -44,3 +45,25 @@ func GetWriter(ctx context.Context) http.ResponseWriter {
 
 	return v
 }
+
+func setDecodeConfig(ctx context.Context, cfg decodeConfig) context.Context {
+	return context.WithValue(ctx, decodeKey, cfg)
+}
+
+func getDecodeConfig(ctx context.Context) decodeConfig {
+	v, ok := ctx.Value(decodeKey).(decodeConfig)
+	if !ok {
+		return decodeConfig{maxBytes: DefaultMaxBodyBytes}
+	}
+
+	return v
+}
+
+// WithMaxBodyBytes returns a copy of the request where Decode accepts bodies
+// of up to maxBytes. It allows a route to raise or lower the global limit.
+func WithMaxBodyBytes(r *http.Request, maxBytes int64) *http.Request {
+	cfg := getDecodeConfig(r.Context())
+	cfg.maxBytes = maxBytes
+
+	return r.WithContext(setDecodeConfig(r.Context(), cfg))
+}
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS represents the cross-origin policy of the app. An origin is either
+// exact, like https://app.example.com, "*" for any origin or has a single
+// wildcard, like https://*.example.com or http://localhost:*.
+type CORS struct {
+	Origins       []string
+	Methods       []string
+	Headers       []string
+	ExposeHeaders []string
+	Credentials   bool
+
+	// MaxAge is how long the browser caches a preflight response. Browsers
+	// cap it, Chromium at 2 hours.
+	MaxAge time.Duration
+}
+
+// DefaultCORS returns the policy for the origins with the methods and headers
+// used by the API.
+func DefaultCORS(origins []string) CORS {
+	return CORS{
+		Origins:       origins,
+		Methods:       []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+		Headers:       []string{"Accept", "Accept-Language", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Traceparent", "If-None-Match", "If-Modified-Since", "If-Match"},
+		ExposeHeaders: []string{"Retry-After", "X-RateLimit-Remaining", "Traceparent", "ETag", "Last-Modified"},
+		MaxAge:        24 * time.Hour,
+	}
+}
+
+// allowOrigin returns the value of Access-Control-Allow-Origin for the
+// request origin, or an empty string when it is not allowed.
+func (c CORS) allowOrigin(origin string) string {
+	for _, allowed := range c.Origins {
+		switch {
+		case allowed == "*":
+			// Com credenciais o navegador não aceita "*" e exige a origem.
+			if c.Credentials && origin != "" {
+				return origin
+			}
+			return "*"
+
+		case origin == "":
+			continue
+
+		case allowed == origin:
+			return origin
+
+		case strings.Count(allowed, "*") == 1:
+			prefix, suffix, _ := strings.Cut(allowed, "*")
+			if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+				continue
+			}
+
+			// O curinga cobre um subdomínio ou uma porta, nunca um caminho.
+			if middle := origin[len(prefix) : len(origin)-len(suffix)]; !strings.ContainsAny(middle, "/@") {
+				return origin
+			}
+		}
+	}
+
+	return ""
+}
+
+// handle sets the CORS headers and reports whether the request was a
+// preflight already answered.
+func (c CORS) handle(w http.ResponseWriter, r *http.Request) bool {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+
+	allowed := c.allowOrigin(r.Header.Get("Origin"))
+
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+	if allowed == "" {
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", allowed)
+	if c.Credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if len(c.ExposeHeaders) > 0 {
+			h.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+		}
+		return false
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.Methods, ", "))
+	h.Set("Access-Control-Allow-Headers", strings.Join(c.Headers, ", "))
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return true
+}
@@ -1,11 +1,24 @@
 package web
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 )
 
+// DefaultMaxBodyBytes is the largest body Decode accepts when no limit was
+// configured for the application or the route.
+const DefaultMaxBodyBytes = 1 << 20
+
+// decodeConfig holds the limits Decode applies to the request body.
+type decodeConfig struct {
+	maxBytes int64
+	strict   bool
+}
+
 // Param returns the web call parameters from the request.
 func Param(r *http.Request, key string) string {
 	return r.PathValue(key)
@@ -22,15 +35,33 @@ type validator interface {
 
 // Decode reads the body of an HTTP request and decodes the body into the
 // specified data model. If the data model implements the validator interface,
-// the method will be called.
+// the method will be called. Bodies over the configured limit fail with an
+// error wrapping *http.MaxBytesError and, in strict mode, unknown JSON
+// fields are rejected.
 func Decode(r *http.Request, v Decoder) error {
-	data, err := io.ReadAll(r.Body)
+	cfg := getDecodeConfig(r.Context())
+
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, cfg.maxBytes))
 	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return fmt.Errorf("request: payload exceeds %d bytes: %w", mbe.Limit, err)
+		}
 		return fmt.Errorf("request: unable to read payload: %w", err)
 	}
 
-	if err := v.Decode(data); err != nil {
-		return fmt.Errorf("request: decode: %w", err)
+	switch cfg.strict {
+	case true:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("request: decode: %w", err)
+		}
+
+	default:
+		if err := v.Decode(data); err != nil {
+			return fmt.Errorf("request: decode: %w", err)
+		}
 	}
 
 	if v, ok := v.(validator); ok {
@@ -61,7 +61,13 @@ func Respond(ctx context.Context, w http.ResponseWriter, resp Encoder) error {
 	_, span := addSpan(ctx, "web.send.response", attribute.Int("status", statusCode))
 	defer span.End()
 
-	if statusCode == http.StatusNoContent {
+	if v, ok := resp.(httpHeader); ok {
+		for k, vs := range v.HTTPHeader() {
+			w.Header()[k] = vs
+		}
+	}
+
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
 		w.WriteHeader(statusCode)
 		return nil
 	}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// apiContentSecurityPolicy is sent with the API responses, which are never
+// rendered as documents.
+const apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// SecurityHeaders represents the security headers sent with every response.
+// Empty values are not sent.
+type SecurityHeaders struct {
+	// HSTS is the max-age of Strict-Transport-Security, only sent over
+	// HTTPS. Zero disables it.
+	HSTS           time.Duration
+	HSTSSubdomains bool
+	HSTSPreload    bool
+
+	// ContentSecurityPolicy is sent with the single page application. The
+	// API responses get a policy that allows nothing.
+	ContentSecurityPolicy string
+
+	ReferrerPolicy string
+	FrameOptions   string
+	NoSniff        bool
+}
+
+// DefaultSecurityHeaders returns the headers for a production deployment.
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		HSTS:           2 * 365 * 24 * time.Hour,
+		HSTSSubdomains: true,
+		HSTSPreload:    true,
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+			"img-src 'self' data:; font-src 'self' data:; connect-src 'self'; object-src 'none'; " +
+			"base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		ReferrerPolicy: "strict-origin-when-cross-origin",
+		FrameOptions:   "DENY",
+		NoSniff:        true,
+	}
+}
+
+func (s SecurityHeaders) set(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+
+	// Fora de HTTPS o navegador ignora o HSTS. O X-Forwarded-Proto pode ser
+	// forjado, mas no pior caso o cabeçalho apenas é ignorado.
+	if s.HSTS > 0 && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+		value := fmt.Sprintf("max-age=%d", int(s.HSTS.Seconds()))
+		if s.HSTSSubdomains {
+			value += "; includeSubDomains"
+		}
+		if s.HSTSPreload {
+			value += "; preload"
+		}
+		h.Set("Strict-Transport-Security", value)
+	}
+
+	if s.NoSniff {
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+
+	if s.ReferrerPolicy != "" {
+		h.Set("Referrer-Policy", s.ReferrerPolicy)
+	}
+
+	if s.FrameOptions != "" {
+		h.Set("X-Frame-Options", s.FrameOptions)
+	}
+
+	h.Set("Content-Security-Policy", apiContentSecurityPolicy)
+}
@@ -4,7 +4,10 @@ package web
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"path"
+	"strings"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -30,12 +33,14 @@ type Logger func(ctx context.Context, msg string, args ...any)
 // object for each of our http handlers. Feel free to add any configuration
 // data/logic on this App struct.
 type App struct {
-	log     Logger
-	tracer  trace.Tracer
-	mux     *http.ServeMux
-	otmux   http.Handler
-	mw      []MidFunc
-	origins []string
+	log      Logger
+	tracer   trace.Tracer
+	mux      *http.ServeMux
+	otmux    http.Handler
+	mw       []MidFunc
+	cors     *CORS
+	security SecurityHeaders
+	decode   decodeConfig
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
@@ -50,11 +55,34 @@ func NewApp(log Logger, tracer trace.Tracer, mw ...MidFunc) *App {
 	mux := http.NewServeMux()
 
 	return &App{
-		log:    log,
-		tracer: tracer,
-		mux:    mux,
-		otmux:  otelhttp.NewHandler(mux, "request"),
-		mw:     mw,
+		log:      log,
+		tracer:   tracer,
+		mux:      mux,
+		otmux:    otelhttp.NewHandler(mux, "request", otelhttp.WithSpanNameFormatter(spanName)),
+		mw:       mw,
+		security: DefaultSecurityHeaders(),
+		decode:   decodeConfig{maxBytes: DefaultMaxBodyBytes},
+	}
+}
+
+// spanName names the span of the request after the route template, e.g.
+// "GET /v1/dashboards/{dashboard_id}", so the requests of a route are grouped
+// together no matter the ids in the path.
+func spanName(operation string, r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+
+	return operation
+}
+
+// SetDecodeLimits configures the largest body Decode accepts for every route
+// and whether unknown JSON fields are rejected. Routes can still change the
+// size limit with WithMaxBodyBytes.
+func (a *App) SetDecodeLimits(maxBytes int64, strict bool) {
+	a.decode = decodeConfig{
+		maxBytes: maxBytes,
+		strict:   strict,
 	}
 }
 
@@ -63,48 +91,83 @@ func NewApp(log Logger, tracer trace.Tracer, mw ...MidFunc) *App {
 // tracing. The opentelemetry mux then calls the application mux to handle
 // application traffic. This was set up in the NewApp function.
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if a.origins != nil {
-
-		// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Access-Control-Allow-Origin
-		// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Origin
-		//
-		// Limiting the possible Access-Control-Allow-Origin values to a set of
-		// allowed origins requires code on the server side to check the value of
-		// the Origin request header, compare that to a list of allowed origins, and
-		// then if the Origin value is in the list, set the
-		// Access-Control-Allow-Origin value to the same value as the Origin.
-
-		reqOrigin := r.Header.Get("Origin")
-		for _, origin := range a.origins {
-			if origin == "*" || origin == reqOrigin {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-				break
-			}
+	a.security.set(w, r)
+
+	if a.cors != nil && a.cors.handle(w, r) {
+		return
+	}
+
+	a.otmux.ServeHTTP(w, r)
+}
+
+// EnableCORS enables CORS preflight requests to work for the origins with the
+// default policy. It prevents the MethodNotAllowedHandler from being called.
+func (a *App) EnableCORS(origins []string) {
+	a.SetCORS(DefaultCORS(origins))
+}
+
+// SetCORS sets the cross-origin policy of the app.
+func (a *App) SetCORS(cors CORS) {
+	a.cors = &cors
+}
+
+// SetSecurityHeaders sets the security headers sent with every response.
+func (a *App) SetSecurityHeaders(headers SecurityHeaders) {
+	a.security = headers
+}
+
+// FileServerSPA serves a statically built single page application from the
+// dir inside the file system under the path prefix. Requests for files that
+// do not exist fall back to index.html so client side routes work on reload.
+// Assets are cached for a long time since the build names them by content
+// hash; index.html is always revalidated so new releases are picked up.
+func (a *App) FileServerSPA(static fs.FS, dir string, prefix string) error {
+	fSys, err := fs.Sub(static, dir)
+	if err != nil {
+		return fmt.Errorf("switching to static folder: %w", err)
+	}
+
+	index, err := fs.ReadFile(fSys, "index.html")
+	if err != nil {
+		return fmt.Errorf("reading index.html: %w", err)
+	}
+
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(fSys)))
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(strings.TrimPrefix(r.URL.Path, prefix)), "/")
+
+		if csp := a.security.ContentSecurityPolicy; csp != "" {
+			w.Header().Set("Content-Security-Policy", csp)
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "POST, PATCH, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "86400")
+		if name == "" || name == "." || name == "index.html" {
+			serveIndex(w, index)
+			return
+		}
 
-		// Handle pre-flight by sending a 200 OK response.
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
+		if _, err := fs.Stat(fSys, name); err != nil {
+			if path.Ext(name) != "" {
+				http.NotFound(w, r)
+				return
+			}
+			serveIndex(w, index)
 			return
 		}
+
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		fileServer.ServeHTTP(w, r)
 	}
 
-	// In the following example, max-age is set to 2 years, and is suffixed with
-	// preload, which is necessary for inclusion in all major web browsers' HSTS
-	// preload lists, like Chromium, Edge, and Firefox.
-	w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	a.mux.HandleFunc(fmt.Sprintf("GET %s", prefix), h)
 
-	a.otmux.ServeHTTP(w, r)
+	return nil
 }
 
-// EnableCORS enables CORS preflight requests to work. It prevents the
-// MethodNotAllowedHandler from being called.
-func (a *App) EnableCORS(origins []string) {
-	a.origins = origins
+func serveIndex(w http.ResponseWriter, index []byte) {
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(index)
 }
 
 // HandlerFuncNoMid sets a handler function for a given HTTP method and path
@@ -138,6 +201,8 @@ func (a *App) HandlerFunc(method string, group string, path string, handlerFunc
 	handlerFunc = wrapMiddleware(a.mw, handlerFunc)
 
 	h := func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(setDecodeConfig(r.Context(), a.decode))
+
 		ctx := setTracer(r.Context(), a.tracer)
 		ctx = setWriter(ctx, w)
 
@@ -173,6 +238,8 @@ func (a *App) RawHandlerFunc(method string, group string, path string, rawHandle
 	handlerFunc = wrapMiddleware(a.mw, handlerFunc)
 
 	h := func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(setDecodeConfig(r.Context(), a.decode))
+
 		ctx := setTracer(r.Context(), a.tracer)
 		ctx = setWriter(ctx, w)
 
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of task statuses.
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Job is a recurring unit of work.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+
+	// Timeout bounds a single run. The lock of a global job is held for the
+	// timeout plus a margin, so another instance never runs it concurrently.
+	Timeout time.Duration
+
+	// Local jobs run on every instance and are not persisted, like flushing
+	// state kept in the memory of the process. Global jobs run once per
+	// schedule across all the instances.
+	Local bool
+
+	// Final marks a local job to run once more when the worker stops.
+	Final bool
+}
+
+// Handler processes an on-demand task.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Task is an on-demand job kept in the queue until it succeeds or runs out
+// of attempts.
+type Task struct {
+	ID          uuid.UUID
+	Name        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAt       time.Time
+	CreatedAt   time.Time
+	FinishedAt  *time.Time
+}
@@ -0,0 +1,73 @@
+package workerdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+)
+
+type taskDB struct {
+	ID          uuid.UUID      `db:"task_id"`
+	Name        string         `db:"name"`
+	Payload     string         `db:"payload"`
+	Status      string         `db:"status"`
+	Attempts    int            `db:"attempts"`
+	MaxAttempts int            `db:"max_attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	RunAt       time.Time      `db:"run_at"`
+	CreatedAt   time.Time      `db:"created_at"`
+	FinishedAt  sql.NullTime   `db:"finished_at"`
+}
+
+func toDBTask(bus worker.Task) taskDB {
+	db := taskDB{
+		ID:          bus.ID,
+		Name:        bus.Name,
+		Payload:     string(bus.Payload),
+		Status:      bus.Status,
+		Attempts:    bus.Attempts,
+		MaxAttempts: bus.MaxAttempts,
+		LastError:   sql.NullString{String: bus.LastError, Valid: bus.LastError != ""},
+		RunAt:       bus.RunAt.UTC(),
+		CreatedAt:   bus.CreatedAt.UTC(),
+	}
+
+	if bus.FinishedAt != nil {
+		db.FinishedAt = sql.NullTime{Time: bus.FinishedAt.UTC(), Valid: true}
+	}
+
+	return db
+}
+
+func toBusTask(db taskDB) worker.Task {
+	bus := worker.Task{
+		ID:          db.ID,
+		Name:        db.Name,
+		Payload:     json.RawMessage(db.Payload),
+		Status:      db.Status,
+		Attempts:    db.Attempts,
+		MaxAttempts: db.MaxAttempts,
+		LastError:   db.LastError.String,
+		RunAt:       db.RunAt.In(time.Local),
+		CreatedAt:   db.CreatedAt.In(time.Local),
+	}
+
+	if db.FinishedAt.Valid {
+		t := db.FinishedAt.Time.In(time.Local)
+		bus.FinishedAt = &t
+	}
+
+	return bus
+}
+
+func toBusTasks(dbs []taskDB) []worker.Task {
+	bus := make([]worker.Task, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusTask(db)
+	}
+
+	return bus
+}
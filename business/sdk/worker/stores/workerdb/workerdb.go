@@ -0,0 +1,246 @@
+// Package workerdb contains the persistence and the locking of the worker
+// jobs.
+package workerdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/business/sdk/sqldb"
+	"github.com/jcpaschoal/spi-exata/business/sdk/worker"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for job database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db sqlx.ExtContext) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Register adds the schedule of a global job. An existing schedule keeps its
+// next run unless the new one is sooner.
+func (s *Store) Register(ctx context.Context, name string, next time.Time) error {
+	data := struct {
+		Name string    `db:"name"`
+		Next time.Time `db:"next_run_at"`
+	}{
+		Name: name,
+		Next: next.UTC(),
+	}
+
+	const q = `
+	INSERT INTO "public"."job_schedule"
+		(name, next_run_at)
+	VALUES
+		(:name, :next_run_at)
+	ON CONFLICT (name) DO UPDATE SET
+		next_run_at = LEAST("public"."job_schedule".next_run_at, EXCLUDED.next_run_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Claim locks the jobs among names that are due and not locked by another
+// instance, returning the names of the jobs the owner must run.
+func (s *Store) Claim(ctx context.Context, names []string, owner string, now time.Time, lockUntil time.Time) ([]string, error) {
+	data := struct {
+		Names     []string  `db:"names"`
+		Owner     string    `db:"owner"`
+		Now       time.Time `db:"now"`
+		LockUntil time.Time `db:"lock_until"`
+	}{
+		Names:     names,
+		Owner:     owner,
+		Now:       now.UTC(),
+		LockUntil: lockUntil.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		"public"."job_schedule"
+	SET
+		locked_by = :owner,
+		locked_until = :lock_until
+	WHERE
+		name IN (:names) AND
+		next_run_at <= :now AND
+		(locked_until IS NULL OR locked_until <= :now)
+	RETURNING
+		name`
+
+	var claimed []struct {
+		Name string `db:"name"`
+	}
+	if err := sqldb.NamedQuerySliceUsingIn(ctx, s.log, s.db, q, data, &claimed); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	out := make([]string, len(claimed))
+	for i, c := range claimed {
+		out[i] = c.Name
+	}
+
+	return out, nil
+}
+
+// Release unlocks a job held by the owner and records the run.
+func (s *Store) Release(ctx context.Context, name string, owner string, ranAt time.Time, next time.Time, lastErr string) error {
+	data := struct {
+		Name      string    `db:"name"`
+		Owner     string    `db:"owner"`
+		RanAt     time.Time `db:"last_run_at"`
+		Next      time.Time `db:"next_run_at"`
+		LastError *string   `db:"last_error"`
+	}{
+		Name:  name,
+		Owner: owner,
+		RanAt: ranAt.UTC(),
+		Next:  next.UTC(),
+	}
+
+	if lastErr != "" {
+		data.LastError = &lastErr
+	}
+
+	const q = `
+	UPDATE
+		"public"."job_schedule"
+	SET
+		next_run_at = :next_run_at,
+		last_run_at = :last_run_at,
+		last_error = :last_error,
+		locked_by = NULL,
+		locked_until = NULL
+	WHERE
+		name = :name AND locked_by = :owner`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue adds a task to the queue.
+func (s *Store) Enqueue(ctx context.Context, t worker.Task) error {
+	const q = `
+	INSERT INTO "public"."job_queue"
+		(task_id, name, payload, status, attempts, max_attempts, run_at, created_at)
+	VALUES
+		(:task_id, :name, CAST(:payload AS jsonb), :status, :attempts, :max_attempts, :run_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBTask(t)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimTasks returns up to limit pending tasks of the named jobs that are due
+// and postpones them to lockUntil, so other instances skip them while they
+// run.
+func (s *Store) ClaimTasks(ctx context.Context, names []string, now time.Time, lockUntil time.Time, limit int) ([]worker.Task, error) {
+	data := struct {
+		Names     []string  `db:"names"`
+		Now       time.Time `db:"now"`
+		LockUntil time.Time `db:"lock_until"`
+		Limit     int       `db:"limit"`
+	}{
+		Names:     names,
+		Now:       now.UTC(),
+		LockUntil: lockUntil.UTC(),
+		Limit:     limit,
+	}
+
+	const q = `
+	UPDATE
+		"public"."job_queue"
+	SET
+		run_at = :lock_until
+	WHERE
+		task_id IN (
+			SELECT
+				task_id
+			FROM
+				"public"."job_queue"
+			WHERE
+				status = 'pending' AND run_at <= :now AND name IN (:names)
+			ORDER BY
+				run_at
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		task_id, name, CAST(payload AS text) AS payload, status, attempts, max_attempts,
+		last_error, run_at, created_at, finished_at`
+
+	var dbTasks []taskDB
+	if err := sqldb.NamedQuerySliceUsingIn(ctx, s.log, s.db, q, data, &dbTasks); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusTasks(dbTasks), nil
+}
+
+// UpdateTask records the outcome of a task run.
+func (s *Store) UpdateTask(ctx context.Context, t worker.Task) error {
+	const q = `
+	UPDATE
+		"public"."job_queue"
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		run_at = :run_at,
+		finished_at = :finished_at
+	WHERE
+		task_id = :task_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBTask(t)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFinished removes the tasks finished before the specified time and
+// returns how many were removed.
+func (s *Store) DeleteFinished(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			"public"."job_queue"
+		WHERE
+			status <> 'pending' AND finished_at <= :before
+		RETURNING task_id
+	)
+	SELECT count(1) FROM deleted`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,433 @@
+// Package worker runs the background jobs of the system: recurring jobs on a
+// cron-like schedule and on-demand tasks queued in the database. Global jobs
+// and tasks are locked in the database, so any number of API or worker
+// instances can run side by side and every run happens once.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+	"github.com/jcpaschoal/spi-exata/foundation/otel"
+)
+
+const (
+	defaultPoll        = 5 * time.Second
+	defaultTimeout     = 5 * time.Minute
+	defaultMaxAttempts = 5
+	lockMargin         = time.Minute
+	taskBatchSize      = 10
+	baseDelay          = 10 * time.Second
+	maxDelay           = time.Hour
+)
+
+// ErrUnknownJob is returned when a task is enqueued for a job without handler.
+var ErrUnknownJob = errors.New("unknown job")
+
+// Storer interface declares the behavior this package needs to persist and
+// lock the jobs.
+type Storer interface {
+	Register(ctx context.Context, name string, next time.Time) error
+	Claim(ctx context.Context, names []string, owner string, now time.Time, lockUntil time.Time) ([]string, error)
+	Release(ctx context.Context, name string, owner string, ranAt time.Time, next time.Time, lastErr string) error
+	Enqueue(ctx context.Context, t Task) error
+	ClaimTasks(ctx context.Context, names []string, now time.Time, lockUntil time.Time, limit int) ([]Task, error)
+	UpdateTask(ctx context.Context, t Task) error
+	DeleteFinished(ctx context.Context, before time.Time) (int, error)
+}
+
+// Config represents the settings of a worker.
+type Config struct {
+	// Instance identifies the holder of the locks. Defaults to the host name
+	// and the process id.
+	Instance string
+
+	// Poll is how often the schedules and the queue are checked.
+	Poll time.Duration
+
+	// LocalOnly runs only the local jobs. It is used by the API when the
+	// global jobs and the tasks are processed by the worker binary.
+	LocalOnly bool
+}
+
+// Worker manages the set of APIs for running background jobs.
+type Worker struct {
+	log      *logger.Logger
+	storer   Storer
+	instance string
+	poll     time.Duration
+	local    bool
+
+	mu       sync.Mutex
+	jobs     map[string]Job
+	handlers map[string]Handler
+	running  map[string]bool
+	wg       sync.WaitGroup
+}
+
+// New constructs a worker for running the background jobs.
+func New(log *logger.Logger, storer Storer, cfg Config) *Worker {
+	if cfg.Instance == "" {
+		host, _ := os.Hostname()
+		cfg.Instance = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	if cfg.Poll <= 0 {
+		cfg.Poll = defaultPoll
+	}
+
+	return &Worker{
+		log:      log,
+		storer:   storer,
+		instance: cfg.Instance,
+		poll:     cfg.Poll,
+		local:    cfg.LocalOnly,
+		jobs:     make(map[string]Job),
+		handlers: make(map[string]Handler),
+		running:  make(map[string]bool),
+	}
+}
+
+// Schedule registers a recurring job. A global job can also be run on demand
+// by enqueueing a task with its name.
+func (w *Worker) Schedule(job Job) {
+	if job.Timeout <= 0 {
+		job.Timeout = defaultTimeout
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.jobs[job.Name] = job
+
+	if !job.Local {
+		w.handlers[job.Name] = func(ctx context.Context, _ json.RawMessage) error {
+			return job.Run(ctx)
+		}
+	}
+}
+
+// Handle registers the handler of an on-demand job.
+func (w *Worker) Handle(name string, fn Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers[name] = fn
+}
+
+// Enqueue adds a task to the queue to run at the specified time. The task is
+// processed by any instance that handles the job, retrying with backoff when
+// it fails.
+func (w *Worker) Enqueue(ctx context.Context, name string, payload any, runAt time.Time) (Task, error) {
+	ctx, span := otel.AddSpan(ctx, "business.sdk.worker.enqueue")
+	defer span.End()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Task{}, fmt.Errorf("marshal: %w", err)
+	}
+
+	now := time.Now()
+	if runAt.IsZero() {
+		runAt = now
+	}
+
+	t := Task{
+		ID:          uuid.New(),
+		Name:        name,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+	}
+
+	if err := w.storer.Enqueue(ctx, t); err != nil {
+		return Task{}, fmt.Errorf("enqueue: %w", err)
+	}
+
+	return t, nil
+}
+
+// PurgeFinished deletes the tasks finished before the specified time.
+func (w *Worker) PurgeFinished(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.sdk.worker.purgeFinished")
+	defer span.End()
+
+	n, err := w.storer.DeleteFinished(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("deleteFinished: %w", err)
+	}
+
+	return n, nil
+}
+
+// Run executes the jobs until the context is cancelled. On shutdown it waits
+// for the jobs in progress and then runs the final jobs.
+func (w *Worker) Run(ctx context.Context) error {
+	now := time.Now()
+
+	// Os horários locais ficam em memória, os globais no banco.
+	next := make(map[string]time.Time)
+
+	for name, job := range w.snapshot() {
+		next[name] = job.Schedule.Next(now)
+
+		if job.Local || w.local {
+			continue
+		}
+
+		if err := w.storer.Register(ctx, name, next[name]); err != nil {
+			return fmt.Errorf("register: job[%s]: %w", name, err)
+		}
+	}
+
+	w.log.Info(ctx, "worker", "status", "started", "instance", w.instance, "jobs", len(next), "localOnly", w.local)
+
+	ticker := time.NewTicker(w.poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.tick(ctx, next)
+
+		case <-ctx.Done():
+			w.wg.Wait()
+			w.runFinal()
+
+			w.log.Info(context.Background(), "worker", "status", "stopped", "instance", w.instance)
+			return nil
+		}
+	}
+}
+
+func (w *Worker) tick(ctx context.Context, next map[string]time.Time) {
+	now := time.Now()
+	jobs := w.snapshot()
+
+	var global []string
+	for name, job := range jobs {
+		switch {
+		case job.Local:
+			if now.Before(next[name]) {
+				continue
+			}
+			next[name] = job.Schedule.Next(now)
+			w.start(ctx, job, "")
+
+		case !w.local:
+			global = append(global, name)
+		}
+	}
+
+	if w.local {
+		return
+	}
+
+	if len(global) > 0 {
+		claimed, err := w.storer.Claim(ctx, global, w.instance, now, now.Add(w.maxTimeout(jobs)+lockMargin))
+		if err != nil {
+			w.log.Error(ctx, "worker", "status", "claim jobs", "ERROR", err)
+		}
+
+		for _, name := range claimed {
+			w.start(ctx, jobs[name], w.instance)
+		}
+	}
+
+	w.processTasks(ctx)
+}
+
+// start runs the job in its own goroutine unless a previous run in this
+// instance is still in progress. Global jobs release the lock and record the
+// next run when they finish.
+func (w *Worker) start(ctx context.Context, job Job, owner string) {
+	w.mu.Lock()
+	if w.running[job.Name] {
+		w.mu.Unlock()
+		return
+	}
+	w.running[job.Name] = true
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			w.mu.Lock()
+			delete(w.running, job.Name)
+			w.mu.Unlock()
+		}()
+
+		ranAt := time.Now()
+		err := w.execute(ctx, job.Name, job.Timeout, job.Run)
+
+		if owner == "" {
+			return
+		}
+
+		var lastErr string
+		if err != nil {
+			lastErr = err.Error()
+		}
+
+		// A liberação não pode depender do contexto do worker: no desligamento
+		// o lock ficaria preso até expirar.
+		if err := w.storer.Release(context.Background(), job.Name, owner, ranAt, job.Schedule.Next(time.Now()), lastErr); err != nil {
+			w.log.Error(ctx, "worker", "status", "release job", "job", job.Name, "ERROR", err)
+		}
+	}()
+}
+
+func (w *Worker) processTasks(ctx context.Context) {
+	names := w.handlerNames()
+	if len(names) == 0 {
+		return
+	}
+
+	for {
+		now := time.Now()
+
+		tasks, err := w.storer.ClaimTasks(ctx, names, now, now.Add(defaultTimeout+lockMargin), taskBatchSize)
+		if err != nil {
+			w.log.Error(ctx, "worker", "status", "claim tasks", "ERROR", err)
+			return
+		}
+
+		for _, t := range tasks {
+			w.runTask(ctx, t)
+		}
+
+		if len(tasks) < taskBatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (w *Worker) runTask(ctx context.Context, t Task) {
+	w.mu.Lock()
+	fn := w.handlers[t.Name]
+	w.mu.Unlock()
+
+	var err error
+	if fn == nil {
+		err = ErrUnknownJob
+	} else {
+		err = w.execute(ctx, t.Name, defaultTimeout, func(ctx context.Context) error {
+			return fn(ctx, t.Payload)
+		})
+	}
+
+	now := time.Now()
+	t.Attempts++
+
+	switch {
+	case err == nil:
+		t.Status = StatusDone
+		t.LastError = ""
+		t.FinishedAt = &now
+
+	case t.Attempts >= t.MaxAttempts:
+		t.Status = StatusFailed
+		t.LastError = err.Error()
+		t.FinishedAt = &now
+
+	default:
+		t.LastError = err.Error()
+		t.RunAt = now.Add(retryDelay(t.Attempts))
+	}
+
+	if err := w.storer.UpdateTask(context.Background(), t); err != nil {
+		w.log.Error(ctx, "worker", "status", "update task", "taskID", t.ID, "job", t.Name, "ERROR", err)
+	}
+}
+
+// execute runs fn with the timeout, recovering from panics so a broken job
+// never takes the process down.
+func (w *Worker) execute(ctx context.Context, name string, timeout time.Duration, fn func(ctx context.Context) error) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := otel.AddSpan(ctx, "business.sdk.worker."+name)
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+
+		if err != nil {
+			w.log.Error(ctx, "worker", "status", "job failed", "job", name, "took", time.Since(start).String(), "ERROR", err)
+			return
+		}
+
+		w.log.Debug(ctx, "worker", "status", "job completed", "job", name, "took", time.Since(start).String())
+	}()
+
+	return fn(ctx)
+}
+
+func (w *Worker) runFinal() {
+	for _, job := range w.snapshot() {
+		if !job.Final || !job.Local {
+			continue
+		}
+
+		w.execute(context.Background(), job.Name, job.Timeout, job.Run)
+	}
+}
+
+func (w *Worker) snapshot() map[string]Job {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	jobs := make(map[string]Job, len(w.jobs))
+	for name, job := range w.jobs {
+		jobs[name] = job
+	}
+
+	return jobs
+}
+
+func (w *Worker) handlerNames() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.handlers))
+	for name := range w.handlers {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (w *Worker) maxTimeout(jobs map[string]Job) time.Duration {
+	d := defaultTimeout
+	for _, job := range jobs {
+		d = max(d, job.Timeout)
+	}
+
+	return d
+}
+
+// retryDelay returns the wait before the next attempt of a task that failed
+// the specified number of times.
+func retryDelay(attempts int) time.Duration {
+	d := baseDelay << min(attempts-1, 20)
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	return d
+}
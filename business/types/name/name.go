@@ -4,7 +4,9 @@ package name
 import (
 	"database/sql"
 	"fmt"
-	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Name represents a name in the system.
@@ -29,13 +31,85 @@ func (n Name) MarshalText() ([]byte, error) {
 
 // =============================================================================
 
-var nameRegEx = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9' -]{2,19}$")
+// Charset defines the characters a name can be made of.
+type Charset struct {
+	first func(r rune) bool
+	rest  func(r rune) bool
+}
+
+// Set of charsets a name can use.
+var (
+	// Person allows ASCII letters, digits, apostrophes, hyphens and spaces,
+	// starting with a letter.
+	Person = Charset{
+		first: isASCIILetter,
+		rest: func(r rune) bool {
+			return isASCIILetter(r) || (r >= '0' && r <= '9') || strings.ContainsRune("' -", r)
+		},
+	}
+
+	// Organization allows letters of any language, digits, spaces and the
+	// punctuation common in company names, starting with a letter or digit.
+	Organization = Charset{
+		first: func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		},
+		rest: func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("' -_.&,()/", r)
+		},
+	}
+)
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// Rules defines what a valid name looks like. Lengths are counted in
+// characters.
+type Rules struct {
+	MinLength int
+	MaxLength int
+	Charset   Charset
+}
+
+// DefaultRules are the rules applied by Parse and ParseNull.
+var DefaultRules = Rules{
+	MinLength: 3,
+	MaxLength: 20,
+	Charset:   Person,
+}
+
+// Check returns an error if the value doesn't comply with the rules.
+func (r Rules) Check(value string) error {
+	if n := utf8.RuneCountInString(value); n < r.MinLength || n > r.MaxLength {
+		return fmt.Errorf("invalid name %q: must have between %d and %d characters", value, r.MinLength, r.MaxLength)
+	}
+
+	for i, c := range value {
+		valid := r.Charset.rest
+		if i == 0 {
+			valid = r.Charset.first
+		}
+
+		if !valid(c) {
+			return fmt.Errorf("invalid name %q: character %q not allowed", value, c)
+		}
+	}
+
+	return nil
+}
 
 // Parse parses the string value and returns a name if the value complies
 // with the rules for a name.
 func Parse(value string) (Name, error) {
-	if !nameRegEx.MatchString(value) {
-		return Name{}, fmt.Errorf("invalid name %q", value)
+	return ParseWith(value, DefaultRules)
+}
+
+// ParseWith parses the string value and returns a name if the value complies
+// with the specified rules.
+func ParseWith(value string, rules Rules) (Name, error) {
+	if err := rules.Check(value); err != nil {
+		return Name{}, err
 	}
 
 	return Name{value}, nil
@@ -52,6 +126,37 @@ func MustParse(value string) Name {
 	return name
 }
 
+// Derive returns a name from the first candidate that complies with the
+// default rules once the characters they don't allow are dropped and the
+// value is cut to the maximum length. It serves names that come from other
+// systems, e.g. the display name of a directory.
+func Derive(candidates ...string) (Name, error) {
+	rules := DefaultRules
+
+	for _, candidate := range candidates {
+		var b strings.Builder
+		for _, c := range candidate {
+			if b.Len() == 0 && !rules.Charset.first(c) {
+				continue
+			}
+			if rules.Charset.rest(c) {
+				b.WriteRune(c)
+			}
+		}
+
+		value := b.String()
+		if n := []rune(value); len(n) > rules.MaxLength {
+			value = string(n[:rules.MaxLength])
+		}
+
+		if name, err := ParseWith(strings.TrimSpace(value), rules); err == nil {
+			return name, nil
+		}
+	}
+
+	return Name{}, fmt.Errorf("no valid name among %q", candidates)
+}
+
 // =============================================================================
 
 // Null represents a name in the system that can be empty.
@@ -96,8 +201,8 @@ func ParseNull(value string) (Null, error) {
 		return Null{}, nil
 	}
 
-	if !nameRegEx.MatchString(value) {
-		return Null{}, fmt.Errorf("invalid name %q", value)
+	if err := DefaultRules.Check(value); err != nil {
+		return Null{}, err
 	}
 
 	return Null{value, true}, nil
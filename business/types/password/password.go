@@ -2,6 +2,7 @@
 package password
 
 import (
+	"crypto/rand"
 	"fmt"
 	"regexp"
 )
@@ -51,6 +52,23 @@ func MustParse(value string) Password {
 	return password
 }
 
+// Random returns a password of the maximum length nobody knows, for users
+// who authenticate somewhere else and never use the local password.
+func Random() (Password, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789#@!-"
+
+	b := make([]byte, 19)
+	if _, err := rand.Read(b); err != nil {
+		return Password{}, fmt.Errorf("random: %w", err)
+	}
+
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+
+	return Parse(string(b))
+}
+
 func ParseConfirm(pass string, confirm string) (Password, error) {
 	p, err := Parse(pass)
 	if err != nil {
@@ -1,12 +1,19 @@
-// Package phone represents a phone number in the system.
+// Package phone represents a phone number in the system. Numbers are kept in
+// the E.164 form (+5511912345678) so the same number typed in different ways
+// is stored, and compared, as the same value.
 package phone
 
 import (
 	"database/sql"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
+// DefaultCountryCode is the country code assumed for numbers given without
+// one, like "11 91234-5678".
+const DefaultCountryCode = "55"
+
 // Phone represents a phone number in the system.
 type Phone struct {
 	value string
@@ -22,6 +29,11 @@ func (p Phone) Equal(p2 Phone) bool {
 	return p.value == p2.value
 }
 
+// Format returns the phone number formatted for display.
+func (p Phone) Format() string {
+	return format(p.value)
+}
+
 // MarshalText provides support for logging and any marshal needs.
 func (p Phone) MarshalText() ([]byte, error) {
 	return []byte(p.value), nil
@@ -29,17 +41,19 @@ func (p Phone) MarshalText() ([]byte, error) {
 
 // =============================================================================
 
-// phoneRegEx allows for an optional +, followed by digits, spaces, or hyphens.
-var phoneRegEx = regexp.MustCompile(`^\+?[0-9\s-]{3,20}$`)
+// phoneRegEx allows for an optional +, followed by digits, spaces, hyphens,
+// dots or parentheses.
+var phoneRegEx = regexp.MustCompile(`^\+?[0-9\s().-]{3,25}$`)
 
 // Parse parses the string value and returns a phone number if the value complies
-// with the rules for a phone number.
+// with the rules for a phone number. The number is normalized to E.164.
 func Parse(value string) (Phone, error) {
-	if !phoneRegEx.MatchString(value) {
-		return Phone{}, fmt.Errorf("invalid phone %q", value)
+	e164, err := normalize(value)
+	if err != nil {
+		return Phone{}, err
 	}
 
-	return Phone{value}, nil
+	return Phone{e164}, nil
 }
 
 // MustParse parses the string value and returns a phone number if the value
@@ -83,6 +97,16 @@ func (n Null) Equal(n2 Null) bool {
 	return n.value == n2.value && n.valid == n2.valid
 }
 
+// Format returns the phone number formatted for display, or an empty string
+// when there is no number.
+func (n Null) Format() string {
+	if !n.valid {
+		return ""
+	}
+
+	return format(n.value)
+}
+
 // MarshalText provides support for logging and any marshal needs.
 func (n Null) MarshalText() ([]byte, error) {
 	return []byte(n.value), nil
@@ -97,11 +121,12 @@ func ParseNull(value string) (Null, error) {
 		return Null{}, nil
 	}
 
-	if !phoneRegEx.MatchString(value) {
-		return Null{}, fmt.Errorf("invalid phone %q", value)
+	e164, err := normalize(value)
+	if err != nil {
+		return Null{}, err
 	}
 
-	return Null{value, true}, nil
+	return Null{e164, true}, nil
 }
 
 // MustParseNull parses the string value and returns a phone number if the value
@@ -114,3 +139,74 @@ func MustParseNull(value string) Null {
 
 	return phone
 }
+
+// =============================================================================
+
+// normalize returns the value in the E.164 form. Numbers starting with + or
+// the international prefix 00 carry the country code; any other number is
+// taken as a national number of DefaultCountryCode.
+func normalize(value string) (string, error) {
+	if !phoneRegEx.MatchString(value) {
+		return "", fmt.Errorf("invalid phone %q", value)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+
+	switch {
+	case strings.HasPrefix(value, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		// O 0 do prefixo de longa distância nacional não faz parte do número.
+		digits = DefaultCountryCode + strings.TrimPrefix(digits, "0")
+	}
+
+	// O E.164 admite no máximo 15 dígitos e nenhum código de país começa com 0.
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", fmt.Errorf("invalid phone %q", value)
+	}
+
+	if national, ok := strings.CutPrefix(digits, "55"); ok {
+		if err := validateBR(national); err != nil {
+			return "", fmt.Errorf("invalid phone %q: %w", value, err)
+		}
+	}
+
+	return "+" + digits, nil
+}
+
+// validateBR checks a brazilian national number: a two digit area code
+// followed by 8 digits for landlines or 9 digits, starting with 9, for
+// mobiles.
+func validateBR(national string) error {
+	switch {
+	case len(national) != 10 && len(national) != 11:
+		return fmt.Errorf("expected area code and 8 or 9 digits")
+	case national[0] == '0' || national[1] == '0':
+		return fmt.Errorf("invalid area code %q", national[:2])
+	case len(national) == 11 && national[2] != '9':
+		return fmt.Errorf("mobile numbers start with 9")
+	}
+
+	return nil
+}
+
+// format groups the digits of the E.164 value for display. Brazilian numbers
+// follow the national format, +55 11 91234-5678; other numbers are shown as
+// stored.
+func format(e164 string) string {
+	national, ok := strings.CutPrefix(e164, "+55")
+	if !ok || len(national) < 10 {
+		return e164
+	}
+
+	area, number := national[:2], national[2:]
+	split := len(number) - 4
+
+	return fmt.Sprintf("+55 %s %s-%s", area, number[:split], number[split:])
+}
@@ -8,6 +8,10 @@ var (
 	Analyst = newRole("ANALYST")
 	Admin   = newRole("ADMIN")
 	User    = newRole("USER")
+
+	// TenantAdmin manages the users, the access and the branding of its own
+	// tenant only. ADMIN is the only role that acts across tenants.
+	TenantAdmin = newRole("TENANT_ADMIN")
 )
 
 // =============================================================================
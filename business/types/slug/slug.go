@@ -0,0 +1,79 @@
+// Package slug represents a slug in the system, the short identifier of a
+// tenant used in urls and subdomains.
+package slug
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Length limits of a slug. The maximum follows the tenant slug column.
+const (
+	MinLength = 3
+	MaxLength = 64
+)
+
+// reserved are the slugs that collide with routes and subdomains of the
+// platform itself.
+var reserved = []string{
+	"admin", "api", "app", "assets", "auth", "dashboard", "dashboards", "debug",
+	"docs", "graphql", "help", "login", "logout", "mail", "me", "metrics",
+	"new", "root", "settings", "static", "status", "support", "system",
+	"tenant", "tenants", "user", "users", "v1", "www",
+}
+
+// Slug represents a slug in the system.
+type Slug struct {
+	value string
+}
+
+// String returns the value of the slug.
+func (s Slug) String() string {
+	return s.value
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s Slug) Equal(s2 Slug) bool {
+	return s.value == s2.value
+}
+
+// MarshalText provides support for logging and any marshal needs.
+func (s Slug) MarshalText() ([]byte, error) {
+	return []byte(s.value), nil
+}
+
+// =============================================================================
+
+// slugRegEx allows lowercase letters and digits in words joined by single
+// hyphens.
+var slugRegEx = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Parse parses the string value and returns a slug if the value complies
+// with the rules for a slug.
+func Parse(value string) (Slug, error) {
+	if len(value) < MinLength || len(value) > MaxLength {
+		return Slug{}, fmt.Errorf("invalid slug %q: must have between %d and %d characters", value, MinLength, MaxLength)
+	}
+
+	if !slugRegEx.MatchString(value) {
+		return Slug{}, fmt.Errorf("invalid slug %q: only lowercase letters and digits separated by hyphens", value)
+	}
+
+	if slices.Contains(reserved, value) {
+		return Slug{}, fmt.Errorf("invalid slug %q: reserved", value)
+	}
+
+	return Slug{value}, nil
+}
+
+// MustParse parses the string value and returns a slug if the value
+// complies with the rules for a slug. If an error occurs the function panics.
+func MustParse(value string) Slug {
+	s, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
@@ -0,0 +1,284 @@
+// Package alert sends alerts about the service to people. Each sink, like a
+// Slack channel or PagerDuty, gets the alerts at or above its severity. The
+// same alert repeated inside a window is sent once and the number of alerts
+// sent per minute is capped, so a failure loop doesn't flood the channels.
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/ratelimit"
+)
+
+// Severity represents how urgent an alert is.
+type Severity int
+
+// Set of severities, from the least urgent.
+const (
+	Info Severity = iota + 1
+	Warning
+	Error
+	Critical
+)
+
+var severityNames = map[Severity]string{
+	Info:     "info",
+	Warning:  "warning",
+	Error:    "error",
+	Critical: "critical",
+}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// ParseSeverity converts a name like "warning" into a Severity.
+func ParseSeverity(name string) (Severity, error) {
+	for s, n := range severityNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return s, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown severity %q", name)
+}
+
+// Alert represents something people must know about.
+type Alert struct {
+	Severity Severity
+	Title    string
+	Message  string
+	Service  string
+	Fields   map[string]string
+	Time     time.Time
+
+	// Key identifies repetitions of the same alert. Defaults to the
+	// severity and the title.
+	Key string
+}
+
+// Sink delivers alerts to a channel.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, a Alert) error
+}
+
+// Route sends the alerts at or above the severity to the sink.
+type Route struct {
+	Sink        Sink
+	MinSeverity Severity
+}
+
+// Config represents the deduplication and the rate limit of the alerts.
+type Config struct {
+	// Window is how long an alert is not sent again.
+	Window time.Duration
+
+	// PerMinute is the most alerts sent in a minute, across every key.
+	PerMinute int
+}
+
+// =============================================================================
+
+type seen struct {
+	sentAt     time.Time
+	suppressed int
+}
+
+// Alerter dispatches the alerts to the routes from a goroutine, so Notify
+// never blocks the caller. Without routes the alerts are dropped.
+type Alerter struct {
+	service string
+
+	mu      sync.Mutex
+	cfg     Config
+	routes  []Route
+	limiter *ratelimit.Memory
+	seen    map[string]*seen
+
+	queue chan Alert
+	done  chan struct{}
+	once  sync.Once
+}
+
+// New constructs an alerter for the service. The routes are added with
+// AddRoute once the configuration is known, so the alerter can be handed to
+// the logger before the configuration is read.
+func New(service string) *Alerter {
+	a := Alerter{
+		service: service,
+		seen:    make(map[string]*seen),
+		queue:   make(chan Alert, 100),
+		done:    make(chan struct{}),
+	}
+
+	a.Configure(Config{Window: 10 * time.Minute, PerMinute: 20})
+
+	go a.run()
+
+	return &a
+}
+
+// Configure sets the deduplication and the rate limit of the alerts.
+func (a *Alerter) Configure(cfg Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.cfg = cfg
+
+	a.limiter = nil
+	if cfg.PerMinute > 0 {
+		a.limiter = ratelimit.NewMemory(float64(cfg.PerMinute)/60, cfg.PerMinute)
+	}
+}
+
+// AddRoute sends the alerts at or above the severity of the route to its sink.
+func (a *Alerter) AddRoute(r Route) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.routes = append(a.routes, r)
+}
+
+// Routes returns the name and the severity of the configured routes.
+func (a *Alerter) Routes() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	routes := make(map[string]string, len(a.routes))
+	for _, r := range a.routes {
+		routes[r.Sink.Name()] = r.MinSeverity.String()
+	}
+
+	return routes
+}
+
+// Notify queues the alert. It is dropped when the queue is full or the
+// alerter is shut down.
+func (a *Alerter) Notify(al Alert) {
+	if al.Service == "" {
+		al.Service = a.service
+	}
+
+	if al.Time.IsZero() {
+		al.Time = time.Now()
+	}
+
+	if al.Key == "" {
+		al.Key = al.Severity.String() + "|" + al.Title
+	}
+
+	defer func() {
+		recover() // Notify depois do Shutdown.
+	}()
+
+	select {
+	case a.queue <- al:
+	default:
+		fmt.Fprintf(os.Stderr, "alert: queue full, dropping %q\n", al.Title)
+	}
+}
+
+// Shutdown sends the alerts still queued.
+func (a *Alerter) Shutdown(ctx context.Context) error {
+	a.once.Do(func() {
+		close(a.queue)
+	})
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *Alerter) run() {
+	defer close(a.done)
+
+	for al := range a.queue {
+		routes, ok := a.admit(&al)
+		if !ok {
+			continue
+		}
+
+		if err := a.send(routes, al); err != nil {
+			// O logger não pode ser usado aqui: um erro logado voltaria como
+			// um novo alerta.
+			fmt.Fprintf(os.Stderr, "alert: sending %q: %s\n", al.Title, err)
+		}
+	}
+}
+
+// admit decides if the alert is sent, returning the routes it goes to. The
+// alerts suppressed since the last one sent with the same key are counted
+// in its fields.
+func (a *Alerter) admit(al *Alert) ([]Route, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var routes []Route
+	for _, r := range a.routes {
+		if al.Severity >= r.MinSeverity {
+			routes = append(routes, r)
+		}
+	}
+
+	if len(routes) == 0 {
+		return nil, false
+	}
+
+	now := al.Time
+
+	s, exists := a.seen[al.Key]
+	if exists && now.Sub(s.sentAt) < a.cfg.Window {
+		s.suppressed++
+		return nil, false
+	}
+
+	if a.limiter != nil {
+		if res, _ := a.limiter.Allow(context.Background(), "alerts"); !res.Allowed {
+			return nil, false
+		}
+	}
+
+	if exists && s.suppressed > 0 {
+		fields := make(map[string]string, len(al.Fields)+1)
+		for k, v := range al.Fields {
+			fields[k] = v
+		}
+		fields["suppressed"] = fmt.Sprintf("%d in the last %s", s.suppressed, now.Sub(s.sentAt).Round(time.Second))
+		al.Fields = fields
+	}
+
+	for key, s := range a.seen {
+		if now.Sub(s.sentAt) >= a.cfg.Window {
+			delete(a.seen, key)
+		}
+	}
+
+	a.seen[al.Key] = &seen{sentAt: now}
+
+	return routes, true
+}
+
+func (a *Alerter) send(routes []Route, al Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	var errs []error
+	for _, r := range routes {
+		if err := r.Sink.Send(ctx, al); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Sink.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
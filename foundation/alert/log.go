@@ -0,0 +1,40 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// LogEvent returns a logger event function that turns the records into
+// alerts of the severity. A record can raise its own severity with a
+// "severity" attribute, e.g. log.Error(ctx, "...", "severity", "critical").
+//
+// Records with the same message and status are repetitions of the same
+// alert; the rest of the attributes, like the error, go in the fields.
+func (a *Alerter) LogEvent(severity Severity) logger.EventFn {
+	return func(ctx context.Context, r logger.Record) {
+		al := Alert{
+			Severity: severity,
+			Title:    r.Message,
+			Time:     r.Time,
+			Fields:   make(map[string]string, len(r.Attributes)),
+		}
+
+		for k, v := range r.Attributes {
+			if k == "severity" {
+				if s, err := ParseSeverity(fmt.Sprint(v)); err == nil {
+					al.Severity = s
+				}
+				continue
+			}
+
+			al.Fields[k] = fmt.Sprint(v)
+		}
+
+		al.Key = fmt.Sprintf("%s|%s|%s|%s", al.Severity, r.Message, al.Fields["status"], al.Fields["source_err_func"])
+
+		a.Notify(al)
+	}
+}
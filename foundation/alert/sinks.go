@@ -0,0 +1,291 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Slack posts the alerts to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack constructs a sink for the incoming webhook url.
+func NewSlack(url string) *Slack {
+	return &Slack{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements the Sink interface.
+func (*Slack) Name() string {
+	return "slack"
+}
+
+var slackColors = map[Severity]string{
+	Info:     "#439fe0",
+	Warning:  "warning",
+	Error:    "danger",
+	Critical: "danger",
+}
+
+// Send implements the Sink interface.
+func (s *Slack) Send(ctx context.Context, a Alert) error {
+	type field struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+		Short bool   `json:"short"`
+	}
+
+	type attachment struct {
+		Color  string  `json:"color"`
+		Text   string  `json:"text,omitempty"`
+		Fields []field `json:"fields,omitempty"`
+		Footer string  `json:"footer"`
+		TS     int64   `json:"ts"`
+	}
+
+	att := attachment{
+		Color:  slackColors[a.Severity],
+		Text:   a.Message,
+		Footer: a.Service,
+		TS:     a.Time.Unix(),
+	}
+
+	for _, k := range sortedKeys(a.Fields) {
+		att.Fields = append(att.Fields, field{Title: k, Value: a.Fields[k], Short: len(a.Fields[k]) < 40})
+	}
+
+	msg := struct {
+		Text        string       `json:"text"`
+		Attachments []attachment `json:"attachments"`
+	}{
+		Text:        fmt.Sprintf("*[%s] %s*", strings.ToUpper(a.Severity.String()), a.Title),
+		Attachments: []attachment{att},
+	}
+
+	return postJSON(ctx, s.client, s.url, msg, nil)
+}
+
+// =============================================================================
+
+// PagerDuty triggers incidents with the PagerDuty Events API v2.
+type PagerDuty struct {
+	routingKey string
+	url        string
+	client     *http.Client
+}
+
+// NewPagerDuty constructs a sink for the integration with the routing key.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{
+		routingKey: routingKey,
+		url:        "https://events.pagerduty.com/v2/enqueue",
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements the Sink interface.
+func (*PagerDuty) Name() string {
+	return "pagerduty"
+}
+
+// Send implements the Sink interface. The key of the alert is the dedup key
+// of the incident, so PagerDuty groups the repetitions as well.
+func (p *PagerDuty) Send(ctx context.Context, a Alert) error {
+	details := make(map[string]string, len(a.Fields)+1)
+	for k, v := range a.Fields {
+		details[k] = v
+	}
+	if a.Message != "" {
+		details["message"] = a.Message
+	}
+
+	event := map[string]any{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.Service + "|" + a.Key,
+		"payload": map[string]any{
+			"summary":        a.Title,
+			"source":         a.Service,
+			"severity":       a.Severity.String(),
+			"timestamp":      a.Time.UTC().Format(time.RFC3339),
+			"custom_details": details,
+		},
+	}
+
+	return postJSON(ctx, p.client, p.url, event, nil)
+}
+
+// =============================================================================
+
+// Webhook posts the alerts as JSON to a URL. When a secret is set the body is
+// signed with HMAC-SHA256 in the X-Signature header.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhook constructs a sink delivering to url.
+func NewWebhook(url string, secret string) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements the Sink interface.
+func (*Webhook) Name() string {
+	return "webhook"
+}
+
+// Send implements the Sink interface.
+func (w *Webhook) Send(ctx context.Context, a Alert) error {
+	body := struct {
+		Severity string            `json:"severity"`
+		Title    string            `json:"title"`
+		Message  string            `json:"message,omitempty"`
+		Service  string            `json:"service"`
+		Fields   map[string]string `json:"fields,omitempty"`
+		Time     time.Time         `json:"time"`
+	}{
+		Severity: a.Severity.String(),
+		Title:    a.Title,
+		Message:  a.Message,
+		Service:  a.Service,
+		Fields:   a.Fields,
+		Time:     a.Time.UTC(),
+	}
+
+	return postJSON(ctx, w.client, w.url, body, w.secret)
+}
+
+// =============================================================================
+
+// EmailConfig represents the settings to send alerts by email.
+type EmailConfig struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Email sends the alerts by email through an SMTP server.
+type Email struct {
+	cfg EmailConfig
+}
+
+// NewEmail constructs a sink sending through the SMTP server at cfg.Addr,
+// e.g. "smtp.example.com:587".
+func NewEmail(cfg EmailConfig) *Email {
+	return &Email{
+		cfg: cfg,
+	}
+}
+
+// Name implements the Sink interface.
+func (*Email) Name() string {
+	return "email"
+}
+
+// Send implements the Sink interface.
+func (e *Email) Send(ctx context.Context, a Alert) error {
+	var body bytes.Buffer
+
+	fmt.Fprintf(&body, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&body, "Subject: [%s] %s: %s\r\n", strings.ToUpper(a.Severity.String()), a.Service, oneLine(a.Title))
+	fmt.Fprintf(&body, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	if a.Message != "" {
+		fmt.Fprintf(&body, "%s\r\n\r\n", a.Message)
+	}
+
+	for _, k := range sortedKeys(a.Fields) {
+		fmt.Fprintf(&body, "%s: %s\r\n", k, a.Fields[k])
+	}
+
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(e.cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("smtp addr: %w", err)
+		}
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)
+	}
+
+	// SendMail não aceita contexto; o prazo do envio fica sem efeito aqui.
+	if err := smtp.SendMail(e.cfg.Addr, auth, e.cfg.From, e.cfg.To, body.Bytes()); err != nil {
+		return fmt.Errorf("sendmail: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func postJSON(ctx context.Context, client *http.Client, url string, v any, secret []byte) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(secret) > 0 {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
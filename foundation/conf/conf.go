@@ -0,0 +1,453 @@
+// Package conf loads the configuration of a service from defaults, an optional
+// YAML or TOML file and the environment, in that order of precedence.
+//
+// The settings are the fields of a struct tagged the way envconfig expects:
+//
+//	Host     string `envconfig:"DB_HOST" default:"localhost" required:"true"`
+//	Password string `envconfig:"DB_PASSWORD" conf:"mask"`
+//
+// In the file the same setting is written as db.host, matching the names of
+// the struct fields without regard to case, underscores or hyphens.
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+)
+
+// ErrHelpWanted is returned by Parse when --help is requested. The usage
+// is returned along with it.
+var ErrHelpWanted = errors.New("help wanted")
+
+// ErrVersionWanted is returned by Parse when --version is requested. The
+// version is returned along with it.
+var ErrVersionWanted = errors.New("version wanted")
+
+// Version identifies the build of the service. A Version field in the config
+// is used for the --version output and is never loaded.
+type Version struct {
+	Build string `json:"build"`
+	Desc  string `json:"desc"`
+}
+
+// String implements the fmt.Stringer interface.
+func (v Version) String() string {
+	return strings.TrimSpace(v.Desc + " " + v.Build)
+}
+
+// field represents a setting found in the config struct.
+type field struct {
+	key      string
+	env      string
+	def      string
+	hasDef   bool
+	required bool
+	mask     bool
+	value    reflect.Value
+}
+
+// Parse loads the config pointed by cfg. The args are the command line
+// without the program name and accept --config, --help and --version.
+func Parse(args []string, cfg any) (string, error) {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return "", errors.New("conf: cfg must be a pointer to a struct")
+	}
+
+	fields, version := collect(rv.Elem(), "")
+
+	var file string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "-h" || arg == "--help" || arg == "-help":
+			return usage(fields, version), ErrHelpWanted
+
+		case arg == "-v" || arg == "--version" || arg == "-version":
+			return version.String(), ErrVersionWanted
+
+		case arg == "--config" || arg == "-config":
+			if i+1 >= len(args) {
+				return "", errors.New("conf: --config requires a file")
+			}
+			i++
+			file = args[i]
+
+		case strings.HasPrefix(arg, "--config="):
+			file = strings.TrimPrefix(arg, "--config=")
+
+		default:
+			return "", fmt.Errorf("conf: unknown argument %q", arg)
+		}
+	}
+
+	for _, f := range fields {
+		if !f.hasDef {
+			continue
+		}
+
+		if err := set(f.value, entry{values: []string{f.def}}); err != nil {
+			return "", fmt.Errorf("conf: default of %s: %w", f.key, err)
+		}
+	}
+
+	if file != "" {
+		if err := load(file, fields); err != nil {
+			return "", err
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+
+		value, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+
+		if err := set(f.value, entry{values: []string{value}}); err != nil {
+			return "", fmt.Errorf("conf: env %s: %w", f.env, err)
+		}
+	}
+
+	var missing []string
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			missing = append(missing, f.env)
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("conf: required settings not provided: %s", strings.Join(missing, ", "))
+	}
+
+	return "", nil
+}
+
+// load applies the settings of the file. Keys that match no setting are
+// rejected so a typo is not silently ignored.
+func load(file string, fields []field) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("conf: reading file: %w", err)
+	}
+
+	var entries map[string]entry
+
+	switch {
+	case strings.HasSuffix(file, ".yaml"), strings.HasSuffix(file, ".yml"):
+		entries, err = parseYAML(data)
+	case strings.HasSuffix(file, ".toml"):
+		entries, err = parseTOML(data)
+	default:
+		return fmt.Errorf("conf: unknown file format %q: expected .yaml, .yml or .toml", file)
+	}
+
+	if err != nil {
+		return fmt.Errorf("conf: parsing %s: %w", file, err)
+	}
+
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[normalize(f.key)] = f
+	}
+
+	for key, e := range entries {
+		f, ok := byKey[normalize(key)]
+		if !ok {
+			return fmt.Errorf("conf: %s:%d: unknown setting %q", file, e.line, key)
+		}
+
+		if err := set(f.value, e); err != nil {
+			return fmt.Errorf("conf: %s:%d: %s: %w", file, e.line, key, err)
+		}
+	}
+
+	return nil
+}
+
+// collect walks the struct returning its settings. Fields without the
+// envconfig tag are not settings, except the nested structs.
+func collect(v reflect.Value, prefix string) ([]field, Version) {
+	var fields []field
+	var version Version
+
+	t := v.Type()
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		key := prefix + snake(sf.Name)
+
+		if sf.Type == reflect.TypeFor[Version]() {
+			version = fv.Interface().(Version)
+			continue
+		}
+
+		env, tagged := sf.Tag.Lookup("envconfig")
+
+		if !tagged && sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeFor[time.Time]() {
+			nested, ver := collect(fv, key+".")
+			fields = append(fields, nested...)
+			if ver != (Version{}) {
+				version = ver
+			}
+			continue
+		}
+
+		if !tagged {
+			continue
+		}
+
+		def, hasDef := sf.Tag.Lookup("default")
+
+		fields = append(fields, field{
+			key:      key,
+			env:      env,
+			def:      def,
+			hasDef:   hasDef,
+			required: sf.Tag.Get("required") == "true",
+			mask:     hasOption(sf.Tag.Get("conf"), "mask"),
+			value:    fv,
+		})
+	}
+
+	return fields, version
+}
+
+func hasOption(tag string, option string) bool {
+	for opt := range strings.SplitSeq(tag, ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// set parses the entry into the field.
+func set(v reflect.Value, e entry) error {
+	if v.Kind() == reflect.Slice {
+		values := e.values
+		if !e.list {
+			values = nil
+			for s := range strings.SplitSeq(e.values[0], ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+		return nil
+	}
+
+	if e.list {
+		return errors.New("expected a single value, got a list")
+	}
+
+	return setScalar(v, e.values[0])
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeFor[time.Duration]() {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// String returns the config as JSON with the fields tagged conf:"mask"
+// replaced, so it can be logged.
+func String(cfg any) (string, error) {
+	var buf bytes.Buffer
+
+	if err := encode(&buf, reflect.Indirect(reflect.ValueOf(cfg))); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func encode(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+
+	buf.WriteByte('{')
+
+	first := true
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		name, _ := json.Marshal(sf.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		fv := v.Field(i)
+
+		switch {
+		case hasOption(sf.Tag.Get("conf"), "mask"):
+			buf.WriteString(`"[MASKED]"`)
+
+		case sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeFor[Version]():
+			if err := encode(buf, fv); err != nil {
+				return err
+			}
+
+		default:
+			data, err := json.Marshal(fv.Interface())
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+		}
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+// usage lists every setting with its file key, environment variable and
+// default value.
+func usage(fields []field, version Version) string {
+	var buf bytes.Buffer
+
+	if s := version.String(); s != "" {
+		fmt.Fprintf(&buf, "%s\n\n", s)
+	}
+
+	fmt.Fprintf(&buf, "Usage: %s [--config file.yaml|file.toml] [--help] [--version]\n\n", filepath.Base(os.Args[0]))
+	buf.WriteString("Settings are read from the defaults, then the config file, then the\n")
+	buf.WriteString("environment. File keys ignore case, underscores and hyphens.\n\n")
+
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tENV\tTYPE\tDEFAULT")
+
+	for _, f := range fields {
+		def := f.def
+		switch {
+		case f.mask && def != "":
+			def = "[MASKED]"
+		case f.required:
+			def = strings.TrimSpace(def + " (required)")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.key, f.env, typeName(f.value.Type()), def)
+	}
+
+	w.Flush()
+
+	return buf.String()
+}
+
+func typeName(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeFor[time.Duration]():
+		return "duration"
+	case t.Kind() == reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	}
+
+	return t.Kind().String()
+}
+
+// snake converts a field name, e.g. ReadTimeout or CORSAllowedOrigins, to the
+// key shown in the usage: read_timeout and cors_allowed_origins.
+func snake(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// normalize makes read_timeout, read-timeout and readTimeout the same key.
+func normalize(key string) string {
+	key = strings.ToLower(key)
+	return strings.NewReplacer("_", "", "-", "").Replace(key)
+}
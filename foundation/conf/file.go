@@ -0,0 +1,268 @@
+package conf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// entry represents a value read from the config file.
+type entry struct {
+	values []string
+	list   bool
+	line   int
+}
+
+// parseYAML reads the subset of YAML used by config files: nested mappings,
+// scalars and lists, either in block or flow style. Anchors, multi-line
+// strings and multiple documents are not supported.
+func parseYAML(data []byte) (map[string]entry, error) {
+	type level struct {
+		indent int
+		path   string
+	}
+
+	entries := make(map[string]entry)
+	stack := []level{{indent: -1}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for n := 1; scanner.Scan(); n++ {
+		raw := scanner.Text()
+
+		content := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(content) == "" || content == "---" {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(content, " ")
+		indent := len(content) - len(trimmed)
+
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n)
+		}
+
+		// Um item de lista pertence à última chave sem valor, que pode estar
+		// na mesma indentação que ele.
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+				stack = stack[:len(stack)-1]
+			}
+
+			owner := stack[len(stack)-1].path
+			if owner == "" {
+				return nil, fmt.Errorf("line %d: list item without a key", n)
+			}
+
+			value, err := unquote(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+
+			e := entries[owner]
+			e.values = append(e.values, value)
+			e.list = true
+			if e.line == 0 {
+				e.line = n
+			}
+			entries[owner] = e
+
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || (value != "" && value[0] != ' ') {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		key, err := unquote(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		path := key
+		if parent := stack[len(stack)-1].path; parent != "" {
+			path = parent + "." + key
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			stack = append(stack, level{indent: indent, path: path})
+			continue
+		}
+
+		e, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		e.line = n
+
+		entries[path] = e
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// parseTOML reads the subset of TOML used by config files: tables, key/value
+// pairs, strings, numbers, booleans and arrays of those.
+func parseTOML(data []byte) (map[string]entry, error) {
+	entries := make(map[string]entry)
+
+	var table string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header", n)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+
+		start := n
+
+		// Arrays podem ocupar várias linhas até o colchete de fechamento.
+		value = strings.TrimSpace(value)
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("line %d: unterminated array", start)
+			}
+			n++
+			value += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+
+		key, err := unquote(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		if table != "" {
+			key = table + "." + key
+		}
+
+		e, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+		e.line = start
+
+		entries[key] = e
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// parseValue reads a scalar or an inline list like [a, "b"].
+func parseValue(value string) (entry, error) {
+	if !strings.HasPrefix(value, "[") {
+		s, err := unquote(value)
+		if err != nil {
+			return entry{}, err
+		}
+		return entry{values: []string{s}}, nil
+	}
+
+	if !strings.HasSuffix(value, "]") {
+		return entry{}, errors.New("unterminated list")
+	}
+
+	e := entry{list: true, values: []string{}}
+
+	for _, item := range splitList(value[1 : len(value)-1]) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		s, err := unquote(item)
+		if err != nil {
+			return entry{}, err
+		}
+		e.values = append(e.values, s)
+	}
+
+	return e, nil
+}
+
+// splitList splits the items of an inline list on the commas outside quotes.
+func splitList(s string) []string {
+	var items []string
+	var quote rune
+	start := 0
+
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(items, s[start:])
+}
+
+// stripComment removes a # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func unquote(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,126 @@
+// Package crypto encrypts small values at rest, like the credentials of the
+// datasources, with AES-256-GCM. The sealed value carries the id of the key
+// so the key can be rotated while the old values are still readable.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size in bytes of the keys, AES-256.
+const KeySize = 32
+
+// ErrUnknownKey is returned when a value was sealed with a key the box
+// does not hold.
+var ErrUnknownKey = errors.New("unknown key")
+
+// ErrMalformed is returned when the value is too short to be sealed.
+var ErrMalformed = errors.New("malformed sealed value")
+
+// Box seals and opens values. The first key seals, every key opens.
+type Box struct {
+	active byte
+	aeads  map[byte]cipher.AEAD
+}
+
+// NewBox constructs a box from the keys, the first one being used to seal
+// the new values. Each key is identified by its position in the list, so
+// rotating a key means prepending the new one.
+func NewBox(keys ...[]byte) (*Box, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+
+	if len(keys) > 255 {
+		return nil, errors.New("at most 255 keys are supported")
+	}
+
+	b := Box{
+		active: byte(len(keys)),
+		aeads:  make(map[byte]cipher.AEAD, len(keys)),
+	}
+
+	// O id é contado a partir do fim da lista: incluir uma chave nova no
+	// início não muda o id das chaves que já existiam.
+	for i, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %d: expected %d bytes, got %d", i, KeySize, len(key))
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		b.aeads[byte(len(keys)-i)] = aead
+	}
+
+	return &b, nil
+}
+
+// ParseKey decodes a base64 encoded key, e.g. the output of
+// "openssl rand -base64 32".
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// Seal encrypts the plaintext. The result is the key id, the nonce and the
+// ciphertext. The associated data is authenticated but not stored, the same
+// value must be given to Open, e.g. the id of the record so a sealed value
+// cannot be copied to another one.
+func (b *Box) Seal(plaintext []byte, associated []byte) ([]byte, error) {
+	aead := b.aeads[b.active]
+
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0] = b.active
+
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+
+	return aead.Seal(out, out[1:], plaintext, associated), nil
+}
+
+// Open decrypts a value produced by Seal with the same associated data.
+func (b *Box) Open(sealed []byte, associated []byte) ([]byte, error) {
+	if len(sealed) < 1 {
+		return nil, ErrMalformed
+	}
+
+	aead, ok := b.aeads[sealed[0]]
+	if !ok {
+		return nil, fmt.Errorf("key %d: %w", sealed[0], ErrUnknownKey)
+	}
+
+	if len(sealed) < 1+aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+
+	nonce := sealed[1 : 1+aead.NonceSize()]
+
+	plaintext, err := aead.Open(nil, nonce, sealed[1+aead.NonceSize():], associated)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	return plaintext, nil
+}
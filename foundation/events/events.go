@@ -0,0 +1,23 @@
+// Package events publishes messages to a message broker. The Broker interface
+// hides the broker in use so the same events can go to NATS or Kafka.
+package events
+
+import (
+	"context"
+)
+
+// Message is a single event sent to a broker. Key identifies the event for
+// deduplication and, in Kafka, selects the partition.
+type Message struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Body    []byte
+}
+
+// Broker publishes messages. Publish must only return nil once the broker
+// accepted the message.
+type Broker interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
@@ -0,0 +1,257 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jcpaschoal/spi-exata/foundation/events"
+)
+
+// msg is published by every case, with a single header so the frame is
+// always written the same way.
+var msg = events.Message{
+	Topic:   "acl.changed",
+	Key:     "evt-1",
+	Headers: map[string]string{"Content-Type": "application/json"},
+	Body:    []byte(`{"id":1}`),
+}
+
+const (
+	natsInfo    = `INFO {"server_id":"NDX4","version":"2.10.20","proto":1,"headers":true,"max_payload":1048576}` + "\r\n"
+	natsConnect = `CONNECT {"headers":true,"lang":"go","name":"spi-exata","pedantic":false,"verbose":false}`
+	natsHPUB    = "HPUB acl.changed 64 72"
+	natsPayload = "NATS/1.0\r\nNats-Msg-Id: evt-1\r\nContent-Type: application/json\r\n\r\n" + `{"id":1}`
+)
+
+func TestNATS(t *testing.T) {
+	tests := []struct {
+		name     string
+		greeting string
+		replies  []string
+		wantErrs []bool
+		wantCmds []string
+	}{
+		{
+			name:     "accepted",
+			greeting: natsInfo,
+			replies:  []string{"PONG\r\n"},
+			wantErrs: []bool{false},
+			wantCmds: []string{natsConnect, natsHPUB, natsPayload, "PING"},
+		},
+		{
+			name:     "keepAlive",
+			greeting: natsInfo,
+			replies:  []string{"PING\r\nPONG\r\n"},
+			wantErrs: []bool{false},
+			wantCmds: []string{natsConnect, natsHPUB, natsPayload, "PING", "PONG"},
+		},
+		{
+			name:     "refusedThenReconnect",
+			greeting: natsInfo,
+			replies:  []string{"-ERR 'Permissions Violation for Publish to \"acl.changed\"'\r\n", "PONG\r\n"},
+			wantErrs: []bool{true, false},
+			wantCmds: []string{natsConnect, natsHPUB, natsPayload, "PING", natsConnect, natsHPUB, natsPayload, "PING"},
+		},
+		{
+			name:     "badGreeting",
+			greeting: "-ERR 'Authorization Violation'\r\n",
+			wantErrs: []bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newNATSServer(t, tt.greeting, tt.replies)
+
+			n := events.NewNATS("nats://"+srv.addr, "spi-exata")
+
+			for i, wantErr := range tt.wantErrs {
+				err := n.Publish(context.Background(), msg)
+				if (err != nil) != wantErr {
+					t.Fatalf("publish %d: got error %v, want error %t", i, err, wantErr)
+				}
+			}
+
+			n.Close()
+
+			cmds := srv.commands()
+			if strings.Join(cmds, "|") != strings.Join(tt.wantCmds, "|") {
+				t.Errorf("got commands %q, want %q", cmds, tt.wantCmds)
+			}
+		})
+	}
+}
+
+func TestKafka(t *testing.T) {
+	const wantBody = `{"records":[{"key":"ZXZ0LTE=","value":"eyJpZCI6MX0="}]}`
+
+	tests := []struct {
+		name    string
+		status  int
+		reply   string
+		wantErr bool
+	}{
+		{
+			name:   "accepted",
+			status: http.StatusOK,
+			reply:  `{"offsets":[{"partition":0,"offset":42,"error_code":null,"error":null}],"key_schema_id":null,"value_schema_id":null}`,
+		},
+		{
+			name:    "recordFailed",
+			status:  http.StatusOK,
+			reply:   `{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"This server is not the leader for that topic-partition."}],"key_schema_id":null,"value_schema_id":null}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknownTopic",
+			status:  http.StatusNotFound,
+			reply:   `{"error_code":40401,"message":"Topic acl.changed not found."}`,
+			wantErr: true,
+		},
+		{
+			name:    "badReply",
+			status:  http.StatusOK,
+			reply:   `<html>proxy error</html>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var body []byte
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				body, _ = io.ReadAll(r.Body)
+
+				w.Header().Set("Content-Type", "application/vnd.kafka.v2+json")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.reply)
+			}))
+			defer srv.Close()
+
+			k := events.NewKafka(srv.URL + "/")
+			defer k.Close()
+
+			err := k.Publish(context.Background(), msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+
+			if got.Method != http.MethodPost || got.URL.Path != "/topics/acl.changed" {
+				t.Errorf("got %s %s, want POST /topics/acl.changed", got.Method, got.URL.Path)
+			}
+			if ct := got.Header.Get("Content-Type"); ct != "application/vnd.kafka.binary.v2+json" {
+				t.Errorf("got Content-Type %q, want the binary embedded format", ct)
+			}
+			if string(body) != wantBody {
+				t.Errorf("got body %s, want %s", body, wantBody)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// natsServer greets each connection and answers every PING with the next
+// recorded reply, keeping the commands and payloads it reads.
+type natsServer struct {
+	addr string
+	wg   sync.WaitGroup
+
+	mu   sync.Mutex
+	cmds []string
+	next int
+}
+
+func newNATSServer(t *testing.T, greeting string, replies []string) *natsServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := natsServer{addr: ln.Addr().String()}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			srv.wg.Add(1)
+			go func() {
+				defer srv.wg.Done()
+				defer conn.Close()
+
+				io.WriteString(conn, greeting)
+
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimSuffix(line, "\r\n")
+					srv.record(line)
+
+					switch {
+					case strings.HasPrefix(line, "HPUB "):
+						// O último campo é o tamanho do cabeçalho mais o corpo.
+						f := strings.Fields(line)
+						size, _ := strconv.Atoi(f[len(f)-1])
+
+						buf := make([]byte, size+2)
+						if _, err := io.ReadFull(r, buf); err != nil {
+							return
+						}
+						srv.record(string(buf[:size]))
+
+					case line == "PING":
+						srv.mu.Lock()
+						reply := ""
+						if srv.next < len(replies) {
+							reply = replies[srv.next]
+							srv.next++
+						}
+						srv.mu.Unlock()
+
+						io.WriteString(conn, reply)
+					}
+				}
+			}()
+		}
+	}()
+
+	return &srv
+}
+
+func (s *natsServer) record(cmd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cmds = append(s.cmds, cmd)
+}
+
+// commands waits for the client connections to be closed and returns what
+// the server read from them.
+func (s *natsServer) commands() []string {
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cmds
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kafka publishes messages to Kafka through a REST Proxy (v2 API), so the
+// service needs no native Kafka client. Headers are not supported by the
+// proxy and are dropped; the Key is sent as the record key.
+type Kafka struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewKafka constructs a broker for the REST Proxy at baseURL, e.g.
+// "http://localhost:8082".
+func NewKafka(baseURL string) *Kafka {
+	return &Kafka{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value"`
+}
+
+type kafkaResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish implements the Broker interface.
+func (k *Kafka) Publish(ctx context.Context, msg Message) error {
+	rec := kafkaRecord{
+		Value: base64.StdEncoding.EncodeToString(msg.Body),
+	}
+	if msg.Key != "" {
+		rec.Key = base64.StdEncoding.EncodeToString([]byte(msg.Key))
+	}
+
+	body, err := json.Marshal(map[string]any{"records": []kafkaRecord{rec}})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	endpoint := k.baseURL + "/topics/" + url.PathEscape(msg.Topic)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post: unexpected status %d", resp.StatusCode)
+	}
+
+	var kr kafkaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kr); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	for _, o := range kr.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("produce: code %d: %s", *o.ErrorCode, o.Error)
+		}
+	}
+
+	return nil
+}
+
+// Close implements the Broker interface.
+func (k *Kafka) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
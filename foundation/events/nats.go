@@ -0,0 +1,200 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATS publishes messages to a NATS server using the client protocol. The Key
+// is sent in the Nats-Msg-Id header, which JetStream uses to drop duplicates.
+// The connection is opened on first use and again after any failure.
+type NATS struct {
+	addr string
+	name string
+
+	mu    sync.Mutex
+	conn  net.Conn
+	wmu   sync.Mutex
+	pongs chan struct{}
+	errs  chan error
+}
+
+// NewNATS constructs a broker for the server at addr, e.g. "localhost:4222".
+// The name identifies the client in the server monitoring.
+func NewNATS(addr string, name string) *NATS {
+	addr = strings.TrimPrefix(addr, "nats://")
+
+	return &NATS{
+		addr: addr,
+		name: name,
+	}
+}
+
+// Publish implements the Broker interface. The message is followed by a PING
+// so it returns only after the server processed it.
+func (n *NATS) Publish(ctx context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return fmt.Errorf("connect: %w", err)
+		}
+	}
+
+	var hdr strings.Builder
+	hdr.WriteString("NATS/1.0\r\n")
+	if msg.Key != "" {
+		fmt.Fprintf(&hdr, "Nats-Msg-Id: %s\r\n", msg.Key)
+	}
+	for k, v := range msg.Headers {
+		fmt.Fprintf(&hdr, "%s: %s\r\n", k, v)
+	}
+	hdr.WriteString("\r\n")
+
+	h := hdr.String()
+	frame := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n", msg.Topic, len(h), len(h)+len(msg.Body), h, msg.Body)
+
+	if err := n.write(ctx, frame); err != nil {
+		n.reset()
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	select {
+	case <-n.pongs:
+		return nil
+
+	case err := <-n.errs:
+		n.reset()
+		return fmt.Errorf("publish: %w", err)
+
+	case <-ctx.Done():
+		n.reset()
+		return fmt.Errorf("publish: %w", ctx.Err())
+	}
+}
+
+// Close implements the Broker interface.
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.reset()
+
+	return nil
+}
+
+// connect dials the server, waits for the INFO greeting and sends CONNECT.
+func (n *NATS) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(conn)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("read info: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+
+	opts, err := json.Marshal(map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  true,
+		"name":     n.name,
+		"lang":     "go",
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	n.conn = conn
+	n.pongs = make(chan struct{}, 1)
+	n.errs = make(chan error, 1)
+
+	if err := n.write(ctx, "CONNECT "+string(opts)+"\r\n"); err != nil {
+		n.reset()
+		return err
+	}
+
+	go n.read(conn, r, n.pongs, n.errs)
+
+	return nil
+}
+
+// read answers the server keep alive and reports acknowledgements and errors
+// until the connection is closed.
+func (n *NATS) read(conn net.Conn, r *bufio.Reader, pongs chan<- struct{}, errs chan<- error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			select {
+			case errs <- err:
+			default:
+			}
+			return
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "PING":
+			n.wmu.Lock()
+			_, err := conn.Write([]byte("PONG\r\n"))
+			n.wmu.Unlock()
+			if err != nil {
+				return
+			}
+
+		case line == "PONG":
+			select {
+			case pongs <- struct{}{}:
+			default:
+			}
+
+		case strings.HasPrefix(line, "-ERR"):
+			select {
+			case errs <- errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))):
+			default:
+			}
+		}
+	}
+}
+
+func (n *NATS) write(ctx context.Context, data string) error {
+	n.wmu.Lock()
+	defer n.wmu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		n.conn.SetWriteDeadline(deadline)
+		defer n.conn.SetWriteDeadline(time.Time{})
+	}
+
+	_, err := n.conn.Write([]byte(data))
+	return err
+}
+
+func (n *NATS) reset() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+}
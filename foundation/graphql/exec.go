@@ -0,0 +1,294 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+type executor struct {
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []Error
+}
+
+// selectionSet resolves the fields of the object in the order they were
+// requested. Fields with the same response key are merged.
+func (e *executor) selectionSet(ctx context.Context, obj *Object, source any, set []selection, path []any) *orderedMap {
+	var groups fieldGroups
+	e.collect(obj, set, &groups)
+
+	result := orderedMap{
+		keys:   make([]string, 0, len(groups.keys)),
+		values: make([]any, 0, len(groups.keys)),
+	}
+
+	for _, key := range groups.keys {
+		fields := groups.fields[key]
+
+		fieldPath := append(clonePath(path), key)
+		result.keys = append(result.keys, key)
+		result.values = append(result.values, e.field(ctx, obj, source, fields, fieldPath))
+	}
+
+	return &result
+}
+
+func (e *executor) field(ctx context.Context, obj *Object, source any, fields []*field, path []any) any {
+	f := fields[0]
+
+	if f.name == "__typename" {
+		return obj.Name
+	}
+
+	def := obj.Fields[f.name]
+
+	args, err := e.arguments(f.arguments)
+	if err != nil {
+		e.addError(ctx, err, f, path)
+		return nil
+	}
+
+	val, err := def.Resolve(ctx, Params{Source: source, Args: args})
+	if err != nil {
+		e.addError(ctx, err, f, path)
+		return nil
+	}
+
+	if def.Type == nil || isNil(val) {
+		return val
+	}
+
+	var set []selection
+	for _, f := range fields {
+		set = append(set, f.selection...)
+	}
+
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice {
+		return e.selectionSet(ctx, def.Type, val, set, path)
+	}
+
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = e.selectionSet(ctx, def.Type, rv.Index(i).Interface(), set, append(clonePath(path), i))
+	}
+
+	return list
+}
+
+func (e *executor) addError(ctx context.Context, err error, f *field, path []any) {
+	gerr := Error{Message: err.Error()}
+
+	var known Error
+	switch {
+	case errors.As(err, &known):
+		gerr = known
+	case e.schema.PresentError != nil:
+		gerr = e.schema.PresentError(ctx, err)
+	}
+
+	gerr.Locations = []Location{{Line: f.line, Column: f.column}}
+	gerr.Path = path
+
+	e.errors = append(e.errors, gerr)
+}
+
+// =============================================================================
+
+type fieldGroups struct {
+	keys   []string
+	fields map[string][]*field
+}
+
+// collect flattens the fragments of the selection set, leaving out the
+// selections excluded by @include and @skip.
+func (e *executor) collect(obj *Object, set []selection, groups *fieldGroups) {
+	if groups.fields == nil {
+		groups.fields = make(map[string][]*field)
+	}
+
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+
+			key := sel.responseKey()
+			if _, exists := groups.fields[key]; !exists {
+				groups.keys = append(groups.keys, key)
+			}
+			groups.fields[key] = append(groups.fields[key], sel)
+
+		case *fragmentSpread:
+			if !e.included(sel.directives) {
+				continue
+			}
+			e.collect(obj, e.doc.fragments[sel.name].selection, groups)
+
+		case *inlineFragment:
+			if !e.included(sel.directives) {
+				continue
+			}
+			e.collect(obj, sel.selection, groups)
+		}
+	}
+}
+
+func (e *executor) included(dirs []directive) bool {
+	for _, d := range dirs {
+		v, _ := literal(d.arguments[0].val, e.vars)
+		b, _ := v.(bool)
+
+		switch d.name {
+		case "include":
+			if !b {
+				return false
+			}
+		case "skip":
+			if b {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (e *executor) arguments(args []argument) (Args, error) {
+	if len(args) == 0 {
+		return Args{}, nil
+	}
+
+	values := make(Args, len(args))
+	for _, arg := range args {
+		v, err := literal(arg.val, e.vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", arg.name, err)
+		}
+		values[arg.name] = v
+	}
+
+	return values, nil
+}
+
+// literal converts a value of the document to Go, replacing the variables.
+func literal(v value, vars map[string]any) (any, error) {
+	switch v.kind {
+	case valueNull:
+		return nil, nil
+
+	case valueInt:
+		n, err := strconv.ParseInt(v.raw, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %s", v.raw)
+		}
+		return int(n), nil
+
+	case valueFloat:
+		f, err := strconv.ParseFloat(v.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %s", v.raw)
+		}
+		return f, nil
+
+	case valueString, valueEnum:
+		return v.raw, nil
+
+	case valueBoolean:
+		return v.raw == "true", nil
+
+	case valueVariable:
+		return vars[v.variable], nil
+
+	case valueList:
+		list := make([]any, len(v.list))
+		for i, item := range v.list {
+			lv, err := literal(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = lv
+		}
+		return list, nil
+
+	case valueObject:
+		obj := make(map[string]any, len(v.object))
+		for _, arg := range v.object {
+			ov, err := literal(arg.val, vars)
+			if err != nil {
+				return nil, err
+			}
+			obj[arg.name] = ov
+		}
+		return obj, nil
+	}
+
+	return nil, fmt.Errorf("unknown value")
+}
+
+// =============================================================================
+
+// orderedMap keeps the fields of a result in the order of the query, which
+// the spec requires and encoding/json does not do for maps.
+type orderedMap struct {
+	keys   []string
+	values []any
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+
+		v, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", key, err)
+		}
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// clonePath returns a copy of the path with room for one more element, so the
+// paths of sibling fields do not share the backing array.
+func clonePath(path []any) []any {
+	cp := make([]any, len(path), len(path)+1)
+	copy(cp, path)
+	return cp
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+
+	return false
+}
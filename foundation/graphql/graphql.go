@@ -0,0 +1,223 @@
+// Package graphql provides a small GraphQL executor for read only APIs. It
+// supports queries with variables, aliases, fragments and the @include and
+// @skip directives. The schema is declared in Go: every field is nullable and
+// leaf values are encoded to JSON as returned by the resolvers. Mutations,
+// subscriptions and introspection other than __typename are not supported.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// ResolveFunc returns the value of a field. Source is the value resolved for
+// the parent object, nil for the fields of the query type.
+type ResolveFunc func(ctx context.Context, p Params) (any, error)
+
+// Params represents the values available to a resolver.
+type Params struct {
+	Source any
+	Args   Args
+}
+
+// Field describes a field of an object. A nil Type makes it a leaf, the value
+// returned by Resolve is sent as is. Otherwise the value, or every element of
+// a slice value, is resolved against the fields of the Type.
+type Field struct {
+	Type    *Object
+	Args    []string
+	Resolve ResolveFunc
+}
+
+// Object describes a type with fields. The fields can be set after the
+// object is declared so types can refer to each other.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Schema is the entry point of the queries.
+type Schema struct {
+	Query *Object
+
+	// MaxDepth rejects queries nesting more objects than allowed. Zero means
+	// no limit.
+	MaxDepth int
+
+	// MaxNodes rejects queries selecting more fields than allowed, counted
+	// with the fragments expanded where they are spread. A fragment spread
+	// twice counts twice, which keeps a short query of fragments spreading
+	// each other from growing exponentially. Zero means no limit.
+	MaxNodes int
+
+	// MaxFields rejects selection sets written with more fields than
+	// allowed. Zero means no limit.
+	MaxFields int
+
+	// PresentError converts the errors returned by the resolvers. When nil
+	// the message of the error is used.
+	PresentError func(ctx context.Context, err error) Error
+}
+
+// Request represents a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Response represents the result of a request. Data is nil when the request
+// could not be executed.
+type Response struct {
+	Data   any     `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Error represents an error of the request or of a single field.
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Error implements the error interface.
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Location is the position of a field in the query.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// =============================================================================
+
+// Args contains the arguments of a field, with the variables replaced.
+type Args map[string]any
+
+// String returns the argument as a string, empty when not informed.
+func (a Args) String(name string) (string, error) {
+	v, exists := a[name]
+	if !exists || v == nil {
+		return "", nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q: expected a string", name)
+	}
+
+	return s, nil
+}
+
+// Int returns the argument as an int, zero when not informed. Variables
+// decoded from JSON arrive as float64 and are accepted when integral.
+func (a Args) Int(name string) (int, error) {
+	v, exists := a[name]
+	if !exists || v == nil {
+		return 0, nil
+	}
+
+	switch n := v.(type) {
+	case int:
+		return n, nil
+
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int(n), nil
+		}
+	}
+
+	return 0, fmt.Errorf("argument %q: expected an int", name)
+}
+
+// =============================================================================
+
+// Execute runs the query of the request.
+func (s *Schema) Execute(ctx context.Context, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return requestError(err)
+	}
+
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+
+	if err := s.validate(doc, op); err != nil {
+		return requestError(err)
+	}
+
+	vars, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return requestError(err)
+	}
+
+	e := executor{
+		schema: s,
+		doc:    doc,
+		vars:   vars,
+	}
+
+	data := e.selectionSet(ctx, s.Query, nil, op.selection, nil)
+
+	return Response{
+		Data:   data,
+		Errors: e.errors,
+	}
+}
+
+func requestError(err error) Response {
+	return Response{
+		Errors: []Error{{Message: err.Error()}},
+	}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) != 1 {
+			return nil, fmt.Errorf("the document has %d operations, operationName is required", len(doc.operations))
+		}
+		return doc.operations[0], nil
+	}
+
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(op *operation, provided map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+
+	for _, vd := range op.variables {
+		if v, exists := provided[vd.name]; exists && v != nil {
+			vars[vd.name] = v
+			continue
+		}
+
+		if vd.defaultVal.kind != valueNull {
+			v, err := literal(vd.defaultVal, nil)
+			if err != nil {
+				return nil, fmt.Errorf("variable $%s: %w", vd.name, err)
+			}
+			vars[vd.name] = v
+			continue
+		}
+
+		if vd.nonNull {
+			return nil, fmt.Errorf("variable $%s of a non null type was not provided", vd.name)
+		}
+
+		vars[vd.name] = nil
+	}
+
+	return vars, nil
+}
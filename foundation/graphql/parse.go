@@ -0,0 +1,654 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is the parsed form of a request. Only the parts of the language
+// the executor supports are kept.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name      string
+	variables []variableDef
+	selection []selection
+}
+
+type variableDef struct {
+	name       string
+	nonNull    bool
+	defaultVal value
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selection     []selection
+}
+
+// selection is one of *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  []argument
+	directives []directive
+	selection  []selection
+	line       int
+	column     int
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+}
+
+type inlineFragment struct {
+	typeCondition string
+	directives    []directive
+	selection     []selection
+}
+
+type argument struct {
+	name string
+	val  value
+}
+
+type directive struct {
+	name      string
+	arguments []argument
+}
+
+// value is a literal or a reference to a variable found in the document.
+type value struct {
+	kind     valueKind
+	raw      string
+	list     []value
+	object   []argument
+	variable string
+}
+
+type valueKind int
+
+const (
+	valueNull valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBoolean
+	valueEnum
+	valueList
+	valueObject
+	valueVariable
+)
+
+// =============================================================================
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	line   int
+	column int
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	col  int
+	tok  token
+}
+
+// parse reads the document of a request.
+func parse(src string) (doc *document, err error) {
+	p := parser{src: src, line: 1, col: 1}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			perr, ok := rec.(syntaxError)
+			if !ok {
+				panic(rec)
+			}
+			doc, err = nil, perr
+		}
+	}()
+
+	p.next()
+
+	doc = &document{
+		fragments: make(map[string]*fragment),
+	}
+
+	if p.tok.kind == tokenEOF {
+		p.fail("empty document")
+	}
+
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peekPunct("{"):
+			doc.operations = append(doc.operations, &operation{selection: p.parseSelectionSet()})
+
+		case p.tok.kind == tokenName && p.tok.value == "query":
+			doc.operations = append(doc.operations, p.parseOperation())
+
+		case p.tok.kind == tokenName && (p.tok.value == "mutation" || p.tok.value == "subscription"):
+			p.fail("%s operations are not supported", p.tok.value)
+
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			f := p.parseFragment()
+			if _, exists := doc.fragments[f.name]; exists {
+				p.fail("fragment %q is defined more than once", f.name)
+			}
+			doc.fragments[f.name] = f
+
+		default:
+			p.fail("unexpected %s", p.describe())
+		}
+	}
+
+	return doc, nil
+}
+
+func (p *parser) parseOperation() *operation {
+	p.expectName("query")
+
+	var op operation
+
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		p.next()
+	}
+
+	if p.skipPunct("(") {
+		for !p.skipPunct(")") {
+			op.variables = append(op.variables, p.parseVariableDef())
+		}
+	}
+
+	if p.peekPunct("@") {
+		p.fail("directives are not supported on operations")
+	}
+
+	op.selection = p.parseSelectionSet()
+
+	return &op
+}
+
+func (p *parser) parseVariableDef() variableDef {
+	p.expectPunct("$")
+
+	vd := variableDef{
+		name: p.parseName(),
+	}
+
+	p.expectPunct(":")
+	vd.nonNull = p.parseType()
+
+	vd.defaultVal = value{kind: valueNull}
+	if p.skipPunct("=") {
+		vd.defaultVal = p.parseValue(true)
+	}
+
+	return vd
+}
+
+// parseType skips the type of a variable, the executor does not check types.
+// It reports whether the outer type is non null.
+func (p *parser) parseType() bool {
+	if p.skipPunct("[") {
+		p.parseType()
+		p.expectPunct("]")
+	} else {
+		p.parseName()
+	}
+
+	return p.skipPunct("!")
+}
+
+func (p *parser) parseFragment() *fragment {
+	p.expectName("fragment")
+
+	f := fragment{
+		name: p.parseName(),
+	}
+
+	if f.name == "on" {
+		p.fail("fragment cannot be named \"on\"")
+	}
+
+	p.expectName("on")
+	f.typeCondition = p.parseName()
+
+	if p.peekPunct("@") {
+		p.fail("directives are not supported on fragment definitions")
+	}
+
+	f.selection = p.parseSelectionSet()
+
+	return &f
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expectPunct("{")
+
+	var set []selection
+	for !p.skipPunct("}") {
+		set = append(set, p.parseSelection())
+	}
+
+	if len(set) == 0 {
+		p.fail("empty selection set")
+	}
+
+	return set
+}
+
+func (p *parser) parseSelection() selection {
+	if p.skipPunct("...") {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			return &fragmentSpread{
+				name:       p.parseName(),
+				directives: p.parseDirectives(),
+			}
+		}
+
+		var inf inlineFragment
+		if p.tok.kind == tokenName && p.tok.value == "on" {
+			p.next()
+			inf.typeCondition = p.parseName()
+		}
+		inf.directives = p.parseDirectives()
+		inf.selection = p.parseSelectionSet()
+
+		return &inf
+	}
+
+	f := field{
+		line:   p.tok.line,
+		column: p.tok.column,
+	}
+
+	f.name = p.parseName()
+	if p.skipPunct(":") {
+		f.alias = f.name
+		f.name = p.parseName()
+	}
+
+	f.arguments = p.parseArguments(false)
+	f.directives = p.parseDirectives()
+
+	if p.peekPunct("{") {
+		f.selection = p.parseSelectionSet()
+	}
+
+	return &f
+}
+
+func (p *parser) parseArguments(constant bool) []argument {
+	if !p.skipPunct("(") {
+		return nil
+	}
+
+	var args []argument
+	for !p.skipPunct(")") {
+		name := p.parseName()
+		p.expectPunct(":")
+		args = append(args, argument{name: name, val: p.parseValue(constant)})
+	}
+
+	return args
+}
+
+func (p *parser) parseDirectives() []directive {
+	var dirs []directive
+	for p.skipPunct("@") {
+		dirs = append(dirs, directive{
+			name:      p.parseName(),
+			arguments: p.parseArguments(false),
+		})
+	}
+
+	return dirs
+}
+
+func (p *parser) parseValue(constant bool) value {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				p.fail("variables are not allowed in default values")
+			}
+			p.next()
+			return value{kind: valueVariable, variable: p.parseName()}
+
+		case "[":
+			p.next()
+			v := value{kind: valueList}
+			for !p.skipPunct("]") {
+				v.list = append(v.list, p.parseValue(constant))
+			}
+			return v
+
+		case "{":
+			p.next()
+			v := value{kind: valueObject}
+			for !p.skipPunct("}") {
+				name := p.parseName()
+				p.expectPunct(":")
+				v.object = append(v.object, argument{name: name, val: p.parseValue(constant)})
+			}
+			return v
+		}
+
+	case tokenInt:
+		p.next()
+		return value{kind: valueInt, raw: tok.value}
+
+	case tokenFloat:
+		p.next()
+		return value{kind: valueFloat, raw: tok.value}
+
+	case tokenString:
+		p.next()
+		return value{kind: valueString, raw: tok.value}
+
+	case tokenName:
+		p.next()
+		switch tok.value {
+		case "true", "false":
+			return value{kind: valueBoolean, raw: tok.value}
+		case "null":
+			return value{kind: valueNull}
+		}
+		return value{kind: valueEnum, raw: tok.value}
+	}
+
+	p.fail("unexpected %s, expected a value", p.describe())
+	return value{}
+}
+
+// =============================================================================
+
+type syntaxError struct {
+	msg    string
+	line   int
+	column int
+}
+
+func (e syntaxError) Error() string {
+	return fmt.Sprintf("syntax error: %s (line %d, column %d)", e.msg, e.line, e.column)
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(syntaxError{
+		msg:    fmt.Sprintf(format, args...),
+		line:   p.tok.line,
+		column: p.tok.column,
+	})
+}
+
+func (p *parser) describe() string {
+	switch p.tok.kind {
+	case tokenEOF:
+		return "end of document"
+	case tokenString:
+		return "string"
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+func (p *parser) peekPunct(v string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == v
+}
+
+func (p *parser) skipPunct(v string) bool {
+	if p.peekPunct(v) {
+		p.next()
+		return true
+	}
+
+	if p.tok.kind == tokenEOF {
+		p.fail("unexpected end of document")
+	}
+
+	return false
+}
+
+func (p *parser) expectPunct(v string) {
+	if !p.peekPunct(v) {
+		p.fail("unexpected %s, expected %q", p.describe(), v)
+	}
+	p.next()
+}
+
+func (p *parser) expectName(v string) {
+	if p.tok.kind != tokenName || p.tok.value != v {
+		p.fail("unexpected %s, expected %q", p.describe(), v)
+	}
+	p.next()
+}
+
+func (p *parser) parseName() string {
+	if p.tok.kind != tokenName {
+		p.fail("unexpected %s, expected a name", p.describe())
+	}
+
+	name := p.tok.value
+	p.next()
+
+	return name
+}
+
+// next reads the following token. Whitespace, commas and comments are
+// ignored as the spec defines.
+func (p *parser) next() {
+	p.skipIgnored()
+
+	p.tok = token{line: p.line, column: p.col}
+
+	if p.pos >= len(p.src) {
+		p.tok.kind = tokenEOF
+		return
+	}
+
+	c := p.src[p.pos]
+
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.advance(3)
+		p.tok.kind, p.tok.value = tokenPunct, "..."
+
+	case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+		p.advance(1)
+		p.tok.kind, p.tok.value = tokenPunct, string(c)
+
+	case c == '_' || isLetter(c):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.advance(1)
+		}
+		p.tok.kind, p.tok.value = tokenName, p.src[start:p.pos]
+
+	case c == '-' || isDigit(c):
+		p.readNumber()
+
+	case c == '"':
+		p.readString()
+
+	default:
+		p.fail("unexpected character %q", c)
+	}
+}
+
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case ' ', '\t', ',', '\r':
+			p.advance(1)
+
+		case '\n':
+			p.pos++
+			p.line++
+			p.col = 1
+
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.advance(1)
+			}
+
+		default:
+			if strings.HasPrefix(p.src[p.pos:], "\uFEFF") {
+				p.advance(3)
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (p *parser) readNumber() {
+	start := p.pos
+	float := false
+
+	if p.src[p.pos] == '-' {
+		p.advance(1)
+	}
+
+	digits := func() {
+		n := 0
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.advance(1)
+			n++
+		}
+		if n == 0 {
+			p.fail("invalid number")
+		}
+	}
+
+	digits()
+
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		float = true
+		p.advance(1)
+		digits()
+	}
+
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		float = true
+		p.advance(1)
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.advance(1)
+		}
+		digits()
+	}
+
+	p.tok.kind, p.tok.value = tokenInt, p.src[start:p.pos]
+	if float {
+		p.tok.kind = tokenFloat
+	}
+}
+
+func (p *parser) readString() {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		p.fail("block strings are not supported")
+	}
+
+	p.advance(1)
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.fail("unterminated string")
+		}
+
+		c := p.src[p.pos]
+
+		switch c {
+		case '"':
+			p.advance(1)
+			p.tok.kind, p.tok.value = tokenString, sb.String()
+			return
+
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				p.fail("unterminated string")
+			}
+
+			esc := p.src[p.pos+1]
+			p.advance(2)
+
+			switch esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					p.fail("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					p.fail("invalid unicode escape")
+				}
+				sb.WriteRune(rune(r))
+				p.advance(4)
+			default:
+				p.fail("invalid escape \\%c", esc)
+			}
+
+		default:
+			_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			sb.WriteString(p.src[p.pos : p.pos+size])
+			p.advance(size)
+		}
+	}
+}
+
+func (p *parser) advance(n int) {
+	p.pos += n
+	p.col += n
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
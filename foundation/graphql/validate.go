@@ -0,0 +1,194 @@
+package graphql
+
+import (
+	"fmt"
+	"slices"
+)
+
+// validate checks the operation against the schema before anything is
+// resolved, so an invalid query never reaches the resolvers.
+func (s *Schema) validate(doc *document, op *operation) error {
+	v := validator{
+		schema:   s,
+		doc:      doc,
+		defined:  make(map[string]bool, len(op.variables)),
+		visiting: make(map[string]bool),
+	}
+
+	for _, vd := range op.variables {
+		if v.defined[vd.name] {
+			return fmt.Errorf("variable $%s is defined more than once", vd.name)
+		}
+		v.defined[vd.name] = true
+	}
+
+	return v.selectionSet(s.Query, op.selection, 1)
+}
+
+type validator struct {
+	schema   *Schema
+	doc      *document
+	defined  map[string]bool
+	visiting map[string]bool
+	nodes    int
+}
+
+func (v *validator) selectionSet(obj *Object, set []selection, depth int) error {
+	if v.schema.MaxDepth > 0 && depth > v.schema.MaxDepth {
+		return fmt.Errorf("query exceeds the maximum depth of %d", v.schema.MaxDepth)
+	}
+
+	if v.schema.MaxFields > 0 {
+		var fields int
+		for _, sel := range set {
+			if _, ok := sel.(*field); ok {
+				fields++
+			}
+		}
+
+		if fields > v.schema.MaxFields {
+			return fmt.Errorf("selection set exceeds the maximum of %d fields", v.schema.MaxFields)
+		}
+	}
+
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *field:
+			if err := v.field(obj, sel, depth); err != nil {
+				return err
+			}
+
+		case *fragmentSpread:
+			if err := v.directives(sel.directives); err != nil {
+				return err
+			}
+
+			frag, exists := v.doc.fragments[sel.name]
+			if !exists {
+				return fmt.Errorf("unknown fragment %q", sel.name)
+			}
+
+			if frag.typeCondition != obj.Name {
+				return fmt.Errorf("fragment %q on %q cannot be spread on %q", frag.name, frag.typeCondition, obj.Name)
+			}
+
+			if v.visiting[frag.name] {
+				return fmt.Errorf("fragment %q spreads itself", frag.name)
+			}
+
+			v.visiting[frag.name] = true
+			err := v.selectionSet(obj, frag.selection, depth)
+			delete(v.visiting, frag.name)
+
+			if err != nil {
+				return err
+			}
+
+		case *inlineFragment:
+			if err := v.directives(sel.directives); err != nil {
+				return err
+			}
+
+			if sel.typeCondition != "" && sel.typeCondition != obj.Name {
+				return fmt.Errorf("fragment on %q cannot be spread on %q", sel.typeCondition, obj.Name)
+			}
+
+			if err := v.selectionSet(obj, sel.selection, depth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) field(obj *Object, f *field, depth int) error {
+	// A contagem para no limite, então validar custa no máximo MaxNodes
+	// campos por mais que os fragmentos se multipliquem.
+	v.nodes++
+	if v.schema.MaxNodes > 0 && v.nodes > v.schema.MaxNodes {
+		return fmt.Errorf("query exceeds the maximum of %d fields", v.schema.MaxNodes)
+	}
+
+	if err := v.directives(f.directives); err != nil {
+		return err
+	}
+
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 || f.selection != nil {
+			return fmt.Errorf("field \"__typename\" takes no arguments nor selection")
+		}
+		return nil
+	}
+
+	def, exists := obj.Fields[f.name]
+	if !exists {
+		return fmt.Errorf("cannot query field %q on type %q", f.name, obj.Name)
+	}
+
+	for _, arg := range f.arguments {
+		if !slices.Contains(def.Args, arg.name) {
+			return fmt.Errorf("unknown argument %q on field \"%s.%s\"", arg.name, obj.Name, f.name)
+		}
+
+		if err := v.value(arg.val); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case def.Type == nil && f.selection != nil:
+		return fmt.Errorf("field \"%s.%s\" is a leaf and takes no selection", obj.Name, f.name)
+
+	case def.Type != nil && f.selection == nil:
+		return fmt.Errorf("field \"%s.%s\" of type %q must have a selection of subfields", obj.Name, f.name, def.Type.Name)
+
+	case def.Type != nil:
+		return v.selectionSet(def.Type, f.selection, depth+1)
+	}
+
+	return nil
+}
+
+func (v *validator) directives(dirs []directive) error {
+	for _, d := range dirs {
+		if d.name != "include" && d.name != "skip" {
+			return fmt.Errorf("unknown directive @%s", d.name)
+		}
+
+		if len(d.arguments) != 1 || d.arguments[0].name != "if" {
+			return fmt.Errorf("directive @%s requires only the \"if\" argument", d.name)
+		}
+
+		if err := v.value(d.arguments[0].val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *validator) value(val value) error {
+	switch val.kind {
+	case valueVariable:
+		if !v.defined[val.variable] {
+			return fmt.Errorf("variable $%s is not defined", val.variable)
+		}
+
+	case valueList:
+		for _, item := range val.list {
+			if err := v.value(item); err != nil {
+				return err
+			}
+		}
+
+	case valueObject:
+		for _, arg := range val.object {
+			if err := v.value(arg.val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchema() *Schema {
+	leaf := func(ctx context.Context, p Params) (any, error) { return "v", nil }
+
+	item := Object{
+		Name: "Item",
+		Fields: map[string]*Field{
+			"a": {Resolve: leaf},
+			"b": {Resolve: leaf},
+		},
+	}
+
+	query := Object{
+		Name: "Query",
+		Fields: map[string]*Field{
+			"x": {Resolve: leaf},
+			"item": {
+				Type:    &item,
+				Resolve: func(ctx context.Context, p Params) (any, error) { return struct{}{}, nil },
+			},
+		},
+	}
+
+	return &Schema{
+		Query:     &query,
+		MaxDepth:  5,
+		MaxNodes:  100,
+		MaxFields: 10,
+	}
+}
+
+// fanOut returns a query where every fragment spreads the next one twice,
+// so the selection doubles at each level.
+func fanOut(levels int) string {
+	var b strings.Builder
+	b.WriteString("{ ...F0 }\n")
+	for i := range levels {
+		fmt.Fprintf(&b, "fragment F%d on Query { ...F%d ...F%d }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment F%d on Query { x }\n", levels)
+
+	return b.String()
+}
+
+// wide returns a query selecting the item n times, each with m aliased
+// fields.
+func wide(n, m int) string {
+	var b strings.Builder
+	b.WriteString("{ ")
+	for i := range n {
+		fmt.Fprintf(&b, "i%d: item { ", i)
+		for j := range m {
+			fmt.Fprintf(&b, "a%d: a ", j)
+		}
+		b.WriteString("} ")
+	}
+	b.WriteString("}")
+
+	return b.String()
+}
+
+func TestValidateLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{
+			name:  "within limits",
+			query: "{ x item { a b } }",
+		},
+		{
+			name:  "fragments within the budget",
+			query: fanOut(5),
+		},
+		{
+			name:  "fragments fan out",
+			query: fanOut(40),
+			err:   "maximum of 100 fields",
+		},
+		{
+			name:  "wide selection set",
+			query: "{ " + strings.Repeat("x ", 11) + "}",
+			err:   "maximum of 10 fields",
+		},
+		{
+			name:  "fields across selection sets",
+			query: wide(10, 10),
+			err:   "maximum of 100 fields",
+		},
+		{
+			name:  "fields across selection sets within the budget",
+			query: wide(9, 10),
+		},
+	}
+
+	schema := testSchema()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			resp := schema.Execute(context.Background(), Request{Query: tt.query})
+
+			// Sem o orçamento, 40 níveis de fragmentos não terminam.
+			if d := time.Since(start); d > time.Second {
+				t.Fatalf("query took %s", d)
+			}
+
+			switch {
+			case tt.err == "" && len(resp.Errors) > 0:
+				t.Fatalf("unexpected errors: %v", resp.Errors)
+
+			case tt.err != "" && (len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, tt.err)):
+				t.Fatalf("got errors %v, want %q", resp.Errors, tt.err)
+			}
+		})
+	}
+}
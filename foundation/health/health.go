@@ -0,0 +1,189 @@
+// Package health provides a registry of dependency checks used to report
+// whether the service is ready to receive traffic.
+package health
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Set of statuses reported by the checks and the registry.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+	StatusDraining = "draining"
+)
+
+// DefaultTimeout bounds a check registered without a timeout.
+const DefaultTimeout = time.Second
+
+// CheckFunc reports the dependency is not usable by returning an error.
+type CheckFunc func(ctx context.Context) error
+
+// Check represents a dependency the service relies on. An optional check
+// that fails degrades the service without taking it out of rotation.
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	Optional bool
+	Func     CheckFunc
+}
+
+// Result represents the outcome of a single check.
+type Result struct {
+	Name     string
+	Status   string
+	Optional bool
+	Error    string
+	Duration time.Duration
+}
+
+// Report represents the outcome of every check in the registry.
+type Report struct {
+	Status string
+	Checks []Result
+}
+
+// Ready reports whether the service can receive traffic.
+func (r Report) Ready() bool {
+	return r.Status == StatusUp || r.Status == StatusDegraded
+}
+
+// Registry maintains the dependency checks of the service.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	draining atomic.Bool
+}
+
+// New constructs an empty registry.
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check to the registry.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check)
+}
+
+// Drain marks the service as shutting down. From now on the report is
+// failing so the load balancers stop sending new traffic.
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain was called.
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Run executes the checks concurrently, each bounded by its own timeout,
+// and reports the combined status.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+
+	for i, check := range checks {
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: results,
+	}
+
+	for _, res := range results {
+		if res.Status == StatusUp {
+			continue
+		}
+
+		if !res.Optional {
+			report.Status = StatusDown
+			break
+		}
+
+		report.Status = StatusDegraded
+	}
+
+	// Durante o desligamento as dependências continuam saudáveis, mas a
+	// instância não deve mais receber tráfego.
+	if r.Draining() {
+		report.Status = StatusDraining
+	}
+
+	return report
+}
+
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	res := Result{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Optional: check.Optional,
+	}
+
+	start := time.Now()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Func(ctx)
+	}()
+
+	// Nem toda dependência respeita o contexto, então o timeout também é
+	// aplicado aqui.
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// Dial returns a check that succeeds when a TCP connection to the address
+// can be opened. Useful for dependencies without a health endpoint.
+func Dial(addr string) CheckFunc {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
@@ -4,6 +4,7 @@ package keystore
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
@@ -57,7 +58,7 @@ func (ks *KeyStore) LoadByJSON(document string) (int, error) {
 		return len(ks.store), fmt.Errorf("unable to marshal document: %w", err)
 	}
 
-	publicPEM, err := toPublicPEM(d.PEM)
+	publicPEM, err := PublicPEM(d.PEM)
 	if err != nil {
 		return 0, fmt.Errorf("converting private PEM to public: %w", err)
 	}
@@ -108,7 +109,7 @@ func (ks *KeyStore) LoadByFileSystem(fsys fs.FS) (int, error) {
 		}
 
 		privatePEM := string(pem)
-		publicPEM, err := toPublicPEM(privatePEM)
+		publicPEM, err := PublicPEM(privatePEM)
 		if err != nil {
 			return fmt.Errorf("converting private PEM to public: %w", err)
 		}
@@ -160,7 +161,9 @@ func (ks *KeyStore) PublicKey(kid string) (string, error) {
 	return key.publicPEM, nil
 }
 
-func toPublicPEM(privatePEM string) (string, error) {
+// PublicPEM returns the public key, PEM encoded, of a PKCS1 or PKCS8 RSA
+// private key.
+func PublicPEM(privatePEM string) (string, error) {
 	block, _ := pem.Decode([]byte(privatePEM))
 	if block == nil {
 		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1 or PKCS8 key")
@@ -197,3 +200,24 @@ func toPublicPEM(privatePEM string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// GeneratePEM generates a new 2048 bits RSA private key, PEM encoded in the
+// PKCS1 format read by the keystore.
+func GeneratePEM() (string, error) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", fmt.Errorf("generating key: %w", err)
+	}
+
+	block := pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(pk),
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &block); err != nil {
+		return "", fmt.Errorf("encoding to private PEM: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,238 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxMessageSize bounds a message read from the server, so a broken or
+// hostile server cannot make the client allocate without limit.
+const maxMessageSize = 8 << 20
+
+// Set of BER identifiers used by the protocol. Only low tag numbers are
+// needed, so the identifier always fits in one byte.
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+)
+
+// element is a decoded BER element. Primitive elements carry the content,
+// constructed elements the children.
+type element struct {
+	tag      byte
+	content  []byte
+	children []element
+}
+
+func (e element) constructed() bool {
+	return e.tag&constructed != 0
+}
+
+// str returns the content of a primitive element as a string.
+func (e element) str() string {
+	return string(e.content)
+}
+
+// int returns the content of an INTEGER or ENUMERATED element.
+func (e element) int() (int64, error) {
+	if len(e.content) == 0 || len(e.content) > 8 {
+		return 0, fmt.Errorf("malformed integer of %d bytes", len(e.content))
+	}
+
+	// O primeiro byte carrega o sinal em complemento de dois.
+	n := int64(int8(e.content[0]))
+	for _, b := range e.content[1:] {
+		n = n<<8 | int64(b)
+	}
+
+	return n, nil
+}
+
+// =============================================================================
+
+// encode returns the element with the tag and the content, with the length
+// in the definite form the protocol requires.
+func encode(tag byte, content []byte) []byte {
+	n := len(content)
+
+	var length []byte
+	switch {
+	case n < 0x80:
+		length = []byte{byte(n)}
+	case n <= 0xff:
+		length = []byte{0x81, byte(n)}
+	case n <= 0xffff:
+		length = []byte{0x82, byte(n >> 8), byte(n)}
+	default:
+		length = []byte{0x84, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	b := make([]byte, 0, 1+len(length)+n)
+	b = append(b, tag)
+	b = append(b, length...)
+	b = append(b, content...)
+
+	return b
+}
+
+func encodeConstructed(tag byte, children ...[]byte) []byte {
+	var content []byte
+	for _, c := range children {
+		content = append(content, c...)
+	}
+
+	return encode(tag, content)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+func encodeInt(tag byte, n int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+
+		// Para quando o restante é só extensão do sinal do último byte.
+		if (n == 0 && b[0]&0x80 == 0) || (n == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+
+	return encode(tag, b)
+}
+
+func encodeBool(tag byte, v bool) []byte {
+	if v {
+		return encode(tag, []byte{0xff})
+	}
+
+	return encode(tag, []byte{0x00})
+}
+
+// =============================================================================
+
+// readElement reads one complete element from the connection.
+func readElement(r *bufio.Reader) (element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+
+	n, err := readLength(r)
+	if err != nil {
+		return element{}, err
+	}
+
+	if n > maxMessageSize {
+		return element{}, fmt.Errorf("message of %d bytes exceeds the limit", n)
+	}
+
+	content := make([]byte, n)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return element{}, err
+	}
+
+	return decodeContent(tag, content)
+}
+
+func readLength(r *bufio.Reader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if b < 0x80 {
+		return int(b), nil
+	}
+
+	size := int(b & 0x7f)
+	if size == 0 {
+		return 0, errors.New("indefinite length is not allowed")
+	}
+	if size > 4 {
+		return 0, fmt.Errorf("length of %d bytes is not supported", size)
+	}
+
+	var n int
+	for range size {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | int(b)
+	}
+
+	return n, nil
+}
+
+// decode parses every element in the data.
+func decode(data []byte) ([]element, error) {
+	var elements []element
+
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("truncated element")
+		}
+
+		tag := data[0]
+		n, size := int(data[1]), 1
+
+		if n >= 0x80 {
+			size = 1 + n&0x7f
+			if size == 1 || size > 5 || len(data) < 1+size {
+				return nil, errors.New("malformed length")
+			}
+
+			n = 0
+			for _, b := range data[2 : 1+size] {
+				n = n<<8 | int(b)
+			}
+		}
+
+		start := 1 + size
+		if n < 0 || len(data)-start < n {
+			return nil, errors.New("truncated element")
+		}
+
+		e, err := decodeContent(tag, data[start:start+n])
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, e)
+		data = data[start+n:]
+	}
+
+	return elements, nil
+}
+
+func decodeContent(tag byte, content []byte) (element, error) {
+	e := element{
+		tag:     tag,
+		content: content,
+	}
+
+	if e.constructed() {
+		children, err := decode(content)
+		if err != nil {
+			return element{}, err
+		}
+
+		e.children = children
+		e.content = nil
+	}
+
+	return e, nil
+}
@@ -0,0 +1,195 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestEncodeInt(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int64
+		want string
+	}{
+		{name: "zero", n: 0, want: "020100"},
+		{name: "maxOneByte", n: 127, want: "02017f"},
+		{name: "signPadding", n: 128, want: "02020080"},
+		{name: "twoBytes", n: 256, want: "02020100"},
+		{name: "minusOne", n: -1, want: "0201ff"},
+		{name: "minOneByte", n: -128, want: "020180"},
+		{name: "negativeTwoBytes", n: -129, want: "0202ff7f"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeInt(tagInteger, tt.n)
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("got %x, want %s", got, tt.want)
+			}
+
+			// O valor lido de volta é o mesmo que foi escrito.
+			es, err := decode(got)
+			if err != nil {
+				t.Fatalf("decode: %s", err)
+			}
+			if n, err := es[0].int(); err != nil || n != tt.n {
+				t.Errorf("got %d %v, want %d", n, err, tt.n)
+			}
+		})
+	}
+}
+
+func TestEncodeLength(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		want string
+	}{
+		{name: "short", size: 127, want: "047f"},
+		{name: "oneByte", size: 128, want: "048180"},
+		{name: "twoBytes", size: 256, want: "04820100"},
+		{name: "fourBytes", size: 1 << 16, want: "048400010000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := bytes.Repeat([]byte{'a'}, tt.size)
+
+			got := encode(tagOctetString, content)
+			if head := hex.EncodeToString(got[:len(got)-tt.size]); head != tt.want {
+				t.Fatalf("got header %s, want %s", head, tt.want)
+			}
+
+			e, err := readElement(bufio.NewReader(bytes.NewReader(got)))
+			if err != nil {
+				t.Fatalf("read: %s", err)
+			}
+			if len(e.content) != tt.size {
+				t.Errorf("got %d bytes, want %d", len(e.content), tt.size)
+			}
+		})
+	}
+}
+
+func TestReadElement(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    element
+		wantErr bool
+	}{
+		{
+			// BindResponse de sucesso, como enviado pelo OpenLDAP.
+			name: "bindResponse",
+			data: "300c02010161070a010004000400",
+			want: element{tag: tagSequence, children: []element{
+				{tag: tagInteger, content: []byte{1}},
+				{tag: 0x61, children: []element{
+					{tag: tagEnumerated, content: []byte{0}},
+					{tag: tagOctetString, content: []byte{}},
+					{tag: tagOctetString, content: []byte{}},
+				}},
+			}},
+		},
+		{name: "indefiniteLength", data: "3080020101", wantErr: true},
+		{name: "lengthTooLong", data: "04850000000001", wantErr: true},
+		{name: "overLimit", data: "04840fffffff", wantErr: true},
+		{name: "truncatedContent", data: "040561", wantErr: true},
+		{name: "truncatedChild", data: "3003040561", wantErr: true},
+		{name: "malformedChildLength", data: "30020480", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("fixture: %s", err)
+			}
+
+			got, err := readElement(bufio.NewReader(bytes.NewReader(data)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && !equal(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElementInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int64
+		wantErr bool
+	}{
+		{name: "positive", content: "0100", want: 256},
+		{name: "negative", content: "ff7f", want: -129},
+		{name: "empty", content: "", wantErr: true},
+		{name: "tooLong", content: "010203040506070809", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, _ := hex.DecodeString(tt.content)
+
+			got, err := element{tag: tagInteger, content: content}.int()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    string
+		wantErr error
+	}{
+		{name: "equality", filter: "(uid=ana)", want: "a30a0403756964" + "0403616e61"},
+		{name: "noParens", filter: "uid=ana", want: "a30a0403756964" + "0403616e61"},
+		{name: "present", filter: "(mail=*)", want: "87046d61696c"},
+		{name: "substrings", filter: "(cn=a*b)", want: "a40c0402636e" + "3006800161820162"},
+		{name: "escaped", filter: `(cn=a\2a)`, want: "a3080402636e" + "0402612a"},
+		{name: "andNot", filter: "(&(a=1)(!(b=2)))", want: "a012" + "a306040161040131" + "a208a306040162040132"},
+		{name: "unclosed", filter: "(cn=a", wantErr: ErrInvalidFilter},
+		{name: "trailing", filter: "(cn=a))", wantErr: ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compileFilter(tt.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("got %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func equal(a element, b element) bool {
+	if a.tag != b.tag || !bytes.Equal(a.content, b.content) || len(a.children) != len(b.children) {
+		return false
+	}
+
+	for i := range a.children {
+		if !equal(a.children[i], b.children[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,251 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFilter is returned when a search filter does not follow the
+// string representation of RFC 4515.
+var ErrInvalidFilter = errors.New("ldap: invalid filter")
+
+// Set of filter choices of the SearchRequest.
+const (
+	filterAnd          = classContext | constructed | 0
+	filterOr           = classContext | constructed | 1
+	filterNot          = classContext | constructed | 2
+	filterEquality     = classContext | constructed | 3
+	filterSubstrings   = classContext | constructed | 4
+	filterGreaterEqual = classContext | constructed | 5
+	filterLessEqual    = classContext | constructed | 6
+	filterPresent      = classContext | 7
+	filterApprox       = classContext | constructed | 8
+
+	substringInitial = classContext | 0
+	substringAny     = classContext | 1
+	substringFinal   = classContext | 2
+)
+
+// EscapeFilter escapes the characters with a meaning in a filter, so a value
+// given by a user can only ever match itself.
+func EscapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// CheckFilter returns an error if the filter cannot be sent in a search.
+func CheckFilter(filter string) error {
+	_, err := compileFilter(filter)
+	return err
+}
+
+// compileFilter encodes the filter, e.g. "(&(objectClass=user)(mail=a@b.c))".
+// Extensible matches are not supported.
+func compileFilter(filter string) ([]byte, error) {
+	filter = strings.TrimSpace(filter)
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+
+	enc, rest, err := parseFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err)
+	}
+
+	if rest != "" {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrInvalidFilter, rest)
+	}
+
+	return enc, nil
+}
+
+// parseFilter encodes the filter at the start of s and returns what is left.
+func parseFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", errors.New("missing (")
+	}
+	s = s[1:]
+
+	if s == "" {
+		return nil, "", errors.New("missing )")
+	}
+
+	switch s[0] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+
+		var children [][]byte
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			child, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			children = append(children, child)
+			s = rest
+		}
+
+		if len(children) == 0 {
+			return nil, "", errors.New("empty set")
+		}
+
+		rest, err := closeFilter(s)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return encodeConstructed(tag, children...), rest, nil
+
+	case '!':
+		child, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, "", err
+		}
+
+		rest, err = closeFilter(rest)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return encodeConstructed(filterNot, child), rest, nil
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", errors.New("missing )")
+	}
+
+	enc, err := parseItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return enc, s[end+1:], nil
+}
+
+func closeFilter(s string) (string, error) {
+	if !strings.HasPrefix(s, ")") {
+		return "", errors.New("missing )")
+	}
+
+	return s[1:], nil
+}
+
+// parseItem encodes a simple item, e.g. "mail=a@b.c", "cn=jo*" or "uid=*".
+func parseItem(item string) ([]byte, error) {
+	i := strings.IndexByte(item, '=')
+	if i <= 0 {
+		return nil, fmt.Errorf("malformed item %q", item)
+	}
+
+	attr, value := item[:i], item[i+1:]
+
+	tag := byte(filterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = filterGreaterEqual
+	case '<':
+		tag = filterLessEqual
+	case '~':
+		tag = filterApprox
+	case ':':
+		return nil, fmt.Errorf("extensible match is not supported in %q", item)
+	}
+
+	if tag != filterEquality {
+		attr = attr[:len(attr)-1]
+	}
+
+	if attr == "" || strings.ContainsAny(attr, "()*\\") {
+		return nil, fmt.Errorf("malformed attribute in %q", item)
+	}
+
+	if tag == filterEquality && value == "*" {
+		return encodeString(filterPresent, attr), nil
+	}
+
+	if tag == filterEquality && strings.Contains(value, "*") {
+		return parseSubstrings(attr, value)
+	}
+
+	v, err := unescape(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeConstructed(tag, encodeString(tagOctetString, attr), encodeString(tagOctetString, v)), nil
+}
+
+func parseSubstrings(attr string, value string) ([]byte, error) {
+	parts := strings.Split(value, "*")
+
+	var subs [][]byte
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+
+		v, err := unescape(p)
+		if err != nil {
+			return nil, err
+		}
+
+		tag := byte(substringAny)
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(parts) - 1:
+			tag = substringFinal
+		}
+
+		subs = append(subs, encodeString(tag, v))
+	}
+
+	return encodeConstructed(filterSubstrings,
+		encodeString(tagOctetString, attr),
+		encodeConstructed(tagSequence, subs...),
+	), nil
+}
+
+// unescape decodes the \XX escapes of a value.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+3 > len(s) {
+			return "", fmt.Errorf("truncated escape in %q", s)
+		}
+
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("malformed escape in %q", s)
+		}
+
+		b.Write(c)
+		i += 2
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,432 @@
+// Package ldap provides a small LDAPv3 client. It covers what the system needs
+// to authenticate users against a directory: simple bind, StartTLS and
+// search.
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCredentials is returned when the server rejects a bind.
+var ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+
+// Set of result codes of the protocol the client looks at.
+const (
+	ResultSuccess            = 0
+	ResultSizeLimitExceeded  = 4
+	ResultNoSuchObject       = 32
+	ResultInvalidCredentials = 49
+)
+
+// Set of search scopes.
+const (
+	ScopeBaseObject   = 0
+	ScopeSingleLevel  = 1
+	ScopeWholeSubtree = 2
+)
+
+// Error is a result other than success sent by the server. The connection
+// stays usable after it.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.Code)
+	}
+
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Config represents the settings of a connection.
+type Config struct {
+
+	// URL is the address of the server, ldap://host[:389] or
+	// ldaps://host[:636].
+	URL string
+
+	// StartTLS upgrades an ldap:// connection to TLS before anything is
+	// sent. It is ignored for ldaps://.
+	StartTLS bool
+
+	// TLS is used for ldaps:// and StartTLS. The server name defaults to
+	// the host of the URL.
+	TLS *tls.Config
+
+	// Timeout bounds every operation without a deadline in the context.
+	// Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Entry is an object returned by a search.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Get returns the first value of the attribute, matched without regard to
+// case as the protocol does.
+func (e Entry) Get(attr string) string {
+	if vs := e.Values(attr); len(vs) > 0 {
+		return vs[0]
+	}
+
+	return ""
+}
+
+// Values returns every value of the attribute.
+func (e Entry) Values(attr string) []string {
+	for name, vs := range e.Attributes {
+		if strings.EqualFold(name, attr) {
+			return vs
+		}
+	}
+
+	return nil
+}
+
+// SearchRequest represents a search. SizeLimit bounds the entries returned
+// by the server, zero leaves it to the server.
+type SearchRequest struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+	SizeLimit  int
+}
+
+// =============================================================================
+
+// Conn is a connection to a server. Operations are sent one at a time.
+type Conn struct {
+	mu      sync.Mutex
+	nc      net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	msgID   int64
+}
+
+// Dial connects to the server of the config.
+func Dial(ctx context.Context, cfg Config) (*Conn, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: parse url: %w", err)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("ldap: url %q has no host", cfg.URL)
+	}
+
+	port := u.Port()
+
+	var secure bool
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+	case "ldaps":
+		secure = true
+		if port == "" {
+			port = "636"
+		}
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", u.Scheme)
+	}
+
+	tlsCfg := &tls.Config{}
+	if cfg.TLS != nil {
+		tlsCfg = cfg.TLS.Clone()
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName = host
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	nc, err := d.DialContext(dialCtx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: dial: %w", err)
+	}
+
+	if secure {
+		nc, err = handshake(dialCtx, nc, tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := Conn{
+		nc:      nc,
+		r:       bufio.NewReader(nc),
+		timeout: cfg.Timeout,
+	}
+
+	if !secure && cfg.StartTLS {
+		if err := c.startTLS(ctx, tlsCfg); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+
+	return &c, nil
+}
+
+// Close sends the unbind request and closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nc.SetDeadline(time.Now().Add(c.timeout))
+	c.send(encode(classApplication|2, nil))
+
+	return c.nc.Close()
+}
+
+// Bind authenticates the connection with the DN and the password. An empty
+// password is rejected before reaching the server, which would otherwise
+// take it as an anonymous bind and succeed.
+func (c *Conn) Bind(ctx context.Context, dn string, password string) error {
+	if password == "" {
+		return ErrInvalidCredentials
+	}
+
+	req := encodeConstructed(classApplication|constructed|0,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(classContext|0, password),
+	)
+
+	resp, err := c.do(ctx, req, classApplication|constructed|1)
+	if err != nil {
+		return err
+	}
+
+	if err := result(resp[len(resp)-1]); err != nil {
+		var ldapErr *Error
+		if errors.As(err, &ldapErr) && ldapErr.Code == ResultInvalidCredentials {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Search returns the entries matching the request. References to other
+// servers are ignored.
+func (c *Conn) Search(ctx context.Context, sr SearchRequest) ([]Entry, error) {
+	filter, err := compileFilter(sr.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make([][]byte, len(sr.Attributes))
+	for i, a := range sr.Attributes {
+		attrs[i] = encodeString(tagOctetString, a)
+	}
+
+	req := encodeConstructed(classApplication|constructed|3,
+		encodeString(tagOctetString, sr.BaseDN),
+		encodeInt(tagEnumerated, int64(sr.Scope)),
+		encodeInt(tagEnumerated, 0),
+		encodeInt(tagInteger, int64(sr.SizeLimit)),
+		encodeInt(tagInteger, 0),
+		encodeBool(tagBoolean, false),
+		filter,
+		encodeConstructed(tagSequence, attrs...),
+	)
+
+	resp, err := c.do(ctx, req, classApplication|constructed|5)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, op := range resp[:len(resp)-1] {
+		if op.tag != classApplication|constructed|4 {
+			continue
+		}
+
+		e, err := toEntry(op)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := result(resp[len(resp)-1]); err != nil {
+		return entries, err
+	}
+
+	return entries, nil
+}
+
+// =============================================================================
+
+func (c *Conn) startTLS(ctx context.Context, tlsCfg *tls.Config) error {
+	const oid = "1.3.6.1.4.1.1466.20037"
+
+	req := encodeConstructed(classApplication|constructed|23,
+		encodeString(classContext|0, oid),
+	)
+
+	resp, err := c.do(ctx, req, classApplication|constructed|24)
+	if err != nil {
+		return fmt.Errorf("ldap: starttls: %w", err)
+	}
+
+	if err := result(resp[len(resp)-1]); err != nil {
+		return fmt.Errorf("ldap: starttls: %w", err)
+	}
+
+	nc, err := handshake(ctx, c.nc, tlsCfg)
+	if err != nil {
+		return err
+	}
+
+	c.nc = nc
+	c.r = bufio.NewReader(nc)
+
+	return nil
+}
+
+func handshake(ctx context.Context, nc net.Conn, tlsCfg *tls.Config) (net.Conn, error) {
+	tc := tls.Client(nc, tlsCfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("ldap: tls handshake: %w", err)
+	}
+
+	return tc, nil
+}
+
+// do sends the request and reads the responses to it up to the one with the
+// final tag, which is the last returned.
+func (c *Conn) do(ctx context.Context, op []byte, final byte) ([]element, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.timeout)
+	}
+	c.nc.SetDeadline(deadline)
+
+	id, err := c.send(op)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: send: %w", err)
+	}
+
+	var ops []element
+	for {
+		msg, err := readElement(c.r)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: read: %w", err)
+		}
+
+		if msg.tag != tagSequence || len(msg.children) < 2 {
+			return nil, errors.New("ldap: malformed message")
+		}
+
+		msgID, err := msg.children[0].int()
+		if err != nil {
+			return nil, fmt.Errorf("ldap: message id: %w", err)
+		}
+
+		// Notificações não solicitadas chegam com id zero e não são de
+		// nenhuma operação.
+		if msgID != id {
+			continue
+		}
+
+		respOp := msg.children[1]
+		ops = append(ops, respOp)
+
+		if respOp.tag == final {
+			return ops, nil
+		}
+	}
+}
+
+func (c *Conn) send(op []byte) (int64, error) {
+	c.msgID++
+
+	msg := encodeConstructed(tagSequence,
+		encodeInt(tagInteger, c.msgID),
+		op,
+	)
+
+	if _, err := c.nc.Write(msg); err != nil {
+		return 0, err
+	}
+
+	return c.msgID, nil
+}
+
+// result returns the error of an LDAPResult with a code other than success.
+func result(op element) error {
+	if len(op.children) < 3 {
+		return errors.New("ldap: malformed result")
+	}
+
+	code, err := op.children[0].int()
+	if err != nil {
+		return fmt.Errorf("ldap: result code: %w", err)
+	}
+
+	if code == ResultSuccess {
+		return nil
+	}
+
+	return &Error{
+		Code:    int(code),
+		Message: op.children[2].str(),
+	}
+}
+
+func toEntry(op element) (Entry, error) {
+	if len(op.children) < 2 {
+		return Entry{}, errors.New("ldap: malformed entry")
+	}
+
+	e := Entry{
+		DN:         op.children[0].str(),
+		Attributes: make(map[string][]string, len(op.children[1].children)),
+	}
+
+	for _, attr := range op.children[1].children {
+		if len(attr.children) < 2 {
+			return Entry{}, errors.New("ldap: malformed attribute")
+		}
+
+		name := attr.children[0].str()
+
+		values := make([]string, len(attr.children[1].children))
+		for i, v := range attr.children[1].children {
+			values[i] = v.str()
+		}
+
+		e.Attributes[name] = values
+	}
+
+	return e, nil
+}
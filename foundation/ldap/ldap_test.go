@@ -0,0 +1,248 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The messages below are laid out as RFC 4511 puts them on the wire. The
+// requests are the exact bytes the client must send; searchNotice is an
+// unsolicited notification, sent with message ID zero.
+const (
+	bindRequest = "302c020101" + "6027020103" +
+		"041a" + "636e3d61646d696e2c64633d6578616d706c652c64633d6f7267" +
+		"8006" + "736563726574"
+	bindSuccess = "300c02010161070a010004000400"
+	bindInvalid = "300c02010161070a013104000400"
+
+	searchRequest = "3049020102" + "6344" +
+		"041b" + "6f753d70656f706c652c64633d6578616d706c652c64633d6f7267" +
+		"0a01020a0100020102020100010100" +
+		"a30a0403756964" + "0403616e61" +
+		"300a04046d61696c0402636e"
+	searchEntry = "3054020102" + "644f" +
+		"0423" + "7569643d616e612c6f753d70656f706c652c64633d6578616d706c652c64633d6f7267" +
+		"3028" +
+		"301904046d61696c" + "3111040f616e61406578616d706c652e6f7267" +
+		"300b0402636e" + "31050403416e61"
+	searchNotice  = "300c02010078070a010004000400"
+	searchDone    = "300c02010265070a010004000400"
+	searchTooMany = "301f020102" + "651a" + "0a0104" + "0400" + "0413" + "53697a65206c696d6974206578636565646564"
+
+	unbind2 = "30050201024200"
+	unbind3 = "30050201034200"
+)
+
+func TestConn(t *testing.T) {
+	ana := Entry{
+		DN:         "uid=ana,ou=people,dc=example,dc=org",
+		Attributes: map[string][]string{"mail": {"ana@example.org"}, "cn": {"Ana"}},
+	}
+
+	search := SearchRequest{
+		BaseDN:     "ou=people,dc=example,dc=org",
+		Scope:      ScopeWholeSubtree,
+		Filter:     "(uid=ana)",
+		Attributes: []string{"mail", "cn"},
+		SizeLimit:  2,
+	}
+
+	tests := []struct {
+		name      string
+		replies   []string
+		run       func(ctx context.Context, c *Conn) ([]Entry, error)
+		want      []Entry
+		wantErr   error
+		wantCode  int
+		wantFrame []string
+	}{
+		{
+			name:    "bind",
+			replies: []string{bindSuccess},
+			run: func(ctx context.Context, c *Conn) ([]Entry, error) {
+				return nil, c.Bind(ctx, "cn=admin,dc=example,dc=org", "secret")
+			},
+			wantFrame: []string{bindRequest, unbind2},
+		},
+		{
+			name:    "bindInvalid",
+			replies: []string{bindInvalid},
+			run: func(ctx context.Context, c *Conn) ([]Entry, error) {
+				return nil, c.Bind(ctx, "cn=admin,dc=example,dc=org", "secret")
+			},
+			wantErr:   ErrInvalidCredentials,
+			wantFrame: []string{bindRequest, unbind2},
+		},
+		{
+			name: "bindEmptyPassword",
+			run: func(ctx context.Context, c *Conn) ([]Entry, error) {
+				return nil, c.Bind(ctx, "cn=admin,dc=example,dc=org", "")
+			},
+			wantErr:   ErrInvalidCredentials,
+			wantFrame: []string{"30050201014200"},
+		},
+		{
+			name:    "search",
+			replies: []string{bindSuccess, searchEntry + searchNotice + searchDone},
+			run: func(ctx context.Context, c *Conn) ([]Entry, error) {
+				if err := c.Bind(ctx, "cn=admin,dc=example,dc=org", "secret"); err != nil {
+					return nil, err
+				}
+				return c.Search(ctx, search)
+			},
+			want:      []Entry{ana},
+			wantFrame: []string{bindRequest, searchRequest, unbind3},
+		},
+		{
+			name:    "searchSizeLimit",
+			replies: []string{bindSuccess, searchEntry + searchTooMany},
+			run: func(ctx context.Context, c *Conn) ([]Entry, error) {
+				if err := c.Bind(ctx, "cn=admin,dc=example,dc=org", "secret"); err != nil {
+					return nil, err
+				}
+				return c.Search(ctx, search)
+			},
+			want:      []Entry{ana},
+			wantCode:  ResultSizeLimitExceeded,
+			wantFrame: []string{bindRequest, searchRequest, unbind3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, tt.replies)
+
+			ctx := context.Background()
+
+			c, err := Dial(ctx, Config{URL: "ldap://" + srv.addr})
+			if err != nil {
+				t.Fatalf("dial: %s", err)
+			}
+
+			got, err := tt.run(ctx, c)
+			c.Close()
+
+			var ldapErr *Error
+			switch {
+			case tt.wantCode != 0:
+				if !errors.As(err, &ldapErr) || ldapErr.Code != tt.wantCode {
+					t.Fatalf("got error %v, want result code %d", err, tt.wantCode)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got entries %+v, want %+v", got, tt.want)
+			}
+
+			if frames := srv.frames(); strings.Join(frames, "|") != strings.Join(tt.wantFrame, "|") {
+				t.Errorf("got requests\n%s\nwant\n%s", strings.Join(frames, "\n"), strings.Join(tt.wantFrame, "\n"))
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// server answers each message it reads with the next recorded reply and
+// keeps the messages, in hex, until the client closes the connection.
+type server struct {
+	addr string
+	done chan struct{}
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newServer(t *testing.T, replies []string) *server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := server{
+		addr: ln.Addr().String(),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(srv.done)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for i := 0; ; i++ {
+			msg, err := readRaw(r)
+			if err != nil {
+				return
+			}
+
+			srv.mu.Lock()
+			srv.msgs = append(srv.msgs, hex.EncodeToString(msg))
+			srv.mu.Unlock()
+
+			if i < len(replies) {
+				reply, _ := hex.DecodeString(replies[i])
+				conn.Write(reply)
+			}
+		}
+	}()
+
+	return &srv
+}
+
+// frames waits for the connection to be closed and returns the messages
+// read from it.
+func (s *server) frames() []string {
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.msgs
+}
+
+// readRaw reads the bytes of one message as they were sent.
+func readRaw(r *bufio.Reader) ([]byte, error) {
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+
+	n := int(hdr[1])
+	if n >= 0x80 {
+		size := make([]byte, n&0x7f)
+		if _, err := io.ReadFull(r, size); err != nil {
+			return nil, err
+		}
+		hdr = append(hdr, size...)
+
+		n = 0
+		for _, b := range size {
+			n = n<<8 | int(b)
+		}
+	}
+
+	content := make([]byte, n)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+
+	return append(hdr, content...), nil
+}
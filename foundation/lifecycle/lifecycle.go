@@ -0,0 +1,159 @@
+// Package lifecycle coordinates the start and the shutdown of the long running
+// subsystems of a service, such as servers and background workers.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/logger"
+)
+
+// Service represents a subsystem managed by the Manager.
+type Service struct {
+	// Name identifies the service in the logs and errors.
+	Name string
+
+	// Run blocks until the service stops. It must return once its context
+	// is canceled or Stop is called.
+	Run func(ctx context.Context) error
+
+	// Stop asks the service to finish its work before the context deadline.
+	// When nil, the context given to Run is canceled instead.
+	Stop func(ctx context.Context) error
+}
+
+// abandonGrace is how long a service has to return after its context is
+// canceled before the shutdown deadline is enforced on it.
+const abandonGrace = 50 * time.Millisecond
+
+type service struct {
+	Service
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager starts the services in the order they were added and stops them in
+// the reverse order, so a service can rely on the ones added before it.
+type Manager struct {
+	log      *logger.Logger
+	services []*service
+	errors   chan error
+	stopping chan struct{}
+	once     sync.Once
+}
+
+// New constructs a manager without services.
+func New(log *logger.Logger) *Manager {
+	return &Manager{
+		log:      log,
+		errors:   make(chan error, 1),
+		stopping: make(chan struct{}),
+	}
+}
+
+// Add registers a service. Services must be added before Start.
+func (m *Manager) Add(svc Service) {
+	m.services = append(m.services, &service{
+		Service: svc,
+		done:    make(chan struct{}),
+	})
+}
+
+// Start runs every service in its own goroutine.
+func (m *Manager) Start(ctx context.Context) {
+	for _, svc := range m.services {
+		var runCtx context.Context
+		runCtx, svc.cancel = context.WithCancel(ctx)
+
+		go func() {
+			defer close(svc.done)
+
+			m.log.Info(ctx, "lifecycle", "status", "service started", "service", svc.Name)
+
+			err := svc.Run(runCtx)
+
+			select {
+			case <-m.stopping:
+				if err != nil {
+					m.log.Error(ctx, "lifecycle", "status", "service stopped with error", "service", svc.Name, "ERROR", err)
+				}
+				return
+			default:
+			}
+
+			// O serviço parou sozinho: isso derruba o processo inteiro.
+			if err == nil {
+				err = errors.New("stopped unexpectedly")
+			}
+
+			select {
+			case m.errors <- fmt.Errorf("%s: %w", svc.Name, err):
+			default:
+			}
+		}()
+	}
+}
+
+// Errors receives the first service that stops before Shutdown is called.
+func (m *Manager) Errors() <-chan error {
+	return m.errors
+}
+
+// Shutdown stops the services in the reverse order they were added. Each one
+// is given until the context deadline; the ones that miss it are abandoned
+// and reported in the returned error, which joins every failure.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		close(m.stopping)
+	})
+
+	var errs []error
+
+	for i := len(m.services) - 1; i >= 0; i-- {
+		svc := m.services[i]
+
+		if err := m.stop(ctx, svc); err != nil {
+			m.log.Error(ctx, "lifecycle", "status", "could not stop service gracefully", "service", svc.Name, "ERROR", err)
+			errs = append(errs, fmt.Errorf("%s: %w", svc.Name, err))
+			continue
+		}
+
+		m.log.Info(ctx, "lifecycle", "status", "service stopped", "service", svc.Name)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stop(ctx context.Context, svc *service) error {
+	if svc.cancel == nil {
+		return nil
+	}
+
+	var err error
+
+	if svc.Stop != nil {
+		err = svc.Stop(ctx)
+	}
+
+	svc.cancel()
+
+	// Com o prazo já vencido, um serviço que encerra ao ter o contexto
+	// cancelado ainda não deve ser dado como abandonado.
+	select {
+	case <-svc.done:
+		return err
+	case <-time.After(abandonGrace):
+	}
+
+	select {
+	case <-svc.done:
+	case <-ctx.Done():
+		return errors.Join(err, ctx.Err())
+	}
+
+	return err
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ContextFn returns the attributes found in the context to add to a record,
+// like the span or the user of a request.
+type ContextFn func(ctx context.Context) []slog.Attr
+
+// hooks holds what is added to the logger after it is constructed. The
+// pointer is shared by every handler derived with WithAttrs or WithGroup.
+type hooks struct {
+	mu        sync.RWMutex
+	fns       []ContextFn
+	exporters []slog.Handler
+}
+
+// AddContext adds the attributes returned by fn to every record logged with
+// a context.
+func (log *Logger) AddContext(fn ContextFn) {
+	log.hooks.mu.Lock()
+	defer log.hooks.mu.Unlock()
+
+	log.hooks.fns = append(log.hooks.fns, fn)
+}
+
+// Export sends every record to the handler as well, e.g. to ship the logs to
+// an OpenTelemetry collector. The handler sees the records allowed by the
+// levels of the logger.
+func (log *Logger) Export(h slog.Handler) {
+	log.hooks.mu.Lock()
+	defer log.hooks.mu.Unlock()
+
+	log.hooks.exporters = append(log.hooks.exporters, h)
+}
+
+// contextHandler wraps the handler adding the attributes of the context to
+// the records and passing them to the exporters.
+type contextHandler struct {
+	handler slog.Handler
+	hooks   *hooks
+}
+
+func newContextHandler(handler slog.Handler, hooks *hooks) *contextHandler {
+	return &contextHandler{
+		handler: handler,
+		hooks:   hooks,
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// WithAttrs returns a new handler whose attributes consists of h's attributes
+// followed by attrs.
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{handler: h.handler.WithAttrs(attrs), hooks: h.hooks}
+}
+
+// WithGroup returns a new handler with the given group appended to the
+// receiver's existing groups.
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{handler: h.handler.WithGroup(name), hooks: h.hooks}
+}
+
+// Handle adds the attributes of the context to the record and writes it.
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.hooks.mu.RLock()
+	fns := h.hooks.fns
+	exporters := h.hooks.exporters
+	h.hooks.mu.RUnlock()
+
+	if ctx != nil {
+		for _, fn := range fns {
+			r.AddAttrs(fn(ctx)...)
+		}
+	}
+
+	err := h.handler.Handle(ctx, r)
+
+	for _, exp := range exporters {
+		if exp.Enabled(ctx, r.Level) {
+			err = errors.Join(err, exp.Handle(ctx, r.Clone()))
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	return slog.Level(l).String()
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (l *Level) UnmarshalText(data []byte) error {
+	var sl slog.Level
+	if err := sl.UnmarshalText(data); err != nil {
+		return fmt.Errorf("unknown log level %q", string(data))
+	}
+
+	*l = Level(sl)
+
+	return nil
+}
+
+// ParseLevel converts a level name like DEBUG or INFO into a Level.
+func ParseLevel(s string) (Level, error) {
+	var l Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+
+	return l, nil
+}
+
+// Levels is a snapshot of the minimum levels the logger is using.
+type Levels struct {
+	Level   Level            `json:"level"`
+	Modules map[string]Level `json:"modules"`
+}
+
+// levels holds the global minimum level and the per module overrides. A
+// module is any part of a package path, e.g. "business/domain/aclbus", and
+// the longest configured module matching the caller wins.
+type levels struct {
+	global  slog.LevelVar
+	mu      sync.RWMutex
+	modules map[string]Level
+}
+
+func newLevels(minLevel Level) *levels {
+	lv := levels{
+		modules: make(map[string]Level),
+	}
+	lv.global.Set(slog.Level(minLevel))
+
+	return &lv
+}
+
+// Level implements the slog.Leveler interface. The handler must let through
+// the lowest configured level so module overrides below the global level
+// still reach the module check in write.
+func (lv *levels) Level() slog.Level {
+	minLevel := lv.global.Level()
+
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	for _, l := range lv.modules {
+		if slog.Level(l) < minLevel {
+			minLevel = slog.Level(l)
+		}
+	}
+
+	return minLevel
+}
+
+// enabled reports if a record at level logged from pc must be written.
+func (lv *levels) enabled(level Level, pc uintptr) bool {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	if len(lv.modules) == 0 {
+		return slog.Level(level) >= lv.global.Level()
+	}
+
+	minLevel := Level(lv.global.Level())
+
+	// CallersFrames resolves inlined calls, which FuncForPC does not.
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.Function != "" {
+		pkg := packagePath(frame.Function)
+
+		var match string
+		for module, l := range lv.modules {
+			if len(module) > len(match) && strings.Contains(pkg, module) {
+				match = module
+				minLevel = l
+			}
+		}
+	}
+
+	return level >= minLevel
+}
+
+// packagePath strips the receiver and function name from a fully qualified
+// function name.
+func packagePath(funcName string) string {
+	slash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[slash+1:], "."); dot >= 0 {
+		return funcName[:slash+1+dot]
+	}
+
+	return funcName
+}
+
+// =============================================================================
+
+// Levels returns the levels currently in use.
+func (log *Logger) Levels() Levels {
+	lv := Levels{
+		Level:   Level(log.levels.global.Level()),
+		Modules: make(map[string]Level),
+	}
+
+	log.levels.mu.RLock()
+	defer log.levels.mu.RUnlock()
+
+	for module, l := range log.levels.modules {
+		lv.Modules[module] = l
+	}
+
+	return lv
+}
+
+// SetLevel changes the global minimum level at runtime.
+func (log *Logger) SetLevel(level Level) {
+	log.levels.global.Set(slog.Level(level))
+}
+
+// SetModuleLevel changes the minimum level for the packages matching module.
+func (log *Logger) SetModuleLevel(module string, level Level) {
+	log.levels.mu.Lock()
+	defer log.levels.mu.Unlock()
+
+	log.levels.modules[module] = level
+}
+
+// ResetModuleLevel removes the override for module so the global level
+// applies again.
+func (log *Logger) ResetModuleLevel(module string) {
+	log.levels.mu.Lock()
+	defer log.levels.mu.Unlock()
+
+	delete(log.levels.modules, module)
+}
+
+// ConfigureModules applies a list of overrides in the form
+// "module=LEVEL,module=LEVEL", as used in environment configuration.
+func (log *Logger) ConfigureModules(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		module, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid module level %q: expected module=LEVEL", entry)
+		}
+
+		level, err := ParseLevel(strings.TrimSpace(name))
+		if err != nil {
+			return fmt.Errorf("module %q: %w", module, err)
+		}
+
+		log.SetModuleLevel(strings.TrimSpace(module), level)
+	}
+
+	return nil
+}
@@ -21,6 +21,8 @@ type Logger struct {
 	discard   bool
 	handler   slog.Handler
 	traceIDFn TraceIDFn
+	levels    *levels
+	hooks     *hooks
 }
 
 // New constructs a new log for application use.
@@ -36,7 +38,9 @@ func NewWithEvents(w io.Writer, minLevel Level, serviceName string, traceIDFn Tr
 // NewWithHandler returns a new log for application use with the underlying
 // handler.
 func NewWithHandler(h slog.Handler) *Logger {
-	return &Logger{handler: h}
+	hks := hooks{}
+
+	return &Logger{handler: newContextHandler(h, &hks), levels: newLevels(LevelDebug), hooks: &hks}
 }
 
 // NewStdLogger returns a standard library Logger that wraps the slog Logger.
@@ -126,6 +130,10 @@ func (log *Logger) write(ctx context.Context, level Level, caller int, msg strin
 	var pcs [1]uintptr
 	runtime.Callers(caller, pcs[:])
 
+	if !log.levels.enabled(level, pcs[0]) {
+		return
+	}
+
 	r := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
 
 	if log.traceIDFn != nil {
@@ -151,8 +159,12 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		return a
 	}
 
+	// The levels can be changed at runtime, so the handler reads them on
+	// every call instead of a fixed value.
+	lv := newLevels(minLevel)
+
 	// Construct the slog JSON handler for use.
-	handler := slog.Handler(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: slog.Level(minLevel), ReplaceAttr: f}))
+	handler := slog.Handler(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: lv, ReplaceAttr: f}))
 
 	// If events are to be processed, wrap the JSON handler around the custom
 	// log handler.
@@ -160,6 +172,11 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		handler = newLogHandler(handler, events)
 	}
 
+	// The attributes of the context and the exporters are added after the
+	// logger is constructed, once the configuration is known.
+	hks := hooks{}
+	handler = newContextHandler(handler, &hks)
+
 	// Attributes to add to every log.
 	attrs := []slog.Attr{
 		{Key: "service", Value: slog.StringValue(serviceName)},
@@ -172,5 +189,7 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		discard:   w == io.Discard,
 		handler:   handler,
 		traceIDFn: traceIDFn,
+		levels:    lv,
+		hooks:     &hks,
 	}
 }
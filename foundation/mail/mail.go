@@ -0,0 +1,76 @@
+// Package mail sends plain text messages through an SMTP server, like the
+// confirmation links sent to the users.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Config represents the SMTP server and the sender address.
+type Config struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Message is a plain text message to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// SMTP sends messages through an SMTP server.
+type SMTP struct {
+	cfg Config
+}
+
+// NewSMTP constructs a sender through the SMTP server at cfg.Addr, e.g.
+// "smtp.example.com:587". PLAIN authentication is used when cfg.Username is
+// set.
+func NewSMTP(cfg Config) *SMTP {
+	return &SMTP{
+		cfg: cfg,
+	}
+}
+
+// Send delivers the message.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", msg.To)
+	}
+
+	var body bytes.Buffer
+
+	fmt.Fprintf(&body, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(s.cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("smtp addr: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+
+	// SendMail não aceita contexto; o prazo do envio fica sem efeito aqui.
+	if err := smtp.SendMail(s.cfg.Addr, auth, s.cfg.From, []string{msg.To}, body.Bytes()); err != nil {
+		return fmt.Errorf("sendmail: %w", err)
+	}
+
+	return nil
+}
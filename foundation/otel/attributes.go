@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Set of attributes added to the span of the request so the traces can be
+// filtered per client, e.g. { span.tenant_id = "..." } in Tempo.
+const (
+	TenantIDKey    = attribute.Key("tenant_id")
+	DashboardIDKey = attribute.Key("dashboard_id")
+	UserIDKey      = attribute.Key("user_id")
+	UserRoleKey    = attribute.Key("user.role")
+	RouteKey       = attribute.Key("http.route")
+)
+
+// ErrorEventName is the name of the event added for the errors of a request.
+const ErrorEventName = "business.error"
+
+// SetAttributes adds the attributes to the span in the context.
+func SetAttributes(ctx context.Context, keyValues ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(keyValues...)
+}
+
+// SetRoute adds the route template, e.g. "GET /v1/dashboards/{dashboard_id}",
+// to the span in the context instead of the path requested.
+func SetRoute(ctx context.Context, route string) {
+	if route == "" {
+		return
+	}
+
+	SetAttributes(ctx, RouteKey.String(route))
+}
+
+// AddErrorEvent records the error as an event of the span in the context.
+// Errors expected by the business, like a validation failure, leave the span
+// status alone; failed marks the span as failed.
+func AddErrorEvent(ctx context.Context, err error, failed bool, keyValues ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := append([]attribute.KeyValue{attribute.String("error.message", err.Error())}, keyValues...)
+	span.AddEvent(ErrorEventName, trace.WithAttributes(attrs...))
+
+	if failed {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,330 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// LogAttrs returns the span id of the context so a log record can be matched
+// to the span it was written in. It is meant for logger.AddContext.
+func LogAttrs(ctx context.Context) []slog.Attr {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasSpanID() {
+		return nil
+	}
+
+	return []slog.Attr{slog.String("span_id", sc.SpanID().String())}
+}
+
+// LogConfig defines the information needed to export logs.
+type LogConfig struct {
+	ServiceName string
+	Host        string
+
+	// BatchSize is the most records sent at once and Interval the longest a
+	// record waits to be sent.
+	BatchSize int
+	Interval  time.Duration
+}
+
+// LogExporter sends the log records to an OpenTelemetry collector over OTLP
+// gRPC. It implements the slog.Handler interface and is meant for
+// logger.Export. Records are sent in batches from a goroutine; when the queue
+// is full the records are dropped so logging never blocks a request.
+type LogExporter struct {
+	conn     *grpc.ClientConn
+	client   collogspb.LogsServiceClient
+	resource *resourcepb.Resource
+	cfg      LogConfig
+
+	queue chan *logspb.LogRecord
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewLogExporter constructs an exporter to the collector at cfg.Host, e.g.
+// "otel-collector:4317".
+func NewLogExporter(cfg LogConfig) (*LogExporter, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("log exporter requires a host")
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	conn, err := grpc.NewClient(cfg.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("creating grpc client: %w", err)
+	}
+
+	exp := LogExporter{
+		conn:   conn,
+		client: collogspb.NewLogsServiceClient(conn),
+		resource: &resourcepb.Resource{
+			Attributes: []*commonpb.KeyValue{stringKV("service.name", cfg.ServiceName)},
+		},
+		cfg:   cfg,
+		queue: make(chan *logspb.LogRecord, 4*cfg.BatchSize),
+		done:  make(chan struct{}),
+	}
+
+	go exp.run()
+
+	return &exp, nil
+}
+
+// Shutdown sends the records still queued and closes the connection.
+func (exp *LogExporter) Shutdown(ctx context.Context) error {
+	exp.once.Do(func() {
+		close(exp.queue)
+	})
+
+	select {
+	case <-exp.done:
+	case <-ctx.Done():
+		exp.conn.Close()
+		return ctx.Err()
+	}
+
+	return exp.conn.Close()
+}
+
+// Enabled implements the slog.Handler interface. The levels are checked by
+// the logger before the record gets here.
+func (exp *LogExporter) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements the slog.Handler interface.
+func (exp *LogExporter) Handle(ctx context.Context, r slog.Record) error {
+	return exp.handle(ctx, r, nil, "")
+}
+
+// WithAttrs implements the slog.Handler interface.
+func (exp *LogExporter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{exp: exp, attrs: attrs}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (exp *LogExporter) WithGroup(name string) slog.Handler {
+	return &logHandler{exp: exp, group: name}
+}
+
+func (exp *LogExporter) handle(ctx context.Context, r slog.Record, attrs []slog.Attr, group string) (err error) {
+	lr := logspb.LogRecord{
+		TimeUnixNano:         uint64(r.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       severity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: r.Message}},
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		traceID := sc.TraceID()
+		spanID := sc.SpanID()
+		lr.TraceId = traceID[:]
+		lr.SpanId = spanID[:]
+		lr.Flags = uint32(sc.TraceFlags())
+	}
+
+	for _, a := range attrs {
+		lr.Attributes = append(lr.Attributes, toKV(group, a))
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		lr.Attributes = append(lr.Attributes, toKV(group, a))
+		return true
+	})
+
+	// Enviar para uma fila fechada entra em pânico quando um log é escrito
+	// depois do Shutdown.
+	defer func() {
+		if recover() != nil {
+			err = errors.New("log exporter is shut down")
+		}
+	}()
+
+	select {
+	case exp.queue <- &lr:
+		return nil
+	default:
+		return errors.New("log exporter queue is full")
+	}
+}
+
+// run sends the records in batches until the queue is closed.
+func (exp *LogExporter) run() {
+	defer close(exp.done)
+
+	ticker := time.NewTicker(exp.cfg.Interval)
+	defer ticker.Stop()
+
+	batch := make([]*logspb.LogRecord, 0, exp.cfg.BatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := exp.export(batch); err != nil {
+			// O logger não pode ser usado aqui: o erro voltaria para o
+			// próprio exportador.
+			fmt.Fprintf(os.Stderr, "otel: exporting %d log records: %s\n", len(batch), err)
+		}
+
+		batch = make([]*logspb.LogRecord, 0, exp.cfg.BatchSize)
+	}
+
+	for {
+		select {
+		case lr, ok := <-exp.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, lr)
+			if len(batch) >= exp.cfg.BatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (exp *LogExporter) export(batch []*logspb.LogRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req := collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: exp.resource,
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope:      &commonpb.InstrumentationScope{Name: "github.com/jcpaschoal/spi-exata/foundation/logger"},
+						LogRecords: batch,
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := exp.client.Export(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	if ps := resp.GetPartialSuccess(); ps != nil && ps.GetRejectedLogRecords() > 0 {
+		return fmt.Errorf("%d records rejected: %s", ps.GetRejectedLogRecords(), ps.GetErrorMessage())
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// logHandler carries the attributes and the group set with WithAttrs and
+// WithGroup.
+type logHandler struct {
+	exp   *LogExporter
+	attrs []slog.Attr
+	group string
+}
+
+func (h *logHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *logHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.exp.handle(ctx, r, h.attrs, h.group)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{exp: h.exp, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...), group: h.group}
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+
+	return &logHandler{exp: h.exp, attrs: h.attrs, group: name}
+}
+
+// =============================================================================
+
+func severity(level slog.Level) logspb.SeverityNumber {
+	switch {
+	case level >= slog.LevelError:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case level >= slog.LevelWarn:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case level >= slog.LevelInfo:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	default:
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	}
+}
+
+func stringKV(key string, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func toKV(group string, a slog.Attr) *commonpb.KeyValue {
+	key := a.Key
+	if group != "" {
+		key = group + "." + key
+	}
+
+	return &commonpb.KeyValue{Key: key, Value: toAnyValue(a.Value)}
+}
+
+func toAnyValue(v slog.Value) *commonpb.AnyValue {
+	v = v.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.String()}}
+	case slog.KindInt64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.Int64()}}
+	case slog.KindUint64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v.Uint64())}}
+	case slog.KindFloat64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.Float64()}}
+	case slog.KindBool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.Bool()}}
+	case slog.KindGroup:
+		var kvs []*commonpb.KeyValue
+		for _, a := range v.Group() {
+			kvs = append(kvs, toKV("", a))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: kvs}}}
+	}
+
+	// Duração, tempo, erros e demais valores vão como texto.
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v.String()}}
+}
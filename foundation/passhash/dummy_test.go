@@ -0,0 +1,41 @@
+package passhash
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestDummyCost checks the dummy hash is made at the cost of the new hashes,
+// so CompareDummy takes as long as Compare against a hash from Hash.
+func TestDummyCost(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "fixed", cfg: Config{Cost: bcrypt.MinCost, Workers: 1}},
+		{name: "calibrated", cfg: Config{Target: 1, Workers: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(tt.cfg)
+			if err != nil {
+				t.Fatalf("new: %s", err)
+			}
+
+			cost, err := bcrypt.Cost(h.dummy)
+			if err != nil {
+				t.Fatalf("cost: %s", err)
+			}
+
+			if cost != h.Cost() {
+				t.Errorf("got dummy cost %d, want %d", cost, h.Cost())
+			}
+
+			if h.NeedsRehash(h.dummy) {
+				t.Error("got dummy needing a rehash")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package passhash hashes and verifies passwords with bcrypt on a bounded
+// pool, so a burst of logins or a bulk import cannot take every CPU of the
+// service away from the requests.
+package passhash
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultCost is the cost used by the tools that do not calibrate it.
+const DefaultCost = bcrypt.DefaultCost
+
+// DefaultTarget is how long a hash should take when the cost is calibrated.
+const DefaultTarget = 250 * time.Millisecond
+
+// Config represents the settings of the hasher. A zero Cost is calibrated
+// to the Target on the current machine and a zero Workers uses one worker
+// per CPU.
+type Config struct {
+	Cost    int
+	Target  time.Duration
+	Workers int
+}
+
+// Hasher hashes and compares passwords, running at most Workers bcrypt
+// operations at the same time.
+type Hasher struct {
+	cost  int
+	slots chan struct{}
+	dummy []byte
+}
+
+// New constructs a hasher. Calibrating the cost and preparing the dummy
+// hash take a few hashes, so it is meant to be called once at startup.
+func New(cfg Config) (*Hasher, error) {
+	cost := cfg.Cost
+	if cost == 0 {
+		target := cfg.Target
+		if target <= 0 {
+			target = DefaultTarget
+		}
+		cost = Calibrate(target)
+	}
+
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("cost %d out of range [%d, %d]", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	dummy, err := bcrypt.GenerateFromPassword([]byte("dummy"), cost)
+	if err != nil {
+		return nil, fmt.Errorf("dummy hash: %w", err)
+	}
+
+	h := Hasher{
+		cost:  cost,
+		slots: make(chan struct{}, workers),
+		dummy: dummy,
+	}
+
+	return &h, nil
+}
+
+// Cost returns the bcrypt cost of the new hashes.
+func (h *Hasher) Cost() int {
+	return h.cost
+}
+
+// Hash returns the bcrypt hash of the password. It waits for a free worker
+// and gives up when the context is done, even while the hash is running.
+func (h *Hasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	var hash []byte
+
+	err := h.run(ctx, func() error {
+		var err error
+		hash, err = bcrypt.GenerateFromPassword([]byte(password), h.cost)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// Compare checks the password against the hash, returning
+// bcrypt.ErrMismatchedHashAndPassword when they do not match.
+func (h *Hasher) Compare(ctx context.Context, hash []byte, password string) error {
+	return h.run(ctx, func() error {
+		return bcrypt.CompareHashAndPassword(hash, []byte(password))
+	})
+}
+
+// CompareDummy does the work of Compare against a hash no password matches.
+// It lets a caller fail for an unknown user in the same time it takes to
+// fail for a wrong password. The dummy hash has the cost of the new hashes,
+// so the stored hashes of another cost must be rehashed, see NeedsRehash.
+func (h *Hasher) CompareDummy(ctx context.Context, password string) {
+	h.Compare(ctx, h.dummy, password)
+}
+
+// NeedsRehash reports whether the hash was made at a cost other than the
+// cost of the new hashes. Such a hash takes a different time to compare
+// than the dummy hash, so it should be replaced once the password is known.
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false
+	}
+
+	return cost != h.cost
+}
+
+// run executes fn on a free worker. A bcrypt operation cannot be interrupted,
+// so when the context is done fn keeps its worker until it ends and only the
+// caller stops waiting.
+func (h *Hasher) run(ctx context.Context, fn func() error) error {
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() { <-h.slots }()
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// =============================================================================
+
+// Calibrate returns the highest cost whose hash takes no longer than the
+// target on this machine, never below bcrypt.DefaultCost. Each extra point
+// of cost doubles the time, so it times the default cost and extrapolates.
+func Calibrate(target time.Duration) int {
+	start := time.Now()
+	bcrypt.GenerateFromPassword([]byte("calibrate"), bcrypt.DefaultCost)
+	took := time.Since(start)
+
+	cost := bcrypt.DefaultCost
+	for cost < bcrypt.MaxCost && took*2 <= target {
+		took *= 2
+		cost++
+	}
+
+	return cost
+}
@@ -0,0 +1,143 @@
+package passhash_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jcpaschoal/spi-exata/foundation/passhash"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cost    int
+		wantErr bool
+	}{
+		{name: "min", cost: bcrypt.MinCost},
+		{name: "belowMin", cost: bcrypt.MinCost - 1, wantErr: true},
+		{name: "aboveMax", cost: bcrypt.MaxCost + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := passhash.New(passhash.Config{Cost: tt.cost, Workers: 1})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && h.Cost() != tt.cost {
+				t.Errorf("got cost %d, want %d", h.Cost(), tt.cost)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	h, err := passhash.New(passhash.Config{Cost: bcrypt.MinCost, Workers: 1})
+	if err != nil {
+		t.Fatalf("new: %s", err)
+	}
+
+	hash, err := h.Hash(context.Background(), "secret")
+	if err != nil {
+		t.Fatalf("hash: %s", err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		password string
+		wantErr  error
+	}{
+		{name: "match", ctx: context.Background(), password: "secret"},
+		{name: "mismatch", ctx: context.Background(), password: "wrong", wantErr: bcrypt.ErrMismatchedHashAndPassword},
+		{name: "canceled", ctx: canceled, password: "secret", wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := h.Compare(tt.ctx, hash, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h, err := passhash.New(passhash.Config{Cost: bcrypt.MinCost, Workers: 1})
+	if err != nil {
+		t.Fatalf("new: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		cost int
+		want bool
+	}{
+		{name: "sameCost", cost: bcrypt.MinCost},
+		{name: "otherCost", cost: bcrypt.MinCost + 1, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := bcrypt.GenerateFromPassword([]byte("secret"), tt.cost)
+			if err != nil {
+				t.Fatalf("hash: %s", err)
+			}
+
+			if got := h.NeedsRehash(hash); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkHash measures a hash at each cost, the numbers Calibrate
+// extrapolates from. Every point of cost doubles the time.
+func BenchmarkHash(b *testing.B) {
+	for _, cost := range []int{bcrypt.MinCost, 8, bcrypt.DefaultCost, 12, 14} {
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			h, err := passhash.New(passhash.Config{Cost: cost, Workers: 1})
+			if err != nil {
+				b.Fatalf("new: %s", err)
+			}
+
+			ctx := context.Background()
+
+			for b.Loop() {
+				if _, err := h.Hash(ctx, "benchmark-password"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkHashParallel measures the throughput of the pool under
+// concurrent hashes, which is bounded by the workers and not by the callers.
+func BenchmarkHashParallel(b *testing.B) {
+	for _, workers := range []int{1, 2, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			h, err := passhash.New(passhash.Config{Cost: bcrypt.DefaultCost, Workers: workers})
+			if err != nil {
+				b.Fatalf("new: %s", err)
+			}
+
+			ctx := context.Background()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := h.Hash(ctx, "benchmark-password"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
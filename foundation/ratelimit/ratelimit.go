@@ -0,0 +1,110 @@
+// Package ratelimit implements token bucket rate limiting. The Limiter
+// interface allows the buckets to live in memory, for a single instance, or
+// in a shared store when the limit must hold across every instance.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result reports the outcome of a rate limit check.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter decides if the request identified by key can proceed.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// =============================================================================
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Memory is an in-memory token bucket limiter. Each key gets a bucket of
+// burst tokens refilled at rate tokens per second.
+type Memory struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemory constructs a token bucket limiter that allows rate requests per
+// second with bursts of up to burst requests.
+func NewMemory(rate float64, burst int) *Memory {
+	return &Memory{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow implements the Limiter interface.
+func (m *Memory) Allow(ctx context.Context, key string) (Result, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, exists := m.buckets[key]
+	if !exists {
+		b = &bucket{tokens: m.burst, lastSeen: now}
+		m.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(m.burst, b.tokens+elapsed*m.rate)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / m.rate * float64(time.Second))
+		return Result{Allowed: false, Remaining: 0, RetryAfter: wait}, nil
+	}
+
+	b.tokens--
+
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// Run removes buckets that are full again, and therefore equivalent to a new
+// bucket, on every interval until the context is cancelled. This keeps the
+// memory bounded by the number of active clients.
+func (m *Memory) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.evict()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Memory) evict() {
+	now := m.now()
+	full := time.Duration(m.burst / m.rate * float64(time.Second))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, b := range m.buckets {
+		if now.Sub(b.lastSeen) >= full {
+			delete(m.buckets, key)
+		}
+	}
+}
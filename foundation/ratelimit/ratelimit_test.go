@@ -0,0 +1,242 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+)
+
+func TestMemory(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	// 2 requisições por segundo com rajada de 3.
+	m := NewMemory(2, 3)
+
+	tests := []struct {
+		name string
+		key  string
+		at   time.Duration
+		want Result
+	}{
+		{name: "burst1", key: "a", want: Result{Allowed: true, Remaining: 2}},
+		{name: "burst2", key: "a", want: Result{Allowed: true, Remaining: 1}},
+		{name: "burst3", key: "a", want: Result{Allowed: true, Remaining: 0}},
+		{name: "empty", key: "a", want: Result{RetryAfter: 500 * time.Millisecond}},
+		{name: "otherKey", key: "b", want: Result{Allowed: true, Remaining: 2}},
+		{name: "halfToken", key: "a", at: 250 * time.Millisecond, want: Result{RetryAfter: 250 * time.Millisecond}},
+		{name: "refilled", key: "a", at: 500 * time.Millisecond, want: Result{Allowed: true, Remaining: 0}},
+		{name: "capped", key: "a", at: time.Hour, want: Result{Allowed: true, Remaining: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.now = func() time.Time { return start.Add(tt.at) }
+
+			got, err := m.Allow(context.Background(), tt.key)
+			if err != nil {
+				t.Fatalf("allow: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("evict", func(t *testing.T) {
+		m.evict()
+
+		// "b" está cheio desde o início, "a" acabou de gastar um token.
+		if _, exists := m.buckets["b"]; exists {
+			t.Error("got the full bucket kept")
+		}
+		if _, exists := m.buckets["a"]; !exists {
+			t.Error("got the bucket still refilling evicted")
+		}
+	})
+}
+
+func TestRedis(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  []string
+		want     Result
+		wantCmds []string
+		wantErr  bool
+	}{
+		{
+			name:     "allowed",
+			replies:  []string{"*3\r\n:1\r\n:19\r\n:0\r\n"},
+			want:     Result{Allowed: true, Remaining: 19},
+			wantCmds: []string{"EVALSHA"},
+		},
+		{
+			name:     "denied",
+			replies:  []string{"*3\r\n:0\r\n:0\r\n:150\r\n"},
+			want:     Result{RetryAfter: 150 * time.Millisecond},
+			wantCmds: []string{"EVALSHA"},
+		},
+		{
+			name:     "noScript",
+			replies:  []string{"-NOSCRIPT No matching script. Please use EVAL.\r\n", "*3\r\n:1\r\n:19\r\n:0\r\n"},
+			want:     Result{Allowed: true, Remaining: 19},
+			wantCmds: []string{"EVALSHA", "EVAL"},
+		},
+		{
+			name:     "serverError",
+			replies:  []string{"-ERR user_script:1: Script attempted to access nonexistent global variable\r\n"},
+			wantCmds: []string{"EVALSHA"},
+			wantErr:  true,
+		},
+		{
+			name:     "unexpectedReply",
+			replies:  []string{"+OK\r\n"},
+			wantCmds: []string{"EVALSHA"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, tt.replies)
+
+			client := redis.New(redis.Config{Addr: srv.addr})
+			defer client.Close()
+
+			rl := NewRedis(client, "ratelimit:", 10, 20)
+
+			got, err := rl.Allow(context.Background(), "user:42")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			cmds := srv.commands()
+			if len(cmds) != len(tt.wantCmds) {
+				t.Fatalf("got %d commands, want %d", len(cmds), len(tt.wantCmds))
+			}
+
+			for i, cmd := range cmds {
+				script := bucketSHA
+				if tt.wantCmds[i] == "EVAL" {
+					script = bucketScript
+				}
+
+				// 20 tokens a 10 por segundo enchem em 2s, mais 1s de folga.
+				want := []string{tt.wantCmds[i], script, "1", "ratelimit:user:42", "10", "20", "3000"}
+				if strings.Join(cmd, "|") != strings.Join(want, "|") {
+					t.Errorf("got command %q, want %q", cmd, want)
+				}
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// server answers each command it reads with the next recorded reply.
+type server struct {
+	addr string
+
+	mu   sync.Mutex
+	cmds [][]string
+}
+
+func newServer(t *testing.T, replies []string) *server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := server{addr: ln.Addr().String()}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+				for {
+					cmd, err := readCommand(r)
+					if err != nil {
+						return
+					}
+
+					srv.mu.Lock()
+					srv.cmds = append(srv.cmds, cmd)
+					n := len(srv.cmds)
+					srv.mu.Unlock()
+
+					if n > len(replies) {
+						return
+					}
+					io.WriteString(conn, replies[n-1])
+				}
+			}()
+		}
+	}()
+
+	return &srv
+}
+
+func (s *server) commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cmds
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := make([]string, n)
+	for i := range cmd {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(buf[:size])
+	}
+
+	return cmd, nil
+}
+
+func readLength(r *bufio.Reader, kind byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) < 3 || line[0] != kind {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
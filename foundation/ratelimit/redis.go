@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+)
+
+// bucketScript refills and takes a token from the bucket of KEYS[1] in one
+// step, so instances sharing the server never race on the same bucket. The
+// clock is the one of the server, the same for every instance. It returns
+// whether the request is allowed, the tokens left and the wait in
+// milliseconds until the next token.
+const bucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+local wait = 0
+if tokens < 1 then
+	wait = math.ceil((1 - tokens) / rate * 1000)
+else
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+
+return {allowed, math.floor(tokens), wait}
+`
+
+var bucketSHA = func() string {
+	sum := sha1.Sum([]byte(bucketScript))
+	return hex.EncodeToString(sum[:])
+}()
+
+// Redis is a token bucket limiter kept in Redis, so the limit holds across
+// every instance of the service. It has the same rate and burst semantics
+// as Memory. The script reads the clock of the server before writing, which
+// needs Redis 5 or later.
+type Redis struct {
+	client *redis.Client
+	prefix string
+	rate   string
+	burst  string
+	ttl    string
+}
+
+// NewRedis constructs a token bucket limiter that allows rate requests per
+// second with bursts of up to burst requests. The buckets are stored under
+// keys starting with prefix and expire once they would be full again.
+func NewRedis(client *redis.Client, prefix string, rate float64, burst int) *Redis {
+	full := time.Duration(float64(burst) / rate * float64(time.Second))
+
+	return &Redis{
+		client: client,
+		prefix: prefix,
+		rate:   strconv.FormatFloat(rate, 'f', -1, 64),
+		burst:  strconv.Itoa(burst),
+		ttl:    strconv.FormatInt((full + time.Second).Milliseconds(), 10),
+	}
+}
+
+// Allow implements the Limiter interface.
+func (rl *Redis) Allow(ctx context.Context, key string) (Result, error) {
+	key = rl.prefix + key
+
+	// O script é enviado só quando o servidor ainda não o conhece, por
+	// exemplo depois de um restart ou de um SCRIPT FLUSH.
+	v, err := rl.client.Do(ctx, "EVALSHA", bucketSHA, "1", key, rl.rate, rl.burst, rl.ttl)
+
+	var srvErr redis.Error
+	if errors.As(err, &srvErr) && strings.HasPrefix(string(srvErr), "NOSCRIPT") {
+		v, err = rl.client.Do(ctx, "EVAL", bucketScript, "1", key, rl.rate, rl.burst, rl.ttl)
+	}
+
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: %w", err)
+	}
+
+	reply, ok := v.([]any)
+	if !ok || len(reply) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", v)
+	}
+
+	var n [3]int64
+	for i, item := range reply {
+		if n[i], ok = item.(int64); !ok {
+			return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", v)
+		}
+	}
+
+	res := Result{
+		Allowed:    n[0] == 1,
+		Remaining:  int(max(0, min(n[1], math.MaxInt32))),
+		RetryAfter: time.Duration(n[2]) * time.Millisecond,
+	}
+
+	return res, nil
+}
@@ -0,0 +1,297 @@
+// Package redis provides a small Redis client speaking RESP over a pool of
+// connections. It covers the commands the system needs for caching.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNil is returned when the key does not exist.
+var ErrNil = errors.New("redis: nil")
+
+// Error is an error reply sent by the server, e.g. "WRONGTYPE ...". The
+// connection stays usable after it.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Config represents the settings of the client.
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+
+	// PoolSize is how many idle connections are kept. Defaults to 10.
+	PoolSize int
+
+	// Timeout bounds every command without a deadline in the context.
+	// Defaults to 2 seconds.
+	Timeout time.Duration
+}
+
+// Client manages a pool of connections to a Redis server. Connections are
+// opened on demand and dropped after any network or protocol failure.
+type Client struct {
+	cfg  Config
+	pool chan *conn
+}
+
+// New constructs a client for the server. No connection is opened until the
+// first command.
+func New(cfg Config) *Client {
+	cfg.Addr = strings.TrimPrefix(cfg.Addr, "redis://")
+
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+
+	return &Client{
+		cfg:  cfg,
+		pool: make(chan *conn, cfg.PoolSize),
+	}
+}
+
+// Ping checks the server is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get returns the value of the key or ErrNil when it does not exist.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %T", v)
+	}
+
+	return b, nil
+}
+
+// Set stores the value under the key. A positive ttl expires the key.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Del removes the keys and returns how many existed.
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	v, err := c.Do(ctx, append([]string{"DEL"}, keys...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T", v)
+	}
+
+	return n, nil
+}
+
+// Do sends a command and returns the reply: string for status replies, int64
+// for integers, []byte for bulk strings and []any for arrays. A nil reply is
+// returned as ErrNil.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("redis: connect: %w", err)
+	}
+
+	v, err := cn.do(ctx, c.cfg.Timeout, args)
+
+	var srvErr Error
+	switch {
+	case err == nil, errors.Is(err, ErrNil), errors.As(err, &srvErr):
+		c.put(cn)
+	default:
+		cn.Close()
+		return nil, fmt.Errorf("redis: %s: %w", args[0], err)
+	}
+
+	return v, err
+}
+
+// Close closes the idle connections.
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.pool:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{
+		Conn: nc,
+		r:    bufio.NewReader(nc),
+		w:    bufio.NewWriter(nc),
+	}
+
+	if c.cfg.Password != "" {
+		if _, err := cn.do(ctx, c.cfg.Timeout, []string{"AUTH", c.cfg.Password}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if c.cfg.DB != 0 {
+		if _, err := cn.do(ctx, c.cfg.Timeout, []string{"SELECT", strconv.Itoa(c.cfg.DB)}); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("select: %w", err)
+		}
+	}
+
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.pool <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// =============================================================================
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args []string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	cn.SetDeadline(deadline)
+
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(cn.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return cn.read()
+}
+
+func (cn *conn) read() (any, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+
+	case '-':
+		return nil, Error(body)
+
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, buf); err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+
+		items := make([]any, n)
+		for i := range items {
+			v, err := cn.read()
+
+			// Um elemento nulo não invalida o restante do array.
+			var srvErr Error
+			switch {
+			case errors.Is(err, ErrNil):
+				v = nil
+			case errors.As(err, &srvErr):
+				v = srvErr
+			case err != nil:
+				return nil, err
+			}
+
+			items[i] = v
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
@@ -0,0 +1,281 @@
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcpaschoal/spi-exata/foundation/redis"
+)
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      string
+		want       any
+		wantErr    error
+		wantSrvErr bool
+		wantFail   bool
+	}{
+		{name: "status", reply: "+OK\r\n", want: "OK"},
+		{name: "integer", reply: ":-42\r\n", want: int64(-42)},
+		{name: "bulk", reply: "$5\r\nhello\r\n", want: []byte("hello")},
+		{name: "binaryBulk", reply: "$7\r\na\r\nb\r\nc\r\n", want: []byte("a\r\nb\r\nc")},
+		{name: "emptyBulk", reply: "$0\r\n\r\n", want: []byte{}},
+		{name: "nilBulk", reply: "$-1\r\n", wantErr: redis.ErrNil},
+		{name: "nilArray", reply: "*-1\r\n", wantErr: redis.ErrNil},
+		{name: "emptyArray", reply: "*0\r\n", want: []any{}},
+		{
+			name:  "mixedArray",
+			reply: "*4\r\n$3\r\nfoo\r\n$-1\r\n:7\r\n-ERR no such key\r\n",
+			want:  []any{[]byte("foo"), nil, int64(7), redis.Error("ERR no such key")},
+		},
+		{
+			name:  "nestedArray",
+			reply: "*2\r\n*2\r\n:1\r\n:2\r\n+OK\r\n",
+			want:  []any{[]any{int64(1), int64(2)}, "OK"},
+		},
+		{name: "serverError", reply: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", wantSrvErr: true},
+		{name: "unknownType", reply: "?1\r\n", wantFail: true},
+		{name: "noCRLF", reply: "+OK\n", wantFail: true},
+		{name: "badLength", reply: "$x\r\n", wantFail: true},
+		{name: "truncatedBulk", reply: "$10\r\nhello\r\n", wantFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t, []string{tt.reply})
+
+			client := redis.New(redis.Config{Addr: "redis://" + srv.addr, Timeout: 100 * time.Millisecond})
+
+			got, err := client.Do(context.Background(), "GET", "key")
+			client.Close()
+
+			var srvErr redis.Error
+			switch {
+			case tt.wantSrvErr:
+				if !errors.As(err, &srvErr) {
+					t.Fatalf("got error %v, want a server error", err)
+				}
+			case tt.wantFail:
+				if err == nil || errors.As(err, &srvErr) {
+					t.Fatalf("got error %v, want a protocol failure", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+
+			want := []string{"0: GET key"}
+			if cmds := srv.commands(); !reflect.DeepEqual(cmds, want) {
+				t.Errorf("got commands %q, want %q", cmds, want)
+			}
+		})
+	}
+}
+
+// TestConnections checks a connection is authenticated once when opened,
+// kept after a server error and dropped after a protocol failure.
+func TestConnections(t *testing.T) {
+	srv := newServer(t, []string{
+		"+OK\r\n",
+		"+OK\r\n",
+		"+OK\r\n",
+		"$5\r\nvalue\r\n",
+		":1\r\n",
+		"-WRONGTYPE Operation against a key holding the wrong kind of value\r\n",
+		"?garbage\r\n",
+		"+OK\r\n",
+		"+OK\r\n",
+		"+PONG\r\n",
+	})
+
+	client := redis.New(redis.Config{Addr: srv.addr, Password: "secret", DB: 2})
+	defer client.Close()
+
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		run     func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "set",
+			run:  func() (any, error) { return nil, client.Set(ctx, "key", []byte("value"), time.Minute) },
+		},
+		{
+			name: "get",
+			run:  func() (any, error) { return client.Get(ctx, "key") },
+			want: []byte("value"),
+		},
+		{
+			name: "del",
+			run:  func() (any, error) { return client.Del(ctx, "key", "other") },
+			want: int64(1),
+		},
+		{
+			name:    "serverError",
+			run:     func() (any, error) { return client.Get(ctx, "list") },
+			want:    []byte(nil),
+			wantErr: true,
+		},
+		{
+			name:    "protocolFailure",
+			run:     func() (any, error) { return nil, client.Ping(ctx) },
+			wantErr: true,
+		},
+		{
+			name: "redial",
+			run:  func() (any, error) { return nil, client.Ping(ctx) },
+		},
+	}
+
+	for _, st := range steps {
+		got, err := st.run()
+		if (err != nil) != st.wantErr {
+			t.Fatalf("%s: got error %v, want error %t", st.name, err, st.wantErr)
+		}
+		if !reflect.DeepEqual(got, st.want) {
+			t.Errorf("%s: got %#v, want %#v", st.name, got, st.want)
+		}
+	}
+
+	want := []string{
+		"0: AUTH secret",
+		"0: SELECT 2",
+		"0: SET key value PX 60000",
+		"0: GET key",
+		"0: DEL key other",
+		"0: GET list",
+		"0: PING",
+		"1: AUTH secret",
+		"1: SELECT 2",
+		"1: PING",
+	}
+
+	if cmds := srv.commands(); !reflect.DeepEqual(cmds, want) {
+		t.Errorf("got commands %q, want %q", cmds, want)
+	}
+}
+
+// =============================================================================
+
+// server answers each command it reads with the next recorded reply. The
+// commands are kept prefixed by the number of the connection.
+type server struct {
+	addr string
+
+	mu    sync.Mutex
+	cmds  []string
+	conns int
+}
+
+func newServer(t *testing.T, replies []string) *server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	srv := server{addr: ln.Addr().String()}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			srv.mu.Lock()
+			id := srv.conns
+			srv.conns++
+			srv.mu.Unlock()
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+				for {
+					cmd, err := readCommand(r)
+					if err != nil {
+						return
+					}
+
+					srv.mu.Lock()
+					srv.cmds = append(srv.cmds, fmt.Sprintf("%d: %s", id, strings.Join(cmd, " ")))
+					n := len(srv.cmds)
+					srv.mu.Unlock()
+
+					if n > len(replies) {
+						return
+					}
+					io.WriteString(conn, replies[n-1])
+				}
+			}()
+		}
+	}()
+
+	return &srv
+}
+
+func (s *server) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cmds
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := make([]string, n)
+	for i := range cmd {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(buf[:size])
+	}
+
+	return cmd, nil
+}
+
+func readLength(r *bufio.Reader, kind byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+
+	if len(line) < 3 || line[0] != kind {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
@@ -0,0 +1,196 @@
+package saml
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// canonicalize returns the exclusive canonical form without comments of the
+// element, the form the signatures are computed over. The skip element is
+// left out, which is how the enveloped signature transform removes the
+// signature from the signed element. The inclusive prefixes are rendered
+// as the InclusiveNamespaces PrefixList asks, "#default" for the default
+// namespace.
+func canonicalize(e *element, skip *element, inclusive []string) []byte {
+	c := canonicalizer{
+		skip:      skip,
+		inclusive: inclusive,
+	}
+
+	c.element(e, map[string]string{})
+
+	return []byte(c.b.String())
+}
+
+type canonicalizer struct {
+	b         strings.Builder
+	skip      *element
+	inclusive []string
+}
+
+func (c *canonicalizer) element(e *element, rendered map[string]string) {
+	type decl struct {
+		prefix string
+		uri    string
+	}
+
+	// Os prefixos usados de fato pelo elemento e pelos atributos, mais os
+	// da lista inclusiva, são declarados quando o ancestral na saída não os
+	// declarou com o mesmo valor.
+	used := []string{e.prefix}
+	for _, a := range e.attrs {
+		if !a.isNamespace() && a.prefix != "" && a.prefix != "xml" {
+			used = append(used, a.prefix)
+		}
+	}
+	for _, p := range c.inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		if _, ok := e.lookupNamespace(p); ok || p == "" {
+			used = append(used, p)
+		}
+	}
+
+	var decls []decl
+	for _, p := range used {
+		if slices.ContainsFunc(decls, func(d decl) bool { return d.prefix == p }) {
+			continue
+		}
+
+		uri, _ := e.lookupNamespace(p)
+
+		prev, ok := rendered[p]
+		if (ok && prev == uri) || (!ok && p == "" && uri == "") {
+			continue
+		}
+
+		decls = append(decls, decl{prefix: p, uri: uri})
+	}
+
+	slices.SortFunc(decls, func(a, b decl) int {
+		return strings.Compare(a.prefix, b.prefix)
+	})
+
+	type attrOut struct {
+		space string
+		name  string
+		value string
+	}
+
+	var attrs []attrOut
+	for _, a := range e.attrs {
+		if a.isNamespace() {
+			continue
+		}
+
+		out := attrOut{
+			name:  a.local,
+			value: a.value,
+		}
+		if a.prefix != "" {
+			out.space, _ = e.lookupNamespace(a.prefix)
+			out.name = a.prefix + ":" + a.local
+		}
+
+		attrs = append(attrs, out)
+	}
+
+	slices.SortFunc(attrs, func(a, b attrOut) int {
+		if n := strings.Compare(a.space, b.space); n != 0 {
+			return n
+		}
+		return strings.Compare(localName(a.name), localName(b.name))
+	})
+
+	name := e.local
+	if e.prefix != "" {
+		name = e.prefix + ":" + e.local
+	}
+
+	c.b.WriteString("<" + name)
+
+	if len(decls) > 0 {
+		rendered = maps.Clone(rendered)
+	}
+
+	for _, d := range decls {
+		if d.prefix == "" {
+			c.b.WriteString(` xmlns="`)
+		} else {
+			c.b.WriteString(" xmlns:" + d.prefix + `="`)
+		}
+		escapeAttr(&c.b, d.uri)
+		c.b.WriteString(`"`)
+
+		rendered[d.prefix] = d.uri
+	}
+
+	for _, a := range attrs {
+		c.b.WriteString(" " + a.name + `="`)
+		escapeAttr(&c.b, a.value)
+		c.b.WriteString(`"`)
+	}
+
+	c.b.WriteString(">")
+
+	for _, child := range e.children {
+		switch v := child.(type) {
+		case string:
+			escapeText(&c.b, v)
+		case *element:
+			if v != c.skip {
+				c.element(v, rendered)
+			}
+		}
+	}
+
+	c.b.WriteString("</" + name + ">")
+}
+
+func localName(name string) string {
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		return local
+	}
+
+	return name
+}
+
+func escapeText(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttr(b *strings.Builder, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\t':
+			b.WriteString("&#x9;")
+		case '\n':
+			b.WriteString("&#xA;")
+		case '\r':
+			b.WriteString("&#xD;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package saml
+
+import (
+	"os"
+	"testing"
+)
+
+// TestCanonicalizeFixture checks the canonical forms of the signed response
+// in testdata, written by hand from the exclusive c14n specification.
+func TestCanonicalizeFixture(t *testing.T) {
+	root := parseFixture(t, "testdata/response.xml")
+
+	assertion := root.element(nsAssertion, "Assertion")
+	sig := signature(assertion)
+
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{name: "envelopedAssertion", got: canonicalize(assertion, sig, nil), want: "testdata/assertion.c14n"},
+		{name: "signedInfo", got: canonicalize(sig.element(nsDSig, "SignedInfo"), nil, nil), want: "testdata/signedinfo.c14n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Fatalf("read: %s", err)
+			}
+
+			if string(tt.got) != string(want) {
+				t.Errorf("got\n%s\nwant\n%s", tt.got, want)
+			}
+		})
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		child     bool
+		inclusive []string
+		want      string
+	}{
+		{
+			// Exemplo da seção 2.2 da especificação do exclusive c14n.
+			name:  "specExample",
+			doc:   `<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2></n0:local>`,
+			child: true,
+			want:  `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2>`,
+		},
+		{
+			name: "attributeOrder",
+			doc:  `<a xmlns:b="http://b" xmlns:c="http://a" b:x="1" c:y="2" z="3" a="4"/>`,
+			want: `<a xmlns:b="http://b" xmlns:c="http://a" a="4" z="3" c:y="2" b:x="1"></a>`,
+		},
+		{
+			name: "unusedNamespace",
+			doc:  `<root xmlns:unused="urn:u"><x/></root>`,
+			want: `<root><x></x></root>`,
+		},
+		{
+			name: "defaultNamespace",
+			doc:  `<a xmlns="urn:a"><b/></a>`,
+			want: `<a xmlns="urn:a"><b></b></a>`,
+		},
+		{
+			name:  "inheritedDefaultNamespace",
+			doc:   `<a xmlns="urn:a"><b/></a>`,
+			child: true,
+			want:  `<b xmlns="urn:a"></b>`,
+		},
+		{
+			name: "undeclaredDefaultNamespace",
+			doc:  `<a xmlns="urn:a"><b xmlns=""/></a>`,
+			want: `<a xmlns="urn:a"><b xmlns=""></b></a>`,
+		},
+		{
+			name: "escaping",
+			doc:  `<a v="&quot;&#9;&#10;&lt;&gt;">a &amp; b &lt; c &gt; d&#13;</a>`,
+			want: `<a v="&quot;&#x9;&#xA;&lt;>">a &amp; b &lt; c &gt; d&#xD;</a>`,
+		},
+		{
+			name: "comments",
+			doc:  `<a><!-- c -->x<!-- d -->y</a>`,
+			want: `<a>xy</a>`,
+		},
+		{
+			name:      "inclusivePrefix",
+			doc:       `<a xmlns:ds="urn:ds" xmlns:xs="urn:xs"><b/></a>`,
+			child:     true,
+			inclusive: []string{"xs"},
+			want:      `<b xmlns:xs="urn:xs"></b>`,
+		},
+		{
+			name:      "inclusiveDefault",
+			doc:       `<a xmlns="urn:a"><p:b xmlns:p="urn:p"/></a>`,
+			child:     true,
+			inclusive: []string{"#default"},
+			want:      `<p:b xmlns="urn:a" xmlns:p="urn:p"></p:b>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("parse: %s", err)
+			}
+
+			if tt.child {
+				e = e.children[0].(*element)
+			}
+
+			if got := canonicalize(e, nil, tt.inclusive); string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func parseFixture(t *testing.T, path string) *element {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+
+	root, err := parse(data)
+	if err != nil {
+		t.Fatalf("parse: %s", err)
+	}
+
+	return root
+}
@@ -0,0 +1,195 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when a signature is missing, malformed or
+// does not verify with the certificates of the identity provider.
+var ErrInvalidSignature = errors.New("saml: invalid signature")
+
+// Set of algorithms accepted in a signature. SHA-1 is not accepted.
+const (
+	algExcC14N     = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algDigSHA256   = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algDigSHA512   = "http://www.w3.org/2001/04/xmlenc#sha512"
+	signatureLocal = "Signature"
+)
+
+// signature returns the enveloped signature of the element, nil when it is
+// not signed.
+func signature(e *element) *element {
+	return e.element(nsDSig, signatureLocal)
+}
+
+// verify checks the enveloped signature of the element. The reference must
+// point to the element itself by its ID, and the ID must be unique in the
+// document, so a signed element cannot be moved around a forged one. The
+// key is always one of the certificates given, never the one in KeyInfo.
+func verify(root *element, signed *element, certs []*x509.Certificate) error {
+	sig := signature(signed)
+	if sig == nil {
+		return fmt.Errorf("%w: element %s is not signed", ErrInvalidSignature, signed.local)
+	}
+
+	if len(signed.elements(nsDSig, signatureLocal)) > 1 {
+		return fmt.Errorf("%w: more than one signature", ErrInvalidSignature)
+	}
+
+	id := signed.attr("ID")
+	if id == "" {
+		return fmt.Errorf("%w: signed element has no ID", ErrInvalidSignature)
+	}
+
+	var count int
+	root.walk(func(e *element) {
+		if e.attr("ID") == id {
+			count++
+		}
+	})
+	if count != 1 {
+		return fmt.Errorf("%w: ID %q is not unique", ErrInvalidSignature, id)
+	}
+
+	si := sig.element(nsDSig, "SignedInfo")
+	if si == nil {
+		return fmt.Errorf("%w: missing SignedInfo", ErrInvalidSignature)
+	}
+
+	cm := si.element(nsDSig, "CanonicalizationMethod")
+	if cm == nil || cm.attr("Algorithm") != algExcC14N {
+		return fmt.Errorf("%w: canonicalization must be exclusive c14n", ErrInvalidSignature)
+	}
+
+	var sigHash crypto.Hash
+	sm := si.element(nsDSig, "SignatureMethod")
+	switch {
+	case sm == nil:
+		return fmt.Errorf("%w: missing SignatureMethod", ErrInvalidSignature)
+	case sm.attr("Algorithm") == algRSASHA256:
+		sigHash = crypto.SHA256
+	case sm.attr("Algorithm") == algRSASHA512:
+		sigHash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: signature method %q is not supported", ErrInvalidSignature, sm.attr("Algorithm"))
+	}
+
+	refs := si.elements(nsDSig, "Reference")
+	if len(refs) != 1 {
+		return fmt.Errorf("%w: expected one reference, got %d", ErrInvalidSignature, len(refs))
+	}
+	ref := refs[0]
+
+	if ref.attr("URI") != "#"+id {
+		return fmt.Errorf("%w: reference %q does not point to the signed element", ErrInvalidSignature, ref.attr("URI"))
+	}
+
+	var inclusive []string
+	var excC14N bool
+	if ts := ref.element(nsDSig, "Transforms"); ts != nil {
+		for _, t := range ts.elements(nsDSig, "Transform") {
+			switch t.attr("Algorithm") {
+			case algEnveloped:
+			case algExcC14N:
+				excC14N = true
+				inclusive = prefixList(t)
+			default:
+				return fmt.Errorf("%w: transform %q is not supported", ErrInvalidSignature, t.attr("Algorithm"))
+			}
+		}
+	}
+	if !excC14N {
+		return fmt.Errorf("%w: reference must be canonicalized with exclusive c14n", ErrInvalidSignature)
+	}
+
+	var h hash.Hash
+	dm := ref.element(nsDSig, "DigestMethod")
+	switch {
+	case dm == nil:
+		return fmt.Errorf("%w: missing DigestMethod", ErrInvalidSignature)
+	case dm.attr("Algorithm") == algDigSHA256:
+		h = sha256.New()
+	case dm.attr("Algorithm") == algDigSHA512:
+		h = sha512.New()
+	default:
+		return fmt.Errorf("%w: digest method %q is not supported", ErrInvalidSignature, dm.attr("Algorithm"))
+	}
+
+	dv := ref.element(nsDSig, "DigestValue")
+	if dv == nil {
+		return fmt.Errorf("%w: missing DigestValue", ErrInvalidSignature)
+	}
+
+	want, err := decodeBase64(dv.text())
+	if err != nil {
+		return fmt.Errorf("%w: digest value: %s", ErrInvalidSignature, err)
+	}
+
+	h.Write(canonicalize(signed, sig, inclusive))
+	if subtle.ConstantTimeCompare(h.Sum(nil), want) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	sv := sig.element(nsDSig, "SignatureValue")
+	if sv == nil {
+		return fmt.Errorf("%w: missing SignatureValue", ErrInvalidSignature)
+	}
+
+	sigValue, err := decodeBase64(sv.text())
+	if err != nil {
+		return fmt.Errorf("%w: signature value: %s", ErrInvalidSignature, err)
+	}
+
+	sh := sigHash.New()
+	sh.Write(canonicalize(si, nil, prefixList(cm)))
+	digest := sh.Sum(nil)
+
+	for _, cert := range certs {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+
+		if rsa.VerifyPKCS1v15(pub, sigHash, digest, sigValue) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: signature does not match the certificates", ErrInvalidSignature)
+}
+
+// prefixList returns the prefixes of the InclusiveNamespaces of a transform.
+func prefixList(t *element) []string {
+	in := t.element(nsExcC14N, "InclusiveNamespaces")
+	if in == nil {
+		return nil
+	}
+
+	return strings.Fields(in.attr("PrefixList"))
+}
+
+// decodeBase64 decodes a base64 value, which the documents wrap in lines.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			return -1
+		}
+		return r
+	}, s)
+
+	return base64.StdEncoding.DecodeString(s)
+}
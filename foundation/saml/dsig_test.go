@@ -0,0 +1,119 @@
+package saml
+
+import (
+	"crypto/x509"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestVerify checks the signature of the response in testdata. Its digest is
+// the SHA-256 of assertion.c14n and its value was made with
+// "openssl dgst -sha256 -sign" over signedinfo.c14n, with the key of idp.crt.
+func TestVerify(t *testing.T) {
+	data, err := os.ReadFile("testdata/response.xml")
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+	doc := string(data)
+
+	idp := readCertificates(t, "testdata/idp.crt")
+	other := readCertificates(t, "testdata/other.crt")
+
+	replace := func(old string, new string) func(string) string {
+		return func(s string) string {
+			if !strings.Contains(s, old) {
+				t.Fatalf("fixture has no %q", old)
+			}
+			return strings.Replace(s, old, new, 1)
+		}
+	}
+
+	withoutSignature := func(s string) string {
+		start := strings.Index(s, "<ds:Signature ")
+		end := strings.Index(s, "</ds:Signature>") + len("</ds:Signature>")
+		return s[:start] + s[end:]
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(string) string
+		certs   int
+		wantMsg string
+	}{
+		{name: "valid"},
+		{name: "validWithSecondCertificate", certs: 1},
+		{
+			// O comentário some na forma canônica e a assinatura continua
+			// valendo; o texto lido do NameID é o mesmo que foi assinado.
+			name:   "commentInText",
+			mutate: replace("ana@example.org", "ana@exa<!---->mple.org"),
+		},
+		{name: "tamperedText", mutate: replace("ana@example.org", "eve@example.org"), wantMsg: "digest mismatch"},
+		{name: "tamperedAttribute", mutate: replace(`IssueInstant="2026`, `IssueInstant="2027`), wantMsg: "digest mismatch"},
+		{name: "otherCertificate", certs: -1, wantMsg: "does not match the certificates"},
+		{name: "unsigned", mutate: withoutSignature, wantMsg: "is not signed"},
+		{name: "duplicateID", mutate: replace("</samlp:Response>", `<saml:Assertion ID="_a1"/></samlp:Response>`), wantMsg: "is not unique"},
+		{name: "otherReference", mutate: replace(`URI="#_a1"`, `URI="#_resp1"`), wantMsg: "does not point"},
+		{name: "sha1", mutate: replace("xmldsig-more#rsa-sha256", "xmldsig#rsa-sha1"), wantMsg: "not supported"},
+		{name: "inclusiveC14N", mutate: replace(`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>`, `<ds:CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/>`), wantMsg: "exclusive c14n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := doc
+			if tt.mutate != nil {
+				d = tt.mutate(d)
+			}
+
+			root, err := parse([]byte(d))
+			if err != nil {
+				t.Fatalf("parse: %s", err)
+			}
+
+			certs := idp
+			switch tt.certs {
+			case 1:
+				certs = []*x509.Certificate{other[0], idp[0]}
+			case -1:
+				certs = other
+			}
+
+			assertion := root.element(nsAssertion, "Assertion")
+
+			err = verify(root, assertion, certs)
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("verify: %s", err)
+				}
+				if got := assertion.element(nsAssertion, "Subject").element(nsAssertion, "NameID").text(); got != "ana@example.org" {
+					t.Errorf("got NameID %q, want %q", got, "ana@example.org")
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidSignature) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("got error %v, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+func readCertificates(t *testing.T, path string) []*x509.Certificate {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %s", err)
+	}
+
+	certs, err := ParseCertificates(string(data))
+	if err != nil {
+		t.Fatalf("parse certificates: %s", err)
+	}
+
+	return certs
+}
@@ -0,0 +1,438 @@
+// Package saml provides what a SAML 2.0 service provider needs for the web
+// browser SSO profile: the metadata, the authentication request of the
+// HTTP-Redirect binding and the validation of the signed responses of the
+// HTTP-POST binding. Encrypted assertions are not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Set of error variables of the package.
+var (
+	ErrInvalidResponse    = errors.New("saml: invalid response")
+	ErrInvalidCertificate = errors.New("saml: invalid certificate")
+)
+
+// Set of name identifier formats.
+const (
+	NameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+)
+
+const (
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmBearer   = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	defaultMaxSkew  = 2 * time.Minute
+	requestIDPrefix = "id-"
+)
+
+// ServiceProvider identifies the service provider to the identity provider.
+type ServiceProvider struct {
+	EntityID string
+	ACSURL   string
+}
+
+// IdentityProvider holds what the service provider knows of the identity
+// provider: its entity ID, where to send the requests and the certificates
+// its responses are signed with.
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// Assertion is the validated content of a response.
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	Attributes   map[string][]string
+
+	// NotOnOrAfter is when the assertion stops being valid. Its ID must be
+	// remembered until then to reject a replay.
+	NotOnOrAfter time.Time
+}
+
+// Values returns the values of the attribute, by its Name.
+func (a Assertion) Values(name string) []string {
+	return a.Attributes[name]
+}
+
+// ParseCertificates decodes the PEM certificates of an identity provider. A
+// bare base64 certificate, as found in the metadata, is accepted too.
+func ParseCertificates(data string) ([]*x509.Certificate, error) {
+	rest := []byte(data)
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		der, err := decodeBase64(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: no certificate found", ErrInvalidCertificate)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+// =============================================================================
+
+// ParseResponse validates the base64 SAMLResponse posted to the assertion
+// consumer service and returns its assertion. The response or the assertion
+// must be signed by the identity provider, and the assertion must be issued
+// by it, for the service provider, to be consumed now at the ACS URL.
+func ParseResponse(encoded string, sp ServiceProvider, idp IdentityProvider, now time.Time) (Assertion, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return Assertion{}, fmt.Errorf("%w: decode: %s", ErrInvalidResponse, err)
+	}
+
+	root, err := parse(data)
+	if err != nil {
+		return Assertion{}, fmt.Errorf("%w: parse: %s", ErrInvalidResponse, err)
+	}
+
+	if !root.is(nsProtocol, "Response") {
+		return Assertion{}, fmt.Errorf("%w: root element is %s", ErrInvalidResponse, root.local)
+	}
+
+	if root.attr("Version") != "2.0" {
+		return Assertion{}, fmt.Errorf("%w: version %q", ErrInvalidResponse, root.attr("Version"))
+	}
+
+	if dest := root.attr("Destination"); root.hasAttr("Destination") && dest != sp.ACSURL {
+		return Assertion{}, fmt.Errorf("%w: destination %q", ErrInvalidResponse, dest)
+	}
+
+	if iss := root.element(nsAssertion, "Issuer"); iss != nil && iss.text() != idp.EntityID {
+		return Assertion{}, fmt.Errorf("%w: response issuer %q", ErrInvalidResponse, iss.text())
+	}
+
+	var code string
+	if st := root.element(nsProtocol, "Status"); st != nil {
+		if sc := st.element(nsProtocol, "StatusCode"); sc != nil {
+			code = sc.attr("Value")
+		}
+	}
+	if code != statusSuccess {
+		return Assertion{}, fmt.Errorf("%w: status %q", ErrInvalidResponse, code)
+	}
+
+	if len(root.elements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return Assertion{}, fmt.Errorf("%w: encrypted assertions are not supported", ErrInvalidResponse)
+	}
+
+	assertions := root.elements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return Assertion{}, fmt.Errorf("%w: expected one assertion, got %d", ErrInvalidResponse, len(assertions))
+	}
+	a := assertions[0]
+
+	// Uma assinatura presente é sempre verificada. Basta uma delas, da
+	// resposta ou da asserção, já que a da resposta cobre a asserção.
+	responseSigned := signature(root) != nil
+	if responseSigned {
+		if err := verify(root, root, idp.Certificates); err != nil {
+			return Assertion{}, err
+		}
+	}
+
+	if signature(a) != nil {
+		if err := verify(root, a, idp.Certificates); err != nil {
+			return Assertion{}, err
+		}
+	} else if !responseSigned {
+		return Assertion{}, fmt.Errorf("%w: neither the response nor the assertion is signed", ErrInvalidSignature)
+	}
+
+	return readAssertion(a, sp, idp, now)
+}
+
+func readAssertion(a *element, sp ServiceProvider, idp IdentityProvider, now time.Time) (Assertion, error) {
+	asr := Assertion{
+		ID:         a.attr("ID"),
+		Attributes: map[string][]string{},
+	}
+
+	iss := a.element(nsAssertion, "Issuer")
+	if iss == nil || iss.text() != idp.EntityID {
+		return Assertion{}, fmt.Errorf("%w: assertion issuer is not the identity provider", ErrInvalidResponse)
+	}
+	asr.Issuer = iss.text()
+
+	cond := a.element(nsAssertion, "Conditions")
+	if cond == nil {
+		return Assertion{}, fmt.Errorf("%w: missing conditions", ErrInvalidResponse)
+	}
+
+	notOnOrAfter, err := checkWindow(cond, now)
+	if err != nil {
+		return Assertion{}, err
+	}
+	asr.NotOnOrAfter = notOnOrAfter
+
+	restrictions := cond.elements(nsAssertion, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return Assertion{}, fmt.Errorf("%w: missing audience restriction", ErrInvalidResponse)
+	}
+	for _, ar := range restrictions {
+		var ok bool
+		for _, aud := range ar.elements(nsAssertion, "Audience") {
+			if aud.text() == sp.EntityID {
+				ok = true
+			}
+		}
+		if !ok {
+			return Assertion{}, fmt.Errorf("%w: service provider is not in the audience", ErrInvalidResponse)
+		}
+	}
+
+	subject := a.element(nsAssertion, "Subject")
+	if subject == nil {
+		return Assertion{}, fmt.Errorf("%w: missing subject", ErrInvalidResponse)
+	}
+
+	if nid := subject.element(nsAssertion, "NameID"); nid != nil {
+		asr.NameID = nid.text()
+		asr.NameIDFormat = nid.attr("Format")
+	}
+
+	var confirmed bool
+	for _, sc := range subject.elements(nsAssertion, "SubjectConfirmation") {
+		if sc.attr("Method") != confirmBearer {
+			continue
+		}
+
+		data := sc.element(nsAssertion, "SubjectConfirmationData")
+		if data == nil || data.attr("Recipient") != sp.ACSURL || data.hasAttr("NotBefore") {
+			continue
+		}
+
+		until, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(until.Add(defaultMaxSkew)) {
+			continue
+		}
+
+		if until.Before(asr.NotOnOrAfter) {
+			asr.NotOnOrAfter = until
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return Assertion{}, fmt.Errorf("%w: no valid bearer confirmation for the ACS URL", ErrInvalidResponse)
+	}
+
+	if as := a.element(nsAssertion, "AuthnStatement"); as != nil {
+		asr.SessionIndex = as.attr("SessionIndex")
+	}
+
+	for _, st := range a.elements(nsAssertion, "AttributeStatement") {
+		for _, at := range st.elements(nsAssertion, "Attribute") {
+			name := at.attr("Name")
+			for _, v := range at.elements(nsAssertion, "AttributeValue") {
+				asr.Attributes[name] = append(asr.Attributes[name], v.text())
+			}
+		}
+	}
+
+	return asr, nil
+}
+
+// checkWindow checks the validity period of the conditions, which must be
+// bounded, and returns its end.
+func checkWindow(cond *element, now time.Time) (time.Time, error) {
+	if cond.hasAttr("NotBefore") {
+		notBefore, err := parseTime(cond.attr("NotBefore"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: NotBefore: %s", ErrInvalidResponse, err)
+		}
+		if now.Add(defaultMaxSkew).Before(notBefore) {
+			return time.Time{}, fmt.Errorf("%w: assertion is not valid yet", ErrInvalidResponse)
+		}
+	}
+
+	notOnOrAfter, err := parseTime(cond.attr("NotOnOrAfter"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: NotOnOrAfter: %s", ErrInvalidResponse, err)
+	}
+	if !now.Before(notOnOrAfter.Add(defaultMaxSkew)) {
+		return time.Time{}, fmt.Errorf("%w: assertion expired", ErrInvalidResponse)
+	}
+
+	return notOnOrAfter, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("missing time")
+	}
+
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// =============================================================================
+
+// Metadata returns the metadata document of the service provider, for the
+// identity provider to register it.
+func Metadata(sp ServiceProvider) ([]byte, error) {
+	type acs struct {
+		Binding   string `xml:"Binding,attr"`
+		Location  string `xml:"Location,attr"`
+		Index     int    `xml:"index,attr"`
+		IsDefault bool   `xml:"isDefault,attr"`
+	}
+
+	type spsso struct {
+		AuthnRequestsSigned        bool   `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned       bool   `xml:"WantAssertionsSigned,attr"`
+		ProtocolSupportEnumeration string `xml:"protocolSupportEnumeration,attr"`
+		NameIDFormat               string `xml:"md:NameIDFormat"`
+		AssertionConsumerService   acs    `xml:"md:AssertionConsumerService"`
+	}
+
+	type entity struct {
+		XMLName  xml.Name `xml:"md:EntityDescriptor"`
+		XMLNS    string   `xml:"xmlns:md,attr"`
+		EntityID string   `xml:"entityID,attr"`
+		SPSSO    spsso    `xml:"md:SPSSODescriptor"`
+	}
+
+	doc := entity{
+		XMLNS:    nsMetadata,
+		EntityID: sp.EntityID,
+		SPSSO: spsso{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: nsProtocol,
+			NameIDFormat:               NameIDFormatEmail,
+			AssertionConsumerService: acs{
+				Binding:   bindingPOST,
+				Location:  sp.ACSURL,
+				IsDefault: true,
+			},
+		},
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("saml: marshal metadata: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// AuthnRequestURL returns the URL of the identity provider that starts the
+// login, with the request in the HTTP-Redirect binding. The relay state is
+// sent back by the identity provider with the response.
+func AuthnRequestURL(sp ServiceProvider, idp IdentityProvider, relayState string, now time.Time) (string, error) {
+	type issuer struct {
+		XMLNS string `xml:"xmlns:saml,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	type nameIDPolicy struct {
+		Format      string `xml:"Format,attr"`
+		AllowCreate bool   `xml:"AllowCreate,attr"`
+	}
+
+	type authnRequest struct {
+		XMLName                     xml.Name     `xml:"samlp:AuthnRequest"`
+		XMLNS                       string       `xml:"xmlns:samlp,attr"`
+		ID                          string       `xml:"ID,attr"`
+		Version                     string       `xml:"Version,attr"`
+		IssueInstant                string       `xml:"IssueInstant,attr"`
+		Destination                 string       `xml:"Destination,attr"`
+		ProtocolBinding             string       `xml:"ProtocolBinding,attr"`
+		AssertionConsumerServiceURL string       `xml:"AssertionConsumerServiceURL,attr"`
+		Issuer                      issuer       `xml:"saml:Issuer"`
+		NameIDPolicy                nameIDPolicy `xml:"samlp:NameIDPolicy"`
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("saml: request id: %w", err)
+	}
+
+	req := authnRequest{
+		XMLNS:                       nsProtocol,
+		ID:                          requestIDPrefix + hex.EncodeToString(id),
+		Version:                     "2.0",
+		IssueInstant:                now.UTC().Format(time.RFC3339),
+		Destination:                 idp.SSOURL,
+		ProtocolBinding:             bindingPOST,
+		AssertionConsumerServiceURL: sp.ACSURL,
+		Issuer: issuer{
+			XMLNS: nsAssertion,
+			Value: sp.EntityID,
+		},
+		NameIDPolicy: nameIDPolicy{
+			Format:      NameIDFormatEmail,
+			AllowCreate: true,
+		},
+	}
+
+	data, err := xml.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("saml: marshal request: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("saml: deflate: %w", err)
+	}
+	fw.Write(data)
+	fw.Close()
+
+	u, err := url.Parse(idp.SSOURL)
+	if err != nil {
+		return "", fmt.Errorf("saml: parse sso url: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
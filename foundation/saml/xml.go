@@ -0,0 +1,223 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Set of namespaces of the documents handled by the package.
+const (
+	nsXML       = "http://www.w3.org/XML/1998/namespace"
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsDSig      = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N   = "http://www.w3.org/2001/10/xml-exc-c14n#"
+)
+
+// maxDocumentSize bounds a document given to the parser. Responses of the
+// identity providers have a few kilobytes.
+const maxDocumentSize = 1 << 20
+
+// element is a node of a parsed document. The prefixes are kept as written,
+// the canonicalization of the signatures needs them; the namespaces are
+// resolved on demand through the parents.
+type element struct {
+	prefix   string
+	local    string
+	attrs    []attr
+	children []any // *element or string
+	parent   *element
+}
+
+type attr struct {
+	prefix string
+	local  string
+	value  string
+}
+
+// isNamespace reports whether the attribute declares a namespace.
+func (a attr) isNamespace() bool {
+	return a.prefix == "xmlns" || (a.prefix == "" && a.local == "xmlns")
+}
+
+// lookupNamespace returns the namespace bound to the prefix in the scope of
+// the element, the default namespace for the empty prefix.
+func (e *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+
+	for n := e; n != nil; n = n.parent {
+		for _, a := range n.attrs {
+			if prefix == "" && a.prefix == "" && a.local == "xmlns" {
+				return a.value, true
+			}
+			if prefix != "" && a.prefix == "xmlns" && a.local == prefix {
+				return a.value, true
+			}
+		}
+	}
+
+	return "", prefix == ""
+}
+
+// space returns the namespace of the element.
+func (e *element) space() string {
+	ns, _ := e.lookupNamespace(e.prefix)
+	return ns
+}
+
+// is reports whether the element has the namespace and the local name.
+func (e *element) is(space string, local string) bool {
+	return e.local == local && e.space() == space
+}
+
+// attr returns the value of the attribute without a prefix.
+func (e *element) attr(local string) string {
+	for _, a := range e.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+
+	return ""
+}
+
+// hasAttr reports whether the element has the attribute without a prefix.
+func (e *element) hasAttr(local string) bool {
+	for _, a := range e.attrs {
+		if a.prefix == "" && a.local == local {
+			return true
+		}
+	}
+
+	return false
+}
+
+// text returns every character data of the element, so text split by a
+// comment is read the same way the canonicalization sees it.
+func (e *element) text() string {
+	var b strings.Builder
+	for _, c := range e.children {
+		if s, ok := c.(string); ok {
+			b.WriteString(s)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// elements returns the child elements with the namespace and local name.
+func (e *element) elements(space string, local string) []*element {
+	var es []*element
+	for _, c := range e.children {
+		if ce, ok := c.(*element); ok && ce.is(space, local) {
+			es = append(es, ce)
+		}
+	}
+
+	return es
+}
+
+// element returns the first child element with the namespace and local name.
+func (e *element) element(space string, local string) *element {
+	for _, c := range e.children {
+		if ce, ok := c.(*element); ok && ce.is(space, local) {
+			return ce
+		}
+	}
+
+	return nil
+}
+
+// walk calls fn for the element and every element below it.
+func (e *element) walk(fn func(*element)) {
+	fn(e)
+	for _, c := range e.children {
+		if ce, ok := c.(*element); ok {
+			ce.walk(fn)
+		}
+	}
+}
+
+// =============================================================================
+
+// parse reads the document and returns the root element. Document type
+// declarations are rejected, and with them the entity expansion attacks;
+// comments are dropped.
+func parse(data []byte) (*element, error) {
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("document of %d bytes exceeds the limit", len(data))
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, cur *element
+
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root != nil && cur == nil {
+				return nil, errors.New("more than one root element")
+			}
+
+			e := element{
+				prefix: t.Name.Space,
+				local:  t.Name.Local,
+				parent: cur,
+			}
+			for _, a := range t.Attr {
+				e.attrs = append(e.attrs, attr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+			}
+
+			if cur == nil {
+				root = &e
+			} else {
+				cur.children = append(cur.children, &e)
+			}
+			cur = &e
+
+		case xml.EndElement:
+			if cur == nil || cur.prefix != t.Name.Space || cur.local != t.Name.Local {
+				return nil, fmt.Errorf("unexpected end element %s", t.Name.Local)
+			}
+			cur = cur.parent
+
+		case xml.CharData:
+			if cur != nil {
+				cur.children = append(cur.children, string(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, errors.New("text outside the root element")
+			}
+
+		case xml.Directive:
+			return nil, errors.New("document type declarations are not allowed")
+
+		case xml.ProcInst:
+			if cur != nil {
+				return nil, errors.New("processing instructions are not allowed")
+			}
+
+		case xml.Comment:
+		}
+	}
+
+	if root == nil || cur != nil {
+		return nil, errors.New("incomplete document")
+	}
+
+	return root, nil
+}
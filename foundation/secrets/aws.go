@@ -0,0 +1,187 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AWSConfig represents the settings to reach AWS Secrets Manager.
+type AWSConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint replaces the regional endpoint, e.g. for LocalStack.
+	Endpoint string
+}
+
+// AWS reads secrets from AWS Secrets Manager. The requests are signed with
+// Signature Version 4 so the service needs no AWS SDK.
+type AWS struct {
+	cfg    AWSConfig
+	client *http.Client
+}
+
+// NewAWS constructs a provider for the Secrets Manager of cfg.Region.
+func NewAWS(cfg AWSConfig) *AWS {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", cfg.Region)
+	}
+
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+
+	return &AWS{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type awsResponse struct {
+	SecretString string `json:"SecretString"`
+	Type         string `json:"__type"`
+	Message      string `json:"Message"`
+}
+
+// Secret implements the Provider interface. The name is the name or the ARN
+// of the secret. A field is read from a secret stored as a JSON object.
+func (a *AWS) Secret(ctx context.Context, ref string) (string, error) {
+	name, field := splitRef(ref)
+
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", fmt.Errorf("marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	if err := a.sign(req, body, time.Now().UTC()); err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var ar awsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ar); err != nil {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if strings.HasSuffix(ar.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("aws: %s: %w", name, ErrNotFound)
+		}
+		return "", fmt.Errorf("aws: %s: unexpected status %d: %s: %s", name, resp.StatusCode, ar.Type, ar.Message)
+	}
+
+	if field == "" {
+		return ar.SecretString, nil
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal([]byte(ar.SecretString), &values); err != nil {
+		return "", fmt.Errorf("aws: %s: secret is not a JSON object: %w", name, err)
+	}
+
+	value, err := pick(values, field)
+	if err != nil {
+		return "", fmt.Errorf("aws: %s: %w", name, err)
+	}
+
+	return value, nil
+}
+
+// sign adds the Signature Version 4 headers to the request.
+func (a *AWS) sign(req *http.Request, body []byte, now time.Time) error {
+	const service = "secretsmanager"
+
+	u, err := url.Parse(a.cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := hashHex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.cfg.SessionToken)
+	}
+
+	// Os cabeçalhos assinados precisam estar em ordem alfabética.
+	headers := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if a.cfg.SessionToken != "" {
+		headers = []string{"content-type", "host", "x-amz-date", "x-amz-security-token", "x-amz-target"}
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = u.Host
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", h, strings.TrimSpace(value))
+	}
+
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, a.cfg.Region, service)
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, a.cfg.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.cfg.AccessKeyID, scope, signedHeaders, signature))
+
+	return nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,149 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The secret key is the example of the AWS documentation. The signatures
+// were computed apart from this package, with a reference implementation of
+// Signature Version 4 checked against the get-vanilla case of the AWS test
+// suite.
+const awsSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+
+func TestAWSSign(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cfg      AWSConfig
+		wantAuth string
+	}{
+		{
+			name:     "regional",
+			cfg:      AWSConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: awsSecretKey},
+			wantAuth: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20261016/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=676ad7e7b02acabd808c8d7026a2bd0b7a69d0581258f0148c2974fd38f86158",
+		},
+		{
+			name:     "sessionToken",
+			cfg:      AWSConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: awsSecretKey, SessionToken: "FwoGZXIvYXdzEXAMPLE"},
+			wantAuth: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20261016/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=6c7e0d083747a9937fafb0b4f5b1bbc23ecc2e6b96f27c3377d0484f17a00f68",
+		},
+		{
+			name:     "endpoint",
+			cfg:      AWSConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: awsSecretKey, Endpoint: "http://localhost:4566/"},
+			wantAuth: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20261016/us-east-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=f45d1e0f3e4dff65e50b4585f202919289c5f13b60b0cf10f57b182105e2bac8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAWS(tt.cfg)
+
+			body := []byte(`{"SecretId":"spi/db"}`)
+
+			req, err := http.NewRequest(http.MethodPost, a.cfg.Endpoint+"/", bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("request: %s", err)
+			}
+			req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+			req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+			if err := a.sign(req, body, now); err != nil {
+				t.Fatalf("sign: %s", err)
+			}
+
+			if got := req.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("got Authorization\n%s\nwant\n%s", got, tt.wantAuth)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20261016T090000Z" {
+				t.Errorf("got X-Amz-Date %q, want %q", got, "20261016T090000Z")
+			}
+			if got := req.Header.Get("X-Amz-Security-Token"); got != tt.cfg.SessionToken {
+				t.Errorf("got X-Amz-Security-Token %q, want %q", got, tt.cfg.SessionToken)
+			}
+		})
+	}
+}
+
+func TestAWSSecret(t *testing.T) {
+	const (
+		plain  = `{"ARN":"arn:aws:secretsmanager:us-east-1:123456789012:secret:spi/db-a1B2c3","Name":"spi/db","VersionId":"EXAMPLE1-90ab-cdef-fedc-ba987SECRET1","SecretString":"s3cr3t","VersionStages":["AWSCURRENT"],"CreatedDate":1.523477145713E9}`
+		object = `{"ARN":"arn:aws:secretsmanager:us-east-1:123456789012:secret:spi/db-a1B2c3","Name":"spi/db","VersionId":"EXAMPLE1-90ab-cdef-fedc-ba987SECRET1","SecretString":"{\"username\":\"spi\",\"password\":\"p@ss\",\"port\":5432}","VersionStages":["AWSCURRENT"],"CreatedDate":1.523477145713E9}`
+	)
+
+	tests := []struct {
+		name         string
+		ref          string
+		status       int
+		reply        string
+		want         string
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{name: "plain", ref: "spi/db", status: http.StatusOK, reply: plain, want: "s3cr3t"},
+		{name: "field", ref: "spi/db#password", status: http.StatusOK, reply: object, want: "p@ss"},
+		{name: "numberField", ref: "spi/db#port", status: http.StatusOK, reply: object, want: "5432"},
+		{name: "missingField", ref: "spi/db#token", status: http.StatusOK, reply: object, wantErr: true, wantNotFound: true},
+		{name: "fieldOfPlain", ref: "spi/db#password", status: http.StatusOK, reply: plain, wantErr: true},
+		{
+			name:         "notFound",
+			ref:          "spi/db",
+			status:       http.StatusBadRequest,
+			reply:        `{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`,
+			wantErr:      true,
+			wantNotFound: true,
+		},
+		{
+			name:    "accessDenied",
+			ref:     "spi/db",
+			status:  http.StatusBadRequest,
+			reply:   `{"__type":"AccessDeniedException","Message":"User: arn:aws:iam::123456789012:user/spi is not authorized to perform: secretsmanager:GetSecretValue"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				switch {
+				case r.Method != http.MethodPost || r.URL.Path != "/":
+					t.Errorf("got %s %s, want POST /", r.Method, r.URL.Path)
+				case r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue":
+					t.Errorf("got X-Amz-Target %q", r.Header.Get("X-Amz-Target"))
+				case string(body) != `{"SecretId":"spi/db"}`:
+					t.Errorf("got body %s", body)
+				case !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"):
+					t.Errorf("got Authorization %q", r.Header.Get("Authorization"))
+				}
+
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.reply)
+			}))
+			defer srv.Close()
+
+			a := NewAWS(AWSConfig{Region: "us-east-1", AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: awsSecretKey, Endpoint: srv.URL})
+
+			got, err := a.Secret(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+				t.Errorf("got error %v, want not found %t", err, tt.wantNotFound)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Package secrets reads secrets from a secret manager. The Provider interface
+// hides the manager in use so the same references work with Vault or AWS.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned when the secret or its field does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider reads secrets. A reference is the name of the secret, optionally
+// followed by #field to pick a field of a secret holding key/value pairs,
+// e.g. "spi/db#password".
+type Provider interface {
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// splitRef separates the name of the secret from the field.
+func splitRef(ref string) (string, string) {
+	name, field, _ := strings.Cut(ref, "#")
+	return name, field
+}
+
+// pick returns the field of a secret made of key/value pairs. Without a field
+// the secret must hold a single pair.
+func pick(values map[string]any, field string) (string, error) {
+	if field == "" {
+		if len(values) != 1 {
+			return "", fmt.Errorf("secret has %d fields: a #field is required", len(values))
+		}
+
+		for k := range values {
+			field = k
+		}
+	}
+
+	v, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("field %q: %w", field, ErrNotFound)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("field %q: %w", field, err)
+		}
+		return string(data), nil
+	}
+}
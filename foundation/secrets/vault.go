@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultConfig represents the settings to reach a Vault server.
+type VaultConfig struct {
+	Addr      string
+	Token     string
+	Namespace string
+
+	// Mount is where the KV version 2 engine is mounted. Defaults to
+	// "secret".
+	Mount string
+}
+
+// Vault reads secrets from the KV version 2 engine of HashiCorp Vault with
+// token authentication.
+type Vault struct {
+	cfg    VaultConfig
+	client *http.Client
+}
+
+// NewVault constructs a provider for the Vault server at cfg.Addr, e.g.
+// "https://vault:8200".
+func NewVault(cfg VaultConfig) *Vault {
+	cfg.Addr = strings.TrimSuffix(cfg.Addr, "/")
+
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+
+	return &Vault{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Secret implements the Provider interface. The name is the path of the
+// secret inside the mount.
+func (v *Vault) Secret(ctx context.Context, ref string) (string, error) {
+	name, field := splitRef(ref)
+
+	var segments []string
+	for s := range strings.SplitSeq(strings.Trim(name, "/"), "/") {
+		segments = append(segments, url.PathEscape(s))
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", v.cfg.Addr, url.PathEscape(v.cfg.Mount), strings.Join(segments, "/"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", v.cfg.Token)
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var vr vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vr); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("vault: %s: %w", name, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault: %s: unexpected status %d: %s", name, resp.StatusCode, strings.Join(vr.Errors, "; "))
+	}
+
+	value, err := pick(vr.Data.Data, field)
+	if err != nil {
+		return "", fmt.Errorf("vault: %s: %w", name, err)
+	}
+
+	return value, nil
+}
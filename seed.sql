@@ -17,7 +17,8 @@ BEGIN;
 INSERT INTO "public"."role" ("role_id", "name") VALUES
                                                     (1, 'ADMIN'),
                                                     (2, 'USER'),
-                                                    (3, 'ANALYST')
+                                                    (3, 'ANALYST'),
+                                                    (4, 'TENANT_ADMIN')
 ON CONFLICT ("role_id") DO UPDATE SET "name" = EXCLUDED."name";
 
 -- Resource Types
@@ -29,6 +30,17 @@ INSERT INTO "public"."resource_type" ("resource_type_id", "name") VALUES
                                                                       (3, 'SUBJECT')
 ON CONFLICT ("resource_type_id") DO UPDATE SET "name" = EXCLUDED."name";
 
+-- Role Policies
+-- ADMIN possui todas as ações; ANALYST pode consultar e atualizar; USER depende de ACL explícita.
+INSERT INTO "public"."role_policy" ("role_id", "resource_type_id", "actions") VALUES
+                                                                               (1, 1, '{CREATE,DELETE,UPDATE,GET}'),
+                                                                               (1, 2, '{CREATE,DELETE,UPDATE,GET}'),
+                                                                               (1, 3, '{CREATE,DELETE,UPDATE,GET}'),
+                                                                               (3, 1, '{UPDATE,GET}'),
+                                                                               (3, 2, '{UPDATE,GET}'),
+                                                                               (3, 3, '{UPDATE,GET}')
+ON CONFLICT ("role_id", "resource_type_id") DO UPDATE SET "actions" = EXCLUDED."actions";
+
 
 -- 2. CRIAÇÃO DOS TENANTS E DASHBOARDS (Lógica Procedural)
 DO $$